@@ -0,0 +1,126 @@
+package bus
+
+import (
+	"strconv"
+	"testing"
+)
+
+// benchTokens returns b/l1/…/l<depth-1>, depth tokens long.
+func benchTokens(depth int) []Token {
+	toks := make([]Token, depth)
+	toks[0] = "b"
+	for i := 1; i < depth; i++ {
+		toks[i] = "l" + strconv.Itoa(i)
+	}
+	return toks
+}
+
+func benchTopic(depth int) Topic { return T(benchTokens(depth)...) }
+
+// benchPattern returns the subscription matching benchTopic(depth) for a
+// kind: "exact", "plus" (last token a +) or "hash" (b/#).
+func benchPattern(kind string, depth int) Topic {
+	switch kind {
+	case "plus":
+		toks := benchTokens(depth)
+		toks[depth-1] = "+"
+		return T(toks...)
+	case "hash":
+		return T("b", "#")
+	default:
+		return benchTopic(depth)
+	}
+}
+
+// benchSub subscribes pat and drains it, so publishes measure delivery
+// rather than the drop-oldest path. The returned func stops the drain.
+func benchSub(c *Connection, pat Topic) func() {
+	s := c.Subscribe(pat)
+	done := make(chan struct{})
+	go func() {
+		for range s.Channel() {
+		}
+		close(done)
+	}()
+	return func() { s.Unsubscribe(); <-done }
+}
+
+func BenchmarkPublish(b *testing.B) {
+	for _, kind := range []string{"exact", "plus", "hash"} {
+		for _, depth := range []int{2, 4, 8} {
+			b.Run(kind+"/depth"+strconv.Itoa(depth), func(b *testing.B) {
+				bus := NewBus(64, "+", "#")
+				c := bus.NewConnection("bench")
+				stop := benchSub(c, benchPattern(kind, depth))
+				defer stop()
+				tp := benchTopic(depth)
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					c.Publish(c.NewMessage(tp, i, false))
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkPublishNoSubscribers(b *testing.B) {
+	bus := NewBus(64, "+", "#")
+	c := bus.NewConnection("bench")
+	tp := benchTopic(4)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Publish(c.NewMessage(tp, i, false))
+	}
+}
+
+func BenchmarkPublishRetained(b *testing.B) {
+	bus := NewBus(64, "+", "#")
+	c := bus.NewConnection("bench")
+	tp := benchTopic(4)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Publish(c.NewMessage(tp, i, true))
+	}
+}
+
+func BenchmarkSubscribeUnsubscribe(b *testing.B) {
+	bus := NewBus(4, "+", "#")
+	c := bus.NewConnection("bench")
+	pat := benchPattern("plus", 4)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Unsubscribe(c.Subscribe(pat))
+	}
+}
+
+func BenchmarkInternExisting(b *testing.B) {
+	benchTopic(8)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		T("b", "l1", "l2", "l3", "l4", "l5", "l6", "l7")
+	}
+}
+
+// TestPublishAllocBudget is the regression gate for the benchmarks above:
+// routing a publish to a matching subscriber, for every pattern kind and
+// depth, must cost no more than the message itself.
+func TestPublishAllocBudget(t *testing.T) {
+	const budget = 1
+	for _, kind := range []string{"exact", "plus", "hash"} {
+		for _, depth := range []int{2, 4, 8} {
+			bus := NewBus(4, "+", "#")
+			c := bus.NewConnection("budget")
+			s := c.Subscribe(benchPattern(kind, depth))
+			tp := benchTopic(depth)
+			n := testing.AllocsPerRun(200, func() {
+				c.Publish(c.NewMessage(tp, 1, false))
+				<-s.Channel()
+			})
+			s.Unsubscribe()
+			if n > budget {
+				t.Errorf("%s depth %d: %.1f allocs/publish, budget %d", kind, depth, n, budget)
+			}
+		}
+	}
+}
@@ -6,10 +6,55 @@ import (
 	"errors"
 	"sync"
 	"sync/atomic"
+
+	"devicecode-go/x/clock"
 )
 
 var defaultQLen = 3
 
+// -----------------------------------------------------------------------------
+// Limits
+// -----------------------------------------------------------------------------
+
+// Limits bound what a misbehaving peer (e.g. a host over the bridge) can make
+// the bus allocate. Zero means unlimited, the default.
+type Limits struct {
+	MaxDepth       int // tokens per topic; deeper topics are not interned, subscribed or published
+	MaxTopics      int // distinct interned topics; later topics still work but are not shared
+	MaxSubsPerConn int // live subscriptions per connection
+}
+
+var (
+	ErrTopicTooDeep = errors.New("bus: topic too deep")
+	ErrTooManySubs  = errors.New("bus: too many subscriptions")
+)
+
+var limits struct {
+	maxDepth, maxSubs atomic.Int32
+}
+
+// SetLimits applies l process-wide (the interner is shared by every bus).
+// Existing topics and subscriptions are kept.
+func SetLimits(l Limits) {
+	limits.maxDepth.Store(int32(l.MaxDepth))
+	limits.maxSubs.Store(int32(l.MaxSubsPerConn))
+	interner.mu.Lock()
+	interner.maxTopics = l.MaxTopics
+	interner.mu.Unlock()
+}
+
+// Interned reports how many distinct topics the interner holds.
+func Interned() int {
+	interner.mu.Lock()
+	defer interner.mu.Unlock()
+	return interner.count
+}
+
+func tooDeep(n int) bool {
+	d := int(limits.maxDepth.Load())
+	return d > 0 && n > d
+}
+
 // -----------------------------------------------------------------------------
 // Tokens + Topics
 // -----------------------------------------------------------------------------
@@ -27,6 +72,9 @@ type Topic interface {
 
 	Len() int
 	At(i int) Token
+
+	// String is the canonical slash form; see ParsePath.
+	String() string
 }
 
 func (t topic) Len() int       { return len(t) }
@@ -42,24 +90,30 @@ type internNode struct {
 var interner struct {
 	mu   sync.Mutex
 	root *internNode
-	// (optional) soft cap; set >0 to stop growing after N distinct topics
+	// soft cap (Limits.MaxTopics); >0 stops growing after N distinct topics
 	maxTopics int
 	count     int
 }
 
-func init() {
-	interner.root = &internNode{children: make(map[Token]*internNode)}
-	interner.maxTopics = 0 // 0 = unlimited; you can tune if you want a cap
-}
-
 // Seals `topic` as implementing `Topic`.
 func (t topic) isBusTopic() {}
 
 func internTopic(tokens ...Token) topic {
-	n := interner.root
+	if tooDeep(len(tokens)) {
+		// Never grow the trie for these; the bus refuses them anyway.
+		cp := make(topic, len(tokens))
+		copy(cp, tokens)
+		return cp
+	}
 	// single critical section keeps it simple and TinyGo-friendly
 	interner.mu.Lock()
 	defer interner.mu.Unlock()
+	if interner.root == nil {
+		// Created lazily: package-level topics (e.g. TopicDebug) are
+		// interned before init functions run.
+		interner.root = &internNode{children: make(map[Token]*internNode)}
+	}
+	n := interner.root
 
 	for _, t := range tokens {
 		if n.children == nil {
@@ -152,6 +206,13 @@ type Message struct {
 	Payload  any
 	Retained bool
 	ReplyTo  Topic
+
+	// Optional publisher metadata, carried untouched (including in the
+	// retained store). Zero means unset.
+	Seq  uint32 // per-source sequence number; gaps indicate loss
+	Mono int64  // publisher monotonic time, ns since the publisher started
+
+	owner *Value // non-nil for recycled messages (see Value)
 }
 
 func (m *Message) CanReply() bool { return topicLen(m.ReplyTo) != 0 }
@@ -162,15 +223,47 @@ func (m *Message) CanReply() bool { return topicLen(m.ReplyTo) != 0 }
 
 type Subscription struct {
 	topic topic
+	more  []topic // further filters (SubscribeMany)
 	ch    chan *Message
 	bus   *Bus
 	conn  *Connection
+
+	// mu serialises delivery and close, so drop-oldest is atomic per
+	// subscription and a publisher never sends on a closed channel, even
+	// when publishers run on different cores.
+	mu     sync.Mutex
+	closed bool
 }
 
-func (s *Subscription) Topic() Topic             { return s.topic }
+func (s *Subscription) Topic() Topic             { return s.topic } // the first filter
 func (s *Subscription) Channel() <-chan *Message { return s.ch }
 func (s *Subscription) Unsubscribe()             { s.conn.Unsubscribe(s) }
 
+// filter returns filter i: 0 is Topic, then SubscribeMany's others.
+func (s *Subscription) filter(i int) topic {
+	if i == 0 {
+		return s.topic
+	}
+	return s.more[i-1]
+}
+
+// Match tells which filter m arrived by: the index of the first of the
+// subscription's filters (in SubscribeMany order) that its topic matches,
+// or -1 for a message that matches none, such as a request reply.
+func (s *Subscription) Match(m *Message) int {
+	return s.bus.firstMatch(s, toConcrete(m.Topic))
+}
+
+// close is idempotent and safe against concurrent delivery.
+func (s *Subscription) close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+	s.mu.Unlock()
+}
+
 // Convenience wrapper that replies via the owning connection.
 func (s *Subscription) Reply(to *Message, payload any, retained bool) {
 	s.conn.Reply(to, payload, retained)
@@ -184,6 +277,7 @@ type node struct {
 	children map[Token]*node
 	subs     []*Subscription
 	retained *Message // Message.Topic is opaque; internal traversal uses stored path
+	hist     *history // recent non-retained messages, if Options.History covers the topic
 }
 
 func ensureChild(n *node, t Token) *node {
@@ -204,6 +298,33 @@ type Options struct {
 	QueueLen       int
 	SingleWildcard Token
 	MultiWildcard  Token
+
+	// History keeps the last Depth non-retained messages of every topic
+	// matching Pattern and replays them, oldest first, to new subscribers,
+	// so a late joiner sees the events that led to the current state.
+	// Where patterns overlap the largest Depth applies. A wildcard
+	// subscription may match many histories; its replay is capped at the
+	// queue room its retained messages leave, keeping the newest, so
+	// drop-oldest delivery never discards replay on subscribe.
+	History []HistorySpec
+
+	// Guards refuse publishes whose payload does not suit the topic (see
+	// GuardSpec); with none, Publish checks nothing.
+	Guards []GuardSpec
+
+	// DeadLetter lists patterns whose lost messages are reported on
+	// TopicDeadLetter: a non-retained publish no subscription received,
+	// or a queued message dropped by drop-oldest. Typically control
+	// topics, where a lost command would otherwise go unnoticed.
+	DeadLetter []Topic
+
+	// Clock stamps dead letters; nil means clock.Real.
+	Clock clock.Clock
+}
+
+type HistorySpec struct {
+	Pattern Topic
+	Depth   int
 }
 
 type Bus struct {
@@ -212,18 +333,48 @@ type Bus struct {
 	qLen  int
 	sWild Token
 	mWild Token
+	hist  []histRule
+	hseq  uint64 // publish order of history entries, under mu
+
+	guards []guardRule // fixed at construction
+
+	dead      []topic // Options.DeadLetter
+	deadCount [len(deadReasons)]atomic.Uint32
+	clk       clock.Clock
+
+	dropped  atomic.Uint32 // messages discarded by drop-oldest delivery
+	rejected atomic.Uint32 // publishes refused by Limits.MaxDepth or a guard
 }
 
 func NewBus(queueLen int, singleWild, multiWild Token) *Bus {
-	if queueLen <= 0 || singleWild == nil || multiWild == nil {
+	return NewBusWithOptions(Options{QueueLen: queueLen, SingleWildcard: singleWild, MultiWildcard: multiWild})
+}
+
+func NewBusWithOptions(o Options) *Bus {
+	if o.QueueLen <= 0 || o.SingleWildcard == nil || o.MultiWildcard == nil {
 		panic("bus: Options must fully specify QueueLen>0 and wildcards")
 	}
-	return &Bus{
+	b := &Bus{
 		root:  &node{},
-		qLen:  queueLen,
-		sWild: singleWild,
-		mWild: multiWild,
+		qLen:  o.QueueLen,
+		sWild: o.SingleWildcard,
+		mWild: o.MultiWildcard,
+		clk:   clock.Or(o.Clock),
 	}
+	for _, h := range o.History {
+		if h.Depth > 0 {
+			b.hist = append(b.hist, histRule{pattern: toConcrete(h.Pattern), depth: h.Depth})
+		}
+	}
+	for _, p := range o.DeadLetter {
+		b.dead = append(b.dead, toConcrete(p))
+	}
+	for _, g := range o.Guards {
+		if g.Accept != nil || g.MaxSize > 0 {
+			b.guards = append(b.guards, guardRule{pattern: toConcrete(g.Pattern), accept: g.Accept, maxSize: g.MaxSize})
+		}
+	}
+	return b
 }
 
 func (b *Bus) NewMessage(tp Topic, payload any, retained bool) *Message {
@@ -234,41 +385,103 @@ func (b *Bus) NewMessage(tp Topic, payload any, retained bool) *Message {
 	}
 }
 
-func (b *Bus) addSubscription(tp topic, sub *Subscription) {
+func (b *Bus) addSubscription(sub *Subscription) {
 	b.mu.Lock()
-	n := b.root
-	for _, t := range tp {
-		n = ensureChild(n, t)
-	}
-	n.subs = append(n.subs, sub)
-
 	var retained []*Message
-	b.collectRetainedLocked(b.root, tp, 0, &retained)
+	var hist []histEnt
+	for i := 0; i <= len(sub.more); i++ {
+		tp := sub.filter(i)
+		n := b.root
+		for _, t := range tp {
+			n = ensureChild(n, t)
+		}
+		n.subs = append(n.subs, sub)
+
+		r0, h0 := len(retained), len(hist)
+		b.collectRetainedLocked(b.root, tp, 0, &retained)
+		if len(b.hist) > 0 {
+			b.collectHistoryLocked(b.root, tp, 0, &hist)
+		}
+		if i == 0 {
+			continue
+		}
+		// Replay each message once, for the first filter it matches.
+		keep := r0
+		for _, m := range retained[r0:] {
+			if b.firstMatch(sub, toConcrete(m.Topic)) == i {
+				retained[keep] = m
+				keep++
+			}
+		}
+		retained = retained[:keep]
+		keep = h0
+		for _, he := range hist[h0:] {
+			if b.firstMatch(sub, toConcrete(he.m.Topic)) == i {
+				hist[keep] = he
+				keep++
+			}
+		}
+		hist = hist[:keep]
+	}
+	if len(b.hist) > 0 {
+		hist = newestFirstFit(hist, cap(sub.ch)-len(retained))
+	}
 	b.mu.Unlock()
 
+	for _, he := range hist {
+		b.tryDeliver(sub, he.m)
+	}
 	for _, rm := range retained {
 		b.tryDeliver(sub, rm)
 	}
 }
 
-func (b *Bus) Publish(msg *Message) {
+// Publish delivers msg. A topic deeper than Limits.MaxDepth, or a payload
+// a guard refuses, is dropped and counted in Rejected; use
+// Connection.TryPublish to learn of it.
+func (b *Bus) Publish(msg *Message) { _ = b.publish(msg) }
+
+func (b *Bus) publish(msg *Message) error {
 	msgTopic := toConcrete(msg.Topic)
+	if tooDeep(len(msgTopic)) {
+		b.rejected.Add(1)
+		return ErrTopicTooDeep
+	}
+	if len(b.guards) > 0 {
+		if err := b.guard(msgTopic, msg.Payload); err != nil {
+			b.refuse(msgTopic, DeadPayloadType)
+			return err
+		}
+	}
+	b.deliver(msgTopic, msg)
+	return nil
+}
+
+// deliver retains or records msg and hands it to every matching
+// subscription.
+func (b *Bus) deliver(msgTopic topic, msg *Message) {
+	// A recycled message must not outlive its ring on another bus.
+	if msg.owner != nil && msg.owner.bus != b {
+		msg = msg.detach()
+	}
 
 	b.mu.Lock()
-	// collect into map to dedupe
-	var subs []*Subscription
-	// optional fast-path: reuse slice, then dedupe only if likely duplicates
-	b.collectSubscribersLocked(b.root, msgTopic, 0, &subs)
+	// Collect into a stack buffer; only large fan-outs allocate.
+	var buf [16]*Subscription
+	subs := b.collectSubscribersLocked(b.root, msgTopic, 0, buf[:0])
 
-	// Deduplicate
+	// Deduplicate (a subscription can match via several wildcard paths).
+	// Fan-outs are small, so a quadratic scan beats a map and allocates
+	// nothing.
 	if len(subs) > 1 {
-		seen := make(map[*Subscription]struct{}, len(subs))
 		j := 0
+	outer:
 		for _, s := range subs {
-			if _, ok := seen[s]; ok {
-				continue
+			for _, t := range subs[:j] {
+				if t == s {
+					continue outer
+				}
 			}
-			seen[s] = struct{}{}
 			subs[j] = s
 			j++
 		}
@@ -281,12 +494,17 @@ func (b *Bus) Publish(msg *Message) {
 		} else {
 			b.retainSetLocked(msgTopic, msg)
 		}
+	} else if len(b.hist) > 0 {
+		b.historyAddLocked(msgTopic, msg)
 	}
 	b.mu.Unlock()
 
 	for _, sub := range subs {
 		b.tryDeliver(sub, msg)
 	}
+	if len(subs) == 0 && !msg.Retained && len(b.dead) > 0 {
+		b.lost(msg, DeadNoSubscriber)
+	}
 }
 
 func trySend(ch chan *Message, m *Message) bool {
@@ -298,26 +516,53 @@ func trySend(ch chan *Message, m *Message) bool {
 	}
 }
 
-func drainOne(ch chan *Message) {
+func drainOne(ch chan *Message) *Message {
 	select {
-	case <-ch:
+	case m := <-ch:
+		return m
 	default:
+		return nil
 	}
 }
 
 func (b *Bus) tryDeliver(sub *Subscription, msg *Message) {
-	defer func() { _ = recover() }() // channel may be closed; best-effort
-	if trySend(sub.ch, msg) {
+	// A SubscribeMany queue can outlast a Value's ring.
+	if msg.owner != nil && len(sub.more) > 0 {
+		msg = msg.detach()
+	}
+	sub.mu.Lock()
+	if sub.closed || trySend(sub.ch, msg) {
+		sub.mu.Unlock()
 		return
 	}
-	drainOne(sub.ch)
+	old := drainOne(sub.ch)
+	b.dropped.Add(1)
 	_ = trySend(sub.ch, msg)
+	sub.mu.Unlock()
+	// Reported outside the lock: the record may go to this subscription.
+	if old != nil && len(b.dead) > 0 {
+		b.lost(old, DeadQueueFull)
+	}
 }
 
+// Dropped reports how many queued messages have been discarded to make room
+// for newer ones (drop-oldest) across all subscriptions.
+func (b *Bus) Dropped() uint32 { return b.dropped.Load() }
+
+// Rejected reports how many publishes were refused by Limits.MaxDepth or a
+// guard.
+func (b *Bus) Rejected() uint32 { return b.rejected.Load() }
+
 // -----------------------------------------------------------------------------
 // Unsubscribe + pruning
 // -----------------------------------------------------------------------------
 
+func (b *Bus) removeSubscription(sub *Subscription) {
+	for i := 0; i <= len(sub.more); i++ {
+		b.unsubscribe(sub.filter(i), sub)
+	}
+}
+
 func (b *Bus) unsubscribe(tp topic, sub *Subscription) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -350,7 +595,7 @@ func (b *Bus) pruneEmptyLocked(stack []*node, path []Token) {
 		parent := stack[i]
 		key := path[i]
 		child := parent.children[key]
-		if child != nil && len(child.subs) == 0 && len(child.children) == 0 && child.retained == nil {
+		if child != nil && len(child.subs) == 0 && len(child.children) == 0 && child.retained == nil && child.hist == nil {
 			delete(parent.children, key)
 		} else {
 			break
@@ -362,31 +607,32 @@ func (b *Bus) pruneEmptyLocked(stack []*node, path []Token) {
 // Subscriber collection (topic = concrete message topic)
 // -----------------------------------------------------------------------------
 
-func (b *Bus) collectSubscribersLocked(n *node, tp topic, depth int, out *[]*Subscription) {
+func (b *Bus) collectSubscribersLocked(n *node, tp topic, depth int, out []*Subscription) []*Subscription {
 	if n == nil {
-		return
+		return out
 	}
 	if depth == len(tp) {
-		*out = append(*out, n.subs...)
+		out = append(out, n.subs...)
 		if n.children != nil {
 			if mw := n.children[b.mWild]; mw != nil {
-				*out = append(*out, mw.subs...) // '#' matches zero additional tokens
+				out = append(out, mw.subs...) // '#' matches zero additional tokens
 			}
 		}
-		return
+		return out
 	}
 	tok := tp[depth]
 	if n.children != nil {
 		if child := n.children[tok]; child != nil {
-			b.collectSubscribersLocked(child, tp, depth+1, out)
+			out = b.collectSubscribersLocked(child, tp, depth+1, out)
 		}
 		if sw := n.children[b.sWild]; sw != nil {
-			b.collectSubscribersLocked(sw, tp, depth+1, out)
+			out = b.collectSubscribersLocked(sw, tp, depth+1, out)
 		}
 		if mw := n.children[b.mWild]; mw != nil {
-			*out = append(*out, mw.subs...) // '#' matches any remainder
+			out = append(out, mw.subs...) // '#' matches any remainder
 		}
 	}
+	return out
 }
 
 // -----------------------------------------------------------------------------
@@ -444,6 +690,152 @@ func (b *Bus) collectRetainedLocked(n *node, pattern topic, depth int, out *[]*M
 	}
 }
 
+// -----------------------------------------------------------------------------
+// Bounded history (Options.History)
+// -----------------------------------------------------------------------------
+
+type histRule struct {
+	pattern topic
+	depth   int
+}
+
+// histEnt is a held message and its place in bus-wide publish order.
+type histEnt struct {
+	m   *Message
+	seq uint64
+}
+
+// history is a ring of a topic's most recent non-retained messages.
+type history struct {
+	msgs []histEnt
+	next int
+	n    int
+}
+
+func (h *history) add(m *Message, seq uint64) {
+	h.msgs[h.next] = histEnt{m: m, seq: seq}
+	h.next = (h.next + 1) % len(h.msgs)
+	if h.n < len(h.msgs) {
+		h.n++
+	}
+}
+
+// appendTo appends the held messages oldest first.
+func (h *history) appendTo(out *[]histEnt) {
+	start := h.next - h.n
+	if start < 0 {
+		start += len(h.msgs)
+	}
+	for i := 0; i < h.n; i++ {
+		*out = append(*out, h.msgs[(start+i)%len(h.msgs)])
+	}
+}
+
+// newestFirstFit orders hist by publish order across topics and keeps the
+// newest room entries (none if room <= 0).
+func newestFirstFit(hist []histEnt, room int) []histEnt {
+	if room <= 0 {
+		return nil
+	}
+	// Insertion sort: replay sets are a few entries per topic.
+	for i := 1; i < len(hist); i++ {
+		for j := i; j > 0 && hist[j].seq < hist[j-1].seq; j-- {
+			hist[j], hist[j-1] = hist[j-1], hist[j]
+		}
+	}
+	if len(hist) > room {
+		hist = hist[len(hist)-room:]
+	}
+	return hist
+}
+
+// firstMatch is the index of sub's first filter that tp matches, or -1.
+func (b *Bus) firstMatch(sub *Subscription, tp topic) int {
+	for i := 0; i <= len(sub.more); i++ {
+		if b.matches(sub.filter(i), tp) {
+			return i
+		}
+	}
+	return -1
+}
+
+// matches reports whether concrete topic tp matches pattern p.
+func (b *Bus) matches(p, tp topic) bool {
+	for i, t := range p {
+		switch {
+		case t == b.mWild:
+			return true
+		case i >= len(tp):
+			return false
+		case t != b.sWild && t != tp[i]:
+			return false
+		}
+	}
+	return len(p) == len(tp)
+}
+
+func (b *Bus) historyAddLocked(tp topic, msg *Message) {
+	depth := 0
+	for _, r := range b.hist {
+		if r.depth > depth && b.matches(r.pattern, tp) {
+			depth = r.depth
+		}
+	}
+	if depth == 0 {
+		return
+	}
+	n := b.root
+	for _, t := range tp {
+		n = ensureChild(n, t)
+	}
+	if n.hist == nil {
+		n.hist = &history{msgs: make([]histEnt, depth)}
+	}
+	// Held past any Value ring's reuse window.
+	if msg.owner != nil {
+		msg = msg.detach()
+	}
+	b.hseq++
+	n.hist.add(msg, b.hseq)
+}
+
+func (b *Bus) collectHistoryLocked(n *node, pattern topic, depth int, out *[]histEnt) {
+	if n == nil {
+		return
+	}
+	if depth == len(pattern) {
+		if n.hist != nil {
+			n.hist.appendTo(out)
+		}
+		return
+	}
+	ptok := pattern[depth]
+	switch ptok {
+	case b.mWild:
+		b.collectAllHistoryLocked(n, out)
+	case b.sWild:
+		for _, child := range n.children {
+			b.collectHistoryLocked(child, pattern, depth+1, out)
+		}
+	default:
+		if child := n.children[ptok]; child != nil {
+			b.collectHistoryLocked(child, pattern, depth+1, out)
+		}
+	}
+}
+
+func (b *Bus) collectAllHistoryLocked(n *node, out *[]histEnt) {
+	if n == nil {
+		return
+	}
+	if n.hist != nil {
+		n.hist.appendTo(out)
+	}
+	for _, child := range n.children {
+		b.collectAllHistoryLocked(child, out)
+	}
+}
+
 func (b *Bus) collectAllRetainedLocked(n *node, out *[]*Message) {
 	if n == nil {
 		return
@@ -478,36 +870,119 @@ func (c *Connection) NewMessage(tp Topic, payload any, retained bool) *Message {
 
 func (c *Connection) Publish(msg *Message) { c.bus.Publish(msg) }
 
+// TryPublish is Publish that reports a topic refused by Limits
+// (ErrTopicTooDeep, not counted) or a payload refused by a guard
+// (ErrPayloadType).
+func (c *Connection) TryPublish(msg *Message) error {
+	if tooDeep(topicLen(msg.Topic)) {
+		return ErrTopicTooDeep
+	}
+	return c.bus.publish(msg)
+}
+
+// Subscribe panics if Limits refuse the subscription; in-process callers
+// subscribe to a fixed set of topics, so that is a programming error. Use
+// TrySubscribe for topics that come from outside.
 func (c *Connection) Subscribe(tp Topic) *Subscription {
+	sub, err := c.TrySubscribe(tp)
+	if err != nil {
+		panic(err.Error())
+	}
+	return sub
+}
+
+// TrySubscribe subscribes to tp, or returns ErrTopicTooDeep or
+// ErrTooManySubs without allocating.
+func (c *Connection) TrySubscribe(tp Topic) (*Subscription, error) {
 	ct := toConcrete(tp)
-	sub := &Subscription{topic: ct, ch: make(chan *Message, c.bus.qLen), bus: c.bus, conn: c}
-	c.bus.addSubscription(ct, sub)
+	if tooDeep(len(ct)) {
+		return nil, ErrTopicTooDeep
+	}
+	return c.subscribe(ct, nil)
+}
+
+// SubscribeMany subscribes to several filters through one subscription,
+// so a service waiting on a handful of topics selects on one channel and
+// unsubscribes once. Match tells which filter a message arrived by; a
+// message matching several arrives once. The queue holds QueueLen per
+// filter, so messages from a Value are copied for it. It panics as
+// Subscribe does, and on an empty list.
+func (c *Connection) SubscribeMany(tps []Topic) *Subscription {
+	if len(tps) == 0 {
+		panic("bus: SubscribeMany with no topics")
+	}
+	cts := make([]topic, len(tps))
+	for i, tp := range tps {
+		if cts[i] = toConcrete(tp); tooDeep(len(cts[i])) {
+			panic(ErrTopicTooDeep.Error())
+		}
+	}
+	sub, err := c.subscribe(cts[0], cts[1:])
+	if err != nil {
+		panic(err.Error())
+	}
+	return sub
+}
+
+// subscribe registers a subscription to ct and any more filters.
+func (c *Connection) subscribe(ct topic, more []topic) (*Subscription, error) {
 	c.mu.Lock()
+	if max := int(limits.maxSubs.Load()); max > 0 && len(c.subs) >= max {
+		c.mu.Unlock()
+		return nil, ErrTooManySubs
+	}
+	// Held across addSubscription so the count cannot be overshot; the
+	// bus never takes c.mu, so the lock order is safe.
+	sub := &Subscription{topic: ct, more: more, ch: make(chan *Message, c.bus.qLen*(1+len(more))), bus: c.bus, conn: c}
+	c.bus.addSubscription(sub)
 	c.subs = append(c.subs, sub)
 	c.mu.Unlock()
-	return sub
+	return sub, nil
+}
+
+// Retained returns a snapshot of the retained messages matching tp
+// (wildcards allowed) without subscribing. Order is unspecified.
+func (c *Connection) Retained(tp Topic) []*Message {
+	b := c.bus
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []*Message
+	b.collectRetainedLocked(b.root, toConcrete(tp), 0, &out)
+	// Snapshots may be held indefinitely: copy recycled messages.
+	for i, m := range out {
+		if m.owner != nil {
+			out[i] = m.detach()
+		}
+	}
+	return out
 }
 
 func (c *Connection) Unsubscribe(sub *Subscription) {
-	c.bus.unsubscribe(sub.topic, sub)
+	c.bus.removeSubscription(sub)
 	c.mu.Lock()
 	c.subs = removeSub(c.subs, sub)
 	c.mu.Unlock()
-	close(sub.ch)
+	sub.close()
 }
 
-func (c *Connection) Disconnect() {
+// UnsubscribeAll removes and closes every subscription made through c,
+// typed ones included, so a service can defer one call instead of one per
+// subscription. c stays usable.
+func (c *Connection) UnsubscribeAll() {
 	c.mu.Lock()
 	subs := c.subs
 	c.subs = nil
 	c.mu.Unlock()
 
 	for _, sub := range subs {
-		c.bus.unsubscribe(sub.topic, sub)
-		close(sub.ch)
+		c.bus.removeSubscription(sub)
+		sub.close()
 	}
 }
 
+// Disconnect is UnsubscribeAll, for a connection that is finished with.
+func (c *Connection) Disconnect() { c.UnsubscribeAll() }
+
 func removeSub(list []*Subscription, target *Subscription) []*Subscription {
 	for i, s := range list {
 		if s == target {
@@ -0,0 +1,242 @@
+package bus
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// Property tests: topic matching and retained semantics against a reference
+// -----------------------------------------------------------------------------
+
+const propIters = 300
+
+// refMatch is the reference matcher: '+' matches exactly one token, a
+// trailing '#' matches any remainder including none.
+func refMatch(p, t []Token) bool {
+	for i, pt := range p {
+		if pt == "#" {
+			return true
+		}
+		if i >= len(t) {
+			return false
+		}
+		if pt != "+" && pt != t[i] {
+			return false
+		}
+	}
+	return len(p) == len(t)
+}
+
+var propAlphabet = []Token{"a", "b", "c", 1, 2}
+
+func randTopic(r *rand.Rand) []Token {
+	n := 1 + r.Intn(4)
+	t := make([]Token, n)
+	for i := range t {
+		t[i] = propAlphabet[r.Intn(len(propAlphabet))]
+	}
+	return t
+}
+
+// randPattern draws a topic and sprinkles '+' anywhere and '#' only last.
+func randPattern(r *rand.Rand) []Token {
+	p := randTopic(r)
+	for i := range p {
+		if r.Intn(4) == 0 {
+			p[i] = "+"
+		}
+	}
+	if r.Intn(4) == 0 {
+		p[len(p)-1] = "#"
+	}
+	return p
+}
+
+func key(t []Token) string {
+	var b strings.Builder
+	for _, tok := range t {
+		switch v := tok.(type) {
+		case string:
+			b.WriteString("s:" + v)
+		case int:
+			b.WriteString("i:" + strconv.Itoa(v))
+		}
+		b.WriteByte('/')
+	}
+	return b.String()
+}
+
+func drainIDs(ch <-chan *Message) map[int]bool {
+	got := map[int]bool{}
+	for {
+		select {
+		case m := <-ch:
+			got[m.Payload.(int)] = true
+		default:
+			return got
+		}
+	}
+}
+
+func TestProp_MatchingAgainstReference(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for it := 0; it < propIters; it++ {
+		b := NewBus(64, "+", "#")
+		c := b.NewConnection("prop")
+
+		pats := make([][]Token, 1+r.Intn(5))
+		subs := make([]*Subscription, len(pats))
+		for i := range pats {
+			pats[i] = randPattern(r)
+			subs[i] = c.Subscribe(T(pats[i]...))
+		}
+		topics := make([][]Token, 20)
+		for i := range topics {
+			topics[i] = randTopic(r)
+			c.Publish(c.NewMessage(T(topics[i]...), i, false))
+		}
+		for i, s := range subs {
+			got := drainIDs(s.Channel())
+			for id, tp := range topics {
+				if want := refMatch(pats[i], tp); got[id] != want {
+					t.Fatalf("iter %d: pattern %v topic %v: delivered=%v want %v", it, pats[i], tp, got[id], want)
+				}
+			}
+		}
+	}
+}
+
+func TestProp_RetainedAgainstReference(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for it := 0; it < propIters; it++ {
+		b := NewBus(64, "+", "#")
+		c := b.NewConnection("prop")
+
+		// Reference store: topic key -> (tokens, payload id).
+		type entry struct {
+			t  []Token
+			id int
+		}
+		ref := map[string]entry{}
+		for i := 0; i < 25; i++ {
+			tp := randTopic(r)
+			if r.Intn(5) == 0 {
+				c.Publish(c.NewMessage(T(tp...), nil, true)) // clear
+				delete(ref, key(tp))
+				continue
+			}
+			c.Publish(c.NewMessage(T(tp...), i, true))
+			ref[key(tp)] = entry{t: tp, id: i}
+		}
+
+		pat := randPattern(r)
+		want := map[int]bool{}
+		for _, e := range ref {
+			if refMatch(pat, e.t) {
+				want[e.id] = true
+			}
+		}
+
+		s := c.Subscribe(T(pat...))
+		got := drainIDs(s.Channel())
+		if len(got) != len(want) {
+			t.Fatalf("iter %d: pattern %v: got %d retained, want %d", it, pat, len(got), len(want))
+		}
+		for id := range want {
+			if !got[id] {
+				t.Fatalf("iter %d: pattern %v: missing retained id %d", it, pat, id)
+			}
+		}
+		if n := len(c.Retained(T(pat...))); n != len(want) {
+			t.Fatalf("iter %d: Retained(%v) = %d, want %d", it, pat, n, len(want))
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Fuzzing
+// -----------------------------------------------------------------------------
+
+// tokensOf splits a slash path into tokens; all-digit parts become ints.
+func tokensOf(s string) []Token {
+	parts := strings.Split(s, "/")
+	toks := make([]Token, len(parts))
+	for i, p := range parts {
+		if n, err := strconv.Atoi(p); err == nil && p != "" && p[0] != '+' && p[0] != '-' {
+			toks[i] = n
+		} else {
+			toks[i] = p
+		}
+	}
+	return toks
+}
+
+func FuzzIntern(f *testing.F) {
+	for _, s := range []string{"a", "a/b", "hal/cap/env/temperature/core/value", "1/2/3", "", "a//b", "x/0/y"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		toks := tokensOf(s)
+		t1 := toConcrete(T(toks...))
+		t2 := toConcrete(T(toks...))
+		if t1.Len() != len(toks) {
+			t.Fatalf("Len = %d, want %d", t1.Len(), len(toks))
+		}
+		for i := range toks {
+			if t1.At(i) != toks[i] {
+				t.Fatalf("At(%d) = %v, want %v", i, t1.At(i), toks[i])
+			}
+		}
+		if len(t1) > 0 && &t1[0] != &t2[0] {
+			t.Fatal("interning the same tokens twice returned different slices")
+		}
+		// Append must produce the same canonical topic as T on the whole path.
+		if len(toks) > 1 {
+			a := toConcrete(T(toks[:1]...).Append(toks[1:]...))
+			if &a[0] != &t1[0] {
+				t.Fatal("Append did not return the canonical interned topic")
+			}
+		}
+		// Caller storage must not alias the interned slice.
+		if len(toks) > 0 {
+			toks[0] = "mutated"
+			if t1.At(0) == "mutated" {
+				t.Fatal("interned topic aliases caller storage")
+			}
+		}
+	})
+}
+
+func FuzzMatch(f *testing.F) {
+	seeds := [][2]string{
+		{"a/+/c", "a/b/c"}, {"a/#", "a"}, {"#", "x/y"}, {"+", "a/b"}, {"a/b", "a/b"}, {"1/+", "1/2"},
+	}
+	for _, s := range seeds {
+		f.Add(s[0], s[1])
+	}
+	f.Fuzz(func(t *testing.T, pat, top string) {
+		p, tp := tokensOf(pat), tokensOf(top)
+		// Published topics carry no wildcards; '#' is only meaningful last.
+		for _, tok := range tp {
+			if tok == "+" || tok == "#" {
+				t.Skip()
+			}
+		}
+		for i, tok := range p {
+			if tok == "#" && i != len(p)-1 {
+				t.Skip()
+			}
+		}
+		b := NewBus(2, "+", "#")
+		c := b.NewConnection("fuzz")
+		s := c.Subscribe(T(p...))
+		c.Publish(c.NewMessage(T(tp...), 0, false))
+		got := len(drainIDs(s.Channel())) == 1
+		if want := refMatch(p, tp); got != want {
+			t.Fatalf("pattern %q topic %q: delivered=%v want %v", pat, top, got, want)
+		}
+	})
+}
@@ -3,9 +3,13 @@ package bus
 
 import (
 	"context"
+	"fmt"
 	"sort"
+	"sync"
 	"testing"
 	"time"
+
+	"devicecode-go/x/clock"
 )
 
 const (
@@ -152,6 +156,47 @@ func TestWildcard_RetainedClear(t *testing.T) {
 	}
 }
 
+func TestRetainedSnapshot(t *testing.T) {
+	b := NewBus(1, "+", "#")
+	c := b.NewConnection("test")
+
+	c.Publish(b.NewMessage(T("cap", "a", "info"), 1, true))
+	c.Publish(b.NewMessage(T("cap", "b", "info"), 2, true))
+	c.Publish(b.NewMessage(T("cap", "c", "info"), 3, true))
+	c.Publish(b.NewMessage(T("cap", "c", "value"), 4, true))
+
+	// A queue of one would drop retained deliveries; the snapshot does not.
+	got := c.Retained(T("cap", "+", "info"))
+	if len(got) != 3 {
+		t.Fatalf("Retained(cap/+/info) = %d messages, want 3", len(got))
+	}
+	if n := len(c.Retained(T("cap", "#"))); n != 4 {
+		t.Fatalf("Retained(cap/#) = %d messages, want 4", n)
+	}
+	if n := len(c.Retained(T("none"))); n != 0 {
+		t.Fatalf("Retained(none) = %d messages, want 0", n)
+	}
+}
+
+func TestMessageMetadata_Retained(t *testing.T) {
+	b := NewBus(4, "+", "#")
+	c := b.NewConnection("test")
+
+	m := b.NewMessage(T("cap", "a", "value"), 1, true)
+	m.Seq, m.Mono = 7, 12345
+	c.Publish(m)
+
+	s := c.Subscribe(T("cap", "a", "value"))
+	select {
+	case got := <-s.Channel():
+		if got.Seq != 7 || got.Mono != 12345 {
+			t.Fatalf("retained metadata = (%d, %d), want (7, 12345)", got.Seq, got.Mono)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("no retained delivery")
+	}
+}
+
 func TestWildcard_NoMatchCases(t *testing.T) {
 	b := NewBus(8, "+", "#")
 	c := b.NewConnection("test")
@@ -254,6 +299,89 @@ func TestRequestReply_ManualSubscription(t *testing.T) {
 	<-done
 }
 
+func TestDropOldest_CountsDrops(t *testing.T) {
+	b := NewBus(2, "+", "#")
+	c := b.NewConnection("test")
+	sub := c.Subscribe(T("x"))
+	defer c.Unsubscribe(sub)
+
+	for i := 0; i < 5; i++ {
+		c.Publish(b.NewMessage(T("x"), i, false))
+	}
+	if n := b.Dropped(); n != 3 {
+		t.Fatalf("Dropped() = %d, want 3", n)
+	}
+	if got := (<-sub.Channel()).Payload; got != 3 {
+		t.Fatalf("oldest kept = %v, want 3", got)
+	}
+}
+
+func TestConcurrentPublishSubscribe(t *testing.T) {
+	const pubs, per = 4, 500
+	b := NewBus(8, "+", "#")
+	c := b.NewConnection("test")
+	sub := c.Subscribe(T("c", "+"))
+
+	type seq struct{ pub, n int }
+	last := [pubs]int{-1, -1, -1, -1}
+	got := 0
+	done := make(chan error, 1)
+	go func() {
+		for m := range sub.Channel() {
+			s := m.Payload.(seq)
+			if s.n <= last[s.pub] {
+				done <- fmt.Errorf("publisher %d: %d after %d", s.pub, s.n, last[s.pub])
+				for range sub.Channel() {
+				}
+				return
+			}
+			last[s.pub] = s.n
+			got++
+		}
+		done <- nil
+	}()
+
+	stop := make(chan struct{})
+	var churn sync.WaitGroup
+	churn.Add(1)
+	go func() {
+		defer churn.Done()
+		cc := b.NewConnection("churn")
+		defer cc.Disconnect()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			s := cc.Subscribe(T("c", "#"))
+			s.Unsubscribe()
+			s.Unsubscribe() // idempotent
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for p := 0; p < pubs; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < per; i++ {
+				c.Publish(b.NewMessage(T("c", p), seq{p, i}, false))
+			}
+		}(p)
+	}
+	wg.Wait()
+	close(stop)
+	churn.Wait()
+	c.Unsubscribe(sub)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if got > pubs*per || got+int(b.Dropped()) < pubs*per {
+		t.Fatalf("delivered %d + dropped %d, want %d", got, b.Dropped(), pubs*per)
+	}
+}
+
 // -----------------------------------------------------------------------------
 // helpers
 // -----------------------------------------------------------------------------
@@ -347,3 +475,443 @@ func TestTopic_InvalidTokenPanics(t *testing.T) {
 	// []byte is not comparable, so T should panic
 	_ = T([]byte{1, 2, 3})
 }
+
+// -----------------------------------------------------------------------------
+// Typed subscriptions
+// -----------------------------------------------------------------------------
+
+type testReading struct{ V int }
+
+func TestSubscribeT_FiltersByType(t *testing.T) {
+	b := NewBus(4, "+", "#")
+	c := b.NewConnection("test")
+
+	s := SubscribeT[testReading](c, T("sensor", "+"))
+	defer s.Unsubscribe()
+
+	c.Publish(b.NewMessage(T("sensor", "a"), "not a reading", false))
+	c.Publish(b.NewMessage(T("sensor", "a"), testReading{V: 1}, false))
+	c.Publish(b.NewMessage(T("sensor", "b"), &testReading{V: 2}, false))
+
+	var got []int
+	for len(got) < 2 {
+		select {
+		case m := <-s.Channel():
+			if v, ok := s.Value(m); ok {
+				got = append(got, v.V)
+			}
+		case <-time.After(200 * time.Millisecond):
+			t.Fatalf("timeout; got %v", got)
+		}
+	}
+	if got[0] != 1 || got[1] != 2 {
+		t.Fatalf("got %v, want [1 2]", got)
+	}
+	if n := s.Mismatches(); n != 1 {
+		t.Fatalf("Mismatches() = %d, want 1", n)
+	}
+}
+
+func TestSubscribeT_RetainedAndClose(t *testing.T) {
+	b := NewBus(4, "+", "#")
+	c := b.NewConnection("test")
+
+	c.Publish(b.NewMessage(T("state"), testReading{V: 7}, true))
+	s := SubscribeT[testReading](c, T("state"))
+
+	select {
+	case m := <-s.Channel():
+		if got, ok := s.Value(m); !ok || got.V != 7 {
+			t.Fatal("retained value not delivered as testReading{7}")
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("timeout waiting for retained value")
+	}
+
+	if _, ok := s.Value(&Message{Topic: T("state")}); ok {
+		t.Fatal("nil payload (retained clear) reported a value")
+	}
+	if n := s.Mismatches(); n != 0 {
+		t.Fatalf("Mismatches() = %d after a clear, want 0", n)
+	}
+
+	s.Unsubscribe()
+	select {
+	case _, ok := <-s.Channel():
+		if ok {
+			t.Fatal("expected closed channel after Unsubscribe")
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("channel not closed after Unsubscribe")
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Limits
+// -----------------------------------------------------------------------------
+
+func TestLimits_DepthAndSubs(t *testing.T) {
+	SetLimits(Limits{MaxDepth: 3, MaxSubsPerConn: 2})
+	defer SetLimits(Limits{})
+
+	b := NewBus(4, "+", "#")
+	c := b.NewConnection("peer")
+
+	deep := T("a", "b", "c", "d")
+	if _, err := c.TrySubscribe(deep); err != ErrTopicTooDeep {
+		t.Fatalf("deep subscribe: err = %v, want ErrTopicTooDeep", err)
+	}
+	if err := c.TryPublish(c.NewMessage(deep, 1, true)); err != ErrTopicTooDeep {
+		t.Fatalf("deep publish: err = %v, want ErrTopicTooDeep", err)
+	}
+	c.Publish(c.NewMessage(deep, 1, true))
+	if n := b.Rejected(); n != 1 {
+		t.Fatalf("Rejected() = %d, want 1", n)
+	}
+	if got := c.Retained(T("#")); len(got) != 0 {
+		t.Fatalf("deep publish retained %d messages", len(got))
+	}
+
+	s1, err := c.TrySubscribe(T("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.TrySubscribe(T("y")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.TrySubscribe(T("z")); err != ErrTooManySubs {
+		t.Fatalf("third subscribe: err = %v, want ErrTooManySubs", err)
+	}
+	// Another connection has its own allowance.
+	if _, err := b.NewConnection("other").TrySubscribe(T("z")); err != nil {
+		t.Fatal(err)
+	}
+	s1.Unsubscribe()
+	if _, err := c.TrySubscribe(T("z")); err != nil {
+		t.Fatalf("after unsubscribe: %v", err)
+	}
+}
+
+func TestLimits_InternerCap(t *testing.T) {
+	SetLimits(Limits{MaxTopics: Interned()})
+	defer SetLimits(Limits{})
+
+	n := Interned()
+	a := T("limits", "uninterned")
+	if Interned() != n {
+		t.Fatalf("interner grew past its cap: %d -> %d", n, Interned())
+	}
+	// Beyond the cap topics still route.
+	b := NewBus(4, "+", "#")
+	c := b.NewConnection("test")
+	s := c.Subscribe(T("limits", "+"))
+	c.Publish(c.NewMessage(a, "v", false))
+	select {
+	case got := <-s.Channel():
+		if got.Payload != "v" {
+			t.Fatalf("got %v", got.Payload)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("timeout waiting for uninterned topic")
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Bounded history
+// -----------------------------------------------------------------------------
+
+func TestHistory_LateJoinerReplay(t *testing.T) {
+	b := NewBusWithOptions(Options{
+		QueueLen:       8,
+		SingleWildcard: "+",
+		MultiWildcard:  "#",
+		History:        []HistorySpec{{Pattern: T("chg", "+", "event"), Depth: 3}},
+	})
+	c := b.NewConnection("test")
+
+	for i := 0; i < 5; i++ {
+		c.Publish(c.NewMessage(T("chg", "a", "event"), i, false))
+	}
+	c.Publish(c.NewMessage(T("chg", "b", "event"), "b0", false))
+	c.Publish(c.NewMessage(T("chg", "a", "state"), "s", false)) // not covered
+
+	want := func(sub *Subscription, exp ...any) {
+		t.Helper()
+		for _, e := range exp {
+			select {
+			case m := <-sub.Channel():
+				if m.Payload != e {
+					t.Fatalf("got %v, want %v", m.Payload, e)
+				}
+			case <-time.After(100 * time.Millisecond):
+				t.Fatalf("timeout waiting for %v", e)
+			}
+		}
+		select {
+		case m := <-sub.Channel():
+			t.Fatalf("unexpected %v", m.Payload)
+		default:
+		}
+	}
+
+	// Oldest first, bounded to the last three.
+	want(c.Subscribe(T("chg", "a", "event")), 2, 3, 4)
+	want(c.Subscribe(T("chg", "a", "state")))
+	want(c.Subscribe(T("chg", "b", "#")), "b0")
+
+	if d := b.DebugSnapshot(); d.History != 4 {
+		t.Fatalf("history = %d, want 4", d.History)
+	}
+}
+
+func TestGuards_RefuseWrongTypeToDeadLetter(t *testing.T) {
+	type setpoint struct{ DeciC int }
+	b := NewBusWithOptions(Options{
+		QueueLen:       4,
+		SingleWildcard: "+",
+		MultiWildcard:  "#",
+		Guards: []GuardSpec{
+			{Pattern: T("cfg", "+", "set"), Accept: Expect[setpoint]()},
+			{Pattern: T("cfg", "#"), MaxSize: 64},
+		},
+	})
+	c := b.NewConnection("test")
+	dl := c.Subscribe(TopicDeadLetter)
+	sub := c.Subscribe(T("cfg", "a", "set"))
+
+	if err := c.TryPublish(c.NewMessage(T("cfg", "a", "set"), setpoint{200}, false)); err != nil {
+		t.Fatal(err)
+	}
+	c.Publish(c.NewMessage(T("cfg", "a", "set"), &setpoint{210}, false))
+	// The untyped map an old publisher would send.
+	err := c.TryPublish(c.NewMessage(T("cfg", "a", "set"), map[string]any{"deci_c": 220}, true))
+	if err != ErrPayloadType {
+		t.Fatalf("err = %v", err)
+	}
+	for _, want := range []any{setpoint{200}, &setpoint{210}} {
+		if m := <-sub.Channel(); fmt.Sprint(m.Payload) != fmt.Sprint(want) {
+			t.Fatalf("delivered %v, want %v", m.Payload, want)
+		}
+	}
+	select {
+	case m := <-sub.Channel():
+		t.Fatalf("refused payload delivered: %v", m.Payload)
+	default:
+	}
+	if len(c.Retained(T("cfg", "a", "set"))) != 0 {
+		t.Fatal("refused payload retained")
+	}
+	if m := <-dl.Channel(); fmt.Sprint(m.Payload.(DeadLetter).Topic) != "[cfg a set]" || m.Payload.(DeadLetter).Reason != DeadPayloadType {
+		t.Fatalf("dead letter %+v", m.Payload)
+	}
+
+	// Clearing a retained value and unguarded topics pass.
+	if err := c.TryPublish(c.NewMessage(T("cfg", "a", "set"), nil, true)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.TryPublish(c.NewMessage(T("cfg", "a", "get"), "x", false)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sizes are checked on request, against the tightest rule.
+	if c.CheckSize(T("cfg", "a", "set"), 64) != nil || c.CheckSize(T("other"), 1<<20) != nil {
+		t.Fatal("size within bounds refused")
+	}
+	if c.CheckSize(T("cfg", "a", "set"), 65) != ErrPayloadTooLarge {
+		t.Fatal("oversize accepted")
+	}
+	if m := <-dl.Channel(); m.Payload.(DeadLetter).Reason != DeadTooLarge {
+		t.Fatalf("dead letter %+v", m.Payload)
+	}
+	if b.Rejected() != 2 {
+		t.Fatalf("rejected = %d", b.Rejected())
+	}
+}
+
+func TestDeadLetter_DroppedAndUnroutedControls(t *testing.T) {
+	clk := clock.NewFake(time.Unix(100, 0))
+	b := NewBusWithOptions(Options{
+		QueueLen:       2,
+		SingleWildcard: "+",
+		MultiWildcard:  "#",
+		DeadLetter:     []Topic{T("dev", "+", "control", "+")},
+		Clock:          clk,
+	})
+	c := b.NewConnection("test")
+	dl := c.Subscribe(TopicDeadLetter)
+	next := func() DeadLetter {
+		t.Helper()
+		select {
+		case m := <-dl.Channel():
+			return m.Payload.(DeadLetter)
+		case <-time.After(100 * time.Millisecond):
+			t.Fatal("no dead letter")
+			return DeadLetter{}
+		}
+	}
+
+	// Nobody listening: the command is lost; telemetry is not watched,
+	// and a retained value is not lost.
+	c.Publish(c.NewMessage(T("dev", "fan", "control", "set"), 1, false))
+	c.Publish(c.NewMessage(T("dev", "fan", "value"), 1, false))
+	c.Publish(c.NewMessage(T("dev", "fan", "control", "mode"), "auto", true))
+	if d := next(); fmt.Sprint(d.Topic) != "[dev fan control set]" || d.Reason != DeadNoSubscriber || d.Count != 1 || d.TS != 100e9 {
+		t.Fatalf("dead letter %+v", d)
+	}
+
+	// A slow consumer loses the oldest queued command.
+	sub := c.Subscribe(T("dev", "pump", "control", "+"))
+	for i := 0; i < 3; i++ {
+		c.Publish(c.NewMessage(T("dev", "pump", "control", "set"), i, false))
+	}
+	if d := next(); d.Reason != DeadQueueFull || d.Count != 1 || fmt.Sprint(d.Topic) != "[dev pump control set]" {
+		t.Fatalf("dead letter %+v", d)
+	}
+	if m := <-sub.Channel(); m.Payload != 1 {
+		t.Fatalf("kept %v", m.Payload)
+	}
+	select {
+	case m := <-dl.Channel():
+		t.Fatalf("unexpected %+v", m.Payload)
+	default:
+	}
+
+	// Overflowing the dead letter queue itself reports nothing more; the
+	// jump in Count shows records were lost.
+	for i := 0; i < 5; i++ {
+		c.Publish(c.NewMessage(T("dev", "x", "control", "set"), i, false))
+	}
+	if d := next(); d.Count != 5 {
+		t.Fatalf("dead letter %+v", d)
+	}
+	if d := next(); d.Count != 6 {
+		t.Fatalf("dead letter %+v", d)
+	}
+}
+
+func TestHistory_WildcardReplayFitsQueue(t *testing.T) {
+	b := NewBusWithOptions(Options{
+		QueueLen:       3,
+		SingleWildcard: "+",
+		MultiWildcard:  "#",
+		History:        []HistorySpec{{Pattern: T("chg", "+", "event", "+"), Depth: 3}},
+	})
+	c := b.NewConnection("test")
+
+	// Three tags, three events each, interleaved: 9 held in all.
+	for i := 0; i < 3; i++ {
+		for _, tag := range []string{"x", "y", "z"} {
+			c.Publish(c.NewMessage(T("chg", "a", "event", tag), fmt.Sprint(tag, i), false))
+		}
+	}
+
+	drain := func(sub *Subscription) []any {
+		var got []any
+		for {
+			select {
+			case m := <-sub.Channel():
+				got = append(got, m.Payload)
+			case <-time.After(50 * time.Millisecond):
+				return got
+			}
+		}
+	}
+
+	// The newest three across all tags, in publish order, and nothing lost
+	// to drop-oldest.
+	before := b.Dropped()
+	got := drain(c.Subscribe(T("chg", "+", "event", "+")))
+	if fmt.Sprint(got) != "[x2 y2 z2]" {
+		t.Fatalf("replay = %v, want [x2 y2 z2]", got)
+	}
+	if n := b.Dropped() - before; n != 0 {
+		t.Fatalf("replay dropped %d messages", n)
+	}
+
+	// Retained messages take their room first.
+	c.Publish(c.NewMessage(T("chg", "a", "event", "state"), "st", true))
+	got = drain(c.Subscribe(T("chg", "+", "event", "#")))
+	if fmt.Sprint(got) != "[y2 z2 st]" {
+		t.Fatalf("replay with retained = %v, want [y2 z2 st]", got)
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Introspection
+// -----------------------------------------------------------------------------
+
+func TestDebugSnapshot_CountsAndPrunes(t *testing.T) {
+	b := NewBus(4, "+", "#")
+	c := b.NewConnection("test")
+
+	c.Publish(c.NewMessage(T("hal", "state"), "ready", true))
+	s1 := c.Subscribe(T("hal", "cap", "+", "value"))
+	s2 := c.Subscribe(T("hal", "#"))
+	s3 := c.Subscribe(T("sys", "metrics"))
+
+	d := b.DebugSnapshot()
+	if d.Subs != 3 || d.Retained != 1 || c.NumSubs() != 3 {
+		t.Fatalf("snapshot = %+v, conn subs %d", d, c.NumSubs())
+	}
+	if len(d.Subtrees) != 2 || d.Subtrees[0].Token != "hal" || d.Subtrees[0].Subs != 2 {
+		t.Fatalf("subtrees = %+v", d.Subtrees)
+	}
+
+	s1.Unsubscribe()
+	s2.Unsubscribe()
+	s3.Unsubscribe()
+	c.Publish(c.NewMessage(T("hal", "state"), nil, true))
+	if d := b.DebugSnapshot(); d.Subs != 0 || d.Retained != 0 || d.Nodes != 0 || len(d.Subtrees) != 0 {
+		t.Fatalf("after cleanup: %+v", d)
+	}
+}
+
+func TestSubscribeMany_OneQueueAndMatch(t *testing.T) {
+	b := NewBus(2, "+", "#")
+	c := b.NewConnection("test")
+	c.Publish(c.NewMessage(T("hal", "cap", "env", "value"), "retained", true))
+
+	// The retained value matches both filters but is replayed once.
+	sub := c.SubscribeMany([]Topic{T("hal", "cap", "+", "value"), T("hal", "#"), T("sys", "time")})
+	if cap(sub.Channel()) != 6 || sub.Topic().String() != "hal/cap/+/value" {
+		t.Fatalf("queue %d, topic %v", cap(sub.Channel()), sub.Topic())
+	}
+	c.Publish(c.NewMessage(T("sys", "time"), "tick", false))
+	c.Publish(c.NewMessage(T("hal", "state"), "ready", false))
+	c.Publish(c.NewMessage(T("hal", "cap", "env", "value"), "live", false))
+
+	var got []string
+	for len(sub.Channel()) > 0 {
+		m := <-sub.Channel()
+		got = append(got, fmt.Sprintf("%v@%d", m.Payload, sub.Match(m)))
+	}
+	if want := "[retained@0 tick@2 ready@1 live@0]"; fmt.Sprint(got) != want {
+		t.Fatalf("got %v, want %s", got, want)
+	}
+	// Its queue outlasts a Value's ring: recycled messages arrive as copies.
+	c.NewValue(T("sys", "time"), false).Publish("v", 0, 0)
+	if m := <-sub.Channel(); m.Payload != "v" || m.owner != nil {
+		t.Fatalf("value message = %+v", m)
+	}
+	if d := b.DebugSnapshot(); d.Subs != 3 || c.NumSubs() != 1 {
+		t.Fatalf("snapshot = %+v, conn subs %d", d, c.NumSubs())
+	}
+
+	typed := SubscribeT[string](c, T("sys", "time"))
+	c.UnsubscribeAll()
+	if _, ok := <-sub.Channel(); ok {
+		t.Fatal("multi subscription not closed")
+	}
+	if _, ok := <-typed.Channel(); ok {
+		t.Fatal("typed subscription not closed")
+	}
+	if d := b.DebugSnapshot(); d.Subs != 0 || c.NumSubs() != 0 {
+		t.Fatalf("after UnsubscribeAll: %+v", d)
+	}
+	// The connection carries on.
+	again := c.Subscribe(T("sys", "time"))
+	c.Publish(c.NewMessage(T("sys", "time"), "tock", false))
+	if m := <-again.Channel(); m.Payload != "tock" {
+		t.Fatalf("after UnsubscribeAll: %v", m.Payload)
+	}
+}
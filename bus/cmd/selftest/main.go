@@ -0,0 +1,417 @@
+// selftest exercises the bus on target and reports over the console (USB
+// on the Pico). Phases:
+//
+//   - functional: exact, wildcard and retained delivery sanity checks
+//   - stress:     thousands of publishes across a deep wildcard tree
+//   - latency:    publish→deliver latency with percentile reporting
+//   - bench:      publishes/s and allocations per publish for exact, + and
+//     # subscriptions at several topic depths, gated on an allocation budget
+//   - concurrent: parallel publishers and subscribe churn (both cores when
+//     built with -scheduler=cores)
+//   - soak:       long-running churn checking for heap growth
+//
+// It builds for the host as well, which is useful for comparing numbers.
+package main
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"devicecode-go/bus"
+)
+
+const (
+	stressDepth   = 5    // levels below the root token
+	stressFanout  = 4    // children per level (4^5 = 1024 leaves)
+	stressPubs    = 5000 // publishes in the stress phase
+	latencySample = 1000 // publish→deliver samples
+	soakDuration  = 10 * time.Minute
+	soakReport    = 30 * time.Second
+	soakMaxGrowth = 8 << 10 // bytes of HeapInuse growth tolerated over the soak
+	concPubs      = 4       // concurrent publisher goroutines
+	concPerPub    = 2000    // publishes per publisher
+	benchPubs     = 2000    // publishes per bench case
+	benchMaxAlloc = 2       // allocations per delivered publish tolerated
+)
+
+var failures int
+
+func check(ok bool, what string) {
+	if ok {
+		println("  ok  ", what)
+		return
+	}
+	failures++
+	println("  FAIL", what)
+}
+
+func main() {
+	time.Sleep(3 * time.Second) // let USB enumerate
+	println("[selftest] bus self-test")
+
+	functional()
+	stress()
+	latency()
+	bench()
+	concurrent()
+	soak()
+
+	if failures == 0 {
+		println("[selftest] PASS")
+	} else {
+		println("[selftest] FAIL:", failures, "check(s)")
+	}
+	select {}
+}
+
+// ---- functional ----
+
+func recvWithin(ch <-chan *bus.Message, d time.Duration) *bus.Message {
+	select {
+	case m := <-ch:
+		return m
+	case <-time.After(d):
+		return nil
+	}
+}
+
+func functional() {
+	println("[selftest] functional")
+	b := bus.NewBus(4, "+", "#")
+	c := b.NewConnection("ft")
+
+	s := c.Subscribe(bus.T("a", "b"))
+	c.Publish(c.NewMessage(bus.T("a", "b"), 1, false))
+	m := recvWithin(s.Channel(), 100*time.Millisecond)
+	check(m != nil && m.Payload == 1, "exact delivery")
+	s.Unsubscribe()
+
+	s = c.Subscribe(bus.T("a", "+", "c"))
+	c.Publish(c.NewMessage(bus.T("a", "x", "c"), 2, false))
+	m = recvWithin(s.Channel(), 100*time.Millisecond)
+	check(m != nil && m.Payload == 2, "single-level wildcard")
+	s.Unsubscribe()
+
+	s = c.Subscribe(bus.T("a", "#"))
+	c.Publish(c.NewMessage(bus.T("a", "x", "y", "z"), 3, false))
+	m = recvWithin(s.Channel(), 100*time.Millisecond)
+	check(m != nil && m.Payload == 3, "multi-level wildcard")
+	s.Unsubscribe()
+
+	c.Publish(c.NewMessage(bus.T("r", "1"), 4, true))
+	s = c.Subscribe(bus.T("r", "+"))
+	m = recvWithin(s.Channel(), 100*time.Millisecond)
+	check(m != nil && m.Payload == 4, "retained on subscribe")
+	s.Unsubscribe()
+}
+
+// ---- stress ----
+
+// leaf returns the i-th topic of the stress tree: s/<d0>/<d1>/…
+func leaf(i int) bus.Topic {
+	toks := make([]bus.Token, 0, stressDepth+1)
+	toks = append(toks, "s")
+	for l := 0; l < stressDepth; l++ {
+		toks = append(toks, i%stressFanout)
+		i /= stressFanout
+	}
+	return bus.T(toks...)
+}
+
+func stress() {
+	println("[selftest] stress")
+	b := bus.NewBus(8, "+", "#")
+	c := b.NewConnection("stress")
+
+	// A spread of patterns: all, one subtree, one level of each, and exact.
+	pats := []bus.Topic{
+		bus.T("s", "#"),
+		bus.T("s", 0, "#"),
+		bus.T("s", "+", "+", "+", "+", 1),
+		bus.T("s", "+", 2, "#"),
+		leaf(7),
+	}
+	subs := make([]*bus.Subscription, len(pats))
+	counts := make([]int, len(pats))
+	done := make(chan struct{})
+	for i, p := range pats {
+		subs[i] = c.Subscribe(p)
+		go func(i int, ch <-chan *bus.Message) {
+			for range ch {
+				counts[i]++
+			}
+			done <- struct{}{}
+		}(i, subs[i].Channel())
+	}
+
+	leaves := 1
+	for l := 0; l < stressDepth; l++ {
+		leaves *= stressFanout
+	}
+	topics := make([]bus.Topic, leaves)
+	for i := range topics {
+		topics[i] = leaf(i)
+	}
+
+	t0 := time.Now()
+	for i := 0; i < stressPubs; i++ {
+		c.Publish(c.NewMessage(topics[i%leaves], i, false))
+		if i%64 == 0 {
+			runtime.Gosched() // let consumers drain
+		}
+	}
+	el := time.Since(t0)
+	for _, s := range subs {
+		s.Unsubscribe()
+	}
+	for range subs {
+		<-done
+	}
+
+	println("  publishes:", stressPubs, "in", int(el/time.Microsecond), "us",
+		"(", int(el/time.Nanosecond)/stressPubs, "ns/op )")
+	for i := range pats {
+		println("  pattern", i, "delivered:", counts[i])
+	}
+	println("  dropped (drop-oldest):", int(b.Dropped()))
+	check(counts[0]+int(b.Dropped()) >= stressPubs, "s/# saw every publish (delivered or dropped)")
+}
+
+// ---- concurrent ----
+
+// concurrent publishes from several goroutines while another churns
+// subscriptions on the same subtree. With -scheduler=cores the goroutines
+// are spread over both RP2040 cores. Each publisher's sequence must arrive
+// strictly increasing (gaps are drop-oldest), and every publish must be
+// accounted for as delivered or dropped.
+func concurrent() {
+	println("[selftest] concurrent (cpus:", runtime.NumCPU(), ")")
+	b := bus.NewBus(16, "+", "#")
+	c := b.NewConnection("conc")
+	sub := c.Subscribe(bus.T("c", "+"))
+
+	type seq struct{ pub, n int }
+	got := 0
+	ordered := true
+	last := make([]int, concPubs)
+	for i := range last {
+		last[i] = -1
+	}
+	done := make(chan struct{})
+	go func() {
+		for m := range sub.Channel() {
+			s := m.Payload.(seq)
+			if s.n <= last[s.pub] {
+				ordered = false
+			}
+			last[s.pub] = s.n
+			got++
+		}
+		close(done)
+	}()
+
+	stop := make(chan struct{})
+	churned := make(chan int)
+	go func() {
+		n := 0
+		cc := b.NewConnection("churn")
+		for {
+			select {
+			case <-stop:
+				cc.Disconnect()
+				churned <- n
+				return
+			default:
+			}
+			s := cc.Subscribe(bus.T("c", "#"))
+			runtime.Gosched()
+			cc.Unsubscribe(s)
+			n++
+		}
+	}()
+
+	var wg sync.WaitGroup
+	t0 := time.Now()
+	for p := 0; p < concPubs; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			pc := b.NewConnection("pub")
+			tp := bus.T("c", p)
+			for i := 0; i < concPerPub; i++ {
+				pc.Publish(pc.NewMessage(tp, seq{p, i}, false))
+				if i%32 == 0 {
+					runtime.Gosched()
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+	el := time.Since(t0)
+	close(stop)
+	n := <-churned
+	c.Unsubscribe(sub)
+	<-done
+
+	total := concPubs * concPerPub
+	println("  publishes:", total, "in", int(el/time.Microsecond), "us;",
+		"delivered:", got, "dropped:", int(b.Dropped()), "churn cycles:", n)
+	check(ordered, "per-publisher order preserved")
+	check(got <= total && got+int(b.Dropped()) >= total, "every publish delivered or dropped")
+}
+
+// ---- latency ----
+
+func latency() {
+	println("[selftest] latency")
+	b := bus.NewBus(4, "+", "#")
+	c := b.NewConnection("lat")
+	s := c.Subscribe(bus.T("lat", "+"))
+	defer s.Unsubscribe()
+
+	samples := make([]int64, 0, latencySample)
+	for i := 0; i < latencySample; i++ {
+		t0 := time.Now().UnixNano()
+		c.Publish(c.NewMessage(bus.T("lat", "x"), t0, false))
+		m := recvWithin(s.Channel(), 100*time.Millisecond)
+		if m == nil {
+			continue
+		}
+		samples = append(samples, time.Now().UnixNano()-m.Payload.(int64))
+	}
+	check(len(samples) == latencySample, "all latency samples delivered")
+	if len(samples) == 0 {
+		return
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	pct := func(p int) int { return int(samples[(len(samples)-1)*p/100] / 1000) }
+	println("  us p50:", pct(50), "p90:", pct(90), "p99:", pct(99), "max:", pct(100))
+}
+
+// ---- bench ----
+
+// benchTokens returns b/1/2/…, depth tokens long.
+func benchTokens(depth int) []bus.Token {
+	toks := make([]bus.Token, depth)
+	toks[0] = "b"
+	for i := 1; i < depth; i++ {
+		toks[i] = i
+	}
+	return toks
+}
+
+func mallocs() uint64 {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.Mallocs
+}
+
+// bench publishes to a topic depth tokens long with one subscriber whose
+// pattern is exact, ends in +, or is b/#, draining in line so the numbers
+// cover routing and delivery rather than the scheduler.
+func bench() {
+	println("[selftest] bench")
+	for _, kind := range []string{"exact", "+", "#"} {
+		for _, depth := range []int{2, 4, 8} {
+			b := bus.NewBus(4, "+", "#")
+			c := b.NewConnection("bench")
+			toks := benchTokens(depth)
+			tp, pat := bus.T(toks...), bus.T(toks...)
+			switch kind {
+			case "+":
+				pat = bus.T(append(toks[:depth-1:depth-1], "+")...)
+			case "#":
+				pat = bus.T("b", "#")
+			}
+			s := c.Subscribe(pat)
+
+			runtime.GC()
+			m0 := mallocs()
+			t0 := time.Now()
+			got := 0
+			for i := 0; i < benchPubs; i++ {
+				c.Publish(c.NewMessage(tp, i, false))
+				select {
+				case <-s.Channel():
+					got++
+				default:
+				}
+			}
+			el := time.Since(t0)
+			allocs := mallocs() - m0
+			s.Unsubscribe()
+
+			rate := 0
+			if el > 0 {
+				rate = int(int64(benchPubs) * int64(time.Second) / int64(el))
+			}
+			per10 := int(allocs * 10 / benchPubs) // tenths, println has no floats
+			println("  ", kind, "depth", depth, "pubs/s:", rate, "allocs/pub x10:", per10)
+			check(got == benchPubs, kind+" bench delivered every publish")
+			check(allocs <= benchMaxAlloc*benchPubs, kind+" bench within allocation budget")
+		}
+	}
+}
+
+// ---- soak ----
+
+func heapInuse() uint64 {
+	var ms runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&ms)
+	return ms.HeapInuse
+}
+
+func soak() {
+	println("[selftest] soak for", int(soakDuration/time.Second), "s")
+	b := bus.NewBus(4, "+", "#")
+	c := b.NewConnection("soak")
+
+	// Warm up so every interned topic and trie node exists before the baseline.
+	for i := 0; i < 64; i++ {
+		churn(c, i)
+	}
+	base := heapInuse()
+	println("  baseline heapInuse:", int(base))
+
+	start := time.Now()
+	next := start.Add(soakReport)
+	var peak uint64
+	for i := 1; time.Since(start) < soakDuration; i++ {
+		churn(c, i)
+		if time.Now().After(next) {
+			h := heapInuse()
+			if h > peak {
+				peak = h
+			}
+			println("  t=", int(time.Since(start)/time.Second), "s heapInuse:", int(h), "Δ:", int(int64(h)-int64(base)))
+			next = next.Add(soakReport)
+		}
+	}
+	end := heapInuse()
+	growth := int64(end) - int64(base)
+	println("  end heapInuse:", int(end), "peak:", int(peak), "growth:", int(growth))
+	check(growth <= soakMaxGrowth, "no sustained heap growth")
+}
+
+// churn subscribes, publishes (retained and not), and unsubscribes on a
+// bounded topic set so steady-state memory should be flat.
+func churn(c *bus.Connection, i int) {
+	k := i % 16
+	s := c.Subscribe(bus.T("soak", k, "+"))
+	c.Publish(c.NewMessage(bus.T("soak", k, "v"), i, true))
+	c.Publish(c.NewMessage(bus.T("soak", k, "e"), i, false))
+	for drained := false; !drained; {
+		select {
+		case <-s.Channel():
+		default:
+			drained = true
+		}
+	}
+	s.Unsubscribe()
+	if i%32 == 0 {
+		c.Publish(c.NewMessage(bus.T("soak", k, "v"), nil, true)) // clear retained
+	}
+}
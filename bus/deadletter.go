@@ -0,0 +1,78 @@
+package bus
+
+// -----------------------------------------------------------------------------
+// Dead letters
+// -----------------------------------------------------------------------------
+
+// TopicDeadLetter is where the bus reports lost and refused messages, one
+// DeadLetter each (not retained). Guards and Options.DeadLetter do not
+// apply to it, and a dead letter that is itself lost is not reported.
+var TopicDeadLetter = T("bus", "deadletter")
+
+// Dead letter reasons.
+const (
+	DeadQueueFull    = "queue_full"    // dropped by drop-oldest delivery
+	DeadNoSubscriber = "no_subscriber" // non-retained publish nobody received
+	DeadPayloadType  = "payload_type"  // refused by GuardSpec.Accept
+	DeadTooLarge     = "payload_too_large"
+)
+
+var deadReasons = [...]string{DeadQueueFull, DeadNoSubscriber, DeadPayloadType, DeadTooLarge}
+
+// DeadLetter records one lost or refused message. Count is the bus-wide
+// total for Reason so far, so a subscriber seeing it jump knows records
+// were lost too.
+type DeadLetter struct {
+	Topic  []Token `json:"topic"`
+	Reason string  `json:"reason"`
+	Count  uint32  `json:"count"`
+	TS     int64   `json:"ts_ns"`
+}
+
+// deadWatched reports whether lost messages on tp are to be reported
+// (Options.DeadLetter).
+func (b *Bus) deadWatched(tp topic) bool {
+	for _, p := range b.dead {
+		if b.matches(p, tp) {
+			return true
+		}
+	}
+	return false
+}
+
+// deadLetter publishes a record for tp. Callers must not hold b.mu or any
+// subscription lock.
+func (b *Bus) deadLetter(tp topic, reason string) {
+	var n uint32
+	for i, r := range deadReasons {
+		if r == reason {
+			n = b.deadCount[i].Add(1)
+		}
+	}
+	b.deliver(toConcrete(TopicDeadLetter), &Message{
+		Topic:   TopicDeadLetter,
+		Payload: DeadLetter{Topic: tp, Reason: reason, Count: n, TS: b.clk.Now().UnixNano()},
+	})
+}
+
+// lost reports m, dropped or unrouted, if its topic is watched.
+func (b *Bus) lost(m *Message, reason string) {
+	tp := toConcrete(m.Topic)
+	if len(b.dead) == 0 || b.isDeadLetter(tp) || !b.deadWatched(tp) {
+		return
+	}
+	b.deadLetter(tp, reason)
+}
+
+func (b *Bus) isDeadLetter(tp topic) bool {
+	dl := toConcrete(TopicDeadLetter)
+	if len(tp) != len(dl) {
+		return false
+	}
+	for i := range tp {
+		if tp[i] != dl[i] {
+			return false
+		}
+	}
+	return true
+}
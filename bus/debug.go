@@ -0,0 +1,101 @@
+package bus
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// TopicDebug is the retained topic RunDebug publishes on.
+var TopicDebug = T("sys", "bus", "debug")
+
+// DebugSnapshot is a point-in-time view of a bus, for tracking down
+// subscription leaks on long-running devices.
+type DebugSnapshot struct {
+	Subs     int           `json:"subs"`     // live subscriptions
+	Retained int           `json:"retained"` // retained messages
+	History  int           `json:"history"`  // messages held for replay (Options.History)
+	Nodes    int           `json:"nodes"`    // trie nodes, root excluded
+	Interned int           `json:"interned"` // distinct interned topics (process-wide)
+	Dropped  uint32        `json:"dropped"`
+	Rejected uint32        `json:"rejected"`
+	Subtrees []SubtreeStat `json:"subtrees"` // per first token, most subscriptions first
+}
+
+// SubtreeStat counts what lives under one first-level token, including
+// subscriptions made with a wildcard in that position ("+", "#").
+type SubtreeStat struct {
+	Token    Token `json:"token"`
+	Subs     int   `json:"subs"`
+	Retained int   `json:"retained"`
+	History  int   `json:"history"`
+	Nodes    int   `json:"nodes"`
+}
+
+// DebugSnapshot walks the trie under the bus lock. It allocates and is
+// linear in the trie size, so poll it at human rates.
+func (b *Bus) DebugSnapshot() DebugSnapshot {
+	b.mu.Lock()
+	s := DebugSnapshot{Subs: len(b.root.subs)}
+	for tok, child := range b.root.children {
+		st := SubtreeStat{Token: tok}
+		countLocked(child, &st)
+		s.Subs += st.Subs
+		s.Retained += st.Retained
+		s.History += st.History
+		s.Nodes += st.Nodes
+		s.Subtrees = append(s.Subtrees, st)
+	}
+	b.mu.Unlock()
+
+	s.Interned = Interned()
+	s.Dropped = b.Dropped()
+	s.Rejected = b.Rejected()
+	sort.Slice(s.Subtrees, func(i, j int) bool {
+		a, c := &s.Subtrees[i], &s.Subtrees[j]
+		if a.Subs != c.Subs {
+			return a.Subs > c.Subs
+		}
+		return a.Nodes > c.Nodes
+	})
+	return s
+}
+
+func countLocked(n *node, st *SubtreeStat) {
+	st.Nodes++
+	st.Subs += len(n.subs)
+	if n.retained != nil {
+		st.Retained++
+	}
+	if n.hist != nil {
+		st.History += n.hist.n
+	}
+	for _, child := range n.children {
+		countLocked(child, st)
+	}
+}
+
+// NumSubs reports the connection's live subscriptions.
+func (c *Connection) NumSubs() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.subs)
+}
+
+// RunDebug publishes b's DebugSnapshot on sys/bus/debug (retained) every
+// interval until ctx is cancelled. It is opt-in: nothing runs it by default.
+func RunDebug(ctx context.Context, conn *Connection, every time.Duration) {
+	if every <= 0 {
+		every = time.Minute
+	}
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			conn.Publish(conn.NewMessage(TopicDebug, conn.bus.DebugSnapshot(), true))
+		}
+	}
+}
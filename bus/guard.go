@@ -0,0 +1,92 @@
+package bus
+
+import "errors"
+
+// -----------------------------------------------------------------------------
+// Payload guards (Options.Guards)
+// -----------------------------------------------------------------------------
+
+var (
+	ErrPayloadType     = errors.New("bus: payload type refused")
+	ErrPayloadTooLarge = errors.New("bus: payload too large")
+)
+
+// GuardSpec constrains what may be published on topics matching Pattern.
+// A publish a guard refuses is neither delivered nor retained; it is
+// counted in Rejected and reported on TopicDeadLetter.
+type GuardSpec struct {
+	Pattern Topic
+
+	// Accept reports whether a payload may go on the topic; nil accepts
+	// any. Expect[T] builds the usual type check. A nil payload (clearing
+	// a retained value) is never refused.
+	Accept func(payload any) bool
+
+	// MaxSize bounds the encoded payload, in bytes, that a peer may put
+	// on the topic; 0 is unbounded. The bus carries values and cannot
+	// measure them, so publishers holding an encoding (the bridge) check
+	// it with Connection.CheckSize before decoding.
+	MaxSize int
+}
+
+// Expect returns a GuardSpec.Accept admitting payloads of type T, as a
+// value or a non-nil *T, the same payloads SubscriptionT[T] accepts.
+func Expect[T any]() func(payload any) bool {
+	return func(p any) bool {
+		_, ok := assertT[T](p)
+		return ok
+	}
+}
+
+type guardRule struct {
+	pattern topic
+	accept  func(any) bool
+	maxSize int
+}
+
+// guard checks payload against every rule matching tp.
+func (b *Bus) guard(tp topic, payload any) error {
+	if payload == nil {
+		return nil
+	}
+	for i := range b.guards {
+		g := &b.guards[i]
+		if g.accept != nil && b.matches(g.pattern, tp) && !g.accept(payload) {
+			return ErrPayloadType
+		}
+	}
+	return nil
+}
+
+// maxSize is the smallest MaxSize of the rules matching tp, 0 if none.
+func (b *Bus) maxSize(tp topic) int {
+	n := 0
+	for i := range b.guards {
+		g := &b.guards[i]
+		if g.maxSize > 0 && (n == 0 || g.maxSize < n) && b.matches(g.pattern, tp) {
+			n = g.maxSize
+		}
+	}
+	return n
+}
+
+// refuse counts a refused publish on tp and reports it.
+func (b *Bus) refuse(tp topic, reason string) {
+	b.rejected.Add(1)
+	b.deadLetter(tp, reason)
+}
+
+// CheckSize reports whether a payload of n encoded bytes may be published
+// on tp under GuardSpec.MaxSize. A refusal is counted and reported on
+// TopicDeadLetter as a refused publish would be.
+func (c *Connection) CheckSize(tp Topic, n int) error {
+	b, ct := c.bus, toConcrete(tp)
+	if len(b.guards) == 0 {
+		return nil
+	}
+	if max := b.maxSize(ct); max > 0 && n > max {
+		b.refuse(ct, DeadTooLarge)
+		return ErrPayloadTooLarge
+	}
+	return nil
+}
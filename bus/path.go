@@ -0,0 +1,115 @@
+package bus
+
+import (
+	"strconv"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------
+// Canonical slash form
+// -----------------------------------------------------------------------------
+//
+// A topic is written as its tokens joined by '/': "hal/cap/power/charger/internal/value".
+// Integer tokens are written in decimal and a segment in canonical decimal
+// form ("7", "-3"; not "07" or "+3") parses back as an int. A string token
+// that would not survive the trip (one that reads as an integer, is
+// empty, or holds '/' or a leading '"') is written Go-quoted instead.
+
+// ParsePath returns the topic for a canonical slash form. Leading and
+// trailing slashes are ignored; "" is the empty topic.
+func ParsePath(s string) Topic {
+	return T(splitPath(s)...)
+}
+
+func splitPath(s string) []Token {
+	s = strings.Trim(s, "/")
+	if s == "" {
+		return nil
+	}
+	var toks []Token
+	for len(s) > 0 {
+		seg := s
+		if s[0] == '"' {
+			// Quoted: runs to the closing quote, which must end the segment.
+			if n := quotedLen(s); n > 0 && (n == len(s) || s[n] == '/') {
+				if u, err := strconv.Unquote(s[:n]); err == nil {
+					toks = append(toks, u)
+					s = strings.TrimPrefix(s[n:], "/")
+					continue
+				}
+			}
+		}
+		if i := strings.IndexByte(s, '/'); i >= 0 {
+			seg, s = s[:i], s[i+1:]
+		} else {
+			s = ""
+		}
+		toks = append(toks, segToken(seg))
+	}
+	return toks
+}
+
+// quotedLen is the length of the quoted string s starts with, or 0.
+func quotedLen(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func segToken(seg string) Token {
+	if n, err := strconv.Atoi(seg); err == nil && strconv.Itoa(n) == seg {
+		return n
+	}
+	return seg
+}
+
+// String returns the canonical slash form of t.
+func (t topic) String() string {
+	var b strings.Builder
+	for i, tok := range t {
+		if i > 0 {
+			b.WriteByte('/')
+		}
+		writeToken(&b, tok)
+	}
+	return b.String()
+}
+
+func writeToken(b *strings.Builder, tok Token) {
+	switch v := tok.(type) {
+	case string:
+		if v == "" || strings.IndexByte(v, '/') >= 0 || v[0] == '"' || segToken(v) != Token(v) {
+			b.WriteString(strconv.Quote(v))
+			return
+		}
+		b.WriteString(v)
+	case int:
+		b.WriteString(strconv.Itoa(v))
+	case int8:
+		b.WriteString(strconv.FormatInt(int64(v), 10))
+	case int16:
+		b.WriteString(strconv.FormatInt(int64(v), 10))
+	case int32:
+		b.WriteString(strconv.FormatInt(int64(v), 10))
+	case int64:
+		b.WriteString(strconv.FormatInt(v, 10))
+	case uint:
+		b.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint8:
+		b.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint16:
+		b.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint32:
+		b.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint64:
+		b.WriteString(strconv.FormatUint(v, 10))
+	case uintptr:
+		b.WriteString(strconv.FormatUint(uint64(v), 10))
+	}
+}
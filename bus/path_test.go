@@ -0,0 +1,52 @@
+package bus
+
+import "testing"
+
+func TestParsePath_RoundTrip(t *testing.T) {
+	cases := []struct {
+		in   Topic
+		want string
+	}{
+		{T("hal", "cap", "power", "charger", "internal", "value"), "hal/cap/power/charger/internal/value"},
+		{T("sensor", 1, "temperature"), "sensor/1/temperature"},
+		{T("a", -3, "+", "#"), "a/-3/+/#"},
+		{T("a", "1"), `a/"1"`},
+		{T("a", "", "b"), `a/""/b`},
+		{T("x/y", `"q`), `"x/y"/"\"q"`},
+		{T("07", "+3"), "07/+3"},
+		{T(), ""},
+	}
+	for _, c := range cases {
+		if got := c.in.String(); got != c.want {
+			t.Errorf("String(%v) = %q, want %q", c.in, got, c.want)
+		}
+		back := ParsePath(c.want)
+		if back.Len() != c.in.Len() {
+			t.Fatalf("ParsePath(%q) has %d tokens, want %d", c.want, back.Len(), c.in.Len())
+		}
+		for i := 0; i < back.Len(); i++ {
+			if back.At(i) != c.in.At(i) {
+				t.Errorf("ParsePath(%q)[%d] = %#v, want %#v", c.want, i, back.At(i), c.in.At(i))
+			}
+		}
+	}
+}
+
+func TestParsePath_Forms(t *testing.T) {
+	if tp := ParsePath("/hal/cap/+/#/"); tp.String() != "hal/cap/+/#" {
+		t.Fatalf("trimmed = %q", tp.String())
+	}
+	if tp := ParsePath("a/12/b"); tp.At(1) != 12 {
+		t.Fatalf("numeric token = %#v", tp.At(1))
+	}
+	// An unterminated or run-on quote is an ordinary segment.
+	if tp := ParsePath(`"ab/c`); tp.Len() != 2 || tp.At(0) != `"ab` {
+		t.Fatalf("unterminated quote = %v", tp)
+	}
+	if tp := ParsePath(`"ab"c/d`); tp.Len() != 2 || tp.At(0) != `"ab"c` {
+		t.Fatalf("run-on quote = %v", tp)
+	}
+	if got := TNoIntern(uint8(7), int64(-2), uint64(9)).String(); got != "7/-2/9" {
+		t.Fatalf("integer kinds = %q", got)
+	}
+}
@@ -0,0 +1,60 @@
+package bus
+
+import "sync/atomic"
+
+// -----------------------------------------------------------------------------
+// Typed subscriptions
+// -----------------------------------------------------------------------------
+
+// SubscriptionT wraps a Subscription whose payloads are expected to be T (a
+// value T or a non-nil *T). The conversion runs on receive, in the caller's
+// goroutine, so a typed subscription costs no more than a plain one:
+//
+//	case m := <-s.Channel():
+//		v, ok := s.Value(m)
+//
+// Delivery keeps the bus semantics: bounded queue, drop-oldest on overflow.
+type SubscriptionT[T any] struct {
+	sub        *Subscription
+	mismatches atomic.Uint32
+}
+
+// SubscribeT subscribes c to tp and returns a typed view of the subscription.
+func SubscribeT[T any](c *Connection, tp Topic) *SubscriptionT[T] {
+	return &SubscriptionT[T]{sub: c.Subscribe(tp)}
+}
+
+func (s *SubscriptionT[T]) Topic() Topic             { return s.sub.topic }
+func (s *SubscriptionT[T]) Channel() <-chan *Message { return s.sub.ch }
+
+// Value asserts m's payload to T. Non-nil payloads of another type are
+// counted as mismatches; nil payloads (retained clears) report false
+// without counting.
+func (s *SubscriptionT[T]) Value(m *Message) (T, bool) {
+	var zero T
+	if m == nil || m.Payload == nil {
+		return zero, false
+	}
+	v, ok := assertT[T](m.Payload)
+	if !ok {
+		s.mismatches.Add(1)
+	}
+	return v, ok
+}
+
+// Mismatches reports how many non-nil payloads failed to assert to T.
+func (s *SubscriptionT[T]) Mismatches() uint32 { return s.mismatches.Load() }
+
+// Unsubscribe removes the underlying subscription and closes Channel.
+func (s *SubscriptionT[T]) Unsubscribe() { s.sub.Unsubscribe() }
+
+func assertT[T any](p any) (T, bool) {
+	if v, ok := p.(T); ok {
+		return v, true
+	}
+	if pv, ok := p.(*T); ok && pv != nil {
+		return *pv, true
+	}
+	var zero T
+	return zero, false
+}
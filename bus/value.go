@@ -0,0 +1,61 @@
+package bus
+
+import "sync"
+
+// Value is a reusable publisher for one topic, for telemetry hot paths.
+// Each Publish reuses a Message from a small ring instead of allocating
+// one, so with a payload that boxes without allocation (a pointer, or an
+// interface value built once) publishing allocates nothing.
+//
+// The ring is sized so that every message a subscriber can still have
+// queued, plus the one it is handling and the retained copy, stays intact.
+// That holds only if subscribers follow the immutable contract:
+//
+//   - treat a received Message and its Payload as read-only;
+//   - do not keep a *Message from a Value after handling it (copy the
+//     payload out instead). A handler that takes longer than ring-depth
+//     publish intervals can see a recycled message.
+//
+// The bus enforces the contract at its edges. Retained snapshots
+// (Connection.Retained) return copies. A recycled message published on a
+// different bus, as a bridge might, is copied first.
+type Value struct {
+	bus      *Bus
+	topic    Topic // boxed once
+	retained bool
+
+	mu   sync.Mutex
+	ring []Message
+	next int
+}
+
+// NewValue returns a recycling publisher for tp. tp must not contain
+// wildcards.
+func (c *Connection) NewValue(tp Topic, retained bool) *Value {
+	return &Value{
+		bus:      c.bus,
+		topic:    tp,
+		retained: retained,
+		ring:     make([]Message, 2*c.bus.qLen+2),
+	}
+}
+
+// Publish sends payload with optional metadata (zero means unset).
+func (v *Value) Publish(payload any, seq uint32, mono int64) {
+	v.mu.Lock()
+	m := &v.ring[v.next]
+	v.next++
+	if v.next == len(v.ring) {
+		v.next = 0
+	}
+	*m = Message{Topic: v.topic, Payload: payload, Retained: v.retained, Seq: seq, Mono: mono, owner: v}
+	v.mu.Unlock()
+	v.bus.Publish(m)
+}
+
+// detach returns a heap copy that is not part of any ring.
+func (m *Message) detach() *Message {
+	c := *m
+	c.owner = nil
+	return &c
+}
@@ -0,0 +1,75 @@
+package bus
+
+import "testing"
+
+func TestValue_PublishDoesNotAllocate(t *testing.T) {
+	b := NewBus(4, "+", "#")
+	c := b.NewConnection("test")
+	exact := c.Subscribe(T("v", "x"))
+	wild := c.Subscribe(T("v", "#"))
+	defer exact.Unsubscribe()
+	defer wild.Unsubscribe()
+
+	v := c.NewValue(T("v", "x"), true)
+	var payload any = &struct{ N int }{1} // boxed once
+	allocs := testing.AllocsPerRun(1000, func() {
+		v.Publish(payload, 1, 0)
+	})
+	if allocs != 0 {
+		t.Fatalf("Value.Publish allocs = %v, want 0", allocs)
+	}
+}
+
+func TestValue_DeliversAndRetains(t *testing.T) {
+	b := NewBus(2, "+", "#")
+	c := b.NewConnection("test")
+	sub := c.Subscribe(T("v"))
+	defer sub.Unsubscribe()
+
+	v := c.NewValue(T("v"), true)
+	for i := 1; i <= 3; i++ {
+		v.Publish(i, uint32(i), 0)
+	}
+	// Queue of 2: 1 dropped, 2 and 3 intact despite recycling.
+	for want := 2; want <= 3; want++ {
+		m := <-sub.Channel()
+		if m.Payload != want || m.Seq != uint32(want) {
+			t.Fatalf("got payload %v seq %d, want %d", m.Payload, m.Seq, want)
+		}
+	}
+	snap := c.Retained(T("v"))
+	if len(snap) != 1 || snap[0].Payload != 3 {
+		t.Fatalf("retained = %v", snap)
+	}
+	// The snapshot is a copy: later publishes do not change it.
+	for i := 4; i < 20; i++ {
+		v.Publish(i, uint32(i), 0)
+	}
+	if snap[0].Payload != 3 || snap[0].owner != nil {
+		t.Fatalf("snapshot mutated: %v", snap[0].Payload)
+	}
+}
+
+func TestValue_CopiedOntoOtherBus(t *testing.T) {
+	b1 := NewBus(2, "+", "#")
+	b2 := NewBus(2, "+", "#")
+	c1 := b1.NewConnection("src")
+	c2 := b2.NewConnection("dst")
+	src := c1.Subscribe(T("v"))
+	defer src.Unsubscribe()
+
+	v := c1.NewValue(T("v"), true)
+	v.Publish(1, 0, 0)
+	c2.Publish(<-src.Channel()) // bridge-style forward
+
+	for i := 2; i < 20; i++ {
+		v.Publish(i, 0, 0)
+	}
+	b2.mu.Lock()
+	var out []*Message
+	b2.collectRetainedLocked(b2.root, toConcrete(T("v")), 0, &out)
+	b2.mu.Unlock()
+	if len(out) != 1 || out[0].Payload != 1 || out[0].owner != nil {
+		t.Fatalf("forwarded retained message was recycled: %+v", out)
+	}
+}
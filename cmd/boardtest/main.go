@@ -0,0 +1,191 @@
+// boardtest runs a declarative factory test plan against the HAL and emits
+// a machine-readable pass/fail report.
+//
+// The plan is taken from retained config/boardtest (types.TestPlan) if one
+// is published within planWait of start-up; otherwise defaultPlan is used.
+// The report is published on boardtest/report and written as a single JSON
+// line on the telemetry UART (uart0) for the fixture to parse.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/services/hal"
+	"devicecode-go/types"
+	"devicecode-go/x/shmring"
+	"devicecode-go/x/strconvx"
+)
+
+const (
+	halTimeout = 5 * time.Second
+	planWait   = 1 * time.Second
+	reportUART = "uart0"
+)
+
+var (
+	tHALState = bus.T("hal", "state")
+	tPlan     = bus.T("config", "boardtest")
+	tReport   = bus.T("boardtest", "report")
+)
+
+// Default per-rail input-current window (mA): below flags an open rail,
+// above a short. Fixtures override per rail via the plan.
+const (
+	railMinMA = 5
+	railMaxMA = 2500
+)
+
+// defaultPlan brings each rail up in order, checking the input-current rise
+// of each, then takes them down again.
+var defaultPlan = func() types.TestPlan {
+	rails := []string{"mpcie-usb", "m2", "mpcie", "cm5", "fan", "boost-load"}
+	p := types.TestPlan{Name: "default"}
+	p.Steps = append(p.Steps,
+		types.TestStep{Kind: types.StepExpect, Name: "charger telemetry", Topic: "hal/cap/power/charger/internal/value", WithinMs: 3000},
+		types.TestStep{Kind: types.StepLED, Name: "led on", Rail: "button_led", On: true},
+	)
+	for _, r := range rails {
+		p.Steps = append(p.Steps, types.TestStep{
+			Kind: types.StepRailCurrent, Name: r + " current", Rail: r,
+			Min: railMinMA, Max: railMaxMA, SettleMs: 500, WithinMs: 3000,
+			ContinueOnFail: true,
+		})
+	}
+	for i := len(rails) - 1; i >= 0; i-- {
+		p.Steps = append(p.Steps, types.TestStep{Kind: types.StepRail, Name: rails[i] + " off", Rail: rails[i], On: false, ContinueOnFail: true})
+	}
+	p.Steps = append(p.Steps, types.TestStep{Kind: types.StepLED, Name: "led off", Rail: "button_led", On: false, ContinueOnFail: true})
+	return p
+}()
+
+func main() {
+	time.Sleep(3 * time.Second)
+	println("[boardtest] starting bus + HAL …")
+
+	ctx := context.Background()
+	b := bus.NewBus(4, "+", "#")
+	halConn := b.NewConnection("hal")
+	ui := b.NewConnection("boardtest")
+	go hal.Run(ctx, halConn, nil)
+
+	if !waitHALReady(ctx, ui) {
+		println("[boardtest] HAL not ready")
+		return
+	}
+
+	var tx *shmring.Ring
+	if ev, err := openSerial(ctx, ui, "io", reportUART); err != nil {
+		println("[boardtest] report UART unavailable:", err.Error())
+	} else {
+		tx = shmring.Get(shmring.Handle(ev.TXHandle))
+	}
+
+	plan := waitPlan(ctx, ui)
+	println("[boardtest] running plan:", plan.Name, "steps:", len(plan.Steps))
+
+	r := &runner{conn: ui}
+	rep := r.run(ctx, plan)
+
+	ui.Publish(ui.NewMessage(tReport, rep, true))
+	if line, err := json.Marshal(rep); err == nil && tx != nil {
+		writeAll(tx, append(line, '\n'))
+	}
+	if rep.Pass {
+		println("[boardtest] PASS")
+	} else {
+		println("[boardtest] FAIL")
+	}
+	select {}
+}
+
+func waitHALReady(ctx context.Context, c *bus.Connection) bool {
+	sub := bus.SubscribeT[types.HALState](c, tHALState)
+	defer sub.Unsubscribe()
+	t := time.NewTimer(halTimeout)
+	defer t.Stop()
+	for {
+		select {
+		case m := <-sub.Channel():
+			if st, ok := sub.Value(m); ok && st.Level == "ready" {
+				return true
+			}
+		case <-t.C:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+func waitPlan(ctx context.Context, c *bus.Connection) types.TestPlan {
+	sub := bus.SubscribeT[types.TestPlan](c, tPlan)
+	defer sub.Unsubscribe()
+	t := time.NewTimer(planWait)
+	defer t.Stop()
+	for {
+		select {
+		case m := <-sub.Channel():
+			if p, ok := sub.Value(m); ok {
+				return p
+			}
+		case <-t.C:
+			return defaultPlan
+		case <-ctx.Done():
+			return defaultPlan
+		}
+	}
+}
+
+func openSerial(ctx context.Context, c *bus.Connection, domain, name string) (types.SerialSessionOpened, error) {
+	sub := bus.SubscribeT[types.SerialSessionOpened](c, bus.T("hal", "cap", domain, "serial", name, "event", "session_opened"))
+	defer sub.Unsubscribe()
+
+	rctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	ctrl := bus.T("hal", "cap", domain, "serial", name, "control", "session_open")
+	if _, err := c.RequestWait(rctx, c.NewMessage(ctrl, types.SerialSessionOpen{}, false)); err != nil {
+		return types.SerialSessionOpened{}, err
+	}
+	for {
+		select {
+		case m := <-sub.Channel():
+			if ev, ok := sub.Value(m); ok {
+				return ev, nil
+			}
+		case <-rctx.Done():
+			return types.SerialSessionOpened{}, rctx.Err()
+		}
+	}
+}
+
+func writeAll(r *shmring.Ring, p []byte) {
+	for len(p) > 0 {
+		if n := r.TryWriteFrom(p); n > 0 {
+			p = p[n:]
+			continue
+		}
+		<-r.Writable()
+	}
+}
+
+func logStep(res types.TestStepResult) {
+	status := "FAIL"
+	if res.Pass {
+		status = "ok"
+	}
+	print("[boardtest] step ", res.Index, " ", string(res.Kind))
+	if res.Name != "" {
+		print(" (", res.Name, ")")
+	}
+	print(" ", status, " ", strconvx.Itoa(int(res.ElapsedMs)), "ms")
+	if res.Kind == types.StepRailCurrent {
+		print(" ΔIIN=", strconvx.Itoa(int(res.Value)), "mA")
+	}
+	if res.Detail != "" {
+		print(" ", res.Detail)
+	}
+	println()
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+)
+
+const (
+	replyTimeout  = 500 * time.Millisecond
+	defaultWithin = 2 * time.Second
+	defaultSettle = 300 * time.Millisecond
+
+	// rail_current defaults: input current from the LTC4015.
+	defaultCurrentTopic = "hal/cap/power/charger/internal/value"
+	defaultCurrentField = "iin_mA"
+)
+
+// runner executes a TestPlan against the bus.
+type runner struct {
+	conn *bus.Connection
+}
+
+func (r *runner) run(ctx context.Context, plan types.TestPlan) types.TestReport {
+	rep := types.TestReport{Plan: plan.Name, Pass: true}
+	for i, st := range plan.Steps {
+		t0 := time.Now()
+		res := r.step(ctx, st)
+		res.Index, res.Kind, res.Name = i, st.Kind, st.Name
+		res.ElapsedMs = uint32(time.Since(t0) / time.Millisecond)
+		rep.Steps = append(rep.Steps, res)
+		logStep(res)
+		if !res.Pass {
+			rep.Pass = false
+			if !st.ContinueOnFail {
+				rep.Skipped = len(plan.Steps) - i - 1
+				break
+			}
+		}
+	}
+	return rep
+}
+
+func (r *runner) step(ctx context.Context, st types.TestStep) types.TestStepResult {
+	switch st.Kind {
+	case types.StepRail:
+		return r.control(ctx, tSwitchSet(st.Rail), types.SwitchSet{On: st.On})
+	case types.StepLED:
+		return r.control(ctx, tLEDSet(st.Rail), types.LEDSet{On: st.On})
+	case types.StepExpect:
+		return r.expect(ctx, st)
+	case types.StepRailCurrent:
+		return r.railCurrent(ctx, st)
+	case types.StepWait:
+		select {
+		case <-time.After(time.Duration(st.WithinMs) * time.Millisecond):
+			return types.TestStepResult{Pass: true}
+		case <-ctx.Done():
+			return types.TestStepResult{Detail: "cancelled"}
+		}
+	}
+	return types.TestStepResult{Detail: "unknown step kind"}
+}
+
+// control sends a HAL control and passes on an OK reply.
+func (r *runner) control(ctx context.Context, tp bus.Topic, payload any) types.TestStepResult {
+	cctx, cancel := context.WithTimeout(ctx, replyTimeout)
+	defer cancel()
+	m, err := r.conn.RequestWait(cctx, r.conn.NewMessage(tp, payload, false))
+	if err != nil {
+		return types.TestStepResult{Detail: string(errcode.Timeout)}
+	}
+	switch v := m.Payload.(type) {
+	case types.OKReply:
+		return types.TestStepResult{Pass: true}
+	case types.ErrorReply:
+		return types.TestStepResult{Detail: v.Error}
+	}
+	return types.TestStepResult{Detail: "unexpected reply"}
+}
+
+// expect waits for a message on st.Topic. Retained messages present at
+// subscription count, so a plan observes the latest value immediately.
+func (r *runner) expect(ctx context.Context, st types.TestStep) types.TestStepResult {
+	within := time.Duration(st.WithinMs) * time.Millisecond
+	if within <= 0 {
+		within = defaultWithin
+	}
+	sub := r.conn.Subscribe(bus.ParsePath(st.Topic))
+	defer r.conn.Unsubscribe(sub)
+
+	deadline := time.NewTimer(within)
+	defer deadline.Stop()
+	var last types.TestStepResult
+	last.Detail = "no message"
+	for {
+		select {
+		case m := <-sub.Channel():
+			if m == nil || m.Payload == nil {
+				continue
+			}
+			if st.Field == "" {
+				return types.TestStepResult{Pass: true}
+			}
+			f, ok := m.Payload.(types.Fielder)
+			if !ok {
+				last.Detail = "payload has no fields"
+				continue
+			}
+			v, ok := f.Field(st.Field)
+			if !ok {
+				last.Detail = "no field " + st.Field
+				continue
+			}
+			if v >= st.Min && v <= st.Max {
+				return types.TestStepResult{Pass: true, Value: v}
+			}
+			// Keep waiting: a later sample may settle into range.
+			last = types.TestStepResult{Value: v, Detail: "out of range"}
+		case <-deadline.C:
+			return last
+		case <-ctx.Done():
+			return types.TestStepResult{Detail: "cancelled"}
+		}
+	}
+}
+
+// railCurrent measures the input-current rise caused by enabling st.Rail.
+func (r *runner) railCurrent(ctx context.Context, st types.TestStep) types.TestStepResult {
+	topic, field := st.Topic, st.Field
+	if topic == "" {
+		topic = defaultCurrentTopic
+	}
+	if field == "" {
+		field = defaultCurrentField
+	}
+	within := time.Duration(st.WithinMs) * time.Millisecond
+	if within <= 0 {
+		within = defaultWithin
+	}
+	settle := time.Duration(st.SettleMs) * time.Millisecond
+	if settle <= 0 {
+		settle = defaultSettle
+	}
+
+	before, err := r.sample(ctx, topic, field, within)
+	if err != "" {
+		return types.TestStepResult{Detail: "before: " + err}
+	}
+	if res := r.control(ctx, tSwitchSet(st.Rail), types.SwitchSet{On: true}); !res.Pass {
+		res.Before = before
+		return res
+	}
+	select {
+	case <-time.After(settle):
+	case <-ctx.Done():
+		return types.TestStepResult{Before: before, Detail: "cancelled"}
+	}
+	after, err := r.sample(ctx, topic, field, within)
+	if err != "" {
+		return types.TestStepResult{Before: before, Detail: "after: " + err}
+	}
+
+	res := types.TestStepResult{Value: after - before, Before: before, After: after}
+	switch {
+	case res.Value < st.Min:
+		res.Detail = "open"
+	case res.Value > st.Max:
+		res.Detail = "short"
+	default:
+		res.Pass = true
+	}
+	return res
+}
+
+// sample returns the next fresh reading of field on topic. The retained
+// value delivered on subscribe is discarded so the reading post-dates the
+// call.
+func (r *runner) sample(ctx context.Context, topic, field string, within time.Duration) (int64, string) {
+	sub := r.conn.Subscribe(bus.ParsePath(topic))
+	defer r.conn.Unsubscribe(sub)
+	for drained := false; !drained; {
+		select {
+		case <-sub.Channel():
+		default:
+			drained = true
+		}
+	}
+	t := time.NewTimer(within)
+	defer t.Stop()
+	for {
+		select {
+		case m := <-sub.Channel():
+			if m == nil {
+				continue
+			}
+			if f, ok := m.Payload.(types.Fielder); ok {
+				if v, ok := f.Field(field); ok {
+					return v, ""
+				}
+			}
+		case <-t.C:
+			return 0, "no sample"
+		case <-ctx.Done():
+			return 0, "cancelled"
+		}
+	}
+}
+
+// ---- topics ----
+
+func tSwitchSet(name string) bus.Topic {
+	return bus.T("hal", "cap", "power", string(types.KindSwitch), name, "control", "set")
+}
+
+func tLEDSet(name string) bus.Topic {
+	return bus.T("hal", "cap", "io", string(types.KindLED), name, "control", "set")
+}
@@ -0,0 +1,247 @@
+// devicecodectl talks to a device bus over the bridge from a desktop.
+//
+//	devicecodectl -serial /dev/ttyACM0 topics [filter]
+//	devicecodectl -serial /dev/ttyACM0 subscribe hal/cap/env/+/+/value
+//	devicecodectl -tcp host:7000 publish [-retain] [-type T] <topic> [json]
+//	devicecodectl -tcp host:7000 call [-type T] [-timeout 1s] <topic> [json]
+//	devicecodectl -serial /dev/ttyACM0 mirror [-every 2s] [-clear] hal/cap/env/#=dev1/env …
+//
+// -z asks the device to compress what it sends (worth it for a full
+// topics listing over a slow UART) and reports the ratio on exit.
+//
+// Topics are slash-separated; + and # are wildcards. The serial device is
+// used as-is: USB CDC ignores line settings, a real UART needs stty first.
+// The device end is services/bridge (bridge.Run on a serial capability).
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/services/bridge"
+	"devicecode-go/types"
+)
+
+func main() {
+	serial := flag.String("serial", "", "serial device path")
+	tcp := flag.String("tcp", "", "TCP address (host:port)")
+	z := flag.Bool("z", false, "ask the device to compress what it sends")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 1 || (*serial == "") == (*tcp == "") {
+		usage()
+		os.Exit(2)
+	}
+
+	rw, err := dial(*serial, *tcp)
+	if err != nil {
+		fatal(err)
+	}
+	c := bridge.NewClient(rw)
+	defer c.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *z {
+		zctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		err := c.UseCompression(zctx)
+		cancel()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "devicecodectl: no compression:", err)
+		} else {
+			defer func() {
+				st := c.CompStats()
+				fmt.Fprintf(os.Stderr, "compressed: %d blocks, %d bytes as %d (%.1fx)\n", st.Blocks, st.Raw, st.Wire, st.Ratio())
+			}()
+		}
+	}
+
+	args := flag.Args()
+	switch args[0] {
+	case "topics":
+		err = topics(ctx, c, args[1:])
+	case "subscribe":
+		err = subscribe(ctx, c, args[1:])
+	case "publish":
+		err = publish(c, args[1:])
+	case "call":
+		err = call(ctx, c, args[1:])
+	case "mirror":
+		err = mirror(ctx, c, args[1:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: devicecodectl (-serial PATH | -tcp ADDR) [-z] topics|subscribe|publish|call|mirror …")
+	flag.PrintDefaults()
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "devicecodectl:", err)
+	os.Exit(1)
+}
+
+func dial(serial, tcp string) (io.ReadWriteCloser, error) {
+	if tcp != "" {
+		return net.Dial("tcp", tcp)
+	}
+	return os.OpenFile(serial, os.O_RDWR, 0)
+}
+
+func topics(ctx context.Context, c *bridge.Client, args []string) error {
+	filter := "#"
+	if len(args) > 0 {
+		filter = args[0]
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	fs, err := c.Topics(ctx, bridge.Path(filter))
+	for _, f := range fs {
+		printFrame(f)
+	}
+	return err
+}
+
+func subscribe(ctx context.Context, c *bridge.Client, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("subscribe <filter>")
+	}
+	msgs, cancel, err := c.Subscribe(bridge.Path(args[0]))
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	for {
+		select {
+		case f, ok := <-msgs:
+			if !ok {
+				return c.Err()
+			}
+			printFrame(f)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func publish(c *bridge.Client, args []string) error {
+	fs := flag.NewFlagSet("publish", flag.ExitOnError)
+	retain := fs.Bool("retain", false, "publish retained")
+	typ := fs.String("type", "", "payload type name (types registry)")
+	fs.Parse(args)
+	topic, body, err := topicAndBody(fs.Args())
+	if err != nil {
+		return err
+	}
+	return c.Publish(bridge.Path(topic), *typ, body, *retain)
+}
+
+func call(ctx context.Context, c *bridge.Client, args []string) error {
+	fs := flag.NewFlagSet("call", flag.ExitOnError)
+	typ := fs.String("type", "", "payload type name (types registry)")
+	timeout := fs.Duration("timeout", time.Second, "reply timeout")
+	fs.Parse(args)
+	topic, body, err := topicAndBody(fs.Args())
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, *timeout+2*time.Second)
+	defer cancel()
+	r, err := c.Call(ctx, bridge.Path(topic), *typ, body, *timeout)
+	if err != nil {
+		return err
+	}
+	printFrame(r)
+	return nil
+}
+
+// mirror runs bridge.Mirror into a local bus and prints what lands there,
+// link status included: a check of the remap rules and of the heartbeat.
+func mirror(ctx context.Context, c *bridge.Client, args []string) error {
+	fs := flag.NewFlagSet("mirror", flag.ExitOnError)
+	every := fs.Duration("every", 2*time.Second, "heartbeat interval")
+	clearDown := fs.Bool("clear", false, "clear mirrored values while the link is down")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return fmt.Errorf("mirror <remote>=<local> …")
+	}
+	cfg := bridge.MirrorConfig{Every: *every}
+	for _, a := range fs.Args() {
+		remote, local, ok := strings.Cut(a, "=")
+		if !ok || remote == "" || local == "" {
+			return fmt.Errorf("rule %q: want <remote>=<local>", a)
+		}
+		r := bridge.Rule{Remote: remote, Local: local}
+		if *clearDown {
+			r.OnDown = bridge.DownClear
+		}
+		cfg.Rules = append(cfg.Rules, r)
+	}
+
+	b := bus.NewBus(32, "+", "#")
+	local := b.NewConnection("mirror")
+	sub := local.Subscribe(bus.T("#"))
+	done := make(chan error, 1)
+	go func() { done <- bridge.Mirror(ctx, c, b.NewConnection("bridge"), cfg) }()
+	for {
+		select {
+		case m := <-sub.Channel():
+			var toks []any
+			for i := 0; i < m.Topic.Len(); i++ {
+				toks = append(toks, m.Topic.At(i))
+			}
+			p, _ := json.Marshal(m.Payload)
+			fmt.Println(bridge.PathString(toks), types.PayloadName(m.Payload), string(p))
+		case err := <-done:
+			if err == context.Canceled {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func topicAndBody(args []string) (string, json.RawMessage, error) {
+	switch len(args) {
+	case 1:
+		return args[0], nil, nil
+	case 2:
+		if !json.Valid([]byte(args[1])) {
+			return "", nil, fmt.Errorf("payload is not valid JSON")
+		}
+		return args[0], json.RawMessage(args[1]), nil
+	}
+	return "", nil, fmt.Errorf("expected <topic> [json]")
+}
+
+func printFrame(f bridge.Frame) {
+	p := string(f.Payload)
+	if p == "" {
+		p = "null"
+	}
+	typ := f.Type
+	if typ == "" {
+		typ = "-"
+	}
+	if len(f.Topic) == 0 {
+		fmt.Println(typ, p)
+		return
+	}
+	fmt.Println(bridge.PathString(f.Topic), typ, p)
+}
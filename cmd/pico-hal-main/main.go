@@ -7,6 +7,7 @@ import (
 
 	"devicecode-go/bus"
 	"devicecode-go/services/hal"
+	"devicecode-go/services/powersys"
 	"devicecode-go/types"
 	"devicecode-go/x/strconvx"
 )
@@ -37,35 +38,18 @@ func printTopicWith(prefix string, t bus.Topic) {
 // fixed-point helpers (no fmt)
 
 func printDeci(label string, deci int) {
-	sign := ""
-	if deci < 0 {
-		sign = "-"
-		deci = -deci
-	}
-	whole := deci / 10
-	frac := deci % 10
+	var buf [24]byte
 	print(label)
-	print(sign)
-	print(strconvx.Itoa(whole))
-	print(".")
-	print(strconvx.Itoa(frac))
-	println()
+	println(string(strconvx.AppendDeci(buf[:0], int64(deci))))
 }
 
 func printHundredths(label string, hx100 int) {
 	if hx100 < 0 {
 		hx100 = 0
 	}
-	whole := hx100 / 100
-	frac := hx100 % 100
+	var buf [24]byte
 	print(label)
-	print(strconvx.Itoa(whole))
-	print(".")
-	if frac < 10 {
-		print("0")
-	}
-	print(strconvx.Itoa(frac))
-	println()
+	println(string(strconvx.AppendHundredths(buf[:0], int64(hx100))))
 }
 
 // TinyGo runtime memory snapshot
@@ -91,9 +75,10 @@ func main() {
 	b := bus.NewBus(4, "+", "#")
 	halConn := b.NewConnection("hal")
 	uiConn := b.NewConnection("ui")
+	defer uiConn.UnsubscribeAll()
 
 	println("[main] starting hal.Run …")
-	go hal.Run(ctx, halConn)
+	go hal.Run(ctx, halConn, nil)
 
 	// Allow HAL to publish initial retained state
 	time.Sleep(250 * time.Millisecond)
@@ -116,8 +101,8 @@ func main() {
 	tHumidValue := bus.T("hal", "cap", "env", humidKind, "core", "value")
 
 	println("[main] subscribing to env/temperature/core and env/humidity/core values …")
-	tempSub := uiConn.Subscribe(tTempValue)
-	humidSub := uiConn.Subscribe(tHumidValue)
+	tempSub := bus.SubscribeT[types.TemperatureValue](uiConn, tTempValue)
+	humidSub := bus.SubscribeT[types.HumidityValue](uiConn, tHumidValue)
 
 	// Tickers (no per-loop allocations)
 	rampTicker := time.NewTicker(2 * time.Second)
@@ -135,18 +120,15 @@ func main() {
 	)
 
 	println("[main] subscribing to power/charger/charger0 and env/battery/charger0 values …")
-	valSub := uiConn.Subscribe(bus.T("hal", "cap", "power", "+", name, "value"))
-	stSub := uiConn.Subscribe(bus.T("hal", "cap", "power", "+", name, "status"))
-	evSub := uiConn.Subscribe(bus.T("hal", "cap", "power", "+", name, "event", "+"))
+	powerSub := uiConn.SubscribeMany([]bus.Topic{
+		bus.T("hal", "cap", "power", "+", name, "value"),
+		bus.T("hal", "cap", "power", "+", name, "status"),
+		bus.T("hal", "cap", "power", "+", name, "event", "+"),
+	})
+	powerCh := powerSub.Channel()
 
-	valCh := valSub.Channel()
-	stCh := stSub.Channel()
-	evCh := evSub.Channel()
-
-	// Track latest currents for ISYS≈IIN−IBAT
-	var lastIIn int32
-	var lastIBat int32
-	var haveIIn, haveIBat bool
+	// ISYS and power figures arrive as power/system/<name>/value.
+	go powersys.Run(ctx, b.NewConnection("powersys"), powersys.Config{Charger: name, Battery: name})
 
 	println("[main] entering event loop (ramp PWM every 2s; print SHTC3 and LTC4015 print received values + events) …")
 
@@ -154,17 +136,13 @@ func main() {
 		select {
 
 		case m := <-tempSub.Channel():
-			if v, ok := m.Payload.(types.TemperatureValue); ok {
+			if v, ok := tempSub.Value(m); ok {
 				printDeci("[value] env/temperature/core °C=", int(v.DeciC))
-			} else {
-				println("[value] env/temperature/core (unexpected payload type)")
 			}
 
 		case m := <-humidSub.Channel():
-			if v, ok := m.Payload.(types.HumidityValue); ok {
+			if v, ok := humidSub.Value(m); ok {
 				printHundredths("[value] env/humidity/core %RH=", int(v.RHx100))
-			} else {
-				println("[value] env/humidity/core (unexpected payload type)")
 			}
 
 		case <-rampTicker.C:
@@ -182,26 +160,19 @@ func main() {
 			runtime.GC()
 			printMem()
 
-		case m, ok := <-valCh:
+		case m, ok := <-powerCh:
 			if !ok {
-				valCh = nil
+				powerCh = nil
 				continue
 			}
-			printCapValue(m, &lastIIn, &haveIIn, &lastIBat, &haveIBat)
-
-		case m, ok := <-stCh:
-			if !ok {
-				stCh = nil
-				continue
-			}
-			printCapStatus(m)
-
-		case m, ok := <-evCh:
-			if !ok {
-				evCh = nil
-				continue
+			switch powerSub.Match(m) {
+			case 0:
+				printCapValue(m)
+			case 1:
+				printCapStatus(m)
+			case 2:
+				printCapEvent(m)
 			}
-			printCapEvent(m)
 
 		case <-ctx.Done():
 			return
@@ -211,7 +182,7 @@ func main() {
 
 // ----------- printing helpers -----------
 
-func printCapValue(m *bus.Message, lastIIn *int32, haveIIn *bool, lastIBat *int32, haveIBat *bool) {
+func printCapValue(m *bus.Message) {
 	// hal/cap/<domain>/<kind>/<name>/value
 	dom, _ := m.Topic.At(2).(string)
 	kind, _ := m.Topic.At(3).(string)
@@ -231,20 +202,7 @@ func printCapValue(m *bus.Message, lastIIn *int32, haveIIn *bool, lastIBat *int3
 		print(int(v.PerCellMilliV))
 		print("mV | IBAT=")
 		print(int(v.IBatMilliA))
-		print("mA")
-
-		*lastIBat = v.IBatMilliA
-		*haveIBat = true
-
-		// ISYS ≈ IIN − IBAT (IBAT>0 ⇒ charging)
-		if *haveIIn && *haveIBat {
-			isys := *lastIIn - *lastIBat
-			print(" | ISYS≈")
-			print(int(isys))
-			print("mA")
-		}
-
-		println("")
+		println("mA")
 
 	case types.ChargerValue:
 		print("[value] ")
@@ -259,19 +217,22 @@ func printCapValue(m *bus.Message, lastIIn *int32, haveIIn *bool, lastIBat *int3
 		print(int(v.VSYS_mV))
 		print("mV | IIN=")
 		print(int(v.IIn_mA))
-		print("mA")
-
-		*lastIIn = v.IIn_mA
-		*haveIIn = true
-
-		if *haveIIn && *haveIBat {
-			isys := *lastIIn - *lastIBat
-			print(" | ISYS≈")
-			print(int(isys))
-			print("mA")
-		}
+		println("mA")
 
-		println("")
+	case types.SystemPowerValue:
+		print("[value] ")
+		print(dom)
+		print("/")
+		print(kind)
+		print("/")
+		print(name)
+		print(" | ISYS≈")
+		print(int(v.ISys_mA))
+		print("mA | PIN=")
+		print(int(v.PIn_mW))
+		print("mW | PBAT=")
+		print(int(v.PBat_mW))
+		println("mW")
 	default:
 		// ignore others
 	}
@@ -5,6 +5,7 @@
 //	err := d.Collect(&s)     // fetch when ready; returns ErrNotReady while busy
 //
 // For convenience, d.Read() performs trigger + bounded polling until ready.
+// Trigger, TriggerHint and Result implement envsensor.TempHumSensor.
 //
 // NOTE: I2C.Tx MUST perform a write followed by a repeated-start read when both
 // w and r are provided, without releasing the bus.
@@ -17,6 +18,8 @@ import (
 	"errors"
 	"time"
 
+	"devicecode-go/drivers/envsensor"
+
 	"tinygo.org/x/drivers"
 )
 
@@ -37,7 +40,7 @@ const (
 // Errors returned by the driver.
 var (
 	ErrTimeout  = errors.New("aht20: timeout")
-	ErrNotReady = errors.New("aht20: not ready")
+	ErrNotReady = envsensor.ErrNotReady
 	ErrProtocol = errors.New("aht20: protocol error")
 )
 
@@ -130,12 +133,16 @@ func (d *Device) Status() (byte, error) {
 	return data[0], nil
 }
 
-// Trigger starts a measurement. It is a quick register write with no blocking.
-// After Trigger, the device needs time to convert; see d.TriggerHint().
+// Trigger starts a measurement. It is a quick register write with no blocking,
+// unless the device has lost its calibration (e.g. it was power-cycled) and
+// must be initialised again first. After Trigger, the device needs time to
+// convert; see d.TriggerHint().
 func (d *Device) Trigger() error {
 	// Ensure the device has been configured at least once.
 	if d.cfg.PollInterval == 0 {
 		d.Configure()
+	} else if st, err := d.Status(); err == nil && st&statusCalibrated == 0 {
+		d.Configure(d.cfg)
 	}
 	return d.bus.Tx(d.Address, []byte{cmdTrigger, 0x33, 0x00}, nil)
 }
@@ -174,6 +181,16 @@ func (d *Device) Collect(out *Sample) error {
 	return nil
 }
 
+// Result collects the measurement started by Trigger, converted. While the
+// device is busy it returns ErrNotReady.
+func (d *Device) Result() (envsensor.Reading, error) {
+	var s Sample
+	if err := d.Collect(&s); err != nil {
+		return envsensor.Reading{}, err
+	}
+	return envsensor.Reading{DeciC: s.DeciCelsius(), RHx100: s.DeciRelHumidity() * 10}, nil
+}
+
 // Read performs a full measurement cycle: Trigger followed by bounded
 // polling until Collect succeeds or the timeout elapses.
 func (d *Device) Read() error {
@@ -0,0 +1,307 @@
+// Package bme280 provides a driver for the Bosch BME280
+// temperature/humidity/pressure sensor and its humidity-less sibling, the
+// BMP280. It implements envsensor.TempHumSensor with forced-mode
+// conversions: Trigger starts one, and Result returns it compensated, with
+// the pressure in Reading.Pa (and RHx100 0 on a BMP280).
+//
+// Compensation uses the datasheet's integer routines (BME280 datasheet
+// rev 1.6, section 4.2.3), so there is no floating point on the hot path.
+package bme280
+
+import (
+	"errors"
+	"time"
+
+	"devicecode-go/drivers/envsensor"
+
+	"tinygo.org/x/drivers"
+)
+
+// I2C addresses: SDO low / high.
+const (
+	Address    = 0x76
+	AddressAlt = 0x77
+)
+
+// Chip IDs (register 0xD0).
+const (
+	ChipBME280 = 0x60
+	ChipBMP280 = 0x58
+)
+
+const (
+	regCalib0   = 0x88 // 0x88..0xA1: T1..T3, P1..P9, (0xA0 unused), H1
+	regChipID   = 0xD0
+	regReset    = 0xE0
+	regCalib26  = 0xE1 // 0xE1..0xE7: H2..H6
+	regCtrlHum  = 0xF2
+	regStatus   = 0xF3
+	regCtrlMeas = 0xF4
+	regConfig   = 0xF5
+	regData     = 0xF7 // press[3], temp[3], hum[2]
+
+	statusMeasuring = 0x08
+	modeForced      = 0x01
+)
+
+var (
+	ErrChipID   = errors.New("bme280: unexpected chip id")
+	ErrNotReady = envsensor.ErrNotReady
+)
+
+// Config controls oversampling and filtering. All fields are optional.
+type Config struct {
+	// Address defaults to 0x76 if zero.
+	Address uint16
+	// Chip is the expected chip ID (ChipBME280 or ChipBMP280); a different
+	// part fails Configure with ErrChipID. 0 accepts either.
+	Chip uint8
+	// Oversampling ×1, ×2, ×4, ×8 or ×16 per channel; 0 means ×1. Each step
+	// lowers noise and lengthens the conversion.
+	OversampleT, OversampleP, OversampleH uint8
+	// IIR filter coefficient on pressure and temperature: 0 (off), 2, 4,
+	// 8 or 16. It acts across conversions, so it smooths only when the
+	// sensor is read regularly.
+	Filter uint8
+}
+
+type calib struct {
+	t1                             uint16
+	t2, t3                         int16
+	p1                             uint16
+	p2, p3, p4, p5, p6, p7, p8, p9 int16
+	h1, h3                         uint8
+	h2, h4, h5                     int16
+	h6                             int8
+}
+
+// Device wraps an I2C connection to a BME280 or BMP280.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+
+	cfg   Config
+	ready bool // configured and calibration read
+	chip  uint8
+	cal   calib
+	ctrl  [2]byte // ctrl_hum, ctrl_meas (forced)
+	w     [2]byte
+	buf   [26]byte
+}
+
+// New creates a Device with cfg. It does not touch the bus.
+func New(bus drivers.I2C, cfg Config) Device {
+	d := Device{bus: bus, Address: Address, cfg: cfg}
+	if cfg.Address != 0 {
+		d.Address = cfg.Address
+	}
+	return d
+}
+
+// osrsCode maps an oversampling factor to its register code (×1 for 0 or
+// anything unsupported).
+func osrsCode(n uint8) uint8 {
+	switch n {
+	case 2:
+		return 2
+	case 4:
+		return 3
+	case 8:
+		return 4
+	case 16:
+		return 5
+	}
+	return 1
+}
+
+func filterCode(n uint8) uint8 {
+	switch n {
+	case 2:
+		return 1
+	case 4:
+		return 2
+	case 8:
+		return 3
+	case 16:
+		return 4
+	}
+	return 0
+}
+
+// Configure checks the chip ID, reads the calibration and applies the
+// Config given to New. The first Trigger calls it if it has not been, and
+// so does the next after a reset or failed trigger.
+func (d *Device) Configure() error {
+	cfg := d.cfg
+	d.ready = false
+
+	id, err := d.read(regChipID, 1)
+	if err != nil {
+		return err
+	}
+	switch {
+	case cfg.Chip != 0 && id[0] != cfg.Chip,
+		id[0] != ChipBME280 && id[0] != ChipBMP280:
+		return ErrChipID
+	}
+	d.chip = id[0]
+
+	b, err := d.read(regCalib0, 26)
+	if err != nil {
+		return err
+	}
+	le := func(i int) uint16 { return uint16(b[i]) | uint16(b[i+1])<<8 }
+	c := calib{
+		t1: le(0), t2: int16(le(2)), t3: int16(le(4)),
+		p1: le(6), p2: int16(le(8)), p3: int16(le(10)), p4: int16(le(12)),
+		p5: int16(le(14)), p6: int16(le(16)), p7: int16(le(18)), p8: int16(le(20)),
+		p9: int16(le(22)), h1: b[25],
+	}
+	if d.HasHumidity() {
+		h, err := d.read(regCalib26, 7)
+		if err != nil {
+			return err
+		}
+		c.h2 = int16(uint16(h[0]) | uint16(h[1])<<8)
+		c.h3 = h[2]
+		c.h4 = int16(int8(h[3]))<<4 | int16(h[4]&0x0F)
+		c.h5 = int16(int8(h[5]))<<4 | int16(h[4]>>4)
+		c.h6 = int8(h[6])
+	}
+	d.cal = c
+
+	// Sleep mode while writing config (writes to it are ignored otherwise).
+	if err := d.write(regCtrlMeas, 0); err != nil {
+		return err
+	}
+	if err := d.write(regConfig, filterCode(cfg.Filter)<<2); err != nil {
+		return err
+	}
+	d.ctrl[0] = osrsCode(cfg.OversampleH)
+	d.ctrl[1] = osrsCode(cfg.OversampleT)<<5 | osrsCode(cfg.OversampleP)<<2 | modeForced
+	d.ready = true
+	return nil
+}
+
+// Chip returns the chip ID found by Configure (0 before).
+func (d *Device) Chip() uint8 { return d.chip }
+
+// HasHumidity reports whether the part measures humidity (a BME280).
+func (d *Device) HasHumidity() bool { return d.chip == ChipBME280 }
+
+// Reset issues a soft reset; the device must be configured again.
+func (d *Device) Reset() error {
+	d.ready = false
+	return d.write(regReset, 0xB6)
+}
+
+// Trigger starts a forced-mode conversion, configuring the device first if
+// needed.
+func (d *Device) Trigger() error {
+	if !d.ready {
+		if err := d.Configure(); err != nil {
+			return err
+		}
+	}
+	// ctrl_hum takes effect only after a write to ctrl_meas.
+	if d.HasHumidity() {
+		if err := d.write(regCtrlHum, d.ctrl[0]); err != nil {
+			return err
+		}
+	}
+	if err := d.write(regCtrlMeas, d.ctrl[1]); err != nil {
+		d.ready = false
+		return err
+	}
+	return nil
+}
+
+// TriggerHint returns the maximum conversion time for the configured
+// oversampling (datasheet appendix B): 1.25 ms + 2.3 ms per temperature
+// sample + (2.3 ms per sample + 0.575 ms) for pressure and for humidity.
+func (d *Device) TriggerHint() time.Duration {
+	n := func(code uint8) time.Duration { return time.Duration(1) << (code - 1) }
+	us := 1250 + 2300*n(osrsCode(d.cfg.OversampleT)) + 2300*n(osrsCode(d.cfg.OversampleP)) + 575
+	if d.HasHumidity() {
+		us += 2300*n(osrsCode(d.cfg.OversampleH)) + 575
+	}
+	return us * time.Microsecond
+}
+
+// Result reads and compensates the conversion started by Trigger.
+func (d *Device) Result() (envsensor.Reading, error) {
+	st, err := d.read(regStatus, 1)
+	if err != nil {
+		return envsensor.Reading{}, err
+	}
+	if st[0]&statusMeasuring != 0 {
+		return envsensor.Reading{}, ErrNotReady
+	}
+	n := 6
+	if d.HasHumidity() {
+		n = 8
+	}
+	b, err := d.read(regData, n)
+	if err != nil {
+		return envsensor.Reading{}, err
+	}
+	adcP := int32(b[0])<<12 | int32(b[1])<<4 | int32(b[2])>>4
+	adcT := int32(b[3])<<12 | int32(b[4])<<4 | int32(b[5])>>4
+
+	tFine, centiC := d.cal.temperature(adcT)
+	r := envsensor.Reading{DeciC: centiC / 10, Pa: d.cal.pressure(adcP, tFine) >> 8}
+	if d.HasHumidity() {
+		adcH := int32(b[6])<<8 | int32(b[7])
+		r.RHx100 = int32(uint64(d.cal.humidity(adcH, tFine)) * 100 >> 10)
+	}
+	return r, nil
+}
+
+// temperature returns t_fine and the temperature in 0.01 °C.
+func (c *calib) temperature(adc int32) (tFine, centiC int32) {
+	var1 := (((adc >> 3) - int32(c.t1)<<1) * int32(c.t2)) >> 11
+	var2 := (((((adc >> 4) - int32(c.t1)) * ((adc >> 4) - int32(c.t1))) >> 12) * int32(c.t3)) >> 14
+	tFine = var1 + var2
+	return tFine, (tFine*5 + 128) >> 8
+}
+
+// pressure returns the pressure in Pa as Q24.8.
+func (c *calib) pressure(adc, tFine int32) uint32 {
+	var1 := int64(tFine) - 128000
+	var2 := var1 * var1 * int64(c.p6)
+	var2 += (var1 * int64(c.p5)) << 17
+	var2 += int64(c.p4) << 35
+	var1 = ((var1 * var1 * int64(c.p3)) >> 8) + ((var1 * int64(c.p2)) << 12)
+	var1 = ((int64(1)<<47 + var1) * int64(c.p1)) >> 33
+	if var1 == 0 {
+		return 0 // avoid division by zero
+	}
+	p := int64(1048576 - adc)
+	p = (((p << 31) - var2) * 3125) / var1
+	var1 = (int64(c.p9) * (p >> 13) * (p >> 13)) >> 25
+	var2 = (int64(c.p8) * p) >> 19
+	return uint32(((p + var1 + var2) >> 8) + int64(c.p7)<<4)
+}
+
+// humidity returns the relative humidity in %RH as Q22.10.
+func (c *calib) humidity(adc, tFine int32) uint32 {
+	v := tFine - 76800
+	v = ((((adc << 14) - int32(c.h4)<<20 - int32(c.h5)*v) + 16384) >> 15) *
+		(((((((v*int32(c.h6))>>10)*(((v*int32(c.h3))>>11)+32768))>>10)+2097152)*int32(c.h2) + 8192) >> 14)
+	v -= ((((v >> 15) * (v >> 15)) >> 7) * int32(c.h1)) >> 4
+	v = min(max(v, 0), 419430400)
+	return uint32(v >> 12)
+}
+
+func (d *Device) read(reg byte, n int) ([]byte, error) {
+	d.w[0] = reg
+	if err := d.bus.Tx(d.Address, d.w[:1], d.buf[:n]); err != nil {
+		return nil, err
+	}
+	return d.buf[:n], nil
+}
+
+func (d *Device) write(reg, v byte) error {
+	d.w[0], d.w[1] = reg, v
+	return d.bus.Tx(d.Address, d.w[:], nil)
+}
@@ -0,0 +1,17 @@
+package bme280
+
+import "testing"
+
+// The worked example in the BMP280 datasheet (rev 1.19, section 3.12),
+// whose calibration and compensation are the BME280's.
+func TestCompensate_DatasheetExample(t *testing.T) {
+	c := calib{t1: 27504, t2: 26435, t3: -1000,
+		p1: 36477, p2: -10685, p3: 3024, p4: 2855, p5: 140, p6: -7, p7: 15500, p8: -14600, p9: 6000}
+	tFine, centiC := c.temperature(519888)
+	if tFine != 128422 || centiC != 2508 {
+		t.Fatalf("t_fine %d, T %d (want 128422, 2508)", tFine, centiC)
+	}
+	if p := c.pressure(415148, tFine); p>>8 != 100653 {
+		t.Fatalf("P %d Pa (%d/256), want 100653", p>>8, p)
+	}
+}
@@ -0,0 +1,238 @@
+// Package bq25792 provides a driver for the TI BQ25792 1–4 cell
+// buck-boost charger.
+//
+// The part senses its own currents, so unlike ltc4015 there are no shunt
+// parameters: the ADC reports bus/battery/system voltages and currents in
+// mV/mA directly. Limits are written in datasheet units (10 mA, 10 mV,
+// 100 mV steps); out-of-range requests return ErrRange rather than being
+// clamped.
+//
+// The I2C watchdog is disabled by Configure. Left enabled, its expiry
+// returns every register to its power-on default, silently undoing any
+// limits the host set.
+package bq25792
+
+import (
+	"errors"
+
+	"tinygo.org/x/drivers"
+)
+
+// Fixed I2C address.
+const Address = 0x6B
+
+const (
+	regVREG     = 0x01 // 16-bit, 10 mV
+	regICHG     = 0x03 // 16-bit, 10 mA
+	regVINDPM   = 0x05 // 8-bit, 100 mV
+	regIINDPM   = 0x06 // 16-bit, 10 mA
+	regRecharge = 0x0A // CELL[7:6]
+	regCtrl0    = 0x0F
+	regCtrl1    = 0x10
+	regStat0    = 0x1B // five status registers
+	regFault0   = 0x20 // two fault registers
+	regFlag0    = 0x22 // six flag registers, cleared on read
+	regADCCtrl  = 0x2E
+	regIBUS     = 0x31
+	regIBAT     = 0x33
+	regVBUS     = 0x35
+	regVBAT     = 0x3B
+	regVSYS     = 0x3D
+	regTDIE     = 0x41
+
+	ctrl0EnChg = 1 << 5
+	ctrl0EnHiZ = 1 << 2
+	ctrl1WDMsk = 0x07
+	adcEnable  = 1 << 7 // continuous, 15-bit
+)
+
+// Errors returned by the driver.
+var (
+	ErrRange = errors.New("bq25792: value out of range")
+)
+
+// Charge cycle state (CHG_STAT).
+type ChargeState uint8
+
+const (
+	NotCharging ChargeState = 0
+	Trickle     ChargeState = 1
+	PreCharge   ChargeState = 2
+	FastCC      ChargeState = 3
+	TaperCV     ChargeState = 4
+	TopOff      ChargeState = 6
+	Done        ChargeState = 7
+)
+
+// Snapshot is one read of the ADC and status registers.
+type Snapshot struct {
+	VBUS_mV, IBUS_mA int32
+	VBAT_mV, IBAT_mA int32 // IBAT > 0 while charging
+	VSYS_mV          int32
+	Die_mC           int32
+	State            ChargeState
+	VbusStat         uint8    // VBUS_STAT code (0 none, 1..8 adaptor types)
+	Status           [5]uint8 // raw status registers 0x1B..0x1F
+	Fault            [2]uint8 // raw fault registers 0x20..0x21
+}
+
+// Device wraps an I2C connection to a BQ25792.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+
+	buf [3]byte
+}
+
+// New creates a driver; it does not touch the bus.
+func New(bus drivers.I2C) Device {
+	return Device{bus: bus, Address: Address}
+}
+
+// Configure disables the watchdog and starts the ADC. It returns the cell
+// count strapped on PROG, for the caller to check against its own.
+func (d *Device) Configure() (cells uint8, err error) {
+	v, err := d.read8(regCtrl1)
+	if err != nil {
+		return 0, err
+	}
+	if err := d.write8(regCtrl1, v&^ctrl1WDMsk); err != nil {
+		return 0, err
+	}
+	if err := d.write8(regADCCtrl, adcEnable); err != nil {
+		return 0, err
+	}
+	r, err := d.read8(regRecharge)
+	if err != nil {
+		return 0, err
+	}
+	return r>>6 + 1, nil
+}
+
+// Snapshot reads the ADC results and status.
+func (d *Device) Snapshot() (Snapshot, error) {
+	var s Snapshot
+	var err error
+	get := func(reg uint8) int32 {
+		if err != nil {
+			return 0
+		}
+		var v uint16
+		v, err = d.read16(reg)
+		return int32(int16(v))
+	}
+	s.IBUS_mA = get(regIBUS)
+	s.IBAT_mA = get(regIBAT)
+	s.VBUS_mV = get(regVBUS)
+	s.VBAT_mV = get(regVBAT)
+	s.VSYS_mV = get(regVSYS)
+	s.Die_mC = get(regTDIE) * 500 // 0.5 °C
+	if err != nil {
+		return Snapshot{}, err
+	}
+	for i := range s.Status {
+		if s.Status[i], err = d.read8(regStat0 + uint8(i)); err != nil {
+			return Snapshot{}, err
+		}
+	}
+	for i := range s.Fault {
+		if s.Fault[i], err = d.read8(regFault0 + uint8(i)); err != nil {
+			return Snapshot{}, err
+		}
+	}
+	s.State = ChargeState(s.Status[1] >> 5)
+	s.VbusStat = s.Status[1] >> 1 & 0xF
+	return s, nil
+}
+
+// Flags reads and clears the six interrupt flag registers.
+func (d *Device) Flags() (f [6]uint8, err error) {
+	for i := range f {
+		if f[i], err = d.read8(regFlag0 + uint8(i)); err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+}
+
+// SetChargeEnable starts or suspends charging (EN_CHG).
+func (d *Device) SetChargeEnable(on bool) error {
+	return d.update8(regCtrl0, ctrl0EnChg, on)
+}
+
+// SetHiZ disconnects (true) or reconnects the input.
+func (d *Device) SetHiZ(on bool) error {
+	return d.update8(regCtrl0, ctrl0EnHiZ, on)
+}
+
+// SetInputLimit_mA sets IINDPM (100..3300 mA).
+func (d *Device) SetInputLimit_mA(mA int32) error {
+	if mA < 100 || mA > 3300 {
+		return ErrRange
+	}
+	return d.write16(regIINDPM, uint16(mA/10))
+}
+
+// SetChargeCurrent_mA sets ICHG (50..5000 mA).
+func (d *Device) SetChargeCurrent_mA(mA int32) error {
+	if mA < 50 || mA > 5000 {
+		return ErrRange
+	}
+	return d.write16(regICHG, uint16(mA/10))
+}
+
+// SetChargeVoltage_mV sets VREG for the whole pack (3000..18800 mV).
+func (d *Device) SetChargeVoltage_mV(mV int32) error {
+	if mV < 3000 || mV > 18800 {
+		return ErrRange
+	}
+	return d.write16(regVREG, uint16(mV/10))
+}
+
+// SetVINDPM_mV sets the input voltage regulation limit (3600..22000 mV);
+// charging current is reduced to hold VBUS above it.
+func (d *Device) SetVINDPM_mV(mV int32) error {
+	if mV < 3600 || mV > 22000 {
+		return ErrRange
+	}
+	return d.write8(regVINDPM, uint8(mV/100))
+}
+
+func (d *Device) update8(reg, mask uint8, set bool) error {
+	v, err := d.read8(reg)
+	if err != nil {
+		return err
+	}
+	if set {
+		v |= mask
+	} else {
+		v &^= mask
+	}
+	return d.write8(reg, v)
+}
+
+func (d *Device) read8(reg uint8) (uint8, error) {
+	d.buf[0] = reg
+	if err := d.bus.Tx(d.Address, d.buf[:1], d.buf[1:2]); err != nil {
+		return 0, err
+	}
+	return d.buf[1], nil
+}
+
+func (d *Device) read16(reg uint8) (uint16, error) {
+	d.buf[0] = reg
+	if err := d.bus.Tx(d.Address, d.buf[:1], d.buf[1:3]); err != nil {
+		return 0, err
+	}
+	return uint16(d.buf[1])<<8 | uint16(d.buf[2]), nil
+}
+
+func (d *Device) write8(reg, v uint8) error {
+	d.buf[0], d.buf[1] = reg, v
+	return d.bus.Tx(d.Address, d.buf[:2], nil)
+}
+
+func (d *Device) write16(reg uint8, v uint16) error {
+	d.buf[0], d.buf[1], d.buf[2] = reg, byte(v>>8), byte(v)
+	return d.bus.Tx(d.Address, d.buf[:3], nil)
+}
@@ -0,0 +1,88 @@
+// Package ds18b20 reads Maxim DS18B20 temperature probes over 1-Wire.
+//
+// Probes are assumed externally powered (VDD wired), so a conversion can
+// run on every probe at once and the caller simply waits ConversionTime
+// before reading each scratchpad. Parasite power, which needs a strong
+// pull-up during the conversion, is not supported.
+package ds18b20
+
+import (
+	"errors"
+	"time"
+
+	"devicecode-go/x/onewire"
+)
+
+// Family is the ROM family code of the DS18B20.
+const Family = 0x28
+
+const (
+	cmdConvert      = 0x44
+	cmdReadScratch  = 0xBE
+	cmdWriteScratch = 0x4E
+
+	resetRaw = 0x0550 // 85 °C: power-on value before any conversion
+)
+
+var (
+	ErrAbsent       = errors.New("ds18b20: no response")
+	ErrCRC          = errors.New("ds18b20: scratchpad crc mismatch")
+	ErrNotConverted = errors.New("ds18b20: power-on value (no conversion)")
+)
+
+// Bus is the part of a 1-Wire bus the driver needs; core.OneWire
+// satisfies it.
+type Bus interface {
+	Tx(rom onewire.ROM, w, r []byte) error
+}
+
+// Resolution is the conversion resolution in bits (9..12).
+type Resolution uint8
+
+// ConversionTime is the datasheet maximum for res: 93.75 ms at 9 bits,
+// doubling per bit to 750 ms at 12.
+func ConversionTime(res Resolution) time.Duration {
+	if res < 9 || res > 12 {
+		res = 12
+	}
+	return 93750 * time.Microsecond << (res - 9)
+}
+
+// ConvertAll starts a conversion on every probe on the bus.
+func ConvertAll(b Bus) error {
+	return b.Tx(0, []byte{cmdConvert}, nil)
+}
+
+// SetResolution writes the configuration register of one probe (RAM
+// only; it reverts to the EEPROM value on power loss). The alarm bytes
+// are left at their defaults.
+func SetResolution(b Bus, rom onewire.ROM, res Resolution) error {
+	if res < 9 || res > 12 {
+		return errors.New("ds18b20: resolution out of range")
+	}
+	cfg := byte(res-9)<<5 | 0x1F
+	return b.Tx(rom, []byte{cmdWriteScratch, 0x4B, 0x46, cfg}, nil)
+}
+
+// Read returns the last conversion of one probe in m°C.
+func Read(b Bus, rom onewire.ROM) (int32, error) {
+	var sp [9]byte
+	if err := b.Tx(rom, []byte{cmdReadScratch}, sp[:]); err != nil {
+		return 0, err
+	}
+	all := byte(0xFF)
+	for _, c := range sp {
+		all &= c
+	}
+	if all == 0xFF {
+		return 0, ErrAbsent // nothing drove the wire
+	}
+	if onewire.CRC8(sp[:8]) != sp[8] {
+		return 0, ErrCRC
+	}
+	raw := int16(uint16(sp[1])<<8 | uint16(sp[0]))
+	if raw == resetRaw {
+		return 0, ErrNotConverted
+	}
+	return int32(raw) * 1000 / 16, nil
+}
@@ -0,0 +1,57 @@
+// Package envsensor is the interface shared by the temperature/humidity
+// drivers (aht20, shtc3, bme280), so that one HAL device serves every
+// variant. A measurement has two phases:
+//
+//	err := s.Trigger()          // start a conversion (a quick bus write)
+//	time.Sleep(s.TriggerHint()) // nominal conversion time
+//	r, err := s.Result()        // ErrNotReady while still converting
+//
+// Measure runs that cycle with bounded polling.
+package envsensor
+
+import (
+	"errors"
+	"time"
+)
+
+// Reading is one converted sample, in fixed point.
+type Reading struct {
+	DeciC  int32  // tenths of °C
+	RHx100 int32  // relative humidity, hundredths of %
+	Pa     uint32 // barometric pressure; 0 from sensors without one
+}
+
+// TempHumSensor is implemented by the temperature/humidity drivers.
+type TempHumSensor interface {
+	Trigger() error
+	TriggerHint() time.Duration
+	Result() (Reading, error)
+}
+
+var (
+	ErrNotReady = errors.New("envsensor: not ready")
+	ErrTimeout  = errors.New("envsensor: timeout")
+)
+
+// Measure triggers s, sleeps for its hint, then polls Result a quarter
+// hint apart (at least 1 ms) until a reading is ready or timeout has
+// passed since the trigger.
+func Measure(s TempHumSensor, timeout time.Duration) (Reading, error) {
+	if err := s.Trigger(); err != nil {
+		return Reading{}, err
+	}
+	start := time.Now()
+	hint := s.TriggerHint()
+	poll := max(hint/4, time.Millisecond)
+	time.Sleep(hint)
+	for {
+		r, err := s.Result()
+		if err != ErrNotReady {
+			return r, err
+		}
+		if time.Since(start) >= timeout {
+			return Reading{}, ErrTimeout
+		}
+		time.Sleep(poll)
+	}
+}
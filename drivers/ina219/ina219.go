@@ -0,0 +1,111 @@
+// Package ina219 provides a driver for the TI INA219 single-channel
+// shunt and bus voltage monitor.
+//
+// As with ina3221, only raw quantities are reported (shunt µV, bus mV);
+// the calibration and current registers are not used, so the shunt value
+// lives with the caller. The INA219 has no alert output.
+package ina219
+
+import (
+	"errors"
+
+	"tinygo.org/x/drivers"
+)
+
+// I2C address with A0 and A1 tied to GND (range 0x40..0x4F).
+const Address = 0x40
+
+const (
+	regConfig = 0x00
+	regShunt  = 0x01
+	regBus    = 0x02
+
+	cfgReset    = 1 << 15
+	cfgBRNG32   = 1 << 13
+	cfgModeCont = 0x7 // shunt and bus, continuous
+
+	busOVF = 1 << 0 // math overflow: shunt outside the PGA range
+
+	shuntLSB = 10 // µV
+	busLSB   = 4  // mV
+)
+
+// Errors returned by the driver.
+var ErrOverflow = errors.New("ina219: shunt voltage over range")
+
+// Gain selects the shunt full-scale range.
+type Gain uint8
+
+const (
+	Gain40mV  Gain = 0
+	Gain80mV  Gain = 1
+	Gain160mV Gain = 2
+	Gain320mV Gain = 3
+)
+
+// Config controls the conversion. The zero value is 16 V bus range,
+// ±40 mV shunt range and 12-bit conversions.
+type Config struct {
+	Bus32V bool
+	Gain   Gain
+	ADC    uint8 // BADC/SADC code applied to both; 0 means 0x3 (12-bit, 532 µs)
+}
+
+// Device wraps an I2C connection to an INA219.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+
+	buf [3]byte
+}
+
+// New creates a driver; it does not touch the bus.
+func New(bus drivers.I2C) Device {
+	return Device{bus: bus, Address: Address}
+}
+
+// Configure resets the chip and applies cfg.
+func (d *Device) Configure(cfg Config) error {
+	if err := d.write(regConfig, cfgReset); err != nil {
+		return err
+	}
+	adc := cfg.ADC & 0xF
+	if adc == 0 {
+		adc = 0x3
+	}
+	v := uint16(cfg.Gain&3)<<11 | uint16(adc)<<7 | uint16(adc)<<3 | cfgModeCont
+	if cfg.Bus32V {
+		v |= cfgBRNG32
+	}
+	return d.write(regConfig, v)
+}
+
+// Read returns the last conversion. ErrOverflow means the shunt voltage
+// exceeded the configured gain range.
+func (d *Device) Read() (shunt_uV, bus_mV int32, err error) {
+	s, err := d.read(regShunt)
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := d.read(regBus)
+	if err != nil {
+		return 0, 0, err
+	}
+	if b&busOVF != 0 {
+		return 0, 0, ErrOverflow
+	}
+	return int32(int16(s)) * shuntLSB, int32(b>>3) * busLSB, nil
+}
+
+func (d *Device) read(reg uint8) (uint16, error) {
+	d.buf[0] = reg
+	if err := d.bus.Tx(d.Address, d.buf[:1], d.buf[1:3]); err != nil {
+		return 0, err
+	}
+	return uint16(d.buf[1])<<8 | uint16(d.buf[2]), nil
+}
+
+func (d *Device) write(reg uint8, v uint16) error {
+	d.buf[0], d.buf[1], d.buf[2] = reg, byte(v>>8), byte(v)
+	return d.bus.Tx(d.Address, d.buf[:3], nil)
+}
@@ -0,0 +1,203 @@
+// Package ina3221 provides a driver for the TI INA3221 three-channel
+// shunt and bus voltage monitor.
+//
+// The driver reports raw electrical quantities only: shunt voltage in µV
+// and bus voltage in mV. Converting to current needs the shunt resistance,
+// which belongs to the board, not the chip.
+//
+// The chip has two open-drain alert outputs. CRITICAL asserts when any
+// single conversion of a channel's shunt voltage exceeds its critical
+// limit; WARNING asserts when the averaged value exceeds the warning limit.
+// With Config.Latch set both flags stay asserted until Flags() is read.
+package ina3221
+
+import (
+	"errors"
+
+	"tinygo.org/x/drivers"
+)
+
+// I2C address with A0 tied to GND (0x41 VS, 0x42 SDA, 0x43 SCL).
+const Address = 0x40
+
+const (
+	regConfig   = 0x00
+	regShunt1   = 0x01 // shunt n = 0x01 + 2(n-1), bus n = shunt n + 1
+	regCrit1    = 0x07 // critical n = 0x07 + 2(n-1), warning n = crit n + 1
+	regMask     = 0x0F
+	regManufID  = 0xFE
+	regDieID    = 0xFF
+	manufTI     = 0x5449
+	dieINA3221  = 0x3220
+	cfgReset    = 1 << 15
+	cfgModeCont = 0x7 // shunt and bus, continuous
+
+	maskWEN = 1 << 11 // warning latch enable
+	maskCEN = 1 << 10 // critical latch enable
+
+	shuntLSB = 40 // µV
+	busLSB   = 8  // mV
+)
+
+// Errors returned by the driver.
+var (
+	ErrChannel = errors.New("ina3221: channel out of range")
+	ErrID      = errors.New("ina3221: unexpected device id")
+	ErrLimit   = errors.New("ina3221: limit out of range")
+)
+
+// Averaging and conversion-time codes as in the CONFIG register.
+type (
+	Averaging uint8 // 0:1 1:4 2:16 3:64 4:128 5:256 6:512 7:1024 samples
+	ConvTime  uint8 // 0:140µs 1:204µs 2:332µs 3:588µs 4:1.1ms 5:2.1ms 6:4.2ms 7:8.2ms
+)
+
+// Config selects channels and conversion settings. The zero value enables
+// all three channels at the chip defaults (1 sample, 1.1 ms).
+type Config struct {
+	Channels  uint8 // bit n-1 enables channel n; 0 means all
+	Averaging Averaging
+	BusCT     ConvTime // 0 means 1.1 ms
+	ShuntCT   ConvTime // 0 means 1.1 ms
+	Latch     bool     // latch WARNING/CRITICAL until Flags() is read
+}
+
+// Flags are the alert flags of the mask/enable register, one bit per
+// channel (bit n-1 for channel n).
+type Flags struct {
+	Critical uint8
+	Warning  uint8
+}
+
+// Device wraps an I2C connection to an INA3221.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+
+	buf [3]byte
+}
+
+// New creates a driver; it does not touch the bus.
+func New(bus drivers.I2C) Device {
+	return Device{bus: bus, Address: Address}
+}
+
+// Configure resets the chip, checks its identity and applies cfg.
+func (d *Device) Configure(cfg Config) error {
+	if err := d.write(regConfig, cfgReset); err != nil {
+		return err
+	}
+	m, err := d.read(regManufID)
+	if err != nil {
+		return err
+	}
+	id, err := d.read(regDieID)
+	if err != nil {
+		return err
+	}
+	if m != manufTI || id != dieINA3221 {
+		return ErrID
+	}
+
+	ch := cfg.Channels & 0x7
+	if ch == 0 {
+		ch = 0x7
+	}
+	bct, sct := cfg.BusCT, cfg.ShuntCT
+	if bct == 0 {
+		bct = 4
+	}
+	if sct == 0 {
+		sct = 4
+	}
+	v := uint16(reverse3(ch))<<12 | uint16(cfg.Averaging&7)<<9 |
+		uint16(bct&7)<<6 | uint16(sct&7)<<3 | cfgModeCont
+	if err := d.write(regConfig, v); err != nil {
+		return err
+	}
+	var mask uint16
+	if cfg.Latch {
+		mask = maskWEN | maskCEN
+	}
+	return d.write(regMask, mask)
+}
+
+// reverse3 maps channel bits (bit 0 = ch1) to the CONFIG layout, where
+// bit 14 enables channel 1.
+func reverse3(b uint8) uint8 {
+	return (b&1)<<2 | b&2 | (b&4)>>2
+}
+
+// Read returns the last conversion of channel ch (1..3).
+func (d *Device) Read(ch int) (shunt_uV, bus_mV int32, err error) {
+	if ch < 1 || ch > 3 {
+		return 0, 0, ErrChannel
+	}
+	reg := uint8(regShunt1 + 2*(ch-1))
+	s, err := d.read(reg)
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := d.read(reg + 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int32(int16(s)>>3) * shuntLSB, int32(int16(b)>>3) * busLSB, nil
+}
+
+// SetLimits programs the critical and warning shunt-voltage limits of
+// channel ch in µV. A limit of 0 disables it (sets the register maximum).
+func (d *Device) SetLimits(ch int, warn_uV, crit_uV int32) error {
+	if ch < 1 || ch > 3 {
+		return ErrChannel
+	}
+	reg := uint8(regCrit1 + 2*(ch-1))
+	c, err := limitReg(crit_uV)
+	if err != nil {
+		return err
+	}
+	w, err := limitReg(warn_uV)
+	if err != nil {
+		return err
+	}
+	if err := d.write(reg, c); err != nil {
+		return err
+	}
+	return d.write(reg+1, w)
+}
+
+func limitReg(uV int32) (uint16, error) {
+	if uV == 0 {
+		return 0x7FF8, nil
+	}
+	n := uV / shuntLSB
+	if n < -4096 || n > 4095 {
+		return 0, ErrLimit
+	}
+	return uint16(int16(n) << 3), nil
+}
+
+// Flags reads (and, when latched, clears) the alert flags.
+func (d *Device) Flags() (Flags, error) {
+	v, err := d.read(regMask)
+	if err != nil {
+		return Flags{}, err
+	}
+	return Flags{
+		Critical: reverse3(uint8(v>>7) & 7),
+		Warning:  reverse3(uint8(v>>3) & 7),
+	}, nil
+}
+
+func (d *Device) read(reg uint8) (uint16, error) {
+	d.buf[0] = reg
+	if err := d.bus.Tx(d.Address, d.buf[:1], d.buf[1:3]); err != nil {
+		return 0, err
+	}
+	return uint16(d.buf[1])<<8 | uint16(d.buf[2]), nil
+}
+
+func (d *Device) write(reg uint8, v uint16) error {
+	d.buf[0], d.buf[1], d.buf[2] = reg, byte(v>>8), byte(v)
+	return d.bus.Tx(d.Address, d.buf[:3], nil)
+}
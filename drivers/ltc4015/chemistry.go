@@ -28,31 +28,12 @@ func (li Lithium) SetMaxCVTime_s(sec uint16) error {
 	return li.d.writeWord(regMaxCVTime, sec)
 }
 
-func (li Lithium) SetMaxChargeTime_s(sec uint16) error {
-	if err := li.d.ensureTargetsWritable(); err != nil {
-		return err
-	}
-	return li.d.writeWord(regMaxChargeTime, sec)
-}
+func (li Lithium) SetMaxChargeTime_s(sec uint16) error { return li.d.SetMaxChargeTime_s(sec) }
 
 // SetCOverXThreshold_mA configures the C/x comparator threshold using RSNSB scaling.
-func (li Lithium) SetCOverXThreshold_mA(mA int32) error {
-	if err := li.d.ensureTargetsWritable(); err != nil {
-		return err
-	}
-	if li.d.rsnsB_uOhm == 0 {
-		return ErrRSNSBUnset
-	}
-	code := li.d.currCode(mA, li.d.rsnsB_uOhm)
-	return li.d.writeWord(regCOverXThreshold, code)
-}
+func (li Lithium) SetCOverXThreshold_mA(mA int32) error { return li.d.SetCOverXThreshold_mA(mA) }
 
-func (li Lithium) EnableCOverXTermination(on bool) error {
-	if on {
-		return li.d.SetChargerConfigBits(EnCOverXTerm)
-	}
-	return li.d.ClearChargerConfigBits(EnCOverXTerm)
-}
+func (li Lithium) EnableCOverXTermination(on bool) error { return li.d.EnableCOverXTermination(on) }
 
 // JEITA configuration (raw NTC_RATIO thresholds).
 func (li Lithium) SetJEITAThresholdsRaw(t1, t2, t3, t4, t5, t6 uint16) error {
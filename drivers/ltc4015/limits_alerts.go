@@ -325,6 +325,16 @@ func (d *Device) SetVinUvcl_mV(mV int32) error {
 	return d.writeWord(regVinUvclSetting, code)
 }
 
+// Raw input parameter codes, to save a setting and restore it exactly.
+func (d *Device) IinLimitSettingCode() (uint16, error) { return d.readWord(regIinLimitSetting) }
+func (d *Device) VinUvclSettingCode() (uint16, error)  { return d.readWord(regVinUvclSetting) }
+func (d *Device) SetIinLimitSettingCode(code uint16) error {
+	return d.writeWord(regIinLimitSetting, code&0x3F)
+}
+func (d *Device) SetVinUvclSettingCode(code uint16) error {
+	return d.writeWord(regVinUvclSetting, code&0xFF)
+}
+
 // Charge parameter setting (programmable variants only).
 
 // ICHARGE_TARGET: (code+1)*1 mV across RSNSB, 0..31.
@@ -98,6 +98,19 @@ func (d *Device) BSR_uOhmPerCell() (uint32, error) {
 	return uint32((int64(raw) * int64(d.rsnsB_uOhm)) / div), nil
 }
 
+// StartBSR requests a BSR measurement. The charger pauses charging briefly
+// to take it, so it only runs while charging; RUN_BSR clears when done.
+func (d *Device) StartBSR() error { return d.SetConfigBits(RunBSR) }
+
+// BSRRunning reports whether a requested BSR measurement is still pending.
+func (d *Device) BSRRunning() (bool, error) {
+	v, err := d.ReadConfig()
+	if err != nil {
+		return false, err
+	}
+	return v&RunBSR != 0, nil
+}
+
 // Measurement validity
 
 func (d *Device) MeasSystemValid() (bool, error) {
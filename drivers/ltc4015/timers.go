@@ -0,0 +1,56 @@
+package ltc4015
+
+// Charge termination: the charge timers and the C/x comparator.
+//
+// MAX_CHARGE_TIME bounds a whole charge cycle on every chemistry. The
+// phase timers end a phase rather than the cycle: MAX_CV_TIME the lithium
+// CV phase, MAX_ABSORB_TIME the LiFePO4 and lead-acid absorb phase (see
+// the chemistry views). The part has no enable for timer termination: a
+// phase always ends when its timer runs out, and C/x termination, when
+// enabled, can end it sooner. All timer and threshold writes need
+// programmable targets.
+
+// SetMaxChargeTime_s sets MAX_CHARGE_TIME (s).
+func (d *Device) SetMaxChargeTime_s(sec uint16) error {
+	if err := d.ensureTargetsWritable(); err != nil {
+		return err
+	}
+	return d.writeWord(regMaxChargeTime, sec)
+}
+
+// MaxChargeTime_s reads MAX_CHARGE_TIME (s).
+func (d *Device) MaxChargeTime_s() (uint16, error) {
+	return d.readWord(regMaxChargeTime)
+}
+
+// SetCOverXThreshold_mA sets the C/x comparator threshold (RSNSB scaling).
+func (d *Device) SetCOverXThreshold_mA(mA int32) error {
+	if err := d.ensureTargetsWritable(); err != nil {
+		return err
+	}
+	if d.rsnsB_uOhm == 0 {
+		return ErrRSNSBUnset
+	}
+	return d.writeWord(regCOverXThreshold, d.currCode(mA, d.rsnsB_uOhm))
+}
+
+// COverXThreshold_mA reads back the C/x comparator threshold.
+func (d *Device) COverXThreshold_mA() (int32, error) {
+	if d.rsnsB_uOhm == 0 {
+		return 0, ErrRSNSBUnset
+	}
+	raw, err := d.readWord(regCOverXThreshold)
+	if err != nil {
+		return 0, err
+	}
+	uA := (int64(raw) * 1464870) / int64(d.rsnsB_uOhm)
+	return int32(uA / 1000), nil
+}
+
+// EnableCOverXTermination toggles en_c_over_x_term in CHARGER_CONFIG_BITS.
+func (d *Device) EnableCOverXTermination(on bool) error {
+	if on {
+		return d.SetChargerConfigBits(EnCOverXTerm)
+	}
+	return d.ClearChargerConfigBits(EnCOverXTerm)
+}
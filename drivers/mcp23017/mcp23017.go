@@ -0,0 +1,103 @@
+// Package mcp23017 provides a driver for the Microchip MCP23017 16-bit
+// I2C GPIO expander.
+//
+// Pins are numbered 0..15: GPA0..GPA7 then GPB0..GPB7. The chip is used
+// in its power-on register layout (IOCON.BANK = 0), where each A register
+// is followed by its B twin, so every 16-bit value is one little-endian
+// transfer.
+package mcp23017
+
+import (
+	"tinygo.org/x/drivers"
+)
+
+// Address with A0..A2 tied to GND (range 0x20..0x27).
+const Address = 0x20
+
+const (
+	regIODIR   = 0x00 // 1 = input
+	regGPINTEN = 0x04
+	regINTCON  = 0x08 // 0 = interrupt on any change
+	regIOCON   = 0x0A
+	regGPPU    = 0x0C
+	regGPIO    = 0x12
+	regOLAT    = 0x14
+
+	ioconMirror = 1 << 6 // INTA and INTB both report either port
+	ioconODR    = 1 << 2 // INT outputs open-drain
+)
+
+// Config is applied as a whole by Configure. Bit n is pin n.
+type Config struct {
+	Input  uint16 // 1 = input, 0 = output
+	Pull   uint16 // 100 kΩ pull-up on inputs
+	Change uint16 // inputs that assert INT on any change
+	Output uint16 // initial output latch, written before the directions
+}
+
+// Device wraps an I2C connection to an MCP23017.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+
+	buf [3]byte
+}
+
+// New creates a driver; it does not touch the bus.
+func New(bus drivers.I2C) Device {
+	return Device{bus: bus, Address: Address}
+}
+
+// Configure applies cfg. INTA/INTB are mirrored and open-drain (active
+// low), so either may be wired, or both to one pull-up. Outputs get their
+// level before they are enabled, so a rail enable does not glitch.
+func (d *Device) Configure(cfg Config) error {
+	if err := d.write8(regIOCON, ioconMirror|ioconODR); err != nil {
+		return err
+	}
+	if err := d.write16(regOLAT, cfg.Output); err != nil {
+		return err
+	}
+	if err := d.write16(regGPPU, cfg.Pull); err != nil {
+		return err
+	}
+	if err := d.write16(regIODIR, cfg.Input); err != nil {
+		return err
+	}
+	if err := d.write16(regINTCON, 0); err != nil {
+		return err
+	}
+	if err := d.write16(regGPINTEN, cfg.Change&cfg.Input); err != nil {
+		return err
+	}
+	_, err := d.Read() // clear any pending interrupt
+	return err
+}
+
+// Read returns the level of every pin. It also clears a pending interrupt.
+func (d *Device) Read() (uint16, error) {
+	return d.read16(regGPIO)
+}
+
+// Write sets the output latch; input pins ignore it.
+func (d *Device) Write(v uint16) error {
+	return d.write16(regOLAT, v)
+}
+
+func (d *Device) read16(reg byte) (uint16, error) {
+	d.buf[0] = reg
+	if err := d.bus.Tx(d.Address, d.buf[:1], d.buf[1:3]); err != nil {
+		return 0, err
+	}
+	return uint16(d.buf[1]) | uint16(d.buf[2])<<8, nil
+}
+
+func (d *Device) write8(reg, v byte) error {
+	d.buf[0], d.buf[1] = reg, v
+	return d.bus.Tx(d.Address, d.buf[:2], nil)
+}
+
+func (d *Device) write16(reg byte, v uint16) error {
+	d.buf[0], d.buf[1], d.buf[2] = reg, byte(v), byte(v>>8)
+	return d.bus.Tx(d.Address, d.buf[:3], nil)
+}
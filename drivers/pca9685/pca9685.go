@@ -0,0 +1,144 @@
+// Package pca9685 provides a driver for the NXP PCA9685 16-channel,
+// 12-bit PWM controller.
+//
+// All sixteen outputs share one prescaler, so the frequency is a property
+// of the chip. Each output is driven with its ON edge at count 0 and its
+// OFF edge at the duty; 0 and Full use the full-off/full-on bits so the
+// extremes are glitch-free.
+package pca9685
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+// Address with A0..A5 tied to GND (range 0x40..0x7F; 0x70 is All Call).
+const Address = 0x40
+
+// Full is the duty for a permanently high output; duties run 0..Full.
+const Full = 4096
+
+const (
+	regMode1    = 0x00
+	regMode2    = 0x01
+	regLED0     = 0x06 // LEDn_ON_L; four registers per output
+	regPrescale = 0xFE
+
+	mode1Restart = 1 << 7
+	mode1AI      = 1 << 5 // register auto-increment
+	mode1Sleep   = 1 << 4
+
+	mode2Invert = 1 << 4
+	mode2OutDrv = 1 << 2 // totem-pole; open-drain when clear
+
+	bitFull = 1 << 4 // in LEDn_ON_H / LEDn_OFF_H
+
+	oscHz = 25_000_000
+
+	// Prescale limits give 24..1526 Hz with the internal oscillator.
+	MinFreqHz = 24
+	MaxFreqHz = 1526
+)
+
+var ErrRange = errors.New("pca9685: value out of range")
+
+// Config selects the output stage. The zero value is totem-pole, not inverted.
+type Config struct {
+	OpenDrain bool
+	Invert    bool
+}
+
+// Device wraps an I2C connection to a PCA9685.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+
+	buf [1 + 4*16]byte
+}
+
+// New creates a driver; it does not touch the bus.
+func New(bus drivers.I2C) Device {
+	return Device{bus: bus, Address: Address}
+}
+
+// Configure sets the output stage and the frequency, leaving the
+// oscillator running with auto-increment enabled (All Call is disabled).
+// Outputs keep their previous duty (all off after power-on).
+func (d *Device) Configure(cfg Config, freqHz uint32) error {
+	m2 := byte(mode2OutDrv)
+	if cfg.OpenDrain {
+		m2 = 0
+	}
+	if cfg.Invert {
+		m2 |= mode2Invert
+	}
+	if err := d.write(regMode2, m2); err != nil {
+		return err
+	}
+	return d.SetFreq(freqHz)
+}
+
+// Prescale returns the prescaler value for freqHz.
+func Prescale(freqHz uint32) (byte, error) {
+	if freqHz < MinFreqHz || freqHz > MaxFreqHz {
+		return 0, ErrRange
+	}
+	// round(osc / (4096 × f)) − 1
+	return byte((oscHz+2048*freqHz)/(4096*freqHz) - 1), nil
+}
+
+// SetFreq changes the shared output frequency. The prescaler can only be
+// written while the oscillator sleeps, so every output pauses briefly.
+func (d *Device) SetFreq(freqHz uint32) error {
+	pre, err := Prescale(freqHz)
+	if err != nil {
+		return err
+	}
+	if err := d.write(regMode1, mode1AI|mode1Sleep); err != nil {
+		return err
+	}
+	if err := d.write(regPrescale, pre); err != nil {
+		return err
+	}
+	if err := d.write(regMode1, mode1AI); err != nil {
+		return err
+	}
+	// The oscillator needs 500 µs to start; RESTART resumes the outputs
+	// and is only valid once it has.
+	time.Sleep(time.Millisecond)
+	return d.write(regMode1, mode1AI|mode1Restart)
+}
+
+// Set writes the duty (0..Full) of output ch (0..15).
+func (d *Device) Set(ch int, duty uint16) error {
+	return d.SetRange(ch, []uint16{duty})
+}
+
+// SetRange writes consecutive outputs starting at ch in one transfer.
+func (d *Device) SetRange(ch int, duty []uint16) error {
+	if ch < 0 || ch+len(duty) > 16 || len(duty) == 0 {
+		return ErrRange
+	}
+	b := d.buf[:1+4*len(duty)]
+	b[0] = byte(regLED0 + 4*ch)
+	for i, v := range duty {
+		on, off := uint16(0), v
+		switch {
+		case v == 0:
+			off = bitFull << 8
+		case v >= Full:
+			on, off = bitFull<<8, 0
+		}
+		o := 1 + 4*i
+		b[o], b[o+1] = byte(on), byte(on>>8)
+		b[o+2], b[o+3] = byte(off), byte(off>>8)
+	}
+	return d.bus.Tx(d.Address, b, nil)
+}
+
+func (d *Device) write(reg, v byte) error {
+	d.buf[0], d.buf[1] = reg, v
+	return d.bus.Tx(d.Address, d.buf[:2], nil)
+}
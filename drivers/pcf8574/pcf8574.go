@@ -0,0 +1,43 @@
+// Package pcf8574 provides a driver for the NXP/TI PCF8574 8-bit
+// quasi-bidirectional I2C GPIO expander.
+//
+// The chip has no registers: a write sets the eight port latches and a
+// read returns the pin levels. A latch of 1 is a weak pull-up, which makes
+// the pin usable as an input; a latch of 0 sinks current. INT (open-drain,
+// active low) asserts on any input change and clears on the next read.
+package pcf8574
+
+import (
+	"tinygo.org/x/drivers"
+)
+
+// Address with A0..A2 tied to GND (range 0x20..0x27; the PCF8574A uses
+// 0x38..0x3F).
+const Address = 0x20
+
+// Device wraps an I2C connection to a PCF8574.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+
+	buf [1]byte
+}
+
+// New creates a driver; it does not touch the bus.
+func New(bus drivers.I2C) Device {
+	return Device{bus: bus, Address: Address}
+}
+
+// Read returns the pin levels and clears a pending interrupt.
+func (d *Device) Read() (uint8, error) {
+	if err := d.bus.Tx(d.Address, nil, d.buf[:]); err != nil {
+		return 0, err
+	}
+	return d.buf[0], nil
+}
+
+// Write sets the port latches. Input pins must be written as 1.
+func (d *Device) Write(v uint8) error {
+	d.buf[0] = v
+	return d.bus.Tx(d.Address, d.buf[:], nil)
+}
@@ -0,0 +1,102 @@
+// Package shtc3 provides a driver for the Sensirion SHTC3
+// temperature/humidity sensor, implementing envsensor.TempHumSensor.
+//
+// Conversions use the normal-power mode without clock stretching, so the
+// bus is free while the sensor converts: Trigger wakes the sensor and
+// starts a conversion, and Result reads it and puts the sensor back to
+// sleep. The sensor NACKs reads until the conversion is done, which the
+// bus reports as an error like any other, so Result returns
+// envsensor.ErrNotReady for any failed read and a missing sensor shows as
+// a timeout from envsensor.Measure.
+//
+// Datasheet: Sensirion SHTC3, version 4 (2019).
+package shtc3
+
+import (
+	"errors"
+	"time"
+
+	"devicecode-go/drivers/envsensor"
+
+	"tinygo.org/x/drivers"
+)
+
+// I2C address (fixed).
+const Address = 0x70
+
+// Commands (16-bit, MSB first).
+const (
+	cmdWakeup  = 0x3517
+	cmdSleep   = 0xB098
+	cmdMeasure = 0x7866 // normal mode, temperature first, no clock stretching
+)
+
+const (
+	wakeupTime = 240 * time.Microsecond // max, datasheet table 5
+	convTime   = 13 * time.Millisecond  // normal mode max 12.1 ms
+)
+
+var ErrCRC = errors.New("shtc3: crc mismatch")
+
+// Device wraps an I2C connection to an SHTC3.
+type Device struct {
+	bus drivers.I2C
+	w   [2]byte
+	r   [6]byte
+}
+
+// New creates a Device. It does not touch the bus.
+func New(bus drivers.I2C) Device {
+	return Device{bus: bus}
+}
+
+func (d *Device) cmd(c uint16) error {
+	d.w[0], d.w[1] = byte(c>>8), byte(c)
+	return d.bus.Tx(Address, d.w[:], nil)
+}
+
+// Trigger wakes the sensor and starts a conversion.
+func (d *Device) Trigger() error {
+	if err := d.cmd(cmdWakeup); err != nil {
+		return err
+	}
+	time.Sleep(wakeupTime)
+	return d.cmd(cmdMeasure)
+}
+
+// TriggerHint returns the maximum conversion time.
+func (d *Device) TriggerHint() time.Duration { return convTime }
+
+// Result reads the conversion started by Trigger.
+func (d *Device) Result() (envsensor.Reading, error) {
+	if err := d.bus.Tx(Address, nil, d.r[:]); err != nil {
+		return envsensor.Reading{}, envsensor.ErrNotReady
+	}
+	_ = d.cmd(cmdSleep)
+	if crc8(d.r[0:2]) != d.r[2] || crc8(d.r[3:5]) != d.r[5] {
+		return envsensor.Reading{}, ErrCRC
+	}
+	t := int64(d.r[0])<<8 | int64(d.r[1])
+	h := int64(d.r[3])<<8 | int64(d.r[4])
+	// T = -45 + 175·raw/2^16 °C; RH = 100·raw/2^16 %.
+	return envsensor.Reading{
+		DeciC:  int32(-450 + (1750*t)>>16),
+		RHx100: int32((10000 * h) >> 16),
+	}, nil
+}
+
+// crc8 is the Sensirion CRC: polynomial 0x31, initial value 0xFF.
+func crc8(p []byte) byte {
+	crc := byte(0xFF)
+	for _, b := range p {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x31
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
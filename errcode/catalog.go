@@ -0,0 +1,80 @@
+package errcode
+
+// Num is a code's stable wire number, for transports (the bridge) where
+// clients should not parse strings. Numbers are never reused or changed:
+// a new code takes the next free number, a retired one keeps its slot.
+type Num uint16
+
+// catalogue is the one table mapping names to numbers.
+var catalogue = [...]struct {
+	n Num
+	c Code
+}{
+	{0, OK},
+	{1, Error},
+	{2, Busy},
+	{3, Unsupported},
+	{4, InvalidParams},
+	{5, InvalidPayload},
+	{6, UnknownCapability},
+	{7, HALNotReady},
+	{8, InvalidTopic},
+	{9, Conflict},
+	{10, Interlocked},
+	{11, UnknownBus},
+	{12, BusInUse},
+	{13, UnknownPin},
+	{14, PinInUse},
+	{15, PinFunc},
+	{16, Timeout},
+	{17, Unavailable},
+	{18, SubscribeRefused},
+	{19, PublishRefused},
+	{20, CRCMismatch},
+	{21, Quarantined},
+}
+
+// Num returns c's wire number. Codes outside the catalogue (free-form
+// status strings) encode as Error; send the name alongside for those.
+func (c Code) Num() Num {
+	n, _ := NumOf(c)
+	return n
+}
+
+// NumOf returns c's wire number and whether c is catalogued.
+func NumOf(c Code) (Num, bool) {
+	for _, e := range catalogue {
+		if e.c == c {
+			return e.n, true
+		}
+	}
+	return 1, false
+}
+
+// FromNum returns the code numbered n, or Error if n is unknown (e.g. from
+// a newer peer).
+func FromNum(n Num) Code {
+	for _, e := range catalogue {
+		if e.n == n {
+			return e.c
+		}
+	}
+	return Error
+}
+
+// Entry is one catalogue row.
+type Entry struct {
+	Num       Num  `json:"num"`
+	Code      Code `json:"code"`
+	Retryable bool `json:"retryable"`
+}
+
+// Catalogue lists every code in number order, e.g. for a host to build its
+// own table.
+func Catalogue() []Entry {
+	out := make([]Entry, len(catalogue))
+	for i, e := range catalogue {
+		out[i] = Entry{Num: e.n, Code: e.c, Retryable: Retryable(e.c)}
+	}
+	return out
+}
@@ -6,7 +6,8 @@ type Code string
 
 func (c Code) Error() string { return string(c) }
 
-// Canonical codes (short, stable).
+// Canonical codes (short, stable). Each also has a wire number; see
+// catalog.go, which must list every code added here.
 const (
 	OK                Code = "ok"
 	Busy              Code = "busy"
@@ -17,23 +18,33 @@ const (
 	HALNotReady       Code = "hal_not_ready"
 	InvalidTopic      Code = "invalid_topic"
 	Conflict          Code = "config_conflict"
+	Interlocked       Code = "interlocked" // refused by an interlock group
 
 	UnknownBus  Code = "unknown_bus"
 	BusInUse    Code = "bus_in_use"
 	UnknownPin  Code = "unknown_pin"
 	PinInUse    Code = "pin_in_use"
+	PinFunc     Code = "pin_func_unsupported" // pin mux cannot route the function
 	Timeout     Code = "timeout"
 	Unavailable Code = "unavailable"
+	CRCMismatch Code = "crc_mismatch" // bus-level checksum (e.g. SMBus PEC) failed
+	Quarantined Code = "quarantined"  // controls refused after repeated malformed payloads
+
+	// Bridge link refusals (limits, malformed topics).
+	SubscribeRefused Code = "subscribe_refused"
+	PublishRefused   Code = "publish_refused"
 
 	Error Code = "error" // generic fallback
 )
 
 // Optional wrapper when we want to keep context and a cause.
+// Msg and Field are surfaced to remote callers in error replies.
 type E struct {
-	C   Code
-	Op  string
-	Msg string
-	Err error
+	C     Code
+	Op    string
+	Msg   string
+	Field string // offending payload/param field, if any
+	Err   error
 }
 
 func (e *E) Error() string {
@@ -60,6 +71,24 @@ func Of(err error) Code {
 	return Error
 }
 
+// Retryable reports whether the same request may succeed if repeated later
+// without modification (transient contention or readiness conditions).
+func Retryable(c Code) bool {
+	switch c {
+	case Busy, Timeout, Unavailable, HALNotReady, CRCMismatch:
+		return true
+	}
+	return false
+}
+
+// DetailOf extracts the human detail and offending field from err, if any.
+func DetailOf(err error) (detail, field string) {
+	if e, ok := err.(*E); ok && e != nil {
+		return e.Msg, e.Field
+	}
+	return "", ""
+}
+
 // MapDriverErr maps low-level driver errors to a Code.
 // Extend the heuristics per platform/driver.
 func MapDriverErr(err error) Code {
@@ -0,0 +1,413 @@
+// Package reactorfsm is the reactor's rail supervisor: input freshness,
+// the over-temp and VBAT latches, the PG debounce and the rail up/down
+// sequencing, with time taken from an injected clock and commands going
+// out through Out. It does no I/O of its own, so scenarios can be driven
+// step by step on a clock.Fake.
+//
+// Not safe for concurrent use; the reactor owns it from one goroutine.
+package reactorfsm
+
+import (
+	"time"
+
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+// State is the rails state.
+type State int
+
+const (
+	Off State = iota
+	UpSeq
+	On
+	DownSeq
+)
+
+func (s State) String() string {
+	switch s {
+	case Off:
+		return "off"
+	case UpSeq:
+		return "up_seq"
+	case On:
+		return "on"
+	case DownSeq:
+		return "down_seq"
+	}
+	return "?"
+}
+
+// TempSource is one of the temperatures voted by the over-temp latch.
+type TempSource int
+
+const (
+	TempCore    TempSource = iota // board sensor: TempLimit/TempHyst
+	TempCharger                   // charger NTC: ChgTempLimit/ChgTempHyst
+	numTempSources
+)
+
+func (s TempSource) String() string {
+	switch s {
+	case TempCore:
+		return "core"
+	case TempCharger:
+		return "charger"
+	}
+	return "?"
+}
+
+// RailStep is one rail in bring-up order. GapBefore is enforced before
+// the rail is operated in either direction; the first rail of a sequence
+// goes at once.
+type RailStep struct {
+	Name      string
+	GapBefore time.Duration
+}
+
+// Cause says why the rails are being cut, in the order they are checked.
+type Cause int
+
+const (
+	CutNone Cause = iota
+	CutTempStale
+	CutBrownout
+	CutOverTemp
+	CutUser
+)
+
+func (c Cause) String() string {
+	switch c {
+	case CutNone:
+		return "none"
+	case CutTempStale:
+		return "temp_stale"
+	case CutBrownout:
+		return "brownout"
+	case CutOverTemp:
+		return "over_temp"
+	case CutUser:
+		return "user"
+	}
+	return "?"
+}
+
+// Out receives the FSM's decisions. Calls are made from inside Step (or
+// the input method that triggered them) and must not call back in.
+type Out interface {
+	// Switch commands one rail.
+	Switch(name string, on bool)
+	// Transition reports a state change; UpSeq is reported before its
+	// first Switch.
+	Transition(from, to State)
+	// OverTemp reports an edge of the over-temp latch; src is the source
+	// that set it.
+	OverTemp(active bool, src TempSource)
+	// Cut reports why a down sequence starts from UpSeq or On (not for
+	// EarlyDown, whose caller already knows).
+	Cut(c Cause)
+	// Reverse reports a down sequence turned back up.
+	Reverse()
+}
+
+// FSM is the rail supervisor.
+type FSM struct {
+	clk clock.Clock
+	cfg types.ReactorConfig
+	seq []RailStep
+	out Out
+
+	// inputs (latest)
+	vin, vbat     int32
+	temp          [numTempSources]int
+	atVIN, atVBAT time.Time
+	atTemp        [numTempSources]time.Time
+
+	// latches
+	vbatGood bool       // VBAT hysteresis
+	otActive bool       // over-temp: forces down until recovered
+	otSource TempSource // the source that set otActive
+	userOff  bool       // manual: forces down until released
+	held     bool       // telemetry roll-up degraded: holds bring-up
+
+	// PG + temperature debounce
+	pgSince  time.Time
+	pgStable bool
+
+	// sequencing
+	state         State
+	seqIdx        int // index into seq of the next rail to operate
+	seqOnCount    int // rails currently on
+	nextActionDue time.Time
+}
+
+// New returns an FSM in Off that brings up seq in order. clk nil means
+// clock.Real.
+func New(clk clock.Clock, cfg types.ReactorConfig, seq []RailStep, out Out) *FSM {
+	return &FSM{clk: clock.Or(clk), cfg: cfg, seq: seq, out: out}
+}
+
+// SetConfig replaces the thresholds; the caller validates them.
+func (f *FSM) SetConfig(cfg types.ReactorConfig) { f.cfg = cfg }
+
+// ---- inputs: each is stamped with the clock's now ----
+
+func (f *FSM) VIN(mV int32)  { f.vin, f.atVIN = mV, f.clk.Now() }
+func (f *FSM) VBAT(mV int32) { f.vbat, f.atVBAT = mV, f.clk.Now() }
+
+// Temp records a reading from src; each source keeps its own value and
+// freshness.
+func (f *FSM) Temp(src TempSource, deciC int) {
+	f.temp[src], f.atTemp[src] = deciC, f.clk.Now()
+}
+
+// TempAt is when the last reading from src arrived (zero if none has).
+func (f *FSM) TempAt(src TempSource) time.Time { return f.atTemp[src] }
+
+// SetUserOff sets the manual rails-off latch; Step acts on it.
+func (f *FSM) SetUserOff(off bool) { f.userOff = off }
+func (f *FSM) UserOff() bool       { return f.userOff }
+
+// SetHeld holds bring-up (and the reversal of a down sequence) while the
+// reactor's telemetry roll-up is degraded. Rails already up stay up; the
+// freshness checks above still cut them.
+func (f *FSM) SetHeld(held bool) { f.held = held }
+func (f *FSM) Held() bool        { return f.held }
+
+func (f *FSM) State() State { return f.state }
+
+// Debouncing reports a PG debounce in progress (inputs good, not yet
+// stable), which the reactor treats as activity.
+func (f *FSM) Debouncing() bool { return !f.pgSince.IsZero() }
+
+// ---- freshness and decisions ----
+
+func (f *FSM) staleMax() time.Duration {
+	return time.Duration(f.cfg.StaleMax_ms) * time.Millisecond
+}
+
+func (f *FSM) debounceOK() time.Duration {
+	return time.Duration(f.cfg.DebounceOK_ms) * time.Millisecond
+}
+
+func (f *FSM) fresh(at, now time.Time) bool {
+	return !at.IsZero() && now.Sub(at) <= f.staleMax()
+}
+
+func (f *FSM) supplyPG(now time.Time) bool {
+	// VIN fresh ≥ PGOnVIN, or the VBAT hysteresis latch.
+	return (f.fresh(f.atVIN, now) && f.vin >= f.cfg.PGOnVIN_mV) || f.vbatGood
+}
+
+// tempLimit is src's latch limit and hysteresis; ok is false for a
+// source left out of the vote.
+func (f *FSM) tempLimit(src TempSource) (limit, hyst int, ok bool) {
+	switch src {
+	case TempCore:
+		return int(f.cfg.TempLimit_deciC), int(f.cfg.TempHyst_deciC), true
+	case TempCharger:
+		c := f.cfg.ChgTempLimit_deciC
+		return int(c), int(f.cfg.ChgTempHyst_deciC), c != 0
+	}
+	return 0, 0, false
+}
+
+// tempVote polls the sources: over is the first fresh one at or over its
+// limit (-1 if none); clear is whether every one is fresh and at or below
+// limit-hyst.
+func (f *FSM) tempVote(now time.Time) (over TempSource, clear bool) {
+	over, clear = -1, true
+	for src := range numTempSources {
+		limit, hyst, ok := f.tempLimit(src)
+		if !ok {
+			continue
+		}
+		fresh := f.fresh(f.atTemp[src], now)
+		if fresh && f.temp[src] >= limit && over < 0 {
+			over = src
+		}
+		if !fresh || f.temp[src] > limit-hyst {
+			clear = false
+		}
+	}
+	return over, clear
+}
+
+func (f *FSM) tempOKForTurnOn(now time.Time) bool {
+	_, clear := f.tempVote(now)
+	return clear
+}
+
+// MustCut reports why the rails must come down now, or CutNone.
+func (f *FSM) MustCut() Cause {
+	now := f.clk.Now()
+	if !f.fresh(f.atTemp[TempCore], now) {
+		return CutTempStale
+	}
+	vinOK := f.fresh(f.atVIN, now) && f.vin >= f.cfg.SagVIN_mV
+	vbatOK := f.fresh(f.atVBAT, now) && f.vbat >= f.cfg.SagVBAT_mV
+	switch {
+	case !(vinOK || vbatOK):
+		return CutBrownout
+	case f.otActive:
+		return CutOverTemp
+	case f.userOff:
+		return CutUser
+	}
+	return CutNone
+}
+
+func (f *FSM) updateLatches(now time.Time) {
+	// Either source over sets the latch; only both fresh and below
+	// hysteresis clears it.
+	over, clear := f.tempVote(now)
+	if over >= 0 && !f.otActive {
+		f.otActive, f.otSource = true, over
+		f.out.OverTemp(true, over)
+	} else if clear && f.otActive {
+		f.otActive = false
+		f.out.OverTemp(false, f.otSource)
+	}
+	if f.fresh(f.atVBAT, now) {
+		if !f.vbatGood && f.vbat >= f.cfg.PGOnVBAT_mV {
+			f.vbatGood = true
+		} else if f.vbatGood && f.vbat < f.cfg.PGOnVBAT_mV-f.cfg.PGOffHyst_mV {
+			f.vbatGood = false
+		}
+	} else {
+		f.vbatGood = false
+	}
+}
+
+// ---- sequencing ----
+
+func (f *FSM) setState(s State) {
+	if f.state != s {
+		from := f.state
+		f.state = s
+		f.out.Transition(from, s)
+	}
+}
+
+func (f *FSM) startUp(now time.Time) {
+	f.setState(UpSeq)
+	f.seqIdx = 0
+	f.nextActionDue = now // first step fires at once
+}
+
+// startDown also restarts the PG debounce: a reversal needs the inputs
+// stably good from here, not from before the cut.
+func (f *FSM) startDown(now time.Time) {
+	f.pgSince, f.pgStable = time.Time{}, false
+	f.setState(DownSeq)
+	f.seqOnCount = min(max(f.seqOnCount, 0), len(f.seq))
+	f.seqIdx = f.seqOnCount - 1 // last rail on goes first
+	f.nextActionDue = now
+}
+
+// EarlyDown starts the down sequence ahead of MustCut when VIN is about
+// to collapse and the battery cannot carry the load: rails up or coming
+// up, and VBAT stale or below sag. It reports whether it acted.
+func (f *FSM) EarlyDown() bool {
+	if f.state != UpSeq && f.state != On {
+		return false
+	}
+	now := f.clk.Now()
+	if f.fresh(f.atVBAT, now) && f.vbat >= f.cfg.SagVBAT_mV {
+		return false
+	}
+	f.startDown(now)
+	return true
+}
+
+func (f *FSM) advance(now time.Time) {
+	if f.state != UpSeq && f.state != DownSeq || now.Before(f.nextActionDue) {
+		return
+	}
+	switch f.state {
+	case UpSeq:
+		if f.seqIdx >= len(f.seq) {
+			f.seqOnCount = len(f.seq)
+			f.setState(On)
+			return
+		}
+		f.out.Switch(f.seq[f.seqIdx].Name, true)
+		f.seqOnCount++
+		f.seqIdx++
+		if f.seqIdx < len(f.seq) {
+			f.nextActionDue = now.Add(f.seq[f.seqIdx].GapBefore)
+		}
+	case DownSeq:
+		if f.seqIdx < 0 {
+			f.seqOnCount = 0
+			f.setState(Off)
+			return
+		}
+		f.out.Switch(f.seq[f.seqIdx].Name, false)
+		f.seqOnCount--
+		f.seqIdx--
+		if f.seqIdx >= 0 {
+			f.nextActionDue = now.Add(f.seq[f.seqIdx].GapBefore)
+		}
+	}
+}
+
+// Step updates the latches, takes any transition due (with reversal of a
+// down sequence once inputs are stably good) and operates the next rail
+// if its gap has passed.
+func (f *FSM) Step() {
+	now := f.clk.Now()
+	f.updateLatches(now)
+
+	switch f.state {
+	case Off, DownSeq:
+		if !f.otActive && !f.userOff && !f.held && f.supplyPG(now) && f.tempOKForTurnOn(now) {
+			if f.pgSince.IsZero() {
+				f.pgSince, f.pgStable = now, false
+			} else if !f.pgStable && now.Sub(f.pgSince) >= f.debounceOK() {
+				f.pgStable = true
+			}
+		} else {
+			f.pgSince, f.pgStable = time.Time{}, false
+		}
+		if f.pgStable {
+			if f.state == DownSeq {
+				f.out.Reverse()
+			}
+			f.startUp(now)
+		}
+	case UpSeq, On:
+		if c := f.MustCut(); c != CutNone {
+			f.out.Cut(c)
+			f.startDown(now)
+		}
+	}
+	f.advance(now)
+}
+
+// NextDue is the earliest time Step has time-based work: the end of a PG
+// debounce, the next rail, or an input going stale. Zero if none.
+func (f *FSM) NextDue() time.Time {
+	var due time.Time
+	earlier := func(t time.Time) {
+		if !t.IsZero() && (due.IsZero() || t.Before(due)) {
+			due = t
+		}
+	}
+	now := f.clk.Now()
+	if (f.state == Off || f.state == DownSeq) && !f.pgSince.IsZero() && !f.pgStable {
+		earlier(f.pgSince.Add(f.debounceOK()))
+	}
+	if f.state == UpSeq || f.state == DownSeq {
+		earlier(f.nextActionDue)
+	}
+	// Freshness flips just after at+StaleMax.
+	for _, at := range [...]time.Time{f.atVIN, f.atVBAT, f.atTemp[TempCore], f.atTemp[TempCharger]} {
+		if !at.IsZero() {
+			if exp := at.Add(f.staleMax() + time.Millisecond); exp.After(now) {
+				earlier(exp)
+			}
+		}
+	}
+	return due
+}
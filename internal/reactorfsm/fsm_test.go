@@ -0,0 +1,278 @@
+package reactorfsm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var testCfg = types.ReactorConfig{
+	TempLimit_deciC: 780, TempHyst_deciC: 60,
+	PGOnVIN_mV: 12000, SagVIN_mV: 10600,
+	PGOnVBAT_mV: 12400, PGOffHyst_mV: 800, SagVBAT_mV: 11400,
+	DebounceOK_ms: 300, StaleMax_ms: 4000,
+}
+
+var testSeq = []RailStep{{"a", 50 * time.Millisecond}, {"b", 400 * time.Millisecond}, {"c", 300 * time.Millisecond}}
+
+// rec logs every decision as "<ms since t0> <what>".
+type rec struct {
+	clk   *clock.Fake
+	ev    []string
+	otSrc TempSource // of the last OverTemp edge
+}
+
+func (r *rec) add(s string) {
+	r.ev = append(r.ev, fmt.Sprint(r.clk.Now().Sub(t0).Milliseconds(), " ", s))
+}
+
+func (r *rec) Switch(name string, on bool) {
+	if on {
+		r.add(name + " on")
+	} else {
+		r.add(name + " off")
+	}
+}
+func (r *rec) Transition(_, to State) { r.add("-> " + to.String()) }
+func (r *rec) Cut(c Cause)            { r.add("cut " + c.String()) }
+func (r *rec) Reverse()               { r.add("reverse") }
+func (r *rec) OverTemp(on bool, src TempSource) {
+	r.otSrc = src
+	if on {
+		r.add("ot on")
+	} else {
+		r.add("ot off")
+	}
+}
+
+// level is what the sensors report from at onwards; temp < 0 means the
+// temperature sensor has gone silent.
+type level struct {
+	at        time.Duration
+	vin, vbat int32
+	temp      int
+}
+
+// run drives f until end the way the reactor does: sensors report every
+// second and on each change of level, and Step runs on every report and
+// at every NextDue.
+func run(t *testing.T, f *FSM, clk *clock.Fake, script []level, end time.Duration) {
+	t.Helper()
+	lv, next := script[0], 1
+	var feed time.Duration
+	for i := 0; ; i++ {
+		if i > 10000 {
+			t.Fatal("no progress")
+		}
+		now := clk.Now().Sub(t0)
+		if next < len(script) && script[next].at <= now {
+			lv, next = script[next], next+1
+			feed = now
+		}
+		if feed <= now {
+			f.VIN(lv.vin)
+			f.VBAT(lv.vbat)
+			if lv.temp >= 0 {
+				f.Temp(TempCore, lv.temp)
+			}
+			feed = now + time.Second
+		}
+		f.Step()
+
+		wake := min(feed, end)
+		if next < len(script) {
+			wake = min(wake, script[next].at)
+		}
+		if due := f.NextDue(); !due.IsZero() {
+			if !due.After(clk.Now()) {
+				continue
+			}
+			wake = min(wake, due.Sub(t0))
+		}
+		if wake >= end {
+			return
+		}
+		clk.Advance(wake - now)
+	}
+}
+
+var upFrom0 = []string{"300 -> up_seq", "300 a on", "700 b on", "1000 c on", "1000 -> on"}
+
+func TestFSM_Scenarios(t *testing.T) {
+	cases := []struct {
+		name   string
+		script []level
+		end    time.Duration
+		want   []string
+	}{
+		{
+			name: "brownout during up",
+			script: []level{
+				{0, 13000, 12800, 250},
+				{800 * time.Millisecond, 9000, 11000, 250},
+			},
+			end: 3 * time.Second,
+			want: []string{"300 -> up_seq", "300 a on", "700 b on",
+				"800 cut brownout", "800 -> down_seq", "800 b off", "850 a off", "850 -> off"},
+		},
+		{
+			name: "reversal",
+			script: []level{
+				{0, 13000, 12800, 250},
+				{2 * time.Second, 13000, 12800, 780},
+				{2050 * time.Millisecond, 13000, 12800, 700},
+			},
+			end: 4 * time.Second,
+			want: append(append([]string{}, upFrom0...),
+				"2000 ot on", "2000 cut over_temp", "2000 -> down_seq", "2000 c off", "2050 ot off",
+				"2350 reverse", "2350 -> up_seq", "2350 a on", "2750 b on", "3050 c on", "3050 -> on"),
+		},
+		{
+			name: "stale temperature",
+			script: []level{
+				{0, 13000, 12800, 250},
+				{1500 * time.Millisecond, 13000, 12800, -1},
+			},
+			end: 8 * time.Second,
+			want: append(append([]string{}, upFrom0...),
+				"5001 cut temp_stale", "5001 -> down_seq", "5001 c off", "5401 b off", "5451 a off", "5451 -> off"),
+		},
+		{
+			name: "over-temp latch holds until below hysteresis",
+			script: []level{
+				{0, 13000, 12800, 250},
+				{2 * time.Second, 13000, 12800, 800},
+				{3 * time.Second, 13000, 12800, 721},
+				{4 * time.Second, 13000, 12800, 720},
+			},
+			end: 6 * time.Second,
+			want: append(append([]string{}, upFrom0...),
+				"2000 ot on", "2000 cut over_temp", "2000 -> down_seq", "2000 c off", "2400 b off", "2450 a off", "2450 -> off",
+				"4000 ot off", "4300 -> up_seq", "4300 a on", "4700 b on", "5000 c on", "5000 -> on"),
+		},
+		{
+			name: "battery alone carries the rails",
+			script: []level{
+				{0, 0, 12800, 250},
+				{2 * time.Second, 0, 11500, 250},
+				{3 * time.Second, 0, 11300, 250},
+			},
+			end: 4 * time.Second,
+			want: append(append([]string{}, upFrom0...),
+				"3000 cut brownout", "3000 -> down_seq", "3000 c off", "3400 b off", "3450 a off", "3450 -> off"),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clk := clock.NewFake(t0)
+			r := &rec{clk: clk}
+			f := New(clk, testCfg, testSeq, r)
+			run(t, f, clk, tc.script, tc.end)
+			if got, want := strings.Join(r.ev, "\n"), strings.Join(tc.want, "\n"); got != want {
+				t.Fatalf("events:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestFSM_EarlyDownOnlyWithoutBattery(t *testing.T) {
+	clk := clock.NewFake(t0)
+	r := &rec{clk: clk}
+	f := New(clk, testCfg, testSeq, r)
+	run(t, f, clk, []level{{0, 13000, 12800, 250}}, 2*time.Second)
+
+	if f.EarlyDown() {
+		t.Fatal("early down with the battery above sag")
+	}
+	f.VBAT(11000)
+	if !f.EarlyDown() || f.State() != DownSeq || f.Debouncing() {
+		t.Fatalf("state %v, debouncing %v", f.State(), f.Debouncing())
+	}
+	if f.EarlyDown() {
+		t.Fatal("early down twice")
+	}
+}
+
+func TestFSM_HeldDelaysBringUp(t *testing.T) {
+	clk := clock.NewFake(t0)
+	r := &rec{clk: clk}
+	f := New(clk, testCfg, testSeq, r)
+	f.SetHeld(true)
+	run(t, f, clk, []level{{0, 13000, 12800, 250}}, 2*time.Second)
+	if len(r.ev) != 0 || f.Debouncing() {
+		t.Fatalf("held: %v", r.ev)
+	}
+	// Released, the PG debounce starts over.
+	clk.Advance(t0.Add(2 * time.Second).Sub(clk.Now()))
+	f.SetHeld(false)
+	run(t, f, clk, []level{{0, 13000, 12800, 250}}, 4*time.Second)
+	if got := strings.Join(r.ev, ","); got != "2300 -> up_seq,2300 a on,2700 b on,3000 c on,3000 -> on" {
+		t.Fatalf("released: %s", got)
+	}
+}
+
+func TestFSM_TempVote(t *testing.T) {
+	cfg := testCfg
+	cfg.ChgTempLimit_deciC, cfg.ChgTempHyst_deciC = 600, 50
+	clk := clock.NewFake(t0)
+	r := &rec{clk: clk}
+	f := New(clk, cfg, testSeq, r)
+	// feed reports the supplies and the temperatures (< 0: silent), then
+	// steps after d.
+	feed := func(d time.Duration, core, chg int) {
+		clk.Advance(d)
+		f.VIN(13000)
+		f.VBAT(12800)
+		if core >= 0 {
+			f.Temp(TempCore, core)
+		}
+		if chg >= 0 {
+			f.Temp(TempCharger, chg)
+		}
+		f.Step()
+	}
+	latched := func(want bool, src TempSource, what string) {
+		t.Helper()
+		if got := f.MustCut() == CutOverTemp; got != want || want && r.otSrc != src {
+			t.Fatalf("%s: latched %v (want %v), source %v", what, got, want, r.otSrc)
+		}
+	}
+
+	feed(0, 250, -1)
+	feed(time.Second, 250, -1)
+	if f.Debouncing() || f.State() != Off {
+		t.Fatal("bring-up without a charger reading")
+	}
+	feed(0, 250, 300)
+	feed(300*time.Millisecond, 250, 300)
+	if f.State() != UpSeq {
+		t.Fatalf("state %v with both sources good", f.State())
+	}
+
+	// Either source over its own limit latches.
+	feed(time.Second, 250, 600)
+	latched(true, TempCharger, "charger at its limit")
+	feed(time.Second, 250, 551)
+	latched(true, TempCharger, "charger above hysteresis")
+	feed(time.Second, 250, 550)
+	latched(false, 0, "charger recovered")
+	feed(time.Second, 780, 300)
+	latched(true, TempCore, "core at its limit")
+
+	// Release wants both fresh: the charger going silent holds the latch,
+	// whatever the core says.
+	feed(5*time.Second, 790, -1)
+	feed(time.Second, 700, -1)
+	latched(true, TempCore, "charger stale")
+	feed(time.Second, 700, 300)
+	latched(false, 0, "both recovered")
+	if got := strings.Join(r.ev, ","); !strings.Contains(got, "ot on") || strings.Count(got, "ot off") != 2 {
+		t.Fatalf("edges: %s", got)
+	}
+}
@@ -1,13 +1,26 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"runtime"
 	"time"
 
 	"devicecode-go/bus"
+	"devicecode-go/internal/reactorfsm"
+	"devicecode-go/services/bridge"
+	"devicecode-go/services/energy"
 	"devicecode-go/services/hal"
+	"devicecode-go/services/metrics"
+	"devicecode-go/services/passthru"
+	"devicecode-go/services/powersys"
+	"devicecode-go/services/supervisor"
+	"devicecode-go/services/tempcomp"
+	"devicecode-go/services/timesync"
 	"devicecode-go/types"
+	"devicecode-go/x/clock"
+	"devicecode-go/x/fmtx"
+	"devicecode-go/x/linecrc"
 	"devicecode-go/x/shmring"
 	"devicecode-go/x/strconvx"
 )
@@ -18,10 +31,18 @@ import (
 
 const halTimeout = 5 * time.Second
 
+// Thermal, power and freshness defaults: the reactor starts with these
+// and takes replacements from config/reactor (types.ReactorConfig).
+
 // Thermal (deci-°C)
 const (
 	TEMP_LIMIT = 780 // 78.0 °C => force rails OFF
 	TEMP_HYST  = 60  // allow ON again at 72.0 °C
+
+	// Charger NTC (power/temperature/internal), voted with the core sensor:
+	// either over its limit latches, both must recover to release.
+	CHG_TEMP_LIMIT = 600 // 60.0 °C
+	CHG_TEMP_HYST  = 50  // 55.0 °C
 )
 
 // Power thresholds (mV)
@@ -41,9 +62,132 @@ const (
 	DIE_TEMP_TAKEOVER = 2 * time.Second
 )
 
-// Supervisory cadence
+// Startup self-check (types.SelfCheckConfig): how long the configured
+// checks have to pass. The default config checks nothing.
+const SELF_CHECK_TIMEOUT = 30 * time.Second
+
+// Supervisory cadence. The reactor sleeps until its next due action; the
+// safety tick bounds the sleep so a missed deadline cannot stall it.
+const (
+	SAFETY_TICK = 1 * time.Second
+	MEM_EVERY   = 3 * time.Second
+)
+
+// Post-mortem log: the logger keeps its newest LASTLOG_BYTES of output,
+// and the reactor checkpoints them to HAL's reserved log flash every
+// LASTLOG_EVERY when there is new output, and after each incident, at most
+// once per LASTLOG_MIN_GAP (each checkpoint costs a 4 KiB erase). The
+// previous boot's checkpoint is served on sys/lastlog/control/get.
+const (
+	LASTLOG_BYTES   = 2048 // power of two (a shmring)
+	LASTLOG_EVERY   = 15 * time.Minute
+	LASTLOG_MIN_GAP = time.Minute
+)
+
+// Button LED: one pattern per system state, the first state that applies
+// winning. Steps alternate on and off in ms, starting on (see
+// types.LEDPattern); config/reactor can replace any of them.
+const (
+	ledFault       = iota // charger reports a battery fault
+	ledOverTemp           // over-temp latch active
+	ledMaintenance        // manual rails-off latch (button hold)
+	ledOff                // rails down or going down
+	ledBattery            // rails up, VIN not above VBAT
+	ledCharging           // rails up, charger in a charge phase
+	ledOn                 // rails up
+	ledStates
+)
+
+var ledDefaults = [ledStates]types.LEDPattern{
+	ledFault:       {State: "fault", Steps_ms: []uint16{100, 100}},
+	ledOverTemp:    {State: "overtemp", Steps_ms: []uint16{100, 100, 100, 100, 100, 700}},
+	ledMaintenance: {State: "maintenance", Steps_ms: []uint16{1500, 1500}},
+	ledOff:         {State: "off", Steps_ms: []uint16{200, 800}},
+	ledBattery:     {State: "battery", Steps_ms: []uint16{100, 150, 100, 1650}},
+	ledCharging:    {State: "charging", Steps_ms: []uint16{1800, 200}},
+	ledOn:          {State: "on", Steps_ms: []uint16{1}},
+}
+
+const ledMaxSteps = 8
+
+// Low-power idle: request HAL idle mode after this long with the rails off and no
+// activity; leave it before any up-sequence. Requests are not waited on; an
+// idle request unanswered within POWER_REPLY_TIMEOUT counts as refused.
 const (
-	TICK = 100 * time.Millisecond // balances debounce precision and MCU overhead
+	IDLE_AFTER          = 30 * time.Second
+	POWER_REPLY_TIMEOUT = 250 * time.Millisecond
+)
+
+// Holding the front-panel button this long toggles a manual rails-off latch
+// (safe shutdown before pulling power, and back on again).
+const SHUTDOWN_HOLD = 5 * time.Second
+
+// Buzzer priorities: the over-temp alarm cannot be cut short by the
+// brownout warning; either pre-empts anything played by other clients at 0.
+const (
+	BUZZ_PRI_BROWNOUT = 1
+	BUZZ_PRI_OVERTEMP = 2
+)
+
+// Metrics export cadence (sys/metrics, mirrored to uart0)
+const METRICS_EVERY = 10 * time.Second
+
+// Telemetry line footers: each uart0 JSON line ends " #<seq>*<crc16>"
+// (x/linecrc), so the collector can drop truncated or corrupted lines and
+// report how many were lost.
+const TELEMETRY_FOOTER = true
+
+// Battery temperature probe (hal/cap/env/temperature/<name>) used to
+// compensate the lead-acid charge voltage; "" leaves the charger's setting
+// alone.
+const BATT_PROBE = ""
+
+// Host bridge (services/bridge): the serial capability it serves on, ""
+// for none. It takes that UART from the log mirror, whose lines then go
+// to the USB console only.
+const BRIDGE_UART = "uart1"
+
+// Bus introspection cadence (sys/bus/debug); 0 leaves it off.
+const BUS_DEBUG_EVERY = 0 * time.Second
+
+// Bus allocation guards. HAL topics are at most 7 tokens deep; the limits
+// only bite on a misbehaving peer.
+var busLimits = bus.Limits{MaxDepth: 12, MaxTopics: 1024, MaxSubsPerConn: 32}
+
+// Charger events explain the current charger state; keep the last few per
+// tag so a late subscriber sees the cause. A wildcard subscriber (the
+// reactor's evTopic) matches every tag's history; the bus caps its replay
+// at the room its queue has left after retained values, newest first, so
+// replay is never lost to drop-oldest.
+var busHistory = []bus.HistorySpec{
+	{Pattern: bus.T("hal", "cap", "power", "charger", "+", "event", "+"), Depth: 3},
+}
+
+// Payload guards: typed topics the bridge or an older service could fill
+// with a generic map. Refusals go to bus/deadletter.
+var busGuards = []bus.GuardSpec{
+	{Pattern: bus.T("config", "hal"), Accept: bus.Expect[types.HALConfig]()},
+	{Pattern: bus.T("config", "reactor"), Accept: bus.Expect[types.ReactorConfig]()},
+	{Pattern: bus.T("config", "log", "+"), Accept: bus.Expect[types.LogConfig]()},
+	{Pattern: bus.T("hal", "cap", "power", string(types.KindSwitch), "+", "control", "set"), Accept: bus.Expect[types.SwitchSet]()},
+	{Pattern: bus.T("config", "#"), MaxSize: 4096},
+}
+
+// Dead letters: commands lost to a full queue or sent to nobody are
+// reported on bus/deadletter.
+var busDeadLetter = []bus.Topic{
+	bus.T("hal", "cap", "+", "+", "+", "control", "+"),
+	bus.T("hal", "+", "control", "+"),
+}
+
+// -----------------------------------------------------------------------------
+// Metrics
+// -----------------------------------------------------------------------------
+
+var (
+	mFSMTransitions = metrics.NewCounter("power.fsm.transitions")
+	mUART0Dropped   = metrics.NewCounter("uart0.dropped_bytes")
+	mUART1Dropped   = metrics.NewCounter("uart1.dropped_bytes")
 )
 
 // -----------------------------------------------------------------------------
@@ -64,6 +208,15 @@ var (
 	tLEDCtrlSet = bus.T("hal", "cap", "io", string(types.KindLED), "button_led", "control", "set")
 )
 
+// Button (configure its LongMs to SHUTDOWN_HOLD)
+var tButtonLong = bus.T("hal", "cap", "io", string(types.KindButton), "button", "event", "long")
+
+// Buzzer (absent on most builds; controls to it then go unanswered)
+var (
+	tBuzzerPlay = bus.T("hal", "cap", "io", string(types.KindBuzzer), "buzzer", "control", "play")
+	tBuzzerStop = bus.T("hal", "cap", "io", string(types.KindBuzzer), "buzzer", "control", "stop")
+)
+
 // Die
 var tDieTempValue = bus.T("hal", "cap", "env", string(types.KindTemperature), "die", "value")
 
@@ -80,6 +233,50 @@ var (
 	evTopic  = bus.T("hal", "cap", "power", "+", "internal", "event", "+")
 )
 
+// HAL power mode; replies come back on reactor/power/<mode>
+var (
+	tPowerSet     = bus.T("hal", "power", "control", "set")
+	tPowerReplies = bus.T("reactor", "power", "+")
+)
+
+func tPowerReply(mode types.PowerMode) bus.Topic {
+	return bus.T("reactor", "power", string(mode))
+}
+
+// Thresholds: config/reactor sets them, reactor/config retains those in force
+var (
+	tConfigReactor = bus.T("config", "reactor")
+	tReactorConfig = bus.T("reactor", "config")
+)
+
+// Roll-up of the reactor's inputs (retained); HAL publishes it when the
+// setup defines the group
+var tTelemetryGroup = bus.T("group", "power-telemetry", "state")
+
+// Startup self-check: verdict (retained), its override, and its inputs
+var (
+	tSelfCheck         = bus.T("reactor", "selfcheck")
+	tSelfCheckOverride = bus.T("reactor", "selfcheck", "control", "override")
+	tCapStatusAll      = bus.T("hal", "cap", "+", "+", "+", "status")
+	tTempValueAll      = bus.T("hal", "cap", "+", string(types.KindTemperature), "+", "value")
+)
+
+// Incident counters (retained), kept in HAL's NV store under nvIncidents
+var (
+	tIncidents = bus.T("reactor", "incidents")
+	tNVGet     = bus.T("hal", "nv", "control", "get")
+	tNVPut     = bus.T("hal", "nv", "control", "put")
+)
+
+const nvIncidents = "reactor/incidents"
+
+// Log checkpoint in HAL's log flash, and the previous boot's on request
+var (
+	tLogGet     = bus.T("hal", "nv", "control", "log_get")
+	tLogPut     = bus.T("hal", "nv", "control", "log_put")
+	tLastLogGet = bus.T("sys", "lastlog", "control", "get")
+)
+
 // Power switches
 func tSwitch(name string) bus.Topic {
 	return bus.T("hal", "cap", "power", string(types.KindSwitch), name, "control", "set")
@@ -100,12 +297,7 @@ func tSessClosed(name string) bus.Topic {
 // Rail order (pre-gap semantics)
 // -----------------------------------------------------------------------------
 
-type RailStep struct {
-	Name      string
-	GapBefore time.Duration // enforced before operating this rail
-}
-
-var powerSeq = []RailStep{
+var powerSeq = []reactorfsm.RailStep{
 	{Name: "mpcie-usb", GapBefore: 200 * time.Millisecond},
 	{Name: "m2", GapBefore: 200 * time.Millisecond},
 	{Name: "mpcie", GapBefore: 200 * time.Millisecond},
@@ -115,18 +307,9 @@ var powerSeq = []RailStep{
 }
 
 // -----------------------------------------------------------------------------
-// Reactor state machine (single goroutine)
+// Reactor (single goroutine); the rails FSM is internal/reactorfsm
 // -----------------------------------------------------------------------------
 
-type railsState int
-
-const (
-	stateOff railsState = iota
-	stateUpSeq
-	stateOn
-	stateDownSeq
-)
-
 type Reactor struct {
 	ui *bus.Connection
 
@@ -135,249 +318,757 @@ type Reactor struct {
 	// Logger UART1 already handled by global logger (see SetUART1)
 
 	// inputs (latest)
-	vin_mV, vbat_mV int32
 	iin_mA, ibat_mA int32
-	lastTDeci       int
-	tsVIN, tsVBAT   time.Time
-	tsTemp          time.Time
 
-	// derived latches
-	vbatGood bool // VBAT hysteresis
-	otActive bool // over-temp latch (forces down until recovered)
+	// rails: latches, debounce and sequencing
+	fsm *reactorfsm.FSM
 
-	// debounce
-	pgSince  time.Time
-	pgStable bool
+	// lifetime incident counters (reactor/incidents)
+	incidents types.ReactorIncidents
 
-	// rails / sequencing
-	state         railsState
-	seqIdx        int       // index into powerSeq for next action
-	seqOnCount    int       // number of rails currently ON
-	nextActionDue time.Time // when next rail operation may run
+	// post-mortem log: the previous boot's checkpoint, and this boot's
+	// (logNext zero: no log store)
+	lastLog    types.LastLog
+	logNext    time.Time
+	logSavedAt time.Time
+	logSaved   uint32 // log output (shmring.Produced) at the last checkpoint
+
+	// startup self-check (reactor/selfcheck) and the telemetry hold; either
+	// holds bring-up
+	sc        selfCheck
+	groupHeld bool
+
+	// thresholds in force (reactor/config)
+	cfg types.ReactorConfig
 
 	// LED
-	ledSteady bool
 	levelUp   bool
-	ledTick   int // throttles breathe commands
+	led       [ledStates][]uint16    // steps per state, config applied
+	ledState  int                    // state shown (-1: none yet)
+	ledStep   int                    // index into its steps
+	ledNext   time.Time              // next step edge (zero: steady)
+	overTemp  bool                   // over-temp latch
+	chgState  types.ChargerStateBits // latest charger state bits
+	onBattery bool                   // latest charger status: VIN not above VBAT
+
+	// low-power idle
+	idle         bool      // HAL idle mode requested and acknowledged
+	idleAsked    time.Time // idle request in flight since (zero: none)
+	lastActivity time.Time // last state change or hardware event
 
 	// misc
-	now time.Time
+	clk     clock.Clock
+	now     time.Time
+	memNext time.Time // next memory snapshot
 
 	// telemetry drop counters (bytes)
 	droppedUART0Bytes int
+
+	// telemetry line footers (TELEMETRY_FOOTER)
+	teleLines linecrc.Writer
+	teleFoot  [20]byte
 }
 
-func NewReactor(ui *bus.Connection) *Reactor {
-	return &Reactor{
-		ui:      ui,
-		levelUp: true,
-		state:   stateOff,
-		now:     time.Now(),
+// NewReactor returns a reactor reading time from clk (nil: clock.Real), so
+// the state machine can be driven by a fake clock in tests.
+func NewReactor(ui *bus.Connection, clk clock.Clock) *Reactor {
+	clk = clock.Or(clk)
+	now := clk.Now()
+	r := &Reactor{
+		ui:       ui,
+		clk:      clk,
+		levelUp:  true,
+		now:      now,
+		cfg:      defaultReactorConfig(),
+		ledState: -1,
+
+		lastActivity: now,
 	}
+	r.sc = selfCheck{state: scRunning, since: now,
+		links: map[types.CapabilityAddress]types.Link{}, temps: map[types.CapabilityAddress]int32{}}
+	r.fsm = reactorfsm.New(clk, r.cfg, powerSeq, railsOut{r})
+	r.fsm.SetHeld(true) // until the self-check passes
+	r.setLED(nil)
+	return r
 }
 
-// ---- freshness and decisions ----
-
-func (r *Reactor) freshVIN() bool { return !r.tsVIN.IsZero() && r.now.Sub(r.tsVIN) <= STALE_MAX }
-func (r *Reactor) freshBAT() bool { return !r.tsVBAT.IsZero() && r.now.Sub(r.tsVBAT) <= STALE_MAX }
-func (r *Reactor) freshTMP() bool { return !r.tsTemp.IsZero() && r.now.Sub(r.tsTemp) <= STALE_MAX }
-
-func (r *Reactor) supplyPG() bool {
-	// Supply PG for turning on: VIN fresh ≥ PG_ON_VIN OR VBAT hysteresis true.
-	return (r.freshVIN() && int(r.vin_mV) >= PG_ON_VIN) || r.vbatGood
+// ---- thresholds ----
+
+func defaultReactorConfig() types.ReactorConfig {
+	return types.ReactorConfig{
+		TempLimit_deciC: TEMP_LIMIT, TempHyst_deciC: TEMP_HYST,
+		ChgTempLimit_deciC: CHG_TEMP_LIMIT, ChgTempHyst_deciC: CHG_TEMP_HYST,
+		PGOnVIN_mV: PG_ON_VIN, SagVIN_mV: SAG_VIN,
+		PGOnVBAT_mV: PG_ON_VBAT, PGOffHyst_mV: PG_OFF_HYST, SagVBAT_mV: SAG_VBAT,
+		DebounceOK_ms: uint32(DEBOUNCE_OK / time.Millisecond),
+		StaleMax_ms:   uint32(STALE_MAX / time.Millisecond),
+		SelfCheck:     types.SelfCheckConfig{Timeout_ms: uint32(SELF_CHECK_TIMEOUT / time.Millisecond)},
+	}
 }
 
-func (r *Reactor) tempOKForTurnOn() bool {
-	// Must be fresh and ≤ LIMIT - HYST
-	return r.freshTMP() && r.lastTDeci <= (TEMP_LIMIT-TEMP_HYST)
+// validReactorConfig rejects thresholds that would leave a latch unable
+// to clear or bring-up below the cut point.
+func validReactorConfig(c types.ReactorConfig) bool {
+	return c.TempHyst_deciC > 0 && c.TempHyst_deciC < c.TempLimit_deciC &&
+		(c.ChgTempLimit_deciC == 0 || c.ChgTempHyst_deciC > 0 && c.ChgTempHyst_deciC < c.ChgTempLimit_deciC) &&
+		c.SagVIN_mV > 0 && c.SagVIN_mV < c.PGOnVIN_mV &&
+		c.SagVBAT_mV > 0 && c.SagVBAT_mV < c.PGOnVBAT_mV-c.PGOffHyst_mV && c.PGOffHyst_mV >= 0 &&
+		c.StaleMax_ms > 0 && validLED(c.LED) && validSelfCheck(c.SelfCheck)
 }
 
-func (r *Reactor) mustCutNow() bool {
-	// Immediate cut if: temperature stale OR both sources bad (stale or < SAG) OR over-temp latch.
-	if !r.freshTMP() {
-		return true
-	}
-	vinOK := r.freshVIN() && int(r.vin_mV) >= SAG_VIN
-	vbatOK := r.freshBAT() && int(r.vbat_mV) >= SAG_VBAT
-	return !(vinOK || vbatOK) || r.otActive
+// validSelfCheck wants a time limit with any check, and a tolerance for
+// sensors that must agree.
+func validSelfCheck(c types.SelfCheckConfig) bool {
+	checks := len(c.Required) > 0 || c.MinVBAT_mV > 0 || len(c.Temps) > 0
+	return c.MinVBAT_mV >= 0 && c.TempTol_deciC >= 0 &&
+		(!checks || c.Timeout_ms > 0) && (len(c.Temps) == 0 || len(c.Temps) >= 2)
 }
 
-func (r *Reactor) updateLatchesFromValues() {
-	// Over-temp latch
-	if r.freshTMP() {
-		if r.lastTDeci >= TEMP_LIMIT {
-			if !r.otActive {
-				log.Println("[thermal] over-temp → latch active")
-			}
-			r.otActive = true
-		} else if r.lastTDeci <= (TEMP_LIMIT - TEMP_HYST) {
-			if r.otActive {
-				log.Println("[thermal] temp recovered below hysteresis")
+// validLED accepts patterns for known states, each a single step or an
+// even number (up to ledMaxSteps) of non-zero ones.
+func validLED(ps []types.LEDPattern) bool {
+	for _, p := range ps {
+		n := len(p.Steps_ms)
+		if ledIndex(p.State) < 0 || n == 0 || n > ledMaxSteps || (n > 1 && n%2 != 0) {
+			return false
+		}
+		for _, ms := range p.Steps_ms {
+			if n > 1 && ms == 0 {
+				return false
 			}
-			r.otActive = false
 		}
 	}
-	// VBAT hysteresis
-	if r.freshBAT() {
-		if !r.vbatGood && int(r.vbat_mV) >= PG_ON_VBAT {
-			r.vbatGood = true
-		} else if r.vbatGood && int(r.vbat_mV) < (PG_ON_VBAT-PG_OFF_HYST) {
-			r.vbatGood = false
+	return true
+}
+
+func ledIndex(state string) int {
+	for i, p := range ledDefaults {
+		if p.State == state {
+			return i
 		}
+	}
+	return -1
+}
+
+// OnConfig applies thresholds from config/reactor and publishes the set
+// in force on reactor/config either way.
+func (r *Reactor) OnConfig(c types.ReactorConfig) {
+	if validReactorConfig(c) {
+		r.cfg = c
+		r.fsm.SetConfig(c)
+		r.setLED(c.LED)
+		log.Println("[config] reactor thresholds updated")
 	} else {
-		r.vbatGood = false
+		log.Println("[config] reactor thresholds rejected; keeping current")
 	}
+	r.publishConfig()
 }
 
-// ---- sequencing (non-blocking) ----
+func (r *Reactor) publishConfig() {
+	r.ui.Publish(r.ui.NewMessage(tReactorConfig, r.cfg, true))
+}
+
+// ---- rails ----
+
+// railsOut carries the FSM's decisions onto the bus, the incident
+// counters and the idle bookkeeping.
+type railsOut struct{ r *Reactor }
 
-func (r *Reactor) startUpSeq() {
-	log.Println("[power] PG debounced + Temp OK → rails UP")
-	r.state = stateUpSeq
-	r.seqIdx = 0            // next to apply
-	r.nextActionDue = r.now // first step fires immediately
-	if r.seqOnCount < 0 {   // safety
-		r.seqOnCount = 0
+func (o railsOut) Switch(name string, on bool) {
+	if on {
+		log.Println("[event] powering rail UP: ", name)
+	} else {
+		log.Println("[event] powering rail down: ", name)
 	}
+	o.r.ui.Publish(o.r.ui.NewMessage(tSwitch(name), types.SwitchSet{On: on}, false))
 }
 
-func (r *Reactor) startDownSeq() {
-	log.Println("[power] brownout/stale/over-temp → rails DOWN")
-	r.state = stateDownSeq
-	if r.seqOnCount < 0 {
-		r.seqOnCount = 0
+func (o railsOut) Transition(_, to reactorfsm.State) {
+	r := o.r
+	r.lastActivity = r.now
+	mFSMTransitions.Inc()
+	if to == reactorfsm.UpSeq {
+		log.Println("[power] PG debounced + Temp OK → rails UP")
+		r.exitIdle() // full speed before sequencing
 	}
-	if r.seqOnCount > len(powerSeq) {
-		r.seqOnCount = len(powerSeq)
+}
+
+func (o railsOut) OverTemp(active bool, src reactorfsm.TempSource) {
+	r := o.r
+	r.overTemp = active
+	if active {
+		log.Println("[thermal] over-temp (", src.String(), ") → latch active")
+		r.countIncident(&r.incidents.OverTemp)
+		r.ui.Publish(r.ui.NewMessage(tBuzzerPlay, types.BuzzerPlay{
+			Pattern: "alarm", Repeat: types.BuzzerRepeatForever, Priority: BUZZ_PRI_OVERTEMP,
+		}, false))
+		return
+	}
+	log.Println("[thermal] temp recovered below hysteresis")
+	r.ui.Publish(r.ui.NewMessage(tBuzzerStop, types.BuzzerStop{Priority: BUZZ_PRI_OVERTEMP}, false))
+}
+
+func (o railsOut) Cut(c reactorfsm.Cause) {
+	log.Println("[power] brownout/stale/over-temp → rails DOWN")
+	if c == reactorfsm.CutTempStale || c == reactorfsm.CutBrownout {
+		o.r.countIncident(&o.r.incidents.EmergencyDown)
 	}
-	r.seqIdx = r.seqOnCount - 1 // start from last ON rail
-	r.nextActionDue = r.now     // first off fires immediately
 }
 
-func (r *Reactor) advanceSequenceIfDue() {
-	if r.state != stateUpSeq && r.state != stateDownSeq {
+func (o railsOut) Reverse() {
+	log.Println("[power] inputs stably good → reverse to UP sequence")
+}
+
+// ---- low-power idle ----
+
+// requestPower asks HAL for a power mode without waiting; the reply comes
+// back on tPowerReply(mode) and reaches OnPowerReply from the main loop.
+func (r *Reactor) requestPower(mode types.PowerMode) {
+	m := r.ui.NewMessage(tPowerSet, types.PowerSet{Mode: mode}, false)
+	m.ReplyTo = tPowerReply(mode)
+	r.ui.Publish(m)
+}
+
+func (r *Reactor) stepIdle() {
+	if !r.idleAsked.IsZero() {
+		if r.now.Sub(r.idleAsked) >= POWER_REPLY_TIMEOUT {
+			log.Println("[power] mode idle request unanswered")
+			r.idleAsked = time.Time{}
+			r.lastActivity = r.now // back off a full IDLE_AFTER
+		}
 		return
 	}
-	if r.now.Before(r.nextActionDue) {
+	if r.idle || r.fsm.State() != reactorfsm.Off || r.fsm.Debouncing() {
+		return
+	}
+	if r.now.Sub(r.lastActivity) >= IDLE_AFTER {
+		r.idleAsked = r.now
+		r.requestPower(types.PowerIdle)
+	}
+}
+
+// exitIdle asks for full speed if idle is granted or pending. HAL serves
+// the request in order with the rail commands that follow.
+func (r *Reactor) exitIdle() {
+	if !r.idle && r.idleAsked.IsZero() {
 		return
 	}
+	r.idle = false
+	r.idleAsked = time.Time{}
+	r.requestPower(types.PowerRun)
+}
 
-	switch r.state {
-	case stateUpSeq:
-		if r.seqIdx >= len(powerSeq) {
-			// finished: all rails are on
-			r.state = stateOn
-			r.seqOnCount = len(powerSeq)
+// OnPowerReply handles HAL's answer to a requestPower. An idle grant that
+// arrives after exitIdle has been superseded by the run request behind it.
+func (r *Reactor) OnPowerReply(mode types.PowerMode, ok bool) {
+	switch {
+	case !ok:
+		log.Println("[power] mode ", string(mode), " rejected")
+		if mode == types.PowerIdle && !r.idleAsked.IsZero() {
+			r.idleAsked = time.Time{}
+			r.lastActivity = r.now
+		}
+	case mode == types.PowerIdle:
+		if r.idleAsked.IsZero() {
 			return
 		}
-		step := powerSeq[r.seqIdx]
-		log.Println("[event] powering rail UP: ", step.Name)
-		r.publishSwitch(step.Name, true)
-		r.seqOnCount++
-		r.seqIdx++
-		if r.seqIdx < len(powerSeq) {
-			r.nextActionDue = r.now.Add(powerSeq[r.seqIdx].GapBefore)
+		log.Println("[power] rails off and quiet → idle")
+		r.idleAsked = time.Time{}
+		r.idle = true
+	case mode == types.PowerRun:
+		log.Println("[power] leaving idle")
+	}
+}
+
+// ---- LED policy tied to system state ----
+
+// setLED installs the default patterns with ps applied over them; the
+// next step restarts the pattern in force.
+func (r *Reactor) setLED(ps []types.LEDPattern) {
+	for i, p := range ledDefaults {
+		r.led[i] = p.Steps_ms
+	}
+	for _, p := range ps {
+		r.led[ledIndex(p.State)] = p.Steps_ms
+	}
+	r.ledState = -1
+}
+
+// ledStateNow picks the state the LED shows.
+func (r *Reactor) ledStateNow() int {
+	const faults = types.BatShortFault | types.BatMissingFault | types.MaxChargeTimeFault
+	const charging = types.Precharge | types.CCCVCharge | types.AbsorbCharge | types.EqualizeCharge
+	up := r.fsm.State() == reactorfsm.UpSeq || r.fsm.State() == reactorfsm.On
+	switch {
+	case r.chgState&faults != 0:
+		return ledFault
+	case r.overTemp:
+		return ledOverTemp
+	case r.fsm.UserOff():
+		return ledMaintenance
+	case !up:
+		return ledOff
+	case r.onBattery:
+		return ledBattery
+	case r.chgState&charging != 0:
+		return ledCharging
+	}
+	return ledOn
+}
+
+func (r *Reactor) stepLED() {
+	if s := r.ledStateNow(); s != r.ledState {
+		r.ledState, r.ledStep = s, 0
+		r.showLED()
+		return
+	}
+	if r.ledNext.IsZero() || r.now.Before(r.ledNext) {
+		return
+	}
+	r.ledStep = (r.ledStep + 1) % len(r.led[r.ledState])
+	r.showLED()
+}
+
+// showLED drives the LED for the current step and schedules the next.
+func (r *Reactor) showLED() {
+	steps := r.led[r.ledState]
+	on := r.ledStep%2 == 0
+	r.ledNext = time.Time{}
+	if len(steps) == 1 {
+		on = steps[0] != 0
+	} else {
+		r.ledNext = r.now.Add(time.Duration(steps[r.ledStep]) * time.Millisecond)
+	}
+	r.ui.Publish(r.ui.NewMessage(tLEDCtrlSet, types.LEDSet{On: on}, false))
+}
+
+// ---- scheduling ----
+
+// step runs every supervisory action that is due at r.now.
+func (r *Reactor) step() {
+	// 0) Startup self-check, which holds bring-up until it passes
+	r.stepSelfCheck()
+
+	// 1) Rails FSM: latches, transitions (with symmetric reversal) and
+	// the next sequencing step if due
+	r.fsm.Step()
+
+	// 2) LED behaviour
+	r.stepLED()
+
+	// 3) Periodic memory snapshot
+	if !r.now.Before(r.memNext) {
+		r.emitMemSnapshot()
+		r.memNext = r.now.Add(MEM_EVERY)
+	}
+
+	// 4) Low-power idle when rails are off and nothing is happening
+	r.stepIdle()
+
+	// 5) Log checkpoint
+	if !r.logNext.IsZero() && !r.now.Before(r.logNext) {
+		r.checkpointLog()
+	}
+}
+
+// nextDue returns the earliest time at which step has time-based work to do:
+// the rails FSM's (PG debounce, next sequence action, input staleness), the
+// next LED edge, idle, the memory snapshot or the log checkpoint. It never
+// lies beyond SAFETY_TICK.
+func (r *Reactor) nextDue() time.Time {
+	due := r.now.Add(SAFETY_TICK)
+	earlier := func(t time.Time) {
+		if !t.IsZero() && t.Before(due) {
+			due = t
+		}
+	}
+	earlier(r.fsm.NextDue())
+	if r.sc.state == scRunning {
+		earlier(r.selfCheckDeadline())
+	}
+	earlier(r.ledNext)
+	if !r.idleAsked.IsZero() {
+		earlier(r.idleAsked.Add(POWER_REPLY_TIMEOUT))
+	} else if r.fsm.State() == reactorfsm.Off && !r.idle {
+		earlier(r.lastActivity.Add(IDLE_AFTER))
+	}
+	earlier(r.memNext)
+	earlier(r.logNext)
+	return due
+}
+
+// OnCapMessage handles a power value, status or event from the main loop's
+// capSub; which is capSub.Match(m).
+func (r *Reactor) OnCapMessage(which int, m *bus.Message) {
+	switch which {
+	case 0: // value
+		r.now = r.clk.Now()
+		switch v := m.Payload.(type) {
+		case types.BatteryValue:
+			r.OnBattery(v)
+			printCapValue(m)
+		case types.ChargerValue:
+			r.OnCharger(v)
+			printCapValue(m)
+		case types.SystemPowerValue:
+			r.OnSystemPower(v)
+			printCapValue(m)
+		case types.TemperatureValue:
+			r.fsm.Temp(reactorfsm.TempCharger, int(v.DeciC))
+			r.OnTempDeciC("[value] power/temperature/internal °C=", int(v.DeciC), "power/temperature/internal")
 		}
-	case stateDownSeq:
-		if r.seqIdx < 0 {
-			// finished: all rails are off
-			r.state = stateOff
-			r.seqOnCount = 0
-			return
+	case 1: // status
+		printCapStatus(m)
+	case 2: // event
+		r.lastActivity = r.clk.Now()
+		r.now = r.lastActivity
+		printCapEvent(m)
+		if v, ok := m.Payload.(types.VinCollapseWarning); ok {
+			r.OnVinCollapse(v)
 		}
-		step := powerSeq[r.seqIdx]
-		log.Println("[event] powering rail down: ", step.Name)
-		r.publishSwitch(step.Name, false)
-		r.seqOnCount--
-		r.seqIdx--
-		if r.seqIdx >= 0 {
-			r.nextActionDue = r.now.Add(powerSeq[r.seqIdx].GapBefore)
+		// JSON: {"<dom>/<kind>/<name>/event":"<tag>"}
+		if r.jsonOut != nil {
+			if tag, _ := m.Topic.At(6).(string); tag != "" {
+				key := bus.TNoIntern(m.Topic.At(2), m.Topic.At(3), m.Topic.At(4), "event").String()
+				w := r.jsonLine()
+				w.begin()
+				w.kvStr(key, tag)
+				w.end()
+			}
 		}
 	}
 }
 
-func (r *Reactor) publishSwitch(name string, on bool) {
-	r.ui.Publish(r.ui.NewMessage(tSwitch(name), types.SwitchSet{On: on}, false))
+// OnVinCollapse reacts to the charger's brownout pre-warning. If the
+// battery cannot carry the load once VIN goes, start the orderly down
+// sequence now rather than waiting for the cut at SAG. The PG debounce
+// restarts, so the rails come back only if VIN proves stable again.
+func (r *Reactor) OnVinCollapse(v types.VinCollapseWarning) {
+	if !r.fsm.EarlyDown() {
+		return
+	}
+	log.Println("[power] VIN collapsing (", int(v.Slope_mVps), " mV/s) → early rails DOWN")
+	r.countIncident(&r.incidents.EmergencyDown)
+	r.ui.Publish(r.ui.NewMessage(tBuzzerPlay, types.BuzzerPlay{
+		Pattern: "warning", Repeat: 2, Priority: BUZZ_PRI_BROWNOUT,
+	}, false))
 }
 
-// ---- state transitions (with symmetric reversal) ----
+// OnTelemetryGroup holds bring-up while HAL reports the reactor's inputs
+// degraded (a member erroring, stale or not yet read). Rails already up
+// are left to the FSM's own freshness checks.
+func (r *Reactor) OnTelemetryGroup(v types.GroupState) {
+	held := v.Link != types.LinkUp
+	if held == r.groupHeld {
+		return
+	}
+	r.groupHeld = held
+	r.applyHold()
+	if !held {
+		log.Println("[power] telemetry up → bring-up allowed")
+		return
+	}
+	for _, o := range v.Offenders {
+		log.Println("[power] telemetry degraded:", o.Cap.Domain+"/"+string(o.Cap.Kind)+"/"+o.Cap.Name, o.Error, "→ bring-up held")
+	}
+}
+
+// applyHold holds bring-up while the telemetry roll-up is degraded or the
+// self-check has not released it.
+func (r *Reactor) applyHold() {
+	r.fsm.SetHeld(r.groupHeld || r.sc.state == scRunning || r.sc.state == scFailed)
+}
 
-func (r *Reactor) stepFSM() {
-	r.updateLatchesFromValues()
+// ---- startup self-check ----
 
-	switch r.state {
-	case stateOff, stateDownSeq:
-		// Evaluate PG/thermal with debounce
-		if !r.otActive && r.supplyPG() && r.tempOKForTurnOn() {
-			if r.pgSince.IsZero() {
-				r.pgSince = r.now
-				r.pgStable = false
-			} else if !r.pgStable && r.now.Sub(r.pgSince) >= DEBOUNCE_OK {
-				r.pgStable = true
+// The self-check runs from boot until every check in
+// ReactorConfig.SelfCheck passes or its time runs out. Only the first
+// bring-up waits for it; a failure holds the rails off until overridden.
+const (
+	scRunning    = "running"
+	scPassed     = "passed"
+	scFailed     = "failed"
+	scOverridden = "overridden"
+)
+
+type selfCheck struct {
+	state     string
+	since     time.Time
+	published bool // state above has been published
+	links     map[types.CapabilityAddress]types.Link
+	temps     map[types.CapabilityAddress]int32
+	vbat      int32
+	vbatSeen  bool
+}
+
+func (r *Reactor) selfCheckDeadline() time.Time {
+	return r.sc.since.Add(time.Duration(r.cfg.SelfCheck.Timeout_ms) * time.Millisecond)
+}
+
+// OnCapStatus and OnCapTemp feed the self-check while it runs.
+func (r *Reactor) OnCapStatus(a types.CapabilityAddress, st types.CapabilityStatus) {
+	if r.sc.state == scRunning {
+		r.sc.links[a] = st.Link
+	}
+}
+
+func (r *Reactor) OnCapTemp(a types.CapabilityAddress, deci int32) {
+	if r.sc.state == scRunning {
+		r.sc.temps[a] = deci
+	}
+}
+
+// selfCheckItems evaluates every configured check on the inputs so far.
+func (r *Reactor) selfCheckItems() (items []types.SelfCheckItem, ok bool) {
+	c := r.cfg.SelfCheck
+	ok = true
+	add := func(it types.SelfCheckItem) {
+		ok = ok && it.OK
+		items = append(items, it)
+	}
+	for _, a := range c.Required {
+		it := types.SelfCheckItem{Check: "cap/" + a.Domain + "/" + string(a.Kind) + "/" + a.Name}
+		switch l, seen := r.sc.links[a]; {
+		case !seen:
+			it.Detail = "absent"
+		case l != types.LinkUp:
+			it.Detail = string(l)
+		default:
+			it.OK = true
+		}
+		add(it)
+	}
+	if c.MinVBAT_mV > 0 {
+		it := types.SelfCheckItem{Check: "vbat", Value: r.sc.vbat}
+		switch {
+		case !r.sc.vbatSeen:
+			it.Detail = "no_reading"
+		case r.sc.vbat < c.MinVBAT_mV:
+			it.Detail = "below_floor"
+		default:
+			it.OK = true
+		}
+		add(it)
+	}
+	if len(c.Temps) > 0 {
+		it := types.SelfCheckItem{Check: "temp_spread"}
+		var lo, hi int32
+		n := 0
+		for _, a := range c.Temps {
+			v, seen := r.sc.temps[a]
+			if !seen {
+				continue
 			}
-		} else {
-			r.pgSince = time.Time{}
-			r.pgStable = false
+			if n == 0 {
+				lo, hi = v, v
+			}
+			lo, hi, n = min(lo, v), max(hi, v), n+1
 		}
-
-		// If actively powering down and inputs become stably good, reverse.
-		if r.state == stateDownSeq && r.pgStable {
-			log.Println("[power] inputs stably good → reverse to UP sequence")
-			r.startUpSeq()
-			return
+		it.Value = hi - lo
+		switch {
+		case n < len(c.Temps):
+			it.Detail = "no_reading"
+		case it.Value > c.TempTol_deciC:
+			it.Detail = "too_wide"
+		default:
+			it.OK = true
 		}
-		if r.state == stateOff && r.pgStable {
-			r.startUpSeq()
-			return
+		add(it)
+	}
+	return items, ok
+}
+
+// stepSelfCheck decides the self-check once it passes or times out.
+func (r *Reactor) stepSelfCheck() {
+	if r.sc.state != scRunning {
+		return
+	}
+	items, ok := r.selfCheckItems()
+	switch {
+	case ok:
+		r.sc.state = scPassed
+		log.Println("[selfcheck] passed → bring-up allowed")
+	case !r.now.Before(r.selfCheckDeadline()):
+		r.sc.state = scFailed
+		for _, it := range items {
+			if !it.OK {
+				log.Println("[selfcheck] ", it.Check, ": ", it.Detail)
+			}
 		}
+		log.Println("[selfcheck] failed → bring-up refused until overridden")
+	case r.sc.published:
+		return
+	}
+	r.applyHold()
+	r.publishSelfCheck(items)
+}
 
-	case stateUpSeq, stateOn:
-		if r.mustCutNow() {
-			r.startDownSeq()
-			return
+// OnSelfCheckOverride releases bring-up from a running or failed
+// self-check.
+func (r *Reactor) OnSelfCheckOverride() {
+	if r.sc.state != scRunning && r.sc.state != scFailed {
+		return
+	}
+	items, _ := r.selfCheckItems()
+	r.sc.state = scOverridden
+	log.Println("[selfcheck] overridden → bring-up allowed")
+	r.applyHold()
+	r.publishSelfCheck(items)
+}
+
+func (r *Reactor) publishSelfCheck(items []types.SelfCheckItem) {
+	r.sc.published = true
+	r.ui.Publish(r.ui.NewMessage(tSelfCheck,
+		types.SelfCheckResult{State: r.sc.state, Checks: items, TS: r.clk.Now().UnixNano()}, true))
+}
+
+// ---- incident counters ----
+
+// loadIncidents restores the counters from HAL's NV store. Without a
+// store they count from this boot and say so.
+func (r *Reactor) loadIncidents() {
+	ctx, cancel := context.WithTimeout(context.Background(), POWER_REPLY_TIMEOUT)
+	defer cancel()
+	m, err := r.ui.RequestWait(ctx, r.ui.NewMessage(tNVGet, types.NVGet{Key: nvIncidents}, false))
+	if err == nil {
+		if rec, ok := m.Payload.(types.NVRecord); ok {
+			r.incidents.Persisted = true
+			if rec.Found {
+				if ot, ed, ok := decodeIncidents(rec.Data); ok {
+					r.incidents.OverTemp, r.incidents.EmergencyDown = ot, ed
+				}
+			}
 		}
 	}
+	if !r.incidents.Persisted {
+		log.Println("[incident] no NV store; counting from boot")
+	}
+	r.publishIncidents()
 }
 
-// ---- LED policy tied to rails state ----
+// countIncident bumps one counter, republishes and persists the set, and
+// brings the log checkpoint forward so it covers the lead-up.
+func (r *Reactor) countIncident(c *uint32) {
+	*c++
+	r.publishIncidents()
+	if r.incidents.Persisted {
+		b := encodeIncidents(r.incidents.OverTemp, r.incidents.EmergencyDown)
+		r.ui.Publish(r.ui.NewMessage(tNVPut, types.NVPut{Key: nvIncidents, Data: b}, false))
+	}
+	if !r.logNext.IsZero() {
+		r.logNext = r.logSavedAt.Add(LASTLOG_MIN_GAP)
+	}
+}
 
-func (r *Reactor) stepLED() {
-	switch r.state {
-	case stateUpSeq, stateOn:
-		r.ledTick = 0
-		if !r.ledSteady {
-			// Steady ON on healthy rails
-			r.ui.Publish(r.ui.NewMessage(tLEDCtrlSet, types.LEDSet{On: true}, false))
-			r.ledSteady = true
+func (r *Reactor) publishIncidents() {
+	r.incidents.TS = r.clk.Now().UnixNano()
+	r.ui.Publish(r.ui.NewMessage(tIncidents, r.incidents, true))
+}
+
+// ---- post-mortem log ----
+
+// loadLastLog fetches the previous boot's log checkpoint before this boot
+// writes its first. Without a log store there is nothing to serve and no
+// checkpoints are taken.
+func (r *Reactor) loadLastLog() {
+	ctx, cancel := context.WithTimeout(context.Background(), POWER_REPLY_TIMEOUT)
+	defer cancel()
+	m, err := r.ui.RequestWait(ctx, r.ui.NewMessage(tLogGet, nil, false))
+	ll, ok := types.LastLog{}, false
+	if err == nil {
+		ll, ok = m.Payload.(types.LastLog)
+	}
+	if !ok {
+		log.Println("[lastlog] no log store; no checkpoints")
+		return
+	}
+	r.lastLog = ll
+	if ll.Found {
+		log.Println("[lastlog] previous boot: ", len(ll.Data), " bytes on sys/lastlog")
+	}
+	r.logSavedAt = r.clk.Now()
+	r.logNext = r.logSavedAt.Add(LASTLOG_EVERY)
+}
+
+// checkpointLog stores the logger's newest output if there is any since
+// the last checkpoint. A full tail starts at its first whole line.
+func (r *Reactor) checkpointLog() {
+	r.logNext = r.now.Add(LASTLOG_EVERY)
+	if p := log.hist.Produced(); p != r.logSaved {
+		data := log.hist.Tail(nil, LASTLOG_BYTES)
+		if len(data) == LASTLOG_BYTES {
+			if i := bytes.IndexByte(data, '\n'); i >= 0 {
+				data = data[i+1:]
+			}
 		}
-	default:
-		// Blink at 1 Hz: 100 ms ON, 900 ms OFF (TICK = 100 ms)
-		r.ledSteady = false
-		r.ledTick++
-		phase := r.ledTick % 10
-		switch phase {
-		case 0:
-			r.ui.Publish(r.ui.NewMessage(tLEDCtrlSet, types.LEDSet{On: true}, false))
-		case 1:
-			// keep ON for one more tick? comment/uncomment to lengthen pulse
-			// r.ui.Publish(r.ui.NewMessage(tLEDCtrlSet, types.LEDSet{On: true}, false))
-		case 2:
-			r.ui.Publish(r.ui.NewMessage(tLEDCtrlSet, types.LEDSet{On: false}, false))
+		// Read after a reboot, so stamped with the wall clock, if known.
+		var ts int64
+		if ms, ok := timesync.EpochMs(r.now); ok {
+			ts = ms * 1e6
 		}
+		r.ui.Publish(r.ui.NewMessage(tLogPut, types.LastLog{TS: ts, Data: data}, false))
+		r.logSaved, r.logSavedAt = p, r.now
 	}
 }
 
+// The NV record is the counts as types.ReactorIncidents in its CBOR
+// encoding (types.MarshalPayload). Records from before that are 12
+// bytes, little-endian: a layout version, then the over-temp and
+// emergency-down counts; they are still read. Anything else is ignored
+// and counting restarts from zero.
+const incidentsV1 = 1
+
+func encodeIncidents(overTemp, emergencyDown uint32) []byte {
+	_, b, _ := types.MarshalPayload(types.ReactorIncidents{OverTemp: overTemp, EmergencyDown: emergencyDown})
+	return b
+}
+
+func decodeIncidents(b []byte) (overTemp, emergencyDown uint32, ok bool) {
+	if len(b) == 12 && le32(b[0:]) == incidentsV1 {
+		return le32(b[4:]), le32(b[8:]), true
+	}
+	v, _, err := types.UnmarshalPayload("ReactorIncidents", b)
+	in, ok := v.(types.ReactorIncidents)
+	if err != nil || !ok {
+		return 0, 0, false
+	}
+	return in.OverTemp, in.EmergencyDown, true
+}
+
+func le32(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+func put32(b []byte, v uint32) {
+	b[0], b[1], b[2], b[3] = byte(v), byte(v>>8), byte(v>>16), byte(v>>24)
+}
+
+// OnButtonLong toggles the manual rails-off latch. Shorter long presses
+// (a button configured with a lower LongMs) are ignored.
+func (r *Reactor) OnButtonLong(v types.ButtonGesture) {
+	if time.Duration(v.HeldMs)*time.Millisecond < SHUTDOWN_HOLD {
+		return
+	}
+	r.fsm.SetUserOff(!r.fsm.UserOff())
+	if r.fsm.UserOff() {
+		log.Println("[power] button held → manual rails DOWN")
+	} else {
+		log.Println("[power] button held → manual latch released")
+	}
+	r.ui.Publish(r.ui.NewMessage(tBuzzerPlay, types.BuzzerPlay{Pattern: "ack"}, false))
+}
+
 // ---- public input updaters (emit telemetry) ----
 
 func (r *Reactor) OnCharger(v types.ChargerValue) {
-	r.vin_mV = v.VIN_mV
+	r.fsm.VIN(v.VIN_mV)
 	r.iin_mA = v.IIn_mA
-	r.tsVIN = r.now
+	r.chgState = types.ChargerStateBits(v.State)
+	r.onBattery = types.SystemStatus(v.Sys)&types.VinGtVbat == 0
 
 	// JSON: {"power/charger/internal/vin":..,"vsys":..,"iin":..}
 	if r.jsonOut != nil {
-		var w jsonw
-		w.write = r.jsonWrite
+		w := r.jsonLine()
 		w.begin()
 		w.kvInt("power/charger/internal/vin", int(v.VIN_mV))
 		w.kvInt("power/charger/internal/vsys", int(v.VSYS_mV))
@@ -429,15 +1120,32 @@ func (r *Reactor) OnCharger(v types.ChargerValue) {
 	}
 }
 
+func (r *Reactor) OnSystemPower(v types.SystemPowerValue) {
+	// JSON: {"power/system/internal/isys":..,"pin":..,"pbat":..,"psys":..}
+	if r.jsonOut != nil {
+		w := r.jsonLine()
+		w.begin()
+		w.kvInt("power/system/internal/isys", int(v.ISys_mA))
+		w.kvInt("power/system/internal/pin", int(v.PIn_mW))
+		w.kvInt("power/system/internal/pbat", int(v.PBat_mW))
+		w.kvInt("power/system/internal/psys", int(v.PSys_mW))
+		if v.EffPct != 0 {
+			w.kvInt("power/system/internal/eff", int(v.EffPct))
+		}
+		w.end()
+	}
+}
+
 func (r *Reactor) OnBattery(v types.BatteryValue) {
-	r.vbat_mV = v.PackMilliV
+	r.fsm.VBAT(v.PackMilliV)
 	r.ibat_mA = v.IBatMilliA
-	r.tsVBAT = r.now
+	if r.sc.state == scRunning {
+		r.sc.vbat, r.sc.vbatSeen = v.PackMilliV, true
+	}
 
 	// JSON: {"power/battery/internal/vbat":..,"ibat":..}
 	if r.jsonOut != nil {
-		var w jsonw
-		w.write = r.jsonWrite
+		w := r.jsonLine()
 		w.begin()
 		w.kvInt("power/battery/internal/vbat", int(v.PackMilliV))
 		w.kvInt("power/battery/internal/ibat", int(v.IBatMilliA))
@@ -449,8 +1157,7 @@ func (r *Reactor) OnBattery(v types.BatteryValue) {
 func (r *Reactor) OnTempDeciC(label string, deci int, jsonKey string) {
 	log.Deci(label, deci)
 	if r.jsonOut != nil {
-		var w jsonw
-		w.write = r.jsonWrite
+		w := r.jsonLine()
 		w.begin()
 		w.kvInt(jsonKey, deci)
 		w.end()
@@ -473,8 +1180,7 @@ func (r *Reactor) emitMemSnapshot() {
 	)
 	// JSON (minimal to keep overhead low)
 	if r.jsonOut != nil {
-		var w jsonw
-		w.write = r.jsonWrite
+		w := r.jsonLine()
 		w.begin()
 		w.kvInt("sys/mem/alloc", int(ms.Alloc))
 		w.end()
@@ -491,17 +1197,37 @@ func main() {
 	log.SetStart(time.Now())
 
 	ctx := context.Background()
+	clk := clock.Real
 
 	log.Println("[main] bootstrapping bus …")
-	b := bus.NewBus(3, "+", "#")
-	halConn := b.NewConnection("hal")
+	bus.SetLimits(busLimits)
+	b := bus.NewBusWithOptions(bus.Options{
+		QueueLen:       3,
+		SingleWildcard: "+",
+		MultiWildcard:  "#",
+		History:        busHistory,
+		Guards:         busGuards,
+		DeadLetter:     busDeadLetter,
+		Clock:          clk,
+	})
 	uiConn := b.NewConnection("ui")
+	defer uiConn.UnsubscribeAll()
 
-	log.Println("[main] starting hal.Run …")
-	go hal.Run(ctx, halConn)
+	metrics.Default.Func("bus.dropped", func() int64 { return int64(b.Dropped()) })
+	metrics.Default.Func("bus.rejected", func() int64 { return int64(b.Rejected()) })
+	metrics.Default.Func("bus.topics", func() int64 { return int64(bus.Interned()) })
 
-	// Wait for retained hal/state=ready (or time out)
-	if !waitHALReady(ctx, halConn, halTimeout) {
+	log.Println("[main] starting services …")
+	sup := supervisor.New(b, clk)
+	for _, svc := range services(clk) {
+		if err := sup.Add(svc); err != nil {
+			log.Println("[main] service ", svc.Name, ": ", err.Error())
+		}
+	}
+	if err := sup.Start(ctx); err != nil {
+		log.Println("[main] ", err.Error())
+	}
+	if st, _ := sup.State("hal"); st.State != types.ServiceReady {
 		for {
 			log.Println("[main] HAL not ready within timeout")
 			time.Sleep(2 * time.Second)
@@ -510,49 +1236,78 @@ func main() {
 
 	// Subscriptions (env + power)
 	log.Println("[main] subscribing env + power …")
-	tempSub := uiConn.Subscribe(tTempValue)
-	tempDieSub := uiConn.Subscribe(tDieTempValue)
-	humidSub := uiConn.Subscribe(tHumValue)
-	valSub := uiConn.Subscribe(valTopic)
-	stSub := uiConn.Subscribe(stTopic)
-	evSub := uiConn.Subscribe(evTopic)
+	tempSub := bus.SubscribeT[types.TemperatureValue](uiConn, tTempValue)
+	tempDieSub := bus.SubscribeT[types.TemperatureValue](uiConn, tDieTempValue)
+	humidSub := bus.SubscribeT[types.HumidityValue](uiConn, tHumValue)
+	capSub := uiConn.SubscribeMany([]bus.Topic{valTopic, stTopic, evTopic})
 
 	// UART sessions (TX only needed for our use)
 	const (
 		uartTele = "uart0" // telemetry JSON
 		uartLog  = "uart1" // log mirror
 	)
-	subSessOpenTele := uiConn.Subscribe(tSessOpened(uartTele))
-	subSessOpenLog := uiConn.Subscribe(tSessOpened(uartLog))
+	subSessOpenTele := bus.SubscribeT[types.SerialSessionOpened](uiConn, tSessOpened(uartTele))
 	subSessClosedTele := uiConn.Subscribe(tSessClosed(uartTele))
-	subSessClosedLog := uiConn.Subscribe(tSessClosed(uartLog))
+	// The log mirror gives way to the bridge; nil channels disable its cases.
+	var subSessOpenLog *bus.SubscriptionT[types.SerialSessionOpened]
+	var openLogC, closedLogC <-chan *bus.Message
+	if uartLog != BRIDGE_UART {
+		subSessOpenLog = bus.SubscribeT[types.SerialSessionOpened](uiConn, tSessOpened(uartLog))
+		openLogC, closedLogC = subSessOpenLog.Channel(), uiConn.Subscribe(tSessClosed(uartLog)).Channel()
+	}
+
+	// Metrics snapshots (mirrored to telemetry UART)
+	metricsSub := bus.SubscribeT[types.MetricsSnapshot](uiConn, metrics.TopicSnapshot)
+
+	// Front-panel button (safe shutdown)
+	buttonSub := bus.SubscribeT[types.ButtonGesture](uiConn, tButtonLong)
+
+	// Replies to power mode requests
+	powerSub := uiConn.Subscribe(tPowerReplies)
+
+	// Thresholds (retained; a stored config import publishes one at boot)
+	cfgSub := bus.SubscribeT[types.ReactorConfig](uiConn, tConfigReactor)
+
+	// Telemetry roll-up (retained)
+	groupSub := bus.SubscribeT[types.GroupState](uiConn, tTelemetryGroup)
+
+	// Startup self-check: its inputs are dropped once it is decided
+	scSub := uiConn.SubscribeMany([]bus.Topic{tCapStatusAll, tTempValueAll})
+	scC := scSub.Channel()
+	overrideSub := uiConn.Subscribe(tSelfCheckOverride)
 
 	// Kick open requests (fire-and-forget; events carry handles)
 	uiConn.Publish(uiConn.NewMessage(tSessOpen(uartTele), nil, false))
-	uiConn.Publish(uiConn.NewMessage(tSessOpen(uartLog), nil, false))
+	if openLogC != nil {
+		uiConn.Publish(uiConn.NewMessage(tSessOpen(uartLog), nil, false))
+	}
 
 	// Retry back-off guards
 	var retryTeleAt, retryLogAt time.Time
 
 	// Reactor
-	r := NewReactor(uiConn)
+	r := NewReactor(uiConn, clk)
+	r.publishConfig()
+	r.loadIncidents()
+	r.loadLastLog()
+	lastLogSub := uiConn.Subscribe(tLastLogGet)
 
-	// Supervisory ticker
-	ticker := time.NewTicker(TICK)
-	defer ticker.Stop()
-	memTick := 0
+	// Supervisory timer: re-armed after every wake for the next due action.
+	wake := clk.NewTimer(0)
+	defer wake.Stop()
+	r.memNext = r.clk.Now().Add(MEM_EVERY)
 
 	log.Println("[main] entering reactor loop …")
 	for {
 		select {
 		// ---- UART session opened/closed ----
 		case m := <-subSessOpenTele.Channel():
-			if ev, ok := m.Payload.(types.SerialSessionOpened); ok {
+			if ev, ok := subSessOpenTele.Value(m); ok {
 				r.jsonOut = shmring.Get(shmring.Handle(ev.TXHandle))
 				log.Println("[uart0] telemetry session opened")
 			}
-		case m := <-subSessOpenLog.Channel():
-			if ev, ok := m.Payload.(types.SerialSessionOpened); ok {
+		case m := <-openLogC:
+			if ev, ok := subSessOpenLog.Value(m); ok {
 				log.SetUART1(shmring.Get(shmring.Handle(ev.TXHandle)))
 				log.Println("[uart1] log session opened")
 			}
@@ -560,136 +1315,248 @@ func main() {
 			r.jsonOut = nil
 			log.Println("[uart0] telemetry session closed")
 			// Auto-reopen with back-off
-			if time.Now().After(retryTeleAt) {
+			if r.clk.Now().After(retryTeleAt) {
 				uiConn.Publish(uiConn.NewMessage(tSessOpen(uartTele), nil, false))
-				retryTeleAt = time.Now().Add(2 * time.Second)
+				retryTeleAt = r.clk.Now().Add(2 * time.Second)
 			}
-		case <-subSessClosedLog.Channel():
+		case <-closedLogC:
 			log.SetUART1(nil)
 			log.Println("[uart1] log session closed")
 			// Auto-reopen with back-off
-			if time.Now().After(retryLogAt) {
+			if r.clk.Now().After(retryLogAt) {
 				uiConn.Publish(uiConn.NewMessage(tSessOpen(uartLog), nil, false))
-				retryLogAt = time.Now().Add(2 * time.Second)
+				retryLogAt = r.clk.Now().Add(2 * time.Second)
 			}
 
 		// ---- Env prints ----
 		case m := <-tempSub.Channel():
-			if v, ok := m.Payload.(types.TemperatureValue); ok {
-				if !aht20Alive {
-					aht20Alive = true
-				}
-				r.now = time.Now()
-				deci := int(v.DeciC)
-				r.lastTDeci = deci
-				r.tsTemp = r.now
-				r.OnTempDeciC("[value] env/temperature/core °C=", deci, "env/temperature/core")
+			v, ok := tempSub.Value(m)
+			if !ok {
+				break
 			}
+			if !aht20Alive {
+				aht20Alive = true
+			}
+			r.now = r.clk.Now()
+			deci := int(v.DeciC)
+			r.fsm.Temp(reactorfsm.TempCore, deci)
+			r.OnTempDeciC("[value] env/temperature/core °C=", deci, "env/temperature/core")
 		case m := <-humidSub.Channel():
-			if v, ok := m.Payload.(types.HumidityValue); ok {
-				log.Hundredths("[value] env/humidity/core %RH=", int(v.RHx100))
-				// JSON
-				if r.jsonOut != nil {
-					var w jsonw
-					w.write = r.jsonWrite
-					w.begin()
-					w.kvInt("env/humidity/core", int(v.RHx100))
-					w.end()
-				}
+			v, ok := humidSub.Value(m)
+			if !ok {
+				break
+			}
+			log.Hundredths("[value] env/humidity/core %RH=", int(v.RHx100))
+			// JSON
+			if r.jsonOut != nil {
+				w := r.jsonLine()
+				w.begin()
+				w.kvInt("env/humidity/core", int(v.RHx100))
+				w.end()
 			}
 
 		// ---- Die Temp Backup ----
 		case m := <-tempDieSub.Channel():
-			if v, ok := m.Payload.(types.TemperatureValue); ok {
-				r.now = time.Now()
-				deci := int(v.DeciC)
-				if !aht20Alive || (r.now.Sub(r.tsTemp) > DIE_TEMP_TAKEOVER) {
-					aht20Alive = false
-					r.lastTDeci = deci
-					r.tsTemp = r.now
-					r.OnTempDeciC("[value] env/temperature/core °C=", deci, "env/temperature/core")
-				}
+			v, ok := tempDieSub.Value(m)
+			if !ok {
+				break
+			}
+			r.now = r.clk.Now()
+			deci := int(v.DeciC)
+			if !aht20Alive || (r.now.Sub(r.fsm.TempAt(reactorfsm.TempCore)) > DIE_TEMP_TAKEOVER) {
+				aht20Alive = false
+				r.fsm.Temp(reactorfsm.TempCore, deci)
+				r.OnTempDeciC("[value] env/temperature/core °C=", deci, "env/temperature/core")
 			}
 
 		// ---- Power values / status / events ----
-		case m := <-valSub.Channel():
-			r.now = time.Now()
-			switch v := m.Payload.(type) {
-			case types.BatteryValue:
-				r.OnBattery(v)
-				printCapValue(m, &r.iin_mA, nil, &r.ibat_mA, nil)
-			case types.ChargerValue:
-				r.OnCharger(v)
-				printCapValue(m, &r.iin_mA, nil, &r.ibat_mA, nil)
-			case types.TemperatureValue:
-				r.OnTempDeciC("[value] power/temperature/internal °C=", int(v.DeciC), "power/temperature/internal")
+		case m := <-capSub.Channel():
+			r.OnCapMessage(capSub.Match(m), m)
+
+		// ---- Button ----
+		case m := <-buttonSub.Channel():
+			v, ok := buttonSub.Value(m)
+			if !ok {
+				break
+			}
+			r.lastActivity = r.clk.Now()
+			r.now = r.lastActivity
+			r.OnButtonLong(v)
+
+		// ---- Thresholds ----
+		case m := <-cfgSub.Channel():
+			if v, ok := cfgSub.Value(m); ok {
+				r.now = r.clk.Now()
+				r.OnConfig(v)
 			}
 
-		case m := <-stSub.Channel():
-			printCapStatus(m)
+		// ---- Telemetry roll-up ----
+		case m := <-groupSub.Channel():
+			if v, ok := groupSub.Value(m); ok {
+				r.now = r.clk.Now()
+				r.OnTelemetryGroup(v)
+			}
 
-		case m := <-evSub.Channel():
-			printCapEvent(m)
-			// JSON: {"<dom>/<kind>/<name>/event":"<tag>"}
-			if r.jsonOut != nil {
-				dom, _ := m.Topic.At(2).(string)
-				kind, _ := m.Topic.At(3).(string)
-				name, _ := m.Topic.At(4).(string)
-				tag, _ := m.Topic.At(6).(string)
-				if dom != "" && kind != "" && name != "" && tag != "" {
-					var w jsonw
-					w.write = r.jsonWrite
-					w.begin()
-					w.kvStr(dom+"/"+kind+"/"+name+"/event", tag)
-					w.end()
+		// ---- Startup self-check ----
+		case m := <-scC:
+			r.now = r.clk.Now()
+			switch v := m.Payload.(type) {
+			case types.CapabilityStatus:
+				r.OnCapStatus(capAddrOf(m.Topic), v)
+			case types.TemperatureValue:
+				r.OnCapTemp(capAddrOf(m.Topic), int32(v.DeciC))
+			}
+		case m := <-lastLogSub.Channel():
+			uiConn.Reply(m, r.lastLog, false)
+		case m := <-overrideSub.Channel():
+			r.now = r.clk.Now()
+			r.OnSelfCheckOverride()
+			uiConn.Reply(m, types.OKReply{OK: true}, false)
+
+		// ---- Power mode replies ----
+		case m := <-powerSub.Channel():
+			r.now = r.clk.Now()
+			mode, _ := m.Topic.At(2).(string)
+			_, ok := m.Payload.(types.OKReply)
+			r.OnPowerReply(types.PowerMode(mode), ok)
+
+		// ---- Metrics snapshot → JSON ----
+		case m := <-metricsSub.Channel():
+			snap, ok := metricsSub.Value(m)
+			if ok && r.jsonOut != nil {
+				w := r.jsonLine()
+				w.begin()
+				for i := range snap.Metrics {
+					w.kvInt("sys/metrics/"+snap.Metrics[i].Name, int(snap.Metrics[i].Value))
 				}
+				w.end()
 			}
 
-		// ---- Supervisory tick ----
-		case <-ticker.C:
-			r.now = time.Now()
-
-			// 1) Run FSM (includes symmetric reversal)
-			r.stepFSM()
+		// ---- Supervisory wake ----
+		case <-wake.C():
+		case <-ctx.Done():
+			return
+		}
 
-			// 2) Advance sequencing steps if due
-			r.advanceSequenceIfDue()
+		// Any input or deadline may change decisions: run what is due, then
+		// sleep until the next deadline.
+		r.now = r.clk.Now()
+		r.step()
+		if scC != nil && r.sc.state != scRunning {
+			scSub.Unsubscribe()
+			scC = nil
+		}
+		resetTimer(wake, r.nextDue().Sub(r.now))
+	}
+}
 
-			// 3) LED behaviour
-			r.stepLED()
+// capAddrOf is the capability address of a hal/cap/<domain>/<kind>/<name>/… topic.
+func capAddrOf(t bus.Topic) types.CapabilityAddress {
+	d, _ := t.At(2).(string)
+	k, _ := t.At(3).(string)
+	n, _ := t.At(4).(string)
+	return types.CapabilityAddress{Domain: d, Kind: types.Kind(k), Name: n}
+}
 
-			// 4) Periodic memory snapshot (~3 s)
-			memTick++
-			if memTick%30 == 0 { // 30 * 100 ms = 3 s
-				r.emitMemSnapshot()
-			}
-		case <-ctx.Done():
-			return
+// resetTimer re-arms t for d, draining a pending fire so the next receive
+// observes only the new deadline.
+func resetTimer(t clock.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C():
+		default:
 		}
 	}
+	if d < 0 {
+		d = 0
+	}
+	t.Reset(d)
 }
 
 // -----------------------------------------------------------------------------
 // HAL readiness helper
 // -----------------------------------------------------------------------------
 
-func waitHALReady(ctx context.Context, c *bus.Connection, d time.Duration) bool {
-	sub := c.Subscribe(halReadiness)
-	defer c.Unsubscribe(sub)
-
-	ctx2, cancel := context.WithTimeout(ctx, d)
-	defer cancel()
+// services lists what the supervisor runs alongside the reactor. The HAL
+// comes first; consumers of its capabilities run after it is ready.
+func services(clk clock.Clock) []supervisor.Service {
+	svcs := []supervisor.Service{
+		{
+			Name:  "hal",
+			Run:   func(ctx context.Context, c *bus.Connection) { hal.Run(ctx, c, clk) },
+			Ready: halReadiness, IsReady: halReady, ReadyTimeout: halTimeout,
+		},
+		// Metrics exporter (sys/metrics)
+		{
+			Name:    "metrics",
+			Run:     func(ctx context.Context, c *bus.Connection) { metrics.Run(ctx, c, METRICS_EVERY) },
+			Restart: supervisor.RestartAlways,
+		},
+		// Derived ISYS / power figures (hal/cap/power/system/internal/value)
+		{
+			Name:    "powersys",
+			Run:     func(ctx context.Context, c *bus.Connection) { powersys.Run(ctx, c, powersys.Config{}) },
+			Restart: supervisor.RestartAlways,
+		},
+		// Wall-clock time from the host or GNSS (sys/time)
+		{
+			Name:    "timesync",
+			Run:     func(ctx context.Context, c *bus.Connection) { timesync.Run(ctx, c, timesync.Config{Clock: clk}) },
+			Restart: supervisor.RestartAlways,
+		},
+		// Daily energy totals (hal/cap/power/energy/internal), kept in NV
+		{
+			Name:    "energy",
+			After:   []string{"hal"},
+			Run:     func(ctx context.Context, c *bus.Connection) { energy.Run(ctx, c, energy.Config{Clock: clk}) },
+			Restart: supervisor.RestartAlways,
+		},
+		// Serial passthrough on demand (passthru/control/start|stop, passthru/state)
+		{
+			Name:    "passthru",
+			After:   []string{"hal"},
+			Run:     func(ctx context.Context, c *bus.Connection) { passthru.Run(ctx, c, passthru.Config{Clock: clk}) },
+			Restart: supervisor.RestartAlways,
+		},
+	}
+	if BUS_DEBUG_EVERY > 0 {
+		svcs = append(svcs, supervisor.Service{
+			Name: "busdebug",
+			Run:  func(ctx context.Context, c *bus.Connection) { bus.RunDebug(ctx, c, BUS_DEBUG_EVERY) },
+		})
+	}
+	if BATT_PROBE != "" {
+		svcs = append(svcs, supervisor.Service{
+			Name:    "tempcomp",
+			Run:     func(ctx context.Context, c *bus.Connection) { tempcomp.Run(ctx, c, tempcomp.Config{Probe: BATT_PROBE}) },
+			Restart: supervisor.RestartAlways,
+		})
+	}
+	if BRIDGE_UART != "" {
+		svcs = append(svcs, supervisor.Service{
+			Name:    "bridge",
+			After:   []string{"hal"},
+			Run:     func(ctx context.Context, c *bus.Connection) { bridge.Run(ctx, c, bridge.Config{Name: BRIDGE_UART}) },
+			Restart: supervisor.RestartAlways,
+		})
+	}
+	return svcs
+}
 
-	for {
-		select {
-		case m := <-sub.Channel():
-			if st, ok := m.Payload.(types.HALState); ok && st.Level == "ready" {
-				return true
-			}
-		case <-ctx2.Done():
-			return false
-		}
+// halReady accepts a retained hal/state of ready, logging the errors of a
+// rejected config meanwhile.
+func halReady(p any) bool {
+	st, ok := p.(types.HALState)
+	if !ok {
+		return false
 	}
+	if st.Level == "ready" {
+		return true
+	}
+	for _, e := range st.Errors {
+		log.Println("[hal] config rejected: ", e.ID, " (", e.Type, ") ", e.Error, " ", e.Detail)
+	}
+	return false
 }
 
 // -----------------------------------------------------------------------------
@@ -701,9 +1568,29 @@ func (r *Reactor) jsonWrite(b []byte) int {
 	if r == nil || r.jsonOut == nil || len(b) == 0 {
 		return 0
 	}
+	if TELEMETRY_FOOTER {
+		r.teleLines.Add(b)
+	}
+	return r.jsonPut(b)
+}
+
+// jsonEOL ends a telemetry line, with its footer if enabled.
+func (r *Reactor) jsonEOL() {
+	if r == nil || r.jsonOut == nil {
+		return
+	}
+	if TELEMETRY_FOOTER {
+		r.jsonPut(append(r.teleLines.Footer(r.teleFoot[:0]), '\n'))
+		return
+	}
+	r.jsonPut(nl[:])
+}
+
+func (r *Reactor) jsonPut(b []byte) int {
 	n := r.jsonOut.TryWriteFrom(b)
 	if n < len(b) {
 		r.droppedUART0Bytes += (len(b) - n)
+		mUART0Dropped.Add(uint32(len(b) - n))
 		// Rate-limited note
 		if r.droppedUART0Bytes == (len(b)-n) || (r.droppedUART0Bytes%1024) == 0 {
 			log.Println("[uart0] dropped bytes =", r.droppedUART0Bytes)
@@ -713,13 +1600,19 @@ func (r *Reactor) jsonWrite(b []byte) int {
 }
 
 // uart1 (logger mirror) — returns bytes written; tracks dropped bytes on partial writes.
+// Everything also goes to the history ring.
 func (l *Logger) logWrite(b []byte) int {
-	if l == nil || l.target == nil || len(b) == 0 {
+	if l == nil || len(b) == 0 {
+		return 0
+	}
+	l.keep(b)
+	if l.target == nil {
 		return 0
 	}
 	n := l.target.TryWriteFrom(b)
 	if n < len(b) {
 		l.droppedUART1Bytes += (len(b) - n)
+		mUART1Dropped.Add(uint32(len(b) - n))
 		// Avoid recursion; print to console directly.
 		if l.droppedUART1Bytes == (len(b)-n) || (l.droppedUART1Bytes%1024) == 0 {
 			print("[uart1] dropped bytes = ")
@@ -730,15 +1623,32 @@ func (l *Logger) logWrite(b []byte) int {
 	return n
 }
 
+// keep adds b to the history ring. Nobody reads that ring: the logger
+// releases everything it writes, and shmring.Tail reads back the newest.
+func (l *Logger) keep(b []byte) {
+	if l.hist == nil {
+		return
+	}
+	for len(b) > 0 {
+		n := l.hist.TryWriteFrom(b)
+		l.hist.ReadRelease(l.hist.Available())
+		b = b[n:]
+	}
+}
+
 // -----------------------------------------------------------------------------
 // Minimal streaming JSON writer for shmring (no buffers/allocs)
 // -----------------------------------------------------------------------------
 
 type jsonw struct {
 	write func([]byte) int
+	eol   func() // ends the line; nil: '\n'
 	first bool
 }
 
+// jsonLine returns a writer for one uart0 telemetry line.
+func (r *Reactor) jsonLine() jsonw { return jsonw{write: r.jsonWrite, eol: r.jsonEOL} }
+
 func (w *jsonw) begin() {
 	w.first = true
 	if w.write != nil {
@@ -746,9 +1656,15 @@ func (w *jsonw) begin() {
 	}
 }
 func (w *jsonw) end() {
-	if w.write != nil {
+	if w.write == nil {
+		return
+	}
+	if w.eol == nil {
 		w.write([]byte("}\n"))
+		return
 	}
+	w.write([]byte("}"))
+	w.eol()
 }
 func (w *jsonw) comma() {
 	if w.write == nil {
@@ -817,35 +1733,25 @@ func (w *jsonw) kvStr(k, s string) {
 // Printing helpers (via Logger)
 // -----------------------------------------------------------------------------
 
-func printCapValue(m *bus.Message, lastIIn *int32, _ *bool, lastIBat *int32, _ *bool) {
+func printCapValue(m *bus.Message) {
 	// hal/cap/<domain>/<kind>/<name>/value
 	dom, _ := m.Topic.At(2).(string)
 	kind, _ := m.Topic.At(3).(string)
 	name, _ := m.Topic.At(4).(string)
 
+	var buf [128]byte
 	switch v := m.Payload.(type) {
 	case types.BatteryValue:
-		log.Print("[value] ", dom, "/", kind, "/", name,
-			" | VBAT=", int(v.PackMilliV), "mV per=", int(v.PerCellMilliV), "mV | IBAT=", int(v.IBatMilliA), "mA | BSR=", int(v.BSR_uOhmPerCell), "uR")
-		if lastIBat != nil {
-			*lastIBat = v.IBatMilliA
-		}
-		if lastIIn != nil {
-			isys := *lastIIn - v.IBatMilliA
-			log.Print(" | ISYS≈", int(isys), "mA")
-		}
-		log.Println()
+		kv, _ := fmtx.AppendKV(buf[:0], v)
+		log.Println("[value] ", dom, "/", kind, "/", name, " | ", kv)
+
+	case types.SystemPowerValue:
+		kv, _ := fmtx.AppendKV(buf[:0], v)
+		log.Println("[value] ", dom, "/", kind, "/", name, " | ", kv)
 
 	case types.ChargerValue:
-		log.Print("[value] ", dom, "/", kind, "/", name,
-			" | VIN=", int(v.VIN_mV), "mV | VSYS=", int(v.VSYS_mV), "mV | IIN=", int(v.IIn_mA), "mA")
-		if lastIIn != nil {
-			*lastIIn = v.IIn_mA
-			if lastIBat != nil {
-				isys := *lastIIn - *lastIBat
-				log.Print(" | ISYS≈", int(isys), "mA")
-			}
-		}
+		kv, _ := fmtx.AppendKV(buf[:0], v)
+		log.Print("[value] ", dom, "/", kind, "/", name, " | ", kv)
 		// ---- human-readable (SET bits only) ----
 		{
 			it := types.NewBitIter(types.SystemStatus(v.Sys), types.SystemStatusTable[:])
@@ -916,11 +1822,9 @@ func printCapStatus(m *bus.Message) {
 	}
 
 	if sVal, ok := m.Payload.(types.CapabilityStatus); ok {
-		log.Println(
-			"[link] ", dom, "/", kind, "/", name,
-			" | link=", string(sVal.Link),
-			" ts=", strconvx.Itoa64(sVal.TS),
-		)
+		var buf [96]byte
+		kv, _ := fmtx.AppendKV(buf[:0], sVal)
+		log.Println("[link] ", dom, "/", kind, "/", name, " | ", kv)
 	}
 }
 
@@ -947,6 +1851,7 @@ func printCapEvent(m *bus.Message) {
 
 type Logger struct {
 	target            *shmring.Ring
+	hist              *shmring.Ring // newest output, for the log checkpoint
 	t0                time.Time
 	lineStart         bool
 	droppedUART1Bytes int // mirror dropped bytes
@@ -981,63 +1886,13 @@ func (l *Logger) writePrefixIfLineStart() {
 		l.t0 = time.Now()
 	}
 	el := time.Since(l.t0)
-	secs := int(el / time.Second)
-	ms := int((el % time.Second) / time.Millisecond) // 0..999
 
-	// Console (no allocations)
-	print(strconvx.Itoa(secs))
-	print(".")
-	if ms < 100 {
-		print("0")
-	}
-	if ms < 10 {
-		print("0")
-	}
-	print(strconvx.Itoa(ms))
-	print(" ")
-
-	// UART1: build once, single write
-	if l.target != nil {
-		var buf [20]byte
-		n := 0
-		n += writeDec(buf[:], n, secs)
-		buf[n] = '.'
-		n++
-		n += writeDecPad3(buf[:], n, ms)
-		buf[n] = ' '
-		n++
-		l.logWrite(buf[:n])
-	}
-}
-func writeDecPad3(dst []byte, off int, v int) int {
-	if v < 0 {
-		v = 0
-	} else if v > 999 {
-		v = 999
-	}
-	dst[off+0] = byte('0' + (v/100)%10)
-	dst[off+1] = byte('0' + (v/10)%10)
-	dst[off+2] = byte('0' + v%10)
-	return 3
-}
-func writeDec(dst []byte, off int, v int) int {
-	if v == 0 {
-		dst[off] = '0'
-		return 1
-	}
-	var tmp [10]byte
-	j := 0
-	for v > 0 {
-		tmp[j] = byte('0' + v%10)
-		v /= 10
-		j++
-	}
-	i := off
-	for k := j - 1; k >= 0; k-- {
-		dst[i] = tmp[k]
-		i++
-	}
-	return i - off
+	// "secs.mmm " built once for both sinks.
+	var buf [24]byte
+	b := strconvx.AppendMilli(buf[:0], int64(el/time.Millisecond))
+	b = append(b, ' ')
+	print(string(b))
+	l.logWrite(b)
 }
 func (l *Logger) writePart(v any) {
 	switch x := v.(type) {
@@ -1080,31 +1935,20 @@ func (l *Logger) newline() {
 func (l *Logger) Println(parts ...any) { l.Print(parts...); l.newline() }
 
 func (l *Logger) Deci(label string, deci int) {
-	l.writePrefixIfLineStart()
-	if deci < 0 {
-		l.writeString(label)
-		l.writeString("-")
-		deci = -deci
-	} else {
-		l.writeString(label)
-	}
-	whole := deci / 10
-	frac := deci % 10
-	l.Println(strconvx.Itoa(whole), ".", strconvx.Itoa(frac))
+	var buf [24]byte
+	l.writeString(label)
+	l.writeBytes(strconvx.AppendDeci(buf[:0], int64(deci)))
+	l.newline()
 }
 func (l *Logger) Hundredths(label string, hx100 int) {
-	l.writePrefixIfLineStart()
 	if hx100 < 0 {
 		hx100 = 0
 	}
-	whole := hx100 / 100
-	frac := hx100 % 100
-	if frac < 10 {
-		l.Println(label, strconvx.Itoa(whole), ".0", strconvx.Itoa(frac))
-	} else {
-		l.Println(label, strconvx.Itoa(whole), ".", strconvx.Itoa(frac))
-	}
+	var buf [24]byte
+	l.writeString(label)
+	l.writeBytes(strconvx.AppendHundredths(buf[:0], int64(hx100)))
+	l.newline()
 }
 
 // Global logger instance
-var log = Logger{lineStart: true}
+var log = Logger{lineStart: true, hist: shmring.New(LASTLOG_BYTES)}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/internal/reactorfsm"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+	"devicecode-go/x/shmring"
+)
+
+func TestIncidentsRecord_RoundTrip(t *testing.T) {
+	b := encodeIncidents(3, 0x01020304)
+	want := append(append(append([]byte{0xa2, 0x69}, "over_temp"...), 0x03, 0x6e), "emergency_down"...)
+	want = append(want, 0x1a, 1, 2, 3, 4)
+	if string(b) != string(want) {
+		t.Fatalf("encoded % x, want % x", b, want)
+	}
+	ot, ed, ok := decodeIncidents(b)
+	if !ok || ot != 3 || ed != 0x01020304 {
+		t.Fatalf("decoded %d,%d,%v", ot, ed, ok)
+	}
+	// A record in the earlier fixed layout is still read.
+	ot, ed, ok = decodeIncidents([]byte{1, 0, 0, 0, 3, 0, 0, 0, 4, 3, 2, 1})
+	if !ok || ot != 3 || ed != 0x01020304 {
+		t.Fatalf("v1 decoded %d,%d,%v", ot, ed, ok)
+	}
+	for _, bad := range [][]byte{nil, b[:8], append(b[:len(b):len(b)], 0), {2, 0, 0, 0, 3, 0, 0, 0, 4, 0, 0, 0}} {
+		if _, _, ok := decodeIncidents(bad); ok {
+			t.Fatalf("% x decoded", bad)
+		}
+	}
+}
+
+// reactorRig drives a Reactor on a fake clock the way the main loop does:
+// inputs stamp r.now, and step runs at each nextDue.
+type reactorRig struct {
+	t     *testing.T
+	clk   *clock.Fake
+	r     *Reactor
+	rails *bus.Subscription
+}
+
+func newReactorRig(t *testing.T) *reactorRig {
+	b := bus.NewBus(64, "+", "#")
+	c := b.NewConnection("reactor")
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	return &reactorRig{t: t, clk: clk, r: NewReactor(c, clk),
+		rails: c.Subscribe(bus.T("hal", "cap", "power", string(types.KindSwitch), "+", "control", "set"))}
+}
+
+// inputs refreshes VIN, VBAT and the core temperature at the current
+// time; the charger NTC stays at a cool 30 °C.
+func (g *reactorRig) inputs(vin, vbat int32, deci int) {
+	g.r.now = g.clk.Now()
+	g.r.OnCharger(types.ChargerValue{VIN_mV: vin})
+	g.r.OnBattery(types.BatteryValue{PackMilliV: vbat})
+	g.r.fsm.Temp(reactorfsm.TempCore, deci)
+	g.r.fsm.Temp(reactorfsm.TempCharger, 300)
+}
+
+// run steps the reactor at each of its deadlines for d, refreshing inputs
+// every second, and returns the rail commands issued with their offsets
+// from the start.
+func (g *reactorRig) run(d time.Duration, vin, vbat int32, deci int) []string {
+	var out []string
+	start, end := g.clk.Now(), g.clk.Now().Add(d)
+	feed := start
+	for g.clk.Now().Before(end) {
+		if !g.clk.Now().Before(feed) {
+			g.inputs(vin, vbat, deci)
+			feed = feed.Add(time.Second)
+		}
+		g.r.now = g.clk.Now()
+		g.r.step()
+		for {
+			select {
+			case m := <-g.rails.Channel():
+				on := "off"
+				if m.Payload.(types.SwitchSet).On {
+					on = "on"
+				}
+				out = append(out, fmt.Sprint(g.clk.Now().Sub(start).Milliseconds(), " ", m.Topic.At(4), " ", on))
+				continue
+			default:
+			}
+			break
+		}
+		next := g.r.nextDue()
+		if next.After(feed) {
+			next = feed
+		}
+		if next.After(end) {
+			next = end
+		}
+		g.clk.Advance(next.Sub(g.clk.Now()))
+	}
+	return out
+}
+
+func TestReactor_UpSequenceThenOverTempDown(t *testing.T) {
+	g := newReactorRig(t)
+
+	// Good supply and temperature: PG debounces, then rails come up one
+	// gap apart.
+	up := g.run(2*time.Second, 13000, 12800, 250)
+	want := []string{"300 mpcie-usb on", "500 m2 on", "700 mpcie on", "900 cm5 on", "1100 fan on", "1600 boost-load on"}
+	if fmt.Sprint(up) != fmt.Sprint(want) {
+		t.Fatalf("up:\n got %v\nwant %v", up, want)
+	}
+	if g.r.fsm.State() != reactorfsm.On {
+		t.Fatalf("state = %v", g.r.fsm.State())
+	}
+
+	// Over-temp latches and takes the rails down in reverse order, each
+	// after the gap of the rail that follows it.
+	down := g.run(2*time.Second, 13000, 12800, TEMP_LIMIT)
+	want = []string{"0 boost-load off", "200 fan off", "400 cm5 off", "600 mpcie off", "800 m2 off", "1000 mpcie-usb off"}
+	if fmt.Sprint(down) != fmt.Sprint(want) {
+		t.Fatalf("down:\n got %v\nwant %v", down, want)
+	}
+	if g.r.fsm.State() != reactorfsm.Off || g.r.incidents.OverTemp != 1 {
+		t.Fatalf("state %v, incidents %+v", g.r.fsm.State(), g.r.incidents)
+	}
+
+	// Cooling to just above the hysteresis point keeps them off.
+	if got := g.run(2*time.Second, 13000, 12800, TEMP_LIMIT-TEMP_HYST+1); len(got) != 0 {
+		t.Fatalf("rails moved before recovery: %v", got)
+	}
+}
+
+func TestReactor_StaleTemperatureCuts(t *testing.T) {
+	g := newReactorRig(t)
+	g.run(2*time.Second, 13000, 12800, 250)
+
+	// Supply readings keep coming; the temperature stops.
+	start := g.clk.Now()
+	var first string
+	for first == "" {
+		g.clk.Advance(500 * time.Millisecond)
+		g.r.now = g.clk.Now()
+		g.r.OnCharger(types.ChargerValue{VIN_mV: 13000})
+		g.r.OnBattery(types.BatteryValue{PackMilliV: 12800})
+		g.r.step()
+		select {
+		case m := <-g.rails.Channel():
+			first = fmt.Sprint(m.Topic.At(4))
+		default:
+		}
+	}
+	// Stale once more than StaleMax has passed since the last sample.
+	if el := g.clk.Now().Sub(start); el <= STALE_MAX-time.Second || el > STALE_MAX+time.Second {
+		t.Fatalf("cut after %v", el)
+	}
+	if first != "boost-load" || g.r.incidents.EmergencyDown != 1 {
+		t.Fatalf("first off %s, incidents %+v", first, g.r.incidents)
+	}
+}
+
+// ledEdges steps the reactor at each of its deadlines for d and returns
+// the button LED changes with their offsets from the start.
+func (g *reactorRig) ledEdges(led *bus.Subscription, d time.Duration) []string {
+	var out []string
+	start, end := g.clk.Now(), g.clk.Now().Add(d)
+	for g.clk.Now().Before(end) {
+		g.r.now = g.clk.Now()
+		g.r.step()
+		for len(led.Channel()) > 0 {
+			on := "off"
+			if (<-led.Channel()).Payload.(types.LEDSet).On {
+				on = "on"
+			}
+			out = append(out, fmt.Sprint(g.clk.Now().Sub(start).Milliseconds(), " ", on))
+		}
+		next := g.r.nextDue()
+		if next.After(end) {
+			next = end
+		}
+		g.clk.Advance(next.Sub(g.clk.Now()))
+	}
+	return out
+}
+
+func TestReactor_LEDPatternFollowsState(t *testing.T) {
+	g := newReactorRig(t)
+	led := g.r.ui.Subscribe(tLEDCtrlSet)
+	g.run(2*time.Second, 13000, 12800, 250)
+	for len(led.Channel()) > 0 {
+		<-led.Channel()
+	}
+
+	// Rails up on a supply and charging.
+	g.r.OnCharger(types.ChargerValue{VIN_mV: 13000, Sys: uint16(types.VinGtVbat), State: uint16(types.CCCVCharge)})
+	if got := g.ledEdges(led, 2100*time.Millisecond); fmt.Sprint(got) != "[0 on 1800 off 2000 on]" {
+		t.Fatalf("charging: %v", got)
+	}
+
+	// A battery fault outranks it.
+	g.r.OnCharger(types.ChargerValue{VIN_mV: 13000, Sys: uint16(types.VinGtVbat), State: uint16(types.BatMissingFault)})
+	if got := g.ledEdges(led, 250*time.Millisecond); fmt.Sprint(got) != "[0 on 100 off 200 on]" {
+		t.Fatalf("fault: %v", got)
+	}
+
+	// Config replaces one pattern; the fault shows it from the start.
+	c := defaultReactorConfig()
+	c.LED = []types.LEDPattern{{State: "fault", Steps_ms: []uint16{50, 450}}}
+	g.r.OnConfig(c)
+	if got := g.ledEdges(led, 600*time.Millisecond); fmt.Sprint(got) != "[0 on 50 off 500 on 550 off]" {
+		t.Fatalf("configured fault: %v", got)
+	}
+	for _, bad := range [][]types.LEDPattern{
+		{{State: "party", Steps_ms: []uint16{100, 100}}},
+		{{State: "on"}},
+		{{State: "on", Steps_ms: []uint16{100, 100, 100}}},
+		{{State: "on", Steps_ms: []uint16{100, 0}}},
+	} {
+		if validLED(bad) {
+			t.Fatalf("accepted %+v", bad)
+		}
+	}
+}
+
+func TestReactor_SelfCheckGatesFirstBringUp(t *testing.T) {
+	g := newReactorRig(t)
+	res := g.r.ui.Subscribe(tSelfCheck)
+	charger := types.CapabilityAddress{Domain: "power", Kind: types.KindCharger, Name: "internal"}
+	core := types.CapabilityAddress{Domain: "env", Kind: types.KindTemperature, Name: "core"}
+	die := types.CapabilityAddress{Domain: "env", Kind: types.KindTemperature, Name: "die"}
+	c := defaultReactorConfig()
+	c.SelfCheck = types.SelfCheckConfig{Required: []types.CapabilityAddress{charger}, MinVBAT_mV: 12000,
+		Temps: []types.CapabilityAddress{core, die}, TempTol_deciC: 50, Timeout_ms: 5000}
+	g.r.OnConfig(c)
+
+	// Charger up, battery fine, but the sensors disagree: nothing comes up,
+	// and at the deadline the check fails naming the spread.
+	g.r.OnCapStatus(charger, types.CapabilityStatus{Link: types.LinkUp})
+	g.r.OnCapTemp(core, 250)
+	g.r.OnCapTemp(die, 320)
+	if got := g.run(6*time.Second, 13000, 12800, 250); len(got) != 0 {
+		t.Fatalf("rails moved before the self-check passed: %v", got)
+	}
+	var last types.SelfCheckResult
+	for len(res.Channel()) > 0 {
+		last = (<-res.Channel()).Payload.(types.SelfCheckResult)
+	}
+	if last.State != scFailed || len(last.Checks) != 3 || last.Checks[2].Detail != "too_wide" || last.Checks[2].Value != 70 ||
+		!last.Checks[0].OK || !last.Checks[1].OK {
+		t.Fatalf("result %+v", last)
+	}
+
+	// Agreement after the deadline changes nothing; the override does.
+	g.r.OnCapTemp(die, 260)
+	if got := g.run(time.Second, 13000, 12800, 250); len(got) != 0 {
+		t.Fatalf("rails moved after failing: %v", got)
+	}
+	g.r.OnSelfCheckOverride()
+	if got := g.run(2*time.Second, 13000, 12800, 250); len(got) != len(powerSeq) {
+		t.Fatalf("after override: %v", got)
+	}
+	if last = (<-res.Channel()).Payload.(types.SelfCheckResult); last.State != scOverridden {
+		t.Fatalf("result %+v", last)
+	}
+}
+
+func TestReactor_CapMessagesWithTelemetryOpen(t *testing.T) {
+	g := newReactorRig(t)
+	g.r.jsonOut = shmring.New(8192)
+	capSub := g.r.ui.SubscribeMany([]bus.Topic{valTopic, stTopic, evTopic})
+	charger := func(tail ...bus.Token) bus.Topic {
+		return bus.T(append([]bus.Token{"hal", "cap", "power", string(types.KindCharger), "internal"}, tail...)...)
+	}
+
+	// Values and statuses are one token short of an event's tag.
+	g.r.ui.Publish(g.r.ui.NewMessage(charger("value"), types.ChargerValue{VIN_mV: 13000}, false))
+	g.r.ui.Publish(g.r.ui.NewMessage(charger("status"), types.CapabilityStatus{Link: types.LinkUp}, false))
+	g.r.ui.Publish(g.r.ui.NewMessage(charger("event", "vin_lo"), types.VinCollapseWarning{}, false))
+	for i := 0; i < 3; i++ {
+		m := <-capSub.Channel()
+		g.r.OnCapMessage(capSub.Match(m), m)
+	}
+
+	buf := make([]byte, 8192)
+	out := string(buf[:g.r.jsonOut.TryReadInto(buf)])
+	if !strings.Contains(out, `"power/charger/internal/event":"vin_lo"`) {
+		t.Fatalf("telemetry %q", out)
+	}
+}
@@ -0,0 +1,289 @@
+package bridge
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+	"devicecode-go/x/lz4"
+)
+
+func TestFrame_TopicRoundTrip(t *testing.T) {
+	if got := PathString(Path("/hal/cap/+/#/")); got != "hal/cap/+/#" {
+		t.Fatalf("path = %q", got)
+	}
+	b := bus.NewBus(4, "+", "#")
+	c := b.NewConnection("t")
+	m := c.NewMessage(bus.T("hal", "cap", "env", 3, "value"), types.TemperatureValue{DeciC: 253}, true)
+
+	// Through JSON and back, as it crosses the wire.
+	raw, err := json.Marshal(msgFrame(OpMsg, 9, m, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f Frame
+	if err := json.Unmarshal(raw, &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.ID != 9 || !f.Retained || f.Type != "TemperatureValue" || string(f.Payload) != `{"deci_c":253}` {
+		t.Fatalf("frame = %+v", f)
+	}
+	if tp := topicOf(f.Topic); tp.Len() != 5 || tp.At(3) != 3 || tp.At(4) != "value" {
+		t.Fatalf("topic = %v", f.Topic)
+	}
+	e := errFrame(2, errcode.Timeout)
+	if e.ErrCode() != errcode.Timeout || (Frame{Err: "busy"}).ErrCode() != errcode.Busy {
+		t.Fatalf("error frame = %+v", e)
+	}
+}
+
+func TestFrameReader_ResyncsOnNextLine(t *testing.T) {
+	long := `{"op":"pub","p":"` + strings.Repeat("x", maxLine) + `"}`
+	in := "{\"op\":\"ping\",\"id\":1}\n" +
+		"garbage {\n" + // not JSON
+		"{\"op\":\"ping\",\"id\":\n" + // cut short by a reset
+		long + "\n" +
+		"\r\n" + // blank: skipped without complaint
+		"{\"op\":\"ping\",\"id\":2}\r\n"
+	fr := newFrameReader(strings.NewReader(in))
+	var ids []uint32
+	bad := 0
+	for {
+		var f Frame
+		err := fr.next(&f)
+		if err == errBadFrame {
+			bad++
+			continue
+		}
+		if err != nil {
+			break
+		}
+		ids = append(ids, f.ID)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 || bad != 3 {
+		t.Fatalf("ids %v, bad %d", ids, bad)
+	}
+}
+
+func TestFrameReader_ExpandsZFrames(t *testing.T) {
+	block := lz4.Compress(nil, []byte("{\"op\":\"pong\",\"id\":1}\n{\"op\":\"pong\",\"id\":2}\n"))
+	z, _ := json.Marshal(Frame{Op: OpZ, Z: block})
+	nested, _ := json.Marshal(Frame{Op: OpZ, Z: lz4.Compress(nil, append(z, '\n'))})
+	in := string(z) + "\n" +
+		`{"op":"z","z":"AAAA"}` + "\n" + // not an LZ4 block
+		string(nested) + "\n" +
+		"{\"op\":\"pong\",\"id\":3}\n"
+	fr := newFrameReader(strings.NewReader(in))
+	var ids []uint32
+	bad := 0
+	for {
+		var f Frame
+		err := fr.next(&f)
+		if err == errBadFrame {
+			bad++
+			continue
+		}
+		if err != nil {
+			break
+		}
+		ids = append(ids, f.ID)
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 2 || ids[2] != 3 || bad != 2 {
+		t.Fatalf("ids %v, bad %d", ids, bad)
+	}
+	if fr.stats.Blocks != 2 || fr.stats.Wire != uint64(len(z)+len(nested)+2) {
+		t.Fatalf("stats = %+v", fr.stats)
+	}
+}
+
+// link serves b over a pipe and returns a client on the other end.
+func link(t *testing.T, b *bus.Bus) (*Client, net.Conn) {
+	t.Helper()
+	dev, host := net.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { _ = Serve(ctx, b.NewConnection("bridge"), dev); close(done) }()
+	c := NewClient(host)
+	t.Cleanup(func() {
+		cancel()
+		c.Close()
+		dev.Close()
+		<-done
+	})
+	return c, dev
+}
+
+func within(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestServe_TopicsSubscribePublishCall(t *testing.T) {
+	b := bus.NewBus(8, "+", "#")
+	dev := b.NewConnection("dev")
+	dev.Publish(dev.NewMessage(bus.T("hal", "cap", "env", "temperature", "core", "value"), types.TemperatureValue{DeciC: 200}, true))
+	dev.Publish(dev.NewMessage(bus.T("hal", "state"), types.HALState{Level: "ready"}, true))
+	c, _ := link(t, b)
+	ctx := within(t)
+
+	fs, err := c.Topics(ctx, Path("hal/cap/#"))
+	if err != nil || len(fs) != 1 || fs[0].Type != "TemperatureValue" {
+		t.Fatalf("topics = %+v (err %v)", fs, err)
+	}
+
+	// Subscribe replays the retained value, then follows publishes.
+	msgs, cancel, err := c.Subscribe(Path("hal/cap/env/+/+/value"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+	if f := <-msgs; string(f.Payload) != `{"deci_c":200}` {
+		t.Fatalf("replay = %s", f.Payload)
+	}
+	dev.Publish(dev.NewMessage(bus.T("hal", "cap", "env", "temperature", "core", "value"), types.TemperatureValue{DeciC: 210}, true))
+	if f := <-msgs; string(f.Payload) != `{"deci_c":210}` {
+		t.Fatalf("follow = %s", f.Payload)
+	}
+
+	// Publish from the host arrives typed on the device bus.
+	in := dev.Subscribe(bus.T("hal", "cap", "power", "switch", "fan", "control", "set"))
+	if err := c.Publish(Path("hal/cap/power/switch/fan/control/set"), "SwitchSet", json.RawMessage(`{"on":true}`), false); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case m := <-in.Channel():
+		if s, ok := m.Payload.(types.SwitchSet); !ok || !s.On {
+			t.Fatalf("published %#v", m.Payload)
+		}
+		// Answer the call below from the same subscription.
+		go func() {
+			m := <-in.Channel()
+			dev.Reply(m, types.OKReply{OK: true}, false)
+		}()
+	case <-ctx.Done():
+		t.Fatal("publish not delivered")
+	}
+
+	r, err := c.Call(ctx, Path("hal/cap/power/switch/fan/control/set"), "SwitchSet", json.RawMessage(`{"on":false}`), time.Second)
+	if err != nil || r.Type != "OKReply" {
+		t.Fatalf("call = %+v (err %v)", r, err)
+	}
+	// Nobody answers: the device times the call out.
+	if _, err := c.Call(ctx, Path("nobody/home"), "", nil, 50*time.Millisecond); err != errcode.Timeout {
+		t.Fatalf("unanswered call err = %v", err)
+	}
+}
+
+func TestServe_SkipsGarbageAndCarriesOn(t *testing.T) {
+	b := bus.NewBus(8, "+", "#")
+	dev, host := net.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go Serve(ctx, b.NewConnection("bridge"), dev)
+	defer host.Close()
+
+	go func() {
+		_, _ = host.Write([]byte("\x00\xff noise\n{\"op\":\"pi"))
+		_, _ = host.Write([]byte("\n{\"op\":\"ping\",\"id\":7}\n"))
+	}()
+	_ = host.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, err := bufio.NewReader(host).ReadBytes('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f Frame
+	if err := json.Unmarshal(line, &f); err != nil || f.Op != OpPong || f.ID != 7 {
+		t.Fatalf("reply = %s (err %v)", line, err)
+	}
+}
+
+func TestServe_CBORPayloads(t *testing.T) {
+	b := bus.NewBus(8, "+", "#")
+	dev := b.NewConnection("dev")
+	info := types.Info{SchemaVersion: 1, Driver: "ds18b20", Detail: &types.TemperatureInfo{Sensor: "ds18b20", Bus: "ow0", ROM: "28ff"}}
+	dev.Publish(dev.NewMessage(bus.T("hal", "cap", "env", "temperature", "probe", "info"), info, true))
+	dev.Publish(dev.NewMessage(bus.T("hal", "cap", "env", "temperature", "probe", "meta"), map[string]any{"x": 1}, true))
+	c, _ := link(t, b)
+	c.UseCBOR()
+	ctx := within(t)
+
+	fs, err := c.Topics(ctx, Path("hal/cap/env/temperature/probe/+"))
+	if err != nil || len(fs) != 2 {
+		t.Fatalf("topics = %+v (err %v)", fs, err)
+	}
+	for _, f := range fs {
+		v, err := f.Value()
+		switch f.Type {
+		case "Info":
+			// The detail comes back by value under its own type name.
+			got, ok := v.(types.Info)
+			if err != nil || len(f.PayloadCBOR) == 0 || len(f.Payload) != 0 || !ok ||
+				got.Driver != "ds18b20" || got.Detail != *info.Detail.(*types.TemperatureInfo) {
+				t.Fatalf("info = %#v (err %v)", v, err)
+			}
+		default:
+			// No CBOR encoding: JSON as before.
+			if err != nil || len(f.PayloadCBOR) != 0 || string(f.Payload) != `{"x":1}` {
+				t.Fatalf("meta = %+v (err %v)", f, err)
+			}
+		}
+	}
+
+	// A CBOR publish is decoded by the type the verb list implies.
+	dev.Publish(dev.NewMessage(bus.T("hal", "cap", "power", "switch", "fan", "verbs"),
+		types.CapabilityVerbs{Verbs: []types.VerbInfo{{Verb: "set", Payload: "SwitchSet"}}}, true))
+	in := dev.Subscribe(bus.T("hal", "cap", "power", "switch", "fan", "control", "set"))
+	_, p, _ := types.MarshalPayload(types.SwitchSet{On: true})
+	if err := c.write(Frame{Op: OpPub, Topic: Path("hal/cap/power/switch/fan/control/set"), PayloadCBOR: p}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case m := <-in.Channel():
+		if s, ok := m.Payload.(types.SwitchSet); !ok || !s.On {
+			t.Fatalf("published %#v", m.Payload)
+		}
+	case <-ctx.Done():
+		t.Fatal("publish not delivered")
+	}
+}
+
+func TestServe_CompressesAfterHello(t *testing.T) {
+	b := bus.NewBus(8, "+", "#")
+	dev := b.NewConnection("dev")
+	for i := 0; i < 40; i++ {
+		dev.Publish(dev.NewMessage(bus.T("hal", "cap", "env", "temperature", i, "value"), types.TemperatureValue{DeciC: int16(200 + i)}, true))
+	}
+	c, _ := link(t, b)
+	ctx := within(t)
+
+	if err := c.UseCompression(ctx); err != nil {
+		t.Fatal(err)
+	}
+	fs, err := c.Topics(ctx, Path("hal/cap/env/#"))
+	if err != nil || len(fs) != 40 {
+		t.Fatalf("topics = %d frames (err %v)", len(fs), err)
+	}
+	for _, f := range fs {
+		v, err := f.Value()
+		tv, ok := v.(types.TemperatureValue)
+		if err != nil || !ok || wireTokens(f.Topic)[4] != int(tv.DeciC)-200 {
+			t.Fatalf("frame %+v = %#v (err %v)", f, v, err)
+		}
+	}
+	if st := c.CompStats(); st.Blocks == 0 || st.Ratio() < 2 {
+		t.Fatalf("stats = %+v, ratio %.2f", st, st.Ratio())
+	}
+
+	// Small replies still go as plain frames.
+	if err := c.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,257 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrClosed is returned once the link has failed or been closed.
+	ErrClosed = errors.New("bridge: closed")
+	// ErrNoCompression reports a device that did not grant compression.
+	ErrNoCompression = errors.New("bridge: compression not granted")
+)
+
+// Client is the host end of a bridge link. It is safe for concurrent use.
+type Client struct {
+	rw io.ReadWriteCloser
+
+	wmu sync.Mutex
+	enc *json.Encoder
+
+	mu      sync.Mutex
+	nextID  uint32
+	wantEnc string // Frame.Enc for requests
+	zstats  CompStats
+	pending map[uint32]*waiter
+	err     error
+	done    chan struct{}
+}
+
+// NewClient starts a client on an open transport (serial device, TCP
+// connection). Close releases it.
+func NewClient(rw io.ReadWriteCloser) *Client {
+	c := &Client{rw: rw, enc: json.NewEncoder(rw), pending: map[uint32]*waiter{}, done: make(chan struct{})}
+	go c.readLoop()
+	return c
+}
+
+func (c *Client) Close() error {
+	err := c.rw.Close()
+	<-c.done
+	return err
+}
+
+// UseCBOR asks the device for CBOR payloads (Frame.PayloadCBOR) on later
+// Topics, Subscribe and Call. Frame.Value decodes either encoding.
+func (c *Client) UseCBOR() {
+	c.mu.Lock()
+	c.wantEnc = EncCBOR
+	c.mu.Unlock()
+}
+
+// UseCompression asks the device to compress what it sends from now on
+// (CompLZ4). A device that cannot refuses with errcode.Unsupported (an
+// older one) or grants nothing (ErrNoCompression); either way the link
+// carries on uncompressed.
+func (c *Client) UseCompression(ctx context.Context) error {
+	r, err := c.exchange(ctx, Frame{Op: OpHello, Comp: CompLZ4})
+	if err == nil && r.Comp != CompLZ4 {
+		err = ErrNoCompression
+	}
+	return err
+}
+
+// CompStats reports the compressed frames received so far.
+func (c *Client) CompStats() CompStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.zstats
+}
+
+func (c *Client) encoding() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.wantEnc
+}
+
+// Err reports why the link ended, or nil while it is up.
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *Client) readLoop() {
+	defer close(c.done)
+	fr := newFrameReader(c.rw)
+	for {
+		var f Frame
+		err := fr.next(&f)
+		if err == errBadFrame {
+			continue
+		}
+		if err != nil {
+			c.mu.Lock()
+			c.err = ErrClosed
+			for id, w := range c.pending {
+				close(w.ch)
+				delete(c.pending, id)
+			}
+			c.mu.Unlock()
+			return
+		}
+		c.mu.Lock()
+		w := c.pending[f.ID]
+		c.zstats = fr.stats
+		c.mu.Unlock()
+		if w == nil {
+			continue
+		}
+		if w.lossy {
+			select {
+			case w.ch <- f:
+			default: // slow subscriber: drop, as the bus would
+			}
+			continue
+		}
+		select {
+		case w.ch <- f:
+		case <-w.gone:
+		}
+	}
+}
+
+// waiter receives the frames for one id. Subscriptions are lossy; topic
+// listings and calls are not.
+type waiter struct {
+	ch    chan Frame
+	gone  chan struct{} // closed when the caller stops listening
+	lossy bool
+}
+
+func (c *Client) open(n int, lossy bool) (uint32, chan Frame, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return 0, nil, c.err
+	}
+	c.nextID++
+	w := &waiter{ch: make(chan Frame, n), gone: make(chan struct{}), lossy: lossy}
+	c.pending[c.nextID] = w
+	return c.nextID, w.ch, nil
+}
+
+func (c *Client) forget(id uint32) {
+	c.mu.Lock()
+	if w := c.pending[id]; w != nil {
+		close(w.gone)
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+}
+
+func (c *Client) write(f Frame) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return c.enc.Encode(f)
+}
+
+// Topics returns the retained messages matching filter.
+func (c *Client) Topics(ctx context.Context, filter []any) ([]Frame, error) {
+	id, ch, err := c.open(16, false)
+	if err != nil {
+		return nil, err
+	}
+	defer c.forget(id)
+	if err := c.write(Frame{Op: OpTopics, ID: id, Topic: filter, Enc: c.encoding()}); err != nil {
+		return nil, err
+	}
+	var out []Frame
+	for {
+		select {
+		case f, ok := <-ch:
+			if !ok {
+				return out, ErrClosed
+			}
+			switch f.Op {
+			case OpEnd:
+				return out, nil
+			case OpError:
+				return out, f.ErrCode()
+			}
+			out = append(out, f)
+		case <-ctx.Done():
+			return out, ctx.Err()
+		}
+	}
+}
+
+// Subscribe delivers messages matching filter until cancel is called or
+// the link ends (the channel is then closed).
+func (c *Client) Subscribe(filter []any) (msgs <-chan Frame, cancel func(), err error) {
+	id, ch, err := c.open(32, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := c.write(Frame{Op: OpSub, ID: id, Topic: filter, Enc: c.encoding()}); err != nil {
+		c.forget(id)
+		return nil, nil, err
+	}
+	var once sync.Once
+	cancel = func() {
+		once.Do(func() {
+			c.forget(id)
+			_ = c.write(Frame{Op: OpUnsub, ID: id})
+		})
+	}
+	return ch, cancel, nil
+}
+
+// Publish sends payload (JSON) to topic. typ names the payload type for
+// types.DecodePayload; empty leaves it to the device.
+func (c *Client) Publish(topic []any, typ string, payload json.RawMessage, retained bool) error {
+	return c.write(Frame{Op: OpPub, Topic: topic, Type: typ, Payload: payload, Retained: retained})
+}
+
+// Call makes a request on topic and returns the reply frame. timeout is
+// applied on the device; ctx bounds the wait here.
+func (c *Client) Call(ctx context.Context, topic []any, typ string, payload json.RawMessage, timeout time.Duration) (Frame, error) {
+	return c.exchange(ctx, Frame{Op: OpCall, Topic: topic, Type: typ, Payload: payload, TimeoutMs: uint32(timeout / time.Millisecond), Enc: c.encoding()})
+}
+
+// Ping round-trips a heartbeat through the device's bridge loop.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.exchange(ctx, Frame{Op: OpPing})
+	return err
+}
+
+// exchange sends f under a fresh id and waits for the one frame answering
+// it; an error frame is returned with its code.
+func (c *Client) exchange(ctx context.Context, f Frame) (Frame, error) {
+	id, ch, err := c.open(1, false)
+	if err != nil {
+		return Frame{}, err
+	}
+	defer c.forget(id)
+	f.ID = id
+	if err := c.write(f); err != nil {
+		return Frame{}, err
+	}
+	select {
+	case r, ok := <-ch:
+		if !ok {
+			return Frame{}, ErrClosed
+		}
+		if r.Op == OpError {
+			return r, r.ErrCode()
+		}
+		return r, nil
+	case <-ctx.Done():
+		return Frame{}, ctx.Err()
+	}
+}
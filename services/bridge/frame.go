@@ -0,0 +1,388 @@
+// Package bridge carries bus traffic over a byte stream so a host can use
+// the device bus: list retained state, subscribe, publish and make
+// request–reply calls. The device runs Serve (or Run, bound to a serial
+// capability); the host uses Client, e.g. through cmd/devicecodectl.
+//
+// The transport is JSON lines, one Frame per line, in the same shape as
+// services/recorder records:
+//
+//	host → device
+//	{"op":"sub","id":1,"topic":["hal","cap","env","+","+","value"]}
+//	{"op":"unsub","id":1}
+//	{"op":"pub","topic":["config","log","hal"],"ret":true,"p":{"level":"debug"}}
+//	{"op":"call","id":2,"topic":[…,"control","set"],"type":"SwitchSet","p":{"on":true},"timeout_ms":500}
+//	{"op":"topics","id":3,"topic":["hal","#"]}
+//	{"op":"ping","id":4}
+//	{"op":"hello","id":5,"comp":"lz4"}
+//
+//	device → host
+//	{"op":"msg","id":1,"topic":[…],"ret":true,"type":"TemperatureValue","p":{"deci_c":253},"seq":7,"mono":…}
+//	{"op":"reply","id":2,"type":"OKReply","p":{"ok":true}}
+//	{"op":"end","id":3}
+//	{"op":"error","id":2,"err":"timeout","code":16}
+//	{"op":"pong","id":4}
+//	{"op":"hello","id":5,"comp":"lz4"}
+//	{"op":"z","z":"…"}
+//
+// A sub, topics or call frame with "enc":"cbor" asks for the payloads it
+// gets back in their CBOR encoding (types.MarshalPayload), base64 in "pc"
+// instead of JSON in "p"; a payload type without one still comes as JSON.
+// A pub or call may likewise carry its payload in "pc", with its type
+// named or implied by the verb list.
+//
+// A hello frame with "comp":"lz4", normally the first on a link, turns on
+// compression of what the device sends after its reply; the reply names
+// the compression granted (none if "comp" is absent). The device then
+// batches its frames, a retained listing especially, and sends each batch
+// as one "z" frame holding the frames' JSON lines as an LZ4 block
+// (x/lz4, base64) when that is shorter. A reader expands a z frame back
+// into its frames. The bridge.z_* metrics count the bytes either side of
+// compression on the device; Client.CompStats does on the host.
+//
+// Either end skips a line that does not decode (or exceeds maxLine) and
+// carries on at the next newline, so noise on a UART costs one frame.
+//
+// Payload types are named with types.PayloadName and rebuilt with
+// types.DecodePayload. A pub or call on a HAL control topic without a type
+// is decoded using the capability's retained verb list.
+//
+// Errors carry the errcode name and its stable number (errcode.Num);
+// clients should switch on the number. ErrorReply payloads do the same.
+//
+// Mirror copies remote topics into a local bus under remap rules and uses
+// ping/pong heartbeats to mark (or clear) them while the device is
+// unreachable.
+package bridge
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"devicecode-go/bus"
+	"devicecode-go/errcode"
+	"devicecode-go/services/metrics"
+	"devicecode-go/types"
+	"devicecode-go/x/lz4"
+)
+
+// Frame ops.
+const (
+	OpSub    = "sub"
+	OpUnsub  = "unsub"
+	OpPub    = "pub"
+	OpCall   = "call"
+	OpTopics = "topics"
+	OpPing   = "ping"
+	OpHello  = "hello"
+
+	OpMsg   = "msg"
+	OpReply = "reply"
+	OpEnd   = "end"
+	OpError = "error"
+	OpPong  = "pong"
+	OpZ     = "z"
+)
+
+// EncCBOR is the Frame.Enc asking for CBOR payloads.
+const EncCBOR = "cbor"
+
+// CompLZ4 is the Frame.Comp asking for (or granting) LZ4 compression.
+const CompLZ4 = "lz4"
+
+// Frame is one line on the wire. ID correlates sub/msg, call/reply and
+// topics/msg…/end.
+type Frame struct {
+	Op          string          `json:"op"`
+	ID          uint32          `json:"id,omitempty"`
+	Topic       []any           `json:"topic,omitempty"`
+	Retained    bool            `json:"ret,omitempty"`
+	Type        string          `json:"type,omitempty"`
+	Payload     json.RawMessage `json:"p,omitempty"`
+	PayloadCBOR []byte          `json:"pc,omitempty"` // CBOR payload
+	Enc         string          `json:"enc,omitempty"`
+	Seq         uint32          `json:"seq,omitempty"`
+	Mono        int64           `json:"mono,omitempty"`
+	TimeoutMs   uint32          `json:"timeout_ms,omitempty"`
+	Err         string          `json:"err,omitempty"`
+	Code        errcode.Num     `json:"code,omitempty"` // Err's wire number
+	Comp        string          `json:"comp,omitempty"`
+	Z           []byte          `json:"z,omitempty"` // LZ4 block of frame lines
+}
+
+// errFrame reports c for request id.
+func errFrame(id uint32, c errcode.Code) Frame {
+	return Frame{Op: OpError, ID: id, Err: string(c), Code: c.Num()}
+}
+
+// ErrCode returns an error frame's code: from the number when present, else
+// the name (older devices send only that).
+func (f Frame) ErrCode() errcode.Code {
+	if f.Code != 0 {
+		return errcode.FromNum(f.Code)
+	}
+	return errcode.Code(f.Err)
+}
+
+// Path splits a slash-separated topic ("hal/cap/+/+/+/value") into wire
+// tokens, in the bus's canonical form (see bus.ParsePath).
+func Path(s string) []any {
+	tp := bus.ParsePath(s)
+	toks := make([]any, tp.Len())
+	for i := range toks {
+		toks[i] = tp.At(i)
+	}
+	return toks
+}
+
+// PathString is the inverse of Path for display. A token that is not a
+// bus token (a peer's odd JSON) is shown as its JSON.
+func PathString(toks []any) string {
+	bt := wireTokens(toks)
+	for _, t := range bt {
+		switch t.(type) {
+		case string, int:
+		default:
+			var b strings.Builder
+			for i, t := range toks {
+				if i > 0 {
+					b.WriteByte('/')
+				}
+				enc, _ := json.Marshal(t)
+				b.Write(enc)
+			}
+			return b.String()
+		}
+	}
+	return bus.TNoIntern(bt...).String()
+}
+
+// msgFrame encodes a bus message, its payload as CBOR if asked and the
+// type has an encoding.
+func msgFrame(op string, id uint32, m *bus.Message, asCBOR bool) Frame {
+	f := Frame{Op: op, ID: id, Retained: m.Retained, Type: types.PayloadName(m.Payload), Seq: m.Seq, Mono: m.Mono}
+	for i := 0; i < m.Topic.Len(); i++ {
+		f.Topic = append(f.Topic, m.Topic.At(i))
+	}
+	if m.Payload == nil {
+		return f
+	}
+	if asCBOR {
+		if _, b, ok := types.MarshalPayload(m.Payload); ok {
+			f.PayloadCBOR = b
+			return f
+		}
+	}
+	if b, err := json.Marshal(m.Payload); err == nil {
+		f.Payload = b
+	}
+	return f
+}
+
+// Value rebuilds the frame's payload: CBOR or registered JSON types as
+// their Go type, other JSON as a generic value.
+func (f Frame) Value() (any, error) {
+	if len(f.PayloadCBOR) > 0 {
+		v, ok, err := types.UnmarshalPayload(f.Type, f.PayloadCBOR)
+		if !ok {
+			return nil, types.ErrNoCBOR
+		}
+		return v, err
+	}
+	if len(f.Payload) == 0 {
+		return nil, nil
+	}
+	if v, ok, err := types.DecodePayload(f.Type, f.Payload); ok {
+		return v, err
+	}
+	var v any
+	err := json.Unmarshal(f.Payload, &v)
+	return v, err
+}
+
+// topicOf is the bus topic for wire tokens.
+func topicOf(raw []any) bus.Topic {
+	return bus.T(wireTokens(raw)...)
+}
+
+// wireTokens maps JSON-decoded tokens to bus tokens: integral numbers to int.
+func wireTokens(raw []any) []bus.Token {
+	toks := make([]bus.Token, len(raw))
+	for i, t := range raw {
+		if f, ok := t.(float64); ok && f == float64(int(f)) {
+			toks[i] = int(f)
+			continue
+		}
+		toks[i] = t
+	}
+	return toks
+}
+
+// ---- line framing ----
+
+// maxLine bounds one frame on the wire; a longer line is skipped.
+const maxLine = 4096
+
+const (
+	zBatch   = maxLine     // a compressing writer flushes a batch this long
+	zMin     = 128         // batches shorter than this go uncompressed
+	maxBlock = 2 * maxLine // a z frame expands to at most this
+)
+
+// errBadFrame reports a line that was skipped.
+var errBadFrame = errors.New("bridge: bad frame")
+
+var (
+	mBadFrames = metrics.NewCounter("bridge.bad_frames")
+	mZBlocks   = metrics.NewCounter("bridge.z_blocks")
+	mZRaw      = metrics.NewCounter("bridge.z_raw_bytes")  // frame lines sent compressed
+	mZWire     = metrics.NewCounter("bridge.z_wire_bytes") // the z lines they went as
+)
+
+// CompStats tallies the z frames a reader has expanded.
+type CompStats struct {
+	Blocks uint64 // z frames
+	Raw    uint64 // bytes of frame lines they held
+	Wire   uint64 // bytes of the z lines themselves
+}
+
+// Ratio is Raw/Wire, 1 before any z frame.
+func (s CompStats) Ratio() float64 {
+	if s.Wire == 0 {
+		return 1
+	}
+	return float64(s.Raw) / float64(s.Wire)
+}
+
+// frameReader reads one Frame per line. A line that does not decode, or
+// is too long, is skipped and reading resumes after its newline, so line
+// noise or a peer reset mid-frame costs only that frame. A z frame is
+// expanded and its frames returned in turn.
+type frameReader struct {
+	r       *bufio.Reader
+	line    []byte
+	block   []byte // the expanded z frame
+	pending []byte // its lines not yet returned
+	stats   CompStats
+}
+
+func newFrameReader(r io.Reader) *frameReader {
+	return &frameReader{r: bufio.NewReaderSize(r, 256)}
+}
+
+// next decodes the next non-blank line into f. It returns errBadFrame
+// for a skipped line (the stream is still usable) and the reader's error
+// otherwise.
+func (fr *frameReader) next(f *Frame) error {
+	for {
+		var line []byte
+		over, inner := false, len(fr.pending) > 0
+		if inner {
+			i := bytes.IndexByte(fr.pending, '\n') + 1
+			if i == 0 {
+				i = len(fr.pending)
+			}
+			line, fr.pending = fr.pending[:i], fr.pending[i:]
+			over = len(line) > maxLine
+		} else {
+			var err error
+			if over, err = fr.readLine(); err != nil {
+				return err
+			}
+			line = fr.line
+		}
+		wire := len(line)
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 && !over {
+			continue // blank line: keep-alive or CRLF residue
+		}
+		*f = Frame{}
+		if over || json.Unmarshal(line, f) != nil {
+			mBadFrames.Inc()
+			return errBadFrame
+		}
+		if f.Op != OpZ {
+			return nil
+		}
+		if inner {
+			mBadFrames.Inc() // z frames do not nest
+			return errBadFrame
+		}
+		block, err := lz4.Decompress(fr.block[:0], f.Z, maxBlock)
+		fr.block = block
+		if err != nil {
+			mBadFrames.Inc()
+			return errBadFrame
+		}
+		fr.pending = block
+		fr.stats.Blocks++
+		fr.stats.Raw += uint64(len(block))
+		fr.stats.Wire += uint64(wire)
+	}
+}
+
+// readLine reads through the next newline into fr.line, keeping at most
+// maxLine bytes; over reports that the line was longer.
+func (fr *frameReader) readLine() (over bool, err error) {
+	fr.line = fr.line[:0]
+	for {
+		chunk, err := fr.r.ReadSlice('\n')
+		if !over && len(fr.line)+len(chunk) > maxLine {
+			over, fr.line = true, fr.line[:0]
+		}
+		if !over {
+			fr.line = append(fr.line, chunk...)
+		}
+		if err != bufio.ErrBufferFull {
+			return over, err
+		}
+	}
+}
+
+// frameWriter writes one Frame per line. With comp set it batches them
+// instead, and flush sends the batch as one z frame if that is shorter.
+type frameWriter struct {
+	w     io.Writer
+	comp  bool
+	batch []byte
+}
+
+func (fw *frameWriter) put(f Frame) error {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if !fw.comp {
+		_, err = fw.w.Write(append(b, '\n'))
+		return err
+	}
+	fw.batch = append(append(fw.batch, b...), '\n')
+	if len(fw.batch) >= zBatch {
+		return fw.flush()
+	}
+	return nil
+}
+
+// flush writes out the batch.
+func (fw *frameWriter) flush() error {
+	raw := fw.batch
+	fw.batch = fw.batch[:0]
+	if len(raw) == 0 {
+		return nil
+	}
+	if len(raw) >= zMin {
+		z, err := json.Marshal(Frame{Op: OpZ, Z: lz4.Compress(nil, raw)})
+		if err == nil && len(z) < len(raw)-1 && len(z) < maxLine {
+			mZBlocks.Inc()
+			mZRaw.Add(uint32(len(raw)))
+			mZWire.Add(uint32(len(z) + 1))
+			_, err = fw.w.Write(append(z, '\n'))
+			return err
+		}
+	}
+	_, err := fw.w.Write(raw)
+	return err
+}
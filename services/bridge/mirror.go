@@ -0,0 +1,240 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/types"
+)
+
+// DownPolicy says what Mirror does with a rule's mirrored retained values
+// while the remote is unreachable.
+type DownPolicy uint8
+
+const (
+	DownMark  DownPolicy = iota // keep the (stale) values; <Local>/link says down
+	DownClear                   // also delete them from the local bus
+)
+
+// Rule mirrors remote topics matching Remote to the local bus, with
+// Remote's literal prefix (the tokens before its first wildcard) replaced
+// by Local, which must not be empty: Remote "hal/cap/env/#", Local
+// "dev1/env" maps hal/cap/env/temperature/core/value to
+// dev1/env/temperature/core/value.
+type Rule struct {
+	Remote string
+	Local  string
+	OnDown DownPolicy
+}
+
+// MirrorConfig configures Mirror.
+type MirrorConfig struct {
+	Rules  []Rule
+	Every  time.Duration // heartbeat interval; default 2 s
+	Misses int           // heartbeat intervals without a pong before down; default 3
+}
+
+// Mirror copies the rules' remote topics onto conn until ctx is cancelled
+// or the link ends, and keeps a retained types.CapabilityStatus on
+// <Local>/link for each rule: up while heartbeats are answered, down once
+// none has been for Misses intervals. Frames arriving while down are
+// dropped; on recovery the rules are re-subscribed, so retained
+// values are replayed. When the link ends every rule is marked down
+// before Mirror returns ErrClosed.
+func Mirror(ctx context.Context, c *Client, conn *bus.Connection, cfg MirrorConfig) error {
+	if cfg.Every <= 0 {
+		cfg.Every = 2 * time.Second
+	}
+	if cfg.Misses <= 0 {
+		cfg.Misses = 3
+	}
+	m := &mirror{c: c, conn: conn, in: make(chan ruleFrame, 32)}
+	for _, r := range cfg.Rules {
+		remote := Path(r.Remote)
+		lit := 0
+		for lit < len(remote) && remote[lit] != "+" && remote[lit] != "#" {
+			lit++
+		}
+		m.rules = append(m.rules, &mirrorRule{Rule: r, remote: remote, lit: lit, local: Path(r.Local), held: map[string]bus.Topic{}})
+	}
+
+	if err := m.subscribe(ctx); err != nil {
+		m.down()
+		return err
+	}
+	m.link(types.LinkUp)
+
+	// Pings run apart from the loop: a stuck link blocks the writer, and
+	// liveness is judged by how long ago the last pong came back.
+	beat := make(chan struct{}, 1)
+	go func() {
+		t := time.NewTicker(cfg.Every)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+			pctx, cancel := context.WithTimeout(ctx, cfg.Every)
+			err := c.Ping(pctx)
+			cancel()
+			if err == nil {
+				select {
+				case beat <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	check := time.NewTicker(cfg.Every)
+	defer check.Stop()
+	defer func() { m.stop() }()
+	up, last := true, time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case rf := <-m.in:
+			if up {
+				m.apply(rf)
+			}
+		case <-beat:
+			last = time.Now()
+			if !up {
+				if err := m.subscribe(ctx); err != nil {
+					continue
+				}
+				up = true
+				m.link(types.LinkUp)
+			}
+		case <-check.C:
+			if c.Err() != nil {
+				if up {
+					m.down()
+				}
+				return ErrClosed
+			}
+			if up && time.Since(last) >= time.Duration(cfg.Misses)*cfg.Every {
+				up = false
+				m.down()
+			}
+		}
+	}
+}
+
+type mirrorRule struct {
+	Rule
+	remote []any
+	lit    int // tokens of remote before the first wildcard
+	local  []any
+	held   map[string]bus.Topic // retained local topics, by path
+}
+
+type ruleFrame struct {
+	r *mirrorRule
+	f Frame
+}
+
+type mirror struct {
+	c     *Client
+	conn  *bus.Connection
+	rules []*mirrorRule
+	in    chan ruleFrame
+	stop  context.CancelFunc // ends the current subscriptions
+}
+
+// subscribe (re)subscribes every rule, ending any previous generation.
+func (m *mirror) subscribe(ctx context.Context) error {
+	if m.stop != nil {
+		m.stop()
+	}
+	sctx, cancel := context.WithCancel(ctx)
+	var cancels []func()
+	m.stop = func() {
+		cancel()
+		for _, f := range cancels {
+			f()
+		}
+	}
+	for _, r := range m.rules {
+		ch, unsub, err := m.c.Subscribe(r.remote)
+		if err != nil {
+			m.stop()
+			return err
+		}
+		cancels = append(cancels, unsub)
+		go func(r *mirrorRule, ch <-chan Frame) {
+			for {
+				select {
+				case f, ok := <-ch:
+					if !ok {
+						return
+					}
+					select {
+					case m.in <- ruleFrame{r, f}:
+					case <-sctx.Done():
+						return
+					}
+				case <-sctx.Done():
+					return
+				}
+			}
+		}(r, ch)
+	}
+	return nil
+}
+
+func (m *mirror) apply(rf ruleFrame) {
+	r, f := rf.r, rf.f
+	if f.Op != OpMsg || len(f.Topic) < r.lit {
+		return
+	}
+	toks := append(append([]any{}, r.local...), f.Topic[r.lit:]...)
+	tp := topicOf(toks)
+	var payload any
+	if len(f.Payload) > 0 && string(f.Payload) != "null" {
+		if v, ok, err := types.DecodePayload(f.Type, f.Payload); ok && err == nil {
+			payload = v
+		} else if err := json.Unmarshal(f.Payload, &payload); err != nil {
+			return
+		}
+	}
+	if f.Retained {
+		key := tp.String()
+		if payload == nil {
+			delete(r.held, key)
+		} else {
+			r.held[key] = tp
+		}
+	}
+	m.conn.Publish(m.conn.NewMessage(tp, payload, f.Retained))
+}
+
+// down marks every rule down and clears those that ask for it.
+func (m *mirror) down() {
+	m.link(types.LinkDown)
+	for _, r := range m.rules {
+		if r.OnDown != DownClear {
+			continue
+		}
+		for key, tp := range r.held {
+			m.conn.Publish(m.conn.NewMessage(tp, nil, true))
+			delete(r.held, key)
+		}
+	}
+}
+
+func (m *mirror) link(l types.Link) {
+	st := types.CapabilityStatus{Link: l, TS: time.Now().UnixNano()}
+	if l == types.LinkDown {
+		st.Error = "bridge_down"
+	}
+	for _, r := range m.rules {
+		toks := append(append([]any{}, r.local...), "link")
+		m.conn.Publish(m.conn.NewMessage(topicOf(toks), st, true))
+	}
+}
@@ -0,0 +1,67 @@
+package bridge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/types"
+)
+
+func TestMirror_RemapsAndMarksDown(t *testing.T) {
+	remote := bus.NewBus(8, "+", "#")
+	rc := remote.NewConnection("dev")
+	rc.Publish(rc.NewMessage(bus.T("hal", "cap", "env", "temperature", "core", "value"), types.TemperatureValue{DeciC: 222}, true))
+	c, dev := link(t, remote)
+
+	local := bus.NewBus(8, "+", "#")
+	lc := local.NewConnection("local")
+	val := lc.Subscribe(bus.T("dev1", "env", "temperature", "core", "value"))
+	lnk := bus.SubscribeT[types.CapabilityStatus](lc, bus.T("dev1", "env", "link"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- Mirror(ctx, c, local.NewConnection("mirror"), MirrorConfig{
+			Rules: []Rule{{Remote: "hal/cap/env/#", Local: "dev1/env", OnDown: DownClear}},
+			Every: 20 * time.Millisecond,
+		})
+	}()
+
+	wait := func(ch <-chan *bus.Message) *bus.Message {
+		t.Helper()
+		select {
+		case m := <-ch:
+			return m
+		case <-time.After(2 * time.Second):
+			t.Fatal("nothing mirrored")
+			return nil
+		}
+	}
+	if st, _ := lnk.Value(wait(lnk.Channel())); st.Link != types.LinkUp {
+		t.Fatalf("link = %+v", st)
+	}
+	if v, ok := wait(val.Channel()).Payload.(types.TemperatureValue); !ok || v.DeciC != 222 {
+		t.Fatalf("mirrored %#v", v)
+	}
+
+	// The device end goes away: the rule is marked down and, with
+	// DownClear, its retained value is withdrawn.
+	dev.Close()
+	if st, _ := lnk.Value(wait(lnk.Channel())); st.Link != types.LinkDown || st.Error != "bridge_down" {
+		t.Fatalf("link = %+v", st)
+	}
+	if m := wait(val.Channel()); m.Payload != nil {
+		t.Fatalf("not cleared: %#v", m.Payload)
+	}
+	select {
+	case err := <-done:
+		if err != ErrClosed {
+			t.Fatalf("mirror err = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("mirror did not end with the link")
+	}
+}
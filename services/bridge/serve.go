@@ -0,0 +1,343 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+	"devicecode-go/x/shmring"
+)
+
+const (
+	callTimeout = time.Second // call default when timeout_ms is 0
+	maxCallTime = 10 * time.Second
+	maxSubs     = 16 // per link
+	outQueue    = 16
+)
+
+// Serve answers bridge frames read from rw until ctx is cancelled or the
+// stream fails. A line that is not a frame is skipped. Subscriptions made
+// by the peer end with it.
+func Serve(ctx context.Context, conn *bus.Connection, rw io.ReadWriter) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	in := make(chan Frame)
+	errc := make(chan error, 1)
+	go func() {
+		fr := newFrameReader(rw)
+		for {
+			var f Frame
+			if err := fr.next(&f); err == errBadFrame {
+				continue // resynced on the next line
+			} else if err != nil {
+				errc <- err
+				return
+			}
+			select {
+			case in <- f:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s := &server{conn: conn, ctx: ctx, out: make(chan Frame, outQueue), subs: map[uint32]*bus.Subscription{}}
+	defer s.unsubscribeAll()
+	fw := &frameWriter{w: rw}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errc:
+			return err
+		case f := <-in:
+			s.handle(f, fw)
+			if err := fw.flush(); err != nil {
+				return err
+			}
+		case f := <-s.out:
+			if err := fw.put(f); err != nil {
+				return err
+			}
+			if len(s.out) > 0 {
+				continue // batch a burst
+			}
+			if err := fw.flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+type server struct {
+	conn *bus.Connection
+	ctx  context.Context
+	out  chan Frame // from forwarders and calls
+	subs map[uint32]*bus.Subscription
+}
+
+func (s *server) send(f Frame) {
+	select {
+	case s.out <- f:
+	case <-s.ctx.Done():
+	}
+}
+
+func (s *server) handle(f Frame, fw *frameWriter) {
+	switch f.Op {
+	case OpSub:
+		if _, dup := s.subs[f.ID]; dup || len(s.subs) >= maxSubs || len(f.Topic) == 0 {
+			_ = fw.put(errFrame(f.ID, errcode.SubscribeRefused))
+			return
+		}
+		sub, err := s.conn.TrySubscribe(topicOf(f.Topic))
+		if err != nil {
+			_ = fw.put(errFrame(f.ID, errcode.SubscribeRefused))
+			return
+		}
+		s.subs[f.ID] = sub
+		go func(id uint32, ch <-chan *bus.Message, asCBOR bool) {
+			for m := range ch {
+				s.send(msgFrame(OpMsg, id, m, asCBOR))
+			}
+		}(f.ID, sub.Channel(), f.Enc == EncCBOR)
+
+	case OpUnsub:
+		if sub, ok := s.subs[f.ID]; ok {
+			s.conn.Unsubscribe(sub)
+			delete(s.subs, f.ID)
+		}
+
+	case OpPub:
+		tp := topicOf(f.Topic)
+		if s.conn.CheckSize(tp, len(f.Payload)+len(f.PayloadCBOR)) != nil {
+			_ = fw.put(errFrame(f.ID, errcode.PublishRefused))
+			return
+		}
+		payload, err := s.decode(tp, f)
+		if err != nil {
+			_ = fw.put(errFrame(f.ID, errcode.InvalidPayload))
+			return
+		}
+		switch err := s.conn.TryPublish(s.conn.NewMessage(tp, payload, f.Retained)); err {
+		case nil:
+		case bus.ErrPayloadType:
+			_ = fw.put(errFrame(f.ID, errcode.InvalidPayload))
+		default:
+			_ = fw.put(errFrame(f.ID, errcode.PublishRefused))
+		}
+
+	case OpCall:
+		tp := topicOf(f.Topic)
+		if s.conn.CheckSize(tp, len(f.Payload)+len(f.PayloadCBOR)) != nil {
+			_ = fw.put(errFrame(f.ID, errcode.PublishRefused))
+			return
+		}
+		payload, err := s.decode(tp, f)
+		if err != nil {
+			_ = fw.put(errFrame(f.ID, errcode.InvalidPayload))
+			return
+		}
+		d := time.Duration(f.TimeoutMs) * time.Millisecond
+		if d <= 0 {
+			d = callTimeout
+		}
+		if d > maxCallTime {
+			d = maxCallTime
+		}
+		go s.call(f.ID, s.conn.NewMessage(tp, payload, false), d, f.Enc == EncCBOR)
+
+	case OpTopics:
+		filter := bus.T("#")
+		if len(f.Topic) > 0 {
+			filter = topicOf(f.Topic)
+		}
+		for _, m := range s.conn.Retained(filter) {
+			if err := fw.put(msgFrame(OpMsg, f.ID, m, f.Enc == EncCBOR)); err != nil {
+				return
+			}
+		}
+		_ = fw.put(Frame{Op: OpEnd, ID: f.ID})
+
+	case OpPing:
+		_ = fw.put(Frame{Op: OpPong, ID: f.ID})
+
+	case OpHello:
+		// The reply goes out as before; the grant applies after it.
+		rep := Frame{Op: OpHello, ID: f.ID}
+		if f.Comp == CompLZ4 {
+			rep.Comp = CompLZ4
+		}
+		_ = fw.put(rep)
+		_ = fw.flush()
+		fw.comp = rep.Comp != ""
+
+	default:
+		_ = fw.put(errFrame(f.ID, errcode.Unsupported))
+	}
+}
+
+func (s *server) call(id uint32, m *bus.Message, d time.Duration, asCBOR bool) {
+	ctx, cancel := context.WithTimeout(s.ctx, d)
+	defer cancel()
+	rep, err := s.conn.RequestWait(ctx, m)
+	if err != nil {
+		s.send(errFrame(id, errcode.Timeout))
+		return
+	}
+	f := msgFrame(OpReply, id, rep, asCBOR)
+	f.Topic = nil // reply inbox is local
+	s.send(f)
+}
+
+func (s *server) unsubscribeAll() {
+	for id, sub := range s.subs {
+		s.conn.Unsubscribe(sub)
+		delete(s.subs, id)
+	}
+}
+
+// decode builds the payload for tp. Named types go through the registry;
+// untyped HAL controls take the type from the capability's verb list;
+// anything else is a generic JSON value. A CBOR payload must resolve to a
+// type.
+func (s *server) decode(tp bus.Topic, f Frame) (any, error) {
+	if len(f.PayloadCBOR) == 0 && (len(f.Payload) == 0 || string(f.Payload) == "null") {
+		return nil, nil
+	}
+	name := f.Type
+	if name == "" {
+		name = s.verbPayload(tp)
+	}
+	if len(f.PayloadCBOR) > 0 {
+		v, ok, err := types.UnmarshalPayload(name, f.PayloadCBOR)
+		if !ok {
+			return nil, types.ErrNoCBOR
+		}
+		return v, err
+	}
+	if v, ok, err := types.DecodePayload(name, f.Payload); ok {
+		return v, err
+	}
+	var v any
+	err := json.Unmarshal(f.Payload, &v)
+	return v, err
+}
+
+func (s *server) verbPayload(tp bus.Topic) string {
+	if tp.Len() != 7 || tp.At(0) != "hal" || tp.At(5) != "control" {
+		return ""
+	}
+	verb, _ := tp.At(6).(string)
+	vt := bus.T("hal", "cap", tp.At(2), tp.At(3), tp.At(4), "verbs")
+	for _, m := range s.conn.Retained(vt) {
+		if cv, ok := m.Payload.(types.CapabilityVerbs); ok {
+			for _, v := range cv.Verbs {
+				if v.Verb == verb {
+					return v.Payload
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// ---- serial binding ----
+
+// Config selects the serial capability the bridge serves on.
+type Config struct {
+	Domain string // default "io"
+	Name   string // e.g. "uart0"
+}
+
+// Run opens a session on the configured serial capability and serves the
+// bridge over its rings until ctx is cancelled. A closed or expired
+// session is re-opened.
+func Run(ctx context.Context, conn *bus.Connection, cfg Config) {
+	if cfg.Domain == "" {
+		cfg.Domain = "io"
+	}
+	base := bus.T("hal", "cap", cfg.Domain, string(types.KindSerial), cfg.Name)
+	opened := bus.SubscribeT[types.SerialSessionOpened](conn, base.Append("event", "session_opened"))
+	defer opened.Unsubscribe()
+	ended := conn.SubscribeMany([]bus.Topic{base.Append("event", "session_closed"), base.Append("event", "session_expired")})
+	defer ended.Unsubscribe()
+
+	open := func() {
+		conn.Publish(conn.NewMessage(base.Append("control", "session_open"), types.SerialSessionOpen{}, false))
+	}
+	open()
+
+	var stop context.CancelFunc = func() {}
+	defer func() { stop() }()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m := <-opened.Channel():
+			ev, ok := opened.Value(m)
+			if !ok {
+				continue
+			}
+			stop()
+			rx := shmring.Get(shmring.Handle(ev.RXHandle))
+			tx := shmring.Get(shmring.Handle(ev.TXHandle))
+			if rx == nil || tx == nil {
+				continue
+			}
+			sctx, cancel := context.WithCancel(ctx)
+			stop = cancel
+			go Serve(sctx, conn, &ringRW{ctx: sctx, rx: rx, tx: tx})
+		case m := <-ended.Channel():
+			stop()
+			if ended.Match(m) == 0 { // closed rather than expired
+				time.Sleep(time.Second)
+			}
+			open()
+		}
+	}
+}
+
+// ringRW adapts a session's rings to io.ReadWriter.
+type ringRW struct {
+	ctx    context.Context
+	rx, tx *shmring.Ring
+}
+
+func (r *ringRW) Read(p []byte) (int, error) {
+	for {
+		if n := r.rx.TryReadInto(p); n > 0 {
+			return n, nil
+		}
+		select {
+		case <-r.ctx.Done():
+			return 0, io.EOF
+		case <-r.rx.Readable():
+		}
+	}
+}
+
+// Write blocks until p is in the TX ring: a partial frame would corrupt
+// the stream. A host that stops reading stalls only the bridge (bus
+// subscriptions drop oldest) until the session is closed or expires.
+func (r *ringRW) Write(p []byte) (int, error) {
+	total := 0
+	for len(p) > 0 {
+		n := r.tx.TryWriteFrom(p)
+		p, total = p[n:], total+n
+		if len(p) == 0 {
+			break
+		}
+		select {
+		case <-r.ctx.Done():
+			return total, io.EOF
+		case <-r.tx.Writable():
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,313 @@
+// Package cli is a small line-oriented debug shell bound to a serial
+// capability. It opens a raw session on the capability, reads commands from
+// the RX ring and answers on the TX ring, translating each command into bus
+// operations:
+//
+//	help                      list commands
+//	caps                      list capabilities (from retained info)
+//	get <topic>               print retained payloads (wildcards allowed)
+//	pub <topic> [json]        publish; HAL controls are decoded by verb type
+//	rail <name> on|off        switch a power rail and print the reply
+//	log level <svc> <level>   publish retained config/log/<svc>
+//
+// Topics are written as slash-separated tokens, e.g. hal/cap/env/+/core/value.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/types"
+	"devicecode-go/x/fmtx"
+	"devicecode-go/x/shmring"
+)
+
+const (
+	maxLine      = 160
+	replyTimeout = 500 * time.Millisecond
+	writeTimeout = 100 * time.Millisecond
+	prompt       = "> "
+)
+
+// Config selects the serial capability to bind to.
+type Config struct {
+	Domain string // default "io"
+	Name   string // e.g. "uart1"
+}
+
+type shell struct {
+	conn *bus.Connection
+	rx   *shmring.Ring
+	tx   *shmring.Ring
+	line []byte
+}
+
+// Run opens a session on the configured serial capability and serves the
+// shell until ctx is cancelled. If the session closes it is re-opened.
+func Run(ctx context.Context, conn *bus.Connection, cfg Config) {
+	if cfg.Domain == "" {
+		cfg.Domain = "io"
+	}
+	base := bus.T("hal", "cap", cfg.Domain, string(types.KindSerial), cfg.Name)
+	opened := bus.SubscribeT[types.SerialSessionOpened](conn, base.Append("event", "session_opened"))
+	defer opened.Unsubscribe()
+	ended := conn.SubscribeMany([]bus.Topic{base.Append("event", "session_closed"), base.Append("event", "session_expired")})
+	defer ended.Unsubscribe()
+
+	open := func() {
+		conn.Publish(conn.NewMessage(base.Append("control", "session_open"), types.SerialSessionOpen{}, false))
+	}
+	open()
+
+	sh := &shell{conn: conn, line: make([]byte, 0, maxLine)}
+	var readable <-chan struct{}
+	buf := make([]byte, 32)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m := <-opened.Channel():
+			ev, ok := opened.Value(m)
+			if !ok {
+				continue
+			}
+			sh.rx = shmring.Get(shmring.Handle(ev.RXHandle))
+			sh.tx = shmring.Get(shmring.Handle(ev.TXHandle))
+			readable = nil
+			if sh.rx != nil {
+				readable = sh.rx.Readable()
+			}
+			sh.line = sh.line[:0]
+			sh.write("\r\ndevicecode shell — type 'help'\r\n" + prompt)
+		case m := <-ended.Channel():
+			// Closed, or reaped idle by the device: the rings are gone.
+			sh.rx, sh.tx, readable = nil, nil, nil
+			if ended.Match(m) == 0 { // closed
+				time.Sleep(time.Second)
+			}
+			open()
+		case <-readable:
+			for {
+				n := sh.rx.TryReadInto(buf)
+				if n == 0 {
+					break
+				}
+				sh.feed(buf[:n])
+			}
+		}
+	}
+}
+
+// feed handles echo, backspace and line completion.
+func (s *shell) feed(p []byte) {
+	for _, c := range p {
+		switch {
+		case c == '\r' || c == '\n':
+			if len(s.line) == 0 && c == '\n' {
+				continue // CRLF tail
+			}
+			s.write("\r\n")
+			s.exec(string(s.line))
+			s.line = s.line[:0]
+			s.write(prompt)
+		case c == 0x08 || c == 0x7f:
+			if len(s.line) > 0 {
+				s.line = s.line[:len(s.line)-1]
+				s.write("\b \b")
+			}
+		case c >= 0x20 && c < 0x7f && len(s.line) < maxLine:
+			s.line = append(s.line, c)
+			s.tx.TryWriteFrom([]byte{c})
+		}
+	}
+}
+
+// write sends s to the TX ring, waiting briefly for space; any remainder
+// after writeTimeout is dropped.
+func (s *shell) write(str string) {
+	if s.tx == nil {
+		return
+	}
+	p := []byte(str)
+	deadline := time.Now().Add(writeTimeout)
+	for len(p) > 0 {
+		n := s.tx.TryWriteFrom(p)
+		p = p[n:]
+		if len(p) == 0 || time.Now().After(deadline) {
+			return
+		}
+		select {
+		case <-s.tx.Writable():
+		case <-time.After(time.Until(deadline)):
+		}
+	}
+}
+
+func (s *shell) println(parts ...string) {
+	s.write(strings.Join(parts, "") + "\r\n")
+}
+
+func (s *shell) exec(line string) {
+	f := strings.Fields(line)
+	if len(f) == 0 {
+		return
+	}
+	switch f[0] {
+	case "help", "?":
+		s.println("help | caps | get <topic> | pub <topic> [json] | rail <name> on|off | log level <svc> <level>")
+	case "caps":
+		s.caps()
+	case "get":
+		if len(f) != 2 {
+			s.println("usage: get <topic>")
+			return
+		}
+		s.get(f[1])
+	case "pub":
+		if len(f) < 2 {
+			s.println("usage: pub <topic> [json]")
+			return
+		}
+		// JSON may contain spaces: take everything after the topic verbatim.
+		rest := strings.TrimSpace(strings.TrimSpace(line)[len("pub"):])
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, f[1]))
+		s.pub(f[1], rest)
+	case "rail":
+		if len(f) != 3 || (f[2] != "on" && f[2] != "off") {
+			s.println("usage: rail <name> on|off")
+			return
+		}
+		s.rail(f[1], f[2] == "on")
+	case "log":
+		if len(f) != 4 || f[1] != "level" {
+			s.println("usage: log level <svc> <level>")
+			return
+		}
+		s.conn.Publish(s.conn.NewMessage(bus.T("config", "log", f[2]), types.LogConfig{Level: types.LogLevel(f[3])}, true))
+		s.println("ok")
+	default:
+		s.println("unknown command: ", f[0])
+	}
+}
+
+func (s *shell) caps() {
+	msgs := s.conn.Retained(bus.T("hal", "cap", "+", "+", "+", "info"))
+	if len(msgs) == 0 {
+		s.println("(none)")
+		return
+	}
+	for _, m := range msgs {
+		t := m.Topic
+		s.println(bus.TNoIntern(t.At(2), t.At(3), t.At(4)).String())
+	}
+}
+
+func (s *shell) get(path string) {
+	msgs := s.conn.Retained(bus.ParsePath(path))
+	if len(msgs) == 0 {
+		s.println("(no retained value)")
+		return
+	}
+	var buf [128]byte
+	for _, m := range msgs {
+		if kv, ok := fmtx.AppendKV(buf[:0], m.Payload); ok {
+			s.println(m.Topic.String(), " ", string(kv))
+			continue
+		}
+		s.println(m.Topic.String(), " ", encode(m.Payload))
+	}
+}
+
+func (s *shell) pub(path, body string) {
+	tp := bus.ParsePath(path)
+	payload, err := s.decode(tp, body)
+	if err != nil {
+		s.println("bad payload: ", err.Error())
+		return
+	}
+	// HAL controls answer; everything else is fire-and-forget.
+	if tp.Len() == 7 && tok(tp.At(0)) == "hal" && tok(tp.At(5)) == "control" {
+		s.request(tp, payload)
+		return
+	}
+	s.conn.Publish(s.conn.NewMessage(tp, payload, false))
+	s.println("ok")
+}
+
+func (s *shell) rail(name string, on bool) {
+	s.request(bus.T("hal", "cap", "power", string(types.KindSwitch), name, "control", "set"), types.SwitchSet{On: on})
+}
+
+func (s *shell) request(tp bus.Topic, payload any) {
+	ctx, cancel := context.WithTimeout(context.Background(), replyTimeout)
+	defer cancel()
+	m, err := s.conn.RequestWait(ctx, s.conn.NewMessage(tp, payload, false))
+	if err != nil {
+		s.println("no reply: ", err.Error())
+		return
+	}
+	s.println(encode(m.Payload))
+}
+
+// decode builds a payload for tp from JSON. For HAL control topics the
+// capability's retained verb list names the payload type, so the JSON is
+// decoded into that concrete type; otherwise a generic value is used.
+func (s *shell) decode(tp bus.Topic, body string) (any, error) {
+	if body == "" {
+		return nil, nil
+	}
+	if tp.Len() == 7 && tok(tp.At(5)) == "control" {
+		verb := tok(tp.At(6))
+		vt := bus.T("hal", "cap", tp.At(2), tp.At(3), tp.At(4), "verbs")
+		for _, m := range s.conn.Retained(vt) {
+			cv, ok := m.Payload.(types.CapabilityVerbs)
+			if !ok {
+				continue
+			}
+			for _, v := range cv.Verbs {
+				if v.Verb == verb {
+					if p, ok, err := types.DecodePayload(v.Payload, []byte(body)); ok {
+						return p, err
+					}
+				}
+			}
+		}
+		if p, ok, err := types.DecodePayload(halVerbPayload[verb], []byte(body)); ok {
+			return p, err
+		}
+	}
+	var v any
+	err := json.Unmarshal([]byte(body), &v)
+	return v, err
+}
+
+// HAL-handled verbs are not in device verb tables.
+var halVerbPayload = map[string]string{
+	"poll_start": "PollStart",
+	"poll_stop":  "PollStop",
+	"burst":      "PollBurst",
+	"read_sync":  "ReadSync",
+}
+
+// ---- topic helpers ----
+
+func tok(t bus.Token) string {
+	if s, ok := t.(string); ok {
+		return s
+	}
+	return "?"
+}
+
+func encode(v any) string {
+	if v == nil {
+		return "null"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "<unencodable>"
+	}
+	return string(b)
+}
@@ -0,0 +1,335 @@
+// Package energy integrates the derived system power capability (see
+// powersys) into daily energy totals: energy in from the input, into and
+// out of the battery, and to the load. It publishes them as a capability,
+// hal/cap/<domain>/energy/<name>/{info,status,value,history}, and keeps
+// them in HAL's NV store so a reboot does not lose the day, which is what
+// solar deployments need to size panels and batteries.
+//
+// Days are UTC calendar days of the wall-clock time from timesync. The
+// board has no RTC, so until the time is synced nothing is integrated;
+// counting then would file energy under a day since boot.
+package energy
+
+import (
+	"context"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/services/timesync"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+// Days of history kept, today included; their sum is the week figure.
+const Days = 7
+
+// Config names the source and the derived capability. Empty fields take
+// the defaults noted.
+type Config struct {
+	Domain    string        // default "power"
+	Source    string        // system power capability name; default "internal"
+	Name      string        // energy capability name; default Source
+	MaxGap    time.Duration // samples further apart are not integrated; default 30s
+	SaveEvery time.Duration // NV write cadence; default 15 min
+	Clock     clock.Clock   // nil: clock.Real
+	// Epoch gives the wall-clock Unix ms of a Clock reading, and whether
+	// time is synced; nil: timesync.EpochMs.
+	Epoch func(time.Time) (int64, bool)
+}
+
+func (c *Config) defaults() {
+	if c.Domain == "" {
+		c.Domain = "power"
+	}
+	if c.Source == "" {
+		c.Source = "internal"
+	}
+	if c.Name == "" {
+		c.Name = c.Source
+	}
+	if c.MaxGap <= 0 {
+		c.MaxGap = 30 * time.Second
+	}
+	if c.SaveEvery <= 0 {
+		c.SaveEvery = 15 * time.Minute
+	}
+	c.Clock = clock.Or(c.Clock)
+	if c.Epoch == nil {
+		c.Epoch = timesync.EpochMs
+	}
+}
+
+// nvReplyTimeout bounds the NV requests; without a store they go
+// unanswered or are refused, and totals then count from boot.
+const nvReplyTimeout = 250 * time.Millisecond
+
+// Run follows the source until ctx is cancelled, publishing today's totals
+// on every sample once time is synced, and the history at start, at each
+// day rollover and at each save.
+func Run(ctx context.Context, conn *bus.Connection, cfg Config) {
+	cfg.defaults()
+	clk := cfg.Clock
+	wall := func() (time.Time, bool) {
+		ms, ok := cfg.Epoch(clk.Now())
+		return time.UnixMilli(ms), ok
+	}
+	key := "energy/" + cfg.Name
+	base := bus.T("hal", "cap", cfg.Domain, string(types.KindEnergy), cfg.Name)
+
+	m := NewMeter(cfg.MaxGap)
+	persisted := load(ctx, conn, key, m)
+	conn.Publish(conn.NewMessage(base.Append("info"), types.Info{
+		SchemaVersion: 1, Driver: "energy",
+		Detail: types.EnergyInfo{Source: cfg.Source, Days: Days, Persisted: persisted},
+	}, true))
+	status := base.Append("status")
+	value := base.Append("value")
+	history := base.Append("history")
+
+	src := bus.SubscribeT[types.SystemPowerValue](conn,
+		bus.T("hal", "cap", cfg.Domain, string(types.KindSystem), cfg.Source, "value"))
+	defer src.Unsubscribe()
+
+	var link types.Link
+	setLink := func(l types.Link) {
+		if l != link {
+			link = l
+			conn.Publish(conn.NewMessage(status,
+				types.CapabilityStatus{Link: l, TS: clk.Now().UnixNano()}, true))
+		}
+	}
+	setLink(types.LinkDown)
+	pubHistory := func() {
+		conn.Publish(conn.NewMessage(history, m.History(clk.Now()), true))
+	}
+	save := func() {
+		if persisted {
+			b := m.Encode()
+			conn.Publish(conn.NewMessage(bus.T("hal", "nv", "control", "put"), types.NVPut{Key: key, Data: b[:]}, false))
+		}
+		pubHistory()
+	}
+	if now, ok := wall(); ok {
+		m.Roll(now)
+	}
+	pubHistory()
+
+	t := clk.NewTimer(cfg.SaveEvery)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			save()
+			return
+		case <-t.C():
+			save()
+			t.Reset(cfg.SaveEvery)
+		case msg := <-src.Channel():
+			v, ok := src.Value(msg)
+			if !ok {
+				continue
+			}
+			now, ok := wall()
+			if !ok {
+				continue // no calendar day yet
+			}
+			if m.Add(now, v) {
+				save() // a day closed
+			}
+			conn.Publish(conn.NewMessage(value, m.Today(clk.Now()), true))
+			setLink(types.LinkUp)
+		}
+	}
+}
+
+// load restores m from the NV store and reports whether there is one.
+func load(ctx context.Context, conn *bus.Connection, key string, m *Meter) bool {
+	ctx, cancel := context.WithTimeout(ctx, nvReplyTimeout)
+	defer cancel()
+	rep, err := conn.RequestWait(ctx, conn.NewMessage(bus.T("hal", "nv", "control", "get"), types.NVGet{Key: key}, false))
+	if err != nil {
+		return false
+	}
+	rec, ok := rep.Payload.(types.NVRecord)
+	if !ok {
+		return false
+	}
+	if rec.Found {
+		m.Decode(rec.Data)
+	}
+	return true
+}
+
+// ---- integration ----
+
+// mWms is one mWh in mW·ms, the unit energy accumulates in so short
+// sample intervals are not rounded away.
+const mWms = 3600 * 1000
+
+type acc struct{ in, toBatt, fromBatt, load int64 } // mW·ms
+
+func (a acc) totals() types.EnergyTotals {
+	return types.EnergyTotals{
+		In_mWh: clampU32(a.in / mWms), ToBatt_mWh: clampU32(a.toBatt / mWms),
+		FromBatt_mWh: clampU32(a.fromBatt / mWms), ToLoad_mWh: clampU32(a.load / mWms),
+	}
+}
+
+func (a *acc) add(t types.EnergyTotals) {
+	a.in += int64(t.In_mWh) * mWms
+	a.toBatt += int64(t.ToBatt_mWh) * mWms
+	a.fromBatt += int64(t.FromBatt_mWh) * mWms
+	a.load += int64(t.ToLoad_mWh) * mWms
+}
+
+func clampU32(v int64) uint32 {
+	if v > 0xFFFFFFFF {
+		return 0xFFFFFFFF
+	}
+	return uint32(v)
+}
+
+// Meter integrates system power samples into per-day totals. Each
+// interval is charged at the power of the sample that opened it, to the
+// day the interval ends in. Roll and Add take wall-clock time; Today and
+// History only stamp theirs. Not safe for concurrent use.
+type Meter struct {
+	maxGap time.Duration
+	day    int32     // Unix day of days[0]
+	days   [Days]acc // days[0] is today
+	last   types.SystemPowerValue
+	lastAt time.Time
+}
+
+// NewMeter returns an empty meter that skips intervals longer than maxGap
+// (the source was down, nothing is known about them).
+func NewMeter(maxGap time.Duration) *Meter { return &Meter{maxGap: maxGap} }
+
+func dayOf(t time.Time) int32 { return int32(t.Unix() / 86400) }
+
+// Roll moves the day window to now and reports whether a day closed. A
+// clock stepped backwards relabels today.
+func (m *Meter) Roll(now time.Time) bool {
+	d := dayOf(now)
+	switch {
+	case d == m.day:
+		return false
+	case d < m.day:
+		m.day = d
+		return false
+	}
+	n := int(d - m.day)
+	if n > Days {
+		n = Days
+	}
+	copy(m.days[n:], m.days[:Days-n])
+	clear(m.days[:n])
+	m.day = d
+	return true
+}
+
+// Add integrates up to now and takes v as the power from now on. It
+// reports whether a day closed.
+func (m *Meter) Add(now time.Time, v types.SystemPowerValue) bool {
+	rolled := m.Roll(now)
+	if dt := now.Sub(m.lastAt); !m.lastAt.IsZero() && dt > 0 && dt <= m.maxGap {
+		ms, a := dt.Milliseconds(), &m.days[0]
+		if m.last.PIn_mW > 0 {
+			a.in += int64(m.last.PIn_mW) * ms
+		}
+		if p := m.last.PBat_mW; p > 0 {
+			a.toBatt += int64(p) * ms
+		} else {
+			a.fromBatt -= int64(p) * ms
+		}
+		if m.last.PSys_mW > 0 {
+			a.load += int64(m.last.PSys_mW) * ms
+		}
+	}
+	m.last, m.lastAt = v, now
+	return rolled
+}
+
+// Today returns today's totals so far.
+func (m *Meter) Today(now time.Time) types.EnergyValue {
+	t := m.days[0].totals()
+	return types.EnergyValue{
+		Day: m.day, In_mWh: t.In_mWh, ToBatt_mWh: t.ToBatt_mWh,
+		FromBatt_mWh: t.FromBatt_mWh, ToLoad_mWh: t.ToLoad_mWh,
+		TS: now.UnixNano(),
+	}
+}
+
+// History returns every kept day, newest first, and their sum.
+func (m *Meter) History(now time.Time) types.EnergyHistory {
+	h := types.EnergyHistory{Day: m.day, Days: make([]types.EnergyTotals, Days), TS: now.UnixNano()}
+	var week acc
+	for i := range m.days {
+		h.Days[i] = m.days[i].totals()
+		week.in += m.days[i].in
+		week.toBatt += m.days[i].toBatt
+		week.fromBatt += m.days[i].fromBatt
+		week.load += m.days[i].load
+	}
+	h.Week = week.totals()
+	return h
+}
+
+// ---- NV record ----
+
+// The NV record is little-endian: a layout version, the Unix day of the
+// first entry, then Days entries of four mWh counts (in, to battery, from
+// battery, to load), newest first. Sub-mWh remainders are not kept. A
+// record of another length or version is ignored.
+const (
+	recordV1  = 1
+	recordLen = 8 + Days*16
+)
+
+// Encode returns the NV record for m.
+func (m *Meter) Encode() [recordLen]byte {
+	var b [recordLen]byte
+	put32(b[0:], recordV1)
+	put32(b[4:], uint32(m.day))
+	for i := range m.days {
+		t, o := m.days[i].totals(), 8+i*16
+		put32(b[o:], t.In_mWh)
+		put32(b[o+4:], t.ToBatt_mWh)
+		put32(b[o+8:], t.FromBatt_mWh)
+		put32(b[o+12:], t.ToLoad_mWh)
+	}
+	return b
+}
+
+// Decode adds a record's totals to m, aligned on its own day window
+// (entries older than the window are dropped), and reports whether the
+// record was valid.
+func (m *Meter) Decode(b []byte) bool {
+	if len(b) != recordLen || le32(b[0:]) != recordV1 {
+		return false
+	}
+	day := int32(le32(b[4:]))
+	if m.day == 0 {
+		m.day = day
+	}
+	for i := 0; i < Days; i++ {
+		j := int(m.day-day) + i // index of that day in m
+		if j < 0 || j >= Days {
+			continue
+		}
+		o := 8 + i*16
+		m.days[j].add(types.EnergyTotals{
+			In_mWh: le32(b[o:]), ToBatt_mWh: le32(b[o+4:]),
+			FromBatt_mWh: le32(b[o+8:]), ToLoad_mWh: le32(b[o+12:]),
+		})
+	}
+	return true
+}
+
+func le32(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+func put32(b []byte, v uint32) {
+	b[0], b[1], b[2], b[3] = byte(v), byte(v>>8), byte(v>>16), byte(v>>24)
+}
@@ -0,0 +1,142 @@
+package energy
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+// day 20000 (2024-10-04) at hh:mm UTC
+func at(day int, hh, mm int) time.Time {
+	return time.Unix(int64(20000+day)*86400, 0).Add(time.Duration(hh)*time.Hour + time.Duration(mm)*time.Minute)
+}
+
+func TestMeter_IntegratesSplitsAndRolls(t *testing.T) {
+	m := NewMeter(30 * time.Second)
+	charging := types.SystemPowerValue{PIn_mW: 20000, PBat_mW: 12000, PSys_mW: 6000}
+	night := types.SystemPowerValue{PBat_mW: -4000, PSys_mW: 3600}
+
+	// Two hours of daylight sampled every 10 s, then a night hour.
+	now := at(0, 10, 0)
+	for i := 0; i < 720; i++ {
+		m.Add(now, charging)
+		now = now.Add(10 * time.Second)
+	}
+	m.Add(now, night)
+	for i := 0; i < 360; i++ {
+		now = now.Add(10 * time.Second)
+		m.Add(now, night)
+	}
+	got := m.Today(now)
+	if got.In_mWh != 40000 || got.ToBatt_mWh != 24000 || got.ToLoad_mWh != 12000+3600 || got.FromBatt_mWh != 4000 {
+		t.Fatalf("today %+v", got)
+	}
+
+	// A gap longer than MaxGap is not integrated.
+	now = now.Add(time.Hour)
+	m.Add(now, night)
+	if m.Today(now).FromBatt_mWh != 4000 {
+		t.Fatal("gap integrated")
+	}
+
+	// Two days on: today is empty, the first day is two back, the week
+	// still counts it.
+	if !m.Add(at(2, 9, 0), charging) {
+		t.Fatal("no rollover")
+	}
+	h := m.History(at(2, 9, 0))
+	if h.Day != 20002 || h.Days[0] != (types.EnergyTotals{}) || h.Days[2].In_mWh != 40000 || h.Week.ToLoad_mWh != 15600 {
+		t.Fatalf("history %+v", h)
+	}
+}
+
+func TestMeter_RecordRoundTrip(t *testing.T) {
+	m := NewMeter(time.Minute)
+	p := types.SystemPowerValue{PIn_mW: 3600, PBat_mW: 1800}
+	for i, now := 0, at(0, 12, 0); i <= 60; i, now = i+1, now.Add(time.Minute) {
+		m.Add(now, p)
+	}
+	m.Roll(at(1, 0, 0))
+	b := m.Encode()
+
+	// Restored a day later: what was yesterday is two days back.
+	r := NewMeter(time.Minute)
+	if !r.Decode(b[:]) {
+		t.Fatal("record refused")
+	}
+	r.Roll(at(2, 8, 0))
+	h := r.History(at(2, 8, 0))
+	if h.Days[2].In_mWh != 3600 || h.Days[2].ToBatt_mWh != 1800 || h.Week.In_mWh != 3600 {
+		t.Fatalf("restored %+v", h)
+	}
+	if r.Decode(b[:10]) || r.Decode(append(b[:], 0)) {
+		t.Fatal("bad record accepted")
+	}
+}
+
+func TestRun_RebootThenSync(t *testing.T) {
+	// An earlier boot that day left an hour of 3.6 W input.
+	prev := NewMeter(time.Minute)
+	for i, now := 0, at(0, 9, 0); i <= 60; i, now = i+1, now.Add(time.Minute) {
+		prev.Add(now, types.SystemPowerValue{PIn_mW: 3600})
+	}
+	rec := prev.Encode()
+
+	// The local clock counts from boot; the epoch source reports unsynced
+	// until offsetMs is set, and signals each use.
+	b := bus.NewBus(128, "+", "#")
+	clk := clock.NewFake(time.Unix(90, 0))
+	var offsetMs atomic.Int64
+	asked := make(chan struct{}, 8)
+	epoch := func(t time.Time) (int64, bool) {
+		asked <- struct{}{}
+		o := offsetMs.Load()
+		return t.UnixMilli() + o, o != 0
+	}
+	c := b.NewConnection("test")
+	get := c.Subscribe(bus.T("hal", "nv", "control", "get"))
+	value := c.Subscribe(bus.T("hal", "cap", "power", string(types.KindEnergy), "internal", "value"))
+	src := bus.T("hal", "cap", "power", string(types.KindSystem), "internal", "value")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go Run(ctx, b.NewConnection("energy"), Config{Clock: clk, Epoch: epoch, MaxGap: 2 * time.Minute})
+	c.Reply(<-get.Channel(), types.NVRecord{Key: "energy/internal", Found: true, Data: rec[:]}, false)
+	<-asked // start-up roll, refused
+
+	// Samples before the sync are not integrated under a boot-relative day.
+	sample := func() {
+		c.Publish(c.NewMessage(src, types.SystemPowerValue{PIn_mW: 3600}, false))
+		<-asked
+	}
+	for i := 0; i < 3; i++ {
+		sample()
+		clk.Advance(10 * time.Second)
+	}
+	if len(value.Channel()) != 0 {
+		t.Fatal("counted before the time was synced")
+	}
+
+	// Synced into the same calendar day: the restored total carries on.
+	offsetMs.Store(at(0, 18, 0).UnixMilli() - clk.Now().UnixMilli())
+	for i := 0; i <= 60; i++ {
+		sample()
+		clk.Advance(time.Minute)
+	}
+	var v types.EnergyValue
+	for i := 0; i <= 60; i++ {
+		select {
+		case m := <-value.Channel():
+			v = m.Payload.(types.EnergyValue)
+		case <-time.After(time.Second):
+			t.Fatalf("value %d missing", i)
+		}
+	}
+	if v.Day != 20000 || v.In_mWh != 7200 {
+		t.Fatalf("after sync %+v", v)
+	}
+}
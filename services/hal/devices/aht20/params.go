@@ -0,0 +1,30 @@
+package aht20dev
+
+import "devicecode-go/x/cbor"
+
+// MarshalCBOR and UnmarshalCBOR carry Params through config export and
+// import (hal/config/control), keyed by the JSON field names.
+func (p Params) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("Bus", p.Bus)
+		m.Uint("Addr", uint64(p.Addr))
+		m.Text("Domain", p.Domain)
+		m.Text("Name", p.Name)
+	})
+}
+
+func (p *Params) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Bus":
+			return cbor.ReadText(d, &p.Bus)
+		case "Addr":
+			return cbor.ReadUint(d, &p.Addr)
+		case "Domain":
+			return cbor.ReadText(d, &p.Domain)
+		case "Name":
+			return cbor.ReadText(d, &p.Name)
+		}
+		return d.Skip()
+	})
+}
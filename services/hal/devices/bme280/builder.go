@@ -0,0 +1,68 @@
+package bme280dev
+
+import (
+	"context"
+
+	"devicecode-go/drivers/bme280"
+	"devicecode-go/errcode"
+	envdev "devicecode-go/services/hal/devices/env"
+	"devicecode-go/services/hal/internal/core"
+)
+
+func init() {
+	core.RegisterBuilder("bme280", builder{chip: bme280.ChipBME280})
+	core.RegisterParams[Params]("bme280")
+	core.RegisterBuilder("bmp280", builder{chip: bme280.ChipBMP280})
+	core.RegisterParams[Params]("bmp280")
+}
+
+type Params struct {
+	Bus    string // e.g. "i2c0"
+	Addr   uint16 // defaults to bme280.Address (0x76) if zero
+	Domain string // REQUIRED, e.g. "env"
+	Name   string // REQUIRED
+
+	// Station altitude (m, -500..9000) for the sea-level pressure.
+	AltitudeM int32
+	// Oversampling ×1..×16 for temperature, pressure and humidity (0: ×1)
+	// and the IIR filter coefficient (0: off); see bme280.Config.
+	OversampleT, OversampleP, OversampleH uint8
+	Filter                                uint8
+}
+
+type builder struct{ chip uint8 }
+
+func (b builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, error) {
+	p, ok := in.Params.(Params)
+	if !ok || p.Bus == "" || p.Domain == "" || p.Name == "" {
+		return nil, errcode.InvalidParams
+	}
+	if p.AltitudeM < -500 || p.AltitudeM > 9000 {
+		return nil, &errcode.E{C: errcode.InvalidParams, Op: "build", Msg: "altitude out of range", Field: "AltitudeM"}
+	}
+	if p.Addr == 0 {
+		p.Addr = bme280.Address
+	}
+	bus, err := in.Res.Reg.ClaimI2C(in.ID, core.ResourceID(p.Bus))
+	if err != nil {
+		return nil, err
+	}
+
+	// Configure (chip ID check, calibration) occurs on the first Trigger.
+	drv := bme280.New(bus, bme280.Config{
+		Address: p.Addr, Chip: b.chip,
+		OversampleT: p.OversampleT, OversampleP: p.OversampleP, OversampleH: p.OversampleH,
+		Filter: p.Filter,
+	})
+	sensor := "bme280"
+	if b.chip == bme280.ChipBMP280 {
+		sensor = "bmp280"
+	}
+	return envdev.New(envdev.Config{
+		ID: in.ID, Bus: p.Bus, Addr: p.Addr, Domain: p.Domain, Name: p.Name,
+		Sensor: sensor, Drv: &drv,
+		NoHumidity: b.chip == bme280.ChipBMP280, Pressure: true, AltitudeM: p.AltitudeM,
+		MinDeciC: -400, MaxDeciC: 850, // −40..85 °C
+		Res: in.Res,
+	}), nil
+}
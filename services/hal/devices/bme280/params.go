@@ -0,0 +1,45 @@
+package bme280dev
+
+import "devicecode-go/x/cbor"
+
+// MarshalCBOR and UnmarshalCBOR carry Params through config export and
+// import (hal/config/control), keyed by the JSON field names.
+func (p Params) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("Bus", p.Bus)
+		m.Uint("Addr", uint64(p.Addr))
+		m.Text("Domain", p.Domain)
+		m.Text("Name", p.Name)
+		m.Int("AltitudeM", int64(p.AltitudeM))
+		m.Uint("OversampleT", uint64(p.OversampleT))
+		m.Uint("OversampleP", uint64(p.OversampleP))
+		m.Uint("OversampleH", uint64(p.OversampleH))
+		m.Uint("Filter", uint64(p.Filter))
+	})
+}
+
+func (p *Params) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Bus":
+			return cbor.ReadText(d, &p.Bus)
+		case "Addr":
+			return cbor.ReadUint(d, &p.Addr)
+		case "Domain":
+			return cbor.ReadText(d, &p.Domain)
+		case "Name":
+			return cbor.ReadText(d, &p.Name)
+		case "AltitudeM":
+			return cbor.ReadInt(d, &p.AltitudeM)
+		case "OversampleT":
+			return cbor.ReadUint(d, &p.OversampleT)
+		case "OversampleP":
+			return cbor.ReadUint(d, &p.OversampleP)
+		case "OversampleH":
+			return cbor.ReadUint(d, &p.OversampleH)
+		case "Filter":
+			return cbor.ReadUint(d, &p.Filter)
+		}
+		return d.Skip()
+	})
+}
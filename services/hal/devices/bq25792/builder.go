@@ -0,0 +1,94 @@
+package bq25792dev
+
+import (
+	"context"
+
+	"devicecode-go/drivers/bq25792"
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+// Params mirror ltc4015dev.Params where the parts overlap; the BQ25792
+// senses current internally, so there are no shunt values.
+type Params struct {
+	Bus   string // e.g. "i2c0" (required)
+	Addr  uint16 // default 0x6B
+	Cells uint8  // required; checked against the PROG strap
+
+	// Optional INT# (active-low pulse): when Int is set a falling edge
+	// clears the flags and samples at once instead of waiting for a poll.
+	Int        bool
+	IntPin     int
+	IntPinName string // optional alias; overrides IntPin
+
+	DomainBattery string // required
+	DomainCharger string // required
+	Name          string // required
+
+	Boot []types.BootAction `json:"boot,omitempty"`
+}
+
+func init() {
+	core.RegisterBuilder("bq25792", builder{})
+	core.RegisterParams[Params]("bq25792")
+}
+
+type builder struct{}
+
+func (builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, error) {
+	p, ok := in.Params.(Params)
+	if !ok {
+		if pp, ok2 := in.Params.(*Params); ok2 && pp != nil {
+			p = *pp
+		} else {
+			return nil, errcode.InvalidParams
+		}
+	}
+	switch {
+	case p.Bus == "", p.Cells < 1, p.Cells > 4,
+		p.DomainBattery == "", p.DomainCharger == "", p.Name == "":
+		return nil, errcode.InvalidParams
+	}
+	if p.Addr == 0 {
+		p.Addr = bq25792.Address
+	}
+
+	pin := -1
+	if p.Int {
+		n, err := core.ResolvePin(in.Res.Reg, p.IntPin, p.IntPinName)
+		if err != nil {
+			return nil, err
+		}
+		pin = n
+	}
+	i2c, err := in.Res.Reg.ClaimI2C(in.ID, core.ResourceID(p.Bus))
+	if err != nil {
+		return nil, err
+	}
+	if pin >= 0 {
+		ph, err := in.Res.Reg.ClaimPin(in.ID, pin, core.FuncGPIOIn)
+		if err != nil {
+			in.Res.Reg.ReleaseI2C(in.ID, core.ResourceID(p.Bus))
+			return nil, err
+		}
+		_ = ph.AsGPIO().ConfigureInput(core.PullUp) // INT# is open-drain
+	}
+
+	drv := bq25792.New(i2c)
+	drv.Address = p.Addr
+	d := &Device{
+		id:     in.ID,
+		aBat:   core.CapAddr{Domain: p.DomainBattery, Kind: types.KindBattery, Name: p.Name},
+		aChg:   core.CapAddr{Domain: p.DomainCharger, Kind: types.KindCharger, Name: p.Name},
+		res:    in.Res,
+		pin:    pin,
+		drv:    drv,
+		params: p,
+		reqCh:  make(chan request, 8),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	d.registerVerbs()
+	return d, nil
+}
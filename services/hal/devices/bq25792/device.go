@@ -0,0 +1,298 @@
+package bq25792dev
+
+import (
+	"context"
+
+	"devicecode-go/drivers/bq25792"
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+type Device struct {
+	id   string
+	aBat core.CapAddr // power/battery/<name>
+	aChg core.CapAddr // power/charger/<name>
+
+	res core.Resources
+	pin int // -1 when INT# is not wired
+	es  core.GPIOEdgeStream
+
+	// The worker owns drv; controls only queue to it.
+	drv   bq25792.Device
+	reqCh chan request
+	quit  chan struct{}
+	done  chan struct{}
+	ready bool // configured since the last bus error
+
+	// Settings applied so far, replayed after a reconfigure (the part
+	// returns to its defaults if it loses power).
+	want types.ChargerConfigure
+
+	verbs  core.VerbTable
+	params Params
+}
+
+type opCode uint8
+
+const (
+	opRead opCode = iota
+	opConfigure
+)
+
+type request struct {
+	op  opCode
+	cfg types.ChargerConfigure
+}
+
+func (d *Device) ID() string { return d.id }
+
+func (d *Device) Capabilities() []core.CapabilitySpec {
+	return []core.CapabilitySpec{
+		{
+			Domain: d.aBat.Domain, Kind: types.KindBattery, Name: d.aBat.Name,
+			Info: types.Info{SchemaVersion: 1, Driver: "bq25792", Detail: types.BatteryInfo{
+				Cells: d.params.Cells, Chem: "lithium", Bus: d.params.Bus, Addr: d.params.Addr,
+			}},
+		},
+		{
+			Domain: d.aChg.Domain, Kind: types.KindCharger, Name: d.aChg.Name,
+			Info: types.Info{SchemaVersion: 1, Driver: "bq25792", Detail: types.ChargerInfo{
+				Bus: d.params.Bus, Addr: d.params.Addr,
+			}},
+		},
+	}
+}
+
+func (d *Device) Init(ctx context.Context) error {
+	if d.pin >= 0 {
+		if es, err := d.res.Reg.SubscribeGPIOEdges(d.id, d.pin, core.EdgeFalling, 0, 4); err == nil {
+			d.es = es
+		} else {
+			_ = d.res.Pub.Emit(core.Event{Addr: d.aBat, Err: "int_subscribe_failed"})
+			_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, Err: "int_subscribe_failed"})
+		}
+	}
+	_ = d.res.Pub.Emit(core.Event{Addr: d.aBat, Err: "initialising"})
+	_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, Err: "initialising"})
+
+	go d.worker()
+
+	for _, a := range d.params.Boot {
+		_, _ = d.Control(d.aChg, a.Verb, a.Payload)
+	}
+	d.enqueue(request{op: opRead})
+	return nil
+}
+
+func (d *Device) Close() error {
+	close(d.quit)
+	<-d.done
+	if d.es != nil {
+		d.es.Close()
+		d.res.Reg.UnsubscribeGPIOEdges(d.id, d.pin)
+	}
+	if d.pin >= 0 {
+		d.res.Reg.ReleasePin(d.id, d.pin)
+	}
+	d.res.Reg.ReleaseI2C(d.id, core.ResourceID(d.params.Bus))
+	return nil
+}
+
+// ---- Controls ----
+
+func (d *Device) Control(_ core.CapAddr, verb string, payload any) (core.EnqueueResult, error) {
+	return d.verbs.Dispatch(verb, payload)
+}
+
+func (d *Device) Verbs(core.CapAddr) []core.VerbSpec { return d.verbs.Specs() }
+
+// registerVerbs registers the subset of the ltc4015 charger verbs this
+// part can honour, with the same names and payloads.
+func (d *Device) registerVerbs() {
+	vt := &d.verbs
+	core.RegisterAction(vt, "read", func() (core.EnqueueResult, error) {
+		return d.enqueue(request{op: opRead})
+	})
+	core.RegisterVerb(vt, "configure", d.configure)
+	core.RegisterAction(vt, "enable", func() (core.EnqueueResult, error) {
+		t := true
+		return d.configure(types.ChargerConfigure{Enable: &t})
+	})
+	core.RegisterAction(vt, "disable", func() (core.EnqueueResult, error) {
+		f := false
+		return d.configure(types.ChargerConfigure{Enable: &f})
+	})
+	core.RegisterVerb(vt, "set_input_limit", func(p types.CurrentMA) (core.EnqueueResult, error) {
+		v := p.MilliA
+		return d.configure(types.ChargerConfigure{IinLimit_mA: &v})
+	})
+	core.RegisterVerb(vt, "set_charge_target", func(p types.CurrentMA) (core.EnqueueResult, error) {
+		v := p.MilliA
+		return d.configure(types.ChargerConfigure{IChargeTarget_mA: &v})
+	})
+	core.RegisterVerb(vt, "set_vin_uvcl", func(p types.VoltageMV) (core.EnqueueResult, error) {
+		v := p.MilliV
+		return d.configure(types.ChargerConfigure{VinUVCL_mV: &v})
+	})
+}
+
+// configure accepts the fields this part supports. Anything else is
+// Unsupported rather than silently ignored.
+func (d *Device) configure(c types.ChargerConfigure) (core.EnqueueResult, error) {
+	supported := types.ChargerConfigure{
+		Enable: c.Enable, IinLimit_mA: c.IinLimit_mA,
+		IChargeTarget_mA: c.IChargeTarget_mA, VinUVCL_mV: c.VinUVCL_mV,
+	}
+	if c != supported {
+		return core.EnqueueResult{OK: false, Error: errcode.Unsupported}, nil
+	}
+	return d.enqueue(request{op: opConfigure, cfg: c})
+}
+
+func (d *Device) enqueue(r request) (core.EnqueueResult, error) {
+	select {
+	case d.reqCh <- r:
+		return core.EnqueueResult{OK: true}, nil
+	default:
+		return core.EnqueueResult{OK: false, Error: errcode.Busy}, nil
+	}
+}
+
+// ---- Worker ----
+
+func (d *Device) worker() {
+	defer close(d.done)
+	var evCh <-chan core.GPIOEdgeEvent
+	if d.es != nil {
+		evCh = d.es.Events()
+	}
+	for {
+		select {
+		case <-d.quit:
+			return
+		case <-evCh:
+			if d.ready {
+				if _, err := d.drv.Flags(); err != nil {
+					d.ready = false
+				}
+			}
+			d.sampleAndPublish()
+		case req := <-d.reqCh:
+			switch req.op {
+			case opRead:
+				d.sampleAndPublish()
+			case opConfigure:
+				merge(&d.want, req.cfg)
+				if d.ready {
+					d.apply(req.cfg)
+				}
+				d.sampleAndPublish()
+			}
+		}
+	}
+}
+
+// bringUp configures the part, checks the cell strap and replays the
+// settings applied so far.
+func (d *Device) bringUp() bool {
+	cells, err := d.drv.Configure()
+	if err != nil {
+		d.errBoth("configure_failed", err)
+		return false
+	}
+	if cells != d.params.Cells {
+		_ = d.res.Pub.Emit(core.Event{Addr: d.aBat, Err: "bq25792_strapping_mismatch"})
+		_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, Err: "bq25792_strapping_mismatch"})
+		return false
+	}
+	_, _ = d.drv.Flags()
+	d.ready = true
+	d.apply(d.want)
+	return true
+}
+
+func (d *Device) apply(c types.ChargerConfigure) {
+	if c.Enable != nil {
+		if err := d.drv.SetChargeEnable(*c.Enable); err != nil {
+			d.errChg("enable_failed", err)
+		}
+	}
+	if c.IinLimit_mA != nil {
+		if err := d.drv.SetInputLimit_mA(*c.IinLimit_mA); err != nil {
+			d.errChg("set_input_limit_failed", err)
+		}
+	}
+	if c.IChargeTarget_mA != nil {
+		if err := d.drv.SetChargeCurrent_mA(*c.IChargeTarget_mA); err != nil {
+			d.errChg("set_charge_target_failed", err)
+		}
+	}
+	if c.VinUVCL_mV != nil {
+		if err := d.drv.SetVINDPM_mV(*c.VinUVCL_mV); err != nil {
+			d.errChg("set_vin_uvcl_failed", err)
+		}
+	}
+}
+
+func merge(dst *types.ChargerConfigure, c types.ChargerConfigure) {
+	if c.Enable != nil {
+		dst.Enable = c.Enable
+	}
+	if c.IinLimit_mA != nil {
+		dst.IinLimit_mA = c.IinLimit_mA
+	}
+	if c.IChargeTarget_mA != nil {
+		dst.IChargeTarget_mA = c.IChargeTarget_mA
+	}
+	if c.VinUVCL_mV != nil {
+		dst.VinUVCL_mV = c.VinUVCL_mV
+	}
+}
+
+// ---- Telemetry ----
+
+// sampleAndPublish maps the snapshot onto the same value types as
+// ltc4015. ChargerValue's raw fields carry this part's codes: State is
+// CHG_STAT, Status is VBUS_STAT and Sys the two fault registers.
+func (d *Device) sampleAndPublish() {
+	if !d.ready && !d.bringUp() {
+		return
+	}
+	s, err := d.drv.Snapshot()
+	if err != nil {
+		d.ready = false
+		d.errBoth("meas_error", err)
+		return
+	}
+	_ = d.res.Pub.Emit(core.Event{Addr: d.aBat, Payload: types.BatteryValue{
+		PackMilliV:    s.VBAT_mV,
+		PerCellMilliV: s.VBAT_mV / int32(d.params.Cells),
+		IBatMilliA:    s.IBAT_mA,
+		TempMilliC:    s.Die_mC,
+	}})
+	_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, Payload: types.ChargerValue{
+		VIN_mV:  s.VBUS_mV,
+		VSYS_mV: s.VSYS_mV,
+		IIn_mA:  s.IBUS_mA,
+		State:   uint16(s.State),
+		Status:  uint16(s.VbusStat),
+		Sys:     uint16(s.Fault[0])<<8 | uint16(s.Fault[1]),
+	}})
+}
+
+// ---- Errors ----
+
+func (d *Device) errBoth(tag string, err error) {
+	code := string(errcode.MapDriverErr(err))
+	_ = d.res.Pub.Emit(core.Event{Addr: d.aBat, EventTag: tag})
+	_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, EventTag: tag})
+	_ = d.res.Pub.Emit(core.Event{Addr: d.aBat, Err: code})
+	_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, Err: code})
+}
+
+func (d *Device) errChg(tag string, err error) {
+	code := string(errcode.MapDriverErr(err))
+	_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, EventTag: tag})
+	_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, Err: code})
+}
@@ -0,0 +1,56 @@
+package bq25792dev
+
+import (
+	"devicecode-go/types"
+	"devicecode-go/x/cbor"
+)
+
+// MarshalCBOR and UnmarshalCBOR carry Params through config export and
+// import (hal/config/control), keyed by the JSON field names.
+func (p Params) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("Bus", p.Bus)
+		m.Uint("Addr", uint64(p.Addr))
+		m.Uint("Cells", uint64(p.Cells))
+		m.Bool("Int", p.Int)
+		m.Int("IntPin", int64(p.IntPin))
+		m.Text("IntPinName", p.IntPinName)
+		m.Text("DomainBattery", p.DomainBattery)
+		m.Text("DomainCharger", p.DomainCharger)
+		m.Text("Name", p.Name)
+		m.Array("boot", len(p.Boot), func(e *cbor.Encoder, i int) { p.Boot[i].MarshalCBOR(e) })
+	})
+}
+
+func (p *Params) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Bus":
+			return cbor.ReadText(d, &p.Bus)
+		case "Addr":
+			return cbor.ReadUint(d, &p.Addr)
+		case "Cells":
+			return cbor.ReadUint(d, &p.Cells)
+		case "Int":
+			return cbor.ReadBool(d, &p.Int)
+		case "IntPin":
+			return cbor.ReadInt(d, &p.IntPin)
+		case "IntPinName":
+			return cbor.ReadText(d, &p.IntPinName)
+		case "DomainBattery":
+			return cbor.ReadText(d, &p.DomainBattery)
+		case "DomainCharger":
+			return cbor.ReadText(d, &p.DomainCharger)
+		case "Name":
+			return cbor.ReadText(d, &p.Name)
+		case "boot":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v types.BootAction
+				err := v.UnmarshalCBOR(d)
+				p.Boot = append(p.Boot, v)
+				return err
+			})
+		}
+		return d.Skip()
+	})
+}
@@ -0,0 +1,74 @@
+package buzzerdev
+
+import (
+	"context"
+
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+func init() {
+	core.RegisterBuilder("buzzer", builder{})
+	core.RegisterParams[Params]("buzzer")
+}
+
+// Params describe a sounder on one PWM pin. The tone frequency is the PWM
+// frequency, so the pin's slice must not be shared with another user.
+type Params struct {
+	Pin     int
+	PinName string // optional alias (e.g. "BUZZER"); overrides Pin
+	Domain  string // default "io"
+	Name    string // default "buzzer"
+	FreqHz  uint16 // default beep frequency (default 2700, typical piezo resonance)
+	DutyPct uint8  // drive duty 1..50 (default 50, loudest)
+}
+
+type builder struct{}
+
+func (builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, error) {
+	p, ok := in.Params.(Params)
+	if !ok || p.Pin < 0 {
+		return nil, errcode.InvalidParams
+	}
+	if p.Domain == "" {
+		p.Domain = "io"
+	}
+	if p.Name == "" {
+		p.Name = "buzzer"
+	}
+	if p.FreqHz == 0 {
+		p.FreqHz = 2700
+	}
+	if p.DutyPct == 0 {
+		p.DutyPct = 50
+	}
+	if p.DutyPct > 50 || !freqOK(p.FreqHz) {
+		return nil, errcode.InvalidParams
+	}
+	pin, err := core.ResolvePin(in.Res.Reg, p.Pin, p.PinName)
+	if err != nil {
+		return nil, err
+	}
+	ph, err := in.Res.Reg.ClaimPin(in.ID, pin, core.FuncPWM)
+	if err != nil {
+		return nil, err
+	}
+	d := &Device{
+		id:   in.ID,
+		p:    p,
+		pin:  pin,
+		pwm:  ph.AsPWM(),
+		pub:  in.Res.Pub,
+		reg:  in.Res.Reg,
+		a:    core.CapAddr{Domain: p.Domain, Kind: types.KindBuzzer, Name: p.Name},
+		kick: make(chan struct{}, 1),
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+		pri:  -1,
+	}
+	core.RegisterVerb(&d.verbs, "beep", d.beep)
+	core.RegisterVerb(&d.verbs, "play", d.play)
+	core.RegisterVerb(&d.verbs, "stop", d.stop)
+	return d, nil
+}
@@ -0,0 +1,266 @@
+package buzzerdev
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+const (
+	pwmTop      = 100 // duty is set in percent
+	beepMs      = 100
+	maxTones    = 32
+	minFreqHz   = 50
+	maxFreqHz   = 10000
+	toneLimitMs = 10_000 // per tone; RepeatForever is the way to sound longer
+)
+
+func freqOK(hz uint16) bool { return hz >= minFreqHz && hz <= maxFreqHz }
+
+// patterns are the built-in sounds, pitched near common piezo resonances.
+var patterns = []struct {
+	name  string
+	tones []types.BuzzerTone
+}{
+	{"chirp", []types.BuzzerTone{{FreqHz: 2700, DurationMs: 40}}},
+	{"ack", []types.BuzzerTone{{FreqHz: 2700, DurationMs: 60}, {DurationMs: 60}, {FreqHz: 3200, DurationMs: 60}}},
+	{"warning", []types.BuzzerTone{{FreqHz: 2000, DurationMs: 150}, {DurationMs: 100}, {FreqHz: 2000, DurationMs: 150}, {DurationMs: 600}}},
+	{"alarm", []types.BuzzerTone{{FreqHz: 2700, DurationMs: 250}, {FreqHz: 2000, DurationMs: 250}}},
+	{"error", []types.BuzzerTone{{FreqHz: 1000, DurationMs: 500}, {DurationMs: 500}}},
+}
+
+func pattern(name string) []types.BuzzerTone {
+	for _, p := range patterns {
+		if p.name == name {
+			return p.tones
+		}
+	}
+	return nil
+}
+
+// job is one request; no tones means silence.
+type job struct {
+	name   string
+	tones  []types.BuzzerTone
+	repeat uint8
+	pri    uint8
+}
+
+type Device struct {
+	id  string
+	p   Params
+	pin int
+	pwm core.PWMHandle
+	pub core.EventEmitter
+	reg core.ResourceRegistry
+	a   core.CapAddr
+
+	// Guarded by mu. pri is the priority of the job playing or pending,
+	// -1 when idle; next replaces whatever the worker is playing.
+	mu   sync.Mutex
+	next *job
+	pri  int
+
+	kick chan struct{}
+	quit chan struct{}
+	done chan struct{}
+
+	verbs core.VerbTable
+}
+
+func (d *Device) ID() string { return d.id }
+
+func (d *Device) Capabilities() []core.CapabilitySpec {
+	names := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		names = append(names, p.name)
+	}
+	return []core.CapabilitySpec{{
+		Domain: d.a.Domain, Kind: types.KindBuzzer, Name: d.a.Name,
+		Info: types.Info{SchemaVersion: 1, Driver: "buzzer", Detail: types.BuzzerInfo{
+			Pin: d.pin, Patterns: names,
+		}},
+	}}
+}
+
+func (d *Device) Init(ctx context.Context) error {
+	if err := d.pwm.Configure(uint64(d.p.FreqHz), pwmTop); err != nil {
+		d.pub.Emit(core.Event{Addr: d.a, Err: string(errcode.MapDriverErr(err))})
+	}
+	d.pwm.Set(0)
+	d.pub.Emit(core.Event{Addr: d.a, Payload: types.BuzzerValue{}})
+	go d.run()
+	return nil
+}
+
+// Close stops playback and releases the pin (the provider drives it low).
+func (d *Device) Close() error {
+	close(d.quit)
+	<-d.done
+	d.pwm.Set(0)
+	d.reg.ReleasePin(d.id, d.pin)
+	return nil
+}
+
+func (d *Device) Control(_ core.CapAddr, verb string, payload any) (core.EnqueueResult, error) {
+	return d.verbs.Dispatch(verb, payload)
+}
+
+func (d *Device) Verbs(core.CapAddr) []core.VerbSpec { return d.verbs.Specs() }
+
+func (d *Device) beep(b types.BuzzerBeep) (core.EnqueueResult, error) {
+	if b.FreqHz == 0 {
+		b.FreqHz = d.p.FreqHz
+	}
+	if b.DurationMs == 0 {
+		b.DurationMs = beepMs
+	}
+	if !freqOK(b.FreqHz) || b.DurationMs > toneLimitMs {
+		return core.EnqueueResult{OK: false, Error: errcode.InvalidParams}, nil
+	}
+	return d.submit(&job{
+		name:  "beep",
+		tones: []types.BuzzerTone{{FreqHz: b.FreqHz, DurationMs: b.DurationMs}},
+		pri:   b.Priority,
+	})
+}
+
+func (d *Device) play(p types.BuzzerPlay) (core.EnqueueResult, error) {
+	j := &job{name: p.Pattern, tones: p.Tones, repeat: p.Repeat, pri: p.Priority}
+	if p.Pattern != "" {
+		if j.tones = pattern(p.Pattern); j.tones == nil {
+			return core.EnqueueResult{OK: false, Error: errcode.InvalidParams}, nil
+		}
+	} else {
+		j.name = "custom"
+		if len(j.tones) == 0 || len(j.tones) > maxTones {
+			return core.EnqueueResult{OK: false, Error: errcode.InvalidParams}, nil
+		}
+		for _, t := range j.tones {
+			if t.DurationMs == 0 || t.DurationMs > toneLimitMs || (t.FreqHz != 0 && !freqOK(t.FreqHz)) {
+				return core.EnqueueResult{OK: false, Error: errcode.InvalidParams}, nil
+			}
+		}
+		// The worker plays from this slice after the control returns.
+		j.tones = append([]types.BuzzerTone(nil), j.tones...)
+	}
+	return d.submit(j)
+}
+
+func (d *Device) stop(s types.BuzzerStop) (core.EnqueueResult, error) {
+	d.mu.Lock()
+	if d.pri >= 0 && int(s.Priority) < d.pri {
+		d.mu.Unlock()
+		return core.EnqueueResult{OK: false, Error: errcode.Busy}, nil
+	}
+	d.next, d.pri = &job{}, -1
+	d.mu.Unlock()
+	d.wake()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+// submit pre-empts what is playing unless it has a higher priority.
+func (d *Device) submit(j *job) (core.EnqueueResult, error) {
+	d.mu.Lock()
+	if d.pri >= 0 && int(j.pri) < d.pri {
+		d.mu.Unlock()
+		return core.EnqueueResult{OK: false, Error: errcode.Busy}, nil
+	}
+	d.next, d.pri = j, int(j.pri)
+	d.mu.Unlock()
+	d.wake()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) wake() {
+	select {
+	case d.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Device) take() *job {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	j := d.next
+	d.next = nil
+	return j
+}
+
+func (d *Device) run() {
+	defer close(d.done)
+	for {
+		select {
+		case <-d.quit:
+			return
+		case <-d.kick:
+		}
+		for j := d.take(); j != nil; {
+			j = d.perform(j)
+		}
+	}
+}
+
+// perform plays j to the end and returns nil, or returns the job that
+// pre-empted it.
+func (d *Device) perform(j *job) *job {
+	if len(j.tones) > 0 {
+		d.pub.Emit(core.Event{Addr: d.a, Payload: types.BuzzerValue{Playing: true, Pattern: j.name, Priority: j.pri}})
+	}
+	t := time.NewTimer(time.Hour)
+	defer t.Stop()
+	for pass := 0; len(j.tones) > 0; pass++ {
+		for _, tn := range j.tones {
+			d.tone(tn.FreqHz)
+			if !t.Stop() {
+				select {
+				case <-t.C:
+				default:
+				}
+			}
+			t.Reset(time.Duration(tn.DurationMs) * time.Millisecond)
+			for waiting := true; waiting; {
+				select {
+				case <-d.quit:
+					d.pwm.Set(0)
+					return nil
+				case <-d.kick:
+					if nj := d.take(); nj != nil {
+						return nj
+					}
+				case <-t.C:
+					waiting = false
+				}
+			}
+		}
+		if j.repeat != types.BuzzerRepeatForever && pass >= int(j.repeat) {
+			break
+		}
+	}
+	d.pwm.Set(0)
+	d.mu.Lock()
+	if d.next == nil {
+		d.pri = -1
+	}
+	d.mu.Unlock()
+	d.pub.Emit(core.Event{Addr: d.a, Payload: types.BuzzerValue{}})
+	return nil
+}
+
+// tone retunes the PWM to hz at the configured duty; 0 is silence.
+func (d *Device) tone(hz uint16) {
+	if hz == 0 {
+		d.pwm.Set(0)
+		return
+	}
+	if err := d.pwm.Configure(uint64(hz), pwmTop); err != nil {
+		d.pwm.Set(0)
+		d.pub.Emit(core.Event{Addr: d.a, Err: string(errcode.MapDriverErr(err))})
+		return
+	}
+	d.pwm.Set(uint16(d.p.DutyPct))
+}
@@ -0,0 +1,36 @@
+package buzzerdev
+
+import "devicecode-go/x/cbor"
+
+// MarshalCBOR and UnmarshalCBOR carry Params through config export and
+// import (hal/config/control), keyed by the JSON field names.
+func (p Params) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("Pin", int64(p.Pin))
+		m.Text("PinName", p.PinName)
+		m.Text("Domain", p.Domain)
+		m.Text("Name", p.Name)
+		m.Uint("FreqHz", uint64(p.FreqHz))
+		m.Uint("DutyPct", uint64(p.DutyPct))
+	})
+}
+
+func (p *Params) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Pin":
+			return cbor.ReadInt(d, &p.Pin)
+		case "PinName":
+			return cbor.ReadText(d, &p.PinName)
+		case "Domain":
+			return cbor.ReadText(d, &p.Domain)
+		case "Name":
+			return cbor.ReadText(d, &p.Name)
+		case "FreqHz":
+			return cbor.ReadUint(d, &p.FreqHz)
+		case "DutyPct":
+			return cbor.ReadUint(d, &p.DutyPct)
+		}
+		return d.Skip()
+	})
+}
@@ -0,0 +1,55 @@
+// Package composite builds virtual devices that combine capabilities of
+// other devices under a small policy, so a simple control loop can be
+// declared in config/hal instead of written as a new device.
+package composite
+
+import (
+	"context"
+
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+func init() {
+	core.RegisterBuilder("composite", builder{})
+	core.RegisterParams[Params]("composite")
+}
+
+// Params select a Policy and wire it to other capabilities by address;
+// they need not exist yet when the composite is built.
+type Params struct {
+	Policy string // "thermostat"
+	Domain string // default "control"
+	Name   string // required
+
+	// thermostat: Field of Source's value is regulated against Setpoint by
+	// switching Output (a switch or LED). Without Cool, demand comes on
+	// below Setpoint and goes off at Setpoint+Hysteresis (a heater); with
+	// Cool, on above Setpoint and off at Setpoint-Hysteresis (a fan).
+	Source     types.CapabilityAddress
+	Field      string // e.g. "deci_c"
+	Output     types.CapabilityAddress
+	Setpoint   int64
+	Hysteresis int64
+	Cool       bool
+	StaleMs    uint32 // drop demand after this long without a sample; 0 => never
+	Disabled   bool   // start with regulation off
+}
+
+type builder struct{}
+
+func (builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, error) {
+	p, ok := in.Params.(Params)
+	if !ok || p.Name == "" || in.Res.Caps == nil {
+		return nil, errcode.InvalidParams
+	}
+	if p.Domain == "" {
+		p.Domain = "control"
+	}
+	switch p.Policy {
+	case "thermostat":
+		return newThermostat(in, p)
+	}
+	return nil, &errcode.E{C: errcode.InvalidParams, Op: "composite", Msg: "unknown policy " + p.Policy, Field: "policy"}
+}
@@ -0,0 +1,51 @@
+package composite
+
+import "devicecode-go/x/cbor"
+
+// MarshalCBOR and UnmarshalCBOR carry Params through config export and
+// import (hal/config/control), keyed by the JSON field names.
+func (p Params) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("Policy", p.Policy)
+		m.Text("Domain", p.Domain)
+		m.Text("Name", p.Name)
+		m.Value("Source", p.Source)
+		m.Text("Field", p.Field)
+		m.Value("Output", p.Output)
+		m.Int("Setpoint", int64(p.Setpoint))
+		m.Int("Hysteresis", int64(p.Hysteresis))
+		m.Bool("Cool", p.Cool)
+		m.Uint("StaleMs", uint64(p.StaleMs))
+		m.Bool("Disabled", p.Disabled)
+	})
+}
+
+func (p *Params) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Policy":
+			return cbor.ReadText(d, &p.Policy)
+		case "Domain":
+			return cbor.ReadText(d, &p.Domain)
+		case "Name":
+			return cbor.ReadText(d, &p.Name)
+		case "Source":
+			return p.Source.UnmarshalCBOR(d)
+		case "Field":
+			return cbor.ReadText(d, &p.Field)
+		case "Output":
+			return p.Output.UnmarshalCBOR(d)
+		case "Setpoint":
+			return cbor.ReadInt(d, &p.Setpoint)
+		case "Hysteresis":
+			return cbor.ReadInt(d, &p.Hysteresis)
+		case "Cool":
+			return cbor.ReadBool(d, &p.Cool)
+		case "StaleMs":
+			return cbor.ReadUint(d, &p.StaleMs)
+		case "Disabled":
+			return cbor.ReadBool(d, &p.Disabled)
+		}
+		return d.Skip()
+	})
+}
@@ -0,0 +1,272 @@
+package composite
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+// thermostat drives Output from one field of Source with hysteresis. It
+// sends set only when demand differs from Output's last reported state,
+// and re-asserts on every sample, so a refused or lost control (e.g. an
+// interlock, or HAL not yet ready) is retried at the source's rate.
+type thermostat struct {
+	id   string
+	p    Params
+	a    core.CapAddr
+	src  core.CapAddr
+	out  core.CapAddr
+	pub  core.EventEmitter
+	caps core.Capabilities
+	clk  clock.Clock
+
+	// Guarded by mu.
+	mu      sync.Mutex
+	st      types.ThermostatValue
+	have    bool // a sample has arrived
+	outOn   bool
+	outSeen bool          // Output has reported a value
+	lastAt  time.Time     // last sample, for the stale check
+	quit    chan struct{} // stops staleLoop; nil without StaleMs
+	cancels []func()
+
+	verbs core.VerbTable
+}
+
+func addr(c types.CapabilityAddress) core.CapAddr {
+	return core.CapAddr{Domain: c.Domain, Kind: c.Kind, Name: c.Name}
+}
+
+func newThermostat(in core.BuilderInput, p Params) (core.Device, error) {
+	switch {
+	case p.Source.Domain == "" || p.Source.Name == "" || !p.Source.Kind.Valid():
+		return nil, &errcode.E{C: errcode.InvalidParams, Op: "composite", Field: "source"}
+	case p.Field == "":
+		return nil, &errcode.E{C: errcode.InvalidParams, Op: "composite", Field: "field"}
+	case p.Output.Domain == "" || p.Output.Name == "" ||
+		(p.Output.Kind != types.KindSwitch && p.Output.Kind != types.KindLED):
+		return nil, &errcode.E{C: errcode.InvalidParams, Op: "composite", Msg: "output must be a switch or led", Field: "output"}
+	case p.Hysteresis < 0:
+		return nil, &errcode.E{C: errcode.InvalidParams, Op: "composite", Field: "hysteresis"}
+	}
+	d := &thermostat{
+		id:   in.ID,
+		p:    p,
+		a:    core.CapAddr{Domain: p.Domain, Kind: types.KindThermostat, Name: p.Name},
+		src:  addr(p.Source),
+		out:  addr(p.Output),
+		pub:  in.Res.Pub,
+		caps: in.Res.Caps,
+		clk:  clock.Or(in.Res.Clock),
+		st:   types.ThermostatValue{Enabled: !p.Disabled, Setpoint: p.Setpoint, Hysteresis: p.Hysteresis},
+	}
+	core.RegisterVerb(&d.verbs, "set", d.set)
+	core.RegisterVerb(&d.verbs, "enable", d.enable)
+	core.RegisterAction(&d.verbs, "read", d.read)
+	core.RegisterAction(&d.verbs, "get", d.read)
+	return d, nil
+}
+
+func (d *thermostat) ID() string { return d.id }
+
+func (d *thermostat) Capabilities() []core.CapabilitySpec {
+	return []core.CapabilitySpec{{
+		Domain: d.a.Domain, Kind: types.KindThermostat, Name: d.a.Name,
+		Info: types.Info{SchemaVersion: 1, Driver: "composite", Detail: types.ThermostatInfo{
+			Source: d.p.Source, Field: d.p.Field, Output: d.p.Output, Cool: d.p.Cool,
+		}},
+	}}
+}
+
+func (d *thermostat) Init(ctx context.Context) error {
+	d.mu.Lock()
+	if d.p.StaleMs > 0 {
+		d.lastAt, d.quit = d.clk.Now(), make(chan struct{})
+		go d.staleLoop(d.quit)
+	}
+	d.emitLocked()
+	d.mu.Unlock()
+	// Watches start after the lock is dropped: the current values arrive
+	// at once, on the watch goroutines. A refused watch fails Init, which
+	// rolls the device back with the rest of its config.
+	for _, w := range [...]struct {
+		a  core.CapAddr
+		fn func(any)
+	}{{d.out, d.onOutput}, {d.src, d.onSample}} {
+		c, err := d.caps.Watch(w.a, w.fn)
+		if err != nil {
+			_ = d.Close()
+			return &errcode.E{C: errcode.Unavailable, Op: "composite", Msg: "watch " + w.a.Name, Err: err}
+		}
+		d.mu.Lock()
+		d.cancels = append(d.cancels, c)
+		d.mu.Unlock()
+	}
+	return nil
+}
+
+// Close stops regulating; Output is left as it is.
+func (d *thermostat) Close() error {
+	d.mu.Lock()
+	cancels := d.cancels
+	d.cancels = nil
+	if d.quit != nil {
+		close(d.quit)
+		d.quit = nil
+	}
+	d.mu.Unlock()
+	for _, c := range cancels {
+		c()
+	}
+	return nil
+}
+
+func (d *thermostat) Control(_ core.CapAddr, verb string, payload any) (core.EnqueueResult, error) {
+	return d.verbs.Dispatch(verb, payload)
+}
+
+func (d *thermostat) Verbs(core.CapAddr) []core.VerbSpec { return d.verbs.Specs() }
+
+func (d *thermostat) set(s types.ThermostatSet) (core.EnqueueResult, error) {
+	if s.Hysteresis < 0 {
+		return core.EnqueueResult{}, &errcode.E{C: errcode.InvalidPayload, Op: "set", Field: "hysteresis"}
+	}
+	d.mu.Lock()
+	d.st.Setpoint, d.st.Hysteresis = s.Setpoint, s.Hysteresis
+	d.evalLocked()
+	d.emitLocked()
+	d.mu.Unlock()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *thermostat) enable(e types.ThermostatEnable) (core.EnqueueResult, error) {
+	d.mu.Lock()
+	if d.st.Enabled != e.On {
+		d.st.Enabled = e.On
+		if e.On {
+			d.evalLocked()
+		} else {
+			d.st.Demand = false
+			d.driveLocked(false)
+		}
+	}
+	d.emitLocked()
+	d.mu.Unlock()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *thermostat) read() (core.EnqueueResult, error) {
+	d.mu.Lock()
+	d.emitLocked()
+	d.mu.Unlock()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *thermostat) onSample(payload any) {
+	f, ok := payload.(types.Fielder)
+	if !ok {
+		return
+	}
+	v, ok := f.Field(d.p.Field)
+	if !ok {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lastAt = d.clk.Now()
+	d.st.Input, d.have, d.st.Stale = v, true, false
+	d.evalLocked()
+	d.emitLocked()
+}
+
+func (d *thermostat) onOutput(payload any) {
+	f, ok := payload.(types.Fielder)
+	if !ok {
+		return
+	}
+	on, ok := f.Field("on")
+	if !ok {
+		return
+	}
+	d.mu.Lock()
+	d.outOn, d.outSeen = on != 0, true
+	d.mu.Unlock()
+}
+
+// staleLoop wakes StaleMs after the last sample. Samples only move
+// lastAt, so the timer is re-armed here and never raced from the watch
+// goroutines; once stale it keeps checking at the same period.
+func (d *thermostat) staleLoop(quit <-chan struct{}) {
+	every := time.Duration(d.p.StaleMs) * time.Millisecond
+	t := d.clk.NewTimer(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-quit:
+			return
+		case <-t.C():
+			t.Reset(d.checkStale(every))
+		}
+	}
+}
+
+// checkStale marks the source stale if no sample came within every, and
+// returns how long to wait before checking again.
+func (d *thermostat) checkStale(every time.Duration) time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if left := every - d.clk.Now().Sub(d.lastAt); left > 0 {
+		return left
+	}
+	if d.st.Stale {
+		return every
+	}
+	d.st.Stale, d.have = true, false
+	if d.st.Enabled {
+		d.st.Demand = false
+		d.driveLocked(false)
+	}
+	d.emitLocked()
+	d.pub.Emit(core.Event{Addr: d.a, Err: "source_stale"})
+	return every
+}
+
+// evalLocked updates demand from the last sample and drives Output.
+func (d *thermostat) evalLocked() {
+	if !d.st.Enabled || !d.have {
+		return
+	}
+	v, sp, hy := d.st.Input, d.st.Setpoint, d.st.Hysteresis
+	switch {
+	case d.p.Cool && !d.st.Demand:
+		d.st.Demand = v > sp
+	case d.p.Cool:
+		d.st.Demand = v > sp-hy
+	case !d.st.Demand:
+		d.st.Demand = v < sp
+	default:
+		d.st.Demand = v < sp+hy
+	}
+	d.driveLocked(d.st.Demand)
+}
+
+func (d *thermostat) driveLocked(on bool) {
+	if d.outSeen && d.outOn == on {
+		return
+	}
+	var payload any = types.SwitchSet{On: on}
+	if d.out.Kind == types.KindLED {
+		payload = types.LEDSet{On: on}
+	}
+	d.caps.Control(d.out, "set", payload)
+}
+
+func (d *thermostat) emitLocked() {
+	d.st.TS = d.clk.Now().UnixNano()
+	d.pub.Emit(core.Event{Addr: d.a, Payload: d.st})
+}
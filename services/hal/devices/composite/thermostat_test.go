@@ -0,0 +1,122 @@
+package composite
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fakeCaps records watches and controls; the test calls the watch
+// callbacks itself.
+type fakeCaps struct {
+	mu      sync.Mutex
+	watch   map[core.CapAddr]func(any)
+	control chan types.SwitchSet
+}
+
+func (c *fakeCaps) Watch(a core.CapAddr, fn func(any)) (func(), error) {
+	c.mu.Lock()
+	c.watch[a] = fn
+	c.mu.Unlock()
+	return func() {}, nil
+}
+
+func (c *fakeCaps) Control(_ core.CapAddr, verb string, payload any) {
+	if s, ok := payload.(types.SwitchSet); ok && verb == "set" {
+		c.control <- s
+	}
+}
+
+func (c *fakeCaps) send(a core.CapAddr, v any) {
+	c.mu.Lock()
+	fn := c.watch[a]
+	c.mu.Unlock()
+	fn(v)
+}
+
+func (c *fakeCaps) set(t *testing.T) bool {
+	t.Helper()
+	select {
+	case s := <-c.control:
+		return s.On
+	case <-time.After(time.Second):
+		t.Fatal("no set")
+		return false
+	}
+}
+
+type errs chan string
+
+func (e errs) Emit(ev core.Event) bool {
+	if ev.Err != "" {
+		e <- ev.Err
+	}
+	return true
+}
+
+func TestThermostat_StaleDropsDemandOnFakeClock(t *testing.T) {
+	clk := clock.NewFake(t0)
+	caps := &fakeCaps{watch: map[core.CapAddr]func(any){}, control: make(chan types.SwitchSet, 8)}
+	pub := make(errs, 8)
+	src := types.CapabilityAddress{Domain: "env", Kind: types.KindTemperature, Name: "core"}
+	out := types.CapabilityAddress{Domain: "power", Kind: types.KindSwitch, Name: "heater"}
+	d, err := builder{}.Build(context.Background(), core.BuilderInput{ID: "th", Res: core.Resources{Pub: pub, Caps: caps, Clock: clk},
+		Params: Params{Policy: "thermostat", Name: "t", Source: src, Field: "deci_c", Output: out, Setpoint: 50, Hysteresis: 10, StaleMs: 1000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	waitPending(t, clk, 1)
+
+	caps.send(addr(out), types.SwitchValue{On: false})
+	caps.send(addr(src), types.TemperatureValue{DeciC: 40})
+	if !caps.set(t) {
+		t.Fatal("below setpoint: heater not switched on")
+	}
+	caps.send(addr(out), types.SwitchValue{On: true})
+
+	// A sample at 600 ms moves the deadline to 1.6 s.
+	clk.Advance(600 * time.Millisecond)
+	caps.send(addr(src), types.TemperatureValue{DeciC: 45})
+	clk.Advance(600 * time.Millisecond)
+	waitPending(t, clk, 1)
+	select {
+	case e := <-pub:
+		t.Fatalf("stale early: %s", e)
+	case <-time.After(20 * time.Millisecond):
+	}
+	clk.Advance(400 * time.Millisecond)
+	if e := <-pub; e != "source_stale" {
+		t.Fatalf("err = %s", e)
+	}
+	if caps.set(t) {
+		t.Fatal("stale: heater left on")
+	}
+	caps.send(addr(out), types.SwitchValue{On: false})
+
+	// A fresh sample resumes regulation.
+	caps.send(addr(src), types.TemperatureValue{DeciC: 40})
+	if !caps.set(t) {
+		t.Fatal("fresh sample: heater not switched back on")
+	}
+}
+
+func waitPending(t *testing.T, clk *clock.Fake, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); clk.Pending() != n; {
+		if time.Now().After(deadline) {
+			t.Fatalf("pending timers = %d, want %d", clk.Pending(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
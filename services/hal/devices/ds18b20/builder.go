@@ -0,0 +1,79 @@
+package ds18b20dev
+
+import (
+	"context"
+
+	"devicecode-go/drivers/ds18b20"
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+	"devicecode-go/x/onewire"
+)
+
+func init() {
+	core.RegisterBuilder("ds18b20", builder{})
+	core.RegisterParams[Params]("ds18b20")
+}
+
+// Probe names one DS18B20 by ROM ID; it becomes <Domain>/temperature/<Name>.
+type Probe struct {
+	ROM  string // 16 hex digits, as reported in …/event/discovered
+	Name string // e.g. "battery_bay"
+}
+
+// Params describe the DS18B20 probes on one 1-Wire bus.
+type Params struct {
+	Bus        string  // e.g. "ow0"
+	Domain     string  // REQUIRED, e.g. "env"
+	Probes     []Probe // at least one
+	Resolution uint8   // 9..12 bits (default 12, 750 ms per read)
+}
+
+type builder struct{}
+
+func (builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, error) {
+	p, ok := in.Params.(Params)
+	if !ok || p.Bus == "" || p.Domain == "" || len(p.Probes) == 0 {
+		return nil, errcode.InvalidParams
+	}
+	if p.Resolution == 0 {
+		p.Resolution = 12
+	}
+	if p.Resolution < 9 || p.Resolution > 12 {
+		return nil, errcode.InvalidParams
+	}
+	d := &Device{
+		id:    in.ID,
+		p:     p,
+		pub:   in.Res.Pub,
+		reg:   in.Res.Reg,
+		reads: make(chan struct{}, 1),
+		scans: make(chan struct{}, 1),
+		quit:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	for i, pr := range p.Probes {
+		rom, err := onewire.ParseROM(pr.ROM)
+		if err != nil || !rom.Valid() || rom.Family() != ds18b20.Family || pr.Name == "" {
+			return nil, errcode.InvalidParams
+		}
+		for _, o := range d.probes[:i] {
+			if o.rom == rom || o.a.Name == pr.Name {
+				return nil, errcode.InvalidParams
+			}
+		}
+		d.probes = append(d.probes, probe{
+			rom: rom,
+			a:   core.CapAddr{Domain: p.Domain, Kind: types.KindTemperature, Name: pr.Name},
+		})
+	}
+
+	bus, err := in.Res.Reg.ClaimOneWire(in.ID, core.ResourceID(p.Bus))
+	if err != nil {
+		return nil, err
+	}
+	d.bus = bus
+	core.RegisterAction(&d.verbs, "read", d.read)
+	core.RegisterAction(&d.verbs, "discover", d.discover)
+	return d, nil
+}
@@ -0,0 +1,187 @@
+package ds18b20dev
+
+import (
+	"context"
+	"time"
+
+	"devicecode-go/drivers/ds18b20"
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+	"devicecode-go/x/onewire"
+)
+
+type probe struct {
+	rom onewire.ROM
+	a   core.CapAddr
+	cfg bool // resolution written since the last failure
+}
+
+type Device struct {
+	id     string
+	p      Params
+	bus    core.OneWire
+	probes []probe
+
+	pub core.EventEmitter
+	reg core.ResourceRegistry
+
+	// The worker runs conversions; requests coalesce in 1-slot channels.
+	reads chan struct{}
+	scans chan struct{}
+	quit  chan struct{}
+	done  chan struct{}
+
+	verbs core.VerbTable
+}
+
+func (d *Device) ID() string { return d.id }
+
+func (d *Device) Capabilities() []core.CapabilitySpec {
+	out := make([]core.CapabilitySpec, 0, len(d.probes))
+	for _, pr := range d.probes {
+		out = append(out, core.CapabilitySpec{
+			Domain: pr.a.Domain, Kind: types.KindTemperature, Name: pr.a.Name,
+			Info: types.Info{SchemaVersion: 1, Driver: "ds18b20", Detail: types.TemperatureInfo{
+				Sensor: "ds18b20", Bus: d.p.Bus, ROM: pr.rom.String(),
+			}},
+		})
+	}
+	return out
+}
+
+func (d *Device) Init(ctx context.Context) error {
+	d.emitAll(core.Event{Err: "initialising"})
+	go d.run()
+	d.scans <- struct{}{}
+	return nil
+}
+
+func (d *Device) Close() error {
+	close(d.quit)
+	<-d.done
+	d.reg.ReleaseOneWire(d.id, core.ResourceID(d.p.Bus))
+	return nil
+}
+
+func (d *Device) Control(_ core.CapAddr, verb string, payload any) (core.EnqueueResult, error) {
+	return d.verbs.Dispatch(verb, payload)
+}
+
+func (d *Device) Verbs(core.CapAddr) []core.VerbSpec { return d.verbs.Specs() }
+
+// read converts and publishes every probe; it is accepted on any probe.
+func (d *Device) read() (core.EnqueueResult, error) {
+	select {
+	case d.reads <- struct{}{}:
+	default: // already pending
+	}
+	return core.EnqueueResult{OK: true}, nil
+}
+
+// discover searches the bus and emits …/event/discovered.
+func (d *Device) discover() (core.EnqueueResult, error) {
+	select {
+	case d.scans <- struct{}{}:
+	default:
+	}
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) run() {
+	defer close(d.done)
+	for {
+		select {
+		case <-d.quit:
+			return
+		case <-d.scans:
+			d.scan()
+		case <-d.reads:
+			d.sample()
+		}
+	}
+}
+
+// scan reports which ROMs are on the bus against the configured probes,
+// so an installer can name a new probe from the event.
+func (d *Device) scan() {
+	roms, err := d.bus.Search()
+	if err != nil {
+		d.emitAll(core.Event{EventTag: "search_failed"})
+	}
+	var ev types.OneWireDiscovery
+	found := make(map[onewire.ROM]bool, len(roms))
+	for _, r := range roms {
+		found[r] = true
+		ev.ROMs = append(ev.ROMs, r.String())
+		if d.probe(r) < 0 {
+			ev.Unnamed = append(ev.Unnamed, r.String())
+		}
+	}
+	for _, pr := range d.probes {
+		if !found[pr.rom] {
+			ev.Missing = append(ev.Missing, pr.rom.String())
+		}
+	}
+	d.emitAll(core.Event{EventTag: "discovered", Payload: ev})
+}
+
+func (d *Device) probe(r onewire.ROM) int {
+	for i := range d.probes {
+		if d.probes[i].rom == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// sample runs one conversion on every probe at once, then reads each.
+func (d *Device) sample() {
+	res := ds18b20.Resolution(d.p.Resolution)
+	for i := range d.probes {
+		pr := &d.probes[i]
+		if !pr.cfg {
+			pr.cfg = ds18b20.SetResolution(d.bus, pr.rom, res) == nil
+		}
+	}
+	if err := ds18b20.ConvertAll(d.bus); err != nil {
+		d.emitAll(core.Event{Err: errCode(err)})
+		return
+	}
+	select {
+	case <-time.After(ds18b20.ConversionTime(res)):
+	case <-d.quit:
+		return
+	}
+	for i := range d.probes {
+		pr := &d.probes[i]
+		mC, err := ds18b20.Read(d.bus, pr.rom)
+		if err != nil {
+			pr.cfg = false // it may have been unplugged and reset
+			d.pub.Emit(core.Event{Addr: pr.a, Err: errCode(err)})
+			continue
+		}
+		d.pub.Emit(core.Event{Addr: pr.a, Payload: types.TemperatureValue{DeciC: int16(mC / 100)}})
+	}
+}
+
+func errCode(err error) string {
+	switch err {
+	case onewire.ErrNoPresence:
+		return "no_presence"
+	case ds18b20.ErrAbsent:
+		return "absent"
+	case ds18b20.ErrCRC:
+		return "crc_error"
+	case ds18b20.ErrNotConverted:
+		return "not_converted"
+	}
+	return string(errcode.MapDriverErr(err))
+}
+
+func (d *Device) emitAll(ev core.Event) {
+	for _, pr := range d.probes {
+		ev.Addr = pr.a
+		d.pub.Emit(ev)
+	}
+}
@@ -0,0 +1,54 @@
+package ds18b20dev
+
+import "devicecode-go/x/cbor"
+
+// MarshalCBOR and UnmarshalCBOR carry Params through config export and
+// import (hal/config/control), keyed by the JSON field names.
+func (p Params) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("Bus", p.Bus)
+		m.Text("Domain", p.Domain)
+		m.Array("Probes", len(p.Probes), func(e *cbor.Encoder, i int) { p.Probes[i].MarshalCBOR(e) })
+		m.Uint("Resolution", uint64(p.Resolution))
+	})
+}
+
+func (p *Params) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Bus":
+			return cbor.ReadText(d, &p.Bus)
+		case "Domain":
+			return cbor.ReadText(d, &p.Domain)
+		case "Probes":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v Probe
+				err := v.UnmarshalCBOR(d)
+				p.Probes = append(p.Probes, v)
+				return err
+			})
+		case "Resolution":
+			return cbor.ReadUint(d, &p.Resolution)
+		}
+		return d.Skip()
+	})
+}
+
+func (p Probe) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("ROM", p.ROM)
+		m.Text("Name", p.Name)
+	})
+}
+
+func (p *Probe) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "ROM":
+			return cbor.ReadText(d, &p.ROM)
+		case "Name":
+			return cbor.ReadText(d, &p.Name)
+		}
+		return d.Skip()
+	})
+}
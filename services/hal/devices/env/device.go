@@ -0,0 +1,188 @@
+// Package envdev is the HAL device shared by the environment sensors.
+// Each sensor's builder claims the bus, wraps its driver (an
+// envsensor.TempHumSensor) and hands it here; this package publishes the
+// temperature, humidity and (where fitted) pressure capabilities and runs
+// the conversions.
+package envdev
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"devicecode-go/drivers/envsensor"
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+// Config describes one sensor to New.
+type Config struct {
+	ID     string
+	Bus    string // claimed I2C bus, released on Close
+	Addr   uint16
+	Domain string
+	Name   string
+	Sensor string // driver name, e.g. "aht20"
+	Drv    envsensor.TempHumSensor
+
+	NoHumidity bool // the sensor has no humidity channel (e.g. BMP280)
+	Pressure   bool // the sensor reports pressure (Reading.Pa)
+	// Station altitude, for PressureValue.SeaLevelPa.
+	AltitudeM int32
+
+	// Plausible temperature range (deci-°C); a sample outside it, with
+	// humidity outside 0..100 % or pressure outside 300..1100 hPa, is
+	// reported as invalid_sample.
+	MinDeciC, MaxDeciC int32
+	// Bounds a whole conversion. Default 250 ms.
+	Timeout time.Duration
+
+	Res core.Resources
+}
+
+type Device struct {
+	c Config
+
+	addrTemp  core.CapAddr
+	addrHum   core.CapAddr
+	addrPress core.CapAddr
+
+	seaLevel uint32 // sea-level reduction factor, Q16
+
+	reading atomic.Uint32
+
+	verbs core.VerbTable
+}
+
+// New returns the device for c. It does not touch the bus.
+func New(c Config) *Device {
+	if c.Timeout <= 0 {
+		c.Timeout = 250 * time.Millisecond
+	}
+	d := &Device{c: c, seaLevel: seaLevelQ16(c.AltitudeM)}
+	core.RegisterAction(&d.verbs, "read", d.read)
+	return d
+}
+
+func (d *Device) ID() string { return d.c.ID }
+
+func (d *Device) Capabilities() []core.CapabilitySpec {
+	caps := []core.CapabilitySpec{
+		{
+			Domain: d.c.Domain,
+			Kind:   types.KindTemperature,
+			Name:   d.c.Name,
+			Info: types.Info{
+				SchemaVersion: 1, Driver: d.c.Sensor,
+				Detail: types.TemperatureInfo{Sensor: d.c.Sensor, Addr: d.c.Addr, Bus: d.c.Bus},
+			},
+		},
+	}
+	if !d.c.NoHumidity {
+		caps = append(caps, core.CapabilitySpec{
+			Domain: d.c.Domain,
+			Kind:   types.KindHumidity,
+			Name:   d.c.Name,
+			Info: types.Info{
+				SchemaVersion: 1, Driver: d.c.Sensor,
+				Detail: types.HumidityInfo{Sensor: d.c.Sensor, Addr: d.c.Addr, Bus: d.c.Bus},
+			},
+		})
+	}
+	if d.c.Pressure {
+		caps = append(caps, core.CapabilitySpec{
+			Domain: d.c.Domain,
+			Kind:   types.KindPressure,
+			Name:   d.c.Name,
+			Info: types.Info{
+				SchemaVersion: 1, Driver: d.c.Sensor,
+				Detail: types.PressureInfo{Sensor: d.c.Sensor, Addr: d.c.Addr, Bus: d.c.Bus, AltitudeM: d.c.AltitudeM},
+			},
+		})
+	}
+	return caps
+}
+
+// Init sets up addresses without touching the bus.
+func (d *Device) Init(ctx context.Context) error {
+	d.addrTemp = core.CapAddr{Domain: d.c.Domain, Kind: types.KindTemperature, Name: d.c.Name}
+	d.addrHum = core.CapAddr{Domain: d.c.Domain, Kind: types.KindHumidity, Name: d.c.Name}
+	d.addrPress = core.CapAddr{Domain: d.c.Domain, Kind: types.KindPressure, Name: d.c.Name}
+	return nil
+}
+
+func (d *Device) Close() error {
+	if d.c.Res.Reg != nil {
+		d.c.Res.Reg.ReleaseI2C(d.c.ID, core.ResourceID(d.c.Bus))
+	}
+	return nil
+}
+
+func (d *Device) Control(_ core.CapAddr, method string, payload any) (core.EnqueueResult, error) {
+	return d.verbs.Dispatch(method, payload)
+}
+
+func (d *Device) Verbs(core.CapAddr) []core.VerbSpec { return d.verbs.Specs() }
+
+// read starts a single background conversion; a read already in flight is Busy.
+func (d *Device) read() (core.EnqueueResult, error) {
+	if d.reading.Swap(1) == 1 {
+		return core.EnqueueResult{OK: false, Error: errcode.Busy}, nil
+	}
+	go func() {
+		defer d.reading.Store(0)
+		d.readOnce()
+	}()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) readOnce() {
+	r, err := envsensor.Measure(d.c.Drv, d.c.Timeout)
+	if err != nil {
+		d.emitErr(string(errcode.MapDriverErr(err)))
+		return
+	}
+	// Hard-range validation: if outside, treat as a failed sample.
+	if r.DeciC < d.c.MinDeciC || r.DeciC > d.c.MaxDeciC ||
+		!d.c.NoHumidity && (r.RHx100 < 0 || r.RHx100 > 10000) ||
+		d.c.Pressure && (r.Pa < 30000 || r.Pa > 110000) {
+		d.emitErr("invalid_sample")
+		return
+	}
+	d.c.Res.Pub.Emit(core.Event{
+		Addr:    d.addrTemp,
+		Payload: types.TemperatureValue{DeciC: int16(r.DeciC)},
+	})
+	if !d.c.NoHumidity {
+		d.c.Res.Pub.Emit(core.Event{
+			Addr:    d.addrHum,
+			Payload: types.HumidityValue{RHx100: uint16(r.RHx100)},
+		})
+	}
+	if d.c.Pressure {
+		d.c.Res.Pub.Emit(core.Event{
+			Addr:    d.addrPress,
+			Payload: types.PressureValue{Pa: r.Pa, SeaLevelPa: uint32(uint64(r.Pa) * uint64(d.seaLevel) >> 16)},
+		})
+	}
+}
+
+func (d *Device) emitErr(code string) {
+	d.c.Res.Pub.Emit(core.Event{Addr: d.addrTemp, Err: code})
+	if !d.c.NoHumidity {
+		d.c.Res.Pub.Emit(core.Event{Addr: d.addrHum, Err: code})
+	}
+	if d.c.Pressure {
+		d.c.Res.Pub.Emit(core.Event{Addr: d.addrPress, Err: code})
+	}
+}
+
+// seaLevelQ16 returns the factor (Q16) that reduces pressure at altitudeM
+// to sea level, from the international barometric formula
+// p0 = p·(1 − h/44330)^−5.255. It is computed once, at build.
+func seaLevelQ16(altitudeM int32) uint32 {
+	f := math.Pow(1-float64(altitudeM)/44330, -5.255)
+	return uint32(f*65536 + 0.5)
+}
@@ -0,0 +1,83 @@
+package envdev
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"devicecode-go/drivers/envsensor"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+// slowSensor is ready after a number of polls.
+type slowSensor struct {
+	polls int
+	r     envsensor.Reading
+}
+
+func (s *slowSensor) Trigger() error             { return nil }
+func (s *slowSensor) TriggerHint() time.Duration { return time.Millisecond }
+func (s *slowSensor) Result() (envsensor.Reading, error) {
+	if s.polls > 0 {
+		s.polls--
+		return envsensor.Reading{}, envsensor.ErrNotReady
+	}
+	return s.r, nil
+}
+
+type events chan core.Event
+
+func (e events) Emit(ev core.Event) bool { e <- ev; return true }
+
+func readOnce(t *testing.T, c Config) map[types.Kind]core.Event {
+	t.Helper()
+	ev := make(events, 3)
+	c.ID, c.Domain, c.Name, c.Sensor = "e", "env", "core", "fake"
+	c.MinDeciC, c.MaxDeciC, c.Res = -375, 825, core.Resources{Pub: ev}
+	d := New(c)
+	if err := d.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := d.Control(core.CapAddr{}, "read", nil); err != nil || !res.OK {
+		t.Fatalf("read: %+v %v", res, err)
+	}
+	got := map[types.Kind]core.Event{}
+	for range d.Capabilities() {
+		select {
+		case e := <-ev:
+			got[e.Addr.Kind] = e
+		case <-time.After(time.Second):
+			t.Fatal("no event")
+		}
+	}
+	return got
+}
+
+func TestRead_PollsUntilReady(t *testing.T) {
+	got := readOnce(t, Config{Drv: &slowSensor{polls: 3, r: envsensor.Reading{DeciC: 215, RHx100: 4550}}})
+	temp, hum := got[types.KindTemperature], got[types.KindHumidity]
+	if temp.Payload != (types.TemperatureValue{DeciC: 215}) || hum.Payload != (types.HumidityValue{RHx100: 4550}) {
+		t.Fatalf("published %+v, %+v", temp.Payload, hum.Payload)
+	}
+}
+
+func TestRead_OutOfRangeIsInvalid(t *testing.T) {
+	got := readOnce(t, Config{Drv: &slowSensor{r: envsensor.Reading{DeciC: 900, RHx100: 4550}}})
+	if temp, hum := got[types.KindTemperature], got[types.KindHumidity]; temp.Err != "invalid_sample" || hum.Err != "invalid_sample" {
+		t.Fatalf("errors %q, %q", temp.Err, hum.Err)
+	}
+}
+
+func TestRead_PressureWithoutHumidity(t *testing.T) {
+	got := readOnce(t, Config{NoHumidity: true, Pressure: true, AltitudeM: 250,
+		Drv: &slowSensor{r: envsensor.Reading{DeciC: 215, Pa: 98325}}})
+	if _, ok := got[types.KindHumidity]; ok || len(got) != 2 {
+		t.Fatalf("published %v", got)
+	}
+	// 250 m adds about 30 hPa.
+	p := got[types.KindPressure].Payload.(types.PressureValue)
+	if p.Pa != 98325 || p.SeaLevelPa < 101200 || p.SeaLevelPa > 101300 {
+		t.Fatalf("pressure %+v", p)
+	}
+}
@@ -6,17 +6,27 @@ import (
 
 	"devicecode-go/errcode"
 	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/x/clock"
 )
 
-func init() { core.RegisterBuilder("gpio_button", builder{}) }
+func init() {
+	core.RegisterBuilder("gpio_button", builder{})
+	core.RegisterParams[Params]("gpio_button")
+}
 
 type Params struct {
 	Pin        int
+	PinName    string // optional alias; overrides Pin
 	Pull       string // "none","up","down"
 	Invert     bool   // true if pressed == low
 	DebounceMs uint16
 	Domain     string
 	Name       string
+
+	// Gesture timings (0 takes the default). A short press is reported
+	// only once DoubleMs has passed without a second press.
+	LongMs   uint16 // hold for event/long (default 1000)
+	DoubleMs uint16 // max gap for event/double (default 300)
 }
 
 type builder struct{}
@@ -30,6 +40,11 @@ func (builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, er
 		return nil, errcode.InvalidParams
 	}
 
+	pin, err := core.ResolvePin(in.Res.Reg, p.Pin, p.PinName)
+	if err != nil {
+		return nil, err
+	}
+	p.Pin = pin
 	ph, err := in.Res.Reg.ClaimPin(in.ID, p.Pin, core.FuncGPIOIn)
 	if err != nil {
 		return nil, err
@@ -44,16 +59,29 @@ func (builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, er
 		_ = gpio.ConfigureInput(core.PullNone)
 	}
 	debounce := time.Duration(p.DebounceMs) * time.Millisecond
+	if p.LongMs == 0 {
+		p.LongMs = 1000
+	}
+	if p.DoubleMs == 0 {
+		p.DoubleMs = 300
+	}
 
-	return &Device{
+	d := &Device{
 		id:       in.ID,
 		pinN:     p.Pin,
 		gpio:     gpio,
 		invert:   p.Invert,
 		pub:      in.Res.Pub,
 		reg:      in.Res.Reg,
+		clk:      clock.Or(in.Res.Clock),
 		dom:      p.Domain,
 		name:     p.Name,
 		debounce: debounce,
-	}, nil
+		g: gestures{
+			longLim:   time.Duration(p.LongMs) * time.Millisecond,
+			doubleGap: time.Duration(p.DoubleMs) * time.Millisecond,
+		},
+	}
+	core.RegisterAction(&d.verbs, "read", d.read)
+	return d, nil
 }
@@ -4,9 +4,9 @@ import (
 	"context"
 	"time"
 
-	"devicecode-go/errcode"
 	"devicecode-go/services/hal/internal/core"
 	"devicecode-go/types"
+	"devicecode-go/x/clock"
 )
 
 type Device struct {
@@ -17,6 +17,7 @@ type Device struct {
 
 	pub core.EventEmitter
 	reg core.ResourceRegistry
+	clk clock.Clock
 
 	dom  string
 	name string
@@ -24,6 +25,9 @@ type Device struct {
 
 	debounce time.Duration
 	es       core.GPIOEdgeStream
+	g        gestures // owned by edgeLoop after Init
+
+	verbs core.VerbTable
 }
 
 func (d *Device) ID() string { return d.id }
@@ -43,6 +47,8 @@ func (d *Device) Init(ctx context.Context) error {
 	// Publish initial value.
 	lvl := d.gpio.Get()
 	pressed := d.logicalPressed(lvl)
+	// A button held at start-up counts from now.
+	d.g.edge(pressed, d.clk.Now())
 	d.pub.Emit(core.Event{
 		Addr:    d.a,
 		Payload: types.ButtonValue{Pressed: pressed},
@@ -67,27 +73,64 @@ func (d *Device) Close() error {
 	return nil
 }
 
-func (d *Device) Control(_ core.CapAddr, verb string, _ any) (core.EnqueueResult, error) {
-	switch verb {
-	case "read":
-		pressed := d.logicalPressed(d.gpio.Get())
-		_ = d.pub.Emit(core.Event{Addr: d.a, Payload: types.ButtonValue{Pressed: pressed}})
-		return core.EnqueueResult{OK: true}, nil
-	default:
-		return core.EnqueueResult{OK: false, Error: errcode.Unsupported}, nil
-	}
+func (d *Device) Control(_ core.CapAddr, verb string, payload any) (core.EnqueueResult, error) {
+	return d.verbs.Dispatch(verb, payload)
 }
 
+func (d *Device) Verbs(core.CapAddr) []core.VerbSpec { return d.verbs.Specs() }
+
+func (d *Device) read() (core.EnqueueResult, error) {
+	pressed := d.logicalPressed(d.gpio.Get())
+	_ = d.pub.Emit(core.Event{Addr: d.a, Payload: types.ButtonValue{Pressed: pressed}})
+	return core.EnqueueResult{OK: true}, nil
+}
+
+// edgeLoop publishes each edge and runs the gesture recogniser, waking
+// for its deadlines between edges. It ends when the stream closes.
 func (d *Device) edgeLoop() {
-	for ev := range d.es.Events() {
-		pressed := d.logicalPressed(ev.Level)
-		tag := "released"
-		if pressed {
-			tag = "pressed"
+	t := d.clk.NewTimer(time.Hour)
+	defer t.Stop()
+	for {
+		if due := d.g.due(); !due.IsZero() {
+			resetTimer(t, due.Sub(d.clk.Now()))
+		} else {
+			t.Stop()
+		}
+		select {
+		case ev, ok := <-d.es.Events():
+			if !ok {
+				return
+			}
+			pressed := d.logicalPressed(ev.Level)
+			tag := "released"
+			if pressed {
+				tag = "pressed"
+			}
+			_ = d.pub.Emit(core.Event{Addr: d.a, EventTag: tag})
+			_ = d.pub.Emit(core.Event{Addr: d.a, Payload: types.ButtonValue{Pressed: pressed}})
+			d.gesture(d.g.edge(pressed, time.Unix(0, ev.TS)))
+		case <-t.C():
+			d.gesture(d.g.expire(d.clk.Now()))
+		}
+	}
+}
+
+func (d *Device) gesture(tag string, held time.Duration) {
+	if tag == "" {
+		return
+	}
+	_ = d.pub.Emit(core.Event{Addr: d.a, EventTag: tag, Payload: types.ButtonGesture{HeldMs: uint32(held / time.Millisecond)}})
+}
+
+// resetTimer re-arms t for d, draining a pending fire.
+func resetTimer(t clock.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C():
+		default:
 		}
-		_ = d.pub.Emit(core.Event{Addr: d.a, EventTag: tag})
-		_ = d.pub.Emit(core.Event{Addr: d.a, Payload: types.ButtonValue{Pressed: pressed}})
 	}
+	t.Reset(d)
 }
 
 func (d *Device) logicalPressed(level bool) bool {
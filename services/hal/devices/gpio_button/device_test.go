@@ -0,0 +1,153 @@
+package gpio_button
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type fakePin struct{ core.GPIOHandle }
+
+func (fakePin) Get() bool { return false }
+
+type fakeStream struct {
+	core.GPIOEdgeStream
+	ch chan core.GPIOEdgeEvent
+}
+
+func (s *fakeStream) Events() <-chan core.GPIOEdgeEvent { return s.ch }
+func (s *fakeStream) Close()                            {}
+
+// fakeReg hands out one edge stream; everything else is unused.
+type fakeReg struct {
+	core.ResourceRegistry
+	es *fakeStream
+}
+
+func (r fakeReg) SubscribeGPIOEdges(string, int, core.GPIOEdge, time.Duration, int) (core.GPIOEdgeStream, error) {
+	return r.es, nil
+}
+func (fakeReg) UnsubscribeGPIOEdges(string, int) {}
+func (fakeReg) ReleasePin(string, int)           {}
+
+type tags chan core.Event
+
+func (c tags) Emit(ev core.Event) bool { c <- ev; return true }
+
+// gestureOf waits for the next gesture event, skipping edge and value
+// events.
+func (c tags) gestureOf(t *testing.T) (string, uint32) {
+	t.Helper()
+	for {
+		select {
+		case ev := <-c:
+			if g, ok := ev.Payload.(types.ButtonGesture); ok {
+				return ev.EventTag, g.HeldMs
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no gesture")
+		}
+	}
+}
+
+func (c tags) none(t *testing.T) {
+	t.Helper()
+	for {
+		select {
+		case ev := <-c:
+			if _, ok := ev.Payload.(types.ButtonGesture); ok {
+				t.Fatalf("unexpected gesture %q", ev.EventTag)
+			}
+		case <-time.After(20 * time.Millisecond):
+			return
+		}
+	}
+}
+
+type rig struct {
+	t   *testing.T
+	clk *clock.Fake
+	es  *fakeStream
+	ev  tags
+}
+
+func newRig(t *testing.T) *rig {
+	r := &rig{t: t, clk: clock.NewFake(t0), es: &fakeStream{ch: make(chan core.GPIOEdgeEvent)}, ev: make(tags, 64)}
+	d := &Device{
+		id: "btn", pinN: 3, gpio: fakePin{}, pub: r.ev, reg: fakeReg{es: r.es}, clk: r.clk,
+		dom: "ui", name: "b",
+		g: gestures{longLim: time.Second, doubleGap: 300 * time.Millisecond},
+	}
+	if err := d.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { close(r.es.ch) })
+	return r
+}
+
+// edge delivers a level change stamped with the fake time and waits for
+// the loop to re-arm its deadline (if any).
+func (r *rig) edge(pressed bool, armed int) {
+	r.es.ch <- core.GPIOEdgeEvent{Pin: 3, Level: pressed, TS: r.clk.Now().UnixNano()}
+	r.settle(armed)
+}
+
+func (r *rig) settle(armed int) {
+	r.t.Helper()
+	for deadline := time.Now().Add(time.Second); r.clk.Pending() != armed; {
+		if time.Now().After(deadline) {
+			r.t.Fatalf("pending timers = %d, want %d", r.clk.Pending(), armed)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestButton_LongPressFiresWhileHeld(t *testing.T) {
+	r := newRig(t)
+	r.edge(true, 1)
+	r.clk.Advance(999 * time.Millisecond)
+	r.ev.none(t)
+	r.clk.Advance(time.Millisecond)
+	if tag, held := r.ev.gestureOf(t); tag != "long" || held != 1000 {
+		t.Fatalf("gesture = %s %d", tag, held)
+	}
+	r.settle(0)
+	r.clk.Advance(2 * time.Second)
+	r.edge(false, 0) // release after long: nothing more
+	r.ev.none(t)
+}
+
+func TestButton_ShortWaitsOutDoubleGap(t *testing.T) {
+	r := newRig(t)
+	r.edge(true, 1)
+	r.clk.Advance(120 * time.Millisecond)
+	r.edge(false, 1)
+	r.clk.Advance(299 * time.Millisecond)
+	r.ev.none(t)
+	r.clk.Advance(time.Millisecond)
+	if tag, held := r.ev.gestureOf(t); tag != "short" || held != 120 {
+		t.Fatalf("gesture = %s %d", tag, held)
+	}
+}
+
+func TestButton_DoublePress(t *testing.T) {
+	r := newRig(t)
+	r.edge(true, 1)
+	r.clk.Advance(80 * time.Millisecond)
+	r.edge(false, 1)
+	r.clk.Advance(200 * time.Millisecond)
+	r.edge(true, 1)
+	r.clk.Advance(50 * time.Millisecond)
+	r.edge(false, 0)
+	if tag, held := r.ev.gestureOf(t); tag != "double" || held != 50 {
+		t.Fatalf("gesture = %s %d", tag, held)
+	}
+	r.clk.Advance(time.Second)
+	r.ev.none(t)
+}
@@ -0,0 +1,81 @@
+package gpio_button
+
+import "time"
+
+// gestures turns debounced press/release edges into short, long and double
+// press gestures. It is driven by the edge loop: edge for every change of
+// level, and expire once due() has passed.
+//
+//   - long:   held for longLim; reported while still held, once per press.
+//   - double: a second press starting within doubleGap of a short release;
+//     reported on the second release.
+//   - short:  a release before longLim that is not followed by a second
+//     press within doubleGap, so it is reported doubleGap after release.
+type gestures struct {
+	longLim   time.Duration
+	doubleGap time.Duration
+
+	down     bool
+	downAt   time.Time
+	longSent bool
+	second   bool // this press follows a short release inside doubleGap
+
+	shortAt   time.Time // release of a short press awaiting a second; zero if none
+	shortHeld time.Duration
+}
+
+// edge records a change of level and returns the gesture it completes, if
+// any, with how long the button was held.
+func (g *gestures) edge(pressed bool, now time.Time) (string, time.Duration) {
+	if pressed == g.down {
+		return "", 0 // a dropped edge in between; nothing to conclude
+	}
+	g.down = pressed
+	if pressed {
+		g.downAt, g.longSent, g.second = now, false, false
+		if g.shortAt.IsZero() {
+			return "", 0
+		}
+		pending, held := g.shortAt, g.shortHeld
+		g.shortAt = time.Time{}
+		if now.Sub(pending) < g.doubleGap {
+			g.second = true
+			return "", 0
+		}
+		return "short", held // its window lapsed before expire ran
+	}
+	held := now.Sub(g.downAt)
+	switch {
+	case g.longSent:
+		return "", 0
+	case g.second:
+		g.second = false
+		return "double", held
+	}
+	g.shortAt, g.shortHeld = now, held
+	return "", 0
+}
+
+// due reports when expire has work to do; zero if nothing is pending.
+func (g *gestures) due() time.Time {
+	switch {
+	case g.down && !g.longSent:
+		return g.downAt.Add(g.longLim)
+	case !g.down && !g.shortAt.IsZero():
+		return g.shortAt.Add(g.doubleGap)
+	}
+	return time.Time{}
+}
+
+// expire returns a gesture whose deadline has passed by now.
+func (g *gestures) expire(now time.Time) (string, time.Duration) {
+	switch {
+	case g.down && !g.longSent && now.Sub(g.downAt) >= g.longLim:
+		g.longSent, g.second = true, false
+		return "long", now.Sub(g.downAt)
+	case !g.down && !g.shortAt.IsZero() && now.Sub(g.shortAt) >= g.doubleGap:
+		g.shortAt = time.Time{}
+		return "short", g.shortHeld
+	}
+	return "", 0
+}
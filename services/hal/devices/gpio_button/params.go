@@ -0,0 +1,45 @@
+package gpio_button
+
+import "devicecode-go/x/cbor"
+
+// MarshalCBOR and UnmarshalCBOR carry Params through config export and
+// import (hal/config/control), keyed by the JSON field names.
+func (p Params) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("Pin", int64(p.Pin))
+		m.Text("PinName", p.PinName)
+		m.Text("Pull", p.Pull)
+		m.Bool("Invert", p.Invert)
+		m.Uint("DebounceMs", uint64(p.DebounceMs))
+		m.Text("Domain", p.Domain)
+		m.Text("Name", p.Name)
+		m.Uint("LongMs", uint64(p.LongMs))
+		m.Uint("DoubleMs", uint64(p.DoubleMs))
+	})
+}
+
+func (p *Params) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Pin":
+			return cbor.ReadInt(d, &p.Pin)
+		case "PinName":
+			return cbor.ReadText(d, &p.PinName)
+		case "Pull":
+			return cbor.ReadText(d, &p.Pull)
+		case "Invert":
+			return cbor.ReadBool(d, &p.Invert)
+		case "DebounceMs":
+			return cbor.ReadUint(d, &p.DebounceMs)
+		case "Domain":
+			return cbor.ReadText(d, &p.Domain)
+		case "Name":
+			return cbor.ReadText(d, &p.Name)
+		case "LongMs":
+			return cbor.ReadUint(d, &p.LongMs)
+		case "DoubleMs":
+			return cbor.ReadUint(d, &p.DoubleMs)
+		}
+		return d.Skip()
+	})
+}
@@ -0,0 +1,69 @@
+package gpio_counter
+
+import (
+	"context"
+	"time"
+
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+func init() {
+	core.RegisterBuilder("gpio_counter", builder{})
+	core.RegisterParams[Params]("gpio_counter")
+}
+
+// Params describe an edge counter on a pin that another device has claimed
+// as an input (e.g. SMBALERT owned by the charger). The counter only
+// observes: it does not claim, configure or release the pin, so it must be
+// listed after the owning device.
+type Params struct {
+	Pin        int
+	PinName    string // optional alias; overrides Pin
+	Edges      string // "rising","falling","both" (default)
+	DebounceMs uint16
+	Domain     string
+	Name       string
+}
+
+type builder struct{}
+
+func (builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, error) {
+	p, ok := in.Params.(Params)
+	if !ok {
+		return nil, errcode.InvalidParams
+	}
+	if p.Domain == "" || p.Name == "" {
+		return nil, errcode.InvalidParams
+	}
+	pin, err := core.ResolvePin(in.Res.Reg, p.Pin, p.PinName)
+	if err != nil {
+		return nil, err
+	}
+	var sel core.GPIOEdge
+	switch p.Edges {
+	case "rising":
+		sel = core.EdgeRising
+	case "falling":
+		sel = core.EdgeFalling
+	case "", "both":
+		sel, p.Edges = core.EdgeBoth, "both"
+	default:
+		return nil, errcode.InvalidParams
+	}
+
+	d := &Device{
+		id:       in.ID,
+		pinN:     pin,
+		edges:    sel,
+		edgeName: p.Edges,
+		debounce: time.Duration(p.DebounceMs) * time.Millisecond,
+		pub:      in.Res.Pub,
+		reg:      in.Res.Reg,
+		a:        core.CapAddr{Domain: p.Domain, Kind: types.KindCounter, Name: p.Name},
+	}
+	core.RegisterAction(&d.verbs, "read", d.read)
+	core.RegisterAction(&d.verbs, "reset", d.reset)
+	return d, nil
+}
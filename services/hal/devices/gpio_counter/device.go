@@ -0,0 +1,104 @@
+package gpio_counter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+type Device struct {
+	id       string
+	pinN     int
+	edges    core.GPIOEdge
+	edgeName string
+	debounce time.Duration
+
+	pub core.EventEmitter
+	reg core.ResourceRegistry
+	a   core.CapAddr
+
+	es core.GPIOEdgeStream
+
+	mu  sync.Mutex
+	val types.CounterValue
+
+	verbs core.VerbTable
+}
+
+func (d *Device) ID() string { return d.id }
+
+func (d *Device) Capabilities() []core.CapabilitySpec {
+	return []core.CapabilitySpec{{
+		Domain: d.a.Domain,
+		Kind:   types.KindCounter,
+		Name:   d.a.Name,
+		Info: types.Info{SchemaVersion: 1, Driver: "gpio_counter",
+			Detail: types.CounterInfo{Pin: d.pinN, Edges: d.edgeName}},
+	}}
+}
+
+func (d *Device) Init(ctx context.Context) error {
+	es, err := d.reg.SubscribeGPIOEdges(d.id, d.pinN, d.edges, d.debounce, 8)
+	if err != nil {
+		// Typically the pin is not (yet) claimed as an input.
+		d.pub.Emit(core.Event{Addr: d.a, Err: "edge_sub_failed"})
+		return nil
+	}
+	d.es = es
+	d.emit()
+	go d.edgeLoop()
+	return nil
+}
+
+func (d *Device) Close() error {
+	if d.es != nil {
+		d.es.Close()
+	}
+	return nil
+}
+
+func (d *Device) Control(_ core.CapAddr, verb string, payload any) (core.EnqueueResult, error) {
+	return d.verbs.Dispatch(verb, payload)
+}
+
+func (d *Device) Verbs(core.CapAddr) []core.VerbSpec { return d.verbs.Specs() }
+
+func (d *Device) read() (core.EnqueueResult, error) {
+	d.emit()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) reset() (core.EnqueueResult, error) {
+	d.mu.Lock()
+	d.val = types.CounterValue{}
+	d.mu.Unlock()
+	d.emit()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) emit() {
+	d.mu.Lock()
+	v := d.val
+	d.mu.Unlock()
+	_ = d.pub.Emit(core.Event{Addr: d.a, Payload: v})
+}
+
+// edgeLoop ends when the stream closes, which includes the owning device
+// releasing the pin.
+func (d *Device) edgeLoop() {
+	for ev := range d.es.Events() {
+		d.mu.Lock()
+		if ev.Level {
+			d.val.Rising++
+		} else {
+			d.val.Falling++
+		}
+		d.val.TS = ev.TS
+		d.mu.Unlock()
+		d.emit()
+	}
+	_ = d.pub.Emit(core.Event{Addr: d.a, Err: "pin_released"})
+}
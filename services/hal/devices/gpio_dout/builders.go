@@ -10,7 +10,9 @@ import (
 func init() {
 	// Register both device kinds with a single parameterised builder.
 	core.RegisterBuilder("gpio_led", gpioBuilder{role: RoleLED})
+	core.RegisterParams[Params]("gpio_led")
 	core.RegisterBuilder("gpio_switch", gpioBuilder{role: RoleSwitch})
+	core.RegisterParams[Params]("gpio_switch")
 }
 
 // One builder, parameterised by device role.
@@ -25,6 +27,9 @@ func (b gpioBuilder) Build(ctx context.Context, in core.BuilderInput) (core.Devi
 	if p.Domain == "" || p.Name == "" {
 		return nil, errcode.InvalidParams
 	}
+	if p.Pin, err = core.ResolvePin(in.Res.Reg, p.Pin, p.PinName); err != nil {
+		return nil, err
+	}
 	ph, err := in.Res.Reg.ClaimPin(in.ID, p.Pin, core.FuncGPIOOut)
 	if err != nil {
 		return nil, err
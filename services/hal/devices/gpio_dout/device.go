@@ -2,18 +2,31 @@ package gpio_dout
 
 import (
 	"context"
+	"time"
 
-	"devicecode-go/errcode"
 	"devicecode-go/services/hal/internal/core"
 	"devicecode-go/types"
 )
 
 type Params struct {
 	Pin       int
+	PinName   string // optional alias (e.g. "FAN_EN"); overrides Pin
 	ActiveLow bool
 	Initial   bool
 	Domain    string
 	Name      string
+
+	// Optional interlock group (switches only). Members of the same group
+	// share the limits; where members disagree the strictest value applies.
+	Interlock    string // group name; empty => none
+	InterlockMax int    // max members on at once (1 => mutually exclusive; 0 => no limit)
+	InrushMax    int    // max members switched on within InrushMs (0 => no limit)
+	InrushMs     uint32
+
+	// Optional soft-start (switches only): pulse the enable with rising duty
+	// for SoftStartMs before latching on, to pre-charge load capacitance.
+	SoftStartMs       uint32
+	SoftStartPeriodUs uint32 // pulse period; 0 => 1000
 }
 
 type Role int
@@ -34,8 +47,14 @@ type Device struct {
 	domain    string
 	name      string
 	initial   bool
+	il        *interlock // nil => not interlocked
+	softMs    uint32
+	softPerUs uint32
+	soft      *softStart // active or completed ramp; nil when off
 	// derived address for the single capability
 	addr core.CapAddr
+
+	verbs core.VerbTable
 }
 
 func New(role Role, id string, p Params, h core.GPIOHandle, pub core.EventEmitter, reg core.ResourceRegistry) *Device {
@@ -67,6 +86,29 @@ func New(role Role, id string, p Params, h core.GPIOHandle, pub core.EventEmitte
 		kind = types.KindSwitch
 	}
 	d.addr = core.CapAddr{Domain: d.domain, Kind: kind, Name: d.name}
+	if role == RoleSwitch && p.Interlock != "" {
+		d.il = joinInterlock(p)
+	}
+	if role == RoleSwitch && p.SoftStartMs > 0 {
+		d.softMs, d.softPerUs = p.SoftStartMs, p.SoftStartPeriodUs
+		if d.softPerUs == 0 {
+			d.softPerUs = 1000
+		}
+	}
+
+	switch role {
+	case RoleSwitch:
+		core.RegisterVerb(&d.verbs, "set", func(p types.SwitchSet) (core.EnqueueResult, error) {
+			return d.set(p.On)
+		})
+	default:
+		core.RegisterVerb(&d.verbs, "set", func(p types.LEDSet) (core.EnqueueResult, error) {
+			return d.set(p.On)
+		})
+	}
+	core.RegisterAction(&d.verbs, "toggle", d.toggle)
+	core.RegisterAction(&d.verbs, "read", d.read)
+	core.RegisterAction(&d.verbs, "get", d.read)
 	return d
 }
 
@@ -82,7 +124,7 @@ func (d *Device) Capabilities() []core.CapabilitySpec {
 			Info: types.Info{
 				SchemaVersion: 1,
 				Driver:        "gpio_dout",
-				Detail:        types.SwitchInfo{Pin: d.pin.Number()},
+				Detail:        types.SwitchInfo{Pin: d.pin.Number(), Interlock: d.ilName()},
 			},
 		}}
 	default:
@@ -100,6 +142,11 @@ func (d *Device) Capabilities() []core.CapabilitySpec {
 }
 
 func (d *Device) Init(ctx context.Context) error {
+	if d.initial && d.il != nil {
+		if err := d.il.acquire(d.id, time.Now().UnixNano()); err != nil {
+			return err
+		}
+	}
 	level := d.initial
 	if d.activeLow {
 		level = !level
@@ -112,6 +159,10 @@ func (d *Device) Init(ctx context.Context) error {
 }
 
 func (d *Device) Close() error {
+	d.stopSoft()
+	if d.il != nil {
+		d.il.release(d.id)
+	}
 	if d.reg != nil {
 		d.reg.ReleasePin(d.id, d.pinN)
 	}
@@ -119,34 +170,49 @@ func (d *Device) Close() error {
 }
 
 func (d *Device) Control(_ core.CapAddr, method string, payload any) (core.EnqueueResult, error) {
-	switch method {
-	case "set":
-		switch d.role {
-		case RoleSwitch:
-			p, code := core.As[types.SwitchSet](payload)
-			if code != "" {
-				return core.EnqueueResult{OK: false, Error: code}, nil
-			}
-			d.setLogical(p.On)
-		default:
-			p, code := core.As[types.LEDSet](payload)
-			if code != "" {
-				return core.EnqueueResult{OK: false, Error: code}, nil
+	return d.verbs.Dispatch(method, payload)
+}
+
+func (d *Device) Verbs(core.CapAddr) []core.VerbSpec { return d.verbs.Specs() }
+
+func (d *Device) set(on bool) (core.EnqueueResult, error) {
+	if on && d.soft != nil {
+		return core.EnqueueResult{OK: true}, nil // ramping or latched
+	}
+	if d.il != nil {
+		if on && !d.getLogical() {
+			if err := d.il.acquire(d.id, time.Now().UnixNano()); err != nil {
+				return core.EnqueueResult{}, err
 			}
-			d.setLogical(p.On)
+		} else if !on {
+			d.il.release(d.id)
 		}
-		d.emitValueNow()
-		return core.EnqueueResult{OK: true}, nil
-	case "toggle":
-		d.setLogical(!d.getLogical())
-		d.emitValueNow()
-		return core.EnqueueResult{OK: true}, nil
-	case "read":
-		d.emitValueNow()
+	}
+	if !on {
+		d.stopSoft()
+	} else if d.softMs > 0 && !d.getLogical() {
+		d.startSoft() // emits the value once latched
 		return core.EnqueueResult{OK: true}, nil
-	default:
-		return core.EnqueueResult{OK: false, Error: errcode.Unsupported}, nil
 	}
+	d.setLogical(on)
+	d.emitValueNow()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) toggle() (core.EnqueueResult, error) {
+	return d.set(!(d.soft != nil || d.getLogical()))
+}
+
+func (d *Device) ilName() string {
+	if d.il == nil {
+		return ""
+	}
+	return d.il.name
+}
+
+func (d *Device) read() (core.EnqueueResult, error) {
+	d.emitValueNow()
+	return core.EnqueueResult{OK: true}, nil
 }
 
 func (d *Device) setLogical(on bool) {
@@ -170,7 +236,7 @@ func (d *Device) emitValueNow() {
 	case RoleSwitch:
 		_ = d.pub.Emit(core.Event{
 			Addr:    d.addr,
-			Payload: types.SwitchValue{On: d.getLogical()},
+			Payload: types.SwitchValue{On: d.getLogical(), TS: time.Now().UnixNano()},
 		})
 	default:
 		_ = d.pub.Emit(core.Event{
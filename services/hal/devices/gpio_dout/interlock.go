@@ -0,0 +1,100 @@
+package gpio_dout
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"devicecode-go/errcode"
+)
+
+// interlock is shared by every switch naming the same group. It limits how
+// many members may be on at once and how many may be switched on inside an
+// inrush window, so rails with large input capacitance are not energised
+// together.
+type interlock struct {
+	name      string
+	maxOn     int
+	inrushMax int
+	inrushWin int64 // ns
+
+	on     map[string]bool
+	starts []int64 // turn-on times within the inrush window
+}
+
+var (
+	ilMu       sync.Mutex
+	interlocks = map[string]*interlock{}
+)
+
+func joinInterlock(p Params) *interlock {
+	ilMu.Lock()
+	defer ilMu.Unlock()
+	g := interlocks[p.Interlock]
+	if g == nil {
+		g = &interlock{name: p.Interlock, on: map[string]bool{}}
+		interlocks[p.Interlock] = g
+	}
+	g.maxOn = stricter(g.maxOn, p.InterlockMax)
+	g.inrushMax = stricter(g.inrushMax, p.InrushMax)
+	if w := int64(time.Duration(p.InrushMs) * time.Millisecond); w > g.inrushWin {
+		g.inrushWin = w
+	}
+	return g
+}
+
+// stricter returns the smaller non-zero limit (0 means no limit).
+func stricter(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// acquire admits id turning on at now, or explains the refusal. Exceeding
+// the on-limit is a configuration conflict; exceeding the inrush limit is
+// transient and reported as busy so callers can retry.
+func (g *interlock) acquire(id string, now int64) error {
+	ilMu.Lock()
+	defer ilMu.Unlock()
+	if g.on[id] {
+		return nil
+	}
+	if g.maxOn > 0 && len(g.on) >= g.maxOn {
+		return &errcode.E{C: errcode.Interlocked, Op: "set",
+			Msg: "group " + g.name + " allows " + strconv.Itoa(g.maxOn) + " on (" + g.members() + ")"}
+	}
+	if g.inrushMax > 0 && g.inrushWin > 0 {
+		keep := g.starts[:0]
+		for _, t := range g.starts {
+			if now-t < g.inrushWin {
+				keep = append(keep, t)
+			}
+		}
+		g.starts = keep
+		if len(g.starts) >= g.inrushMax {
+			return &errcode.E{C: errcode.Busy, Op: "set",
+				Msg: "group " + g.name + " inrush window in use"}
+		}
+		g.starts = append(g.starts, now)
+	}
+	g.on[id] = true
+	return nil
+}
+
+func (g *interlock) release(id string) {
+	ilMu.Lock()
+	delete(g.on, id)
+	ilMu.Unlock()
+}
+
+func (g *interlock) members() string {
+	s := ""
+	for id := range g.on {
+		if s != "" {
+			s += ","
+		}
+		s += id
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+package gpio_dout
+
+import "devicecode-go/x/cbor"
+
+// MarshalCBOR and UnmarshalCBOR carry Params through config export and
+// import (hal/config/control), keyed by the JSON field names.
+func (p Params) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("Pin", int64(p.Pin))
+		m.Text("PinName", p.PinName)
+		m.Bool("ActiveLow", p.ActiveLow)
+		m.Bool("Initial", p.Initial)
+		m.Text("Domain", p.Domain)
+		m.Text("Name", p.Name)
+		m.Text("Interlock", p.Interlock)
+		m.Int("InterlockMax", int64(p.InterlockMax))
+		m.Int("InrushMax", int64(p.InrushMax))
+		m.Uint("InrushMs", uint64(p.InrushMs))
+		m.Uint("SoftStartMs", uint64(p.SoftStartMs))
+		m.Uint("SoftStartPeriodUs", uint64(p.SoftStartPeriodUs))
+	})
+}
+
+func (p *Params) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Pin":
+			return cbor.ReadInt(d, &p.Pin)
+		case "PinName":
+			return cbor.ReadText(d, &p.PinName)
+		case "ActiveLow":
+			return cbor.ReadBool(d, &p.ActiveLow)
+		case "Initial":
+			return cbor.ReadBool(d, &p.Initial)
+		case "Domain":
+			return cbor.ReadText(d, &p.Domain)
+		case "Name":
+			return cbor.ReadText(d, &p.Name)
+		case "Interlock":
+			return cbor.ReadText(d, &p.Interlock)
+		case "InterlockMax":
+			return cbor.ReadInt(d, &p.InterlockMax)
+		case "InrushMax":
+			return cbor.ReadInt(d, &p.InrushMax)
+		case "InrushMs":
+			return cbor.ReadUint(d, &p.InrushMs)
+		case "SoftStartMs":
+			return cbor.ReadUint(d, &p.SoftStartMs)
+		case "SoftStartPeriodUs":
+			return cbor.ReadUint(d, &p.SoftStartPeriodUs)
+		}
+		return d.Skip()
+	})
+}
@@ -0,0 +1,51 @@
+package gpio_dout
+
+import "time"
+
+// softStart pulses the enable with linearly rising duty, then latches it
+// on. Rails with heavy input capacitance charge over several pulses rather
+// than in one inrush step, which keeps VIN above the sag threshold.
+type softStart struct {
+	cancel chan struct{}
+	done   chan struct{}
+}
+
+// startSoft is called from the HAL loop; the pulse train runs in its own
+// goroutine and emits the latched value through HAL when it completes.
+func (d *Device) startSoft() {
+	s := &softStart{cancel: make(chan struct{}), done: make(chan struct{})}
+	d.soft = s
+	go d.runSoft(s)
+}
+
+// stopSoft cancels a ramp in progress and waits (at most one pulse period)
+// for the pulse train to stop.
+func (d *Device) stopSoft() {
+	s := d.soft
+	if s == nil {
+		return
+	}
+	d.soft = nil
+	close(s.cancel)
+	<-s.done
+}
+
+func (d *Device) runSoft(s *softStart) {
+	defer close(s.done)
+	period := time.Duration(d.softPerUs) * time.Microsecond
+	n := time.Duration(d.softMs) * time.Millisecond / period
+	for i := time.Duration(1); i < n; i++ {
+		on := period * i / n
+		d.setLogical(true)
+		time.Sleep(on)
+		d.setLogical(false)
+		select {
+		case <-s.cancel:
+			return
+		default:
+		}
+		time.Sleep(period - on)
+	}
+	d.setLogical(true)
+	d.emitValueNow()
+}
@@ -0,0 +1,146 @@
+package gpioexpdev
+
+import (
+	"context"
+
+	"devicecode-go/drivers/mcp23017"
+	"devicecode-go/drivers/pcf8574"
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+func init() {
+	core.RegisterBuilder("mcp23017", builder{chip: "mcp23017", pins: 16})
+	core.RegisterParams[Params]("mcp23017")
+	core.RegisterBuilder("pcf8574", builder{chip: "pcf8574", pins: 8})
+	core.RegisterParams[Params]("pcf8574")
+}
+
+// Pin is one expander pin; it becomes <Domain>/gpio/<Name>.
+type Pin struct {
+	Pin       uint8  // 0..15 on the MCP23017 (GPB0 is 8), 0..7 on the PCF8574
+	Name      string // REQUIRED, e.g. "modem_en"
+	Output    bool
+	Pull      bool // input pull-up; PCF8574 inputs are always pulled up
+	ActiveLow bool
+	Initial   bool // outputs: initial *logical* level
+}
+
+// Params describe one expander. Pins not listed stay inputs (and, on the
+// MCP23017, do not raise INT).
+type Params struct {
+	Bus    string // e.g. "i2c0"
+	Addr   uint16 // default 0x20
+	Domain string // REQUIRED, e.g. "io"
+	Pins   []Pin
+
+	// Int says the chip's INT output (open-drain, active-low; either
+	// MCP23017 INTA or INTB) is wired to IntPin. Input changes are then
+	// read and published as they happen, not only when polled.
+	Int        bool
+	IntPin     int
+	IntPinName string // optional alias; overrides IntPin
+}
+
+type builder struct {
+	chip string
+	pins uint8
+}
+
+func (b builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, error) {
+	p, ok := in.Params.(Params)
+	if !ok || p.Bus == "" || p.Domain == "" || len(p.Pins) == 0 {
+		return nil, errcode.InvalidParams
+	}
+	if p.Addr == 0 {
+		p.Addr = mcp23017.Address // same default for both parts
+	}
+	var used uint16
+	for i, x := range p.Pins {
+		if x.Name == "" || x.Pin >= b.pins || used&(1<<x.Pin) != 0 {
+			return nil, errcode.InvalidParams
+		}
+		used |= 1 << x.Pin
+		for _, o := range p.Pins[:i] {
+			if o.Name == x.Name {
+				return nil, errcode.InvalidParams
+			}
+		}
+	}
+
+	irq := -1
+	if p.Int {
+		n, err := core.ResolvePin(in.Res.Reg, p.IntPin, p.IntPinName)
+		if err != nil {
+			return nil, err
+		}
+		irq = n
+	}
+
+	i2c, err := in.Res.Reg.ClaimI2C(in.ID, core.ResourceID(p.Bus))
+	if err != nil {
+		return nil, err
+	}
+	d := &Device{
+		id:    in.ID,
+		p:     p,
+		chipN: b.chip,
+		pub:   in.Res.Pub,
+		reg:   in.Res.Reg,
+		irq:   irq,
+		kick:  make(chan struct{}, 1),
+		quit:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	if irq >= 0 {
+		ph, err := in.Res.Reg.ClaimPin(in.ID, irq, core.FuncGPIOIn)
+		if err != nil {
+			in.Res.Reg.ReleaseI2C(in.ID, core.ResourceID(p.Bus))
+			return nil, err
+		}
+		d.ih = ph.AsGPIO()
+		_ = d.ih.ConfigureInput(core.PullUp)
+	}
+
+	// Unlisted pins are inputs; listed outputs start at their initial level.
+	d.in = 1<<b.pins - 1
+	for _, x := range p.Pins {
+		bit := uint16(1) << x.Pin
+		if x.Output {
+			d.in &^= bit
+			if x.Initial != x.ActiveLow {
+				d.out |= bit
+			}
+		} else {
+			if b.chip == "pcf8574" {
+				x.Pull = true
+			}
+			if x.Pull {
+				d.pull |= bit
+			}
+		}
+		pn := &pin{Pin: x, bit: bit, a: core.CapAddr{Domain: p.Domain, Kind: types.KindGPIO, Name: x.Name}}
+		core.RegisterAction(&pn.verbs, "read", d.read)
+		if x.Output {
+			core.RegisterVerb(&pn.verbs, "set", func(s types.GPIOSet) (core.EnqueueResult, error) {
+				return d.set(pn, s)
+			})
+			core.RegisterAction(&pn.verbs, "toggle", func() (core.EnqueueResult, error) {
+				return d.toggle(pn)
+			})
+		}
+		d.pins = append(d.pins, pn)
+	}
+
+	if b.chip == "pcf8574" {
+		drv := pcf8574.New(i2c)
+		drv.Address = p.Addr
+		d.chip = &chip8574{drv: drv}
+	} else {
+		drv := mcp23017.New(i2c)
+		drv.Address = p.Addr
+		d.chip = &chip23017{drv: drv, irq: irq >= 0}
+	}
+	return d, nil
+}
@@ -0,0 +1,292 @@
+package gpioexpdev
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"devicecode-go/drivers/mcp23017"
+	"devicecode-go/drivers/pcf8574"
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+const (
+	retryEvery = time.Second
+	// While INT stays asserted after a read (a change raced the read), the
+	// worker reads again, at most this many times per edge.
+	maxIRQPasses = 4
+)
+
+// chip hides the part differences from the worker. Masks are physical,
+// bit n for pin n.
+type chip interface {
+	configure(in, pull, out uint16) error
+	read() (uint16, error)
+	write(out uint16) error
+}
+
+type chip23017 struct {
+	drv mcp23017.Device
+	irq bool
+}
+
+func (c *chip23017) configure(in, pull, out uint16) error {
+	cfg := mcp23017.Config{Input: in, Pull: pull, Output: out}
+	if c.irq {
+		cfg.Change = in
+	}
+	return c.drv.Configure(cfg)
+}
+func (c *chip23017) read() (uint16, error)  { return c.drv.Read() }
+func (c *chip23017) write(out uint16) error { return c.drv.Write(out) }
+
+// chip8574 keeps inputs latched high (weak pull-up) on every write.
+type chip8574 struct {
+	drv pcf8574.Device
+	in  uint8
+}
+
+func (c *chip8574) configure(in, _, out uint16) error {
+	c.in = uint8(in)
+	return c.write(out)
+}
+func (c *chip8574) read() (uint16, error) {
+	v, err := c.drv.Read()
+	return uint16(v), err
+}
+func (c *chip8574) write(out uint16) error { return c.drv.Write(uint8(out) | c.in) }
+
+type pin struct {
+	Pin
+	bit   uint16
+	a     core.CapAddr
+	verbs core.VerbTable
+
+	// Owned by the worker.
+	level bool // last published logical level
+	known bool
+}
+
+type Device struct {
+	id    string
+	p     Params
+	chipN string
+	chip  chip
+	pins  []*pin
+
+	pub core.EventEmitter
+	reg core.ResourceRegistry
+
+	irq int // -1 when INT is not wired
+	ih  core.GPIOHandle
+	es  core.GPIOEdgeStream
+
+	in, pull uint16 // fixed at build
+
+	// Guarded by mu: the output latch image and pending requests.
+	mu     sync.Mutex
+	out    uint16
+	dirty  bool   // out not yet written
+	report uint16 // pins to publish on the next read
+	all    bool   // publish every pin on the next read
+
+	kick  chan struct{}
+	quit  chan struct{}
+	done  chan struct{}
+	ready bool // chip configured; owned by the worker
+}
+
+func (d *Device) ID() string { return d.id }
+
+func (d *Device) Capabilities() []core.CapabilitySpec {
+	out := make([]core.CapabilitySpec, 0, len(d.pins))
+	for _, pn := range d.pins {
+		out = append(out, core.CapabilitySpec{
+			Domain: pn.a.Domain, Kind: types.KindGPIO, Name: pn.a.Name,
+			Info: types.Info{SchemaVersion: 1, Driver: d.chipN, Detail: types.GPIOInfo{
+				Chip: d.chipN, Bus: d.p.Bus, Addr: d.p.Addr, Pin: int(pn.Pin.Pin),
+				Output: pn.Output, Pull: pn.Pull, ActiveLow: pn.ActiveLow,
+				IRQ: d.irq >= 0 && !pn.Output,
+			}},
+		})
+	}
+	return out
+}
+
+func (d *Device) Init(ctx context.Context) error {
+	d.emitErr("initialising")
+	if d.irq >= 0 {
+		if es, err := d.reg.SubscribeGPIOEdges(d.id, d.irq, core.EdgeFalling, 0, 4); err == nil {
+			d.es = es
+		} else {
+			// Polling still works; inputs are then only read on read.
+			d.emitErr("int_subscribe_failed")
+		}
+	}
+	go d.run()
+	d.wake()
+	return nil
+}
+
+func (d *Device) Close() error {
+	close(d.quit)
+	<-d.done
+	if d.es != nil {
+		d.es.Close()
+		d.reg.UnsubscribeGPIOEdges(d.id, d.irq)
+	}
+	if d.irq >= 0 {
+		d.reg.ReleasePin(d.id, d.irq)
+	}
+	d.reg.ReleaseI2C(d.id, core.ResourceID(d.p.Bus))
+	return nil
+}
+
+func (d *Device) Control(a core.CapAddr, verb string, payload any) (core.EnqueueResult, error) {
+	if pn := d.pin(a.Name); pn != nil {
+		return pn.verbs.Dispatch(verb, payload)
+	}
+	return core.EnqueueResult{OK: false, Error: errcode.Unsupported}, nil
+}
+
+func (d *Device) Verbs(a core.CapAddr) []core.VerbSpec {
+	if pn := d.pin(a.Name); pn != nil {
+		return pn.verbs.Specs()
+	}
+	return nil
+}
+
+func (d *Device) pin(name string) *pin {
+	for _, pn := range d.pins {
+		if pn.a.Name == name {
+			return pn
+		}
+	}
+	return nil
+}
+
+func (d *Device) wake() {
+	select {
+	case d.kick <- struct{}{}:
+	default:
+	}
+}
+
+// read publishes every pin of the chip; it is accepted on any pin.
+func (d *Device) read() (core.EnqueueResult, error) {
+	d.mu.Lock()
+	d.all = true
+	d.mu.Unlock()
+	d.wake()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) set(pn *pin, s types.GPIOSet) (core.EnqueueResult, error) {
+	d.mu.Lock()
+	if s.Level != pn.ActiveLow {
+		d.out |= pn.bit
+	} else {
+		d.out &^= pn.bit
+	}
+	d.dirty = true
+	d.report |= pn.bit
+	d.mu.Unlock()
+	d.wake()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) toggle(pn *pin) (core.EnqueueResult, error) {
+	d.mu.Lock()
+	d.out ^= pn.bit
+	d.dirty = true
+	d.report |= pn.bit
+	d.mu.Unlock()
+	d.wake()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) run() {
+	defer close(d.done)
+	var (
+		retry <-chan time.Time
+		edges <-chan core.GPIOEdgeEvent
+	)
+	if d.es != nil {
+		edges = d.es.Events()
+	}
+	for {
+		irq := false
+		select {
+		case <-d.quit:
+			return
+		case <-d.kick:
+		case <-retry:
+		case <-edges:
+			irq = true
+		}
+		d.service()
+		// INT is level-driven: if it is still asserted, a change landed
+		// between our read and the chip re-arming.
+		for n := 0; irq && d.ready && n < maxIRQPasses && !d.ih.Get(); n++ {
+			d.service()
+		}
+		retry = nil
+		if !d.ready {
+			retry = time.After(retryEvery)
+		}
+	}
+}
+
+// service configures the chip if needed, writes a changed latch, reads
+// the port and publishes inputs that changed plus any requested pins.
+func (d *Device) service() {
+	d.mu.Lock()
+	out, dirty, report, all := d.out, d.dirty, d.report, d.all
+	d.dirty, d.report, d.all = false, 0, false
+	d.mu.Unlock()
+
+	fail := func(err error) {
+		d.mu.Lock()
+		d.dirty = true
+		d.report |= report
+		d.all = d.all || all
+		d.mu.Unlock()
+		d.ready = false // the chip may have been power-cycled
+		d.emitErr(string(errcode.MapDriverErr(err)))
+	}
+	if !d.ready {
+		if err := d.chip.configure(d.in, d.pull, out); err != nil {
+			fail(err)
+			return
+		}
+		d.ready, dirty, all = true, false, true
+	}
+	if dirty {
+		if err := d.chip.write(out); err != nil {
+			fail(err)
+			return
+		}
+	}
+	v, err := d.chip.read()
+	if err != nil {
+		fail(err)
+		return
+	}
+	ts := time.Now().UnixNano()
+	for _, pn := range d.pins {
+		lvl := (v&pn.bit != 0) != pn.ActiveLow
+		changed := !pn.known || lvl != pn.level
+		if all || report&pn.bit != 0 || (!pn.Output && changed) {
+			pn.level, pn.known = lvl, true
+			d.pub.Emit(core.Event{Addr: pn.a, Payload: types.GPIOValue{Level: lvl, TS: ts}})
+		}
+	}
+}
+
+func (d *Device) emitErr(code string) {
+	for _, pn := range d.pins {
+		d.pub.Emit(core.Event{Addr: pn.a, Err: code})
+	}
+}
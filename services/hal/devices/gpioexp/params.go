@@ -0,0 +1,75 @@
+package gpioexpdev
+
+import "devicecode-go/x/cbor"
+
+// MarshalCBOR and UnmarshalCBOR carry Params through config export and
+// import (hal/config/control), keyed by the JSON field names.
+func (p Params) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("Bus", p.Bus)
+		m.Uint("Addr", uint64(p.Addr))
+		m.Text("Domain", p.Domain)
+		m.Array("Pins", len(p.Pins), func(e *cbor.Encoder, i int) { p.Pins[i].MarshalCBOR(e) })
+		m.Bool("Int", p.Int)
+		m.Int("IntPin", int64(p.IntPin))
+		m.Text("IntPinName", p.IntPinName)
+	})
+}
+
+func (p *Params) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Bus":
+			return cbor.ReadText(d, &p.Bus)
+		case "Addr":
+			return cbor.ReadUint(d, &p.Addr)
+		case "Domain":
+			return cbor.ReadText(d, &p.Domain)
+		case "Pins":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v Pin
+				err := v.UnmarshalCBOR(d)
+				p.Pins = append(p.Pins, v)
+				return err
+			})
+		case "Int":
+			return cbor.ReadBool(d, &p.Int)
+		case "IntPin":
+			return cbor.ReadInt(d, &p.IntPin)
+		case "IntPinName":
+			return cbor.ReadText(d, &p.IntPinName)
+		}
+		return d.Skip()
+	})
+}
+
+func (p Pin) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("Pin", uint64(p.Pin))
+		m.Text("Name", p.Name)
+		m.Bool("Output", p.Output)
+		m.Bool("Pull", p.Pull)
+		m.Bool("ActiveLow", p.ActiveLow)
+		m.Bool("Initial", p.Initial)
+	})
+}
+
+func (p *Pin) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Pin":
+			return cbor.ReadUint(d, &p.Pin)
+		case "Name":
+			return cbor.ReadText(d, &p.Name)
+		case "Output":
+			return cbor.ReadBool(d, &p.Output)
+		case "Pull":
+			return cbor.ReadBool(d, &p.Pull)
+		case "ActiveLow":
+			return cbor.ReadBool(d, &p.ActiveLow)
+		case "Initial":
+			return cbor.ReadBool(d, &p.Initial)
+		}
+		return d.Skip()
+	})
+}
@@ -0,0 +1,64 @@
+package gps_nmea
+
+import (
+	"context"
+
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+func init() {
+	core.RegisterBuilder("gps_nmea", builder{})
+	core.RegisterParams[Params]("gps_nmea")
+}
+
+// Params describe a GNSS receiver streaming NMEA 0183 on a UART. The
+// device owns the port; it exposes position and time capabilities under
+// the same domain and name.
+type Params struct {
+	Bus     string // e.g. "uart1"
+	Baud    uint32 // default 9600
+	Domain  string // REQUIRED, e.g. "env"
+	Name    string // REQUIRED
+	StaleMs uint32 // no valid sentence for this long → degraded (default 3000)
+}
+
+type builder struct{}
+
+func (builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, error) {
+	p, ok := in.Params.(Params)
+	if !ok || p.Bus == "" {
+		return nil, errcode.InvalidParams
+	}
+	if p.Domain == "" || p.Name == "" {
+		return nil, errcode.InvalidParams
+	}
+	if p.Baud == 0 {
+		p.Baud = 9600
+	}
+	if p.StaleMs == 0 {
+		p.StaleMs = 3000
+	}
+	port, err := in.Res.Reg.ClaimSerial(in.ID, core.ResourceID(p.Bus))
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Device{
+		id:    in.ID,
+		p:     p,
+		port:  port,
+		pub:   in.Res.Pub,
+		reg:   in.Res.Reg,
+		aPos:  core.CapAddr{Domain: p.Domain, Kind: types.KindPosition, Name: p.Name},
+		aTime: core.CapAddr{Domain: p.Domain, Kind: types.KindTime, Name: p.Name},
+		quit:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	if c, ok := port.(core.SerialConfigurator); ok {
+		d.cfgB = c
+	}
+	core.RegisterAction(&d.verbs, "read", d.read)
+	return d, nil
+}
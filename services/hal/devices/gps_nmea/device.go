@@ -0,0 +1,213 @@
+package gps_nmea
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+	"devicecode-go/x/nmea"
+)
+
+// maxSentence bounds a line; NMEA 0183 allows 82 characters.
+const maxSentence = 96
+
+type Device struct {
+	id   string
+	p    Params
+	port core.SerialPort
+	cfgB core.SerialConfigurator
+
+	pub core.EventEmitter
+	reg core.ResourceRegistry
+
+	aPos  core.CapAddr
+	aTime core.CapAddr
+
+	quit chan struct{}
+	done chan struct{}
+
+	mu      sync.Mutex // last published values, for read
+	pos     types.PositionValue
+	tm      types.TimeValue
+	havePos bool
+	haveTm  bool
+
+	verbs core.VerbTable
+}
+
+func (d *Device) ID() string { return d.id }
+
+func (d *Device) Capabilities() []core.CapabilitySpec {
+	return []core.CapabilitySpec{
+		{
+			Domain: d.aPos.Domain, Kind: types.KindPosition, Name: d.aPos.Name,
+			Info: types.Info{SchemaVersion: 1, Driver: "gps_nmea",
+				Detail: types.PositionInfo{Receiver: "nmea", Bus: d.p.Bus, Baud: d.p.Baud}},
+		},
+		{
+			Domain: d.aTime.Domain, Kind: types.KindTime, Name: d.aTime.Name,
+			Info: types.Info{SchemaVersion: 1, Driver: "gps_nmea",
+				Detail: types.TimeInfo{Source: "gnss"}},
+		},
+	}
+}
+
+func (d *Device) Init(ctx context.Context) error {
+	if d.cfgB != nil {
+		_ = d.cfgB.SetBaudRate(d.p.Baud)
+	}
+	d.emitErr("initialising")
+	go d.run()
+	return nil
+}
+
+func (d *Device) Close() error {
+	close(d.quit)
+	<-d.done
+	if d.reg != nil {
+		d.reg.ReleaseSerial(d.id, core.ResourceID(d.p.Bus))
+	}
+	return nil
+}
+
+func (d *Device) Control(_ core.CapAddr, verb string, payload any) (core.EnqueueResult, error) {
+	return d.verbs.Dispatch(verb, payload)
+}
+
+func (d *Device) Verbs(core.CapAddr) []core.VerbSpec { return d.verbs.Specs() }
+
+// read re-emits the last position and time, if any.
+func (d *Device) read() (core.EnqueueResult, error) {
+	d.mu.Lock()
+	pos, tm, hp, ht := d.pos, d.tm, d.havePos, d.haveTm
+	d.mu.Unlock()
+	if hp {
+		d.pub.Emit(core.Event{Addr: d.aPos, Payload: pos})
+	}
+	if ht {
+		d.pub.Emit(core.Event{Addr: d.aTime, Payload: tm})
+	}
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) emitErr(code string) {
+	d.pub.Emit(core.Event{Addr: d.aPos, Err: code})
+	d.pub.Emit(core.Event{Addr: d.aTime, Err: code})
+}
+
+// run assembles sentences from the port and publishes them. A period of
+// StaleMs without a valid sentence marks both capabilities degraded.
+//
+// Sentences are stamped with the arrival of their '$'. Where the port
+// captures the start of each RX burst at interrupt time, that is offset by
+// one 8N1 character time per byte into the burst; otherwise the stamp is
+// taken when the worker sees the byte.
+func (d *Device) run() {
+	defer close(d.done)
+
+	stamper, _ := d.port.(core.SerialRXStamper)
+	charNs := int64(10*time.Second) / int64(d.p.Baud)
+
+	stale := time.Duration(d.p.StaleMs) * time.Millisecond
+	staleT := time.NewTimer(stale)
+	defer staleT.Stop()
+
+	var (
+		buf    [64]byte
+		line   [maxSentence]byte
+		n      int
+		over   bool  // current line exceeded maxSentence; discard it
+		lineTS int64 // arrival of the current line's '$'
+		burst  int64 // arrival of the current burst's first byte; 0 if unknown
+		off    int64 // bytes read since burst
+		gga    nmea.GGAData
+		live   bool
+	)
+	for {
+		k := d.port.TryRead(buf[:])
+		if stamper != nil && k > 0 {
+			if ts := stamper.RXStamp(); ts != 0 {
+				burst, off = ts, 0
+			}
+		}
+		if k == 0 {
+			burst = 0
+			select {
+			case <-d.quit:
+				return
+			case <-d.port.Readable():
+			case <-staleT.C:
+				if live {
+					live = false
+					d.emitErr("no_data")
+				}
+				staleT.Reset(stale)
+			}
+			continue
+		}
+		for i, c := range buf[:k] {
+			if c == '$' {
+				n, over, lineTS = 0, false, time.Now().UnixNano()
+				if burst != 0 {
+					lineTS = min(lineTS, burst+(off+int64(i))*charNs)
+				}
+			}
+			if n < len(line) {
+				line[n] = c
+				n++
+			} else {
+				over = true
+			}
+			if c != '\n' {
+				continue
+			}
+			if !over && d.sentence(line[:n], lineTS, &gga) {
+				live = true
+				staleT.Reset(stale)
+			}
+			n = 0
+		}
+		off += int64(k)
+	}
+}
+
+// sentence handles one line; it reports whether the line was a valid
+// sentence. GGA is kept and merged into the position published on RMC.
+func (d *Device) sentence(line []byte, ts int64, gga *nmea.GGAData) bool {
+	body, ok := nmea.Check(line)
+	if !ok {
+		return false
+	}
+	switch nmea.KindOf(body) {
+	case nmea.GGA:
+		var g nmea.GGAData
+		if nmea.ParseGGA(body, &g) {
+			*gga = g
+		}
+	case nmea.RMC:
+		var r nmea.RMCData
+		if !nmea.ParseRMC(body, &r) {
+			return true
+		}
+		pos := types.PositionValue{
+			Fix: r.Valid, Quality: gga.Quality, Sats: gga.Sats, HDOPx100: gga.HDOPx100,
+			LatE7: r.LatE7, LonE7: r.LonE7, AltCm: gga.AltCm,
+			SpeedCmps: r.SpeedCmps, CourseCdeg: r.CourseCdeg, TS: ts,
+		}
+		d.mu.Lock()
+		d.pos, d.havePos = pos, true
+		d.mu.Unlock()
+		d.pub.Emit(core.Event{Addr: d.aPos, Payload: pos})
+
+		if r.Valid && r.UnixMs != 0 {
+			tm := types.TimeValue{EpochMs: r.UnixMs, TS: ts}
+			d.mu.Lock()
+			d.tm, d.haveTm = tm, true
+			d.mu.Unlock()
+			d.pub.Emit(core.Event{Addr: d.aTime, Payload: tm})
+		}
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package gps_nmea
+
+import "devicecode-go/x/cbor"
+
+// MarshalCBOR and UnmarshalCBOR carry Params through config export and
+// import (hal/config/control), keyed by the JSON field names.
+func (p Params) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("Bus", p.Bus)
+		m.Uint("Baud", uint64(p.Baud))
+		m.Text("Domain", p.Domain)
+		m.Text("Name", p.Name)
+		m.Uint("StaleMs", uint64(p.StaleMs))
+	})
+}
+
+func (p *Params) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Bus":
+			return cbor.ReadText(d, &p.Bus)
+		case "Baud":
+			return cbor.ReadUint(d, &p.Baud)
+		case "Domain":
+			return cbor.ReadText(d, &p.Domain)
+		case "Name":
+			return cbor.ReadText(d, &p.Name)
+		case "StaleMs":
+			return cbor.ReadUint(d, &p.StaleMs)
+		}
+		return d.Skip()
+	})
+}
@@ -0,0 +1,160 @@
+package inadev
+
+import (
+	"context"
+
+	"devicecode-go/drivers/ina219"
+	"devicecode-go/drivers/ina3221"
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+func init() {
+	core.RegisterBuilder("ina219", builder{sensor: "ina219"})
+	core.RegisterParams[Params]("ina219")
+	core.RegisterBuilder("ina3221", builder{sensor: "ina3221"})
+	core.RegisterParams[Params]("ina3221")
+}
+
+// Rail is one monitored supply; it becomes <Domain>/sensor/<Name>.
+type Rail struct {
+	Name       string // REQUIRED, e.g. "modem_5v"
+	Channel    uint8  // ina3221: 1..3 (REQUIRED); ina219: ignored
+	Shunt_uOhm uint32 // REQUIRED
+	Warn_mA    int32  // optional alert thresholds; 0 disables
+	Crit_mA    int32
+}
+
+// Params describe one INA219 (one rail) or INA3221 (up to three rails).
+type Params struct {
+	Bus    string // e.g. "i2c0"
+	Addr   uint16 // default 0x40
+	Domain string // REQUIRED, e.g. "power"
+	Rails  []Rail
+
+	// INA3221 only: Alert says the chip's CRITICAL and/or WARNING output
+	// (open-drain, active-low; wire-OR both to use one GPIO) is wired to
+	// AlertPin, which then triggers a sample between polls.
+	Alert        bool
+	AlertPin     int
+	AlertPinName string // optional alias; overrides AlertPin
+}
+
+type builder struct{ sensor string }
+
+func (b builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, error) {
+	p, ok := in.Params.(Params)
+	if !ok || p.Bus == "" || p.Domain == "" {
+		return nil, errcode.InvalidParams
+	}
+	if p.Addr == 0 {
+		p.Addr = ina3221.Address // same default for both parts
+	}
+	switch b.sensor {
+	case "ina219":
+		if len(p.Rails) != 1 || p.Alert {
+			return nil, errcode.InvalidParams
+		}
+	case "ina3221":
+		if len(p.Rails) == 0 || len(p.Rails) > 3 {
+			return nil, errcode.InvalidParams
+		}
+	}
+	var used uint8
+	for i, r := range p.Rails {
+		if r.Name == "" || r.Shunt_uOhm == 0 || r.Warn_mA < 0 || r.Crit_mA < 0 {
+			return nil, errcode.InvalidParams
+		}
+		for _, o := range p.Rails[:i] {
+			if o.Name == r.Name {
+				return nil, errcode.InvalidParams
+			}
+		}
+		if b.sensor == "ina3221" {
+			if r.Channel < 1 || r.Channel > 3 || used&(1<<(r.Channel-1)) != 0 {
+				return nil, errcode.InvalidParams
+			}
+			used |= 1 << (r.Channel - 1)
+		}
+		if !limitsFit(b.sensor, r.Shunt_uOhm, types.RailLimits{Warn_mA: r.Warn_mA, Crit_mA: r.Crit_mA}) {
+			return nil, errcode.InvalidParams
+		}
+	}
+
+	pin := -1
+	if p.Alert {
+		n, err := core.ResolvePin(in.Res.Reg, p.AlertPin, p.AlertPinName)
+		if err != nil {
+			return nil, err
+		}
+		pin = n
+	}
+
+	i2c, err := in.Res.Reg.ClaimI2C(in.ID, core.ResourceID(p.Bus))
+	if err != nil {
+		return nil, err
+	}
+	if pin >= 0 {
+		ph, err := in.Res.Reg.ClaimPin(in.ID, pin, core.FuncGPIOIn)
+		if err != nil {
+			in.Res.Reg.ReleaseI2C(in.ID, core.ResourceID(p.Bus))
+			return nil, err
+		}
+		_ = ph.AsGPIO().ConfigureInput(core.PullUp)
+	}
+
+	d := &Device{
+		id:     in.ID,
+		p:      p,
+		sensor: b.sensor,
+		pub:    in.Res.Pub,
+		reg:    in.Res.Reg,
+		pin:    pin,
+		kick:   make(chan struct{}, 1),
+		lims:   make(chan limitReq, 4),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if b.sensor == "ina219" {
+		drv := ina219.New(i2c)
+		drv.Address = p.Addr
+		d.chip = &chip219{drv: drv}
+	} else {
+		drv := ina3221.New(i2c)
+		drv.Address = p.Addr
+		d.chip = &chip3221{drv: drv, latch: pin >= 0}
+	}
+	for _, r := range p.Rails {
+		if b.sensor == "ina219" {
+			r.Channel = 0
+		}
+		rl := &rail{
+			Rail: r,
+			a:    core.CapAddr{Domain: p.Domain, Kind: types.KindSensor, Name: r.Name},
+			lim:  types.RailLimits{Warn_mA: r.Warn_mA, Crit_mA: r.Crit_mA},
+		}
+		core.RegisterAction(&rl.verbs, "read", d.read)
+		core.RegisterVerb(&rl.verbs, "set_limits", func(l types.RailLimits) (core.EnqueueResult, error) {
+			return d.setLimits(rl, l)
+		})
+		d.rails = append(d.rails, rl)
+	}
+	return d, nil
+}
+
+// limitsFit reports whether l is valid for the shunt; on the INA3221 the
+// equivalent shunt voltage must fit the limit registers (±163.8 mV).
+func limitsFit(sensor string, shunt_uOhm uint32, l types.RailLimits) bool {
+	if l.Warn_mA < 0 || l.Crit_mA < 0 {
+		return false
+	}
+	if sensor != "ina3221" {
+		return true
+	}
+	return shuntUV(l.Warn_mA, shunt_uOhm) <= 163_800 && shuntUV(l.Crit_mA, shunt_uOhm) <= 163_800
+}
+
+func shuntUV(mA int32, shunt_uOhm uint32) int64 {
+	return int64(mA) * int64(shunt_uOhm) / 1000
+}
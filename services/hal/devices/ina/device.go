@@ -0,0 +1,288 @@
+package inadev
+
+import (
+	"context"
+	"time"
+
+	"devicecode-go/drivers/ina219"
+	"devicecode-go/drivers/ina3221"
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+const alertHoldoff = 250 * time.Millisecond
+
+// chip hides the part differences from the worker.
+type chip interface {
+	configure() error
+	read(ch int) (shunt_uV, bus_mV int32, err error)
+	setLimits(ch int, warn_uV, crit_uV int32) error
+	// flags returns latched alert flags, bit ch-1 per channel.
+	flags() (crit, warn uint8, err error)
+}
+
+type chip219 struct{ drv ina219.Device }
+
+func (c *chip219) configure() error {
+	return c.drv.Configure(ina219.Config{Bus32V: true, Gain: ina219.Gain320mV})
+}
+func (c *chip219) read(int) (int32, int32, error)       { return c.drv.Read() }
+func (c *chip219) setLimits(int, int32, int32) error    { return nil }
+func (c *chip219) flags() (crit, warn uint8, err error) { return 0, 0, nil }
+
+type chip3221 struct {
+	drv   ina3221.Device
+	latch bool
+}
+
+func (c *chip3221) configure() error                  { return c.drv.Configure(ina3221.Config{Latch: c.latch}) }
+func (c *chip3221) read(ch int) (int32, int32, error) { return c.drv.Read(ch) }
+func (c *chip3221) setLimits(ch int, warn_uV, crit_uV int32) error {
+	return c.drv.SetLimits(ch, warn_uV, crit_uV)
+}
+func (c *chip3221) flags() (crit, warn uint8, err error) {
+	if !c.latch {
+		return 0, 0, nil
+	}
+	f, err := c.drv.Flags()
+	return f.Critical, f.Warning, err
+}
+
+type rail struct {
+	Rail
+	a     core.CapAddr
+	lim   types.RailLimits // owned by the worker after Init
+	level uint8
+	verbs core.VerbTable
+}
+
+type limitReq struct {
+	r   *rail
+	lim types.RailLimits
+}
+
+type Device struct {
+	id     string
+	p      Params
+	sensor string
+	chip   chip
+	rails  []*rail
+
+	pub core.EventEmitter
+	reg core.ResourceRegistry
+	pin int // -1 when no ALERT pin
+	es  core.GPIOEdgeStream
+
+	// The worker owns the chip; controls only queue to it.
+	kick  chan struct{}
+	lims  chan limitReq
+	quit  chan struct{}
+	done  chan struct{}
+	ready bool // chip configured and limits programmed
+}
+
+func (d *Device) ID() string { return d.id }
+
+func (d *Device) Capabilities() []core.CapabilitySpec {
+	out := make([]core.CapabilitySpec, 0, len(d.rails))
+	for _, r := range d.rails {
+		out = append(out, core.CapabilitySpec{
+			Domain: r.a.Domain, Kind: types.KindSensor, Name: r.a.Name,
+			Info: types.Info{SchemaVersion: 1, Driver: d.sensor, Detail: types.RailInfo{
+				Sensor: d.sensor, Bus: d.p.Bus, Addr: d.p.Addr,
+				Channel: r.Channel, Shunt_uOhm: r.Shunt_uOhm,
+			}},
+		})
+	}
+	return out
+}
+
+func (d *Device) Init(ctx context.Context) error {
+	d.emitErr("initialising")
+	if d.pin >= 0 {
+		if es, err := d.reg.SubscribeGPIOEdges(d.id, d.pin, core.EdgeFalling, time.Millisecond, 4); err == nil {
+			d.es = es
+		} else {
+			// Polling still works; the alert level is then checked per sample only.
+			d.emitErr("alert_subscribe_failed")
+		}
+	}
+	go d.run()
+	d.kick <- struct{}{}
+	return nil
+}
+
+func (d *Device) Close() error {
+	close(d.quit)
+	<-d.done
+	if d.es != nil {
+		d.es.Close()
+		d.reg.UnsubscribeGPIOEdges(d.id, d.pin)
+	}
+	if d.pin >= 0 {
+		d.reg.ReleasePin(d.id, d.pin)
+	}
+	d.reg.ReleaseI2C(d.id, core.ResourceID(d.p.Bus))
+	return nil
+}
+
+func (d *Device) Control(a core.CapAddr, verb string, payload any) (core.EnqueueResult, error) {
+	if r := d.rail(a.Name); r != nil {
+		return r.verbs.Dispatch(verb, payload)
+	}
+	return core.EnqueueResult{OK: false, Error: errcode.Unsupported}, nil
+}
+
+func (d *Device) Verbs(a core.CapAddr) []core.VerbSpec {
+	if r := d.rail(a.Name); r != nil {
+		return r.verbs.Specs()
+	}
+	return nil
+}
+
+func (d *Device) rail(name string) *rail {
+	for _, r := range d.rails {
+		if r.a.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// ---- Controls (enqueue only) ----
+
+// read samples every rail of the chip; one already queued is enough.
+func (d *Device) read() (core.EnqueueResult, error) {
+	select {
+	case d.kick <- struct{}{}:
+	default:
+	}
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) setLimits(r *rail, l types.RailLimits) (core.EnqueueResult, error) {
+	if !limitsFit(d.sensor, r.Shunt_uOhm, l) {
+		return core.EnqueueResult{OK: false, Error: errcode.InvalidParams}, nil
+	}
+	select {
+	case d.lims <- limitReq{r: r, lim: l}:
+		return core.EnqueueResult{OK: true}, nil
+	default:
+		return core.EnqueueResult{OK: false, Error: errcode.Busy}, nil
+	}
+}
+
+// ---- Worker ----
+
+func (d *Device) run() {
+	defer close(d.done)
+	var evCh <-chan core.GPIOEdgeEvent
+	if d.es != nil {
+		evCh = d.es.Events()
+	}
+	// A condition that persists re-asserts a latched ALERT after every
+	// read, so edges are ignored for alertHoldoff after each one.
+	holdoff := time.NewTimer(0)
+	<-holdoff.C
+	for {
+		select {
+		case <-d.quit:
+			holdoff.Stop()
+			return
+		case <-d.kick:
+			d.sample()
+		case <-evCh:
+			// ALERT asserted: sample now rather than at the next poll.
+			d.sample()
+			evCh = nil
+			holdoff.Reset(alertHoldoff)
+		case <-holdoff.C:
+			evCh = d.es.Events()
+		case q := <-d.lims:
+			q.r.lim = q.lim
+			if d.ready {
+				if err := d.program(q.r); err != nil {
+					d.ready = false
+				}
+			}
+			d.sample()
+		}
+	}
+}
+
+// sample configures the chip if needed and publishes every rail. Any bus
+// error forces a reconfigure next time, in case the chip lost power.
+func (d *Device) sample() {
+	if !d.ready {
+		if err := d.chip.configure(); err != nil {
+			d.emitErr(string(errcode.MapDriverErr(err)))
+			return
+		}
+		for _, r := range d.rails {
+			if err := d.program(r); err != nil {
+				d.emitErr(string(errcode.MapDriverErr(err)))
+				return
+			}
+		}
+		d.ready = true
+	}
+	crit, warn, err := d.chip.flags()
+	if err != nil {
+		crit, warn = 0, 0
+	}
+	for _, r := range d.rails {
+		s, b, err := d.chip.read(int(r.Channel))
+		if err != nil {
+			d.pub.Emit(core.Event{Addr: r.a, Err: string(errcode.MapDriverErr(err))})
+			if err != ina219.ErrOverflow {
+				d.ready = false
+			}
+			continue
+		}
+		v := types.RailValue{Bus_mV: b, Shunt_uV: s}
+		v.I_mA = int32(int64(s) * 1000 / int64(r.Shunt_uOhm))
+		v.P_mW = int32(int64(b) * int64(v.I_mA) / 1000)
+		v.Alert = r.levelFor(v.I_mA)
+		if r.Channel > 0 {
+			bit := uint8(1) << (r.Channel - 1)
+			switch {
+			case crit&bit != 0:
+				v.Alert = 2
+			case warn&bit != 0 && v.Alert == 0:
+				v.Alert = 1
+			}
+		}
+		if v.Alert != r.level {
+			r.level = v.Alert
+			d.pub.Emit(core.Event{Addr: r.a, EventTag: "alert",
+				Payload: types.RailAlert{Level: levelName[v.Alert], I_mA: v.I_mA}})
+		}
+		d.pub.Emit(core.Event{Addr: r.a, Payload: v})
+	}
+}
+
+var levelName = [...]string{"ok", "warning", "critical"}
+
+// levelFor applies the thresholds in software, so the INA219 (no ALERT
+// output) and unwired INA3221s report alerts too, at poll granularity.
+func (r *rail) levelFor(mA int32) uint8 {
+	switch {
+	case r.lim.Crit_mA > 0 && mA >= r.lim.Crit_mA:
+		return 2
+	case r.lim.Warn_mA > 0 && mA >= r.lim.Warn_mA:
+		return 1
+	}
+	return 0
+}
+
+func (d *Device) program(r *rail) error {
+	return d.chip.setLimits(int(r.Channel),
+		int32(shuntUV(r.lim.Warn_mA, r.Shunt_uOhm)), int32(shuntUV(r.lim.Crit_mA, r.Shunt_uOhm)))
+}
+
+func (d *Device) emitErr(code string) {
+	for _, r := range d.rails {
+		d.pub.Emit(core.Event{Addr: r.a, Err: code})
+	}
+}
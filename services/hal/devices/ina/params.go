@@ -0,0 +1,72 @@
+package inadev
+
+import "devicecode-go/x/cbor"
+
+// MarshalCBOR and UnmarshalCBOR carry Params through config export and
+// import (hal/config/control), keyed by the JSON field names.
+func (p Params) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("Bus", p.Bus)
+		m.Uint("Addr", uint64(p.Addr))
+		m.Text("Domain", p.Domain)
+		m.Array("Rails", len(p.Rails), func(e *cbor.Encoder, i int) { p.Rails[i].MarshalCBOR(e) })
+		m.Bool("Alert", p.Alert)
+		m.Int("AlertPin", int64(p.AlertPin))
+		m.Text("AlertPinName", p.AlertPinName)
+	})
+}
+
+func (p *Params) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Bus":
+			return cbor.ReadText(d, &p.Bus)
+		case "Addr":
+			return cbor.ReadUint(d, &p.Addr)
+		case "Domain":
+			return cbor.ReadText(d, &p.Domain)
+		case "Rails":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v Rail
+				err := v.UnmarshalCBOR(d)
+				p.Rails = append(p.Rails, v)
+				return err
+			})
+		case "Alert":
+			return cbor.ReadBool(d, &p.Alert)
+		case "AlertPin":
+			return cbor.ReadInt(d, &p.AlertPin)
+		case "AlertPinName":
+			return cbor.ReadText(d, &p.AlertPinName)
+		}
+		return d.Skip()
+	})
+}
+
+func (p Rail) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("Name", p.Name)
+		m.Uint("Channel", uint64(p.Channel))
+		m.Uint("Shunt_uOhm", uint64(p.Shunt_uOhm))
+		m.Int("Warn_mA", int64(p.Warn_mA))
+		m.Int("Crit_mA", int64(p.Crit_mA))
+	})
+}
+
+func (p *Rail) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Name":
+			return cbor.ReadText(d, &p.Name)
+		case "Channel":
+			return cbor.ReadUint(d, &p.Channel)
+		case "Shunt_uOhm":
+			return cbor.ReadUint(d, &p.Shunt_uOhm)
+		case "Warn_mA":
+			return cbor.ReadInt(d, &p.Warn_mA)
+		case "Crit_mA":
+			return cbor.ReadInt(d, &p.Crit_mA)
+		}
+		return d.Skip()
+	})
+}
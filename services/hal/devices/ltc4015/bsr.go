@@ -0,0 +1,131 @@
+package ltc4015dev
+
+import (
+	"time"
+
+	"devicecode-go/drivers/ltc4015"
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+const (
+	bsrTrendLen = 8
+	bsrTimeout  = 30 * time.Second // RUN_BSR still set after this: give up
+)
+
+// bsrSched runs BSR measurements on Params.BSREvery_s while charging hard
+// enough for the result to mean something (≥ C/10 with CapacityMAh set).
+// The trend is saved through the registry's NVStore after each result and
+// restored at Init, so it survives reboots; without a store it starts
+// empty each boot. Worker goroutine only.
+type bsrSched struct {
+	startedAt time.Time // non-zero while a measurement is pending
+	lastAt    time.Time // last attempt, for the schedule
+	forced    bool      // run_bsr: start at the next eligible sample
+	last      uint32    // last completed result; 0 until one completes
+	trend     []uint32
+}
+
+// checkBSR advances the schedule on each sample.
+func (d *Device) checkBSR(s *ltc4015.Snapshot, now time.Time) {
+	b := &d.bsr
+	if !b.startedAt.IsZero() {
+		running, err := d.dev.BSRRunning()
+		switch {
+		case err != nil:
+			b.startedAt = time.Time{}
+			d.errBat("bsr_failed", err)
+		case running && now.Sub(b.startedAt) > bsrTimeout:
+			b.startedAt = time.Time{}
+			_ = d.dev.ClearConfigBits(ltc4015.RunBSR)
+			_ = d.res.Pub.Emit(core.Event{Addr: d.aBat, EventTag: "bsr_failed"})
+			_ = d.res.Pub.Emit(core.Event{Addr: d.aBat, Err: string(errcode.Timeout)})
+		case !running:
+			b.startedAt = time.Time{}
+			d.bsrComplete()
+		}
+		return
+	}
+
+	every := time.Duration(d.params.BSREvery_s) * time.Second
+	due := b.forced || (every > 0 && (b.lastAt.IsZero() || now.Sub(b.lastAt) >= every))
+	if !due || s.State&ltc4015.CCCVCharge == 0 {
+		return
+	}
+	if c := int32(d.params.CapacityMAh / 10); s.IBat_mA <= 0 || s.IBat_mA < c {
+		return
+	}
+	b.lastAt, b.forced = now, false
+	if err := d.dev.StartBSR(); err != nil {
+		d.errBat("bsr_failed", err)
+		return
+	}
+	b.startedAt = now
+	_ = d.res.Pub.Emit(core.Event{Addr: d.aBat, EventTag: "bsr_start"})
+}
+
+func (d *Device) bsrComplete() {
+	b := &d.bsr
+	v, err := d.dev.BSR_uOhmPerCell()
+	if err != nil {
+		d.errBat("bsr_failed", err)
+		return
+	}
+	ich, _ := d.dev.IChargeBSR_mA()
+	b.last = v
+	if len(b.trend) == bsrTrendLen {
+		copy(b.trend, b.trend[1:])
+		b.trend = b.trend[:bsrTrendLen-1]
+	}
+	b.trend = append(b.trend, v)
+	d.saveBSRTrend()
+	_ = d.res.Pub.Emit(core.Event{Addr: d.aBat, EventTag: "bsr_complete", Payload: types.BSRResult{
+		BSR_uOhmPerCell: v,
+		ICharge_mA:      ich,
+		Trend:           append([]uint32(nil), b.trend...),
+	}})
+}
+
+func (d *Device) errBat(tag string, err error) {
+	_ = d.res.Pub.Emit(core.Event{Addr: d.aBat, EventTag: tag})
+	_ = d.res.Pub.Emit(core.Event{Addr: d.aBat, Err: string(errcode.MapDriverErr(err))})
+}
+
+// NV record: up to bsrTrendLen little-endian uint32s, oldest first.
+func (d *Device) bsrKey() string { return "ltc4015/" + d.id + "/bsr" }
+
+// loadBSRTrend restores the trend (and last result) saved by an earlier
+// boot. Called from Init before the worker starts.
+func (d *Device) loadBSRTrend() {
+	st, ok := d.res.Reg.(core.NVStore)
+	if !ok {
+		return
+	}
+	rec, found := st.LoadNV(d.bsrKey())
+	if !found || len(rec)%4 != 0 || len(rec)/4 > bsrTrendLen {
+		return
+	}
+	b := &d.bsr
+	b.trend = b.trend[:0]
+	for i := 0; i < len(rec); i += 4 {
+		b.trend = append(b.trend, uint32(rec[i])|uint32(rec[i+1])<<8|uint32(rec[i+2])<<16|uint32(rec[i+3])<<24)
+	}
+	if n := len(b.trend); n > 0 {
+		b.last = b.trend[n-1]
+	}
+}
+
+func (d *Device) saveBSRTrend() {
+	st, ok := d.res.Reg.(core.NVStore)
+	if !ok {
+		return
+	}
+	rec := make([]byte, 0, 4*len(d.bsr.trend))
+	for _, v := range d.bsr.trend {
+		rec = append(rec, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+	}
+	if err := st.SaveNV(d.bsrKey(), rec); err != nil {
+		_ = d.res.Pub.Emit(core.Event{Addr: d.aBat, EventTag: "bsr_save_failed"})
+	}
+}
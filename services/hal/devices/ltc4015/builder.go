@@ -6,14 +6,16 @@ import (
 	"devicecode-go/errcode"
 	"devicecode-go/services/hal/internal/core"
 	"devicecode-go/types"
+	"devicecode-go/x/clock"
 )
 
 // Params must be fully specified. No defaults are applied here.
 type Params struct {
 	// Wiring
-	Bus         string // e.g. "i2c0" (required)
-	Addr        uint16 // required
-	SMBAlertPin int    // required (GPIO, active-low, open-drain)
+	Bus             string // e.g. "i2c0" (required)
+	Addr            uint16 // required
+	SMBAlertPin     int    // required (GPIO, active-low, open-drain)
+	SMBAlertPinName string // optional alias (e.g. "SMBALERT"); overrides SMBAlertPin
 
 	// Power-path characterisation
 	RSNSB_uOhm uint32 // required (battery shunt)
@@ -37,11 +39,40 @@ type Params struct {
 	DomainCharger string // required
 	Name          string // required
 
+	// Brownout pre-warning (optional): emit charger event
+	// vin_collapse_warning when VIN falls faster than this many mV/s.
+	// 0 disables. Effective resolution is the sampling interval.
+	VinCollapse_mVps uint32 `json:"vin_collapse_mVps,omitempty"`
+
+	// Charger state/status tags (cc_phase, cv_phase, iin_limited, …) are
+	// published when they become set, not on every alert pass. With
+	// EventRefresh_s > 0 the tags still set are re-published at that
+	// interval; 0 publishes transitions only.
+	EventRefresh_s uint32 `json:"event_refresh_s,omitempty"`
+
+	// Telemetry granularity (optional): "combined" (default) publishes the
+	// value structs; "split" publishes one retained scalar per field at
+	// …/value/<field> (e.g. …/charger/internal/value/vin_mV), only when it
+	// changes; "both" does both. Fields limits the split leaves to the
+	// named JSON fields; empty means all.
+	Telemetry string   `json:"telemetry,omitempty"`
+	Fields    []string `json:"fields,omitempty"`
+
+	// BSR schedule (optional): start a battery series resistance
+	// measurement every BSREvery_s seconds while charging (CC/CV) at no less
+	// than C/10 of CapacityMAh. 0 disables; the run_bsr verb asks for one
+	// at the next eligible sample either way.
+	BSREvery_s  uint32 `json:"bsr_every_s,omitempty"`
+	CapacityMAh uint32 `json:"capacity_mAh,omitempty"`
+
 	Boot []types.BootAction `json:"boot,omitempty"`
 }
 
 // Builder registration (strict; no legacy shims).
-func init() { core.RegisterBuilder("ltc4015", builder{}) }
+func init() {
+	core.RegisterBuilder("ltc4015", builder{})
+	core.RegisterParams[Params]("ltc4015")
+}
 
 type builder struct{}
 
@@ -64,6 +95,17 @@ func (builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, er
 		return nil, errcode.InvalidParams
 	}
 
+	leaves, ok := parseTelemetry(p.Telemetry, p.Fields)
+	if !ok {
+		return nil, errcode.InvalidParams
+	}
+
+	pin, err := core.ResolvePin(in.Res.Reg, p.SMBAlertPin, p.SMBAlertPinName)
+	if err != nil {
+		return nil, err
+	}
+	p.SMBAlertPin = pin
+
 	// Claim I2C and SMBALERT#.
 	i2c, err := in.Res.Reg.ClaimI2C(in.ID, core.ResourceID(p.Bus))
 	if err != nil {
@@ -89,11 +131,14 @@ func (builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, er
 		aTmp: core.CapAddr{Domain: domChg, Kind: types.KindTemperature, Name: name},
 
 		res:  in.Res,
+		clk:  clock.Or(in.Res.Clock),
 		i2c:  i2c,
 		pin:  p.SMBAlertPin,
 		gpio: gpio,
 
 		params: p,
+		leaves: leaves,
 	}
+	dev.registerVerbs()
 	return dev, nil
 }
@@ -4,6 +4,7 @@ package ltc4015dev
 import (
 	"context"
 	"math"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"devicecode-go/errcode"
 	"devicecode-go/services/hal/internal/core"
 	"devicecode-go/types"
+	"devicecode-go/x/clock"
 
 	"tinygo.org/x/drivers"
 )
@@ -22,6 +24,7 @@ type Device struct {
 	aTmp core.CapAddr // power/charger/<name>/temperature
 
 	res  core.Resources
+	clk  clock.Clock
 	i2c  drivers.I2C
 	pin  int
 	gpio core.GPIOHandle
@@ -36,7 +39,7 @@ type Device struct {
 	alive  atomic.Bool // guards enqueue/timers after stop
 
 	// Retry timer for SMBALERT# re-service
-	retryTimer *time.Timer
+	retryTimer clock.Timer
 
 	// Last configured windows (for state-aware opposite-edge re-arming)
 	lastVinLo, lastVinHi           int32
@@ -49,7 +52,18 @@ type Device struct {
 	desiredState  ltc4015.ChargerStateEnable
 	desiredStatus ltc4015.ChargeStatusEnable
 
+	verbs  core.VerbTable
 	params Params
+
+	// ctlMu serialises verb dispatch so configure can take the completion
+	// ControlDone staged for it.
+	ctlMu  sync.Mutex
+	staged *completion
+
+	leaves leafSet
+	slope  vinSlope
+	tags   chgTags
+	bsr    bsrSched
 }
 
 type opCode uint8
@@ -58,12 +72,29 @@ const (
 	opRead opCode = iota
 	opConfigure
 	opServiceAlert
+	opRunBSR
+	opSolarSweep
 	opStop
 )
 
 type request struct {
-	op  opCode
-	arg any
+	op   opCode
+	arg  any
+	done *completion // nil unless a caller awaits the outcome
+}
+
+// completion is a caller's interest in when a request finishes (see
+// ControlDone).
+type completion struct {
+	ctx  context.Context
+	done func(error)
+}
+
+// finish reports err to the caller, if any.
+func (c *completion) finish(err error) {
+	if c != nil {
+		c.done(err)
+	}
 }
 
 func (d *Device) ID() string { return d.id }
@@ -121,6 +152,7 @@ func (d *Device) Init(ctx context.Context) error {
 	_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, Err: "initialising"})
 	_ = d.res.Pub.Emit(core.Event{Addr: d.aTmp, Err: "initialising"})
 
+	d.loadBSRTrend()
 	go d.worker(d.ctx)
 
 	// Apply any boot actions declared in Params via the standard control path.
@@ -142,7 +174,7 @@ func (d *Device) Close() error {
 	if d.retryTimer != nil {
 		if !d.retryTimer.Stop() {
 			select {
-			case <-d.retryTimer.C:
+			case <-d.retryTimer.C():
 			default:
 			}
 		}
@@ -167,12 +199,12 @@ func (d *Device) Close() error {
 	}
 
 	// Wait for worker exit with a bounded timeout; ensure cancellation if slow.
-	t := time.NewTimer(300 * time.Millisecond)
+	t := d.clk.NewTimer(300 * time.Millisecond)
 	defer t.Stop()
 	select {
 	case <-d.done:
 		// ok
-	case <-t.C:
+	case <-t.C():
 		if d.cancel != nil {
 			d.cancel()
 		}
@@ -184,164 +216,149 @@ func (d *Device) Close() error {
 // ---- Controls ----
 
 func (d *Device) Control(_ core.CapAddr, verb string, payload any) (core.EnqueueResult, error) {
-	switch verb {
-	case "read":
+	d.ctlMu.Lock()
+	defer d.ctlMu.Unlock()
+	return d.verbs.Dispatch(verb, payload)
+}
+
+// ControlDone is Control reporting when the work is finished. The
+// configure path (configure and the verbs built on it) completes on the
+// worker once the registers are written; other verbs are done once queued.
+func (d *Device) ControlDone(ctx context.Context, _ core.CapAddr, verb string, payload any, done func(error)) (core.EnqueueResult, error) {
+	d.ctlMu.Lock()
+	defer d.ctlMu.Unlock()
+	d.staged = &completion{ctx: ctx, done: done}
+	defer func() { d.staged = nil }()
+	return d.verbs.Dispatch(verb, payload)
+}
+
+func (d *Device) Verbs(core.CapAddr) []core.VerbSpec { return d.verbs.Specs() }
+
+// registerVerbs builds the verb table; all convenience verbs map onto configure partials.
+func (d *Device) registerVerbs() {
+	vt := &d.verbs
+	core.RegisterAction(vt, "read", func() (core.EnqueueResult, error) {
 		d.enqueue(opRead, nil)
 		return core.EnqueueResult{OK: true}, nil
-
-	case "configure":
-		cfg, code := core.As[types.ChargerConfigure](payload)
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
-		d.enqueue(opConfigure, cfg)
+	})
+	core.RegisterVerb(vt, "configure", d.configure)
+	core.RegisterAction(vt, "run_bsr", func() (core.EnqueueResult, error) {
+		d.enqueue(opRunBSR, nil)
 		return core.EnqueueResult{OK: true}, nil
+	})
+	core.RegisterVerb(vt, "solar_sweep", d.solarSweep)
 
 	// Convenience verbs -> configure partials
-	case "enable":
+	core.RegisterAction(vt, "enable", func() (core.EnqueueResult, error) {
 		t := true
-		d.enqueue(opConfigure, types.ChargerConfigure{Enable: &t})
-		return core.EnqueueResult{OK: true}, nil
-	case "disable":
+		return d.configure(types.ChargerConfigure{Enable: &t})
+	})
+	core.RegisterAction(vt, "disable", func() (core.EnqueueResult, error) {
 		f := false
-		d.enqueue(opConfigure, types.ChargerConfigure{Enable: &f})
-		return core.EnqueueResult{OK: true}, nil
-
-	case "set_vin_window":
-		p, code := core.As[types.VinWindowSet](payload)
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
+		return d.configure(types.ChargerConfigure{Enable: &f})
+	})
+	core.RegisterVerb(vt, "set_vin_window", func(p types.VinWindowSet) (core.EnqueueResult, error) {
 		lo, hi := p.Lo_mV, p.Hi_mV
-		d.enqueue(opConfigure, types.ChargerConfigure{VinLo_mV: &lo, VinHi_mV: &hi})
-		return core.EnqueueResult{OK: true}, nil
-
-	case "set_vbat_window":
-		p, code := core.As[types.VbatWindowSet](payload)
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
+		return d.configure(types.ChargerConfigure{VinLo_mV: &lo, VinHi_mV: &hi})
+	})
+	core.RegisterVerb(vt, "set_vbat_window", func(p types.VbatWindowSet) (core.EnqueueResult, error) {
 		lo, hi := p.Lo_mVPerCell, p.Hi_mVPerCell
-		d.enqueue(opConfigure, types.ChargerConfigure{VbatLo_mVPerCell: &lo, VbatHi_mVPerCell: &hi})
-		return core.EnqueueResult{OK: true}, nil
-
-	case "set_vsys_window":
-		p, code := core.As[types.VsysWindowSet](payload)
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
+		return d.configure(types.ChargerConfigure{VbatLo_mVPerCell: &lo, VbatHi_mVPerCell: &hi})
+	})
+	core.RegisterVerb(vt, "set_vsys_window", func(p types.VsysWindowSet) (core.EnqueueResult, error) {
 		lo, hi := p.Lo_mV, p.Hi_mV
-		d.enqueue(opConfigure, types.ChargerConfigure{VsysLo_mV: &lo, VsysHi_mV: &hi})
-		return core.EnqueueResult{OK: true}, nil
-
-	case "set_iin_high":
-		p, code := core.As[types.CurrentMA](payload)
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
+		return d.configure(types.ChargerConfigure{VsysLo_mV: &lo, VsysHi_mV: &hi})
+	})
+	core.RegisterVerb(vt, "set_iin_high", func(p types.CurrentMA) (core.EnqueueResult, error) {
 		v := p.MilliA
-		d.enqueue(opConfigure, types.ChargerConfigure{IinHigh_mA: &v})
-		return core.EnqueueResult{OK: true}, nil
-
-	case "set_ibat_low":
-		p, code := core.As[types.CurrentMA](payload)
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
+		return d.configure(types.ChargerConfigure{IinHigh_mA: &v})
+	})
+	core.RegisterVerb(vt, "set_ibat_low", func(p types.CurrentMA) (core.EnqueueResult, error) {
 		v := p.MilliA
-		d.enqueue(opConfigure, types.ChargerConfigure{IbatLow_mA: &v})
-		return core.EnqueueResult{OK: true}, nil
-
-	case "set_die_temp_high":
-		p, code := core.As[types.TempMilliC](payload)
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
+		return d.configure(types.ChargerConfigure{IbatLow_mA: &v})
+	})
+	core.RegisterVerb(vt, "set_die_temp_high", func(p types.TempMilliC) (core.EnqueueResult, error) {
 		v := p.MilliC
-		d.enqueue(opConfigure, types.ChargerConfigure{DieTempHigh_mC: &v})
-		return core.EnqueueResult{OK: true}, nil
-
-	case "set_ntc_ratio_window":
-		p, code := core.As[types.NTCRatioWindowRaw](payload)
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
+		return d.configure(types.ChargerConfigure{DieTempHigh_mC: &v})
+	})
+	core.RegisterVerb(vt, "set_ntc_ratio_window", func(p types.NTCRatioWindowRaw) (core.EnqueueResult, error) {
 		hi, lo := p.Hi, p.Lo
-		d.enqueue(opConfigure, types.ChargerConfigure{NTCRatioHi: &hi, NTCRatioLo: &lo})
-		return core.EnqueueResult{OK: true}, nil
-
-	case "set_vin_uvcl":
-		p, code := core.As[types.VoltageMV](payload)
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
+		return d.configure(types.ChargerConfigure{NTCRatioHi: &hi, NTCRatioLo: &lo})
+	})
+	core.RegisterVerb(vt, "set_vin_uvcl", func(p types.VoltageMV) (core.EnqueueResult, error) {
 		v := p.MilliV
-		d.enqueue(opConfigure, types.ChargerConfigure{VinUVCL_mV: &v})
-		return core.EnqueueResult{OK: true}, nil
-
-	case "set_input_limit":
-		p, code := core.As[types.CurrentMA](payload)
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
+		return d.configure(types.ChargerConfigure{VinUVCL_mV: &v})
+	})
+	core.RegisterVerb(vt, "set_input_limit", func(p types.CurrentMA) (core.EnqueueResult, error) {
 		v := p.MilliA
-		d.enqueue(opConfigure, types.ChargerConfigure{IinLimit_mA: &v})
-		return core.EnqueueResult{OK: true}, nil
-
-	case "set_charge_target":
-		p, code := core.As[types.CurrentMA](payload)
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
+		return d.configure(types.ChargerConfigure{IinLimit_mA: &v})
+	})
+	core.RegisterVerb(vt, "set_charge_target", func(p types.CurrentMA) (core.EnqueueResult, error) {
 		v := p.MilliA
-		d.enqueue(opConfigure, types.ChargerConfigure{IChargeTarget_mA: &v})
-		return core.EnqueueResult{OK: true}, nil
-
-	case "set_bsr_high":
-		p, code := core.As[types.ResistanceMicroOhmPerCell](payload)
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
+		return d.configure(types.ChargerConfigure{IChargeTarget_mA: &v})
+	})
+	core.RegisterVerb(vt, "set_vcharge", func(p types.VoltageMV) (core.EnqueueResult, error) {
+		v := p.MilliV // per cell
+		return d.configure(types.ChargerConfigure{VCharge_mVPerCell: &v})
+	})
+	core.RegisterVerb(vt, "set_max_charge_time", func(p types.DurationS) (core.EnqueueResult, error) {
+		v := p.Seconds
+		return d.configure(types.ChargerConfigure{MaxChargeTime_s: &v})
+	})
+	core.RegisterVerb(vt, "set_max_cv_time", func(p types.DurationS) (core.EnqueueResult, error) {
+		v := p.Seconds
+		return d.configure(types.ChargerConfigure{MaxCVTime_s: &v})
+	})
+	core.RegisterVerb(vt, "set_max_absorb_time", func(p types.DurationS) (core.EnqueueResult, error) {
+		v := p.Seconds
+		return d.configure(types.ChargerConfigure{MaxAbsorbTime_s: &v})
+	})
+	core.RegisterVerb(vt, "set_c_over_x", func(p types.COverXSet) (core.EnqueueResult, error) {
+		return d.configure(types.ChargerConfigure{COverX_mA: &p.Threshold_mA, COverXTerm: &p.Term})
+	})
+	core.RegisterVerb(vt, "set_bsr_high", func(p types.ResistanceMicroOhmPerCell) (core.EnqueueResult, error) {
 		u := p.MicroOhmPerCell
-		d.enqueue(opConfigure, types.ChargerConfigure{BSRHigh_uOhmPerCell: &u})
-		return core.EnqueueResult{OK: true}, nil
-
-	case "alerts_mask":
-		m, code := core.As[types.ChargerAlertMask](payload)
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
-		d.enqueue(opConfigure, types.ChargerConfigure{AlertMask: &m})
-		return core.EnqueueResult{OK: true}, nil
-
-	case "config_bits_update":
-		p, code := core.As[types.ChargerConfigBitsUpdate](payload)
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
-		d.enqueue(opConfigure, types.ChargerConfigure{CfgSet: &p.Set, CfgClear: &p.Clear})
-		return core.EnqueueResult{OK: true}, nil
+		return d.configure(types.ChargerConfigure{BSRHigh_uOhmPerCell: &u})
+	})
+	core.RegisterVerb(vt, "alerts_mask", func(m types.ChargerAlertMask) (core.EnqueueResult, error) {
+		return d.configure(types.ChargerConfigure{AlertMask: &m})
+	})
+	core.RegisterVerb(vt, "config_bits_update", func(p types.ChargerConfigBitsUpdate) (core.EnqueueResult, error) {
+		return d.configure(types.ChargerConfigure{CfgSet: &p.Set, CfgClear: &p.Clear})
+	})
+}
 
-	default:
-		return core.EnqueueResult{OK: false, Error: errcode.Unsupported}, nil
+func (d *Device) configure(cfg types.ChargerConfigure) (core.EnqueueResult, error) {
+	c := d.staged
+	d.staged = nil
+	if !d.post(request{op: opConfigure, arg: cfg, done: c}) {
+		return core.EnqueueResult{OK: false, Error: errcode.Busy}, nil
 	}
+	return core.EnqueueResult{OK: true, Pending: c != nil}, nil
 }
 
 // ---- Worker ----
 
 // enqueue posts a request without blocking the caller.
 func (d *Device) enqueue(op opCode, arg any) {
+	d.post(request{op: op, arg: arg})
+}
+
+// post is enqueue reporting whether the request was queued.
+func (d *Device) post(req request) bool {
 	if !d.alive.Load() || d.ctx == nil {
-		return
+		return false
 	}
 	select {
 	case <-d.ctx.Done():
-		return
+		return false
 	default:
 	}
 	select {
-	case d.reqCh <- request{op: op, arg: arg}:
+	case d.reqCh <- req:
+		return true
 	default:
+		return false
 	}
 }
 
@@ -396,7 +413,7 @@ func (d *Device) worker(ctx context.Context) {
 		if d.retryTimer == nil {
 			return nil
 		}
-		return d.retryTimer.C
+		return d.retryTimer.C()
 	}
 	// Route edge events through the worker to avoid a separate goroutine.
 	var evCh <-chan core.GPIOEdgeEvent
@@ -425,16 +442,33 @@ func (d *Device) worker(ctx context.Context) {
 				d.sampleAndPublish()
 
 			case opConfigure:
+				if req.done != nil && req.done.ctx.Err() != nil {
+					// The caller's deadline passed while queued; HAL has
+					// already reported the timeout.
+					break
+				}
+				var err error
 				if c, _ := req.arg.(types.ChargerConfigure); (c != types.ChargerConfigure{}) {
-					d.applyConfigure(c)
+					err = d.applyConfigure(c)
 					// After any configure: re-arm (opposite edge) then publish.
 					d.rearm()
 					d.sampleAndPublish()
 				}
+				req.done.finish(err)
 
 			case opServiceAlert:
 				d.serviceAlertBatch()
 
+			case opRunBSR:
+				d.bsr.forced = true
+				d.sampleAndPublish()
+
+			case opSolarSweep:
+				if p, ok := req.arg.(types.SolarSweep); ok {
+					d.runSweep(p)
+					d.sampleAndPublish()
+				}
+
 			case opStop:
 				d.alive.Store(false)
 				d.cleanup()
@@ -460,7 +494,7 @@ func (d *Device) cleanup() {
 	if d.retryTimer != nil {
 		if !d.retryTimer.Stop() {
 			select {
-			case <-d.retryTimer.C:
+			case <-d.retryTimer.C():
 			default:
 			}
 		}
@@ -470,7 +504,15 @@ func (d *Device) cleanup() {
 
 // ---------- Configure application ----------
 
-func (d *Device) applyConfigure(c types.ChargerConfigure) {
+// applyConfigure writes c and returns the first step that failed, if any;
+// every step is still attempted.
+func (d *Device) applyConfigure(c types.ChargerConfigure) error {
+	var first error
+	note := func(tag string, err error) {
+		if err != nil && first == nil {
+			first = &errcode.E{C: errcode.Of(err), Op: "configure", Msg: tag}
+		}
+	}
 	// CONFIG bits (set/clear/update)
 	if c.CfgSet != nil || c.CfgClear != nil {
 		var set, clr ltc4015.ConfigBits
@@ -480,43 +522,71 @@ func (d *Device) applyConfigure(c types.ChargerConfigure) {
 		if c.CfgClear != nil {
 			clr = ltc4015.ConfigBits(*c.CfgClear)
 		}
-		_ = d.dev.UpdateConfig(set, clr)
+		note("update_config_failed", d.dev.UpdateConfig(set, clr))
 	}
 	if c.Enable != nil {
 		if *c.Enable {
-			_ = d.dev.ClearConfigBits(ltc4015.SuspendCharger)
+			note("enable_failed", d.dev.ClearConfigBits(ltc4015.SuspendCharger))
 		} else {
-			_ = d.dev.SetConfigBits(ltc4015.SuspendCharger)
+			note("disable_failed", d.dev.SetConfigBits(ltc4015.SuspendCharger))
 		}
 	}
 	if c.LeadAcidTempComp != nil {
 		if d.dev.Chem() == ltc4015.ChemLeadAcid {
 			if *c.LeadAcidTempComp {
-				_ = d.dev.SetChargerConfigBits(ltc4015.EnLeadAcidTempComp)
+				note("lead_acid_temp_comp_failed", d.dev.SetChargerConfigBits(ltc4015.EnLeadAcidTempComp))
 			} else {
-				_ = d.dev.ClearChargerConfigBits(ltc4015.EnLeadAcidTempComp)
+				note("lead_acid_temp_comp_failed", d.dev.ClearChargerConfigBits(ltc4015.EnLeadAcidTempComp))
 			}
 		}
 	}
 
 	// Targets and limits
 	if c.IinLimit_mA != nil {
-		_ = d.dev.SetIinLimit_mA(*c.IinLimit_mA)
+		note("set_input_limit_failed", d.dev.SetIinLimit_mA(*c.IinLimit_mA))
 	}
 	if c.IChargeTarget_mA != nil {
-		if err := d.dev.SetIChargeTarget_mA(*c.IChargeTarget_mA); err != nil {
-			if err == ltc4015.ErrTargetsReadOnly {
-				_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, EventTag: "targets_read_only"})
-				_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, Err: "targets_read_only"})
-			} else {
-				d.errChg("set_charge_target_failed", err)
-			}
+		d.targetFailed(note, "set_charge_target_failed", d.dev.SetIChargeTarget_mA(*c.IChargeTarget_mA))
+	}
+	if c.VCharge_mVPerCell != nil {
+		if la, ok := d.dev.LeadAcid(); ok {
+			d.targetFailed(note, "set_vcharge_failed", la.SetVChargeSetting_mVPerCell(*c.VCharge_mVPerCell, false))
+		} else {
+			d.chemUnsupported(note, "set_vcharge_failed")
+		}
+	}
+	// Charge termination
+	if c.MaxChargeTime_s != nil {
+		d.targetFailed(note, "set_max_charge_time_failed", d.dev.SetMaxChargeTime_s(*c.MaxChargeTime_s))
+	}
+	if c.MaxCVTime_s != nil {
+		if li, ok := d.dev.Lithium(); ok {
+			d.targetFailed(note, "set_max_cv_time_failed", li.SetMaxCVTime_s(*c.MaxCVTime_s))
+		} else {
+			d.chemUnsupported(note, "set_max_cv_time_failed")
+		}
+	}
+	if c.MaxAbsorbTime_s != nil {
+		if la, ok := d.dev.LeadAcid(); ok {
+			d.targetFailed(note, "set_max_absorb_time_failed", la.SetMaxAbsorbTime_s(*c.MaxAbsorbTime_s))
+		} else if lp, ok := d.dev.LiFePO4(); ok {
+			d.targetFailed(note, "set_max_absorb_time_failed", lp.SetMaxAbsorbTime_s(*c.MaxAbsorbTime_s))
+		} else {
+			d.chemUnsupported(note, "set_max_absorb_time_failed")
 		}
 	}
+	if c.COverX_mA != nil {
+		d.targetFailed(note, "set_c_over_x_failed", d.dev.SetCOverXThreshold_mA(*c.COverX_mA))
+	}
+	if c.COverXTerm != nil {
+		note("c_over_x_term_failed", d.dev.EnableCOverXTermination(*c.COverXTerm))
+	}
+
 	if c.IinHigh_mA != nil {
 		if err := d.dev.SetIINHigh_mA(*c.IinHigh_mA); err == nil {
 			d.desiredLimit |= ltc4015.IINHi
 		} else {
+			note("set_iin_high_failed", err)
 			d.errChg("set_iin_high_failed", err)
 		}
 	}
@@ -524,6 +594,7 @@ func (d *Device) applyConfigure(c types.ChargerConfigure) {
 		if err := d.dev.SetIBATLow_mA(*c.IbatLow_mA); err == nil {
 			d.desiredLimit |= ltc4015.IBATLo
 		} else {
+			note("set_ibat_low_failed", err)
 			d.errChg("set_ibat_low_failed", err)
 		}
 	}
@@ -531,6 +602,7 @@ func (d *Device) applyConfigure(c types.ChargerConfigure) {
 		if err := d.dev.SetDieTempHigh_mC(*c.DieTempHigh_mC); err == nil {
 			d.desiredLimit |= ltc4015.DieTempHi
 		} else {
+			note("set_dietemp_high_failed", err)
 			d.errChg("set_dietemp_high_failed", err)
 		}
 	}
@@ -538,6 +610,7 @@ func (d *Device) applyConfigure(c types.ChargerConfigure) {
 		if err := d.dev.SetBSRHigh_uOhmPerCell(*c.BSRHigh_uOhmPerCell); err == nil {
 			d.desiredLimit |= ltc4015.BSRHi
 		} else {
+			note("set_bsr_high_failed", err)
 			d.errChg("set_bsr_high_failed", err)
 		}
 	}
@@ -548,6 +621,7 @@ func (d *Device) applyConfigure(c types.ChargerConfigure) {
 		if err := d.dev.SetVINWindowAndClear(lo, hi); err == nil {
 			d.lastVinLo, d.lastVinHi = lo, hi
 		} else {
+			note("set_vin_window_failed", err)
 			d.errChg("set_vin_window_failed", err)
 		}
 	}
@@ -556,6 +630,7 @@ func (d *Device) applyConfigure(c types.ChargerConfigure) {
 		if err := d.dev.SetVSYSWindowAndClear(lo, hi); err == nil {
 			d.lastVsysLo, d.lastVsysHi = lo, hi
 		} else {
+			note("set_vsys_window_failed", err)
 			d.errChg("set_vsys_window_failed", err)
 		}
 	}
@@ -564,6 +639,7 @@ func (d *Device) applyConfigure(c types.ChargerConfigure) {
 		if err := d.dev.SetVBATWindowPerCellAndClear(lo, hi); err == nil {
 			d.lastVbatLoCell, d.lastVbatHiCell = lo, hi
 		} else {
+			note("set_vbat_window_failed", err)
 			d.errChg("set_vbat_window_failed", err)
 		}
 	}
@@ -572,11 +648,12 @@ func (d *Device) applyConfigure(c types.ChargerConfigure) {
 		if err := d.dev.SetNTCRatioWindowAndClear(hi, lo); err == nil {
 			d.lastNTCHi, d.lastNTCLo = hi, lo
 		} else {
+			note("set_ntc_ratio_window_failed", err)
 			d.errChg("set_ntc_ratio_window_failed", err)
 		}
 	}
 	if c.VinUVCL_mV != nil {
-		_ = d.dev.SetVinUvcl_mV(*c.VinUVCL_mV)
+		note("set_vin_uvcl_failed", d.dev.SetVinUvcl_mV(*c.VinUVCL_mV))
 	}
 
 	// User masks set desired sources (auto re-arming still applies).
@@ -591,6 +668,28 @@ func (d *Device) applyConfigure(c types.ChargerConfigure) {
 			d.desiredStatus = ltc4015.ChargeStatusEnable(*m.ChgStatus)
 		}
 	}
+	return first
+}
+
+// targetFailed reports err from writing a programmable target or timer.
+func (d *Device) targetFailed(note func(string, error), tag string, err error) {
+	switch {
+	case err == nil:
+	case err == ltc4015.ErrTargetsReadOnly:
+		note("targets_read_only", errcode.Unsupported)
+		_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, EventTag: "targets_read_only"})
+		_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, Err: "targets_read_only"})
+	default:
+		note(tag, err)
+		d.errChg(tag, err)
+	}
+}
+
+// chemUnsupported reports a setting the fitted chemistry does not have.
+func (d *Device) chemUnsupported(note func(string, error), tag string) {
+	note(tag, errcode.Unsupported)
+	_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, EventTag: tag})
+	_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, Err: string(errcode.Unsupported)})
 }
 
 // tiny generic pointer-deref helper
@@ -611,7 +710,7 @@ func (d *Device) serviceAlertBatch() {
 	if d.retryTimer != nil {
 		if !d.retryTimer.Stop() {
 			select {
-			case <-d.retryTimer.C:
+			case <-d.retryTimer.C():
 			default:
 			}
 		}
@@ -648,16 +747,7 @@ func (d *Device) serviceAlertBatch() {
 		if ev.Limit.Has(ltc4015.BSRHi) {
 			_ = d.res.Pub.Emit(core.Event{Addr: d.aBat, EventTag: "bsr_high"})
 		}
-		for _, t := range chgStateTags {
-			if ev.ChgState.Has(t.bit) {
-				_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, EventTag: t.tag})
-			}
-		}
-		for _, t := range chgStatusTags {
-			if ev.ChgStatus.Has(t.bit) {
-				_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, EventTag: t.tag})
-			}
-		}
+		d.emitChgTransitions(ev)
 
 		// State-aware opposite-edge re-arming for ALL groups, then publish snapshot.
 		d.rearm()
@@ -669,14 +759,14 @@ func (d *Device) serviceAlertBatch() {
 	// Still asserted? Arm a short retry. Otherwise, ensure the timer is disarmed.
 	if d.alive.Load() && d.dev.AlertActive(func() bool { return d.gpio.Get() }) {
 		if d.retryTimer == nil {
-			d.retryTimer = time.NewTimer(2 * time.Millisecond)
+			d.retryTimer = d.clk.NewTimer(2 * time.Millisecond)
 		} else {
 			d.retryTimer.Reset(2 * time.Millisecond)
 		}
 	} else if d.retryTimer != nil {
 		if !d.retryTimer.Stop() {
 			select {
-			case <-d.retryTimer.C:
+			case <-d.retryTimer.C():
 			default:
 			}
 		}
@@ -743,27 +833,33 @@ func (d *Device) sampleAndPublish() {
 
 	// Use driver snapshot
 	s := d.dev.Snapshot()
+	d.checkBSR(&s, d.clk.Now())
+	if d.bsr.last != 0 {
+		s.BSR_uOhmPerCell = d.bsr.last // last completed result, not mid-measurement
+	}
 
-	_ = d.res.Pub.Emit(core.Event{Addr: d.aBat, Payload: types.BatteryValue{
+	d.publish(d.aBat, types.BatteryValue{
 		PackMilliV:      s.Pack_mV,
 		PerCellMilliV:   s.PerCell_mV,
 		IBatMilliA:      s.IBat_mA,
 		TempMilliC:      s.Die_mC,
 		BSR_uOhmPerCell: s.BSR_uOhmPerCell,
-	}})
-	_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, Payload: types.ChargerValue{
+	}, batteryLeaves)
+	d.publish(d.aChg, types.ChargerValue{
 		VIN_mV:  s.Vin_mV,
 		VSYS_mV: s.Vsys_mV,
 		IIn_mA:  s.IIn_mA,
 		State:   uint16(s.State),
 		Status:  uint16(s.Status),
 		Sys:     uint16(s.System),
-	}})
+	}, chargerLeaves)
+	d.checkVinCollapse(s.Vin_mV, d.clk.Now())
+	d.refreshChgTags(s.State, s.Status, d.clk.Now())
 
 	// Temperature via NTC ratio (Beta equation)
 	if ratio := s.NTCRatio; ratio != 0 {
 		if deciC, ok := ntcRatioToDeciC(ratio, d.params.NTCBiasOhm, d.params.R25Ohm, d.params.BetaK); ok {
-			_ = d.res.Pub.Emit(core.Event{Addr: d.aTmp, Payload: types.TemperatureValue{DeciC: deciC}})
+			d.publish(d.aTmp, types.TemperatureValue{DeciC: deciC}, tempLeaves)
 		} else {
 			_ = d.res.Pub.Emit(core.Event{Addr: d.aTmp, Err: "ntc_ratio_invalid"})
 		}
@@ -0,0 +1,66 @@
+package ltc4015dev
+
+import (
+	"time"
+
+	"devicecode-go/drivers/ltc4015"
+	"devicecode-go/services/hal/internal/core"
+)
+
+// chgTags de-duplicates the level-type charger tags (chgStateTags and
+// chgStatusTags). The chip re-latches these on every alert service pass
+// during steady limiting, so a tag is only published when its bit goes
+// from clear to set. Worker goroutine only.
+type chgTags struct {
+	state  ltc4015.ChargerStateBits // bits last published as set
+	status ltc4015.ChargeStatusBits
+	next   time.Time // next refresh of the set tags
+}
+
+// emitChgTransitions publishes the tags whose bit is now set but was not
+// last time. The live registers decide; the pass's latched bits stand in
+// if a read fails.
+func (d *Device) emitChgTransitions(ev ltc4015.AlertEvent) {
+	state, err := d.dev.ChargerState()
+	if err != nil {
+		state = ev.ChgState
+	}
+	status, err := d.dev.ChargeStatus()
+	if err != nil {
+		status = ev.ChgStatus
+	}
+	t := &d.tags
+	d.emitChgTags(state&^t.state, status&^t.status)
+	t.state, t.status = state, status
+}
+
+// refreshChgTags forgets bits that have cleared since the last alert (so
+// they publish again when next set) and, with Params.EventRefresh_s, re-
+// publishes the tags still set at that interval.
+func (d *Device) refreshChgTags(state ltc4015.ChargerStateBits, status ltc4015.ChargeStatusBits, now time.Time) {
+	t := &d.tags
+	t.state &= state
+	t.status &= status
+	every := time.Duration(d.params.EventRefresh_s) * time.Second
+	switch {
+	case every == 0:
+	case t.next.IsZero():
+		t.next = now.Add(every)
+	case !now.Before(t.next):
+		t.next = now.Add(every)
+		d.emitChgTags(t.state, t.status)
+	}
+}
+
+func (d *Device) emitChgTags(state ltc4015.ChargerStateBits, status ltc4015.ChargeStatusBits) {
+	for _, t := range chgStateTags {
+		if state.Has(t.bit) {
+			_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, EventTag: t.tag})
+		}
+	}
+	for _, t := range chgStatusTags {
+		if status.Has(t.bit) {
+			_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, EventTag: t.tag})
+		}
+	}
+}
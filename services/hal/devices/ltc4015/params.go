@@ -0,0 +1,92 @@
+package ltc4015dev
+
+import (
+	"devicecode-go/types"
+	"devicecode-go/x/cbor"
+)
+
+// MarshalCBOR and UnmarshalCBOR carry Params through config export and
+// import (hal/config/control), keyed by the JSON field names.
+func (p Params) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("Bus", p.Bus)
+		m.Uint("Addr", uint64(p.Addr))
+		m.Int("SMBAlertPin", int64(p.SMBAlertPin))
+		m.Text("SMBAlertPinName", p.SMBAlertPinName)
+		m.Uint("RSNSB_uOhm", uint64(p.RSNSB_uOhm))
+		m.Uint("RSNSI_uOhm", uint64(p.RSNSI_uOhm))
+		m.Uint("Cells", uint64(p.Cells))
+		m.Text("Chem", p.Chem)
+		m.Uint("NTCBiasOhm", uint64(p.NTCBiasOhm))
+		m.Uint("R25Ohm", uint64(p.R25Ohm))
+		m.Uint("BetaK", uint64(p.BetaK))
+		m.Uint("QCountPrescale", uint64(p.QCountPrescale))
+		m.Text("DomainBattery", p.DomainBattery)
+		m.Text("DomainCharger", p.DomainCharger)
+		m.Text("Name", p.Name)
+		m.Uint("vin_collapse_mVps", uint64(p.VinCollapse_mVps))
+		m.Uint("event_refresh_s", uint64(p.EventRefresh_s))
+		m.Text("telemetry", p.Telemetry)
+		m.Texts("fields", p.Fields)
+		m.Uint("bsr_every_s", uint64(p.BSREvery_s))
+		m.Uint("capacity_mAh", uint64(p.CapacityMAh))
+		m.Array("boot", len(p.Boot), func(e *cbor.Encoder, i int) { p.Boot[i].MarshalCBOR(e) })
+	})
+}
+
+func (p *Params) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Bus":
+			return cbor.ReadText(d, &p.Bus)
+		case "Addr":
+			return cbor.ReadUint(d, &p.Addr)
+		case "SMBAlertPin":
+			return cbor.ReadInt(d, &p.SMBAlertPin)
+		case "SMBAlertPinName":
+			return cbor.ReadText(d, &p.SMBAlertPinName)
+		case "RSNSB_uOhm":
+			return cbor.ReadUint(d, &p.RSNSB_uOhm)
+		case "RSNSI_uOhm":
+			return cbor.ReadUint(d, &p.RSNSI_uOhm)
+		case "Cells":
+			return cbor.ReadUint(d, &p.Cells)
+		case "Chem":
+			return cbor.ReadText(d, &p.Chem)
+		case "NTCBiasOhm":
+			return cbor.ReadUint(d, &p.NTCBiasOhm)
+		case "R25Ohm":
+			return cbor.ReadUint(d, &p.R25Ohm)
+		case "BetaK":
+			return cbor.ReadUint(d, &p.BetaK)
+		case "QCountPrescale":
+			return cbor.ReadUint(d, &p.QCountPrescale)
+		case "DomainBattery":
+			return cbor.ReadText(d, &p.DomainBattery)
+		case "DomainCharger":
+			return cbor.ReadText(d, &p.DomainCharger)
+		case "Name":
+			return cbor.ReadText(d, &p.Name)
+		case "vin_collapse_mVps":
+			return cbor.ReadUint(d, &p.VinCollapse_mVps)
+		case "event_refresh_s":
+			return cbor.ReadUint(d, &p.EventRefresh_s)
+		case "telemetry":
+			return cbor.ReadText(d, &p.Telemetry)
+		case "fields":
+			return cbor.ReadTexts(d, &p.Fields)
+		case "bsr_every_s":
+			return cbor.ReadUint(d, &p.BSREvery_s)
+		case "capacity_mAh":
+			return cbor.ReadUint(d, &p.CapacityMAh)
+		case "boot":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v types.BootAction
+				err := v.UnmarshalCBOR(d)
+				p.Boot = append(p.Boot, v)
+				return err
+			})
+		}
+		return d.Skip()
+	})
+}
@@ -0,0 +1,56 @@
+package ltc4015dev
+
+import (
+	"time"
+
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+// Samples closer together than this (e.g. an alert-driven read straight
+// after a poll) are not used for the slope: over a short interval ADC
+// noise dominates.
+const slopeMinDt = 100 * time.Millisecond
+
+// vinSlope tracks dVIN/dt across samples for the brownout pre-warning.
+// Worker goroutine only.
+type vinSlope struct {
+	prev    int32
+	prevAt  time.Time
+	latched bool
+}
+
+// checkVinCollapse raises vin_collapse_warning on the charger when VIN
+// falls faster than Params.VinCollapse_mVps while still above the VIN low
+// window (if one is set), so consumers can start an orderly shutdown before
+// vin_lo. The warning latches until the fall slows to under half the
+// threshold or VIN rises.
+func (d *Device) checkVinCollapse(vin int32, now time.Time) {
+	thr := int64(d.params.VinCollapse_mVps)
+	if thr == 0 {
+		return
+	}
+	s := &d.slope
+	if s.prevAt.IsZero() {
+		s.prev, s.prevAt = vin, now
+		return
+	}
+	dt := now.Sub(s.prevAt)
+	if dt < slopeMinDt {
+		return
+	}
+	slope := int64(vin-s.prev) * int64(time.Second) / int64(dt) // mV/s
+	s.prev, s.prevAt = vin, now
+
+	switch {
+	case slope >= -thr/2:
+		s.latched = false
+	case slope <= -thr && !s.latched:
+		if d.lastVinLo != 0 && vin <= d.lastVinLo {
+			return // already past the window; vin_lo covers it
+		}
+		s.latched = true
+		_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, EventTag: "vin_collapse_warning",
+			Payload: types.VinCollapseWarning{VIN_mV: vin, Slope_mVps: int32(slope)}})
+	}
+}
@@ -0,0 +1,143 @@
+package ltc4015dev
+
+import (
+	"time"
+
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+const (
+	sweepMaxPoints = 64
+	sweepSettleDef = 250 * time.Millisecond
+	sweepSettleMax = 5 * time.Second
+)
+
+// solarSweep validates a solar_sweep request and queues it for the worker.
+func (d *Device) solarSweep(p types.SolarSweep) (core.EnqueueResult, error) {
+	span := p.To_mV - p.From_mV
+	if span < 0 {
+		span = -span
+	}
+	switch {
+	case p.From_mV <= 0, p.To_mV <= 0, p.Step_mV <= 0, p.IinLimit_mA < 0,
+		span/p.Step_mV+1 > sweepMaxPoints,
+		time.Duration(p.Settle_ms)*time.Millisecond > sweepSettleMax:
+		return core.EnqueueResult{OK: false, Error: errcode.InvalidParams}, nil
+	}
+	d.enqueue(opSolarSweep, p)
+	return core.EnqueueResult{OK: true}, nil
+}
+
+// runSweep steps VIN_UVCL across the requested range, recording where the
+// charger settles at each step, and emits the IV curve on the charger.
+// With VIN_UVCL above the panel's maximum power point the charger backs
+// off its input current to hold VIN up, so each step reads one point of
+// the panel's curve (as long as the battery can take the power). The
+// worker does nothing else while a sweep runs: alerts wait for it.
+func (d *Device) runSweep(p types.SolarSweep) {
+	uvcl, err := d.dev.VinUvclSettingCode()
+	if err != nil {
+		d.errChg("solar_sweep_failed", err)
+		return
+	}
+	iin, err := d.dev.IinLimitSettingCode()
+	if err != nil {
+		d.errChg("solar_sweep_failed", err)
+		return
+	}
+	defer func() {
+		_ = d.dev.SetVinUvclSettingCode(uvcl)
+		_ = d.dev.SetIinLimitSettingCode(iin)
+	}()
+	if p.IinLimit_mA > 0 {
+		if err := d.dev.SetIinLimit_mA(p.IinLimit_mA); err != nil {
+			d.errChg("solar_sweep_failed", err)
+			return
+		}
+	}
+
+	settle := time.Duration(p.Settle_ms) * time.Millisecond
+	if settle == 0 {
+		settle = sweepSettleDef
+	}
+	step := p.Step_mV
+	if p.To_mV < p.From_mV {
+		step = -step
+	}
+	_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, EventTag: "solar_sweep_start"})
+
+	pts := make([]types.IVPoint, 0, sweepMaxPoints)
+	t := d.clk.NewTimer(settle)
+	defer t.Stop()
+	for v := p.From_mV; (step > 0 && v <= p.To_mV) || (step < 0 && v >= p.To_mV); v += step {
+		if err := d.dev.SetVinUvcl_mV(v); err != nil {
+			d.errChg("solar_sweep_failed", err)
+			return
+		}
+		if len(pts) > 0 {
+			t.Reset(settle)
+		}
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-t.C():
+		}
+		vin, err := d.dev.Vin_mV()
+		if err != nil {
+			d.errChg("solar_sweep_failed", err)
+			return
+		}
+		i, err := d.dev.Iin_mA()
+		if err != nil {
+			d.errChg("solar_sweep_failed", err)
+			return
+		}
+		pts = append(pts, types.IVPoint{UVCL_mV: v, VIN_mV: vin, IIN_mA: i})
+	}
+
+	r := summariseIV(pts)
+	r.TS = d.clk.Now().UnixNano()
+	_ = d.res.Pub.Emit(core.Event{Addr: d.aChg, EventTag: "solar_sweep", Payload: r})
+}
+
+// summariseIV finds the maximum power point of a sweep. Pmax is refined
+// by the vertex of the parabola through P(VIN) at the best point and its
+// two neighbours, when that parabola peaks between them.
+func summariseIV(pts []types.IVPoint) types.SolarSweepResult {
+	r := types.SolarSweepResult{Points: pts}
+	power := func(p types.IVPoint) float64 {
+		if p.IIN_mA <= 0 || p.VIN_mV <= 0 {
+			return 0
+		}
+		return float64(p.VIN_mV) * float64(p.IIN_mA) / 1000
+	}
+	best := -1
+	for i := range pts {
+		if best < 0 || power(pts[i]) > power(pts[best]) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return r
+	}
+	r.Vmp_mV, r.Imp_mA = pts[best].VIN_mV, pts[best].IIN_mA
+	pmax := power(pts[best])
+	if best > 0 && best < len(pts)-1 {
+		// P = a·x² + b·x + p1 with x = VIN - VIN[best].
+		x0 := float64(pts[best-1].VIN_mV - pts[best].VIN_mV)
+		x2 := float64(pts[best+1].VIN_mV - pts[best].VIN_mV)
+		d0 := power(pts[best-1]) - pmax
+		d2 := power(pts[best+1]) - pmax
+		if det := x0 * x2 * (x0 - x2); det != 0 {
+			a := (d0*x2 - d2*x0) / det
+			b := (x0*x0*d2 - x2*x2*d0) / det
+			if xv := -b / (2 * a); a < 0 && xv > min(x0, x2) && xv < max(x0, x2) {
+				pmax -= b * b / (4 * a)
+			}
+		}
+	}
+	r.Pmax_mW = int32(pmax + 0.5)
+	return r
+}
@@ -0,0 +1,31 @@
+package ltc4015dev
+
+import (
+	"testing"
+
+	"devicecode-go/types"
+)
+
+func TestSummariseIV_PeakBetweenPoints(t *testing.T) {
+	// UVCL stepped down from near open circuit: 1.9, 18, 18.7 and 18.4 W.
+	pts := []types.IVPoint{
+		{UVCL_mV: 19000, VIN_mV: 19000, IIN_mA: 100},
+		{UVCL_mV: 18000, VIN_mV: 18000, IIN_mA: 1000},
+		{UVCL_mV: 17000, VIN_mV: 17000, IIN_mA: 1100},
+		{UVCL_mV: 16000, VIN_mV: 16000, IIN_mA: 1150},
+	}
+	r := summariseIV(pts)
+	// The parabola through the last three peaks 200 mV above 17 V at 18.72 W.
+	if r.Vmp_mV != 17000 || r.Imp_mA != 1100 || r.Pmax_mW != 18720 || len(r.Points) != 4 {
+		t.Fatalf("%+v", r)
+	}
+
+	// At the end of the sweep there is nothing to refine against.
+	r = summariseIV(pts[:3])
+	if r.Vmp_mV != 17000 || r.Pmax_mW != 18700 {
+		t.Fatalf("%+v", r)
+	}
+	if r = summariseIV(nil); r.Pmax_mW != 0 || r.Vmp_mV != 0 {
+		t.Fatalf("%+v", r)
+	}
+}
@@ -0,0 +1,91 @@
+package ltc4015dev
+
+import (
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+// Telemetry publication modes (Params.Telemetry).
+const (
+	TelemetryCombined = "combined" // one struct at …/value (default)
+	TelemetrySplit    = "split"    // one scalar per field at …/value/<field>
+	TelemetryBoth     = "both"
+)
+
+// Leaf names per capability; they match the value structs' JSON tags.
+var (
+	batteryLeaves = []string{"pack_mV", "per_cell_mV", "ibat_mA", "temp_mC", "bsr_uohm_per_cell"}
+	chargerLeaves = []string{"vin_mV", "vsys_mV", "iin_mA", "state", "status", "sys"}
+	tempLeaves    = []string{"deci_c"}
+)
+
+// leafSet is the parsed form of Params.Fields: which leaves to publish and
+// the last value published for each, so unchanged leaves are not repeated.
+type leafSet struct {
+	combined bool
+	split    bool
+	want     map[string]bool // nil => all leaves
+	last     map[string]int64
+}
+
+func parseTelemetry(mode string, fields []string) (leafSet, bool) {
+	var ls leafSet
+	switch mode {
+	case "", TelemetryCombined:
+		ls.combined = true
+	case TelemetrySplit:
+		ls.split = true
+	case TelemetryBoth:
+		ls.combined, ls.split = true, true
+	default:
+		return ls, false
+	}
+	if len(fields) > 0 {
+		ls.want = make(map[string]bool, len(fields))
+		for _, f := range fields {
+			if !knownLeaf(f) {
+				return ls, false
+			}
+			ls.want[f] = true
+		}
+	}
+	if ls.split {
+		ls.last = make(map[string]int64)
+	}
+	return ls, true
+}
+
+func knownLeaf(name string) bool {
+	for _, set := range [][]string{batteryLeaves, chargerLeaves, tempLeaves} {
+		for _, n := range set {
+			if n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// publish emits v for a per the configured mode. Runs on the worker
+// goroutine only, so the last-value map needs no lock.
+func (d *Device) publish(a core.CapAddr, v types.Fielder, leaves []string) {
+	ls := &d.leaves
+	if ls.combined {
+		_ = d.res.Pub.Emit(core.Event{Addr: a, Payload: v})
+	}
+	if !ls.split {
+		return
+	}
+	for _, name := range leaves {
+		if ls.want != nil && !ls.want[name] {
+			continue
+		}
+		x, _ := v.Field(name)
+		// Leaf names are unique across the three capabilities.
+		if prev, ok := ls.last[name]; ok && prev == x {
+			continue
+		}
+		ls.last[name] = x
+		_ = d.res.Pub.Emit(core.Event{Addr: a, Leaf: name, Payload: x})
+	}
+}
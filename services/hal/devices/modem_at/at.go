@@ -0,0 +1,223 @@
+package modem_at
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+// maxLine bounds an AT response line; longer lines are dropped.
+const maxLine = 256
+
+// initModem brings the modem to a known state and publishes its identity.
+func (d *Device) initModem(st *types.ModemValue) bool {
+	ok := false
+	for i := 0; i < 3 && !ok; i++ {
+		_, ok = d.at("AT", time.Second)
+	}
+	if !ok {
+		return false
+	}
+	d.at("ATE0", time.Second)      // no echo
+	d.at("AT+CMEE=1", time.Second) // numeric +CME ERROR
+	d.pub.Emit(core.Event{Addr: d.a, EventTag: "identity", Payload: types.ModemIdentity{
+		Model:    d.query("AT+CGMM"),
+		Revision: d.query("AT+CGMR"),
+		IMEI:     d.query("AT+CGSN"),
+	}})
+	for _, c := range d.p.Init {
+		if lines, ok := d.at(c, 5*time.Second); !ok {
+			d.emitResponse(c, false, lines)
+		}
+	}
+	st.Ready = true
+	return true
+}
+
+// pollOnce refreshes SIM, registration and signal. It is false if the
+// modem stopped answering.
+func (d *Device) pollOnce(st *types.ModemValue) bool {
+	lines, ok := d.at("AT+CPIN?", 2*time.Second)
+	if !ok && (len(lines) == 0 || lines[len(lines)-1] == "timeout") {
+		return false
+	}
+	st.SIM = simState(lines)
+
+	st.Registered, st.Roaming = false, false
+	if lines, ok := d.at("AT+CREG?", 2*time.Second); ok {
+		if v := fieldsAfter(lines, "+CREG:"); len(v) >= 2 {
+			switch v[1] {
+			case 1:
+				st.Registered = true
+			case 5:
+				st.Registered, st.Roaming = true, true
+			}
+		}
+	}
+
+	st.CSQ, st.RSSIdBm = 99, 0
+	if lines, ok := d.at("AT+CSQ", 2*time.Second); ok {
+		if v := fieldsAfter(lines, "+CSQ:"); len(v) >= 1 && v[0] >= 0 && v[0] <= 31 {
+			st.CSQ = uint8(v[0])
+			st.RSSIdBm = int16(-113 + 2*v[0])
+		}
+	}
+	return true
+}
+
+func simState(lines []string) string {
+	for _, l := range lines {
+		switch {
+		case strings.HasPrefix(l, "+CPIN: READY"):
+			return "ready"
+		case strings.HasPrefix(l, "+CPIN: SIM PIN"):
+			return "pin"
+		case strings.HasPrefix(l, "+CPIN: SIM PUK"):
+			return "puk"
+		case l == "+CME ERROR: 10": // SIM not inserted
+			return "absent"
+		}
+	}
+	return "unknown"
+}
+
+// query returns the first information line of cmd, or "".
+func (d *Device) query(cmd string) string {
+	lines, ok := d.at(cmd, time.Second)
+	if !ok || len(lines) < 2 {
+		return ""
+	}
+	return lines[0]
+}
+
+// fieldsAfter parses the comma-separated integers following prefix on the
+// first line that has it.
+func fieldsAfter(lines []string, prefix string) []int {
+	for _, l := range lines {
+		if !strings.HasPrefix(l, prefix) {
+			continue
+		}
+		var out []int
+		for _, f := range strings.Split(strings.TrimSpace(l[len(prefix):]), ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(f))
+			if err != nil {
+				n = -1
+			}
+			out = append(out, n)
+		}
+		return out
+	}
+	return nil
+}
+
+// at runs one command and collects its response lines, the final result
+// (or "timeout") last. ok is true for OK and CONNECT.
+func (d *Device) at(cmd string, timeout time.Duration) (lines []string, ok bool) {
+	d.discard()
+	if !d.write(cmd + "\r") {
+		return []string{"timeout"}, false
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		l, got := d.readLine(deadline)
+		if !got {
+			return append(lines, "timeout"), false
+		}
+		if l == cmd { // echo before ATE0
+			continue
+		}
+		lines = append(lines, l)
+		switch {
+		case l == "OK", strings.HasPrefix(l, "CONNECT"):
+			return lines, true
+		case l == "ERROR", strings.HasPrefix(l, "+CME ERROR"), strings.HasPrefix(l, "+CMS ERROR"),
+			l == "NO CARRIER", l == "BUSY", l == "NO DIALTONE", l == "NO ANSWER":
+			return lines, false
+		}
+	}
+}
+
+// hangup leaves data mode with the +++ escape (1 s guard either side)
+// and drops the call.
+func (d *Device) hangup() {
+	d.sleep(time.Second)
+	d.write("+++")
+	d.sleep(time.Second)
+	d.at("ATH", 2*time.Second)
+}
+
+// discard drops pending input (stale responses, unsolicited results).
+func (d *Device) discard() {
+	var tmp [64]byte
+	for d.port.TryRead(tmp[:]) > 0 {
+	}
+	d.rx = d.rx[:0]
+}
+
+// readLine returns the next non-empty line before deadline.
+func (d *Device) readLine(deadline time.Time) (string, bool) {
+	var tmp [64]byte
+	for {
+		if i := bytes.IndexByte(d.rx, '\n'); i >= 0 {
+			l := strings.TrimSpace(string(d.rx[:i]))
+			d.rx = d.rx[:copy(d.rx, d.rx[i+1:])]
+			if l != "" {
+				return l, true
+			}
+			continue
+		}
+		if n := d.port.TryRead(tmp[:]); n > 0 {
+			if len(d.rx)+n > maxLine {
+				d.rx = d.rx[:0] // overlong line: drop it
+			}
+			d.rx = append(d.rx, tmp[:n]...)
+			continue
+		}
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return "", false
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-d.port.Readable():
+		case <-t.C:
+		case <-d.quit:
+			t.Stop()
+			return "", false
+		}
+		t.Stop()
+	}
+}
+
+// write sends s within a second.
+func (d *Device) write(s string) bool {
+	p := []byte(s)
+	deadline := time.Now().Add(time.Second)
+	for len(p) > 0 {
+		n := d.port.TryWrite(p)
+		p = p[n:]
+		if len(p) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		select {
+		case <-d.port.Writable():
+		case <-time.After(time.Millisecond):
+		}
+	}
+	_ = d.port.Flush()
+	return true
+}
+
+func (d *Device) sleep(t time.Duration) {
+	select {
+	case <-time.After(t):
+	case <-d.quit:
+	}
+}
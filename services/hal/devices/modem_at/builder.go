@@ -0,0 +1,86 @@
+package modem_at
+
+import (
+	"context"
+	"time"
+
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+func init() {
+	core.RegisterBuilder("modem_at", builder{})
+	core.RegisterParams[Params]("modem_at")
+}
+
+// Params describe a cellular modem whose AT command channel is a UART.
+type Params struct {
+	Bus    string // e.g. "uart1"
+	Baud   uint32 // default 115200
+	Domain string // REQUIRED, e.g. "net"
+	Name   string // REQUIRED
+
+	PollMs  uint32   // SIM/registration/RSSI poll period (default 10000)
+	Init    []string // extra commands after the built-in init (e.g. "AT+CGDCONT=1,\"IP\",\"apn\"")
+	DialCmd string   // data dial for session_open{dial:true} (default "ATD*99#")
+	RXSize  int      // session rings, power of two (default 1024)
+	TXSize  int      // (default 1024)
+}
+
+type builder struct{}
+
+func (builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, error) {
+	p, ok := in.Params.(Params)
+	if !ok || p.Bus == "" {
+		return nil, errcode.InvalidParams
+	}
+	if p.Domain == "" || p.Name == "" {
+		return nil, errcode.InvalidParams
+	}
+	if p.Baud == 0 {
+		p.Baud = 115_200
+	}
+	if p.PollMs == 0 {
+		p.PollMs = 10_000
+	}
+	if p.DialCmd == "" {
+		p.DialCmd = "ATD*99#"
+	}
+	if p.RXSize == 0 {
+		p.RXSize = 1024
+	}
+	if p.TXSize == 0 {
+		p.TXSize = 1024
+	}
+	if !isPow2(p.RXSize) || !isPow2(p.TXSize) {
+		return nil, errcode.InvalidParams
+	}
+	port, err := in.Res.Reg.ClaimSerial(in.ID, core.ResourceID(p.Bus))
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Device{
+		id:   in.ID,
+		p:    p,
+		poll: time.Duration(p.PollMs) * time.Millisecond,
+		port: port,
+		pub:  in.Res.Pub,
+		reg:  in.Res.Reg,
+		a:    core.CapAddr{Domain: p.Domain, Kind: types.KindModem, Name: p.Name},
+		reqs: make(chan request, 4),
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	if c, ok := port.(core.SerialConfigurator); ok {
+		d.cfgB = c
+	}
+	core.RegisterVerb(&d.verbs, "command", d.command)
+	core.RegisterVerb(&d.verbs, "session_open", d.sessionOpen)
+	core.RegisterAction(&d.verbs, "session_close", d.sessionClose)
+	core.RegisterAction(&d.verbs, "read", d.read)
+	return d, nil
+}
+
+func isPow2(n int) bool { return n >= 2 && n&(n-1) == 0 }
@@ -0,0 +1,263 @@
+package modem_at
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+type Device struct {
+	id   string
+	p    Params
+	poll time.Duration
+	port core.SerialPort
+	cfgB core.SerialConfigurator
+
+	pub core.EventEmitter
+	reg core.ResourceRegistry
+	a   core.CapAddr
+
+	// Controls are queued to the engine goroutine, which owns the port
+	// (or hands it to a data session).
+	reqs chan request
+	data atomic.Bool // a session is open or opening
+	quit chan struct{}
+	done chan struct{}
+
+	rx    []byte // AT bytes read but not yet consumed as lines
+	sess  *session
+	snCtr uint32
+
+	verbs core.VerbTable
+}
+
+type reqKind uint8
+
+const (
+	reqCommand reqKind = iota
+	reqOpen
+	reqClose
+	reqRead
+)
+
+type request struct {
+	kind reqKind
+	cmd  types.ModemCommand
+	open types.ModemSessionOpen
+}
+
+func (d *Device) ID() string { return d.id }
+
+func (d *Device) Capabilities() []core.CapabilitySpec {
+	return []core.CapabilitySpec{{
+		Domain: d.a.Domain, Kind: types.KindModem, Name: d.a.Name,
+		Info: types.Info{SchemaVersion: 1, Driver: "modem_at",
+			Detail: types.ModemInfo{Bus: d.p.Bus, Baud: d.p.Baud}},
+	}}
+}
+
+func (d *Device) Init(ctx context.Context) error {
+	if d.cfgB != nil {
+		_ = d.cfgB.SetBaudRate(d.p.Baud)
+	}
+	d.pub.Emit(core.Event{Addr: d.a, Err: "initialising"})
+	go d.run()
+	return nil
+}
+
+func (d *Device) Close() error {
+	close(d.quit)
+	<-d.done
+	if d.reg != nil {
+		d.reg.ReleaseSerial(d.id, core.ResourceID(d.p.Bus))
+	}
+	return nil
+}
+
+func (d *Device) Control(_ core.CapAddr, verb string, payload any) (core.EnqueueResult, error) {
+	return d.verbs.Dispatch(verb, payload)
+}
+
+func (d *Device) Verbs(core.CapAddr) []core.VerbSpec { return d.verbs.Specs() }
+
+// ---- Controls (enqueue only) ----
+
+func (d *Device) enqueue(r request) (core.EnqueueResult, error) {
+	select {
+	case d.reqs <- r:
+		return core.EnqueueResult{OK: true}, nil
+	default:
+		return core.EnqueueResult{OK: false, Error: errcode.Busy}, nil
+	}
+}
+
+func (d *Device) command(req types.ModemCommand) (core.EnqueueResult, error) {
+	if len(req.Cmd) < 2 || (req.Cmd[0] != 'A' && req.Cmd[0] != 'a') {
+		return core.EnqueueResult{OK: false, Error: errcode.InvalidParams}, nil
+	}
+	if d.data.Load() {
+		return core.EnqueueResult{OK: false, Error: errcode.Busy}, nil
+	}
+	return d.enqueue(request{kind: reqCommand, cmd: req})
+}
+
+func (d *Device) sessionOpen(req types.ModemSessionOpen) (core.EnqueueResult, error) {
+	if (req.RXSize != 0 && !isPow2(req.RXSize)) || (req.TXSize != 0 && !isPow2(req.TXSize)) {
+		return core.EnqueueResult{OK: false, Error: errcode.InvalidParams}, nil
+	}
+	if !d.data.CompareAndSwap(false, true) {
+		return core.EnqueueResult{OK: false, Error: errcode.Conflict}, nil
+	}
+	res, err := d.enqueue(request{kind: reqOpen, open: req})
+	if !res.OK {
+		d.data.Store(false)
+	}
+	return res, err
+}
+
+func (d *Device) sessionClose() (core.EnqueueResult, error) {
+	if !d.data.Load() {
+		return core.EnqueueResult{OK: true}, nil
+	}
+	return d.enqueue(request{kind: reqClose})
+}
+
+func (d *Device) read() (core.EnqueueResult, error) {
+	return d.enqueue(request{kind: reqRead})
+}
+
+// ---- Engine ----
+
+// run owns the port: it initialises the modem, polls it, runs queued
+// commands and hands the port to data sessions.
+func (d *Device) run() {
+	defer close(d.done)
+
+	var st types.ModemValue
+	st.SIM = "unknown"
+	next := time.NewTimer(0)
+	defer next.Stop()
+
+	for {
+		select {
+		case <-d.quit:
+			if d.sess != nil {
+				d.stopSession()
+			}
+			return
+
+		case r := <-d.reqs:
+			d.handle(r, &st)
+
+		case <-next.C:
+			if st.Data {
+				next.Reset(d.poll)
+				continue
+			}
+			if !st.Ready {
+				if !d.initModem(&st) {
+					d.pub.Emit(core.Event{Addr: d.a, Err: "no_response"})
+					next.Reset(2 * time.Second)
+					continue
+				}
+			}
+			if !d.pollOnce(&st) {
+				st.Ready = false
+				d.pub.Emit(core.Event{Addr: d.a, Err: "no_response"})
+				next.Reset(2 * time.Second)
+				continue
+			}
+			d.emitValue(st)
+			next.Reset(d.poll)
+		}
+	}
+}
+
+func (d *Device) handle(r request, st *types.ModemValue) {
+	switch r.kind {
+	case reqCommand:
+		if st.Data {
+			d.emitResponse(r.cmd.Cmd, false, []string{"data_mode"})
+			return
+		}
+		t := time.Duration(r.cmd.TimeoutMs) * time.Millisecond
+		if t == 0 {
+			t = 2 * time.Second
+		}
+		lines, ok := d.at(r.cmd.Cmd, t)
+		d.emitResponse(r.cmd.Cmd, ok, lines)
+
+	case reqOpen:
+		if st.Data {
+			return
+		}
+		if r.open.Dial {
+			if !st.Ready {
+				d.data.Store(false)
+				d.pub.Emit(core.Event{Addr: d.a, EventTag: "session_failed",
+					Payload: types.ModemResponse{Cmd: d.p.DialCmd, Lines: []string{"not_ready"}}})
+				return
+			}
+			lines, ok := d.at(d.p.DialCmd, 30*time.Second)
+			if !ok || len(lines) == 0 || !strings.HasPrefix(lines[len(lines)-1], "CONNECT") {
+				d.data.Store(false)
+				d.pub.Emit(core.Event{Addr: d.a, EventTag: "session_failed",
+					Payload: types.ModemResponse{Cmd: d.p.DialCmd, OK: false, Lines: lines}})
+				return
+			}
+		}
+		rx, tx := coalesce(r.open.RXSize, d.p.RXSize), coalesce(r.open.TXSize, d.p.TXSize)
+		d.startSession(rx, tx, r.open.Dial)
+		st.Data = true
+		d.pub.Emit(core.Event{Addr: d.a, EventTag: "session_opened", Payload: types.SerialSessionOpened{
+			SessionID: d.sess.id, RXHandle: uint32(d.sess.rxHandle), TXHandle: uint32(d.sess.txHandle),
+		}})
+		d.emitValue(*st)
+
+	case reqClose:
+		if d.sess != nil {
+			d.closeSession(st)
+		}
+
+	case reqRead:
+		if st.Ready {
+			d.emitValue(*st)
+		}
+	}
+}
+
+// closeSession stops the pump, hangs up a dialled call and returns the
+// port to AT mode.
+func (d *Device) closeSession(st *types.ModemValue) {
+	dialled := d.sess.dialled
+	d.stopSession()
+	if dialled {
+		d.hangup()
+	}
+	st.Data = false
+	d.data.Store(false)
+	d.pub.Emit(core.Event{Addr: d.a, EventTag: "session_closed"})
+	d.emitValue(*st)
+}
+
+func (d *Device) emitValue(st types.ModemValue) {
+	st.TS = time.Now().UnixNano()
+	d.pub.Emit(core.Event{Addr: d.a, Payload: st})
+}
+
+func (d *Device) emitResponse(cmd string, ok bool, lines []string) {
+	d.pub.Emit(core.Event{Addr: d.a, EventTag: "response",
+		Payload: types.ModemResponse{Cmd: cmd, OK: ok, Lines: lines}})
+}
+
+func coalesce(v, def int) int {
+	if v == 0 {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,45 @@
+package modem_at
+
+import "devicecode-go/x/cbor"
+
+// MarshalCBOR and UnmarshalCBOR carry Params through config export and
+// import (hal/config/control), keyed by the JSON field names.
+func (p Params) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("Bus", p.Bus)
+		m.Uint("Baud", uint64(p.Baud))
+		m.Text("Domain", p.Domain)
+		m.Text("Name", p.Name)
+		m.Uint("PollMs", uint64(p.PollMs))
+		m.Texts("Init", p.Init)
+		m.Text("DialCmd", p.DialCmd)
+		m.Int("RXSize", int64(p.RXSize))
+		m.Int("TXSize", int64(p.TXSize))
+	})
+}
+
+func (p *Params) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Bus":
+			return cbor.ReadText(d, &p.Bus)
+		case "Baud":
+			return cbor.ReadUint(d, &p.Baud)
+		case "Domain":
+			return cbor.ReadText(d, &p.Domain)
+		case "Name":
+			return cbor.ReadText(d, &p.Name)
+		case "PollMs":
+			return cbor.ReadUint(d, &p.PollMs)
+		case "Init":
+			return cbor.ReadTexts(d, &p.Init)
+		case "DialCmd":
+			return cbor.ReadText(d, &p.DialCmd)
+		case "RXSize":
+			return cbor.ReadInt(d, &p.RXSize)
+		case "TXSize":
+			return cbor.ReadInt(d, &p.TXSize)
+		}
+		return d.Skip()
+	})
+}
@@ -0,0 +1,89 @@
+package modem_at
+
+import (
+	"context"
+
+	"devicecode-go/x/shmring"
+)
+
+// session is a data session: the port is pumped to and from shmring rings
+// exactly as serial_raw does, so clients use the same ring handles.
+type session struct {
+	id       uint32
+	dialled  bool
+	rxHandle shmring.Handle
+	rxRing   *shmring.Ring
+	txHandle shmring.Handle
+	txRing   *shmring.Ring
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// startSession and stopSession run on the engine goroutine.
+func (d *Device) startSession(rxSize, txSize int, dialled bool) {
+	d.discard()
+	rxh, rxr := shmring.NewRegistered(rxSize)
+	txh, txr := shmring.NewRegistered(txSize)
+	ctx, cancel := context.WithCancel(context.Background())
+	d.snCtr++
+	s := &session{
+		id: d.snCtr, dialled: dialled,
+		rxHandle: rxh, rxRing: rxr, txHandle: txh, txRing: txr,
+		cancel: cancel, done: make(chan struct{}),
+	}
+	d.sess = s
+	go d.pump(ctx, s)
+}
+
+func (d *Device) stopSession() {
+	s := d.sess
+	s.cancel()
+	<-s.done
+	shmring.Close(s.rxHandle)
+	shmring.Close(s.txHandle)
+	d.sess = nil
+}
+
+// pump moves bytes port → RX ring and TX ring → port until cancelled.
+func (d *Device) pump(ctx context.Context, s *session) {
+	defer close(s.done)
+	u := d.port
+	for {
+		made := false
+		for {
+			p1, _ := s.rxRing.WriteAcquire()
+			if len(p1) == 0 {
+				break
+			}
+			n := u.TryRead(p1)
+			if n == 0 {
+				break
+			}
+			s.rxRing.WriteCommit(n)
+			made = true
+		}
+		for {
+			p1, _ := s.txRing.ReadAcquire()
+			if len(p1) == 0 {
+				break
+			}
+			n := u.TryWrite(p1)
+			if n == 0 {
+				break
+			}
+			s.txRing.ReadRelease(n)
+			made = true
+		}
+		if made {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-u.Readable():
+		case <-u.Writable():
+		case <-s.rxRing.Writable():
+		case <-s.txRing.Readable():
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package pca9685dev
+
+import (
+	"context"
+
+	"devicecode-go/drivers/pca9685"
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+func init() {
+	core.RegisterBuilder("pca9685", builder{})
+	core.RegisterParams[Params]("pca9685")
+}
+
+// Output is one PWM output of the chip; it becomes <Domain>/pwm/<Name>.
+type Output struct {
+	Channel   uint8  // 0..15
+	Name      string // REQUIRED, e.g. "led_3"
+	FreqHz    uint64 // optional; 0 uses Params.FreqHz
+	Top       uint16 // logical full scale (default 4096)
+	ActiveLow bool
+	Initial   uint16 // initial *logical* level
+}
+
+// Params describe one PCA9685. All outputs share the chip's prescaler, so
+// outputs asking for different frequencies are rejected (errcode.Conflict),
+// as for two channels of one RP2040 slice.
+type Params struct {
+	Bus       string // e.g. "i2c0"
+	Addr      uint16 // default 0x40
+	Domain    string // REQUIRED, e.g. "io"
+	FreqHz    uint64 // default 1000 (24..1526)
+	OpenDrain bool   // outputs sink only (e.g. LEDs to a supply rail)
+	Outputs   []Output
+}
+
+type builder struct{}
+
+func (builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, error) {
+	p, ok := in.Params.(Params)
+	if !ok || p.Bus == "" || p.Domain == "" || len(p.Outputs) == 0 || len(p.Outputs) > 16 {
+		return nil, errcode.InvalidParams
+	}
+	if p.Addr == 0 {
+		p.Addr = pca9685.Address
+	}
+	if p.FreqHz == 0 {
+		p.FreqHz = 1000
+	}
+
+	d := &Device{
+		id:   in.ID,
+		p:    p,
+		pub:  in.Res.Pub,
+		reg:  in.Res.Reg,
+		kick: make(chan struct{}, 1),
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	var used uint16
+	for i, o := range p.Outputs {
+		if o.Name == "" || o.Channel > 15 || used&(1<<o.Channel) != 0 {
+			return nil, errcode.InvalidParams
+		}
+		used |= 1 << o.Channel
+		for _, x := range p.Outputs[:i] {
+			if x.Name == o.Name {
+				return nil, errcode.InvalidParams
+			}
+		}
+		if o.FreqHz == 0 {
+			o.FreqHz = p.FreqHz
+		}
+		if o.Top == 0 {
+			o.Top = pca9685.Full
+		}
+		// The chip is one frequency group; the first output fixes it.
+		err := d.freq.Join(0, o.FreqHz, false, func() error {
+			if _, err := pca9685.Prescale(uint32(o.FreqHz)); err != nil {
+				return errcode.InvalidParams
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		ch := &output{
+			Output: o,
+			a:      core.CapAddr{Domain: p.Domain, Kind: types.KindPWM, Name: o.Name},
+		}
+		ch.level = ch.clamp(o.Initial)
+		core.RegisterVerb(&ch.verbs, "set", func(s types.PWMSet) (core.EnqueueResult, error) {
+			return d.set(ch, s)
+		})
+		core.RegisterVerb(&ch.verbs, "ramp", func(r types.PWMRamp) (core.EnqueueResult, error) {
+			return d.ramp(ch, r)
+		})
+		core.RegisterAction(&ch.verbs, "stop_ramp", func() (core.EnqueueResult, error) {
+			return d.stopRamp(ch)
+		})
+		d.outs = append(d.outs, ch)
+	}
+
+	i2c, err := in.Res.Reg.ClaimI2C(in.ID, core.ResourceID(p.Bus))
+	if err != nil {
+		return nil, err
+	}
+	d.drv = pca9685.New(i2c)
+	d.drv.Address = p.Addr
+	return d, nil
+}
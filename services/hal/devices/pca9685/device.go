@@ -0,0 +1,261 @@
+package pca9685dev
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"devicecode-go/drivers/pca9685"
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+	"devicecode-go/x/ramp"
+)
+
+const retryEvery = time.Second
+
+type output struct {
+	Output
+	a     core.CapAddr
+	verbs core.VerbTable
+
+	// Guarded by Device.mu. Controls and ramps update level and mark the
+	// output dirty; the worker writes it to the chip.
+	level  uint16 // logical 0..Top
+	dirty  bool
+	report bool          // publish the value once written
+	stop   chan struct{} // non-nil while a ramp runs
+}
+
+func (o *output) clamp(lvl uint16) uint16 {
+	if lvl > o.Top {
+		return o.Top
+	}
+	return lvl
+}
+
+// duty maps a logical level to the chip's 0..Full, inverting if ActiveLow.
+func (o *output) duty(logical uint16) uint16 {
+	l := o.clamp(logical)
+	if o.ActiveLow {
+		l = o.Top - l
+	}
+	return uint16(uint32(l) * pca9685.Full / uint32(o.Top))
+}
+
+type Device struct {
+	id   string
+	p    Params
+	drv  pca9685.Device
+	outs []*output
+	freq core.PWMGroups
+
+	pub core.EventEmitter
+	reg core.ResourceRegistry
+
+	mu    sync.Mutex
+	kick  chan struct{}
+	quit  chan struct{}
+	done  chan struct{}
+	ready bool // chip configured; owned by the worker
+}
+
+func (d *Device) ID() string { return d.id }
+
+func (d *Device) Capabilities() []core.CapabilitySpec {
+	out := make([]core.CapabilitySpec, 0, len(d.outs))
+	for _, o := range d.outs {
+		out = append(out, core.CapabilitySpec{
+			Domain: o.a.Domain, Kind: types.KindPWM, Name: o.a.Name,
+			Info: types.Info{SchemaVersion: 1, Driver: "pca9685", Detail: types.PWMInfo{
+				Pin: int(o.Channel), Chip: "pca9685", Bus: d.p.Bus, Addr: d.p.Addr,
+				FreqHz: o.FreqHz, Top: o.Top, ActiveLow: o.ActiveLow, Initial: o.Initial,
+			}},
+		})
+	}
+	return out
+}
+
+func (d *Device) Init(ctx context.Context) error {
+	go d.run()
+	d.wake()
+	return nil
+}
+
+// Close stops ramps and the worker, drives every output to zero duty and
+// releases the bus.
+func (d *Device) Close() error {
+	close(d.quit)
+	<-d.done
+	for _, o := range d.outs {
+		_ = d.drv.Set(int(o.Channel), 0)
+	}
+	d.reg.ReleaseI2C(d.id, core.ResourceID(d.p.Bus))
+	return nil
+}
+
+func (d *Device) Control(a core.CapAddr, verb string, payload any) (core.EnqueueResult, error) {
+	if o := d.output(a.Name); o != nil {
+		return o.verbs.Dispatch(verb, payload)
+	}
+	return core.EnqueueResult{OK: false, Error: errcode.Unsupported}, nil
+}
+
+func (d *Device) Verbs(a core.CapAddr) []core.VerbSpec {
+	if o := d.output(a.Name); o != nil {
+		return o.verbs.Specs()
+	}
+	return nil
+}
+
+func (d *Device) output(name string) *output {
+	for _, o := range d.outs {
+		if o.a.Name == name {
+			return o
+		}
+	}
+	return nil
+}
+
+func (d *Device) wake() {
+	select {
+	case d.kick <- struct{}{}:
+	default:
+	}
+}
+
+// caller holds d.mu
+func (d *Device) cancelRamp(o *output) {
+	if o.stop != nil {
+		close(o.stop)
+		o.stop = nil
+	}
+}
+
+func (d *Device) set(o *output, p types.PWMSet) (core.EnqueueResult, error) {
+	d.mu.Lock()
+	d.cancelRamp(o)
+	o.level = o.clamp(p.Level)
+	o.dirty, o.report = true, true
+	d.mu.Unlock()
+	d.wake()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+// ramp steps towards p.To in its own goroutine; each step only marks the
+// output dirty, so the worker coalesces steps the bus cannot keep up with.
+func (d *Device) ramp(o *output, p types.PWMRamp) (core.EnqueueResult, error) {
+	if p.Steps == 0 || p.DurationMs == 0 {
+		return d.set(o, types.PWMSet{Level: p.To})
+	}
+	d.mu.Lock()
+	if o.stop != nil {
+		d.mu.Unlock()
+		return core.EnqueueResult{OK: false, Error: errcode.Busy}, nil
+	}
+	stop := make(chan struct{})
+	o.stop = stop
+	start, to := o.level, o.clamp(p.To)
+	d.mu.Unlock()
+
+	go func() {
+		defer func() {
+			d.mu.Lock()
+			if o.stop == stop {
+				o.stop = nil
+			}
+			o.report = true
+			d.mu.Unlock()
+			d.wake()
+		}()
+		tick := func(dt time.Duration) bool {
+			t := time.NewTimer(dt)
+			defer t.Stop()
+			select {
+			case <-t.C:
+				return true
+			case <-stop:
+			case <-d.quit:
+			}
+			return false
+		}
+		ramp.Start(start, to, o.Top, p.DurationMs, p.Steps, core.PWMRampMode(p.Mode).Shape(), tick, func(lvl uint16) {
+			d.mu.Lock()
+			if o.stop == stop {
+				o.level, o.dirty = lvl, true
+			}
+			d.mu.Unlock()
+			d.wake()
+		})
+	}()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) stopRamp(o *output) (core.EnqueueResult, error) {
+	d.mu.Lock()
+	d.cancelRamp(o)
+	d.mu.Unlock()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) run() {
+	defer close(d.done)
+	var retry <-chan time.Time
+	for {
+		select {
+		case <-d.quit:
+			return
+		case <-d.kick:
+		case <-retry:
+		}
+		d.flush()
+		retry = nil
+		if !d.ready {
+			retry = time.After(retryEvery)
+		}
+	}
+}
+
+// flush configures the chip if needed and writes every dirty output.
+func (d *Device) flush() {
+	if !d.ready {
+		if err := d.drv.Configure(pca9685.Config{OpenDrain: d.p.OpenDrain}, uint32(d.freq.Freq(0))); err != nil {
+			d.emitAll(string(errcode.MapDriverErr(err)))
+			return
+		}
+		d.ready = true
+		d.mu.Lock()
+		for _, o := range d.outs {
+			o.dirty, o.report = true, true
+		}
+		d.mu.Unlock()
+	}
+	for _, o := range d.outs {
+		d.mu.Lock()
+		dirty, report, lvl := o.dirty, o.report, o.level
+		o.dirty, o.report = false, false
+		d.mu.Unlock()
+		if !dirty && !report {
+			continue
+		}
+		if dirty {
+			if err := d.drv.Set(int(o.Channel), o.duty(lvl)); err != nil {
+				d.mu.Lock()
+				o.dirty, o.report = true, true
+				d.mu.Unlock()
+				d.ready = false // the chip may have been power-cycled
+				d.emitAll(string(errcode.MapDriverErr(err)))
+				return
+			}
+		}
+		if report {
+			d.pub.Emit(core.Event{Addr: o.a, Payload: types.PWMValue{Level: lvl}})
+		}
+	}
+}
+
+func (d *Device) emitAll(code string) {
+	for _, o := range d.outs {
+		d.pub.Emit(core.Event{Addr: o.a, Err: code})
+	}
+}
@@ -0,0 +1,72 @@
+package pca9685dev
+
+import "devicecode-go/x/cbor"
+
+// MarshalCBOR and UnmarshalCBOR carry Params through config export and
+// import (hal/config/control), keyed by the JSON field names.
+func (p Params) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("Bus", p.Bus)
+		m.Uint("Addr", uint64(p.Addr))
+		m.Text("Domain", p.Domain)
+		m.Uint("FreqHz", uint64(p.FreqHz))
+		m.Bool("OpenDrain", p.OpenDrain)
+		m.Array("Outputs", len(p.Outputs), func(e *cbor.Encoder, i int) { p.Outputs[i].MarshalCBOR(e) })
+	})
+}
+
+func (p *Params) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Bus":
+			return cbor.ReadText(d, &p.Bus)
+		case "Addr":
+			return cbor.ReadUint(d, &p.Addr)
+		case "Domain":
+			return cbor.ReadText(d, &p.Domain)
+		case "FreqHz":
+			return cbor.ReadUint(d, &p.FreqHz)
+		case "OpenDrain":
+			return cbor.ReadBool(d, &p.OpenDrain)
+		case "Outputs":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v Output
+				err := v.UnmarshalCBOR(d)
+				p.Outputs = append(p.Outputs, v)
+				return err
+			})
+		}
+		return d.Skip()
+	})
+}
+
+func (p Output) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("Channel", uint64(p.Channel))
+		m.Text("Name", p.Name)
+		m.Uint("FreqHz", uint64(p.FreqHz))
+		m.Uint("Top", uint64(p.Top))
+		m.Bool("ActiveLow", p.ActiveLow)
+		m.Uint("Initial", uint64(p.Initial))
+	})
+}
+
+func (p *Output) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Channel":
+			return cbor.ReadUint(d, &p.Channel)
+		case "Name":
+			return cbor.ReadText(d, &p.Name)
+		case "FreqHz":
+			return cbor.ReadUint(d, &p.FreqHz)
+		case "Top":
+			return cbor.ReadUint(d, &p.Top)
+		case "ActiveLow":
+			return cbor.ReadBool(d, &p.ActiveLow)
+		case "Initial":
+			return cbor.ReadUint(d, &p.Initial)
+		}
+		return d.Skip()
+	})
+}
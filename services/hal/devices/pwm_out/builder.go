@@ -7,16 +7,25 @@ import (
 	"devicecode-go/services/hal/internal/core"
 )
 
-func init() { core.RegisterBuilder("pwm_out", builder{}) }
+func init() {
+	core.RegisterBuilder("pwm_out", builder{})
+	core.RegisterParams[Params]("pwm_out")
+}
 
 type Params struct {
 	Pin       int
+	PinName   string // optional alias (e.g. "FAN_PWM"); overrides Pin
 	FreqHz    uint64 // desired frequency
 	Top       uint16 // wrap value (max logical level)
 	Domain    string
 	Name      string
 	ActiveLow bool
 	Initial   uint16 // initial *logical* level
+
+	// FreqTolPct, when non-zero, accepts a slice frequency within this
+	// percentage of FreqHz rather than failing with Conflict when the other
+	// channel already runs at a different one.
+	FreqTolPct uint8 `json:"freq_tol_pct,omitempty"`
 }
 
 type builder struct{}
@@ -29,6 +38,11 @@ func (builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, er
 	if p.Domain == "" || p.Name == "" {
 		return nil, errcode.InvalidParams
 	}
+	pin, err := core.ResolvePin(in.Res.Reg, p.Pin, p.PinName)
+	if err != nil {
+		return nil, err
+	}
+	p.Pin = pin
 	ph, err := in.Res.Reg.ClaimPin(in.ID, p.Pin, core.FuncPWM)
 	if err != nil {
 		return nil, err
@@ -47,6 +61,8 @@ func (builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, er
 		top:       p.Top,
 		activeLow: p.ActiveLow,
 		initial:   p.Initial,
+		tolPct:    p.FreqTolPct,
 	}
+	dev.registerVerbs()
 	return dev, nil
 }
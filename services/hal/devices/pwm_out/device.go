@@ -20,7 +20,16 @@ type Device struct {
 	top       uint16
 	activeLow bool
 	initial   uint16 // initial *logical* level
+	tolPct    uint8
 	addr      core.CapAddr
+
+	verbs core.VerbTable
+}
+
+func (d *Device) registerVerbs() {
+	core.RegisterVerb(&d.verbs, "set", d.set)
+	core.RegisterVerb(&d.verbs, "ramp", d.ramp)
+	core.RegisterAction(&d.verbs, "stop_ramp", d.stopRamp)
 }
 
 func (d *Device) ID() string { return d.id }
@@ -76,16 +85,20 @@ func (d *Device) toLogical(phys uint16) uint16 {
 }
 
 func (d *Device) Init(ctx context.Context) error {
-	if err := d.pwm.Configure(d.freq, d.top); err != nil {
+	d.addr = core.CapAddr{Domain: d.dom, Kind: types.KindPWM, Name: d.name}
+	eff, err := d.configure()
+	if err != nil {
+		d.pub.Emit(core.Event{Addr: d.addr, Err: string(errcode.MapDriverErr(err))})
+		return nil
+	}
+	if eff != 0 {
 		d.pub.Emit(core.Event{
-			Addr: core.CapAddr{Domain: d.dom, Kind: types.KindPWM, Name: d.name},
-			Err:  string(errcode.MapDriverErr(err)),
+			Addr:     d.addr,
+			EventTag: "freq_negotiated",
+			Payload:  types.PWMFreq{RequestedHz: d.freq, EffectiveHz: eff},
 		})
-		return nil
 	}
 
-	d.addr = core.CapAddr{Domain: d.dom, Kind: types.KindPWM, Name: d.name}
-
 	// Apply initial logical level (default 0) as *physical* output.
 	initialLog := d.clamp(d.initial)
 	d.pwm.Set(d.toPhys(initialLog))
@@ -98,6 +111,16 @@ func (d *Device) Init(ctx context.Context) error {
 	return nil
 }
 
+// configure programs the output, negotiating the slice frequency when
+// FreqTolPct is set and the provider supports it. eff is the negotiated
+// frequency, or 0 for a strict Configure.
+func (d *Device) configure() (eff uint64, err error) {
+	if n, ok := d.pwm.(core.PWMNegotiator); ok && d.tolPct > 0 {
+		return n.ConfigureNear(d.freq, d.top, d.tolPct)
+	}
+	return 0, d.pwm.Configure(d.freq, d.top)
+}
+
 // Close stops any active ramp and releases the claimed pin.
 func (d *Device) Close() error {
 	if d.pwm != nil {
@@ -110,38 +133,33 @@ func (d *Device) Close() error {
 }
 
 func (d *Device) Control(_ core.CapAddr, method string, payload any) (core.EnqueueResult, error) {
-	switch method {
-	case "set":
-		p, code := core.As[types.PWMSet](payload)
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
-		logical := d.clamp(p.Level)
-		d.pwm.Set(d.toPhys(logical))
-		d.pub.Emit(core.Event{
-			Addr:    d.addr,
-			Payload: types.PWMValue{Level: logical}, // publish logical
-		})
-		return core.EnqueueResult{OK: true}, nil
-
-	case "ramp":
-		p, code := core.As[types.PWMRamp](payload) // {To, DurationMs, Steps, Mode}
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
-		toPhys := d.toPhys(d.clamp(p.To)) // invert target if active-low
-		started := d.pwm.Ramp(toPhys, p.DurationMs, p.Steps, core.PWMRampMode(p.Mode))
-		if !started {
-			return core.EnqueueResult{OK: false, Error: errcode.Busy}, nil
-		}
-		// (Optional) we can emit a “ramping” event here if we like, using logical target p.To
-		return core.EnqueueResult{OK: true}, nil
-
-	case "stop_ramp":
-		d.pwm.StopRamp()
-		return core.EnqueueResult{OK: true}, nil
+	return d.verbs.Dispatch(method, payload)
+}
+
+func (d *Device) Verbs(core.CapAddr) []core.VerbSpec { return d.verbs.Specs() }
+
+func (d *Device) set(p types.PWMSet) (core.EnqueueResult, error) {
+	logical := d.clamp(p.Level)
+	d.pwm.Set(d.toPhys(logical))
+	d.pub.Emit(core.Event{
+		Addr:    d.addr,
+		Payload: types.PWMValue{Level: logical}, // publish logical
+	})
+	return core.EnqueueResult{OK: true}, nil
+}
 
-	default:
-		return core.EnqueueResult{OK: false, Error: errcode.Unsupported}, nil
+// ramp starts a provider ramp towards p.To ({To, DurationMs, Steps, Mode}).
+func (d *Device) ramp(p types.PWMRamp) (core.EnqueueResult, error) {
+	toPhys := d.toPhys(d.clamp(p.To)) // invert target if active-low
+	started := d.pwm.Ramp(toPhys, p.DurationMs, p.Steps, core.PWMRampMode(p.Mode))
+	if !started {
+		return core.EnqueueResult{OK: false, Error: errcode.Busy}, nil
 	}
+	// (Optional) we can emit a “ramping” event here if we like, using logical target p.To
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) stopRamp() (core.EnqueueResult, error) {
+	d.pwm.StopRamp()
+	return core.EnqueueResult{OK: true}, nil
 }
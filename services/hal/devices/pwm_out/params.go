@@ -0,0 +1,45 @@
+package pwm_out
+
+import "devicecode-go/x/cbor"
+
+// MarshalCBOR and UnmarshalCBOR carry Params through config export and
+// import (hal/config/control), keyed by the JSON field names.
+func (p Params) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("Pin", int64(p.Pin))
+		m.Text("PinName", p.PinName)
+		m.Uint("FreqHz", uint64(p.FreqHz))
+		m.Uint("Top", uint64(p.Top))
+		m.Text("Domain", p.Domain)
+		m.Text("Name", p.Name)
+		m.Bool("ActiveLow", p.ActiveLow)
+		m.Uint("Initial", uint64(p.Initial))
+		m.Uint("freq_tol_pct", uint64(p.FreqTolPct))
+	})
+}
+
+func (p *Params) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Pin":
+			return cbor.ReadInt(d, &p.Pin)
+		case "PinName":
+			return cbor.ReadText(d, &p.PinName)
+		case "FreqHz":
+			return cbor.ReadUint(d, &p.FreqHz)
+		case "Top":
+			return cbor.ReadUint(d, &p.Top)
+		case "Domain":
+			return cbor.ReadText(d, &p.Domain)
+		case "Name":
+			return cbor.ReadText(d, &p.Name)
+		case "ActiveLow":
+			return cbor.ReadBool(d, &p.ActiveLow)
+		case "Initial":
+			return cbor.ReadUint(d, &p.Initial)
+		case "freq_tol_pct":
+			return cbor.ReadUint(d, &p.FreqTolPct)
+		}
+		return d.Skip()
+	})
+}
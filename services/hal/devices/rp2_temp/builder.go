@@ -8,7 +8,10 @@ import (
 	"devicecode-go/types"
 )
 
-func init() { core.RegisterBuilder("rp2_temp", builder{}) }
+func init() {
+	core.RegisterBuilder("rp2_temp", builder{})
+	core.RegisterParams[Params]("rp2_temp")
+}
 
 type Params struct {
 	Domain string // REQUIRED
@@ -32,13 +35,15 @@ func (builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, er
 	if !ok {
 		return nil, errcode.Unsupported
 	}
-	return &Device{
+	d := &Device{
 		id:   in.ID,
 		pub:  in.Res.Pub,
 		dom:  p.Domain,
 		name: p.Name,
 		read: rdr.ReadOnDieMilliC,
-	}, nil
+	}
+	core.RegisterAction(&d.verbs, "read", d.sample)
+	return d, nil
 }
 
 type Device struct {
@@ -49,6 +54,8 @@ type Device struct {
 
 	addr core.CapAddr
 	read func() int32 // provider-injected milli-celsius reader
+
+	verbs core.VerbTable
 }
 
 func (d *Device) ID() string { return d.id }
@@ -73,10 +80,13 @@ func (d *Device) Init(ctx context.Context) error {
 
 func (d *Device) Close() error { return nil }
 
-func (d *Device) Control(_ core.CapAddr, verb string, _ any) (core.EnqueueResult, error) {
-	if verb != "read" {
-		return core.EnqueueResult{OK: false, Error: errcode.Unsupported}, nil
-	}
+func (d *Device) Control(_ core.CapAddr, verb string, payload any) (core.EnqueueResult, error) {
+	return d.verbs.Dispatch(verb, payload)
+}
+
+func (d *Device) Verbs(core.CapAddr) []core.VerbSpec { return d.verbs.Specs() }
+
+func (d *Device) sample() (core.EnqueueResult, error) {
 	// Synchronous, fast, and non-contentious: no goroutine required.
 	mc := d.read()                // milli-celsius
 	decic := mc / 100             // deci-celsius
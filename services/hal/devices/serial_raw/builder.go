@@ -2,6 +2,7 @@ package serial_raw
 
 import (
 	"context"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -20,6 +21,11 @@ type Params struct {
 	Baud   uint32
 	RXSize int // power of two; default 512 if zero in SessionOpen
 	TXSize int // power of two; default 512 if zero in SessionOpen
+
+	// Default idle timeout for sessions (0 = never expire). A session with
+	// no client activity for this long is closed and session_expired is
+	// emitted, releasing its rings if the client died without closing.
+	IdleTimeoutMs uint32
 }
 
 // ---- Device ----
@@ -32,13 +38,18 @@ type Device struct {
 	busID string
 	port  core.SerialPort
 
-	cfgB core.SerialConfigurator
-	cfgF core.SerialFormatConfigurator
+	cfgB    core.SerialConfigurator
+	cfgF    core.SerialFormatConfigurator
+	cfgFlow core.SerialFlowConfigurator
 
 	params Params
 
+	mu    sync.Mutex // guards sess against idle expiry
 	sess  *session
+	test  *loopbackRun // non-nil while run_loopback owns the port
 	snCtr atomic.Uint32
+
+	verbs core.VerbTable
 }
 
 type session struct {
@@ -54,13 +65,21 @@ type session struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 	done   chan struct{}
+
+	// Idle expiry (0 = off). The reactor sets expired before exiting.
+	idle      time.Duration
+	keepalive atomic.Int64 // Unix ns of the last session_keepalive
+	expired   bool
 }
 
 // ---- Builder registration ----
 
 func Builder() core.Builder { return builder{} }
 
-func init() { core.RegisterBuilder("serial_raw", Builder()) }
+func init() {
+	core.RegisterBuilder("serial_raw", Builder())
+	core.RegisterParams[Params]("serial_raw")
+}
 
 type builder struct{}
 
@@ -92,6 +111,8 @@ func (builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, er
 			Baud:   p.Baud,
 			RXSize: p.RXSize,
 			TXSize: p.TXSize,
+
+			IdleTimeoutMs: p.IdleTimeoutMs,
 		},
 	}
 
@@ -102,6 +123,10 @@ func (builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, er
 	if f, ok := sp.(core.SerialFormatConfigurator); ok {
 		d.cfgF = f
 	}
+	if fc, ok := sp.(core.SerialFlowConfigurator); ok {
+		d.cfgFlow = fc
+	}
+	d.registerVerbs()
 
 	return d, nil
 }
@@ -134,9 +159,16 @@ func (d *Device) Init(ctx context.Context) error {
 }
 
 func (d *Device) Close() error {
+	d.mu.Lock()
 	if d.sess != nil {
 		d.stopSession()
 	}
+	t := d.test
+	d.mu.Unlock()
+	if t != nil {
+		close(t.quit)
+		<-t.done
+	}
 	if d.res.Reg != nil {
 		d.res.Reg.ReleaseSerial(d.id, core.ResourceID(d.busID))
 	}
@@ -146,134 +178,168 @@ func (d *Device) Close() error {
 // ---- Controls ----
 
 func (d *Device) Control(_ core.CapAddr, verb string, payload any) (core.EnqueueResult, error) {
-	switch verb {
-	case "session_open":
-		req, code := core.As[types.SerialSessionOpen](payload) // zero value => apply defaults
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
+	return d.verbs.Dispatch(verb, payload)
+}
 
-		if d.sess != nil {
-			return core.EnqueueResult{OK: false, Error: errcode.Conflict}, nil
-		}
+func (d *Device) Verbs(core.CapAddr) []core.VerbSpec { return d.verbs.Specs() }
+
+// registerVerbs builds the verb table; configuration verbs are only
+// registered when the port supports them.
+func (d *Device) registerVerbs() {
+	core.RegisterVerb(&d.verbs, "session_open", d.sessionOpen) // zero value => apply defaults
+	core.RegisterAction(&d.verbs, "session_close", d.sessionClose)
+	core.RegisterAction(&d.verbs, "session_keepalive", d.sessionKeepalive)
+	core.RegisterVerb(&d.verbs, "run_loopback", d.runLoopback)
+	if d.cfgB != nil {
+		core.RegisterVerb(&d.verbs, "set_baud", d.setBaud)
+	}
+	if d.cfgF != nil {
+		core.RegisterVerb(&d.verbs, "set_format", d.setFormat)
+	}
+	if d.cfgFlow != nil {
+		core.RegisterVerb(&d.verbs, "set_flow_control", d.setFlowControl)
+	}
+}
 
-		rxSize, txSize := req.RXSize, req.TXSize
-		if rxSize == 0 {
-			rxSize = coalescePow2(d.params.RXSize, 512)
-		}
-		if txSize == 0 {
-			txSize = coalescePow2(d.params.TXSize, 512)
-		}
-		if !isPow2(rxSize) || !isPow2(txSize) || rxSize < 2 || txSize < 2 {
-			return core.EnqueueResult{OK: false, Error: errcode.InvalidParams}, nil
-		}
+func (d *Device) sessionOpen(req types.SerialSessionOpen) (core.EnqueueResult, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.sess != nil {
+		return core.EnqueueResult{OK: false, Error: errcode.Conflict}, nil
+	}
+	if d.test != nil {
+		return core.EnqueueResult{OK: false, Error: errcode.Busy}, nil
+	}
+
+	rxSize, txSize := req.RXSize, req.TXSize
+	if rxSize == 0 {
+		rxSize = coalescePow2(d.params.RXSize, 512)
+	}
+	if txSize == 0 {
+		txSize = coalescePow2(d.params.TXSize, 512)
+	}
+	if !isPow2(rxSize) || !isPow2(txSize) || rxSize < 2 || txSize < 2 {
+		return core.EnqueueResult{OK: false, Error: errcode.InvalidParams}, nil
+	}
+
+	idleMs := req.IdleTimeoutMs
+	if idleMs == 0 {
+		idleMs = d.params.IdleTimeoutMs
+	}
+	d.startSession(rxSize, txSize, time.Duration(idleMs)*time.Millisecond)
+
+	// --- Device-level hygiene: drain spurious RX before signalling link up ---
+	// Discard any pre-existing or immediately-arriving bytes on the UART RX path.
+	// Uses a short quiet window so this remains bounded and non-blocking.
+	{
+		const quiet = 5 * time.Millisecond     // time with no bytes before we stop
+		const maxTotal = 15 * time.Millisecond // absolute cap as a safeguard
+
+		tmp := make([]byte, 64)
+		tStart := time.Now()
+		tQuiet := time.Now().Add(quiet)
 
-		d.startSession(rxSize, txSize)
-
-		// --- Device-level hygiene: drain spurious RX before signalling link up ---
-		// Discard any pre-existing or immediately-arriving bytes on the UART RX path.
-		// Uses a short quiet window so this remains bounded and non-blocking.
-		{
-			const quiet = 5 * time.Millisecond     // time with no bytes before we stop
-			const maxTotal = 15 * time.Millisecond // absolute cap as a safeguard
-
-			tmp := make([]byte, 64)
-			tStart := time.Now()
-			tQuiet := time.Now().Add(quiet)
-
-			for {
-				// Non-blocking attempt to pull any pending bytes.
-				if n := d.port.TryRead(tmp); n > 0 {
-					// Extend the quiet window after activity.
-					tQuiet = time.Now().Add(quiet)
-				} else {
-					// No bytes right now. If we have been quiet long enough, or we have
-					// reached the absolute bound, stop draining.
-					now := time.Now()
-					if now.After(tQuiet) || now.Sub(tStart) >= maxTotal {
-						break
-					}
-					// Wait for either a UART RX edge or a very short back-off, then re-check.
-					select {
-					case <-d.port.Readable():
-					case <-time.After(time.Millisecond):
-					}
+		for {
+			// Non-blocking attempt to pull any pending bytes.
+			if n := d.port.TryRead(tmp); n > 0 {
+				// Extend the quiet window after activity.
+				tQuiet = time.Now().Add(quiet)
+			} else {
+				// No bytes right now. If we have been quiet long enough, or we have
+				// reached the absolute bound, stop draining.
+				now := time.Now()
+				if now.After(tQuiet) || now.Sub(tStart) >= maxTotal {
+					break
+				}
+				// Wait for either a UART RX edge or a very short back-off, then re-check.
+				select {
+				case <-d.port.Readable():
+				case <-time.After(time.Millisecond):
 				}
 			}
 		}
-		// --- end hygiene ---
+	}
+	// --- end hygiene ---
 
-		rep := types.SerialSessionOpened{
-			SessionID: d.sess.id,
-			RXHandle:  uint32(d.sess.rxHandle),
-			TXHandle:  uint32(d.sess.txHandle),
-		}
-		d.res.Pub.Emit(core.Event{
-			Addr: d.a, Payload: rep, EventTag: "session_opened",
-		})
-		d.res.Pub.Emit(core.Event{
-			Addr: d.a, EventTag: "link_up",
-		})
+	rep := types.SerialSessionOpened{
+		SessionID: d.sess.id,
+		RXHandle:  uint32(d.sess.rxHandle),
+		TXHandle:  uint32(d.sess.txHandle),
+	}
+	d.res.Pub.Emit(core.Event{
+		Addr: d.a, Payload: rep, EventTag: "session_opened",
+	})
+	d.res.Pub.Emit(core.Event{
+		Addr: d.a, EventTag: "link_up",
+	})
 
-		return core.EnqueueResult{OK: true}, nil
+	return core.EnqueueResult{OK: true}, nil
+}
 
-	case "session_close":
-		// Accept zero-value payload (no fields)
-		_, _ = core.As[types.SerialSessionClose](payload)
-		if d.sess == nil {
-			return core.EnqueueResult{OK: true}, nil
-		}
-		d.stopSession()
-		d.res.Pub.Emit(core.Event{
-			Addr: d.a, EventTag: "session_closed",
-		})
-		d.res.Pub.Emit(core.Event{
-			Addr: d.a, Err: "session_closed",
-		})
+func (d *Device) sessionClose() (core.EnqueueResult, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.sess == nil {
 		return core.EnqueueResult{OK: true}, nil
+	}
+	d.stopSession()
+	d.res.Pub.Emit(core.Event{
+		Addr: d.a, EventTag: "session_closed",
+	})
+	d.res.Pub.Emit(core.Event{
+		Addr: d.a, Err: "session_closed",
+	})
+	return core.EnqueueResult{OK: true}, nil
+}
 
-	case "set_baud":
-		if d.cfgB == nil {
-			return core.EnqueueResult{OK: false, Error: errcode.Unsupported}, nil
-		}
-		req, code := core.As[types.SerialSetBaud](payload)
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
-		_ = d.cfgB.SetBaudRate(req.Baud)
-		return core.EnqueueResult{OK: true}, nil
+func (d *Device) sessionKeepalive() (core.EnqueueResult, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.sess == nil {
+		return core.EnqueueResult{OK: false, Error: errcode.Unavailable}, nil
+	}
+	d.sess.keepalive.Store(time.Now().UnixNano())
+	return core.EnqueueResult{OK: true}, nil
+}
 
-	case "set_format":
-		if d.cfgF == nil {
-			return core.EnqueueResult{OK: false, Error: errcode.Unsupported}, nil
-		}
-		req, code := core.As[types.SerialSetFormat](payload)
-		if code != "" {
-			return core.EnqueueResult{OK: false, Error: code}, nil
-		}
-		if req.DataBits == 0 || req.StopBits == 0 {
-			return core.EnqueueResult{OK: false, Error: errcode.InvalidParams}, nil
-		}
-		par := "none"
-		switch req.Parity {
-		case types.ParityEven:
-			par = "even"
-		case types.ParityOdd:
-			par = "odd"
-		}
-		if err := d.cfgF.SetFormat(req.DataBits, req.StopBits, par); err != nil {
-			return core.EnqueueResult{OK: false, Error: errcode.MapDriverErr(err)}, nil
-		}
-		return core.EnqueueResult{OK: true}, nil
+func (d *Device) setBaud(req types.SerialSetBaud) (core.EnqueueResult, error) {
+	_ = d.cfgB.SetBaudRate(req.Baud)
+	return core.EnqueueResult{OK: true}, nil
+}
 
-	default:
-		return core.EnqueueResult{OK: false, Error: errcode.Unsupported}, nil
+func (d *Device) setFormat(req types.SerialSetFormat) (core.EnqueueResult, error) {
+	if req.DataBits == 0 {
+		return core.EnqueueResult{OK: false, Error: errcode.InvalidParams},
+			&errcode.E{C: errcode.InvalidParams, Op: "set_format", Msg: "must be > 0", Field: "data_bits"}
+	}
+	if req.StopBits == 0 {
+		return core.EnqueueResult{OK: false, Error: errcode.InvalidParams},
+			&errcode.E{C: errcode.InvalidParams, Op: "set_format", Msg: "must be > 0", Field: "stop_bits"}
+	}
+	par := "none"
+	switch req.Parity {
+	case types.ParityEven:
+		par = "even"
+	case types.ParityOdd:
+		par = "odd"
 	}
+	if err := d.cfgF.SetFormat(req.DataBits, req.StopBits, par); err != nil {
+		return core.EnqueueResult{OK: false, Error: errcode.MapDriverErr(err)}, nil
+	}
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) setFlowControl(req types.SerialSetFlowControl) (core.EnqueueResult, error) {
+	if err := d.cfgFlow.SetFlowControl(req.Enabled); err != nil {
+		return core.EnqueueResult{OK: false, Error: errcode.Of(err)}, nil
+	}
+	return core.EnqueueResult{OK: true}, nil
 }
 
 // ---- Session lifecycle ----
 
-func (d *Device) startSession(rxSize, txSize int) {
+// startSession and stopSession are called with d.mu held.
+func (d *Device) startSession(rxSize, txSize int, idle time.Duration) {
 	rxh, rxr := shmring.NewRegistered(rxSize)
 	txh, txr := shmring.NewRegistered(txSize)
 
@@ -287,10 +353,37 @@ func (d *Device) startSession(rxSize, txSize int) {
 		ctx:      ctx,
 		cancel:   cancel,
 		done:     make(chan struct{}),
+		idle:     idle,
 	}
+	s.keepalive.Store(time.Now().UnixNano())
 	d.sess = s
 
 	go d.reactor(s)
+	if idle > 0 {
+		go d.reapOnExpiry(s)
+	}
+}
+
+// reapOnExpiry tears down s if its reactor exited on idle expiry. A
+// session stopped by session_close or Close is left alone.
+func (d *Device) reapOnExpiry(s *session) {
+	<-s.done
+	if !s.expired {
+		return
+	}
+	d.mu.Lock()
+	if d.sess != s {
+		d.mu.Unlock()
+		return
+	}
+	shmring.Close(s.rxHandle)
+	shmring.Close(s.txHandle)
+	d.sess = nil
+	d.mu.Unlock()
+
+	d.res.Pub.Emit(core.Event{Addr: d.a, EventTag: "session_expired",
+		Payload: types.SerialSessionExpired{SessionID: s.id, IdleMs: uint32(s.idle / time.Millisecond)}})
+	d.res.Pub.Emit(core.Event{Addr: d.a, Err: "session_expired"})
 }
 
 func (d *Device) stopSession() {
@@ -311,6 +404,9 @@ func (d *Device) stopSession() {
 
 // ---- Reactor (single goroutine) ----
 
+// statsEvery bounds how often RX overrun counts are checked and published.
+const statsEvery = time.Second
+
 func (d *Device) reactor(s *session) {
 	defer close(s.done)
 
@@ -318,6 +414,27 @@ func (d *Device) reactor(s *session) {
 	rxR := s.rxRing // UART -> app
 	txR := s.txRing // app  -> UART
 
+	// Overrun accounting (optional on the port); published as the
+	// capability value whenever the count changes.
+	stats, _ := u.(core.SerialStatsReporter)
+	var lastOv uint32
+	var lastCheck time.Time
+
+	// Client activity: the client consumes RX and produces TX.
+	var idleC <-chan time.Time
+	var idleT *time.Timer
+	lastAct := time.Now()
+	rxSeen, txSeen := rxR.Consumed(), txR.Produced()
+	if s.idle > 0 {
+		idleT = time.NewTimer(s.idle)
+		defer idleT.Stop()
+		idleC = idleT.C
+	}
+	if stats != nil {
+		lastOv = stats.RXOverruns()
+		d.res.Pub.Emit(core.Event{Addr: d.a, Payload: types.SerialStats{RXOverruns: lastOv}})
+	}
+
 	for {
 		made := false
 
@@ -344,6 +461,14 @@ func (d *Device) reactor(s *session) {
 			made = true
 		}
 
+		if stats != nil && made && time.Since(lastCheck) >= statsEvery {
+			lastCheck = time.Now()
+			if ov := stats.RXOverruns(); ov != lastOv {
+				lastOv = ov
+				d.res.Pub.Emit(core.Event{Addr: d.a, Payload: types.SerialStats{RXOverruns: ov}})
+			}
+		}
+
 		// txRing -> UART TX (use spans; drain p1 completely before p2)
 		for {
 			p1, p2 := txR.ReadAcquire()
@@ -379,6 +504,20 @@ func (d *Device) reactor(s *session) {
 		case <-u.Writable():
 		case <-rxR.Writable():
 		case <-txR.Readable():
+		case <-idleC:
+			now := time.Now()
+			if rx, tx := rxR.Consumed(), txR.Produced(); rx != rxSeen || tx != txSeen {
+				rxSeen, txSeen, lastAct = rx, tx, now
+			}
+			if ka := time.Unix(0, s.keepalive.Load()); ka.After(lastAct) {
+				lastAct = ka
+			}
+			left := s.idle - now.Sub(lastAct)
+			if left <= 0 {
+				s.expired = true
+				return
+			}
+			idleT.Reset(left)
 		}
 	}
 }
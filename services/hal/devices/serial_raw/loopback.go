@@ -0,0 +1,160 @@
+package serial_raw
+
+import (
+	"math/bits"
+	"time"
+
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+// Loopback test bounds.
+const (
+	loopbackDefault = time.Second
+	loopbackMax     = 60 * time.Second
+	loopbackDrain   = 50 * time.Millisecond // quiet time after TX stops
+)
+
+type loopbackRun struct {
+	quit chan struct{} // closed by Close to abandon the run
+	done chan struct{}
+}
+
+// runLoopback starts a production loopback test on the raw port. The
+// result is emitted as event loopback_result when the run finishes.
+func (d *Device) runLoopback(req types.SerialLoopback) (core.EnqueueResult, error) {
+	dur := time.Duration(req.DurationMs) * time.Millisecond
+	if dur == 0 {
+		dur = loopbackDefault
+	}
+	if dur > loopbackMax {
+		return core.EnqueueResult{OK: false, Error: errcode.InvalidParams}, nil
+	}
+	pat := req.Pattern
+	if pat == "" {
+		pat = "counter"
+	}
+	if pat != "counter" && pat != "prbs7" {
+		return core.EnqueueResult{OK: false, Error: errcode.InvalidParams}, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.sess != nil || d.test != nil {
+		return core.EnqueueResult{OK: false, Error: errcode.Busy}, nil
+	}
+	d.test = &loopbackRun{quit: make(chan struct{}), done: make(chan struct{})}
+	go d.loopback(d.test, pat, dur)
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) loopback(t *loopbackRun, pat string, dur time.Duration) {
+	defer close(t.done)
+	u := d.port
+	res := types.SerialLoopbackResult{Pattern: pat, DurationMs: uint32(dur / time.Millisecond)}
+
+	var buf [64]byte
+	for u.TryRead(buf[:]) > 0 { // discard stale RX
+	}
+
+	txGen, rxGen := newPattern(pat), newPattern(pat)
+	var out [64]byte
+	pending := out[:0] // generated but not yet accepted by the port
+
+	start := time.Now()
+	stop := start.Add(dur)
+	quiet := stop.Add(loopbackDrain)
+	tick := time.NewTimer(time.Millisecond)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-t.quit:
+			return
+		default:
+		}
+		now := time.Now()
+		sending := now.Before(stop)
+		if !sending && now.After(quiet) {
+			break
+		}
+		made := false
+
+		if sending {
+			if len(pending) == 0 {
+				pending = out[:]
+				for i := range pending {
+					pending[i] = txGen.next()
+				}
+			}
+			if n := u.TryWrite(pending); n > 0 {
+				pending = pending[n:]
+				res.TXBytes += uint32(n)
+				made = true
+			}
+		}
+
+		if n := u.TryRead(buf[:]); n > 0 {
+			for _, b := range buf[:n] {
+				if x := b ^ rxGen.next(); x != 0 {
+					res.ByteErrors++
+					res.BitErrors += uint32(bits.OnesCount8(x))
+				}
+			}
+			res.RXBytes += uint32(n)
+			if !sending {
+				quiet = time.Now().Add(loopbackDrain)
+			}
+			made = true
+		}
+
+		if made {
+			continue
+		}
+		tick.Reset(time.Millisecond)
+		select {
+		case <-u.Readable():
+		case <-u.Writable():
+		case <-tick.C:
+		case <-t.quit:
+			return
+		}
+	}
+
+	res.BitsChecked = res.RXBytes * 8
+	if ms := uint32(dur / time.Millisecond); ms > 0 {
+		res.RXBytesPerS = uint32(uint64(res.RXBytes) * 1000 / uint64(ms))
+	}
+
+	d.mu.Lock()
+	d.test = nil
+	d.mu.Unlock()
+	d.res.Pub.Emit(core.Event{Addr: d.a, EventTag: "loopback_result", Payload: res})
+}
+
+// pattern generates the loopback test stream.
+type pattern struct {
+	prbs bool
+	n    uint8 // counter
+	lfsr uint8 // PRBS7 state (x^7 + x^6 + 1), never zero
+}
+
+func newPattern(name string) *pattern {
+	return &pattern{prbs: name == "prbs7", lfsr: 0x7F}
+}
+
+func (p *pattern) next() byte {
+	if !p.prbs {
+		b := p.n
+		p.n++
+		return b
+	}
+	var b byte
+	for i := 0; i < 8; i++ {
+		bit := ((p.lfsr >> 6) ^ (p.lfsr >> 5)) & 1
+		p.lfsr = ((p.lfsr << 1) | bit) & 0x7F
+		b = b<<1 | bit
+	}
+	return b
+}
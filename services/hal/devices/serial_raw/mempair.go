@@ -0,0 +1,184 @@
+package serial_raw
+
+import (
+	"context"
+	"sync"
+
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+// ---- serial_loopback: two serial capabilities wired back to back ----
+//
+// A host stand-in for two UARTs joined by a null-modem cable: bytes
+// written to A are read from B and vice versa, through in-memory FIFOs.
+// Each side is a full serial_raw device, so sessions, idle expiry and
+// run_loopback behave as on hardware and session clients (uart-test,
+// passthru, bridge) can be tested without a board.
+
+// PairParams configure a serial_loopback device.
+type PairParams struct {
+	Domain  string // default "io"
+	A, B    string // capability names; required and distinct
+	BufSize int    // bytes in flight each way; default 512
+}
+
+func init() {
+	core.RegisterBuilder("serial_loopback", pairBuilder{})
+	core.RegisterParams[PairParams]("serial_loopback")
+}
+
+type pairBuilder struct{}
+
+func (pairBuilder) Build(ctx context.Context, in core.BuilderInput) (core.Device, error) {
+	p, ok := in.Params.(PairParams)
+	if !ok || p.A == "" || p.B == "" || p.A == p.B {
+		return nil, errcode.InvalidParams
+	}
+	if p.Domain == "" {
+		p.Domain = "io"
+	}
+	if p.BufSize <= 0 {
+		p.BufSize = 512
+	}
+	pa, pb := newMemPair(p.BufSize)
+	// No claimed resources: Reg stays nil so Close releases nothing.
+	res := core.Resources{Pub: in.Res.Pub, Clock: in.Res.Clock}
+	d := &pairDevice{id: in.ID, side: [2]*Device{
+		newMemDevice(in.ID, p.Domain, p.A, pa, res),
+		newMemDevice(in.ID, p.Domain, p.B, pb, res),
+	}}
+	return d, nil
+}
+
+func newMemDevice(id, domain, name string, port *memPort, res core.Resources) *Device {
+	d := &Device{
+		id:     id,
+		a:      core.CapAddr{Domain: domain, Kind: types.KindSerial, Name: name},
+		res:    res,
+		busID:  "mem",
+		port:   port,
+		params: Params{Bus: "mem", Domain: domain, Name: name},
+	}
+	d.registerVerbs()
+	return d
+}
+
+// pairDevice owns both sides of a serial_loopback.
+type pairDevice struct {
+	id   string
+	side [2]*Device
+}
+
+func (d *pairDevice) ID() string { return d.id }
+
+func (d *pairDevice) Capabilities() []core.CapabilitySpec {
+	var out []core.CapabilitySpec
+	for _, s := range d.side {
+		cs := s.Capabilities()
+		for i := range cs {
+			cs[i].Info.Driver = "serial_loopback"
+		}
+		out = append(out, cs...)
+	}
+	return out
+}
+
+func (d *pairDevice) Init(ctx context.Context) error {
+	for _, s := range d.side {
+		if err := s.Init(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *pairDevice) Close() error {
+	for _, s := range d.side {
+		_ = s.Close()
+	}
+	return nil
+}
+
+func (d *pairDevice) Control(cap core.CapAddr, verb string, payload any) (core.EnqueueResult, error) {
+	if s := d.of(cap); s != nil {
+		return s.Control(cap, verb, payload)
+	}
+	return core.EnqueueResult{OK: false, Error: errcode.UnknownCapability}, nil
+}
+
+func (d *pairDevice) Verbs(cap core.CapAddr) []core.VerbSpec {
+	if s := d.of(cap); s != nil {
+		return s.Verbs(cap)
+	}
+	return nil
+}
+
+func (d *pairDevice) of(cap core.CapAddr) *Device {
+	for _, s := range d.side {
+		if s.a == cap {
+			return s
+		}
+	}
+	return nil
+}
+
+// ---- In-memory port ----
+
+// fifo is one direction of the pair. Readiness edges are coalesced, as
+// core.SerialPort requires: a wake means re-check.
+type fifo struct {
+	mu       sync.Mutex
+	buf      []byte
+	cap      int
+	readable chan struct{} // data arrived
+	writable chan struct{} // space freed
+}
+
+func newFIFO(size int) *fifo {
+	return &fifo{cap: size, readable: make(chan struct{}, 1), writable: make(chan struct{}, 1)}
+}
+
+func (f *fifo) write(p []byte) int {
+	f.mu.Lock()
+	n := min(len(p), f.cap-len(f.buf))
+	f.buf = append(f.buf, p[:n]...)
+	f.mu.Unlock()
+	if n > 0 {
+		signal(f.readable)
+	}
+	return n
+}
+
+func (f *fifo) read(p []byte) int {
+	f.mu.Lock()
+	n := copy(p, f.buf)
+	f.buf = f.buf[:copy(f.buf, f.buf[n:])]
+	f.mu.Unlock()
+	if n > 0 {
+		signal(f.writable)
+	}
+	return n
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// memPort is one end of a pair: it writes tx and reads rx.
+type memPort struct{ rx, tx *fifo }
+
+func newMemPair(size int) (*memPort, *memPort) {
+	ab, ba := newFIFO(size), newFIFO(size)
+	return &memPort{rx: ba, tx: ab}, &memPort{rx: ab, tx: ba}
+}
+
+func (p *memPort) TryRead(b []byte) int      { return p.rx.read(b) }
+func (p *memPort) TryWrite(b []byte) int     { return p.tx.write(b) }
+func (p *memPort) Readable() <-chan struct{} { return p.rx.readable }
+func (p *memPort) Writable() <-chan struct{} { return p.tx.writable }
+func (p *memPort) Flush() error              { return nil }
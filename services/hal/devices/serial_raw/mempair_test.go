@@ -0,0 +1,86 @@
+package serial_raw
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+	"devicecode-go/x/shmring"
+)
+
+// opened passes on session_opened events.
+type opened chan core.Event
+
+func (o opened) Emit(ev core.Event) bool {
+	if ev.EventTag == "session_opened" {
+		o <- ev
+	}
+	return true
+}
+
+func openSession(t *testing.T, d core.Device, ev opened, name string) (rx, tx *shmring.Ring) {
+	t.Helper()
+	a := core.CapAddr{Domain: "io", Kind: types.KindSerial, Name: name}
+	if res, err := d.Control(a, "session_open", types.SerialSessionOpen{}); err != nil || !res.OK {
+		t.Fatalf("session_open %s: %+v %v", name, res, err)
+	}
+	e := <-ev
+	if e.Addr != a {
+		t.Fatalf("session_opened on %+v, want %+v", e.Addr, a)
+	}
+	so := e.Payload.(types.SerialSessionOpened)
+	return shmring.Get(shmring.Handle(so.RXHandle)), shmring.Get(shmring.Handle(so.TXHandle))
+}
+
+func TestSerialLoopback_SessionsCrossOver(t *testing.T) {
+	ev := make(opened, 2)
+	d, err := pairBuilder{}.Build(context.Background(), core.BuilderInput{ID: "lb",
+		Res: core.Resources{Pub: ev}, Params: PairParams{A: "a", B: "b", BufSize: 64}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if n := len(d.Capabilities()); n != 2 {
+		t.Fatalf("%d capabilities, want 2", n)
+	}
+	_, aTX := openSession(t, d, ev, "a")
+	bRX, _ := openSession(t, d, ev, "b")
+
+	// Several times the FIFO and ring sizes, so both must apply backpressure.
+	want := make([]byte, 4096)
+	for i := range want {
+		want[i] = byte(i * 7)
+	}
+	go func() {
+		for p := want; len(p) > 0; {
+			n := aTX.TryWriteFrom(p)
+			p = p[n:]
+			if n == 0 {
+				<-aTX.Writable()
+			}
+		}
+	}()
+	var got []byte
+	buf := make([]byte, 128)
+	deadline := time.After(5 * time.Second)
+	for len(got) < len(want) {
+		if n := bRX.TryReadInto(buf); n > 0 {
+			got = append(got, buf[:n]...)
+			continue
+		}
+		select {
+		case <-bRX.Readable():
+		case <-deadline:
+			t.Fatalf("received %d of %d bytes", len(got), len(want))
+		}
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("received bytes differ from those sent")
+	}
+}
@@ -0,0 +1,64 @@
+package serial_raw
+
+import "devicecode-go/x/cbor"
+
+// MarshalCBOR and UnmarshalCBOR carry Params through config export and
+// import (hal/config/control), keyed by the JSON field names.
+func (p Params) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("Bus", p.Bus)
+		m.Text("Domain", p.Domain)
+		m.Text("Name", p.Name)
+		m.Uint("Baud", uint64(p.Baud))
+		m.Int("RXSize", int64(p.RXSize))
+		m.Int("TXSize", int64(p.TXSize))
+		m.Uint("IdleTimeoutMs", uint64(p.IdleTimeoutMs))
+	})
+}
+
+func (p *Params) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Bus":
+			return cbor.ReadText(d, &p.Bus)
+		case "Domain":
+			return cbor.ReadText(d, &p.Domain)
+		case "Name":
+			return cbor.ReadText(d, &p.Name)
+		case "Baud":
+			return cbor.ReadUint(d, &p.Baud)
+		case "RXSize":
+			return cbor.ReadInt(d, &p.RXSize)
+		case "TXSize":
+			return cbor.ReadInt(d, &p.TXSize)
+		case "IdleTimeoutMs":
+			return cbor.ReadUint(d, &p.IdleTimeoutMs)
+		}
+		return d.Skip()
+	})
+}
+
+func (p PairParams) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("Domain", p.Domain)
+		m.Text("A", p.A)
+		m.Text("B", p.B)
+		m.Int("BufSize", int64(p.BufSize))
+	})
+}
+
+func (p *PairParams) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Domain":
+			return cbor.ReadText(d, &p.Domain)
+		case "A":
+			return cbor.ReadText(d, &p.A)
+		case "B":
+			return cbor.ReadText(d, &p.B)
+		case "BufSize":
+			return cbor.ReadInt(d, &p.BufSize)
+		}
+		return d.Skip()
+	})
+}
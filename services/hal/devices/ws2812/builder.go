@@ -0,0 +1,81 @@
+package ws2812dev
+
+import (
+	"context"
+
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+func init() {
+	core.RegisterBuilder("ws2812", builder{})
+	core.RegisterParams[Params]("ws2812")
+}
+
+// MaxPixels bounds Count; a frame of that many takes about 30 ms to send.
+const MaxPixels = 1024
+
+// Params describe a WS2812 (NeoPixel) strip on one pin, driven by a PIO
+// state machine.
+type Params struct {
+	Pin        int
+	PinName    string // optional alias (e.g. "STATUS_BAR"); overrides Pin
+	Domain     string // default "io"
+	Name       string // default "strip"
+	Count      uint16 // pixels, 1..MaxPixels
+	Order      string // "grb" (default), "rgb" or "grbw"
+	Brightness uint8  // initial scale of every colour; 0 means 255
+}
+
+type builder struct{}
+
+func (builder) Build(ctx context.Context, in core.BuilderInput) (core.Device, error) {
+	p, ok := in.Params.(Params)
+	if !ok || p.Pin < 0 || p.Count == 0 || p.Count > MaxPixels {
+		return nil, errcode.InvalidParams
+	}
+	if p.Domain == "" {
+		p.Domain = "io"
+	}
+	if p.Name == "" {
+		p.Name = "strip"
+	}
+	if p.Order == "" {
+		p.Order = "grb"
+	}
+	if p.Brightness == 0 {
+		p.Brightness = 255
+	}
+	if wordOf(p.Order) == nil {
+		return nil, errcode.InvalidParams
+	}
+	pin, err := core.ResolvePin(in.Res.Reg, p.Pin, p.PinName)
+	if err != nil {
+		return nil, err
+	}
+	sm, err := in.Res.Reg.ClaimPIO(in.ID, program, []int{pin})
+	if err != nil {
+		return nil, err
+	}
+	d := &Device{
+		id:     in.ID,
+		p:      p,
+		pin:    pin,
+		sm:     sm,
+		word:   wordOf(p.Order),
+		pub:    in.Res.Pub,
+		reg:    in.Res.Reg,
+		a:      core.CapAddr{Domain: p.Domain, Kind: types.KindLEDStrip, Name: p.Name},
+		pixels: make([]uint32, p.Count),
+		level:  p.Brightness,
+		kick:   make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	core.RegisterVerb(&d.verbs, "set", d.set)
+	core.RegisterVerb(&d.verbs, "fill", d.fill)
+	core.RegisterVerb(&d.verbs, "brightness", d.brightness)
+	core.RegisterAction(&d.verbs, "clear", d.clear)
+	return d, nil
+}
@@ -0,0 +1,233 @@
+package ws2812dev
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+// program is the pico-examples ws2812 program: each bit is T1+T2+T3 = 10
+// cycles, high for 2 (a zero) or 7 (a one), so 800 kHz data needs an
+// 8 MHz state machine clock. Bits leave MSB first from the OSR.
+//
+//	.side_set 1
+//	.wrap_target
+//	bitloop: out x, 1       side 0 [2]
+//	         jmp !x do_zero side 1 [1]
+//	         jmp bitloop    side 1 [4]
+//	do_zero: nop            side 0 [4]
+//	.wrap
+var program = core.PIOProgram{
+	Name:       "ws2812",
+	Code:       []uint16{0x6221, 0x1123, 0x1400, 0xa442},
+	WrapTarget: 0,
+	Wrap:       3,
+	SideSet:    1,
+}
+
+const (
+	bitHz = 800_000
+	smHz  = bitHz * 10
+	latch = 300 * time.Microsecond // low time that ends a frame (WS2812B: > 280 µs)
+)
+
+// wordOf returns the FIFO word for a colour at a brightness in the strip's
+// wire order, left-aligned for the MSB-first shift, or nil for an unknown
+// order.
+func wordOf(order string) func(c uint32, level uint8) uint32 {
+	scale := func(c uint32, shift uint, level uint8) uint32 {
+		return (c >> shift & 0xff) * (uint32(level) + 1) >> 8
+	}
+	switch order {
+	case "grb":
+		return func(c uint32, l uint8) uint32 {
+			return scale(c, 8, l)<<24 | scale(c, 16, l)<<16 | scale(c, 0, l)<<8
+		}
+	case "rgb":
+		return func(c uint32, l uint8) uint32 {
+			return scale(c, 16, l)<<24 | scale(c, 8, l)<<16 | scale(c, 0, l)<<8
+		}
+	case "grbw":
+		return func(c uint32, l uint8) uint32 {
+			return scale(c, 8, l)<<24 | scale(c, 16, l)<<16 | scale(c, 0, l)<<8 | scale(c, 24, l)
+		}
+	}
+	return nil
+}
+
+type Device struct {
+	id   string
+	p    Params
+	pin  int
+	sm   core.PIOStateMachine
+	word func(c uint32, level uint8) uint32
+	pub  core.EventEmitter
+	reg  core.ResourceRegistry
+	a    core.CapAddr
+	up   bool // state machine configured and running
+
+	// Guarded by mu; the worker shows a copy.
+	mu     sync.Mutex
+	pixels []uint32
+	level  uint8
+
+	kick chan struct{}
+	quit chan struct{}
+	done chan struct{}
+
+	verbs core.VerbTable
+}
+
+func (d *Device) ID() string { return d.id }
+
+func (d *Device) Capabilities() []core.CapabilitySpec {
+	return []core.CapabilitySpec{{
+		Domain: d.a.Domain, Kind: types.KindLEDStrip, Name: d.a.Name,
+		Info: types.Info{SchemaVersion: 1, Driver: "ws2812", Detail: types.LEDStripInfo{
+			Pin: d.pin, Count: d.p.Count, Order: d.p.Order, SM: string(d.sm.ID()),
+		}},
+	}}
+}
+
+func (d *Device) Init(ctx context.Context) error {
+	thresh := uint8(24)
+	if d.p.Order == "grbw" {
+		thresh = 32
+	}
+	if err := d.sm.Configure(core.PIOConfig{
+		FreqHz: smHz, SideSetBase: uint8(d.pin), Outputs: []int{d.pin},
+		AutoPull: true, PullThreshold: thresh, JoinTX: true,
+	}); err != nil {
+		d.pub.Emit(core.Event{Addr: d.a, Err: string(errcode.MapDriverErr(err))})
+		close(d.done)
+		return nil
+	}
+	d.up = true
+	d.sm.Enable(true)
+	go d.run()
+	d.wake() // blank whatever the strip showed before boot
+	return nil
+}
+
+// Close blanks the strip and releases the state machine.
+func (d *Device) Close() error {
+	close(d.quit)
+	<-d.done
+	if d.up {
+		d.show(make([]uint32, len(d.pixels)), 0)
+	}
+	d.reg.ReleasePIO(d.id, d.sm.ID())
+	return nil
+}
+
+func (d *Device) Control(_ core.CapAddr, verb string, payload any) (core.EnqueueResult, error) {
+	return d.verbs.Dispatch(verb, payload)
+}
+
+func (d *Device) Verbs(core.CapAddr) []core.VerbSpec { return d.verbs.Specs() }
+
+func (d *Device) set(s types.LEDStripSet) (core.EnqueueResult, error) {
+	if len(s.Colors) == 0 || int(s.Start)+len(s.Colors) > len(d.pixels) {
+		return core.EnqueueResult{OK: false, Error: errcode.InvalidParams}, nil
+	}
+	d.mu.Lock()
+	copy(d.pixels[s.Start:], s.Colors)
+	d.mu.Unlock()
+	d.wake()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) fill(f types.LEDStripFill) (core.EnqueueResult, error) {
+	end := len(d.pixels)
+	if f.Count != 0 {
+		end = int(f.Start) + int(f.Count)
+	}
+	if int(f.Start) >= len(d.pixels) || end > len(d.pixels) {
+		return core.EnqueueResult{OK: false, Error: errcode.InvalidParams}, nil
+	}
+	d.mu.Lock()
+	for i := int(f.Start); i < end; i++ {
+		d.pixels[i] = f.Color
+	}
+	d.mu.Unlock()
+	d.wake()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) brightness(b types.LEDStripBrightness) (core.EnqueueResult, error) {
+	d.mu.Lock()
+	d.level = b.Level
+	d.mu.Unlock()
+	d.wake()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) clear() (core.EnqueueResult, error) {
+	d.mu.Lock()
+	clear(d.pixels)
+	d.mu.Unlock()
+	d.wake()
+	return core.EnqueueResult{OK: true}, nil
+}
+
+func (d *Device) wake() {
+	select {
+	case d.kick <- struct{}{}:
+	default:
+	}
+}
+
+// run shows the latest frame after each change; changes made while a
+// frame is being sent are coalesced into the next.
+func (d *Device) run() {
+	defer close(d.done)
+	frame := make([]uint32, len(d.pixels))
+	last := types.LEDStripValue{Lit: 0xffff}
+	for {
+		select {
+		case <-d.quit:
+			return
+		case <-d.kick:
+		}
+		d.mu.Lock()
+		copy(frame, d.pixels)
+		level := d.level
+		d.mu.Unlock()
+
+		d.show(frame, level)
+		v := types.LEDStripValue{Lit: lit(frame), Brightness: level}
+		if v != last {
+			last = v
+			d.pub.Emit(core.Event{Addr: d.a, Payload: v})
+		}
+	}
+}
+
+// show sends one frame and holds the line low long enough to latch it.
+// It spins on the FIFO rather than yielding: another goroutine running
+// longer than the FIFO's eight pixels (240 µs) would let it run dry, and
+// a line held low for 50 µs latches half a frame.
+func (d *Device) show(frame []uint32, level uint8) {
+	for _, c := range frame {
+		w := d.word(c, level)
+		for !d.sm.TryPut(w) {
+		}
+	}
+	for !d.sm.Drained() {
+	}
+	time.Sleep(latch)
+}
+
+func lit(frame []uint32) uint16 {
+	var n uint16
+	for _, c := range frame {
+		if c != 0 {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,135 @@
+package ws2812dev
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+// fakeSM records every word pushed; its FIFO is always drained.
+type fakeSM struct {
+	mu    sync.Mutex
+	cfg   core.PIOConfig
+	words []uint32
+}
+
+func (s *fakeSM) ID() core.ResourceID { return "pio0.sm0" }
+func (s *fakeSM) Configure(c core.PIOConfig) error {
+	s.cfg = c
+	return nil
+}
+func (s *fakeSM) Enable(bool)            {}
+func (s *fakeSM) TryGet() (uint32, bool) { return 0, false }
+func (s *fakeSM) Drained() bool          { return true }
+func (s *fakeSM) TryPut(w uint32) bool {
+	s.mu.Lock()
+	s.words = append(s.words, w)
+	s.mu.Unlock()
+	return true
+}
+
+// frame returns the last n words pushed.
+func (s *fakeSM) frame(n int) []uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]uint32(nil), s.words[len(s.words)-n:]...)
+}
+
+type fakeReg struct{ core.ResourceRegistry }
+
+func (fakeReg) ReleasePIO(string, core.ResourceID) {}
+
+type values chan core.Event
+
+func (c values) Emit(ev core.Event) bool { c <- ev; return true }
+
+func (c values) next(t *testing.T) types.LEDStripValue {
+	t.Helper()
+	select {
+	case ev := <-c:
+		v, ok := ev.Payload.(types.LEDStripValue)
+		if !ok {
+			t.Fatalf("event %+v", ev)
+		}
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("no value")
+	}
+	return types.LEDStripValue{}
+}
+
+func newStrip(t *testing.T, order string, n int) (*Device, *fakeSM, values) {
+	sm, ev := &fakeSM{}, make(values, 16)
+	d := &Device{
+		id: "strip", p: Params{Count: uint16(n), Order: order}, pin: 7, sm: sm,
+		word: wordOf(order), pub: ev, reg: fakeReg{},
+		a:      core.CapAddr{Domain: "io", Kind: types.KindLEDStrip, Name: "strip"},
+		pixels: make([]uint32, n), level: 255,
+		kick: make(chan struct{}, 1), quit: make(chan struct{}), done: make(chan struct{}),
+	}
+	if err := d.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+	if v := ev.next(t); v.Lit != 0 || v.Brightness != 255 {
+		t.Fatalf("initial %+v", v)
+	}
+	return d, sm, ev
+}
+
+func TestStrip_SendsGRBScaledByBrightness(t *testing.T) {
+	d, sm, ev := newStrip(t, "grb", 3)
+	if sm.cfg.PullThreshold != 24 || !sm.cfg.AutoPull || sm.cfg.SideSetBase != 7 || sm.cfg.FreqHz != 8_000_000 {
+		t.Fatalf("config %+v", sm.cfg)
+	}
+
+	if r, _ := d.set(types.LEDStripSet{Start: 1, Colors: []uint32{0x102030, 0xff0000}}); !r.OK {
+		t.Fatalf("set %+v", r)
+	}
+	if v := ev.next(t); v.Lit != 2 {
+		t.Fatalf("value %+v", v)
+	}
+	if got := sm.frame(3); got[0] != 0 || got[1] != 0x20103000 || got[2] != 0x00ff0000 {
+		t.Fatalf("frame %08x", got)
+	}
+
+	d.brightness(types.LEDStripBrightness{Level: 127})
+	if v := ev.next(t); v.Brightness != 127 {
+		t.Fatalf("value %+v", v)
+	}
+	if got := sm.frame(3); got[2] != 0x007f0000 {
+		t.Fatalf("dimmed %08x", got)
+	}
+}
+
+func TestStrip_FillAndBounds(t *testing.T) {
+	d, sm, ev := newStrip(t, "grbw", 4)
+	if sm.cfg.PullThreshold != 32 {
+		t.Fatalf("threshold %d", sm.cfg.PullThreshold)
+	}
+	d.fill(types.LEDStripFill{Color: 0x40000000, Start: 2})
+	if v := ev.next(t); v.Lit != 2 {
+		t.Fatalf("value %+v", v)
+	}
+	if got := sm.frame(4); got[1] != 0 || got[3] != 0x40 {
+		t.Fatalf("frame %08x", got)
+	}
+
+	for _, r := range []core.EnqueueResult{
+		first(d.set(types.LEDStripSet{Start: 3, Colors: []uint32{1, 2}})),
+		first(d.set(types.LEDStripSet{})),
+		first(d.fill(types.LEDStripFill{Start: 4})),
+		first(d.fill(types.LEDStripFill{Start: 1, Count: 4})),
+	} {
+		if r.OK || r.Error != errcode.InvalidParams {
+			t.Fatalf("accepted %+v", r)
+		}
+	}
+}
+
+func first(r core.EnqueueResult, _ error) core.EnqueueResult { return r }
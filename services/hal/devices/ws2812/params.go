@@ -0,0 +1,39 @@
+package ws2812dev
+
+import "devicecode-go/x/cbor"
+
+// MarshalCBOR and UnmarshalCBOR carry Params through config export and
+// import (hal/config/control), keyed by the JSON field names.
+func (p Params) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("Pin", int64(p.Pin))
+		m.Text("PinName", p.PinName)
+		m.Text("Domain", p.Domain)
+		m.Text("Name", p.Name)
+		m.Uint("Count", uint64(p.Count))
+		m.Text("Order", p.Order)
+		m.Uint("Brightness", uint64(p.Brightness))
+	})
+}
+
+func (p *Params) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Pin":
+			return cbor.ReadInt(d, &p.Pin)
+		case "PinName":
+			return cbor.ReadText(d, &p.PinName)
+		case "Domain":
+			return cbor.ReadText(d, &p.Domain)
+		case "Name":
+			return cbor.ReadText(d, &p.Name)
+		case "Count":
+			return cbor.ReadUint(d, &p.Count)
+		case "Order":
+			return cbor.ReadText(d, &p.Order)
+		case "Brightness":
+			return cbor.ReadUint(d, &p.Brightness)
+		}
+		return d.Skip()
+	})
+}
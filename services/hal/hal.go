@@ -6,16 +6,41 @@ import (
 	"devicecode-go/bus"
 	"devicecode-go/services/hal/internal/core"
 	"devicecode-go/services/hal/internal/provider"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
 )
 
-func Run(ctx context.Context, conn *bus.Connection) {
+// Run starts the HAL on conn. clk is its time source (nil: clock.Real),
+// shared with the devices through core.Resources.
+func Run(ctx context.Context, conn *bus.Connection, clk clock.Clock) {
 	res := provider.NewResources()
+	res.Clock = clk
 
-	// If a compile-time setup is present, publish it as the initial config.
-	if initCfg := provider.InitialHALConfig; len(initCfg.Devices) > 0 { // new
+	// A config imported over the bus and stored by the provider takes
+	// precedence, reactor thresholds included; otherwise publish the
+	// compile-time setup, if present.
+	if cfg, rc, ok := storedConfig(res); ok {
+		if rc != nil {
+			conn.Publish(conn.NewMessage(core.T("config", "reactor"), *rc, true))
+		}
+		conn.Publish(conn.NewMessage(core.T("config", "hal"), cfg, true))
+	} else if initCfg := provider.InitialHALConfig; len(initCfg.Devices) > 0 { // new
 		conn.Publish(conn.NewMessage(core.T("config", "hal"), initCfg, true))
 	}
 
 	h := core.NewHAL(conn, res)
 	h.Run(ctx)
 }
+
+func storedConfig(res core.Resources) (types.HALConfig, *types.ReactorConfig, bool) {
+	cs, ok := res.Reg.(core.ConfigStore)
+	if !ok {
+		return types.HALConfig{}, nil, false
+	}
+	blob, ok := cs.LoadConfig()
+	if !ok {
+		return types.HALConfig{}, nil, false
+	}
+	cfg, rc, err := core.DecodeConfig(blob)
+	return cfg, rc, err == nil
+}
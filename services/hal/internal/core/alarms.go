@@ -0,0 +1,159 @@
+package core
+
+import (
+	"time"
+
+	"devicecode-go/types"
+)
+
+// ---------------- Declarative alarms (single-threaded in HAL loop) ----------------
+//
+// A rule watches one field of the retained values of every capability whose
+// address matches its source pattern ("+" matches any token). Each matching
+// capability is tracked separately; the alarm is active while any of them is
+// in the raised condition. Debounce is evaluated on sample arrival: a
+// condition must still hold on a sample at least DebounceMs after it was first
+// seen before the per-capability state flips.
+
+type alarmTrack struct {
+	active  bool
+	pending bool  // candidate transition observed
+	since   int64 // ns, when the candidate was first seen
+}
+
+type alarmRule struct {
+	spec   types.AlarmSpec
+	track  map[capKey]*alarmTrack
+	active bool
+}
+
+func validAlarmOp(op types.AlarmOp) bool {
+	switch op {
+	case types.AlarmGT, types.AlarmGE, types.AlarmLT, types.AlarmLE:
+		return true
+	}
+	return false
+}
+
+func matchTok(pat, s string) bool { return pat == "+" || pat == s }
+
+func (r *alarmRule) matches(ck capKey) bool {
+	src := r.spec.Source
+	return matchTok(src.Domain, ck.domain) && matchTok(string(src.Kind), string(ck.kind)) && matchTok(src.Name, ck.name)
+}
+
+// raise reports whether v is in the raise condition; clear reports whether v
+// is past the hysteresis band on the other side.
+func (r *alarmRule) raise(v int64) bool {
+	t := r.spec.Threshold
+	switch r.spec.Op {
+	case types.AlarmGT:
+		return v > t
+	case types.AlarmGE:
+		return v >= t
+	case types.AlarmLT:
+		return v < t
+	case types.AlarmLE:
+		return v <= t
+	}
+	return false
+}
+
+func (r *alarmRule) clear(v int64) bool {
+	t, hy := r.spec.Threshold, r.spec.Hysteresis
+	switch r.spec.Op {
+	case types.AlarmGT, types.AlarmGE:
+		return !r.raise(v) && v <= t-hy
+	default:
+		return !r.raise(v) && v >= t+hy
+	}
+}
+
+// alarmUpsert installs or replaces a rule by name. Replacing a rule resets
+// its state and publishes it as inactive.
+func (h *HAL) alarmUpsert(spec types.AlarmSpec) {
+	if spec.Name == "" || spec.Field == "" || !validAlarmOp(spec.Op) {
+		return
+	}
+	if spec.Hysteresis < 0 {
+		spec.Hysteresis = 0
+	}
+	r := &alarmRule{spec: spec, track: make(map[capKey]*alarmTrack)}
+	for i := range h.alarms {
+		if h.alarms[i].spec.Name == spec.Name {
+			h.alarms[i] = r
+			h.pubAlarmState(r, 0, types.CapabilityAddress{}, h.clk.Now().UnixNano())
+			return
+		}
+	}
+	h.alarms = append(h.alarms, r)
+	h.pubAlarmState(r, 0, types.CapabilityAddress{}, h.clk.Now().UnixNano())
+}
+
+// alarmEval feeds a retained value emission through all matching rules.
+func (h *HAL) alarmEval(ck capKey, payload any, ts int64) {
+	if len(h.alarms) == 0 {
+		return
+	}
+	f, ok := payload.(types.Fielder)
+	if !ok {
+		return
+	}
+	for _, r := range h.alarms {
+		if !r.matches(ck) {
+			continue
+		}
+		v, ok := f.Field(r.spec.Field)
+		if !ok {
+			continue
+		}
+		tr := r.track[ck]
+		if tr == nil {
+			tr = &alarmTrack{}
+			r.track[ck] = tr
+		}
+		var flip bool
+		if tr.active {
+			flip = r.clear(v)
+		} else {
+			flip = r.raise(v)
+		}
+		if !flip {
+			tr.pending = false
+			continue
+		}
+		if !tr.pending {
+			tr.pending, tr.since = true, ts
+		}
+		if ts-tr.since < int64(r.spec.DebounceMs)*int64(time.Millisecond) {
+			continue
+		}
+		tr.active, tr.pending = !tr.active, false
+
+		// Aggregate across matching capabilities.
+		on := false
+		for _, t := range r.track {
+			if t.active {
+				on = true
+				break
+			}
+		}
+		if on == r.active {
+			continue
+		}
+		r.active = on
+		src := types.CapabilityAddress{Domain: ck.domain, Kind: ck.kind, Name: ck.name}
+		st := h.pubAlarmState(r, v, src, ts)
+		tag := "cleared"
+		if r.active {
+			tag = "raised"
+		}
+		h.conn.Publish(h.conn.NewMessage(alarmEvent(r.spec.Name, tag), st, false))
+	}
+}
+
+func (h *HAL) pubAlarmState(r *alarmRule, v int64, src types.CapabilityAddress, ts int64) types.AlarmState {
+	st := types.AlarmState{Name: r.spec.Name, Active: r.active, Value: v, Source: src, TS: ts}
+	h.conn.Publish(h.conn.NewMessage(alarmState(r.spec.Name), st, true))
+	return st
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"devicecode-go/bus"
+	"devicecode-go/types"
+)
+
+// ---------------- Capability aliases (single-threaded in HAL loop) ----------------
+//
+// An alias keeps a renamed capability reachable at its old address during
+// a hardware transition. Everything published for the target (info, verbs,
+// status, value, events) is published again under each of its aliases,
+// with the same sequence number, and a control sent to an alias is handled
+// as if sent to the target. Aliases are upserted by address from
+// config/hal; a registered capability always shadows an alias of the same
+// address, and an alias cannot point at another alias.
+
+// aliasUpsert installs or repoints an alias and replays the target's
+// retained topics under it.
+func (h *HAL) aliasUpsert(a types.CapAlias) {
+	from := capKey{domain: a.Alias.Domain, kind: a.Alias.Kind, name: a.Alias.Name}
+	to := capKey{domain: a.Target.Domain, kind: a.Target.Kind, name: a.Target.Name}
+	if from.domain == "" || from.kind == "" || from.name == "" || from == to {
+		return
+	}
+	if _, real := h.capIndex[from]; real {
+		return
+	}
+	if _, chained := h.aliasTo[to]; chained || len(h.aliasOf[from]) > 0 {
+		return
+	}
+	if old, ok := h.aliasTo[from]; ok {
+		if old == to {
+			return
+		}
+		h.aliasOf[old] = removeKey(h.aliasOf[old], from)
+		h.catalogAliases(old)
+	}
+	h.aliasTo[from] = to
+	h.aliasOf[to] = append(h.aliasOf[to], from)
+	h.catalogAliases(to)
+	for _, m := range h.conn.Retained(capBase(to.domain, to.kind, to.name).Append("#")) {
+		h.mirrorTo(from, m)
+	}
+}
+
+// resolveAlias returns the capability behind addr, or addr itself.
+func (h *HAL) resolveAlias(addr CapAddr) CapAddr {
+	ck := capKey{domain: addr.Domain, kind: addr.Kind, name: addr.Name}
+	if to, ok := h.aliasTo[ck]; ok {
+		if _, real := h.capIndex[ck]; !real {
+			return CapAddr{Domain: to.domain, Kind: to.kind, Name: to.name}
+		}
+	}
+	return addr
+}
+
+// mirror republishes m, just published for ck, under ck's aliases.
+func (h *HAL) mirror(ck capKey, m *bus.Message) {
+	for _, a := range h.aliasOf[ck] {
+		h.mirrorTo(a, m)
+	}
+}
+
+func (h *HAL) mirrorTo(a capKey, m *bus.Message) {
+	if _, real := h.capIndex[a]; real {
+		return
+	}
+	am := h.conn.NewMessage(aliasTopic(a, m.Topic), m.Payload, m.Retained)
+	am.Seq, am.Mono = m.Seq, m.Mono
+	h.conn.Publish(am)
+}
+
+// mirrorValue is pubValue for ck's aliases.
+func (h *HAL) mirrorValue(ck capKey, payload any, seq uint32, mono int64) {
+	for _, a := range h.aliasOf[ck] {
+		if _, real := h.capIndex[a]; !real {
+			h.valueOf(a).Publish(payload, seq, mono)
+		}
+	}
+}
+
+// catalogAliases records ck's aliases in its catalogue entry, if it has
+// one; the caller republishes the catalogue.
+func (h *HAL) catalogAliases(ck capKey) {
+	e := h.catalog[ck]
+	if e == nil {
+		return
+	}
+	// A fresh slice: published catalogues share the old one.
+	var as []types.CapabilityAddress
+	for _, a := range h.aliasOf[ck] {
+		as = append(as, types.CapabilityAddress{Domain: a.domain, Kind: a.kind, Name: a.name})
+	}
+	e.Aliases = as
+}
+
+// aliasTopic is tp, a topic under hal/cap/<target>/, moved under a.
+// Control-done topics are per request, so they are not interned.
+func aliasTopic(a capKey, tp bus.Topic) bus.Topic {
+	toks := make([]bus.Token, 0, tp.Len())
+	toks = append(toks, "hal", "cap", a.domain, string(a.kind), a.name)
+	for i := 5; i < tp.Len(); i++ {
+		toks = append(toks, tp.At(i))
+	}
+	if tp.Len() > 6 && tp.At(5) == "event" && tp.At(6) == "control_done" {
+		return bus.TNoIntern(toks...)
+	}
+	return T(toks...)
+}
+
+func removeKey(ks []capKey, k capKey) []capKey {
+	for i := range ks {
+		if ks[i] == k {
+			return append(ks[:i], ks[i+1:]...)
+		}
+	}
+	return ks
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"devicecode-go/bus"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+func TestAlias_MirrorsTopicsAndRoutesControls(t *testing.T) {
+	h := newTestHAL(&fakeReg{}, clock.NewFake(t0))
+	c, old := fakeCap("status-led"), fakeCap("button-led")
+	addr := func(cs CapabilitySpec) types.CapabilityAddress {
+		return types.CapabilityAddress{Domain: cs.Domain, Kind: cs.Kind, Name: cs.Name}
+	}
+	other := fakeCap("other")
+	cfg := types.HALConfig{
+		Devices: []types.HALDevice{addDev(t, &fakeDev{id: "led", caps: []CapabilitySpec{c, other}})},
+		Aliases: []types.CapAlias{
+			{Alias: addr(old), Target: addr(c)},
+			{Alias: addr(other), Target: addr(c)}, // a registered address: ignored
+		},
+	}
+	if errs := h.applyConfig(context.Background(), cfg); len(errs) != 0 {
+		t.Fatalf("apply: %+v", errs)
+	}
+
+	// Replayed on install, then mirrored with the target's sequence.
+	if len(h.conn.Retained(capInfo(old.Domain, old.Kind, old.Name))) != 1 ||
+		len(h.conn.Retained(capVerbs(old.Domain, old.Kind, old.Name))) != 1 {
+		t.Fatal("info and verbs not replayed under the alias")
+	}
+	h.handleEvent(Event{Addr: CapAddr{Domain: c.Domain, Kind: c.Kind, Name: c.Name}, Payload: types.TemperatureValue{DeciC: 5}})
+	want := h.conn.Retained(capValue(c.Domain, c.Kind, c.Name))[0]
+	got := h.conn.Retained(capValue(old.Domain, old.Kind, old.Name))
+	if len(got) != 1 || got[0].Payload != want.Payload || got[0].Seq != want.Seq {
+		t.Fatalf("alias value %+v, target %+v", got, want)
+	}
+	if st := h.conn.Retained(capStatus(old.Domain, old.Kind, old.Name)); len(st) != 1 ||
+		st[0].Payload.(types.CapabilityStatus).Link != types.LinkUp {
+		t.Fatalf("alias status %+v", st)
+	}
+	if len(h.conn.Retained(capValue(other.Domain, other.Kind, other.Name))) != 0 {
+		t.Fatal("alias published over a registered capability")
+	}
+
+	// Controls sent to the alias reach the target.
+	replies := h.conn.Subscribe(bus.T("reply"))
+	m := h.conn.NewMessage(capCtrl(old.Domain, old.Kind, old.Name, "poll_start"), types.PollStart{Verb: "read", IntervalMs: 1000}, false)
+	m.ReplyTo = bus.T("reply")
+	h.handleControl(m)
+	if r := recv(t, replies).Payload; r != (types.OKReply{OK: true}) {
+		t.Fatalf("reply %+v", r)
+	}
+	if _, ok := h.pollItems[pollKey{d: c.Domain, k: c.Kind, n: c.Name, verb: "read"}]; !ok || len(h.pollItems) != 1 {
+		t.Fatalf("poller not on the target: %v", h.pollItems)
+	}
+
+	cat := h.conn.Retained(topicCatalog())[0].Payload.(types.Catalog)
+	for _, e := range cat.Caps {
+		switch e.Name {
+		case c.Name:
+			if len(e.Aliases) != 1 || e.Aliases[0] != addr(old) {
+				t.Fatalf("catalogue aliases %+v", e.Aliases)
+			}
+		case old.Name:
+			t.Fatal("alias catalogued as a capability")
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+)
+
+// ---------------- Configuration apply ----------------
+//
+// A config/hal is applied in two phases so a bad entry cannot take down
+// the devices already running:
+//
+//  1. Validate: every new device is built, which claims its pins and buses
+//     and so detects resource conflicts, and its capability addresses are
+//     checked against the running set and each other.
+//  2. Commit: capabilities are registered and devices started.
+//
+// If anything fails, the new devices are rolled back (claims released, or
+// Close for those already started) and the whole config is rejected with
+// the list of problems. Devices whose ID already runs are left untouched,
+// as before.
+
+// applyConfig applies cfg, or rolls it back and returns why it was rejected.
+func (h *HAL) applyConfig(ctx context.Context, cfg types.HALConfig) []types.ConfigError {
+	// Bus wiring first, so device builders can claim the controllers.
+	if cfg.Buses != nil {
+		if bp, ok := h.res.Reg.(BusPlanner); ok {
+			bp.ApplyBuses(*cfg.Buses)
+		}
+	}
+
+	var (
+		errs  []types.ConfigError
+		built []Device
+		ids   = map[string]bool{}
+		cfgOf = map[string]types.HALDevice{}
+		caps  = map[capKey]string{}
+		xf    = map[string][]xformStep{} // compiled transforms by device ID
+	)
+	reject := func(dc types.HALDevice, err error) {
+		detail, _ := errcode.DetailOf(err)
+		errs = append(errs, types.ConfigError{ID: dc.ID, Type: dc.Type, Error: string(errcode.Of(err)), Detail: detail})
+	}
+
+	// Phase 1: build and check.
+	for i := range cfg.Devices {
+		dc := cfg.Devices[i]
+		if _, exists := h.dev[dc.ID]; exists {
+			continue
+		}
+		if dc.ID == "" || ids[dc.ID] {
+			reject(dc, &errcode.E{C: errcode.Conflict, Msg: "missing or duplicate device id"})
+			continue
+		}
+		if _, late := h.abandoned[dc.ID]; late {
+			reject(dc, &errcode.E{C: errcode.Busy, Msg: "rolled-back instance still initialising"})
+			continue
+		}
+		ids[dc.ID] = true
+		steps, err := compileTransforms(dc.Transforms)
+		if err != nil {
+			reject(dc, err)
+			continue
+		}
+		b, ok := lookupBuilder(dc.Type)
+		if !ok {
+			reject(dc, &errcode.E{C: errcode.Unsupported, Msg: "no builder for type " + dc.Type})
+			continue
+		}
+		dev, err := b.Build(ctx, BuilderInput{ID: dc.ID, Type: dc.Type, Params: dc.Params, Res: h.res})
+		if err != nil {
+			// A builder may fail after some claims; drop whatever it holds.
+			h.releaseClaims(dc.ID)
+			reject(dc, err)
+			continue
+		}
+		built = append(built, dev)
+		cfgOf[dev.ID()] = dc
+		if len(steps) > 0 {
+			xf[dev.ID()] = steps
+		}
+		for _, cs := range dev.Capabilities() {
+			ck := capKey{domain: cs.Domain, kind: cs.Kind, name: cs.Name}
+			if cs.Domain == "" || cs.Kind == "" || cs.Name == "" {
+				reject(dc, &errcode.E{C: errcode.InvalidParams, Msg: "empty capability address"})
+				continue
+			}
+			owner, taken := h.capIndex[ck]
+			if !taken {
+				owner, taken = caps[ck]
+			}
+			if taken {
+				reject(dc, &errcode.E{C: errcode.Conflict,
+					Msg: "capability " + cs.Domain + "/" + string(cs.Kind) + "/" + cs.Name + " owned by " + owner})
+				continue
+			}
+			caps[ck] = dev.ID()
+		}
+	}
+	if len(errs) > 0 {
+		for _, dev := range built {
+			h.releaseClaims(dev.ID())
+		}
+		return errs
+	}
+
+	// Phase 2: register and start. Devices that share no bus start
+	// concurrently; one that overruns its init deadline is left starting
+	// and reported per capability rather than holding up the rest.
+	for _, dev := range built {
+		h.dev[dev.ID()] = dev
+		if steps, ok := xf[dev.ID()]; ok {
+			h.xforms[dev.ID()] = steps
+		}
+		if ms := cfgOf[dev.ID()].ControlTimeoutMs; ms > 0 {
+			h.ctrlTimeout[dev.ID()] = time.Duration(ms) * time.Millisecond
+		}
+		for _, cs := range dev.Capabilities() {
+			if err := h.registerCap(dev.ID(), cs); err != nil {
+				reject(cfgOf[dev.ID()], err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		for _, dev := range built {
+			h.releaseClaims(dev.ID())
+			h.unregisterDevice(dev.ID())
+		}
+		h.pubCatalog()
+		return errs
+	}
+	runs := h.initDevices(ctx, built, cfg.Devices)
+	for _, r := range runs {
+		if r.err != nil && !r.timedOut {
+			reject(r.dc, r.err)
+		}
+	}
+	if len(errs) > 0 {
+		for _, r := range runs {
+			switch {
+			case r.timedOut:
+				// Claims stay held until the late Init returns.
+				r.abandoned = true
+				h.abandoned[r.dev.ID()] = r
+				go awaitInit(ctx, h, r)
+			case r.err == nil:
+				_ = r.dev.Close()
+			default:
+				h.releaseClaims(r.dev.ID())
+			}
+			h.unregisterDevice(r.dev.ID())
+		}
+		h.pubCatalog()
+		return errs
+	}
+	now, mono := h.clk.Now().UnixNano(), h.mono()
+	for _, r := range runs {
+		if !r.timedOut {
+			continue
+		}
+		for _, cs := range r.dev.Capabilities() {
+			h.pubStatus(cs.Domain, cs.Kind, cs.Name, now, mono, "init_timeout")
+		}
+		go awaitInit(ctx, h, r)
+	}
+
+	// Apply declarative pollers from config after all capabilities are registered.
+	for i := range cfg.Pollers {
+		ps := cfg.Pollers[i]
+		if ps.IntervalMs == 0 || ps.Verb == "" || ps.Domain == "" || ps.Kind == "" || ps.Name == "" {
+			continue
+		}
+		h.pollUpsert(
+			ps.Domain, ps.Kind, ps.Name, ps.Verb,
+			time.Duration(ps.IntervalMs)*time.Millisecond,
+			time.Duration(ps.JitterMs)*time.Millisecond,
+			phaseOf(ps.PhaseMs),
+		)
+	}
+	// Alarm rules are upserted by name.
+	for i := range cfg.Alarms {
+		h.alarmUpsert(cfg.Alarms[i])
+	}
+	// So are groups.
+	for i := range cfg.Groups {
+		h.groupUpsert(cfg.Groups[i])
+	}
+	// And aliases, by address.
+	for i := range cfg.Aliases {
+		h.aliasUpsert(cfg.Aliases[i])
+	}
+	h.pubCatalog()
+	return nil
+}
+
+// releaseClaims drops the resources of a device that never started.
+func (h *HAL) releaseClaims(devID string) {
+	if cr, ok := h.res.Reg.(ClaimReleaser); ok {
+		cr.ReleaseAll(devID)
+	}
+}
+
+// unregisterDevice forgets a rolled-back device and its capabilities. Their
+// retained status is left down with error "config_rejected".
+func (h *HAL) unregisterDevice(devID string) {
+	delete(h.dev, devID)
+	delete(h.lastDevEmit, devID)
+	delete(h.backoff, devID)
+	delete(h.xforms, devID)
+	delete(h.ctrlTimeout, devID)
+	for ck, owner := range h.capIndex {
+		if owner != devID {
+			continue
+		}
+		h.pubCap(ck, capStatus(ck.domain, ck.kind, ck.name),
+			types.CapabilityStatus{Link: types.LinkDown, Error: "config_rejected", TS: h.clk.Now().UnixNano()}, true, h.mono())
+		h.groupStatus(ck, "config_rejected", h.clk.Now().UnixNano())
+		delete(h.capIndex, ck)
+		delete(h.catalog, ck)
+		delete(h.lastStatus, ck)
+		delete(h.lastEmit, ck)
+		delete(h.quar, ck)
+		delete(h.storm, ck)
+	}
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"time"
+
+	"devicecode-go/services/metrics"
+)
+
+// ---------------- Poll back-off for failing devices ----------------
+//
+// A device that keeps reporting errors (e.g. a sensor unplugged from I2C)
+// would otherwise be polled at full rate, each attempt costing bus time and
+// a timeout. A poll that finds the device has reported an error, and no
+// good value, since the previous one counts as a failure. After
+// backoffAfter consecutive failures HAL skips that device's polls for an
+// exponentially growing, jittered hold-off; the first good value resets
+// it. The level is shown in CapabilityStatus.Backoff. Controls from
+// clients are not held off.
+
+const (
+	backoffAfter    = 3 // consecutive failed polls before holding off
+	backoffBase     = time.Second
+	backoffMax      = time.Minute
+	backoffMaxLevel = 7 // 1s·2^6 = 64s, capped at backoffMax
+)
+
+var mPollBackoffSkips = metrics.NewCounter("hal.poll.backoff_skips")
+
+type devBackoff struct {
+	erred bool // error reported since the last poll
+	fails int
+	level uint8
+	until int64 // Unix ns; polls before this are skipped
+}
+
+// noteDevErr records an error report from devID.
+func (h *HAL) noteDevErr(devID string) {
+	if devID == "" {
+		return
+	}
+	b := h.backoff[devID]
+	if b == nil {
+		b = &devBackoff{}
+		h.backoff[devID] = b
+	}
+	b.erred = true
+}
+
+// noteDevOK clears devID's back-off on a good value.
+func (h *HAL) noteDevOK(devID string) {
+	if h.backoff[devID] != nil {
+		delete(h.backoff, devID)
+	}
+}
+
+// pollAllowed is called as a poll for devID falls due. It scores the
+// previous poll and reports whether this one should be sent.
+func (h *HAL) pollAllowed(devID string, now int64) bool {
+	b := h.backoff[devID]
+	if b == nil {
+		return true
+	}
+	if now < b.until {
+		return false
+	}
+	if !b.erred {
+		return true
+	}
+	b.erred = false
+	b.fails++
+	if b.fails < backoffAfter {
+		return true
+	}
+	if b.level < backoffMaxLevel {
+		b.level++
+	}
+	d := backoffBase << (b.level - 1)
+	if d > backoffMax {
+		d = backoffMax
+	}
+	// Jitter over the upper half so devices that failed together (a shared
+	// bus) do not retry in lockstep.
+	b.until = now + int64(h.jittered(d/2, d/2))
+	h.pubBackoff(devID, now)
+	return false
+}
+
+// pubBackoff republishes the degraded statuses of devID's capabilities with
+// the new level; no error will arrive to do it while polls are held off.
+func (h *HAL) pubBackoff(devID string, now int64) {
+	for ck, owner := range h.capIndex {
+		if st := h.lastStatus[ck]; owner == devID && st.err != "" {
+			h.pubStatus(ck.domain, ck.kind, ck.name, now, h.mono(), st.err)
+		}
+	}
+}
+
+func (h *HAL) backoffLevel(devID string) uint8 {
+	if b := h.backoff[devID]; b != nil {
+		return b.level
+	}
+	return 0
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+)
+
+// ---------------- Device bring-up ----------------
+//
+// New devices are started in groups: devices that share a bus (from the
+// registry's ResourceLister) start one after another in config order, and
+// groups run concurrently, so a device hanging on one I²C bus does not
+// delay the others. HAL waits for each Init up to the device's deadline
+// (HALDevice.InitTimeoutMs, default defaultInitTimeout). Init still gets
+// the HAL context, since devices may tie their workers to it; an overrun
+// is simply no longer waited for here. Its result comes back to the HAL
+// loop later (finishInit), and a device rolled back meanwhile keeps its
+// claims, and its ID, until then: its Init may still be driving the bus.
+
+const defaultInitTimeout = 2 * time.Second
+
+type initRun struct {
+	dev       Device
+	dc        types.HALDevice
+	timeout   time.Duration
+	done      chan error // Init's result; buffered
+	err       error
+	timedOut  bool
+	abandoned bool // rolled back while Init was still running
+}
+
+// initDevices starts built and returns one run per device, in order.
+func (h *HAL) initDevices(ctx context.Context, built []Device, cfgs []types.HALDevice) []*initRun {
+	byID := make(map[string]types.HALDevice, len(cfgs))
+	for _, dc := range cfgs {
+		byID[dc.ID] = dc
+	}
+	runs := make([]*initRun, len(built))
+	for i, dev := range built {
+		dc := byID[dev.ID()]
+		d := defaultInitTimeout
+		if dc.InitTimeoutMs > 0 {
+			d = time.Duration(dc.InitTimeoutMs) * time.Millisecond
+		}
+		runs[i] = &initRun{dev: dev, dc: dc, timeout: d, done: make(chan error, 1)}
+	}
+
+	var wg sync.WaitGroup
+	for _, g := range h.busGroups(runs) {
+		wg.Add(1)
+		go func(g []*initRun) {
+			defer wg.Done()
+			for _, r := range g {
+				go func(r *initRun) { r.done <- r.dev.Init(ctx) }(r)
+				t := h.clk.NewTimer(r.timeout)
+				select {
+				case r.err = <-r.done:
+				case <-t.C():
+					r.err, r.timedOut = errcode.Timeout, true
+				}
+				t.Stop()
+			}
+		}(g)
+	}
+	wg.Wait()
+	return runs
+}
+
+// busGroups partitions runs so that devices sharing any bus are in one
+// group. Devices without a bus (or without a ResourceLister) are alone.
+func (h *HAL) busGroups(runs []*initRun) [][]*initRun {
+	parent := make([]int, len(runs))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	if rl, ok := h.res.Reg.(ResourceLister); ok {
+		idx := make(map[string]int, len(runs))
+		for i, r := range runs {
+			idx[r.dev.ID()] = i
+		}
+		for _, b := range rl.Resources().Buses {
+			first := -1
+			for _, u := range b.Users {
+				i, ok := idx[u]
+				if !ok {
+					continue
+				}
+				if first < 0 {
+					first = i
+				} else {
+					parent[find(i)] = find(first)
+				}
+			}
+		}
+	}
+	var groups [][]*initRun
+	slot := map[int]int{}
+	for i, r := range runs {
+		root := find(i)
+		g, ok := slot[root]
+		if !ok {
+			g = len(groups)
+			slot[root] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], r)
+	}
+	return groups
+}
+
+// awaitInit waits for an Init that overran its deadline and hands the run
+// back to the HAL loop.
+func awaitInit(ctx context.Context, h *HAL, r *initRun) {
+	r.err = <-r.done
+	select {
+	case h.initLate <- r:
+	case <-ctx.Done():
+	}
+}
+
+// finishInit settles a late Init on the HAL goroutine. A late failure is
+// reported on the device's capabilities. A device rolled back while its
+// Init ran is closed if that Init succeeded, and only then are its claims
+// released and its ID freed for reuse.
+func (h *HAL) finishInit(r *initRun) {
+	id := r.dev.ID()
+	if r.abandoned {
+		delete(h.abandoned, id)
+		if r.err == nil {
+			_ = r.dev.Close()
+		}
+		h.releaseClaims(id)
+		h.pubResources()
+		return
+	}
+	if r.err == nil {
+		return
+	}
+	code := string(errcode.Of(r.err))
+	now, mono := h.clk.Now().UnixNano(), h.mono()
+	for _, cs := range r.dev.Capabilities() {
+		h.pubStatus(cs.Domain, cs.Kind, cs.Name, now, mono, code)
+	}
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+// applyAdvancing runs applyConfig while stepping clk, so Init deadlines
+// expire without waiting in real time.
+func applyAdvancing(t *testing.T, h *HAL, clk *clock.Fake, cfg types.HALConfig) []types.ConfigError {
+	t.Helper()
+	done := make(chan []types.ConfigError, 1)
+	go func() { done <- h.applyConfig(context.Background(), cfg) }()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case errs := <-done:
+			return errs
+		default:
+		}
+		clk.Advance(100 * time.Millisecond)
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("applyConfig did not return")
+	return nil
+}
+
+func lateRun(t *testing.T, h *HAL) *initRun {
+	t.Helper()
+	select {
+	case r := <-h.initLate:
+		return r
+	case <-time.After(time.Second):
+		t.Fatal("late Init never reported")
+		return nil
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestInit_TimeoutLeavesDeviceStarting(t *testing.T) {
+	clk := clock.NewFake(t0)
+	reg := &fakeReg{}
+	h := newTestHAL(reg, clk)
+	release := make(chan error)
+	slow := addDev(t, &fakeDev{id: "slow", caps: []CapabilitySpec{fakeCap("slow")},
+		init: func(context.Context) error { return <-release }})
+	slow.InitTimeoutMs = 500
+
+	if errs := applyAdvancing(t, h, clk, types.HALConfig{Devices: []types.HALDevice{slow}}); len(errs) != 0 {
+		t.Fatalf("timeout rejected the config: %+v", errs)
+	}
+	ck := capKey{domain: "test", kind: types.KindTemperature, name: "slow"}
+	if st := h.lastStatus[ck]; st.link != types.LinkDegraded || st.err != "init_timeout" {
+		t.Fatalf("status = %+v, want degraded/init_timeout", st)
+	}
+
+	release <- errcode.Unavailable
+	h.finishInit(lateRun(t, h))
+	if st := h.lastStatus[ck]; st.err != string(errcode.Unavailable) {
+		t.Fatalf("late failure: status err = %q, want %s", st.err, errcode.Unavailable)
+	}
+	if h.dev["slow"] == nil || len(reg.releasedIDs()) != 0 {
+		t.Fatal("late failure of a committed device dropped it")
+	}
+}
+
+func TestInit_RollbackHoldsClaimsUntilLateInitReturns(t *testing.T) {
+	clk := clock.NewFake(t0)
+	reg := &fakeReg{}
+	h := newTestHAL(reg, clk)
+	release := make(chan error)
+	slowDev := &fakeDev{id: "slow", caps: []CapabilitySpec{fakeCap("slow")},
+		init: func(context.Context) error { return <-release }}
+	slow := addDev(t, slowDev)
+	slow.InitTimeoutMs = 500
+	bad := addDev(t, &fakeDev{id: "bad", caps: []CapabilitySpec{fakeCap("bad")},
+		init: func(context.Context) error { return errcode.Unavailable }})
+
+	errs := applyAdvancing(t, h, clk, types.HALConfig{Devices: []types.HALDevice{slow, bad}})
+	if len(errs) != 1 || errs[0].ID != "bad" {
+		t.Fatalf("errs = %+v, want one for bad", errs)
+	}
+	if got := reg.releasedIDs(); !contains(got, "bad") || contains(got, "slow") {
+		t.Fatalf("released %v: want bad only while slow's Init runs", got)
+	}
+	if len(h.dev) != 0 {
+		t.Fatalf("rolled-back devices still registered: %d", len(h.dev))
+	}
+
+	// The ID stays taken until the late Init is settled.
+	again := applyAdvancing(t, h, clk, types.HALConfig{Devices: []types.HALDevice{slow}})
+	if len(again) != 1 || again[0].Error != string(errcode.Busy) {
+		t.Fatalf("reuse during late Init: errs = %+v, want busy", again)
+	}
+
+	release <- nil
+	h.finishInit(lateRun(t, h))
+	if !contains(reg.releasedIDs(), "slow") {
+		t.Fatal("claims not released after the late Init returned")
+	}
+	if n := slowDev.closes(); n != 1 {
+		t.Fatalf("late-successful rolled-back device closed %d times, want 1", n)
+	}
+	if _, held := h.abandoned["slow"]; held {
+		t.Fatal("ID still reserved after the late Init returned")
+	}
+}
+
+func TestInit_SharedBusStartsInOrder(t *testing.T) {
+	clk := clock.NewFake(t0)
+	reg := &fakeReg{buses: []types.BusClaim{{ID: "i2c0", Class: "i2c", Users: []string{"first", "second"}}}}
+	h := newTestHAL(reg, clk)
+	var order []string
+	first := addDev(t, &fakeDev{id: "first", caps: []CapabilitySpec{fakeCap("first")},
+		init: func(context.Context) error { order = append(order, "first"); return nil }})
+	second := addDev(t, &fakeDev{id: "second", caps: []CapabilitySpec{fakeCap("second")},
+		init: func(context.Context) error { order = append(order, "second"); return nil }})
+
+	if errs := applyAdvancing(t, h, clk, types.HALConfig{Devices: []types.HALDevice{first, second}}); len(errs) != 0 {
+		t.Fatalf("errs = %+v", errs)
+	}
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Fatalf("init order = %v, want config order on a shared bus", order)
+	}
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"sort"
+
+	"devicecode-go/bus"
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+)
+
+// ---------------- Wildcard control broadcast ----------------
+//
+// A control whose domain or name is "+" is applied to every capability of
+// that kind that matches, in address order, with one aggregated reply
+// (types.BroadcastReply). The kind must be concrete, so one payload type
+// fits all targets. HAL-handled verbs (polling, burst, read_sync) are per
+// capability only.
+
+func (h *HAL) handleBroadcast(msg *bus.Message, pat CapAddr, verb string) {
+	switch {
+	case pat.Kind == "+":
+		h.replyErr(msg, &errcode.E{C: errcode.InvalidTopic, Op: verb, Msg: "kind must not be a wildcard"})
+		return
+	case verb == "poll_start" || verb == "poll_stop" || verb == "read_sync" || verb == "burst":
+		h.replyErr(msg, &errcode.E{C: errcode.Unsupported, Op: verb, Msg: "not broadcastable"})
+		return
+	}
+
+	var keys []capKey
+	for ck := range h.capIndex {
+		if ck.kind == pat.Kind && matchTok(pat.Domain, ck.domain) && matchTok(pat.Name, ck.name) {
+			keys = append(keys, ck)
+		}
+	}
+	if len(keys) == 0 {
+		h.replyErr(msg, errcode.UnknownCapability)
+		return
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].domain != keys[j].domain {
+			return keys[i].domain < keys[j].domain
+		}
+		return keys[i].name < keys[j].name
+	})
+
+	rep := types.BroadcastReply{OK: true, Results: make([]types.BroadcastResult, 0, len(keys))}
+	for _, ck := range keys {
+		r := types.BroadcastResult{Address: types.CapabilityAddress{Domain: ck.domain, Kind: ck.kind, Name: ck.name}}
+		code := errcode.Error
+		if dev := h.dev[h.capIndex[ck]]; dev != nil {
+			res, err := dev.Control(CapAddr{Domain: ck.domain, Kind: ck.kind, Name: ck.name}, verb, msg.Payload)
+			switch {
+			case err != nil:
+				code = errcode.Of(err)
+			case res.OK:
+				code = errcode.OK
+			case res.Error != "":
+				code = res.Error
+			}
+		}
+		if r.OK = code == errcode.OK; !r.OK {
+			r.Error = string(code)
+			rep.OK = false
+		}
+		rep.Results = append(rep.Results, r)
+	}
+	if msg.CanReply() {
+		h.conn.Reply(msg, rep, false)
+	}
+}
@@ -0,0 +1,32 @@
+package core
+
+import (
+	"sync"
+
+	"devicecode-go/bus"
+)
+
+// capAccess is the Capabilities HAL hands to devices. It goes through the
+// bus rather than HAL's maps, so it is safe from device goroutines and a
+// control is handled by the HAL loop exactly as a remote one would be.
+type capAccess struct{ conn *bus.Connection }
+
+// Watch subscribes on HAL's own connection, which is bounded by the bus
+// limits, so it must not panic when a composite pushes past them.
+func (a capAccess) Watch(addr CapAddr, fn func(any)) (func(), error) {
+	sub, err := a.conn.TrySubscribe(capValue(addr.Domain, addr.Kind, addr.Name))
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for m := range sub.Channel() {
+			fn(m.Payload)
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { a.conn.Unsubscribe(sub) }) }, nil
+}
+
+func (a capAccess) Control(addr CapAddr, verb string, payload any) {
+	a.conn.Publish(a.conn.NewMessage(capCtrl(addr.Domain, addr.Kind, addr.Name, verb), payload, false))
+}
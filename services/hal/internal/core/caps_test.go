@@ -0,0 +1,45 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/types"
+)
+
+func TestCapAccessWatch_RefusedAtSubscriptionLimit(t *testing.T) {
+	bus.SetLimits(bus.Limits{MaxSubsPerConn: 1})
+	defer bus.SetLimits(bus.Limits{})
+
+	b := bus.NewBus(4, "+", "#")
+	a := capAccess{conn: b.NewConnection("hal")}
+	addr := CapAddr{Domain: "test", Kind: types.KindTemperature, Name: "x"}
+
+	got := make(chan any, 1)
+	cancel, err := a.Watch(addr, func(p any) { got <- p })
+	if err != nil {
+		t.Fatalf("first watch: %v", err)
+	}
+	if _, err := a.Watch(addr, func(any) {}); err != bus.ErrTooManySubs {
+		t.Fatalf("second watch: err = %v, want ErrTooManySubs", err)
+	}
+
+	b.NewConnection("dev").Publish(b.NewMessage(capValue("test", types.KindTemperature, "x"), 7, true))
+	select {
+	case p := <-got:
+		if p != 7 {
+			t.Fatalf("watched %v, want 7", p)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("watch delivered nothing")
+	}
+
+	// Cancelling frees the slot.
+	cancel()
+	c2, err := a.Watch(addr, func(any) {})
+	if err != nil {
+		t.Fatalf("watch after cancel: %v", err)
+	}
+	c2()
+}
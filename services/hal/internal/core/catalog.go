@@ -0,0 +1,64 @@
+package core
+
+import (
+	"sort"
+
+	"devicecode-go/types"
+)
+
+// The catalogue compiles every capability's info, value-field metadata and
+// verbs into one retained document at hal/catalog, so a host UI can learn
+// the whole surface from a single subscription. It is regenerated after
+// each applied configuration.
+
+// catalogInfo records a capability's info for the catalogue.
+func (h *HAL) catalogInfo(ck capKey, info types.Info) {
+	e := h.catalog[ck]
+	if e == nil {
+		e = &types.CatalogEntry{Domain: ck.domain, Kind: ck.kind, Name: ck.name,
+			Fields: types.ValueFields(ck.kind)}
+		h.catalog[ck] = e
+		h.catalogAliases(ck)
+	}
+	e.Driver, e.Detail = info.Driver, info.Detail
+}
+
+// catalogVerbs records a capability's verb list for the catalogue.
+func (h *HAL) catalogVerbs(ck capKey, verbs []types.VerbInfo) {
+	if e := h.catalog[ck]; e != nil {
+		e.Verbs = verbs
+	}
+}
+
+// pubCatalog publishes the retained catalogue, sorted by address.
+func (h *HAL) pubCatalog() {
+	caps := make([]types.CatalogEntry, 0, len(h.catalog))
+	for _, e := range h.catalog {
+		caps = append(caps, *e)
+	}
+	sort.Slice(caps, func(i, j int) bool {
+		a, b := &caps[i], &caps[j]
+		if a.Domain != b.Domain {
+			return a.Domain < b.Domain
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	h.catalogRev++
+	h.conn.Publish(h.conn.NewMessage(topicCatalog(),
+		types.Catalog{Rev: h.catalogRev, TS: h.clk.Now().UnixNano(), Caps: caps}, true))
+}
+
+// pubResources publishes the retained pin and bus ownership map, when the
+// registry can list it.
+func (h *HAL) pubResources() {
+	rl, ok := h.res.Reg.(ResourceLister)
+	if !ok {
+		return
+	}
+	m := rl.Resources()
+	m.TS = h.clk.Now().UnixNano()
+	h.conn.Publish(h.conn.NewMessage(topicResources(), m, true))
+}
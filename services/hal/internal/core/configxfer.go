@@ -0,0 +1,272 @@
+package core
+
+import (
+	"hash/crc32"
+	"strconv"
+
+	"devicecode-go/bus"
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+	"devicecode-go/x/cbor"
+)
+
+// ---------------- Config export / import ----------------
+//
+// hal/config/control/export replies with the last config/hal HAL accepted,
+// plus the reactor's thresholds as retained on reactor/config, serialised
+// as a types.ConfigBlob. hal/config/control/import takes a
+// types.ConfigImport, verifies the checksum and that every device's params
+// decode, then stores the blob through the registry's ConfigStore (loaded
+// at the next boot) and, with Apply, publishes it on config/hal (and
+// config/reactor) now. A registry without a ConfigStore can only apply.
+//
+// The blob is canonical CBOR written and read by the types themselves (see
+// x/cbor); device params go through the mappers their packages registered
+// with RegisterParams.
+
+const configFormat = "cbor"
+
+// EncodeConfig serialises cfg and, if set, rc with a checksum. Params that
+// cannot be written, or that do not read back, fail with Unsupported.
+func EncodeConfig(cfg types.HALConfig, rc *types.ReactorConfig) (types.ConfigBlob, error) {
+	var e cbor.Encoder
+	var err error
+	e.Map(func(m *cbor.Map) {
+		m.Put("hal", func(e *cbor.Encoder) { err = encodeHAL(e, cfg) })
+		if rc != nil {
+			m.Value("reactor", *rc)
+		}
+	})
+	if err != nil {
+		return types.ConfigBlob{}, err
+	}
+	b := e.Bytes()
+	blob := types.ConfigBlob{Format: configFormat, Size: uint32(len(b)), CRC32: crc32.ChecksumIEEE(b), Data: b}
+	// Params mappers are per device package: prove this one reads back
+	// before handing it out.
+	if _, _, err := DecodeConfig(blob); err != nil {
+		return types.ConfigBlob{}, &errcode.E{C: errcode.Unsupported, Op: "config_export", Msg: "blob does not read back", Err: err}
+	}
+	return blob, nil
+}
+
+func encodeHAL(e *cbor.Encoder, cfg types.HALConfig) error {
+	var err error
+	e.Map(func(m *cbor.Map) {
+		if cfg.Buses != nil {
+			m.Value("buses", *cfg.Buses)
+		}
+		m.Array("devices", len(cfg.Devices), func(e *cbor.Encoder, i int) {
+			d := cfg.Devices[i]
+			pm, ok := d.Params.(cbor.Marshaler)
+			if !ok && d.Params != nil && err == nil {
+				err = &errcode.E{C: errcode.Unsupported, Op: "config_export",
+					Msg: "params of " + d.Type + " not serialisable", Field: "devices[" + strconv.Itoa(i) + "].params"}
+			}
+			e.Map(func(m *cbor.Map) {
+				m.Text("id", d.ID)
+				m.Text("type", d.Type)
+				if ok {
+					m.Value("params", pm)
+				}
+				m.Uint("init_timeout_ms", uint64(d.InitTimeoutMs))
+				m.Uint("control_timeout_ms", uint64(d.ControlTimeoutMs))
+				m.Array("transforms", len(d.Transforms), func(e *cbor.Encoder, i int) { d.Transforms[i].MarshalCBOR(e) })
+			})
+		})
+		m.Array("pollers", len(cfg.Pollers), func(e *cbor.Encoder, i int) { cfg.Pollers[i].MarshalCBOR(e) })
+		m.Array("alarms", len(cfg.Alarms), func(e *cbor.Encoder, i int) { cfg.Alarms[i].MarshalCBOR(e) })
+		m.Array("groups", len(cfg.Groups), func(e *cbor.Encoder, i int) { cfg.Groups[i].MarshalCBOR(e) })
+		if len(cfg.Aliases) != 0 {
+			m.Array("aliases", len(cfg.Aliases), func(e *cbor.Encoder, i int) { cfg.Aliases[i].MarshalCBOR(e) })
+		}
+	})
+	return err
+}
+
+// DecodeConfig checks blob and rebuilds the HALConfig, decoding each
+// device's params into the type its package registered, and the reactor
+// section if present.
+func DecodeConfig(blob types.ConfigBlob) (types.HALConfig, *types.ReactorConfig, error) {
+	const op = "config_import"
+	var cfg types.HALConfig
+	if blob.Format != configFormat {
+		return cfg, nil, &errcode.E{C: errcode.Unsupported, Op: op, Msg: "format " + blob.Format, Field: "blob.format"}
+	}
+	if int(blob.Size) != len(blob.Data) {
+		return cfg, nil, &errcode.E{C: errcode.InvalidPayload, Op: op, Msg: "size mismatch", Field: "blob.size"}
+	}
+	if crc32.ChecksumIEEE(blob.Data) != blob.CRC32 {
+		return cfg, nil, &errcode.E{C: errcode.InvalidPayload, Op: op, Msg: "checksum mismatch", Field: "blob.crc32"}
+	}
+	var rc *types.ReactorConfig
+	d := cbor.NewDecoder(blob.Data)
+	err := d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "hal":
+			return decodeHAL(d, &cfg)
+		case "reactor":
+			rc = new(types.ReactorConfig)
+			return rc.UnmarshalCBOR(d)
+		}
+		return d.Skip()
+	})
+	if err == nil {
+		err = d.Done()
+	}
+	if err != nil {
+		if _, ok := err.(*errcode.E); ok {
+			return types.HALConfig{}, nil, err
+		}
+		return types.HALConfig{}, nil, &errcode.E{C: errcode.InvalidPayload, Op: op, Msg: err.Error(), Field: "blob.data"}
+	}
+	return cfg, rc, nil
+}
+
+func decodeHAL(d *cbor.Decoder, cfg *types.HALConfig) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "buses":
+			cfg.Buses = new(types.BusPlan)
+			return cfg.Buses.UnmarshalCBOR(d)
+		case "devices":
+			return d.Array(func(d *cbor.Decoder) error {
+				dev, err := decodeDevice(d, len(cfg.Devices))
+				cfg.Devices = append(cfg.Devices, dev)
+				return err
+			})
+		case "pollers":
+			return d.Array(func(d *cbor.Decoder) error {
+				var p types.PollSpec
+				err := p.UnmarshalCBOR(d)
+				cfg.Pollers = append(cfg.Pollers, p)
+				return err
+			})
+		case "alarms":
+			return d.Array(func(d *cbor.Decoder) error {
+				var a types.AlarmSpec
+				err := a.UnmarshalCBOR(d)
+				cfg.Alarms = append(cfg.Alarms, a)
+				return err
+			})
+		case "groups":
+			return d.Array(func(d *cbor.Decoder) error {
+				var g types.GroupSpec
+				err := g.UnmarshalCBOR(d)
+				cfg.Groups = append(cfg.Groups, g)
+				return err
+			})
+		case "aliases":
+			return d.Array(func(d *cbor.Decoder) error {
+				var a types.CapAlias
+				err := a.UnmarshalCBOR(d)
+				cfg.Aliases = append(cfg.Aliases, a)
+				return err
+			})
+		}
+		return d.Skip()
+	})
+}
+
+// decodeDevice reads devices[i]. "type" precedes "params" in canonical
+// key order, so the params decoder is known when they arrive.
+func decodeDevice(d *cbor.Decoder, i int) (types.HALDevice, error) {
+	const op = "config_import"
+	field := "devices[" + strconv.Itoa(i) + "].params"
+	var dev types.HALDevice
+	err := d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "id":
+			return cbor.ReadText(d, &dev.ID)
+		case "type":
+			return cbor.ReadText(d, &dev.Type)
+		case "init_timeout_ms":
+			return cbor.ReadUint(d, &dev.InitTimeoutMs)
+		case "control_timeout_ms":
+			return cbor.ReadUint(d, &dev.ControlTimeoutMs)
+		case "transforms":
+			return d.Array(func(d *cbor.Decoder) error {
+				var t types.Transform
+				err := t.UnmarshalCBOR(d)
+				dev.Transforms = append(dev.Transforms, t)
+				return err
+			})
+		case "params":
+			decode, ok := lookupParams(dev.Type)
+			if !ok {
+				return &errcode.E{C: errcode.Unsupported, Op: op, Msg: "no params for type " + dev.Type, Field: field}
+			}
+			p, err := decode(d)
+			if err != nil {
+				return &errcode.E{C: errcode.InvalidParams, Op: op, Msg: err.Error(), Field: field}
+			}
+			dev.Params = p
+			return nil
+		}
+		return d.Skip()
+	})
+	return dev, err
+}
+
+// reactorConfig is the reactor's thresholds as last retained on
+// reactor/config, or nil before the reactor has published them.
+func (h *HAL) reactorConfig() *types.ReactorConfig {
+	for _, m := range h.conn.Retained(topicReactorConfig()) {
+		if rc, ok := m.Payload.(types.ReactorConfig); ok {
+			return &rc
+		}
+	}
+	return nil
+}
+
+func (h *HAL) handleConfigCtrl(m *bus.Message) {
+	verb, _ := m.Topic.At(3).(string)
+	switch verb {
+	case "export":
+		blob, err := EncodeConfig(h.applied, h.reactorConfig())
+		if err != nil {
+			h.replyErr(m, err)
+			return
+		}
+		if m.CanReply() {
+			h.conn.Reply(m, blob, false)
+		}
+	case "import":
+		ci, code := As[types.ConfigImport](m.Payload)
+		if code != "" {
+			h.replyErr(m, &errcode.E{C: code, Op: "config_import", Msg: "want ConfigImport"})
+			return
+		}
+		cfg, rc, err := DecodeConfig(ci.Blob)
+		if err != nil {
+			h.replyErr(m, err)
+			return
+		}
+		st := types.ConfigStaged{
+			CRC32: ci.Blob.CRC32, Size: ci.Blob.Size, Devices: len(cfg.Devices), Reactor: rc != nil,
+			Applied: ci.Apply, TS: h.clk.Now().UnixNano(),
+		}
+		if cs, ok := h.res.Reg.(ConfigStore); ok {
+			if err := cs.SaveConfig(ci.Blob); err != nil {
+				h.replyErr(m, err)
+				return
+			}
+			st.Stored = true
+		} else if !ci.Apply {
+			h.replyErr(m, &errcode.E{C: errcode.Unsupported, Op: "config_import", Msg: "no config store; set apply"})
+			return
+		}
+		if ci.Apply {
+			if rc != nil {
+				h.conn.Publish(h.conn.NewMessage(topicConfigReactor(), *rc, true))
+			}
+			h.conn.Publish(h.conn.NewMessage(topicConfigHAL(), cfg, true))
+		}
+		h.conn.Publish(h.conn.NewMessage(topicConfigStaged(), st, true))
+		if m.CanReply() {
+			h.conn.Reply(m, st, false)
+		}
+	default:
+		h.replyErr(m, &errcode.E{C: errcode.Unsupported, Op: "config", Msg: "unknown verb " + verb})
+	}
+}
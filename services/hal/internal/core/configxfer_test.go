@@ -0,0 +1,240 @@
+package core
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+	"devicecode-go/x/cbor"
+	"devicecode-go/x/clock"
+)
+
+// fakeParams stands in for a device package's Params.
+type fakeParams struct {
+	Pin   int
+	Label string
+	Boot  []types.BootAction
+}
+
+func (p fakeParams) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("Pin", int64(p.Pin))
+		m.Text("Label", p.Label)
+		m.Array("boot", len(p.Boot), func(e *cbor.Encoder, i int) { p.Boot[i].MarshalCBOR(e) })
+	})
+}
+
+func (p *fakeParams) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Pin":
+			return cbor.ReadInt(d, &p.Pin)
+		case "Label":
+			return cbor.ReadText(d, &p.Label)
+		case "boot":
+			return d.Array(func(d *cbor.Decoder) error {
+				var a types.BootAction
+				err := a.UnmarshalCBOR(d)
+				p.Boot = append(p.Boot, a)
+				return err
+			})
+		}
+		return d.Skip()
+	})
+}
+
+func init() { RegisterParams[fakeParams]("fake") }
+
+// storeReg is a fakeReg with an in-memory ConfigStore.
+type storeReg struct {
+	fakeReg
+	saved *types.ConfigBlob
+}
+
+func (r *storeReg) SaveConfig(b types.ConfigBlob) error { r.saved = &b; return nil }
+func (r *storeReg) LoadConfig() (types.ConfigBlob, bool) {
+	if r.saved == nil {
+		return types.ConfigBlob{}, false
+	}
+	return *r.saved, true
+}
+
+func sampleConfig() (types.HALConfig, types.ReactorConfig) {
+	cts, phase, on, ma := 0, uint32(0), false, int32(-5)
+	cfg := types.HALConfig{
+		Buses: &types.BusPlan{
+			I2C:     []types.I2CBus{{ID: "i2c0", SDA: 0, SCL: 1, Hz: 400000}},
+			UART:    []types.UARTBus{{ID: "uart0", TX: 12, RX: 13, Baud: 115200, CTS: &cts}},
+			Aliases: map[string]int{"LED": 25, "ZERO": 0},
+		},
+		Devices: []types.HALDevice{
+			{ID: "a", Type: "fake", InitTimeoutMs: 500, ControlTimeoutMs: 250, Params: fakeParams{Pin: 0, Label: "x", Boot: []types.BootAction{
+				{Verb: "configure", Payload: types.ChargerConfigure{Enable: &on, IinLimit_mA: &ma}},
+				{Verb: "enable"},
+			}}},
+			{ID: "b", Type: "fake", Params: fakeParams{Pin: 7}, Transforms: []types.Transform{
+				{Field: "deci_c", Op: types.TransformOffset, Add: -12},
+			}},
+		},
+		Pollers: []types.PollSpec{{Domain: "env", Kind: types.KindTemperature, Name: "core", Verb: "read", IntervalMs: 1000, PhaseMs: &phase}},
+		Alarms: []types.AlarmSpec{{Name: "hot", Source: types.CapabilityAddress{Domain: "env", Kind: types.KindTemperature, Name: "core"},
+			Field: "deci_c", Op: types.AlarmGT, Threshold: 700, Hysteresis: -1, DebounceMs: 100}},
+	}
+	rc := types.ReactorConfig{TempLimit_deciC: 780, TempHyst_deciC: 60, PGOnVIN_mV: 12000, SagVIN_mV: 10600,
+		PGOnVBAT_mV: 12400, PGOffHyst_mV: 800, SagVBAT_mV: 11400, DebounceOK_ms: 300, StaleMax_ms: 4000}
+	return cfg, rc
+}
+
+func TestConfigBlob_RoundTrip(t *testing.T) {
+	cfg, rc := sampleConfig()
+	blob, err := EncodeConfig(cfg, &rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blob.Format != "cbor" || int(blob.Size) != len(blob.Data) {
+		t.Fatalf("blob header = %+v", blob)
+	}
+	got, grc, err := DecodeConfig(blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grc == nil || !reflect.DeepEqual(*grc, rc) {
+		t.Fatalf("reactor = %+v", grc)
+	}
+	b := got.Buses
+	if b == nil || b.I2C[0] != cfg.Buses.I2C[0] || b.UART[0].CTS == nil || *b.UART[0].CTS != 0 ||
+		len(b.Aliases) != 2 || b.Aliases["ZERO"] != 0 || b.Aliases["LED"] != 25 {
+		t.Fatalf("buses = %+v", b)
+	}
+	if len(got.Devices) != 2 || got.Devices[0].InitTimeoutMs != 500 || got.Devices[0].ControlTimeoutMs != 250 ||
+		len(got.Devices[1].Transforms) != 1 || got.Devices[1].Transforms[0] != cfg.Devices[1].Transforms[0] {
+		t.Fatalf("devices = %+v", got.Devices)
+	}
+	p, ok := got.Devices[0].Params.(fakeParams)
+	if !ok || p.Label != "x" || len(p.Boot) != 2 || p.Boot[1].Payload != nil {
+		t.Fatalf("params = %#v", got.Devices[0].Params)
+	}
+	cc, ok := p.Boot[0].Payload.(types.ChargerConfigure)
+	if !ok || cc.Enable == nil || *cc.Enable || cc.IinLimit_mA == nil || *cc.IinLimit_mA != -5 || cc.VinLo_mV != nil {
+		t.Fatalf("boot payload = %#v", p.Boot[0].Payload)
+	}
+	if len(got.Pollers) != 1 || got.Pollers[0].PhaseMs == nil || got.Alarms[0] != cfg.Alarms[0] {
+		t.Fatalf("pollers/alarms = %+v %+v", got.Pollers, got.Alarms)
+	}
+
+	// Canonical: the decoded config writes the same bytes.
+	again, err := EncodeConfig(got, grc)
+	if err != nil || !bytes.Equal(again.Data, blob.Data) || again.CRC32 != blob.CRC32 {
+		t.Fatalf("re-encoding differs (err %v)", err)
+	}
+	// No reactor section: none comes back.
+	if blob, _ := EncodeConfig(cfg, nil); blob.Size == 0 {
+		t.Fatal("empty blob")
+	} else if _, grc, err := DecodeConfig(blob); err != nil || grc != nil {
+		t.Fatalf("without reactor: %v %+v", err, grc)
+	}
+}
+
+func TestConfigBlob_Rejects(t *testing.T) {
+	cfg, _ := sampleConfig()
+	blob, _ := EncodeConfig(cfg, nil)
+
+	bad := blob
+	bad.Data = append([]byte(nil), blob.Data...)
+	bad.Data[len(bad.Data)-1] ^= 1
+	if _, _, err := DecodeConfig(bad); errcode.Of(err) != errcode.InvalidPayload {
+		t.Fatalf("corrupt: err = %v", err)
+	}
+	bad = blob
+	bad.Format = "json"
+	if _, _, err := DecodeConfig(bad); errcode.Of(err) != errcode.Unsupported {
+		t.Fatalf("format: err = %v", err)
+	}
+
+	// A device type with no registered params reads back as unsupported,
+	// naming the device.
+	un := cfg
+	un.Devices = []types.HALDevice{{ID: "u", Type: "nope", Params: fakeParams{}}}
+	if _, err := EncodeConfig(un, nil); errcode.Of(err) != errcode.Unsupported {
+		t.Fatalf("unregistered type: err = %v", err)
+	}
+	// Params that cannot write themselves fail the export.
+	un.Devices = []types.HALDevice{{ID: "u", Type: "fake", Params: struct{ X int }{1}}}
+	_, err := EncodeConfig(un, nil)
+	if f := fieldOf(err); errcode.Of(err) != errcode.Unsupported || f != "devices[0].params" {
+		t.Fatalf("unserialisable params: err = %v field %q", err, f)
+	}
+	// Boot payloads that are not types payloads do not read back.
+	un.Devices = []types.HALDevice{{ID: "u", Type: "fake", Params: fakeParams{Boot: []types.BootAction{{Verb: "x", Payload: struct{ X int }{1}}}}}}
+	if _, err := EncodeConfig(un, nil); errcode.Of(err) != errcode.Unsupported {
+		t.Fatalf("boot payload: err = %v", err)
+	}
+}
+
+func fieldOf(err error) string {
+	_, f := errcode.DetailOf(err)
+	return f
+}
+
+func TestConfigCtrl_ImportExport(t *testing.T) {
+	reg := &storeReg{}
+	b := bus.NewBus(8, "+", "#")
+	h := NewHAL(b.NewConnection("hal"), Resources{Reg: reg, Clock: clock.NewFake(t0)})
+	cli := b.NewConnection("cli")
+	cfgSub := cli.Subscribe(topicConfigHAL())
+	rcSub := cli.Subscribe(topicConfigReactor())
+
+	cfg, rc := sampleConfig()
+	blob, err := EncodeConfig(cfg, &rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := cli.NewMessage(T("hal", "config", "control", "import"), types.ConfigImport{Blob: blob, Apply: true}, false)
+	reply := cli.Request(req)
+	h.handleConfigCtrl(req)
+
+	st, ok := recv(t, reply).Payload.(types.ConfigStaged)
+	if !ok || !st.Stored || !st.Applied || !st.Reactor || st.Devices != 2 || st.CRC32 != blob.CRC32 {
+		t.Fatalf("staged = %+v", st)
+	}
+	if reg.saved == nil || !bytes.Equal(reg.saved.Data, blob.Data) {
+		t.Fatal("blob not stored")
+	}
+	if got, ok := recv(t, rcSub).Payload.(types.ReactorConfig); !ok || !reflect.DeepEqual(got, rc) {
+		t.Fatalf("config/reactor = %+v", got)
+	}
+	if _, ok := recv(t, cfgSub).Payload.(types.HALConfig); !ok {
+		t.Fatal("config/hal not published")
+	}
+
+	// Export: the applied HAL config plus the reactor's retained thresholds.
+	h.applied = cfg
+	rc.StaleMax_ms = 5000
+	cli.Publish(cli.NewMessage(topicReactorConfig(), rc, true))
+	req = cli.NewMessage(T("hal", "config", "control", "export"), nil, false)
+	reply = cli.Request(req)
+	h.handleConfigCtrl(req)
+	out, ok := recv(t, reply).Payload.(types.ConfigBlob)
+	if !ok {
+		t.Fatal("export did not reply with a blob")
+	}
+	_, grc, err := DecodeConfig(out)
+	if err != nil || grc == nil || grc.StaleMax_ms != 5000 {
+		t.Fatalf("exported reactor = %+v (err %v)", grc, err)
+	}
+}
+
+func recv(t *testing.T, s *bus.Subscription) *bus.Message {
+	t.Helper()
+	select {
+	case m := <-s.Channel():
+		return m
+	case <-time.After(time.Second):
+		t.Fatal("no message")
+		return nil
+	}
+}
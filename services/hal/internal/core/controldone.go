@@ -0,0 +1,143 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+)
+
+// ---------------- Control completion (single-threaded in HAL loop) ----------------
+//
+// A control's reply only says the device queued the work. A caller that
+// needs the outcome adds a request ID token, …/control/<verb>/<id>, and
+// HAL also publishes the non-retained event …/event/control_done/<id>
+// (types.ControlDone) once the work is done. Devices that finish work on a
+// worker implement Completer and report through its done callback; for
+// the rest, queueing is the outcome. Work not reported by the device's
+// control deadline (HALDevice.ControlTimeoutMs) is reported as a timeout
+// and its context cancelled. HAL's own verbs finish before they reply and
+// ignore the ID.
+
+const controlDoneDefault = 5 * time.Second
+
+type ctrlPending struct {
+	ck       capKey
+	verb     string
+	id       bus.Token
+	deadline int64 // Unix ns
+	cancel   context.CancelFunc
+}
+
+type ctrlResult struct {
+	seq uint32
+	err error
+}
+
+// controlTracked dispatches a control carrying request ID id to dev.
+func (h *HAL) controlTracked(msg *bus.Message, devID string, dev Device, cap CapAddr, verb string, id bus.Token) {
+	ck := capKey{domain: cap.Domain, kind: cap.Kind, name: cap.Name}
+	c, ok := dev.(Completer)
+	if !ok {
+		res, err := dev.Control(cap, verb, msg.Payload)
+		h.replyEnqueue(msg, res, err)
+		h.ctrlDonePub(ck, verb, id, enqueueErr(res, err))
+		return
+	}
+
+	h.ctrlSeq++
+	seq := h.ctrlSeq
+	ctx, cancel := context.WithCancel(context.Background())
+	done := func(err error) {
+		if ctx.Err() != nil {
+			return // already timed out
+		}
+		select {
+		case h.ctrlDone <- ctrlResult{seq: seq, err: err}:
+		case <-ctx.Done(): // already timed out
+		}
+	}
+	res, err := c.ControlDone(ctx, cap, verb, msg.Payload, done)
+	h.replyEnqueue(msg, res, err)
+	if err := enqueueErr(res, err); err != nil || !res.Pending {
+		cancel()
+		h.ctrlDonePub(ck, verb, id, err)
+		return
+	}
+	timeout := h.ctrlTimeout[devID]
+	if timeout <= 0 {
+		timeout = controlDoneDefault
+	}
+	h.ctrlWait[seq] = &ctrlPending{
+		ck: ck, verb: verb, id: id,
+		deadline: h.clk.Now().Add(timeout).UnixNano(),
+		cancel:   cancel,
+	}
+}
+
+// enqueueErr is the error a control's enqueue result amounts to, or nil.
+func enqueueErr(res EnqueueResult, err error) error {
+	switch {
+	case err != nil:
+		return err
+	case !res.OK:
+		return res.Error
+	}
+	return nil
+}
+
+// ctrlFinish publishes a device's report for a pending control.
+func (h *HAL) ctrlFinish(r ctrlResult) {
+	p, ok := h.ctrlWait[r.seq]
+	if !ok {
+		return
+	}
+	delete(h.ctrlWait, r.seq)
+	p.cancel()
+	h.ctrlDonePub(p.ck, p.verb, p.id, r.err)
+}
+
+// ctrlExpire reports pending controls whose deadline has passed.
+func (h *HAL) ctrlExpire() {
+	now := h.clk.Now().UnixNano()
+	for seq, p := range h.ctrlWait {
+		if p.deadline > now {
+			continue
+		}
+		delete(h.ctrlWait, seq)
+		p.cancel()
+		h.ctrlDonePub(p.ck, p.verb, p.id, &errcode.E{C: errcode.Timeout, Op: p.verb, Msg: "not done before deadline"})
+	}
+}
+
+// ctrlNextWait mirrors syncNextWait for the earliest control deadline.
+func (h *HAL) ctrlNextWait() time.Duration {
+	var first int64
+	for _, p := range h.ctrlWait {
+		if first == 0 || p.deadline < first {
+			first = p.deadline
+		}
+	}
+	if first == 0 {
+		return -1
+	}
+	if d := first - h.clk.Now().UnixNano(); d > 0 {
+		return time.Duration(d)
+	}
+	return 0
+}
+
+func (h *HAL) ctrlDonePub(ck capKey, verb string, id bus.Token, err error) {
+	cd := types.ControlDone{Verb: verb, OK: err == nil, TS: h.clk.Now().UnixNano()}
+	if err != nil {
+		code := errcode.Of(err)
+		if code == "" || code == errcode.OK {
+			code = errcode.Error
+		}
+		cd.Error, cd.Code = string(code), uint16(code.Num())
+		cd.Detail, _ = errcode.DetailOf(err)
+	}
+	h.pubCap(ck, capControlDone(ck.domain, ck.kind, ck.name, id), cd, false, h.mono())
+}
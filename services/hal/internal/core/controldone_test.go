@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+// workerDev completes its controls when the test says so.
+type workerDev struct {
+	fakeDev
+	ctx  context.Context
+	done func(error)
+}
+
+func (d *workerDev) ControlDone(ctx context.Context, _ CapAddr, _ string, _ any, done func(error)) (EnqueueResult, error) {
+	d.ctx, d.done = ctx, done
+	return EnqueueResult{OK: true, Pending: true}, nil
+}
+
+func TestControlDone_ReportsCompletionAndDeadline(t *testing.T) {
+	clk := clock.NewFake(t0)
+	h := newTestHAL(&fakeReg{}, clk)
+	c := fakeCap("x")
+	w := &workerDev{fakeDev: fakeDev{id: "w", caps: []CapabilitySpec{c}}}
+	h.dev["w"] = w
+	if err := h.registerCap("w", c); err != nil {
+		t.Fatal(err)
+	}
+	h.ctrlTimeout["w"] = time.Second
+	events := h.conn.Subscribe(bus.T("hal", "cap", c.Domain, string(c.Kind), c.Name, "event", "control_done", "#"))
+	replies := h.conn.Subscribe(bus.T("reply"))
+	send := func(id bus.Token) {
+		m := h.conn.NewMessage(capCtrl(c.Domain, c.Kind, c.Name, "configure").Append(id), nil, false)
+		m.ReplyTo = bus.T("reply")
+		h.handleControl(m)
+		if r := recv(t, replies); r.Payload != (types.OKReply{OK: true}) {
+			t.Fatalf("reply %+v", r.Payload)
+		}
+	}
+	done := func() (bus.Token, types.ControlDone) {
+		t.Helper()
+		m := recv(t, events)
+		return m.Topic.At(7), m.Payload.(types.ControlDone)
+	}
+
+	// Reported by the device: the event follows, under the request ID.
+	send(7)
+	if len(events.Channel()) != 0 {
+		t.Fatal("done before the device reported")
+	}
+	w.done(nil)
+	h.ctrlFinish(<-h.ctrlDone)
+	if id, cd := done(); id != 7 || !cd.OK || cd.Verb != "configure" {
+		t.Fatalf("done %v %+v", id, cd)
+	}
+	if w.ctx.Err() == nil {
+		t.Fatal("context not released")
+	}
+
+	// Not reported: a timeout at the device's deadline, and a late report
+	// is dropped.
+	send("late")
+	clk.Advance(999 * time.Millisecond)
+	h.ctrlExpire()
+	if len(events.Channel()) != 0 {
+		t.Fatal("timed out early")
+	}
+	clk.Advance(time.Millisecond)
+	h.ctrlExpire()
+	if id, cd := done(); id != "late" || cd.OK || cd.Error != "timeout" {
+		t.Fatalf("timeout %v %+v", id, cd)
+	}
+	if w.ctx.Err() == nil {
+		t.Fatal("context not cancelled at deadline")
+	}
+	w.done(nil)
+	if len(h.ctrlDone) != 0 || len(h.ctrlWait) != 0 || len(events.Channel()) != 0 {
+		t.Fatal("late report kept")
+	}
+}
+
+func TestControlDone_PlainDeviceDoneWhenQueued(t *testing.T) {
+	h := newTestHAL(&fakeReg{}, clock.NewFake(t0))
+	c := fakeCap("y")
+	h.dev["p"] = &fakeDev{id: "p", caps: []CapabilitySpec{c}}
+	if err := h.registerCap("p", c); err != nil {
+		t.Fatal(err)
+	}
+	events := h.conn.Subscribe(bus.T("hal", "cap", c.Domain, string(c.Kind), c.Name, "event", "control_done", "#"))
+	h.handleControl(h.conn.NewMessage(capCtrl(c.Domain, c.Kind, c.Name, "set").Append("r1"), nil, false))
+	cd := recv(t, events).Payload.(types.ControlDone)
+	if cd.OK || cd.Error != "unsupported" || cd.Code == 0 {
+		t.Fatalf("done %+v", cd)
+	}
+}
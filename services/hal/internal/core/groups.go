@@ -0,0 +1,153 @@
+package core
+
+import (
+	"time"
+
+	"devicecode-go/types"
+)
+
+// ---------------- Capability groups (single-threaded in HAL loop) ----------------
+//
+// A group rolls up the health of its member capabilities on the retained
+// group/<name>/state: up while every member's last status is up and, with
+// StaleMs, its last value no older than that; degraded otherwise, listing
+// the offenders. The state is republished only when the roll-up changes.
+
+type groupMember struct {
+	ck   capKey
+	err  string // last status error
+	last int64  // ns, last value (0: none yet)
+}
+
+type group struct {
+	spec    types.GroupSpec
+	members []groupMember
+	stale   int64 // ns (0: never)
+	pub     types.GroupState
+}
+
+// groupUpsert installs or replaces a group by name and publishes its
+// state; members start with no value.
+func (h *HAL) groupUpsert(spec types.GroupSpec) {
+	if spec.Name == "" || len(spec.Members) == 0 {
+		return
+	}
+	g := &group{spec: spec, stale: int64(spec.StaleMs) * int64(time.Millisecond)}
+	for _, a := range spec.Members {
+		g.members = append(g.members, groupMember{ck: capKey{domain: a.Domain, kind: a.Kind, name: a.Name}})
+	}
+	for i := range h.groups {
+		if h.groups[i].spec.Name == spec.Name {
+			h.groups[i] = g
+			h.groupEval(g, h.clk.Now().UnixNano(), true)
+			return
+		}
+	}
+	h.groups = append(h.groups, g)
+	h.groupEval(g, h.clk.Now().UnixNano(), true)
+}
+
+// groupValue records a value of ck; the status that follows it
+// re-evaluates the groups.
+func (h *HAL) groupValue(ck capKey, ts int64) {
+	for _, g := range h.groups {
+		for i := range g.members {
+			if g.members[i].ck == ck {
+				g.members[i].last = ts
+			}
+		}
+	}
+}
+
+// groupStatus records the status of ck and re-evaluates the groups
+// holding it.
+func (h *HAL) groupStatus(ck capKey, err string, ts int64) {
+	for _, g := range h.groups {
+		hit := false
+		for i := range g.members {
+			if g.members[i].ck == ck {
+				g.members[i].err, hit = err, true
+			}
+		}
+		if hit {
+			h.groupEval(g, ts, false)
+		}
+	}
+}
+
+// groupExpire re-evaluates every group with a staleness bound.
+func (h *HAL) groupExpire() {
+	now := h.clk.Now().UnixNano()
+	for _, g := range h.groups {
+		if g.stale > 0 {
+			h.groupEval(g, now, false)
+		}
+	}
+}
+
+// groupNextWait is the time until the first fresh member of any group
+// goes stale, or -1 if none will.
+func (h *HAL) groupNextWait() time.Duration {
+	var first int64
+	for _, g := range h.groups {
+		if g.stale == 0 {
+			continue
+		}
+		for _, m := range g.members {
+			if m.err != "" || m.last == 0 {
+				continue
+			}
+			if at := m.last + g.stale + 1; first == 0 || at < first {
+				first = at
+			}
+		}
+	}
+	if first == 0 {
+		return -1
+	}
+	if d := first - h.clk.Now().UnixNano(); d > 0 {
+		return time.Duration(d)
+	}
+	return 0
+}
+
+// groupEval publishes the group's state if it changed, or always with force.
+func (h *HAL) groupEval(g *group, now int64, force bool) {
+	var off []types.GroupOffender
+	for _, m := range g.members {
+		why := m.err
+		switch {
+		case why != "":
+		case m.last == 0:
+			why = "no_value"
+		case g.stale > 0 && now-m.last > g.stale:
+			why = "stale"
+		default:
+			continue
+		}
+		off = append(off, types.GroupOffender{
+			Cap: types.CapabilityAddress{Domain: m.ck.domain, Kind: m.ck.kind, Name: m.ck.name}, Error: why,
+		})
+	}
+	link := types.LinkUp
+	if len(off) > 0 {
+		link = types.LinkDegraded
+	}
+	if !force && link == g.pub.Link && sameOffenders(off, g.pub.Offenders) {
+		return
+	}
+	g.pub = types.GroupState{Name: g.spec.Name, Link: link, Offenders: off, TS: now}
+	h.conn.Publish(h.conn.NewMessage(groupState(g.spec.Name), g.pub, true))
+}
+
+func sameOffenders(a, b []types.GroupOffender) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
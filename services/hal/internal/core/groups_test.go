@@ -0,0 +1,70 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+func TestGroup_RollsUpStatusAndStaleness(t *testing.T) {
+	clk := clock.NewFake(t0)
+	h := newTestHAL(&fakeReg{}, clk)
+	sub := h.conn.Subscribe(groupState("telemetry"))
+	state := func() string {
+		t.Helper()
+		select {
+		case m := <-sub.Channel():
+			st := m.Payload.(types.GroupState)
+			s := string(st.Link)
+			for _, o := range st.Offenders {
+				s += " " + o.Cap.Name + ":" + o.Error
+			}
+			return s
+		default:
+			return "none"
+		}
+	}
+	a, b := fakeCap("a"), fakeCap("b")
+	value := func(c CapabilitySpec, err string) {
+		h.handleEvent(Event{Addr: CapAddr{Domain: c.Domain, Kind: c.Kind, Name: c.Name}, Payload: types.TemperatureValue{}, Err: err})
+	}
+
+	h.groupUpsert(types.GroupSpec{Name: "telemetry", StaleMs: 2000, Members: []types.CapabilityAddress{
+		{Domain: a.Domain, Kind: a.Kind, Name: a.Name}, {Domain: b.Domain, Kind: b.Kind, Name: b.Name},
+	}})
+	if got := state(); got != "degraded a:no_value b:no_value" {
+		t.Fatalf("initial %q", got)
+	}
+	value(a, "")
+	value(b, "")
+	value(a, "") // unchanged roll-up is not republished
+	var got []string
+	for s := state(); s != "none"; s = state() {
+		got = append(got, s)
+	}
+	if fmt.Sprint(got) != "[degraded b:no_value up]" {
+		t.Fatalf("values %q", got)
+	}
+
+	value(b, "timeout")
+	if got := state(); got != "degraded b:timeout" {
+		t.Fatalf("error %q", got)
+	}
+	value(b, "")
+	state()
+
+	// b keeps reporting; a falls silent and goes stale just after StaleMs.
+	clk.Advance(1500 * time.Millisecond)
+	value(b, "")
+	if w := h.groupNextWait(); w != 500*time.Millisecond+1 {
+		t.Fatalf("next wait %v", w)
+	}
+	clk.Advance(501 * time.Millisecond)
+	h.groupExpire()
+	if got := state(); got != "degraded a:stale" {
+		t.Fatalf("stale %q", got)
+	}
+}
@@ -0,0 +1,197 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+
+	"tinygo.org/x/drivers"
+)
+
+// -----------------------------------------------------------------------------
+// Fakes shared by the core tests
+// -----------------------------------------------------------------------------
+
+var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fakeReg is a registry with no hardware. It records ReleaseAll calls and
+// lists bus users so busGroups can be exercised.
+type fakeReg struct {
+	mu       sync.Mutex
+	released []string
+	buses    []types.BusClaim
+	onRel    func(devID string)
+}
+
+func (r *fakeReg) ClassOf(ResourceID) (BusClass, bool) { return 0, false }
+func (r *fakeReg) ClaimI2C(string, ResourceID) (drivers.I2C, error) {
+	return nil, errcode.Unsupported
+}
+func (r *fakeReg) ReleaseI2C(string, ResourceID) {}
+func (r *fakeReg) ClaimSerial(string, ResourceID) (SerialPort, error) {
+	return nil, errcode.Unsupported
+}
+func (r *fakeReg) ReleaseSerial(string, ResourceID) {}
+func (r *fakeReg) ClaimOneWire(string, ResourceID) (OneWire, error) {
+	return nil, errcode.Unsupported
+}
+func (r *fakeReg) ReleaseOneWire(string, ResourceID) {}
+func (r *fakeReg) ClaimPIO(string, PIOProgram, []int) (PIOStateMachine, error) {
+	return nil, errcode.Unsupported
+}
+func (r *fakeReg) ReleasePIO(string, ResourceID) {}
+func (r *fakeReg) ClaimPin(string, int, PinFunc) (PinHandle, error) {
+	return nil, errcode.Unsupported
+}
+func (r *fakeReg) ReleasePin(string, int)           {}
+func (r *fakeReg) PinByName(string) (int, error)    { return 0, errcode.UnknownPin }
+func (r *fakeReg) UnsubscribeGPIOEdges(string, int) {}
+func (r *fakeReg) SubscribeGPIOEdges(string, int, GPIOEdge, time.Duration, int) (GPIOEdgeStream, error) {
+	return nil, errcode.Unsupported
+}
+
+func (r *fakeReg) ReleaseAll(devID string) {
+	r.mu.Lock()
+	r.released = append(r.released, devID)
+	f := r.onRel
+	r.mu.Unlock()
+	if f != nil {
+		f(devID)
+	}
+}
+
+func (r *fakeReg) Resources() types.ResourceMap {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return types.ResourceMap{Buses: r.buses}
+}
+
+func (r *fakeReg) releasedIDs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.released...)
+}
+
+// fakeDev is a device whose Init is supplied by the test.
+type fakeDev struct {
+	id     string
+	caps   []CapabilitySpec
+	init   func(ctx context.Context) error
+	mu     sync.Mutex
+	closed int
+}
+
+func (d *fakeDev) ID() string                     { return d.id }
+func (d *fakeDev) Capabilities() []CapabilitySpec { return d.caps }
+func (d *fakeDev) Init(ctx context.Context) error {
+	if d.init == nil {
+		return nil
+	}
+	return d.init(ctx)
+}
+func (d *fakeDev) Control(CapAddr, string, any) (EnqueueResult, error) {
+	return EnqueueResult{}, errcode.Unsupported
+}
+func (d *fakeDev) Close() error {
+	d.mu.Lock()
+	d.closed++
+	d.mu.Unlock()
+	return nil
+}
+func (d *fakeDev) closes() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.closed
+}
+
+func fakeCap(name string) CapabilitySpec {
+	return CapabilitySpec{Domain: "test", Kind: types.KindTemperature, Name: name}
+}
+
+// devs hands out test devices by ID through the "fake" builder.
+var (
+	devsMu sync.Mutex
+	devs   = map[string]*fakeDev{}
+)
+
+type fakeBuilder struct{}
+
+func (fakeBuilder) Build(_ context.Context, in BuilderInput) (Device, error) {
+	devsMu.Lock()
+	defer devsMu.Unlock()
+	d, ok := devs[in.ID]
+	if !ok {
+		return nil, errcode.InvalidParams
+	}
+	return d, nil
+}
+
+func init() { RegisterBuilder("fake", fakeBuilder{}) }
+
+func addDev(t *testing.T, d *fakeDev) types.HALDevice {
+	t.Helper()
+	devsMu.Lock()
+	devs[d.id] = d
+	devsMu.Unlock()
+	t.Cleanup(func() {
+		devsMu.Lock()
+		delete(devs, d.id)
+		devsMu.Unlock()
+	})
+	return types.HALDevice{ID: d.id, Type: "fake"}
+}
+
+func newTestHAL(reg *fakeReg, clk clock.Clock) *HAL {
+	b := bus.NewBus(8, "+", "#")
+	return NewHAL(b.NewConnection("hal"), Resources{Reg: reg, Clock: clk})
+}
+
+// -----------------------------------------------------------------------------
+// Capability registration
+// -----------------------------------------------------------------------------
+
+func TestRegisterCap_DuplicateIsConfigError(t *testing.T) {
+	h := newTestHAL(&fakeReg{}, clock.NewFake(t0))
+
+	if err := h.registerCap("a", fakeCap("x")); err != nil {
+		t.Fatalf("first registration: %v", err)
+	}
+	// Re-registering for the owner is idempotent.
+	if err := h.registerCap("a", fakeCap("x")); err != nil {
+		t.Fatalf("owner re-registration: %v", err)
+	}
+	err := h.registerCap("b", fakeCap("x"))
+	if errcode.Of(err) != errcode.Conflict {
+		t.Fatalf("duplicate: err = %v, want %s", err, errcode.Conflict)
+	}
+	if owner := h.capIndex[capKey{domain: "test", kind: types.KindTemperature, name: "x"}]; owner != "a" {
+		t.Fatalf("owner after duplicate = %q, want a", owner)
+	}
+	if err := h.registerCap("b", CapabilitySpec{Domain: "test"}); errcode.Of(err) != errcode.InvalidParams {
+		t.Fatalf("empty address: err = %v, want %s", err, errcode.InvalidParams)
+	}
+}
+
+func TestApply_DuplicateAddressRejectsConfig(t *testing.T) {
+	reg := &fakeReg{}
+	h := newTestHAL(reg, clock.NewFake(t0))
+	a := addDev(t, &fakeDev{id: "dup-a", caps: []CapabilitySpec{fakeCap("x")}})
+	b := addDev(t, &fakeDev{id: "dup-b", caps: []CapabilitySpec{fakeCap("x")}})
+
+	errs := h.applyConfig(context.Background(), types.HALConfig{Devices: []types.HALDevice{a, b}})
+	if len(errs) != 1 || errs[0].ID != "dup-b" || errs[0].Error != string(errcode.Conflict) {
+		t.Fatalf("errs = %+v, want one conflict for dup-b", errs)
+	}
+	if len(h.dev) != 0 || len(h.capIndex) != 0 {
+		t.Fatalf("rejected config left %d devices, %d capabilities", len(h.dev), len(h.capIndex))
+	}
+	if got := reg.releasedIDs(); len(got) != 2 {
+		t.Fatalf("released %v, want both devices", got)
+	}
+}
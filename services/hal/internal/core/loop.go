@@ -3,12 +3,13 @@ package core
 import (
 	"context"
 	"math/rand"
+	"reflect"
 	"time"
 
 	"devicecode-go/bus"
 	"devicecode-go/errcode"
 	"devicecode-go/types"
-	"devicecode-go/x/fmtx"
+	"devicecode-go/x/clock"
 )
 
 const eventQueueLen = 8
@@ -29,28 +30,95 @@ type HAL struct {
 	// Capability index: (domain,kind,name) -> devID
 	capIndex map[capKey]string
 
-	cfgSub  *bus.Subscription
-	ctrlSub *bus.Subscription
+	// Catalogue entries (see catalog.go)
+	catalog    map[capKey]*types.CatalogEntry
+	catalogRev uint32
+
+	cfgSub     *bus.Subscription
+	ctrlSub    *bus.Subscription
+	powerSub   *bus.Subscription
+	cfgCtrlSub *bus.Subscription
+	nvSub      *bus.Subscription
+
+	// Last config/hal accepted without errors (see configxfer.go)
+	applied types.HALConfig
+
+	// Current platform power mode (see power.go)
+	powerMode types.PowerMode
 
 	// Single-threaded publication of device events
 	evCh chan Event
 
+	// Inits that overran their deadline report back here (see bringup.go);
+	// abandoned holds those rolled back meanwhile, by device ID.
+	initLate  chan *initRun
+	abandoned map[string]*initRun
+
+	// Time source (Resources.Clock, default clock.Real)
+	clk clock.Clock
+
 	// ---- Inlined poller state (single-threaded in HAL loop) ----
-	pollWake   chan struct{} // edge-triggered wake
-	pollTimer  *time.Timer   // reused timer
-	pollItems  map[pollKey]*pollItem
-	pollHeap   pollHeap
+	pollWake  chan struct{} // edge-triggered wake
+	pollTimer clock.Timer   // reused timer
+	pollItems map[pollKey]*pollItem
+	pollHeap  pollHeap
+	pollEpoch int64 // phase origin (Unix ns)
+	pollBurst pollBurst
+
+	// Per-capability publish sequence and the monotonic clock origin.
+	capSeq    map[capKey]uint32
+	valPub    map[capKey]*bus.Value // recycling publishers for …/value
+	monoStart time.Time
+
+	// Pending read_sync requests, answered from handleEvent.
+	syncWait   map[capKey][]syncWaiter
 	randJitter *rand.Rand
 
 	// Coalescing timestamps (retained value emissions)
 	lastEmit    map[capKey]int64 // last retained value emission TS (ns) per capability
 	lastDevEmit map[string]int64 // last retained value emission TS (ns) per device
 
+	// Declarative alarm rules (see alarms.go)
+	alarms []*alarmRule
+
+	// Capability group roll-ups (see groups.go)
+	groups []*group
+
+	// Value transforms by device ID (see transforms.go)
+	xforms map[string][]xformStep
+
+	// Payload types safe to retain uncopied (see retain.go)
+	flat map[reflect.Type]bool
+
+	// Controls awaiting completion (see controldone.go); devices report
+	// on ctrlDone. ctrlTimeout holds per-device deadlines that are set.
+	ctrlWait    map[uint32]*ctrlPending
+	ctrlSeq     uint32
+	ctrlDone    chan ctrlResult
+	ctrlTimeout map[string]time.Duration
+
 	// De-chatter: last published status per capability
-	lastStatus map[capKey]struct {
-		link types.Link
-		err  string
-	}
+	lastStatus map[capKey]statusMemo
+
+	// Poll back-off for devices that keep failing (see backoff.go)
+	backoff map[string]*devBackoff
+
+	// Controls quarantined after repeated malformed payloads (see quarantine.go)
+	quar map[capKey]*quarState
+
+	// Event rates, for throttling storms (see storm.go)
+	storm map[capKey]*stormState
+
+	// Capability aliases (see aliases.go): alias -> target, and each
+	// target's aliases.
+	aliasTo map[capKey]capKey
+	aliasOf map[capKey][]capKey
+}
+
+type statusMemo struct {
+	link    types.Link
+	err     string
+	backoff uint8
 }
 
 func NewHAL(conn *bus.Connection, res Resources) *HAL {
@@ -59,48 +127,85 @@ func NewHAL(conn *bus.Connection, res Resources) *HAL {
 		res:         res,
 		dev:         map[string]Device{},
 		capIndex:    map[capKey]string{},
+		catalog:     map[capKey]*types.CatalogEntry{},
 		evCh:        make(chan Event, eventQueueLen),
+		initLate:    make(chan *initRun, 1),
+		abandoned:   map[string]*initRun{},
 		lastEmit:    make(map[capKey]int64),
 		lastDevEmit: make(map[string]int64),
-		lastStatus: make(map[capKey]struct {
-			link types.Link
-			err  string
-		}),
+		lastStatus:  make(map[capKey]statusMemo),
+		backoff:     make(map[string]*devBackoff),
+		quar:        make(map[capKey]*quarState),
+		storm:       make(map[capKey]*stormState),
+		aliasTo:     make(map[capKey]capKey),
+		aliasOf:     make(map[capKey][]capKey),
+		xforms:      make(map[string][]xformStep),
+		flat:        make(map[reflect.Type]bool),
+		ctrlWait:    make(map[uint32]*ctrlPending),
+		ctrlDone:    make(chan ctrlResult, eventQueueLen),
+		ctrlTimeout: make(map[string]time.Duration),
 		// Inlined poller
-		pollWake:   make(chan struct{}, 1),
-		pollTimer:  time.NewTimer(time.Hour),
-		pollItems:  make(map[pollKey]*pollItem),
-		randJitter: rand.New(rand.NewSource(time.Now().UnixNano())),
+		pollWake:  make(chan struct{}, 1),
+		clk:       clock.Or(res.Clock),
+		pollItems: make(map[pollKey]*pollItem),
+		syncWait:  make(map[capKey][]syncWaiter),
+		capSeq:    make(map[capKey]uint32),
+		valPub:    make(map[capKey]*bus.Value),
+		powerMode: types.PowerRun,
 	}
+	h.monoStart = h.clk.Now()
+	h.randJitter = rand.New(rand.NewSource(h.monoStart.UnixNano()))
+	h.pollEpoch = h.monoStart.UnixNano()
+	h.pollTimer = h.clk.NewTimer(time.Hour)
 	// Ensure timer is stopped & drained before use.
 	if !h.pollTimer.Stop() {
 		select {
-		case <-h.pollTimer.C:
+		case <-h.pollTimer.C():
 		default:
 		}
 	}
-	// HAL provides the emitter to devices.
+	// HAL provides the emitter and capability access to devices.
 	h.res.Pub = h
+	h.res.Clock = h.clk
+	h.res.Caps = capAccess{conn: conn}
 	return h
 }
 
 func (h *HAL) Run(ctx context.Context) {
 	h.cfgSub = h.conn.Subscribe(topicConfigHAL())
 	h.ctrlSub = h.conn.Subscribe(ctrlWildcard())
+	h.powerSub = h.conn.Subscribe(topicPowerCtrl())
+	h.cfgCtrlSub = h.conn.Subscribe(topicConfigCtrl())
+	h.nvSub = h.conn.Subscribe(topicNVCtrl())
 	defer h.conn.Unsubscribe(h.cfgSub)
 	defer h.conn.Unsubscribe(h.ctrlSub)
+	defer h.conn.Unsubscribe(h.powerSub)
+	defer h.conn.Unsubscribe(h.cfgCtrlSub)
+	defer h.conn.Unsubscribe(h.nvSub)
 
 	ready := false
 
 	for {
-		// Arm/re-arm poll timer based on next due
+		// Arm/re-arm poll timer based on next due (or read_sync deadline)
 		wait := h.pollNextWait()
+		if sw := h.syncNextWait(); sw >= 0 && (wait < 0 || sw < wait) {
+			wait = sw
+		}
+		if gw := h.groupNextWait(); gw >= 0 && (wait < 0 || gw < wait) {
+			wait = gw
+		}
+		if cw := h.ctrlNextWait(); cw >= 0 && (wait < 0 || cw < wait) {
+			wait = cw
+		}
+		if stw := h.stormNextWait(); stw >= 0 && (wait < 0 || stw < wait) {
+			wait = stw
+		}
 		switch {
 		case wait < 0:
 			// no items -> keep timer stopped
 			if !h.pollTimer.Stop() {
 				select {
-				case <-h.pollTimer.C:
+				case <-h.pollTimer.C():
 				default:
 				}
 			}
@@ -113,7 +218,7 @@ func (h *HAL) Run(ctx context.Context) {
 			// leave timer stopped
 			if !h.pollTimer.Stop() {
 				select {
-				case <-h.pollTimer.C:
+				case <-h.pollTimer.C():
 				default:
 				}
 			}
@@ -129,10 +234,22 @@ func (h *HAL) Run(ctx context.Context) {
 
 		case msg := <-h.cfgSub.Channel():
 			if v, ok := msg.Payload.(types.HALConfig); ok {
-				// Existing applyConfig is additive/idempotent for existing devices.
-				h.applyConfig(ctx, v)
-				if !ready {
+				// applyConfig is additive/idempotent for existing devices and
+				// all-or-nothing for new ones; a rejected config is reported
+				// on hal/state and the running set is left as it was.
+				errs := h.applyConfig(ctx, v)
+				h.pubResources()
+				if len(errs) > 0 {
+					level := "idle"
+					if ready {
+						level = "ready"
+					}
+					h.pubHALReport(level, "config_rejected", errs)
+				} else if h.applied = v; !ready {
 					ready = true
+					h.pubPowerState()
+					h.pubHALState("ready", "")
+				} else {
 					h.pubHALState("ready", "")
 				}
 			}
@@ -145,23 +262,43 @@ func (h *HAL) Run(ctx context.Context) {
 			}
 			h.handleControl(m) // strictly non-blocking
 
+		case m := <-h.powerSub.Channel():
+			h.handlePower(m)
+
+		case m := <-h.cfgCtrlSub.Channel():
+			h.handleConfigCtrl(m)
+
+		case m := <-h.nvSub.Channel():
+			h.handleNV(m)
+
 		case ev := <-h.evCh:
 			// All device→HAL telemetry is published from this goroutine.
 			h.handleEvent(ev)
 
+		case r := <-h.initLate:
+			h.finishInit(r)
+
+		case r := <-h.ctrlDone:
+			h.ctrlFinish(r)
+
 		// Inlined poller wakes
 		case <-h.pollWake:
 			// handled after select
-		case <-h.pollTimer.C:
+		case <-h.pollTimer.C():
 			// handled after select
 		}
 
+		h.syncExpire()
+		h.groupExpire()
+		h.ctrlExpire()
+		h.stormExpire()
+
 		// After any wake/timer: fire at most one due poll (keeps loop responsive)
 		if ready {
 			if fire := h.pollFireDue(); fire != nil {
 				// Coalescing: skip if a retained value was recently emitted
 				k := capKey{domain: fire.key.d, kind: fire.key.k, name: fire.key.n}
-				now := time.Now().UnixNano()
+				now := h.clk.Now().UnixNano()
 
 				ownerID, ok := h.capIndex[k]
 				if ok {
@@ -171,8 +308,10 @@ func (h *HAL) Run(ctx context.Context) {
 					if lastDev > lastAny {
 						lastAny = lastDev
 					}
-					if lastAny > 0 && (now-lastAny) < fire.every.Nanoseconds() {
+					if fire.fastUntil == 0 && lastAny > 0 && (now-lastAny) < fire.every.Nanoseconds() {
 						h.pollBumpAfter(fire.key.d, fire.key.k, fire.key.n, fire.key.verb, lastAny)
+					} else if !h.pollAllowed(ownerID, now) {
+						mPollBackoffSkips.Inc()
 					} else {
 						if dev := h.dev[ownerID]; dev != nil {
 							// Best-effort; devices should return Busy if already active.
@@ -186,7 +325,7 @@ func (h *HAL) Run(ctx context.Context) {
 		// Drain timer channel if we stopped it but it fired concurrently.
 		if !h.pollTimer.Stop() {
 			select {
-			case <-h.pollTimer.C:
+			case <-h.pollTimer.C():
 			default:
 			}
 		}
@@ -199,54 +338,15 @@ func (h *HAL) shutdown() {
 	for _, d := range h.dev {
 		_ = d.Close()
 	}
+	for _, p := range h.ctrlWait {
+		p.cancel()
+	}
 	// 2) If the registry supports Close(), stop background workers (e.g. I2C).
 	if c, ok := h.res.Reg.(interface{ Close() }); ok {
 		c.Close()
 	}
 }
 
-func (h *HAL) applyConfig(ctx context.Context, cfg types.HALConfig) {
-	for i := range cfg.Devices {
-		dc := cfg.Devices[i]
-		if _, exists := h.dev[dc.ID]; exists {
-			continue
-		}
-		b, ok := lookupBuilder(dc.Type)
-		if !ok {
-			panic(fmtx.Sprintf("[hal] no builder for type: %s id: %s\n", dc.Type, dc.ID))
-		}
-		dev, err := b.Build(ctx, BuilderInput{
-			ID:     dc.ID,
-			Type:   dc.Type,
-			Params: dc.Params,
-			Res:    h.res,
-		})
-		if err != nil {
-			panic(fmtx.Sprintf("[hal] build failed for: %s err: %s\n", dc.ID, err.Error()))
-		}
-		h.dev[dev.ID()] = dev
-		// Register capabilities, publish retained info + initial status:down
-		for _, cs := range dev.Capabilities() {
-			h.registerCap(dev.ID(), cs)
-		}
-		if err := dev.Init(ctx); err != nil {
-			panic(fmtx.Sprintf("[hal] init failed for: %s\n", dc.ID))
-		}
-	}
-	// Apply declarative pollers from config after all capabilities are registered.
-	for i := range cfg.Pollers {
-		ps := cfg.Pollers[i]
-		if ps.IntervalMs == 0 || ps.Verb == "" || ps.Domain == "" || ps.Kind == "" || ps.Name == "" {
-			continue
-		}
-		h.pollUpsert(
-			ps.Domain, ps.Kind, ps.Name, ps.Verb,
-			time.Duration(ps.IntervalMs)*time.Millisecond,
-			time.Duration(ps.JitterMs)*time.Millisecond,
-		)
-	}
-}
-
 func (h *HAL) handleControl(msg *bus.Message) {
 	// hal/cap/<domain>/<kind>/<name>/control/<verb>
 	cap, verb, ok := parseCapCtrl(msg.Topic)
@@ -254,18 +354,34 @@ func (h *HAL) handleControl(msg *bus.Message) {
 		h.replyErr(msg, errcode.InvalidTopic)
 		return
 	}
+	if cap.Domain == "+" || cap.Name == "+" {
+		h.handleBroadcast(msg, cap, verb)
+		return
+	}
+	cap = h.resolveAlias(cap)
+	if h.quarRefused(msg, capKey{domain: cap.Domain, kind: cap.Kind, name: cap.Name}, verb) {
+		return
+	}
 
 	// HAL-handled verbs for polling (strictly typed payloads).
 	switch verb {
 	case "poll_start":
 		ps, code := As[types.PollStart](msg.Payload)
-		if code != "" || ps.Verb == "" || ps.IntervalMs == 0 {
-			h.replyErr(msg, errcode.InvalidPayload)
+		switch {
+		case code != "":
+			h.replyErr(msg, &errcode.E{C: code, Op: verb, Msg: "want PollStart"})
+			return
+		case ps.Verb == "":
+			h.replyErr(msg, &errcode.E{C: errcode.InvalidPayload, Op: verb, Msg: "verb required", Field: "verb"})
+			return
+		case ps.IntervalMs == 0:
+			h.replyErr(msg, &errcode.E{C: errcode.InvalidPayload, Op: verb, Msg: "interval must be > 0", Field: "interval_ms"})
 			return
 		}
 		h.pollUpsert(cap.Domain, cap.Kind, cap.Name, ps.Verb,
 			time.Duration(ps.IntervalMs)*time.Millisecond,
-			time.Duration(ps.JitterMs)*time.Millisecond)
+			time.Duration(ps.JitterMs)*time.Millisecond,
+			phaseOf(ps.PhaseMs))
 		h.replyOK(msg)
 		return
 	case "poll_stop":
@@ -277,6 +393,33 @@ func (h *HAL) handleControl(msg *bus.Message) {
 		h.pollStop(cap.Domain, cap.Kind, cap.Name, verbToStop)
 		h.replyOK(msg)
 		return
+	case "burst":
+		pb, code := As[types.PollBurst](msg.Payload)
+		switch {
+		case code != "":
+			h.replyErr(msg, &errcode.E{C: code, Op: verb, Msg: "want PollBurst"})
+			return
+		case pb.RateMs == 0:
+			h.replyErr(msg, &errcode.E{C: errcode.InvalidPayload, Op: verb, Msg: "rate must be > 0", Field: "rate_ms"})
+			return
+		case pb.DurationMs == 0:
+			h.replyErr(msg, &errcode.E{C: errcode.InvalidPayload, Op: verb, Msg: "duration must be > 0", Field: "duration_ms"})
+			return
+		}
+		if _, ok := h.capIndex[capKey{domain: cap.Domain, kind: cap.Kind, name: cap.Name}]; !ok {
+			h.replyErr(msg, errcode.UnknownCapability)
+			return
+		}
+		if pb.Verb == "" {
+			pb.Verb = "read"
+		}
+		h.pollBurstStart(cap.Domain, cap.Kind, cap.Name, pb.Verb,
+			time.Duration(pb.RateMs)*time.Millisecond, time.Duration(pb.DurationMs)*time.Millisecond)
+		h.replyOK(msg)
+		return
+	case "read_sync":
+		h.handleReadSync(msg, cap)
+		return
 	}
 
 	ownerID, ok := h.capIndex[capKey{domain: cap.Domain, kind: cap.Kind, name: cap.Name}]
@@ -290,9 +433,18 @@ func (h *HAL) handleControl(msg *bus.Message) {
 		return
 	}
 
+	if id := ctrlID(msg.Topic); id != nil {
+		h.controlTracked(msg, ownerID, dev, cap, verb, id)
+		return
+	}
 	res, err := dev.Control(cap, verb, msg.Payload)
+	h.replyEnqueue(msg, res, err)
+}
+
+// replyEnqueue replies with a device's enqueue result.
+func (h *HAL) replyEnqueue(msg *bus.Message, res EnqueueResult, err error) {
 	if err != nil {
-		h.replyErr(msg, errcode.Of(err))
+		h.replyErr(msg, err)
 		return
 	}
 	if res.OK {
@@ -305,90 +457,189 @@ func (h *HAL) handleControl(msg *bus.Message) {
 func (h *HAL) handleEvent(ev Event) {
 	d, k, n := ev.Addr.Domain, ev.Addr.Kind, ev.Addr.Name
 	ck := capKey{domain: d, kind: k, name: n}
-	ts := time.Now().UnixNano()
+	ts := h.clk.Now().UnixNano()
+	mono := h.mono()
 	// 1) Error → retained status:degraded; no value/event published.
 	if ev.Err != "" {
-		h.pubStatus(d, k, n, ts, ev.Err)
+		h.noteDevErr(h.capIndex[ck])
+		h.pubStatus(d, k, n, ts, mono, ev.Err)
+		h.syncResolve(ck, nil, errcode.Code(ev.Err))
 		return
 	}
-	// 2) Success: event vs value
+	// 2) Success: event vs value leaf vs value. Retained payloads are
+	// copied so the device cannot change them under late subscribers.
+	if ev.EventTag == "" {
+		p, ok := h.retainable(ev.Payload)
+		if !ok {
+			mUnsafePayloads.Inc()
+			h.pubStatus(d, k, n, ts, mono, "unsafe_payload")
+			return
+		}
+		ev.Payload = p
+	}
 	if ev.EventTag != "" {
-		h.conn.Publish(h.conn.NewMessage(capEventTagged(d, k, n, ev.EventTag), ev.Payload, false))
+		if !h.stormAdmit(ck, ts) {
+			return
+		}
+		h.pubCap(ck, capEventTagged(d, k, n, ev.EventTag), ev.Payload, false, mono)
+	} else if ev.Leaf != "" {
+		h.pubCap(ck, capValue(d, k, n).Append(ev.Leaf), ev.Payload, true, mono)
 	} else {
-		h.conn.Publish(h.conn.NewMessage(capValue(d, k, n), ev.Payload, true))
+		ev.Payload = h.transform(ck, ev.Payload)
+		h.pubValue(ck, ev.Payload, mono)
+		h.noteDevOK(h.capIndex[ck])
 		// Record last successful retained value emission for coalescing (capability-level).
 		h.lastEmit[ck] = ts
 		// Also record device-level emission time for cross-capability coalescing.
 		if ownerID, ok := h.capIndex[ck]; ok {
 			h.lastDevEmit[ownerID] = ts
 		}
+		h.alarmEval(ck, ev.Payload, ts)
+		h.groupValue(ck, ts)
+		h.syncResolve(ck, ev.Payload, nil)
 	}
 	// 3) Retained status: up
-	h.pubStatus(d, k, n, ts, "")
+	h.pubStatus(d, k, n, ts, mono, "")
 }
 
 func (h *HAL) pubHALState(level, status string) {
+	h.pubHALReport(level, status, nil)
+}
+
+func (h *HAL) pubHALReport(level, status string, errs []types.ConfigError) {
 	h.conn.Publish(h.conn.NewMessage(
 		T("hal", "state"),
-		types.HALState{Level: level, Status: status, TS: time.Now().UnixNano()},
+		types.HALState{Level: level, Status: status, TS: h.clk.Now().UnixNano(), Errors: errs},
 		true,
 	))
 }
 
-// registerCap indexes the capability and publishes its info and initial status:down (retained).
-func (h *HAL) registerCap(devID string, cs CapabilitySpec) {
+// registerCap indexes the capability and publishes its info and initial
+// status:down (retained). An empty or already-owned address is refused
+// with InvalidParams or Conflict and nothing is published.
+func (h *HAL) registerCap(devID string, cs CapabilitySpec) error {
 	if cs.Domain == "" || string(cs.Kind) == "" || cs.Name == "" {
-		panic(fmtx.Sprintf("[hal] capability must specify non-empty domain/kind/name: dev=%s", devID))
+		return &errcode.E{C: errcode.InvalidParams, Msg: "empty capability address"}
 	}
 	domain := cs.Domain
 	k := cs.Kind
 	name := cs.Name
-	// Index for control routing.
+	// Index for control routing. Addresses are the stable public identity of
+	// a capability, so two devices may not share one.
+	if owner, ok := h.capIndex[capKey{domain: domain, kind: k, name: name}]; ok && owner != devID {
+		return &errcode.E{C: errcode.Conflict,
+			Msg: "capability " + domain + "/" + string(k) + "/" + name + " owned by " + owner}
+	}
 	h.capIndex[capKey{domain: domain, kind: k, name: name}] = devID
 	// Publish static info (retained).
-	h.conn.Publish(h.conn.NewMessage(
-		capInfo(domain, k, name),
-		types.Info{
-			SchemaVersion: cs.Info.SchemaVersion,
-			Driver:        cs.Info.Driver,
-			Detail:        cs.Info.Detail,
-		},
-		true,
-	))
+	info := types.Info{
+		SchemaVersion: cs.Info.SchemaVersion,
+		Driver:        cs.Info.Driver,
+		Detail:        cs.Info.Detail,
+	}
+	im := h.conn.NewMessage(capInfo(domain, k, name), info, true)
+	h.conn.Publish(im)
+	h.mirror(capKey{domain: domain, kind: k, name: name}, im)
+	h.catalogInfo(capKey{domain: domain, kind: k, name: name}, info)
+	// Publish supported control verbs (retained).
+	h.pubVerbs(devID, CapAddr{Domain: domain, Kind: k, Name: name})
 	// Publish initial status: down (retained).
-	h.conn.Publish(h.conn.NewMessage(
-		capStatus(domain, k, name),
-		types.CapabilityStatus{Link: types.LinkDown, TS: time.Now().UnixNano()},
-		true,
-	))
-	h.lastStatus[capKey{domain: domain, kind: k, name: name}] =
-		struct {
-			link types.Link
-			err  string
-		}{link: types.LinkDown, err: ""}
+	h.pubCap(capKey{domain: domain, kind: k, name: name}, capStatus(domain, k, name),
+		types.CapabilityStatus{Link: types.LinkDown, TS: h.clk.Now().UnixNano()}, true, h.mono())
+	h.lastStatus[capKey{domain: domain, kind: k, name: name}] = statusMemo{link: types.LinkDown}
+	return nil
+}
+
+// halVerbs are handled by the HAL itself for every capability.
+var halVerbs = [...]types.VerbInfo{
+	{Verb: "poll_start", Payload: "PollStart"},
+	{Verb: "poll_stop", Payload: "PollStop"},
+	{Verb: "burst", Payload: "PollBurst"},
+	{Verb: "read_sync", Payload: "ReadSync"},
+}
+
+// pubVerbs publishes the retained verb list for a capability: the device's
+// registered verbs (if it can enumerate them) followed by HAL-level verbs.
+func (h *HAL) pubVerbs(devID string, addr CapAddr) {
+	var specs []VerbSpec
+	if vl, ok := h.dev[devID].(VerbLister); ok {
+		specs = vl.Verbs(addr)
+	}
+	out := make([]types.VerbInfo, 0, len(specs)+len(halVerbs))
+	for _, s := range specs {
+		out = append(out, types.VerbInfo{Verb: s.Verb, Payload: s.Payload})
+	}
+	out = append(out, halVerbs[:]...)
+	ck := capKey{domain: addr.Domain, kind: addr.Kind, name: addr.Name}
+	h.catalogVerbs(ck, out)
+	m := h.conn.NewMessage(capVerbs(addr.Domain, addr.Kind, addr.Name), types.CapabilityVerbs{Verbs: out}, true)
+	h.conn.Publish(m)
+	h.mirror(ck, m)
 }
 
 // pubStatus publishes a retained status update for a capability.
 // err=="" → LinkUp; otherwise LinkDegraded and Error is included.
-func (h *HAL) pubStatus(domain string, kind types.Kind, name string, ts int64, err string) {
+func (h *HAL) pubStatus(domain string, kind types.Kind, name string, ts, mono int64, err string) {
 	link := types.LinkUp
 	if err != "" {
 		link = types.LinkDegraded
 	}
 	ck := capKey{domain: domain, kind: kind, name: name}
-	prev := h.lastStatus[ck]
-	if prev.link == link && prev.err == err {
+	h.groupStatus(ck, err, ts)
+	memo := statusMemo{link: link, err: err, backoff: h.backoffLevel(h.capIndex[ck])}
+	if h.lastStatus[ck] == memo {
 		return // unchanged → suppress publish
 	}
-	h.lastStatus[ck] = struct {
-		link types.Link
-		err  string
-	}{link: link, err: err}
-	h.conn.Publish(h.conn.NewMessage(
-		capStatus(domain, kind, name),
-		types.CapabilityStatus{Link: link, TS: ts, Error: err},
-		true,
-	))
+	h.lastStatus[ck] = memo
+	h.pubCap(ck, capStatus(domain, kind, name),
+		types.CapabilityStatus{Link: link, TS: ts, Error: err, Backoff: memo.backoff}, true, mono)
+}
+
+// pubCap publishes a capability message stamped with the capability's next
+// sequence number and the HAL monotonic time. Value, event and status share
+// one sequence, so a consumer seeing a gap knows it missed a publish.
+func (h *HAL) pubCap(ck capKey, tp bus.Topic, payload any, retained bool, mono int64) {
+	m := h.conn.NewMessage(tp, payload, retained)
+	m.Seq, m.Mono = h.nextSeq(ck), mono
+	h.conn.Publish(m)
+	h.mirror(ck, m)
+}
+
+// pubValue is the telemetry hot path: the retained …/value topic and its
+// messages are reused rather than rebuilt per sample.
+func (h *HAL) pubValue(ck capKey, payload any, mono int64) {
+	seq := h.nextSeq(ck)
+	h.valueOf(ck).Publish(payload, seq, mono)
+	h.mirrorValue(ck, payload, seq, mono)
+}
+
+// valueOf is the …/value publisher for ck, made on first use.
+func (h *HAL) valueOf(ck capKey) *bus.Value {
+	v := h.valPub[ck]
+	if v == nil {
+		v = h.conn.NewValue(capValue(ck.domain, ck.kind, ck.name), true)
+		h.valPub[ck] = v
+	}
+	return v
+}
+
+// nextSeq advances the capability's sequence, shared by all its topics.
+func (h *HAL) nextSeq(ck capKey) uint32 {
+	s := h.capSeq[ck] + 1
+	if s == 0 {
+		s = 1 // 0 means unset
+	}
+	h.capSeq[ck] = s
+	return s
+}
+
+// mono returns ns since HAL start from the monotonic clock reading, so it
+// is unaffected by wall-clock steps (e.g. time sync).
+func (h *HAL) mono() int64 {
+	if d := h.clk.Now().Sub(h.monoStart); d > 0 {
+		return int64(d)
+	}
+	return 1
 }
 
 // ---- HAL as EventEmitter (enqueue to single publisher) ----
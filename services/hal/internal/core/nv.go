@@ -0,0 +1,104 @@
+package core
+
+import (
+	"devicecode-go/bus"
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+)
+
+// ---------------- Application NV records ----------------
+//
+// hal/nv/control/get (types.NVGet) replies types.NVRecord and
+// hal/nv/control/put (types.NVPut) stores a record and replies OK, both
+// through the registry's NVStore. Without one, both reply unsupported and
+// callers fall back to RAM.
+//
+// log_get and log_put do the same for the single log checkpoint
+// (types.LastLog) in the registry's LogStore, kept apart from the records
+// so a checkpoint every few minutes does not wear their region.
+
+// maxNVRecord bounds a record; NV records are counters and small settings.
+const maxNVRecord = 256
+
+// maxLastLog bounds a checkpoint's data, well inside a 4 KiB flash slot.
+const maxLastLog = 2048
+
+func (h *HAL) handleNV(m *bus.Message) {
+	verb, _ := m.Topic.At(3).(string)
+	if verb == "log_get" || verb == "log_put" {
+		h.handleLastLog(m, verb)
+		return
+	}
+	st, ok := h.res.Reg.(NVStore)
+	if !ok {
+		h.replyErr(m, &errcode.E{C: errcode.Unsupported, Op: "nv", Msg: "no nv store"})
+		return
+	}
+	switch verb {
+	case "get":
+		g, code := As[types.NVGet](m.Payload)
+		if code != "" || g.Key == "" {
+			h.replyErr(m, &errcode.E{C: errcode.InvalidPayload, Op: "nv_get", Msg: "want NVGet", Field: "key"})
+			return
+		}
+		b, found := st.LoadNV(g.Key)
+		if m.CanReply() {
+			h.conn.Reply(m, types.NVRecord{Key: g.Key, Found: found, Data: b}, false)
+		}
+	case "put":
+		p, code := As[types.NVPut](m.Payload)
+		if code != "" || p.Key == "" {
+			h.replyErr(m, &errcode.E{C: errcode.InvalidPayload, Op: "nv_put", Msg: "want NVPut", Field: "key"})
+			return
+		}
+		if len(p.Data) > maxNVRecord {
+			h.replyErr(m, &errcode.E{C: errcode.InvalidPayload, Op: "nv_put", Msg: "record too large", Field: "data"})
+			return
+		}
+		if err := st.SaveNV(p.Key, p.Data); err != nil {
+			h.replyErr(m, err)
+			return
+		}
+		h.replyOK(m)
+	default:
+		h.replyErr(m, &errcode.E{C: errcode.Unsupported, Op: "nv", Msg: "unknown verb " + verb})
+	}
+}
+
+func (h *HAL) handleLastLog(m *bus.Message, verb string) {
+	st, ok := h.res.Reg.(LogStore)
+	if !ok {
+		h.replyErr(m, &errcode.E{C: errcode.Unsupported, Op: verb, Msg: "no log store"})
+		return
+	}
+	if verb == "log_get" {
+		var ll types.LastLog
+		if b, found := st.LoadLog(); found {
+			// A checkpoint that does not decode reads as none.
+			if v, _, err := types.UnmarshalPayload("LastLog", b); err == nil {
+				ll, _ = v.(types.LastLog)
+				ll.Found = true
+			}
+		}
+		if m.CanReply() {
+			h.conn.Reply(m, ll, false)
+		}
+		return
+	}
+	ll, code := As[types.LastLog](m.Payload)
+	if code != "" {
+		h.replyErr(m, &errcode.E{C: code, Op: verb, Msg: "want LastLog"})
+		return
+	}
+	if len(ll.Data) > maxLastLog {
+		h.replyErr(m, &errcode.E{C: errcode.InvalidPayload, Op: verb, Msg: "checkpoint too large", Field: "data"})
+		return
+	}
+	ll.Found = false
+	_, b, _ := types.MarshalPayload(ll) // registered, so it always encodes
+	if err := st.SaveLog(b); err != nil {
+		h.replyErr(m, err)
+		return
+	}
+	h.replyOK(m)
+}
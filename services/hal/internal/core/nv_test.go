@@ -0,0 +1,54 @@
+package core
+
+import (
+	"testing"
+
+	"devicecode-go/bus"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+// logReg is a fakeReg with an in-memory LogStore.
+type logReg struct {
+	fakeReg
+	saved []byte
+}
+
+func (r *logReg) SaveLog(b []byte) error  { r.saved = append([]byte(nil), b...); return nil }
+func (r *logReg) LoadLog() ([]byte, bool) { return r.saved, r.saved != nil }
+
+func TestLastLog_PutThenGet(t *testing.T) {
+	reg := &logReg{}
+	b := bus.NewBus(8, "+", "#")
+	h := NewHAL(b.NewConnection("hal"), Resources{Reg: reg, Clock: clock.NewFake(t0)})
+	replies := h.conn.Subscribe(bus.T("reply"))
+	send := func(verb string, payload any) any {
+		t.Helper()
+		m := h.conn.NewMessage(T("hal", "nv", "control", verb), payload, false)
+		m.ReplyTo = bus.T("reply")
+		h.handleNV(m)
+		return recv(t, replies).Payload
+	}
+
+	if r := send("log_get", nil); r.(types.LastLog).Found {
+		t.Fatalf("empty store: %+v", r)
+	}
+	want := types.LastLog{TS: 42, Data: []byte("0.100 [main] entering reactor loop …\n")}
+	if r := send("log_put", want); r != (types.OKReply{OK: true}) {
+		t.Fatalf("put: %+v", r)
+	}
+	got := send("log_get", nil).(types.LastLog)
+	if !got.Found || got.TS != want.TS || string(got.Data) != string(want.Data) {
+		t.Fatalf("got %+v", got)
+	}
+	if r := send("log_put", types.LastLog{Data: make([]byte, maxLastLog+1)}); r.(types.ErrorReply).Error != "invalid_payload" {
+		t.Fatalf("oversize: %+v", r)
+	}
+
+	// Without a LogStore: unsupported.
+	h = newTestHAL(&fakeReg{}, clock.NewFake(t0))
+	replies = h.conn.Subscribe(bus.T("reply"))
+	if r := send("log_get", nil); r.(types.ErrorReply).Error != "unsupported" {
+		t.Fatalf("no store: %+v", r)
+	}
+}
@@ -2,8 +2,11 @@ package core
 
 import (
 	"container/heap"
-	"devicecode-go/types"
+	"sort"
 	"time"
+
+	"devicecode-go/services/metrics"
+	"devicecode-go/types"
 )
 
 // ---------------- Inlined poller: types & helpers ----------------
@@ -18,11 +21,22 @@ type pollKey struct {
 type pollItem struct {
 	key    pollKey
 	due    int64
-	every  time.Duration
+	every  time.Duration // 0: only bursting
 	jitter time.Duration
+	phase  time.Duration // slot offset from pollEpoch, in [0, every)
 	index  int
+
+	// Burst (see pollBurstStart): fire every fast until fastUntil.
+	fast      time.Duration
+	fastUntil int64
 }
 
+// Burst limits: the fastest rate HAL will poll at, and the longest burst.
+const (
+	burstFloor  = 50 * time.Millisecond
+	burstMaxDur = time.Minute
+)
+
 type pollHeap []*pollItem
 
 func (h pollHeap) Len() int           { return len(h) }
@@ -37,6 +51,7 @@ func (h *pollHeap) Pop() any {
 	*h = old[:n-1]
 	return it
 }
+func (h *pollHeap) reinit() { heap.Init(h) }
 func (h pollHeap) Top() *pollItem {
 	if len(h) == 0 {
 		return nil
@@ -44,26 +59,111 @@ func (h pollHeap) Top() *pollItem {
 	return h[0]
 }
 
-func (h *HAL) pollUpsert(d string, k types.Kind, n, verb string, interval, jitter time.Duration) {
+// phaseOf converts an optional phase_ms into pollUpsert's phase argument.
+func phaseOf(ms *uint32) time.Duration {
+	if ms == nil {
+		return -1
+	}
+	return time.Duration(*ms) * time.Millisecond
+}
+
+// pollUpsert schedules verb every interval. A negative phase places the
+// poller automatically (see pollAutoPhase); otherwise phase is taken modulo
+// the interval. Polls fire on the grid pollEpoch + phase + k*interval, so
+// pollers that share an interval keep their spacing instead of bursting.
+func (h *HAL) pollUpsert(d string, k types.Kind, n, verb string, interval, jitter, phase time.Duration) {
 	if interval <= 0 || verb == "" {
 		return
 	}
 	key := pollKey{d: d, k: k, n: n, verb: verb}
-	nextDue := time.Now().Add(h.jittered(interval, jitter)).UnixNano()
-	if it := h.pollItems[key]; it == nil {
-		it2 := &pollItem{
-			key:    key,
-			due:    nextDue,
-			every:  interval,
-			jitter: jitter,
-			index:  -1,
+	it := h.pollItems[key]
+	switch {
+	case phase >= 0:
+	case it != nil && it.every == interval:
+		phase = it.phase // keep an existing placement
+	default:
+		phase = h.pollAutoPhase(interval, it)
+	}
+	if it == nil {
+		it = &pollItem{key: key, index: -1}
+		h.pollItems[key] = it
+	}
+	it.every = interval
+	it.jitter = jitter
+	it.phase = phase % interval
+	it.due = h.pollNext(it, h.clk.Now().UnixNano())
+	if it.index < 0 {
+		heap.Push(&h.pollHeap, it)
+	} else {
+		heap.Fix(&h.pollHeap, it.index)
+	}
+	h.pollReschedule()
+}
+
+// pollAutoPhase returns the midpoint of the widest gap between the phases
+// of other pollers with the same interval (0 if there are none).
+func (h *HAL) pollAutoPhase(interval time.Duration, self *pollItem) time.Duration {
+	var ph []time.Duration
+	for _, it := range h.pollItems {
+		if it != self && it.every == interval {
+			ph = append(ph, it.phase)
 		}
-		h.pollItems[key] = it2
-		heap.Push(&h.pollHeap, it2)
+	}
+	if len(ph) == 0 {
+		return 0
+	}
+	sort.Slice(ph, func(i, j int) bool { return ph[i] < ph[j] })
+	// Wrap-around gap from the last phase back to the first.
+	start, gap := ph[len(ph)-1], ph[0]+interval-ph[len(ph)-1]
+	for i := 1; i < len(ph); i++ {
+		if g := ph[i] - ph[i-1]; g > gap {
+			start, gap = ph[i-1], g
+		}
+	}
+	return (start + gap/2) % interval
+}
+
+// pollNext returns the first grid slot strictly after t, plus jitter.
+// The grid uses the power-mode interval so idle stretching keeps phases.
+// A burst fires fast after t, unjittered, until it ends; the first fire
+// after that ends it.
+func (h *HAL) pollNext(it *pollItem, t int64) int64 {
+	if it.fastUntil != 0 {
+		if t < it.fastUntil {
+			return t + int64(it.fast)
+		}
+		it.fast, it.fastUntil = 0, 0
+	}
+	if it.every == 0 {
+		return -1
+	}
+	every := int64(h.pollInterval(it.every))
+	origin := h.pollEpoch + int64(it.phase)
+	slot := origin
+	if t >= origin {
+		slot = origin + ((t-origin)/every+1)*every
+	}
+	return slot + int64(h.jittered(0, it.jitter))
+}
+
+// pollBurstStart polls verb every rate (at least burstFloor) for dur (at
+// most burstMaxDur), starting now, then reverts to the poller's schedule;
+// a capability without one stops being polled.
+func (h *HAL) pollBurstStart(d string, k types.Kind, n, verb string, rate, dur time.Duration) {
+	rate = max(rate, burstFloor)
+	dur = min(dur, burstMaxDur)
+	key := pollKey{d: d, k: k, n: n, verb: verb}
+	it := h.pollItems[key]
+	if it == nil {
+		it = &pollItem{key: key, index: -1}
+		h.pollItems[key] = it
+	}
+	now := h.clk.Now().UnixNano()
+	it.fast, it.fastUntil = rate, now+int64(dur)
+	it.due = now
+	if it.index < 0 {
+		heap.Push(&h.pollHeap, it)
 	} else {
-		it.every = interval
-		it.jitter = jitter
-		it.due = nextDue
 		heap.Fix(&h.pollHeap, it.index)
 	}
 	h.pollReschedule()
@@ -80,12 +180,9 @@ func (h *HAL) pollStop(d string, k types.Kind, n, verb string) {
 
 func (h *HAL) pollBumpAfter(d string, k types.Kind, n, verb string, lastEmitNs int64) {
 	key := pollKey{d: d, k: k, n: n, verb: verb}
-	if it := h.pollItems[key]; it != nil {
-		due := time.Unix(0, lastEmitNs).Add(it.every)
-		if due.Before(time.Now()) {
-			due = time.Now()
-		}
-		it.due = due.UnixNano()
+	if it := h.pollItems[key]; it != nil && it.every > 0 {
+		// Next slot at least one interval after the last emission.
+		it.due = h.pollNext(it, lastEmitNs+int64(it.every)-1)
 		heap.Fix(&h.pollHeap, it.index)
 		h.pollReschedule()
 	}
@@ -103,7 +200,7 @@ func (h *HAL) pollNextWait() time.Duration {
 	if top == nil {
 		return -1
 	}
-	now := time.Now().UnixNano()
+	now := h.clk.Now().UnixNano()
 	if top.due <= now {
 		return 0
 	}
@@ -111,17 +208,56 @@ func (h *HAL) pollNextWait() time.Duration {
 }
 
 func (h *HAL) pollFireDue() *pollItem {
-	now := time.Now().UnixNano()
+	now := h.clk.Now().UnixNano()
 	top := h.pollHeap.Top()
 	if top != nil && top.due <= now {
 		fire := heap.Pop(&h.pollHeap).(*pollItem)
-		fire.due = time.Now().Add(h.jittered(fire.every, fire.jitter)).UnixNano()
+		wasFast := fire.fastUntil != 0
+		if fire.due = h.pollNext(fire, now); fire.due < 0 {
+			delete(h.pollItems, fire.key) // a burst without a poller ended
+			return nil
+		}
 		heap.Push(&h.pollHeap, fire)
+		if wasFast && fire.fastUntil == 0 {
+			return nil // the burst ended; the poller's own slot follows
+		}
+		h.pollBurst.note(now)
 		return fire
 	}
 	return nil
 }
 
+// ---------------- Poll concurrency stat ----------------
+
+// pollBurstWindow groups fires that land on the bus back to back.
+const pollBurstWindow = 5 * time.Millisecond
+
+var (
+	mPollBurst    = metrics.NewHistogram("hal.poll.burst", 1, 2, 4, 8)
+	mPollBurstMax = metrics.NewGauge("hal.poll.burst_max")
+)
+
+// pollBurst counts polls fired within pollBurstWindow of the first one.
+// Each completed burst is observed into hal.poll.burst.
+type pollBurst struct {
+	start int64
+	n     int32
+}
+
+func (b *pollBurst) note(now int64) {
+	if b.n > 0 && now-b.start <= int64(pollBurstWindow) {
+		b.n++
+	} else {
+		if b.n > 0 {
+			mPollBurst.Observe(int64(b.n))
+		}
+		b.start, b.n = now, 1
+	}
+	if b.n > mPollBurstMax.Value() {
+		mPollBurstMax.Set(b.n)
+	}
+}
+
 func (h *HAL) jittered(interval, jitter time.Duration) time.Duration {
 	if jitter <= 0 {
 		return interval
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+// fires advances clk by step until d has passed, collecting the offset
+// from t0 and name of every poll that falls due.
+func fires(h *HAL, clk *clock.Fake, d, step time.Duration) (at []time.Duration, names []string) {
+	for end := clk.Now().Add(d); clk.Now().Before(end); {
+		clk.Advance(step)
+		for it := h.pollFireDue(); it != nil; it = h.pollFireDue() {
+			at = append(at, clk.Now().Sub(t0))
+			names = append(names, it.key.n)
+		}
+	}
+	return at, names
+}
+
+func TestPoller_FiresOnPhaseGrid(t *testing.T) {
+	clk := clock.NewFake(t0)
+	h := newTestHAL(&fakeReg{}, clk)
+
+	h.pollUpsert("env", types.KindTemperature, "a", "read", time.Second, 0, 250*time.Millisecond)
+	if w := h.pollNextWait(); w != 250*time.Millisecond {
+		t.Fatalf("first wait = %v", w)
+	}
+	at, _ := fires(h, clk, 3*time.Second, 50*time.Millisecond)
+	want := []time.Duration{250 * time.Millisecond, 1250 * time.Millisecond, 2250 * time.Millisecond}
+	if len(at) != len(want) {
+		t.Fatalf("fired at %v, want %v", at, want)
+	}
+	for i := range want {
+		if at[i] != want[i] {
+			t.Fatalf("fired at %v, want %v", at, want)
+		}
+	}
+
+	// A late tick fires once and realigns to the grid rather than
+	// catching up.
+	clk.Advance(2500 * time.Millisecond) // now 5.5 s: slots at 3.25 and 4.25 missed
+	if it := h.pollFireDue(); it == nil {
+		t.Fatal("overdue poll did not fire")
+	}
+	if it := h.pollFireDue(); it != nil {
+		t.Fatal("missed slots fired twice")
+	}
+	if w := h.pollNextWait(); w != 750*time.Millisecond {
+		t.Fatalf("wait after late tick = %v", w)
+	}
+}
+
+func TestPoller_AutoPhaseSpreadsSameInterval(t *testing.T) {
+	clk := clock.NewFake(t0)
+	h := newTestHAL(&fakeReg{}, clk)
+
+	for _, n := range []string{"a", "b", "c", "d"} {
+		h.pollUpsert("env", types.KindTemperature, n, "read", time.Second, 0, -1)
+	}
+	at, names := fires(h, clk, time.Second, 10*time.Millisecond)
+	if len(at) != 4 {
+		t.Fatalf("fired %v at %v", names, at)
+	}
+	// Phases 0, 500, 750, 250 ms: each lands in the widest gap left by
+	// the ones before; a's first slot is a full interval away.
+	want := map[string]time.Duration{"b": 500 * time.Millisecond, "c": 750 * time.Millisecond,
+		"d": 250 * time.Millisecond, "a": time.Second}
+	for i, n := range names {
+		if at[i] != want[n] {
+			t.Fatalf("%s fired at %v, want %v", n, at[i], want[n])
+		}
+	}
+
+	// Stopping one leaves the others on their slots.
+	h.pollStop("env", types.KindTemperature, "c", "read")
+	_, names = fires(h, clk, time.Second, 10*time.Millisecond)
+	if len(names) != 3 || names[0] != "d" || names[1] != "b" || names[2] != "a" {
+		t.Fatalf("after stop fired %v", names)
+	}
+}
+
+func TestPoller_IdleStretchesInterval(t *testing.T) {
+	clk := clock.NewFake(t0)
+	h := newTestHAL(&fakeReg{}, clk)
+	h.powerMode = types.PowerIdle
+
+	h.pollUpsert("env", types.KindTemperature, "a", "read", time.Second, 0, 0)
+	at, _ := fires(h, clk, 3*idlePollScale*time.Second, 100*time.Millisecond)
+	if len(at) != 3 || at[0] != idlePollScale*time.Second || at[1]-at[0] != idlePollScale*time.Second {
+		t.Fatalf("idle fires at %v", at)
+	}
+}
+
+func TestPoller_BurstRevertsToGrid(t *testing.T) {
+	clk := clock.NewFake(t0)
+	h := newTestHAL(&fakeReg{}, clk)
+	ms := time.Millisecond
+
+	h.pollUpsert("env", types.KindTemperature, "a", "read", time.Second, 0, 0)
+	fires(h, clk, 1100*ms, 50*ms)
+	h.pollBurstStart("env", types.KindTemperature, "a", "read", 100*ms, 350*ms)
+	// A capability with no poller is polled for the burst only, and no
+	// faster than the floor.
+	h.pollBurstStart("env", types.KindTemperature, "b", "read", 10*ms, 120*ms)
+
+	at, names := fires(h, clk, 2*time.Second, 25*ms)
+	var got []string
+	for i := range at {
+		got = append(got, names[i]+"@"+at[i].String())
+	}
+	want := "[a@1.125s b@1.125s b@1.175s a@1.225s a@1.325s a@1.425s a@2s a@3s]"
+	if s := fmt.Sprint(got); s != want {
+		t.Fatalf("fired %s\nwant  %s", s, want)
+	}
+	if _, ok := h.pollItems[pollKey{d: "env", k: types.KindTemperature, n: "b", verb: "read"}]; ok {
+		t.Fatal("burst-only poller kept")
+	}
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+)
+
+// ---------------- Power mode coordination ----------------
+//
+// The application requests a mode on hal/power/control/set. HAL applies it
+// through the registry (if it implements PowerManager), publishes retained
+// hal/power/state, and in idle stretches poll intervals so the core spends
+// longer in sleep. Devices keep running: edge-driven sources (SMBALERT#,
+// buttons, UART RX) still emit events and wake the application.
+
+// idlePollScale multiplies poll intervals while in PowerIdle.
+const idlePollScale = 10
+
+func (h *HAL) pollInterval(every time.Duration) time.Duration {
+	if h.powerMode == types.PowerIdle {
+		return every * idlePollScale
+	}
+	return every
+}
+
+func (h *HAL) handlePower(m *bus.Message) {
+	ps, code := As[types.PowerSet](m.Payload)
+	if code != "" {
+		h.replyErr(m, &errcode.E{C: code, Op: "power", Msg: "want PowerSet"})
+		return
+	}
+	switch ps.Mode {
+	case types.PowerRun, types.PowerIdle:
+	default:
+		h.replyErr(m, &errcode.E{C: errcode.InvalidPayload, Op: "power", Msg: "unknown mode", Field: "mode"})
+		return
+	}
+	if ps.Mode != h.powerMode {
+		if pm, ok := h.res.Reg.(PowerManager); ok {
+			if err := pm.SetPowerMode(ps.Mode); err != nil {
+				h.replyErr(m, err)
+				return
+			}
+		}
+		h.powerMode = ps.Mode
+		h.pubPowerState()
+		// Leaving idle: pull stretched polls back in.
+		if ps.Mode == types.PowerRun {
+			now := h.clk.Now().UnixNano()
+			for _, it := range h.pollItems {
+				if due := h.pollNext(it, now); due < it.due {
+					it.due = due
+				}
+			}
+			h.pollHeap.reinit()
+			h.pollReschedule()
+		}
+	}
+	h.replyOK(m)
+}
+
+func (h *HAL) pubPowerState() {
+	h.conn.Publish(h.conn.NewMessage(
+		topicPowerState(),
+		types.PowerState{Mode: h.powerMode, TS: h.clk.Now().UnixNano()},
+		true,
+	))
+}
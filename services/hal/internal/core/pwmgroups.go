@@ -0,0 +1,145 @@
+package core
+
+import (
+	"sync"
+
+	"devicecode-go/errcode"
+	"devicecode-go/x/mathx"
+)
+
+// ---------------- PWM frequency groups ----------------
+//
+// Outputs often share one period generator: the two channels of an RP2040
+// slice, or all sixteen outputs of a PCA9685. PWMGroups is the policy for
+// such a group:
+//
+//   - The first user programs the group frequency.
+//   - Later users must ask for the same frequency (errcode.Conflict otherwise).
+//   - A sole user may change it.
+//   - The last user to leave clears it.
+//
+// Negotiate relaxes the second rule for users that state a tolerance.
+//
+// Groups are keyed however suits the hardware (slice number, chip channel
+// block). The zero value is ready to use.
+
+type PWMGroups struct {
+	mu sync.Mutex
+	g  map[int]*pwmGroup
+}
+
+type pwmGroup struct {
+	hz     uint64
+	lo, hi uint64 // frequencies every current user accepts
+	users  int
+}
+
+// Join counts a user of group k at hz. joined says the caller has already
+// been counted (a repeated Configure). program writes the new period to the
+// hardware; it runs under the group lock, and only when the period must
+// change. On error nothing is recorded.
+func (p *PWMGroups) Join(k int, hz uint64, joined bool, program func() error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.g == nil {
+		p.g = make(map[int]*pwmGroup)
+	}
+	g := p.g[k]
+	if g == nil {
+		g = &pwmGroup{}
+		p.g[k] = g
+	}
+	switch {
+	case g.users == 0:
+		if err := program(); err != nil {
+			return err
+		}
+		g.hz, g.lo, g.hi, g.users = hz, hz, hz, 1
+	case !joined:
+		if g.hz != hz {
+			return errcode.Conflict
+		}
+		g.users++
+	case g.hz == hz:
+	case g.users == 1:
+		if err := program(); err != nil {
+			return err
+		}
+		g.hz, g.lo, g.hi = hz, hz, hz
+	default:
+		return errcode.Conflict
+	}
+	return nil
+}
+
+// Negotiate is Join for a user that accepts any frequency within tolPct
+// percent of hz, and returns the frequency in effect. A new user takes the
+// group's frequency if it is within tolerance. Otherwise, if every user's
+// window overlaps, the group moves to the highest frequency in the overlap;
+// program then receives that frequency and must rescale the outputs
+// already running. Strict (Join) users have a zero-width window. The
+// overlap only narrows while the group is in use, so a user leaving does
+// not widen it again until the group empties.
+func (p *PWMGroups) Negotiate(k int, hz uint64, tolPct uint8, joined bool, program func(hz uint64) error) (uint64, error) {
+	d := hz * uint64(tolPct) / 100
+	lo, hi := hz-d, hz+d
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.g == nil {
+		p.g = make(map[int]*pwmGroup)
+	}
+	g := p.g[k]
+	if g == nil {
+		g = &pwmGroup{}
+		p.g[k] = g
+	}
+	switch {
+	case g.users == 0, joined && g.users == 1:
+		if g.hz != hz || g.users == 0 {
+			if err := program(hz); err != nil {
+				return 0, err
+			}
+		}
+		g.hz, g.lo, g.hi, g.users = hz, lo, hi, 1
+	case g.hz >= lo && g.hz <= hi:
+		if !joined {
+			g.lo, g.hi = mathx.Max(g.lo, lo), mathx.Min(g.hi, hi)
+			g.users++
+		}
+	case joined:
+		return 0, errcode.Conflict
+	default:
+		lo, hi = mathx.Max(g.lo, lo), mathx.Min(g.hi, hi)
+		if lo > hi {
+			return 0, errcode.Conflict
+		}
+		if err := program(hi); err != nil {
+			return 0, err
+		}
+		g.hz, g.lo, g.hi = hi, lo, hi
+		g.users++
+	}
+	return g.hz, nil
+}
+
+// Leave drops one user of group k.
+func (p *PWMGroups) Leave(k int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if g := p.g[k]; g != nil && g.users > 0 {
+		g.users--
+		if g.users == 0 {
+			g.hz, g.lo, g.hi = 0, 0, 0
+		}
+	}
+}
+
+// Freq reports the frequency of group k (0 when unused).
+func (p *PWMGroups) Freq(k int) uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if g := p.g[k]; g != nil {
+		return g.hz
+	}
+	return 0
+}
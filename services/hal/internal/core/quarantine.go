@@ -0,0 +1,110 @@
+package core
+
+import (
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/errcode"
+	"devicecode-go/services/metrics"
+	"devicecode-go/types"
+)
+
+// ---------------- Control quarantine (single-threaded in HAL loop) ----------------
+//
+// A client that keeps sending a capability malformed controls, e.g. a
+// confused host over the bridge, would otherwise hold the loop and the
+// device's worker queue decoding and refusing them. After quarStrikes
+// controls to one capability are refused as invalid_payload within
+// quarWindow, HAL refuses its malformed controls for quarFor before they
+// reach the device. A control whose payload has the type the verb lists in
+// the catalogue still gets through, so one bad client cannot lock out
+// another, e.g. the reactor's over-temp cut to a rail. Refusals are replied to at most once per quarReplyEvery (the
+// rest go unanswered), and the non-retained event …/event/quarantined
+// (types.Quarantine) marks the start.
+
+const (
+	quarStrikes    = 5
+	quarWindow     = 10 * time.Second
+	quarFor        = 30 * time.Second
+	quarReplyEvery = time.Second
+)
+
+var mQuarantines = metrics.NewCounter("hal.quarantines")
+
+type quarState struct {
+	strikes   uint8
+	windowEnd int64 // Unix ns; strikes count towards one quarantine until then
+	until     int64 // Unix ns; quarantined until then (0: not)
+	replyNext int64 // Unix ns; next refusal to reply to
+}
+
+// quarStrike counts a control to ck refused as malformed.
+func (h *HAL) quarStrike(ck capKey, verb string) {
+	if _, ok := h.capIndex[ck]; !ok {
+		return // only registered capabilities, so the map stays bounded
+	}
+	now := h.clk.Now().UnixNano()
+	q := h.quar[ck]
+	if q == nil {
+		q = &quarState{}
+		h.quar[ck] = q
+	}
+	if q.until > now {
+		return
+	}
+	if now >= q.windowEnd {
+		q.strikes, q.windowEnd, q.until = 0, now+int64(quarWindow), 0
+	}
+	q.strikes++
+	if q.strikes < quarStrikes {
+		return
+	}
+	q.until, q.replyNext = now+int64(quarFor), now
+	mQuarantines.Inc()
+	h.pubCap(ck, capEventTagged(ck.domain, ck.kind, ck.name, "quarantined"), types.Quarantine{
+		Verb:    verb,
+		Strikes: q.strikes,
+		ForMs:   uint32(quarFor / time.Millisecond),
+		TS:      now,
+	}, false, h.mono())
+}
+
+// quarRefused reports whether a control to ck is refused by quarantine,
+// replying to it if the refusal is due one.
+func (h *HAL) quarRefused(msg *bus.Message, ck capKey, verb string) bool {
+	q := h.quar[ck]
+	if q == nil {
+		return false
+	}
+	now := h.clk.Now().UnixNano()
+	if now >= q.until {
+		if now >= q.windowEnd {
+			delete(h.quar, ck)
+		}
+		return false
+	}
+	if h.wellTyped(ck, verb, msg.Payload) {
+		return false
+	}
+	if now >= q.replyNext {
+		q.replyNext = now + int64(quarReplyEvery)
+		h.replyErr(msg, &errcode.E{C: errcode.Quarantined, Op: verb,
+			Msg: "repeated malformed controls; retry in " + time.Duration(q.until-now).Round(time.Second).String()})
+	}
+	return true
+}
+
+// wellTyped reports whether payload is what ck's catalogue lists for verb:
+// a payload of the named type, or anything for a verb that takes none.
+func (h *HAL) wellTyped(ck capKey, verb string, payload any) bool {
+	e := h.catalog[ck]
+	if e == nil {
+		return false
+	}
+	for _, v := range e.Verbs {
+		if v.Verb == verb {
+			return v.Payload == "" || v.Payload == types.PayloadName(payload)
+		}
+	}
+	return false
+}
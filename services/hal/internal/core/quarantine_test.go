@@ -0,0 +1,127 @@
+package core
+
+import (
+	"testing"
+
+	"devicecode-go/bus"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+func TestQuarantine_RepeatedMalformedControls(t *testing.T) {
+	clk := clock.NewFake(t0)
+	h := newTestHAL(&fakeReg{}, clk)
+	c := fakeCap("x")
+	h.dev["d"] = &fakeDev{id: "d", caps: []CapabilitySpec{c}}
+	if err := h.registerCap("d", c); err != nil {
+		t.Fatal(err)
+	}
+	events := h.conn.Subscribe(capEventTagged(c.Domain, c.Kind, c.Name, "quarantined"))
+	replies := h.conn.Subscribe(bus.T("reply"))
+	send := func(payload any) {
+		m := h.conn.NewMessage(capCtrl(c.Domain, c.Kind, c.Name, "poll_start"), payload, false)
+		m.ReplyTo = bus.T("reply")
+		h.handleControl(m)
+	}
+	errOf := func() string {
+		t.Helper()
+		return recv(t, replies).Payload.(types.ErrorReply).Error
+	}
+	good := types.PollStart{Verb: "read", IntervalMs: 1000}
+
+	for i := 0; i < quarStrikes; i++ {
+		send("junk")
+		if e := errOf(); e != "invalid_payload" {
+			t.Fatalf("strike %d: %s", i, e)
+		}
+	}
+	q := recv(t, events).Payload.(types.Quarantine)
+	if q.Verb != "poll_start" || q.Strikes != quarStrikes || q.ForMs != 30000 {
+		t.Fatalf("event %+v", q)
+	}
+
+	// Refused, with one reply per second at most.
+	send("junk")
+	send(nil)
+	if e := errOf(); e != "quarantined" || len(replies.Channel()) != 0 {
+		t.Fatalf("refusal %s, %d more", e, len(replies.Channel()))
+	}
+	clk.Advance(quarReplyEvery)
+	send("junk")
+	if e := errOf(); e != "quarantined" {
+		t.Fatalf("second refusal %s", e)
+	}
+
+	// A well-typed control still gets through.
+	send(good)
+	if r := recv(t, replies).Payload; r != (types.OKReply{OK: true}) || len(h.pollItems) != 1 {
+		t.Fatalf("well-typed control during quarantine: %+v", r)
+	}
+
+	clk.Advance(quarFor)
+	send("junk")
+	if e := errOf(); e != "invalid_payload" {
+		t.Fatalf("after quarantine: %s", e)
+	}
+	clk.Advance(quarWindow)
+
+	// Strikes spread wider than the window do not add up.
+	for i := 0; i < quarStrikes; i++ {
+		send("junk")
+		errOf()
+		clk.Advance(quarWindow / (quarStrikes - 1))
+	}
+	if len(events.Channel()) != 0 {
+		t.Fatal("quarantined by slow strikes")
+	}
+}
+
+// switchDev is a power switch with a typed set verb.
+type switchDev struct {
+	fakeDev
+	verbs VerbTable
+	sets  []bool
+}
+
+func newSwitchDev(cs CapabilitySpec) *switchDev {
+	d := &switchDev{fakeDev: fakeDev{id: "sw", caps: []CapabilitySpec{cs}}}
+	RegisterVerb(&d.verbs, "set", func(p types.SwitchSet) (EnqueueResult, error) {
+		d.sets = append(d.sets, p.On)
+		return EnqueueResult{OK: true}, nil
+	})
+	return d
+}
+
+func (d *switchDev) Control(_ CapAddr, verb string, payload any) (EnqueueResult, error) {
+	return d.verbs.Dispatch(verb, payload)
+}
+func (d *switchDev) Verbs(CapAddr) []VerbSpec { return d.verbs.Specs() }
+
+func TestQuarantine_TypedSwitchSetStillReachesRail(t *testing.T) {
+	h := newTestHAL(&fakeReg{}, clock.NewFake(t0))
+	c := CapabilitySpec{Domain: "power", Kind: types.KindSwitch, Name: "cm5"}
+	d := newSwitchDev(c)
+	h.dev["sw"] = d
+	if err := h.registerCap("sw", c); err != nil {
+		t.Fatal(err)
+	}
+	events := h.conn.Subscribe(capEventTagged(c.Domain, c.Kind, c.Name, "quarantined"))
+	set := func(payload any) {
+		h.handleControl(h.conn.NewMessage(capCtrl(c.Domain, c.Kind, c.Name, "set"), payload, false))
+	}
+
+	// A bridge host quarantines the rail with junk...
+	for i := 0; i < quarStrikes; i++ {
+		set("junk")
+	}
+	recv(t, events)
+	set("junk")
+	if len(d.sets) != 0 {
+		t.Fatalf("junk reached the switch: %v", d.sets)
+	}
+	// ...but the reactor's cut, sent without a ReplyTo, still lands.
+	set(types.SwitchSet{On: false})
+	if len(d.sets) != 1 || d.sets[0] {
+		t.Fatalf("cut during quarantine: %v", d.sets)
+	}
+}
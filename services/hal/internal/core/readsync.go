@@ -0,0 +1,127 @@
+package core
+
+import (
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+)
+
+// ---------------- read_sync: request/reply reads ----------------
+//
+// read_sync runs a read verb and holds the request until the capability
+// next emits. The reply is the value payload itself (or an ErrorReply if the
+// device reports an error, or timeout). Deadlines are folded into the
+// run-loop timer alongside the poller, so no extra goroutine is needed.
+
+const (
+	readSyncDefault = time.Second
+	readSyncMax     = 10 * time.Second
+)
+
+type syncWaiter struct {
+	msg      *bus.Message
+	deadline int64 // Unix ns
+}
+
+func (h *HAL) handleReadSync(msg *bus.Message, cap CapAddr) {
+	rs, _ := As[types.ReadSync](msg.Payload) // zero-value allowed
+	if !msg.CanReply() {
+		h.replyErr(msg, errcode.InvalidPayload)
+		return
+	}
+	verb := rs.Verb
+	if verb == "" {
+		verb = "read"
+	}
+	timeout := time.Duration(rs.TimeoutMs) * time.Millisecond
+	switch {
+	case timeout <= 0:
+		timeout = readSyncDefault
+	case timeout > readSyncMax:
+		timeout = readSyncMax
+	}
+
+	ck := capKey{domain: cap.Domain, kind: cap.Kind, name: cap.Name}
+	ownerID, ok := h.capIndex[ck]
+	if !ok {
+		h.replyErr(msg, errcode.UnknownCapability)
+		return
+	}
+	dev := h.dev[ownerID]
+	if dev == nil {
+		h.replyErr(msg, errcode.Error)
+		return
+	}
+	res, err := dev.Control(cap, verb, nil)
+	if err != nil {
+		h.replyErr(msg, err)
+		return
+	}
+	// Busy means a read is already in flight; its emission answers us too.
+	if !res.OK && res.Error != errcode.Busy {
+		h.replyErr(msg, res.Error)
+		return
+	}
+	h.syncWait[ck] = append(h.syncWait[ck], syncWaiter{
+		msg:      msg,
+		deadline: h.clk.Now().Add(timeout).UnixNano(),
+	})
+	h.pollReschedule()
+}
+
+// syncResolve answers every waiter on ck with v, or err when non-nil.
+func (h *HAL) syncResolve(ck capKey, v any, err error) {
+	ws := h.syncWait[ck]
+	if len(ws) == 0 {
+		return
+	}
+	delete(h.syncWait, ck)
+	for _, w := range ws {
+		if err != nil {
+			h.replyErr(w.msg, err)
+		} else {
+			h.conn.Reply(w.msg, v, false)
+		}
+	}
+}
+
+// syncExpire times out waiters whose deadline has passed.
+func (h *HAL) syncExpire() {
+	now := h.clk.Now().UnixNano()
+	for ck, ws := range h.syncWait {
+		keep := ws[:0]
+		for _, w := range ws {
+			if w.deadline <= now {
+				h.replyErr(w.msg, &errcode.E{C: errcode.Timeout, Op: "read_sync", Msg: "no value before deadline"})
+			} else {
+				keep = append(keep, w)
+			}
+		}
+		if len(keep) == 0 {
+			delete(h.syncWait, ck)
+		} else {
+			h.syncWait[ck] = keep
+		}
+	}
+}
+
+// syncNextWait mirrors pollNextWait for the earliest read_sync deadline.
+func (h *HAL) syncNextWait() time.Duration {
+	var first int64
+	for _, ws := range h.syncWait {
+		for _, w := range ws {
+			if first == 0 || w.deadline < first {
+				first = w.deadline
+			}
+		}
+	}
+	if first == 0 {
+		return -1
+	}
+	if d := first - h.clk.Now().UnixNano(); d > 0 {
+		return time.Duration(d)
+	}
+	return 0
+}
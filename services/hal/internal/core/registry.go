@@ -2,6 +2,7 @@ package core
 
 import (
 	"devicecode-go/types"
+	"devicecode-go/x/cbor"
 	"devicecode-go/x/fmtx"
 	"sync"
 )
@@ -9,6 +10,7 @@ import (
 var (
 	regMu    sync.RWMutex
 	builders = map[string]Builder{}
+	params   = map[string]func(*cbor.Decoder) (any, error){}
 )
 
 func RegisterBuilder(typ string, b Builder) {
@@ -20,6 +22,30 @@ func RegisterBuilder(typ string, b Builder) {
 	builders[typ] = b
 }
 
+// RegisterParams records T as typ's Params so a config imported over the
+// bus (hal/config/control/import) can rebuild it; builders assert the
+// concrete type. T reads itself from CBOR (*T is a cbor.Unmarshaler) and
+// should write itself too (T a cbor.Marshaler) for export.
+func RegisterParams[T any, P interface {
+	*T
+	cbor.Unmarshaler
+}](typ string) {
+	regMu.Lock()
+	defer regMu.Unlock()
+	params[typ] = func(d *cbor.Decoder) (any, error) {
+		var p T
+		err := P(&p).UnmarshalCBOR(d)
+		return p, err
+	}
+}
+
+func lookupParams(typ string) (func(*cbor.Decoder) (any, error), bool) {
+	regMu.RLock()
+	defer regMu.RUnlock()
+	d, ok := params[typ]
+	return d, ok
+}
+
 func lookupBuilder(typ string) (Builder, bool) {
 	regMu.RLock()
 	defer regMu.RUnlock()
@@ -12,12 +12,22 @@ func (h *HAL) replyOK(m *bus.Message) {
 	}
 }
 
-func (h *HAL) replyErr(m *bus.Message, code errcode.Code) {
+// replyErr sends a structured error reply. err may be a bare errcode.Code or
+// an *errcode.E carrying detail and the offending field. Malformed controls
+// also count towards the capability's quarantine (see quarantine.go),
+// whether or not they want a reply.
+func (h *HAL) replyErr(m *bus.Message, err error) {
+	if errcode.Of(err) == errcode.InvalidPayload {
+		if cap, verb, ok := parseCapCtrl(m.Topic); ok {
+			h.quarStrike(capKey{domain: cap.Domain, kind: cap.Kind, name: cap.Name}, verb)
+		}
+	}
 	if !m.CanReply() {
 		return
 	}
-	if code == "" {
-		code = errcode.Error
+	var verb string
+	if _, v, ok := parseCapCtrl(m.Topic); ok {
+		verb = v
 	}
-	h.conn.Reply(m, types.ErrorReply{OK: false, Error: string(code)}, false)
+	h.conn.Reply(m, types.ErrorReplyOf(err, verb), false)
 }
@@ -3,6 +3,10 @@ package core
 import (
 	"time"
 
+	"devicecode-go/types"
+	"devicecode-go/x/onewire"
+	"devicecode-go/x/ramp"
+
 	"tinygo.org/x/drivers"
 )
 
@@ -25,9 +29,24 @@ const (
 	FuncGPIOIn PinFunc = iota
 	FuncGPIOOut
 	FuncPWM
+	FuncPIO // routed to a PIO block; claimed through ClaimPIO
 	// Extend here (e.g. FuncSPI_MOSI, FuncUART_TX, …) as we expose more functions.
 )
 
+func (f PinFunc) String() string {
+	switch f {
+	case FuncGPIOIn:
+		return "gpio_in"
+	case FuncGPIOOut:
+		return "gpio_out"
+	case FuncPWM:
+		return "pwm"
+	case FuncPIO:
+		return "pio"
+	}
+	return "unknown"
+}
+
 // GPIO (function-specific view)
 type Pull uint8
 
@@ -78,9 +97,24 @@ type PWMRampMode uint8
 const (
 	// Linear stepping: evenly spaced absolute steps from current to target.
 	PWMRampLinear PWMRampMode = iota
-	// Future modes could include gamma-corrected, exponential, or trapezoidal.
+	// Smoothstep: slow at both ends.
+	PWMRampEase
+	// Raised cosine: slower still at the ends.
+	PWMRampSine
 )
 
+// Shape returns the x/ramp trajectory for m; unknown modes are linear.
+func (m PWMRampMode) Shape() ramp.Shape {
+	switch m {
+	case PWMRampEase:
+		return ramp.Ease
+	case PWMRampSine:
+		return ramp.Sine
+	default:
+		return ramp.Linear
+	}
+}
+
 type PWMHandle interface {
 	Configure(freqHz uint64, top uint16) error
 	Set(level uint16)
@@ -91,6 +125,15 @@ type PWMHandle interface {
 	StopRamp()
 }
 
+// PWMNegotiator is optionally implemented by a PWMHandle whose period is
+// shared with other outputs, for callers that can live with a nearby
+// frequency instead of errcode.Conflict (see PWMGroups.Negotiate).
+type PWMNegotiator interface {
+	// ConfigureNear is Configure accepting any frequency within tolPct
+	// percent of freqHz. It returns the frequency in effect.
+	ConfigureNear(freqHz uint64, top uint16, tolPct uint8) (uint64, error)
+}
+
 // PinHandle narrows to function-specific views; it is invalid to request a view
 // that does not match the claimed function.
 type PinHandle interface {
@@ -99,8 +142,89 @@ type PinHandle interface {
 	AsPWM() PWMHandle   // only valid if claimed with FuncPWM
 }
 
+// ---- PIO (programmable I/O state machines) ----
+
+// PIOProgram is an assembled PIO program as pioasm reports it. Wrap points
+// are relative to the first instruction; the provider relocates jumps when
+// it loads the program elsewhere. Claims of the same Name in one PIO block
+// share the loaded copy.
+type PIOProgram struct {
+	Name       string
+	Code       []uint16
+	WrapTarget uint8
+	Wrap       uint8
+	SideSet    uint8 // side-set bits, including the enable bit with SideSetOpt
+	SideSetOpt bool
+}
+
+// PIOConfig sets a claimed state machine's clock, pin mapping and shifting.
+// Pin fields are GPIO numbers and must be among the pins claimed with it.
+type PIOConfig struct {
+	FreqHz uint32 // state machine clock; 0 runs at the system clock
+
+	OutBase, OutCount uint8
+	SetBase, SetCount uint8
+	SideSetBase       uint8
+	InBase            uint8
+	JmpPin            uint8
+	Outputs           []int // pins driven as outputs from the start; the rest are inputs
+
+	OutShiftRight, InShiftRight bool
+	AutoPull, AutoPush          bool
+	PullThreshold               uint8 // bits; 0 means 32
+	PushThreshold               uint8
+	JoinTX, JoinRX              bool // merge the FIFOs into one 8-deep direction
+}
+
+// PIOStateMachine is one claimed state machine running its program. FIFO
+// access never blocks.
+type PIOStateMachine interface {
+	ID() ResourceID // e.g. "pio0.sm1"
+
+	// Configure stops the machine, applies c and restarts the program at
+	// its first instruction with empty FIFOs; Enable starts it.
+	Configure(c PIOConfig) error
+	Enable(on bool)
+
+	TryPut(w uint32) bool
+	TryGet() (uint32, bool)
+
+	// Drained reports that the TX FIFO is empty and the program has
+	// stalled waiting for more since the last TryPut.
+	Drained() bool
+}
+
 // ---- Transactional buses (I²C) ----
 
+// SMBus is optionally implemented by the drivers.I2C returned from
+// ClaimI2C, for devices that use SMBus packet error checking or block
+// transfers (framing from x/smbus). PEC failures return
+// errcode.CRCMismatch and are counted per bus.
+type SMBus interface {
+	// TxPEC is Tx with a PEC byte: appended to w for a write, or read
+	// after r and verified for a read.
+	TxPEC(addr uint16, w, r []byte) error
+	// BlockRead reads the block at cmd into buf, which bounds its length,
+	// and returns the byte count the device reported.
+	BlockRead(addr uint16, cmd byte, buf []byte, pec bool) (int, error)
+	// BlockWrite writes data (1..smbus.MaxBlock bytes) as a block to cmd.
+	BlockWrite(addr uint16, cmd byte, data []byte, pec bool) error
+	// PECErrors is the bus's cumulative PEC mismatch count.
+	PECErrors() uint32
+}
+
+// ---- 1-Wire ----
+
+// OneWire is a claimed 1-Wire bus. Several devices may claim the same bus;
+// the provider serialises calls, each of which is a whole transaction.
+type OneWire interface {
+	// Search returns the ROM IDs of every device on the bus.
+	Search() ([]onewire.ROM, error)
+	// Tx resets the bus, addresses rom (0: every device), writes w and
+	// then reads len(r) bytes.
+	Tx(rom onewire.ROM, w, r []byte) error
+}
+
 // ---- Stream buses (shape reserved; provider can fill in) ----
 
 // ---- Serial (UART et al.) ----
@@ -127,6 +251,78 @@ type SerialFormatConfigurator interface {
 	SetFormat(databits, stopbits uint8, parity string) error
 }
 
+// SerialFlowConfigurator is implemented by ports wired for RTS/CTS.
+type SerialFlowConfigurator interface {
+	SetFlowControl(on bool) error
+}
+
+// SerialStatsReporter is optionally implemented by ports that can account
+// for received data lost before it reached the port's RX buffer.
+type SerialStatsReporter interface {
+	// RXOverruns returns the cumulative count of hardware RX FIFO overruns.
+	RXOverruns() uint32
+}
+
+// SerialRXStamper is optionally implemented by ports that can timestamp
+// received data at interrupt time, ahead of any scheduling delay.
+type SerialRXStamper interface {
+	// RXStamp returns the arrival time (Unix ns) of the first byte returned
+	// by the last TryRead, or 0 if that read continued a burst already
+	// being read or the time was not captured.
+	RXStamp() int64
+}
+
+// BusPlanner is implemented by registries that can instantiate bus
+// controllers from a run-time plan (config/hal "buses").
+type BusPlanner interface {
+	ApplyBuses(plan types.BusPlan)
+}
+
+// ClaimReleaser is implemented by registries that can drop every claim a
+// device holds. HAL uses it to roll back devices that were built but never
+// started, whose Close may expect Init to have run.
+type ClaimReleaser interface {
+	ReleaseAll(devID string)
+}
+
+// ConfigStore is implemented by registries with non-volatile storage for
+// a config/hal imported over the bus; HAL loads it at the next boot in
+// place of the compile-time setup.
+type ConfigStore interface {
+	SaveConfig(b types.ConfigBlob) error
+	LoadConfig() (types.ConfigBlob, bool)
+}
+
+// NVStore is implemented by registries with non-volatile storage for
+// small application records (hal/nv/control/get and put).
+type NVStore interface {
+	LoadNV(key string) ([]byte, bool)
+	SaveNV(key string, b []byte) error
+}
+
+// LogStore is implemented by registries with a reserved flash region for
+// one log checkpoint (hal/nv/control/log_get and log_put); each save
+// replaces the last.
+type LogStore interface {
+	LoadLog() ([]byte, bool)
+	SaveLog(b []byte) error
+}
+
+// ResourceLister is implemented by registries that can enumerate their
+// claims; HAL publishes the result on hal/resources.
+type ResourceLister interface {
+	Resources() types.ResourceMap
+}
+
+// ResolvePin returns name's GPIO number when name is set, else pin.
+// Builders use it so Params may carry either a number or an alias.
+func ResolvePin(reg ResourceRegistry, pin int, name string) (int, error) {
+	if name == "" {
+		return pin, nil
+	}
+	return reg.PinByName(name)
+}
+
 // ---- Unified registry interface ----
 
 type ResourceRegistry interface {
@@ -142,11 +338,28 @@ type ResourceRegistry interface {
 	ClaimSerial(devID string, id ResourceID) (SerialPort, error)
 	ReleaseSerial(devID string, id ResourceID)
 
+	// 1-Wire buses (shared, like I²C)
+	ClaimOneWire(devID string, id ResourceID) (OneWire, error)
+	ReleaseOneWire(devID string, id ResourceID)
+
 	// Unified pin function claims
 	ClaimPin(devID string, pin int, fn PinFunc) (PinHandle, error)
 	ReleasePin(devID string, pin int)
 
-	// GPIO edge subscriptions (exclusive per claimed input pin).
+	// PIO state machines. ClaimPIO loads prog into a block with room for
+	// it (or finds it loaded there), takes a free state machine and routes
+	// pins to the block; Release stops the machine, returns the pins to
+	// inputs and unloads the program once no machine runs it.
+	ClaimPIO(devID string, prog PIOProgram, pins []int) (PIOStateMachine, error)
+	ReleasePIO(devID string, id ResourceID)
+
+	// PinByName resolves a named pin alias to its GPIO number.
+	PinByName(name string) (int, error)
+
+	// GPIO edge subscriptions on a claimed input pin. The claimant and any
+	// number of observers may subscribe, each with its own edge and debounce
+	// filter; Unsubscribe closes only devID's streams. Releasing the pin
+	// closes all of them.
 	SubscribeGPIOEdges(devID string, pin int, sel GPIOEdge, debounce time.Duration, buf int) (GPIOEdgeStream, error)
 	UnsubscribeGPIOEdges(devID string, pin int)
 }
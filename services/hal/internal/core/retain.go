@@ -0,0 +1,52 @@
+package core
+
+import (
+	"reflect"
+
+	"devicecode-go/services/metrics"
+	"devicecode-go/types"
+)
+
+// ---------------- Copy-on-publish for retained values ----------------
+//
+// A retained value stays on the bus for late subscribers long after the
+// device emitted it, so it must not share memory the device goes on to
+// change (see types.Cloner). HAL copies what it can before publishing: a
+// types.Cloner is cloned and a pointer to a plain value is published as a
+// copy of that value. Plain values need nothing. Anything else would be
+// torn by the device's next write; it is refused and the capability's
+// status goes degraded with "unsafe_payload".
+
+var mUnsafePayloads = metrics.NewCounter("hal.unsafe_payloads")
+
+// retainable returns a copy of p safe to retain, or false.
+func (h *HAL) retainable(p any) (any, bool) {
+	if p == nil {
+		return nil, true
+	}
+	if c, ok := p.(types.Cloner); ok {
+		return c.Clone(), true
+	}
+	t := reflect.TypeOf(p)
+	if t.Kind() == reflect.Pointer {
+		if v := reflect.ValueOf(p); !v.IsNil() && h.selfContained(t.Elem()) {
+			return v.Elem().Interface(), true
+		}
+		return nil, false
+	}
+	if !h.selfContained(t) {
+		return nil, false
+	}
+	return p, true
+}
+
+// selfContained memoises types.SelfContained; the set of payload types
+// is small and fixed.
+func (h *HAL) selfContained(t reflect.Type) bool {
+	ok, seen := h.flat[t]
+	if !seen {
+		ok = types.SelfContained(t)
+		h.flat[t] = ok
+	}
+	return ok
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"testing"
+
+	"devicecode-go/bus"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+type refValue struct{ Points []int32 }
+
+type clonedValue struct{ Points []int32 }
+
+func (v clonedValue) Clone() any {
+	v.Points = append([]int32(nil), v.Points...)
+	return v
+}
+
+func TestRetainedValue_CopiedOrRefused(t *testing.T) {
+	h := newTestHAL(&fakeReg{}, clock.NewFake(t0))
+	c := fakeCap("x")
+	a := CapAddr{Domain: c.Domain, Kind: c.Kind, Name: c.Name}
+	values := h.conn.Subscribe(capValue(c.Domain, c.Kind, c.Name))
+	status := h.conn.Subscribe(capStatus(c.Domain, c.Kind, c.Name))
+	latest := func() *bus.Message {
+		t.Helper()
+		ms := h.conn.Retained(capValue(c.Domain, c.Kind, c.Name))
+		if len(ms) != 1 {
+			t.Fatalf("%d retained", len(ms))
+		}
+		return ms[0]
+	}
+
+	// A pointer to a plain value is retained as a copy of it.
+	tv := &types.TemperatureValue{DeciC: 215}
+	h.handleEvent(Event{Addr: a, Payload: tv})
+	tv.DeciC = 999
+	if got := latest().Payload; got != (types.TemperatureValue{DeciC: 215}) {
+		t.Fatalf("pointer retained as %#v", got)
+	}
+
+	// A Cloner is cloned, so reusing its buffer leaves the retained copy.
+	buf := []int32{1, 2}
+	h.handleEvent(Event{Addr: a, Payload: clonedValue{Points: buf}})
+	buf[0] = 7
+	if got := latest().Payload.(clonedValue); got.Points[0] != 1 {
+		t.Fatalf("clone shares the buffer: %v", got.Points)
+	}
+
+	// Shared references that cannot be copied are refused.
+	for len(values.Channel()) > 0 {
+		<-values.Channel()
+	}
+	for len(status.Channel()) > 0 {
+		<-status.Channel()
+	}
+	h.handleEvent(Event{Addr: a, Payload: refValue{Points: buf}})
+	if len(values.Channel()) != 0 {
+		t.Fatal("unsafe payload published")
+	}
+	if st := recv(t, status).Payload.(types.CapabilityStatus); st.Link != types.LinkDegraded || st.Error != "unsafe_payload" {
+		t.Fatalf("status %+v", st)
+	}
+}
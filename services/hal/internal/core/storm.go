@@ -0,0 +1,114 @@
+package core
+
+import (
+	"time"
+
+	"devicecode-go/services/metrics"
+	"devicecode-go/types"
+)
+
+// ---------------- Event-rate guard (single-threaded in HAL loop) ----------------
+//
+// A device whose events run away, e.g. an alert line stuck low firing
+// interrupt after interrupt, would otherwise flood the bus and the UART
+// telemetry that mirrors it. HAL counts each capability's events per
+// stormWindow; past stormMax in one window it drops the capability's
+// events and publishes the retained warning …/storm (types.EventStorm).
+// The warning is refreshed each window the storm lasts; a window with at
+// most stormMax/2 events ends it, republished inactive with the total
+// dropped. Values and status are never throttled.
+
+const (
+	stormMax    = 20
+	stormWindow = time.Second
+)
+
+var mStormDrops = metrics.NewCounter("hal.storm_drops")
+
+type stormState struct {
+	windowEnd int64  // Unix ns
+	n         uint16 // events this window
+	active    bool
+	dropped   uint32 // in this storm
+}
+
+// stormAdmit counts an event from ck and reports whether to publish it.
+func (h *HAL) stormAdmit(ck capKey, now int64) bool {
+	if _, ok := h.capIndex[ck]; !ok {
+		return true // only registered capabilities, so the map stays bounded
+	}
+	s := h.storm[ck]
+	if s == nil {
+		s = &stormState{windowEnd: now + int64(stormWindow)}
+		h.storm[ck] = s
+	}
+	if now >= s.windowEnd {
+		h.stormRoll(ck, s, now)
+	}
+	if s.n < ^uint16(0) {
+		s.n++
+	}
+	if !s.active && s.n > stormMax {
+		s.active = true
+		h.pubStorm(ck, s, s.n, now)
+	}
+	if s.active {
+		s.dropped++
+		mStormDrops.Inc()
+		return false
+	}
+	return true
+}
+
+// stormRoll closes ck's window: a storm is refreshed or ends.
+func (h *HAL) stormRoll(ck capKey, s *stormState, now int64) {
+	n := s.n
+	s.n, s.windowEnd = 0, now+int64(stormWindow)
+	if !s.active {
+		return
+	}
+	s.active = n > stormMax/2
+	h.pubStorm(ck, s, n, now)
+	if !s.active {
+		s.dropped = 0
+	}
+}
+
+func (h *HAL) pubStorm(ck capKey, s *stormState, rate uint16, now int64) {
+	h.pubCap(ck, capStorm(ck.domain, ck.kind, ck.name), types.EventStorm{
+		Active:  s.active,
+		Rate:    rate,
+		Limit:   stormMax,
+		Dropped: s.dropped,
+		TS:      now,
+	}, true, h.mono())
+}
+
+// stormExpire rolls the windows of storms in progress, so one ends even
+// if its events stop altogether.
+func (h *HAL) stormExpire() {
+	now := h.clk.Now().UnixNano()
+	for ck, s := range h.storm {
+		if s.active && now >= s.windowEnd {
+			h.stormRoll(ck, s, now)
+		}
+	}
+}
+
+// stormNextWait is the time until the window of a storm in progress
+// closes, or -1 if there is none.
+func (h *HAL) stormNextWait() time.Duration {
+	var first int64
+	for _, s := range h.storm {
+		if s.active && (first == 0 || s.windowEnd < first) {
+			first = s.windowEnd
+		}
+	}
+	if first == 0 {
+		return -1
+	}
+	if d := first - h.clk.Now().UnixNano(); d > 0 {
+		return time.Duration(d)
+	}
+	return 0
+}
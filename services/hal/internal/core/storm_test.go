@@ -0,0 +1,75 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+func TestStorm_ThrottlesAndEnds(t *testing.T) {
+	clk := clock.NewFake(t0)
+	h := newTestHAL(&fakeReg{}, clk)
+	c := fakeCap("x")
+	if err := h.registerCap("d", c); err != nil {
+		t.Fatal(err)
+	}
+	warn := h.conn.Subscribe(capStorm(c.Domain, c.Kind, c.Name))
+	a := CapAddr{Domain: c.Domain, Kind: c.Kind, Name: c.Name}
+	sent := 0
+	events := h.conn.Subscribe(capEventTagged(c.Domain, c.Kind, c.Name, "alert"))
+	emit := func(n int) {
+		for i := 0; i < n; i++ {
+			h.handleEvent(Event{Addr: a, EventTag: "alert", Payload: i})
+			for len(events.Channel()) > 0 {
+				<-events.Channel()
+				sent++
+			}
+		}
+	}
+
+	emit(stormMax)
+	if sent != stormMax || h.stormNextWait() != -1 {
+		t.Fatalf("under the limit: %d published", sent)
+	}
+	emit(5)
+	w := recv(t, warn).Payload.(types.EventStorm)
+	if !w.Active || w.Rate != stormMax+1 || w.Limit != stormMax || sent != stormMax {
+		t.Fatalf("warning %+v, %d published", w, sent)
+	}
+	// Values still flow.
+	h.handleEvent(Event{Addr: a, Payload: types.TemperatureValue{DeciC: 1}})
+	if _, ok := h.lastEmit[capKey{domain: c.Domain, kind: c.Kind, name: c.Name}]; !ok {
+		t.Fatal("value throttled")
+	}
+
+	// Still storming next second: refreshed, still dropping.
+	clk.Advance(stormWindow)
+	h.stormExpire()
+	emit(stormMax)
+	if w := recv(t, warn).Payload.(types.EventStorm); !w.Active || w.Dropped != 5 || sent != stormMax {
+		t.Fatalf("refresh %+v, %d published", w, sent)
+	}
+
+	// The events stop: the storm ends on the timer, without another event.
+	if d := h.stormNextWait(); d != stormWindow {
+		t.Fatalf("next wait %v", d)
+	}
+	clk.Advance(stormWindow)
+	h.stormExpire()
+	if w := recv(t, warn).Payload.(types.EventStorm); !w.Active || w.Rate != stormMax {
+		t.Fatalf("second refresh %+v", w)
+	}
+	clk.Advance(stormWindow)
+	h.stormExpire()
+	w = recv(t, warn).Payload.(types.EventStorm)
+	if w.Active || w.Rate != 0 || w.Dropped != 5+stormMax {
+		t.Fatalf("end %+v", w)
+	}
+	clk.Advance(time.Millisecond)
+	emit(1)
+	if sent != stormMax+1 || h.stormNextWait() != -1 {
+		t.Fatalf("after the storm: %d published", sent)
+	}
+}
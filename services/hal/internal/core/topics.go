@@ -11,6 +11,11 @@ func T(tokens ...bus.Token) bus.Topic { return bus.T(tokens...) }
 
 func topicConfigHAL() bus.Topic { return T("config", "hal") }
 
+// Reactor thresholds: config/reactor sets them, reactor/config retains
+// the values in force (see configxfer.go).
+func topicConfigReactor() bus.Topic { return T("config", "reactor") }
+func topicReactorConfig() bus.Topic { return T("reactor", "config") }
+
 // hal/cap/<domain>/<kind>/<name>/...
 func capBase(domain string, kind types.Kind, name string) bus.Topic {
 	return T("hal", "cap", domain, string(kind), name)
@@ -22,9 +27,15 @@ func capInfo(domain string, kind types.Kind, name string) bus.Topic {
 func capStatus(domain string, kind types.Kind, name string) bus.Topic {
 	return capBase(domain, kind, name).Append("status")
 }
+func capVerbs(domain string, kind types.Kind, name string) bus.Topic {
+	return capBase(domain, kind, name).Append("verbs")
+}
 func capValue(domain string, kind types.Kind, name string) bus.Topic {
 	return capBase(domain, kind, name).Append("value")
 }
+func capStorm(domain string, kind types.Kind, name string) bus.Topic {
+	return capBase(domain, kind, name).Append("storm")
+}
 func capEvent(domain string, kind types.Kind, name string) bus.Topic {
 	return capBase(domain, kind, name).Append("event")
 }
@@ -32,10 +43,42 @@ func capEventTagged(domain string, kind types.Kind, name, tag string) bus.Topic
 	return capEvent(domain, kind, name).Append(tag)
 }
 
+// hal/cap/<domain>/<kind>/<name>/event/control_done/<id>; not interned,
+// as IDs are per request.
+func capControlDone(domain string, kind types.Kind, name string, id bus.Token) bus.Topic {
+	return bus.TNoIntern("hal", "cap", domain, string(kind), name, "event", "control_done", id)
+}
+
+// hal/catalog (retained)
+func topicCatalog() bus.Topic { return T("hal", "catalog") }
+
+// hal/resources (retained)
+func topicResources() bus.Topic { return T("hal", "resources") }
+
+// hal/config/control/<export|import>, hal/config/staged (retained)
+func topicConfigCtrl() bus.Topic   { return T("hal", "config", "control", "+") }
+func topicConfigStaged() bus.Topic { return T("hal", "config", "staged") }
+
+// hal/nv/control/<get|put>
+func topicNVCtrl() bus.Topic { return T("hal", "nv", "control", "+") }
+
+// hal/power/control/set, hal/power/state (retained)
+func topicPowerCtrl() bus.Topic  { return T("hal", "power", "control", "set") }
+func topicPowerState() bus.Topic { return T("hal", "power", "state") }
+
+// alarm/<name>/state (retained), alarm/<name>/event/<raised|cleared>
+func alarmState(name string) bus.Topic { return T("alarm", name, "state") }
+func alarmEvent(name, tag string) bus.Topic {
+	return T("alarm", name, "event", tag)
+}
+
+// group/<name>/state (retained)
+func groupState(name string) bus.Topic { return T("group", name, "state") }
+
 // capability control
-// hal/cap/<domain>/<kind>/<name>/control/<verb>
+// hal/cap/<domain>/<kind>/<name>/control/<verb>[/<id>]
 func parseCapCtrl(t bus.Topic) (CapAddr, string, bool) {
-	if t.Len() < 7 {
+	if t.Len() < 7 || t.Len() > 8 {
 		return CapAddr{}, "", false
 	}
 	d, ok1 := t.At(2).(string)
@@ -48,7 +91,19 @@ func parseCapCtrl(t bus.Topic) (CapAddr, string, bool) {
 	return CapAddr{Domain: d, Kind: types.Kind(k), Name: n}, v, true
 }
 
-// hal/cap/+/+/+/control/+
+func capCtrl(domain string, kind types.Kind, name, verb string) bus.Topic {
+	return capBase(domain, kind, name).Append("control", verb)
+}
+
+// ctrlID is the request ID of a control topic, or nil.
+func ctrlID(t bus.Topic) bus.Token {
+	if t.Len() != 8 {
+		return nil
+	}
+	return t.At(7)
+}
+
+// hal/cap/+/+/+/control/# (verb, and optional request ID)
 func ctrlWildcard() bus.Topic {
-	return T("hal", "cap", "+", "+", "+", "control", "+")
+	return T("hal", "cap", "+", "+", "+", "control", "#")
 }
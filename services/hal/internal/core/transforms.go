@@ -0,0 +1,123 @@
+package core
+
+import (
+	"strconv"
+
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+)
+
+// ---------------- Value transforms (single-threaded in HAL loop) ----------------
+//
+// A device's config entry may carry a pipeline of transforms for its
+// values (HALDevice.Transforms), so board-specific scaling lives in config
+// rather than in generic drivers. HAL applies the steps in order to each
+// retained value before publishing it; alarms, groups and read_sync see
+// the result. Steps are reduced to v*num/den + add, then an optional clamp.
+
+type xformStep struct {
+	kind     types.Kind // "" => every capability of the device
+	field    string
+	num, den int64
+	add      int64
+	clamp    bool
+	lo, hi   int64
+}
+
+// unitConv returns v*num/den + add taking from to to, for the units the
+// drivers publish in.
+func unitConv(unit string) (num, den, add int64, ok bool) {
+	switch unit {
+	case "deci_c:milli_c":
+		return 100, 1, 0, true
+	case "milli_c:deci_c":
+		return 1, 100, 0, true
+	case "deci_c:deci_f":
+		return 9, 5, 320, true
+	case "mV:uV", "mA:uA", "mW:uW":
+		return 1000, 1, 0, true
+	case "uV:mV", "uA:mA", "uW:mW", "mWh:Wh":
+		return 1, 1000, 0, true
+	}
+	return 0, 0, 0, false
+}
+
+// compileTransforms checks a device's pipeline and reduces each step.
+func compileTransforms(ts []types.Transform) ([]xformStep, error) {
+	var steps []xformStep
+	for i, t := range ts {
+		bad := func(msg string) error {
+			return &errcode.E{C: errcode.InvalidParams, Op: "transform", Msg: msg, Field: "transforms[" + strconv.Itoa(i) + "]"}
+		}
+		if t.Field == "" {
+			return nil, bad("field required")
+		}
+		s := xformStep{kind: t.Kind, field: t.Field, num: 1, den: 1}
+		switch t.Op {
+		case types.TransformScale:
+			s.num = t.Num
+			if t.Den != 0 {
+				s.den = t.Den
+			}
+			if s.den < 0 {
+				s.num, s.den = -s.num, -s.den
+			}
+		case types.TransformOffset:
+			s.add = t.Add
+		case types.TransformClamp:
+			if t.Min > t.Max {
+				return nil, bad("min above max")
+			}
+			s.clamp, s.lo, s.hi = true, t.Min, t.Max
+		case types.TransformConvert:
+			var ok bool
+			if s.num, s.den, s.add, ok = unitConv(t.Unit); !ok {
+				return nil, bad("unknown unit " + t.Unit)
+			}
+		default:
+			return nil, bad("unknown op " + string(t.Op))
+		}
+		steps = append(steps, s)
+	}
+	return steps, nil
+}
+
+func (s *xformStep) apply(v int64) int64 {
+	if s.num != 1 || s.den != 1 {
+		p := v * s.num
+		// Round half away from zero.
+		if p < 0 {
+			v = (p - s.den/2) / s.den
+		} else {
+			v = (p + s.den/2) / s.den
+		}
+	}
+	v += s.add
+	if s.clamp {
+		v = min(max(v, s.lo), s.hi)
+	}
+	return v
+}
+
+// transform runs the owning device's pipeline over a value of ck.
+func (h *HAL) transform(ck capKey, payload any) any {
+	steps := h.xforms[h.capIndex[ck]]
+	for i := range steps {
+		s := &steps[i]
+		if s.kind != "" && s.kind != ck.kind {
+			continue
+		}
+		f, ok := payload.(types.Fielder)
+		if !ok {
+			return payload
+		}
+		v, ok := f.Field(s.field)
+		if !ok {
+			continue
+		}
+		if fs, ok := payload.(types.FieldSetter); ok {
+			payload, _ = fs.WithField(s.field, s.apply(v))
+		}
+	}
+	return payload
+}
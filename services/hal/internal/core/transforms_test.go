@@ -0,0 +1,66 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+func TestTransforms_AppliedBeforePublish(t *testing.T) {
+	h := newTestHAL(&fakeReg{}, clock.NewFake(t0))
+	dc := addDev(t, &fakeDev{id: "xf", caps: []CapabilitySpec{fakeCap("x")}})
+	dc.Transforms = []types.Transform{
+		{Field: "deci_c", Op: types.TransformScale, Num: 3, Den: 2}, // 251 → 377 (376.5 rounded)
+		{Field: "deci_c", Op: types.TransformOffset, Add: -7},       // → 370
+		{Field: "deci_c", Op: types.TransformConvert, Unit: "deci_c:milli_c"},
+		{Field: "deci_c", Op: types.TransformClamp, Min: -1000, Max: 30000},
+		{Kind: types.KindHumidity, Field: "deci_c", Op: types.TransformOffset, Add: 1}, // other kind
+		{Field: "rh_x100", Op: types.TransformOffset, Add: 1},                          // other field
+	}
+	if errs := h.applyConfig(context.Background(), types.HALConfig{Devices: []types.HALDevice{dc}}); len(errs) != 0 {
+		t.Fatalf("errs %+v", errs)
+	}
+	c := fakeCap("x")
+	ck := capKey{domain: c.Domain, kind: c.Kind, name: c.Name}
+	sub := h.conn.Subscribe(capValue(ck.domain, ck.kind, ck.name))
+
+	h.handleEvent(Event{Addr: CapAddr{Domain: c.Domain, Kind: c.Kind, Name: c.Name}, Payload: types.TemperatureValue{DeciC: 251}})
+	if v := (<-sub.Channel()).Payload.(types.TemperatureValue); v.DeciC != 30000 {
+		t.Fatalf("clamped %d", v.DeciC)
+	}
+	h.handleEvent(Event{Addr: CapAddr{Domain: c.Domain, Kind: c.Kind, Name: c.Name}, Payload: types.TemperatureValue{DeciC: -5}})
+	if v := (<-sub.Channel()).Payload.(types.TemperatureValue); v.DeciC != -1000 {
+		t.Fatalf("negative %d", v.DeciC)
+	}
+
+	// Steps saturate at the field's range.
+	steps, _ := compileTransforms([]types.Transform{{Field: "pack_mV", Op: types.TransformScale, Num: 1_000_000}})
+	h.xforms["xf"] = steps
+	h.handleEvent(Event{Addr: CapAddr{Domain: c.Domain, Kind: c.Kind, Name: c.Name}, Payload: types.BatteryValue{PackMilliV: 12800}})
+	if v := (<-sub.Channel()).Payload.(types.BatteryValue); v.PackMilliV != 1<<31-1 {
+		t.Fatalf("saturated %d", v.PackMilliV)
+	}
+}
+
+func TestTransforms_BadStepRejectsDevice(t *testing.T) {
+	h := newTestHAL(&fakeReg{}, clock.NewFake(t0))
+	for _, bad := range []types.Transform{
+		{Field: "deci_c", Op: "square"},
+		{Op: types.TransformOffset},
+		{Field: "deci_c", Op: types.TransformClamp, Min: 2, Max: 1},
+		{Field: "deci_c", Op: types.TransformConvert, Unit: "furlong:fortnight"},
+	} {
+		dc := addDev(t, &fakeDev{id: "xf-bad", caps: []CapabilitySpec{fakeCap("y")}})
+		dc.Transforms = []types.Transform{bad}
+		errs := h.applyConfig(context.Background(), types.HALConfig{Devices: []types.HALDevice{dc}})
+		if len(errs) != 1 || errs[0].Error != string(errcode.InvalidParams) {
+			t.Fatalf("%+v: errs %+v", bad, errs)
+		}
+	}
+	if len(h.dev) != 0 {
+		t.Fatal("device kept")
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"devicecode-go/errcode"
 	"devicecode-go/types"
+	"devicecode-go/x/clock"
 )
 
 // ---- Addressing ----
@@ -29,6 +30,9 @@ type CapabilitySpec struct {
 type EnqueueResult struct {
 	OK    bool
 	Error errcode.Code // machine-readable short code
+	// Pending (with OK) tells HAL a Completer will report the outcome
+	// through its done callback.
+	Pending bool
 }
 
 // Device is device-centric: controls are non-blocking.
@@ -40,6 +44,29 @@ type Device interface {
 	Close() error
 }
 
+// VerbLister is optionally implemented by devices that can enumerate the
+// control verbs accepted by a capability (normally from their VerbTable).
+type VerbLister interface {
+	Verbs(cap CapAddr) []VerbSpec
+}
+
+// Completer is optionally implemented by devices whose controls finish on
+// a worker (see controldone.go). ControlDone is Control with a completion:
+// if the result is OK and Pending, the device calls done exactly once,
+// from any goroutine, with nil or why the work failed; otherwise done is
+// never called. ctx is cancelled at the device's control deadline, after
+// which queued work should be dropped.
+type Completer interface {
+	ControlDone(ctx context.Context, cap CapAddr, method string, payload any, done func(error)) (EnqueueResult, error)
+}
+
+// PowerManager is optionally implemented by the ResourceRegistry to apply
+// a platform power mode (clock gating, sleep depth). Wake sources (GPIO
+// edges, UART RX, timers) must remain live in every mode.
+type PowerManager interface {
+	SetPowerMode(mode types.PowerMode) error
+}
+
 // Builder input and registration
 
 type BuilderInput struct {
@@ -62,6 +89,9 @@ type Event struct {
 	Payload  any
 	Err      string
 	EventTag string
+	// Leaf, if set, publishes Payload retained at .../value/<Leaf> (one
+	// scalar of a split value) instead of at .../value.
+	Leaf string
 }
 
 // ---- Event emission (devices → HAL) ----
@@ -72,9 +102,29 @@ type EventEmitter interface {
 	Emit(ev Event) bool
 }
 
+// ---- Other devices' capabilities (composite devices) ----
+
+// Capabilities lets a device use capabilities of other devices the way a
+// bus client would: values come from their retained value topics and
+// controls go through HAL's dispatch (interlocks included).
+type Capabilities interface {
+	// Watch calls fn with each retained value of addr, the current one
+	// first, until cancel is called. fn runs on its own goroutine and must
+	// not block for long. It fails if HAL's connection is at its
+	// subscription limit (bus.ErrTooManySubs).
+	Watch(addr CapAddr, fn func(payload any)) (cancel func(), err error)
+	// Control sends verb to addr without waiting for the outcome.
+	Control(addr CapAddr, verb string, payload any)
+}
+
 // ---- HAL-injected resources ----
 
 type Resources struct {
-	Reg ResourceRegistry
-	Pub EventEmitter
+	Reg  ResourceRegistry
+	Pub  EventEmitter
+	Caps Capabilities
+
+	// Clock is the HAL's time source; nil means clock.Real. Devices that
+	// want deterministic tests take time from here instead of package time.
+	Clock clock.Clock
 }
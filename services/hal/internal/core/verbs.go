@@ -0,0 +1,93 @@
+package core
+
+import (
+	"reflect"
+
+	"devicecode-go/errcode"
+)
+
+// ---- Typed control verbs ----
+
+// VerbSpec describes one control verb accepted by a device.
+// Payload is the Go type name of the expected payload ("" => no payload).
+type VerbSpec struct {
+	Verb    string
+	Payload string
+}
+
+type verbEntry struct {
+	spec VerbSpec
+	call func(payload any) (EnqueueResult, error)
+}
+
+// VerbTable is a small, ordered verb -> handler table owned by a device.
+// It is populated once (builder/constructor) and then only read from the
+// HAL goroutine, so it needs no locking.
+type VerbTable struct {
+	entries []verbEntry
+}
+
+// RegisterVerb adds a handler whose payload is asserted to T via As[T].
+// A mismatched payload is answered with errcode.InvalidPayload (naming the
+// expected type) without invoking fn. Registering the same verb twice
+// replaces the handler.
+func RegisterVerb[T any](vt *VerbTable, verb string, fn func(T) (EnqueueResult, error)) {
+	name := payloadName[T]()
+	vt.add(VerbSpec{Verb: verb, Payload: name}, func(payload any) (EnqueueResult, error) {
+		p, code := As[T](payload)
+		if code != "" {
+			return EnqueueResult{OK: false, Error: code}, &errcode.E{C: code, Op: verb, Msg: "want " + name}
+		}
+		return fn(p)
+	})
+}
+
+// RegisterAction adds a handler for a verb that takes no payload.
+// Any payload supplied by the caller is ignored.
+func RegisterAction(vt *VerbTable, verb string, fn func() (EnqueueResult, error)) {
+	vt.add(VerbSpec{Verb: verb}, func(any) (EnqueueResult, error) { return fn() })
+}
+
+func (vt *VerbTable) add(spec VerbSpec, call func(any) (EnqueueResult, error)) {
+	for i := range vt.entries {
+		if vt.entries[i].spec.Verb == spec.Verb {
+			vt.entries[i] = verbEntry{spec: spec, call: call}
+			return
+		}
+	}
+	vt.entries = append(vt.entries, verbEntry{spec: spec, call: call})
+}
+
+// Dispatch routes a control to its handler; unknown verbs are Unsupported.
+func (vt *VerbTable) Dispatch(verb string, payload any) (EnqueueResult, error) {
+	for i := range vt.entries {
+		if vt.entries[i].spec.Verb == verb {
+			return vt.entries[i].call(payload)
+		}
+	}
+	return EnqueueResult{OK: false, Error: errcode.Unsupported}, nil
+}
+
+// Has reports whether verb is registered.
+func (vt *VerbTable) Has(verb string) bool {
+	for i := range vt.entries {
+		if vt.entries[i].spec.Verb == verb {
+			return true
+		}
+	}
+	return false
+}
+
+// Specs returns the registered verbs in registration order.
+func (vt *VerbTable) Specs() []VerbSpec {
+	out := make([]VerbSpec, len(vt.entries))
+	for i := range vt.entries {
+		out[i] = vt.entries[i].spec
+	}
+	return out
+}
+
+// payloadName returns the bare type name of T (e.g. "PWMSet").
+func payloadName[T any]() string {
+	return reflect.TypeOf((*T)(nil)).Elem().Name()
+}
@@ -11,6 +11,15 @@ type Board struct {
 	SPI  []string
 	UART []string
 
+	// Pins is indexed by GPIO number and lists the functions the pin mux
+	// can route to it. Pins outside the table (or with zero caps) are not
+	// usable on this board.
+	Pins []PinCap
+
+	// Aliases names pins fixed by the PCB (e.g. "LED", "VBUS_SENSE").
+	// Wiring-specific names belong in setups.ResourcePlan.Aliases.
+	Aliases map[string]int
+
 	// Optional recommended default aliases for convenience in setups/tools.
 	// These are plain GPIO numbers; mapping to machine.Pin happens in the provider.
 	Defaults struct {
@@ -20,3 +29,91 @@ type Board struct {
 		UART1_TX, UART1_RX int
 	}
 }
+
+// PinCap is a set of pin functions.
+type PinCap uint16
+
+const (
+	CapGPIO PinCap = 1 << iota
+	CapPWM
+	CapADC
+	CapI2C0SDA
+	CapI2C0SCL
+	CapI2C1SDA
+	CapI2C1SCL
+	CapUART0TX
+	CapUART0RX
+	CapUART0CTS
+	CapUART0RTS
+	CapUART1TX
+	CapUART1RX
+	CapUART1CTS
+	CapUART1RTS
+	CapPIO
+)
+
+// Caps returns the functions available on GPIO n (0 if n is not usable).
+func (b *Board) Caps(n int) PinCap {
+	if n < b.GPIOMin || n > b.GPIOMax || n >= len(b.Pins) {
+		return 0
+	}
+	return b.Pins[n]
+}
+
+// Supports reports whether every function in c is available on GPIO n.
+func (b *Board) Supports(n int, c PinCap) bool {
+	return c != 0 && b.Caps(n)&c == c
+}
+
+// Alias resolves a board-level pin name.
+func (b *Board) Alias(name string) (int, bool) {
+	n, ok := b.Aliases[name]
+	return n, ok
+}
+
+// I2CCaps returns the SDA and SCL functions of an I2C controller.
+func I2CCaps(id string) (sda, scl PinCap) {
+	switch id {
+	case "i2c0":
+		return CapI2C0SDA, CapI2C0SCL
+	case "i2c1":
+		return CapI2C1SDA, CapI2C1SCL
+	}
+	return 0, 0
+}
+
+// UARTCaps returns the TX, RX, CTS and RTS functions of a UART controller.
+func UARTCaps(id string) (tx, rx, cts, rts PinCap) {
+	switch id {
+	case "uart0":
+		return CapUART0TX, CapUART0RX, CapUART0CTS, CapUART0RTS
+	case "uart1":
+		return CapUART1TX, CapUART1RX, CapUART1CTS, CapUART1RTS
+	}
+	return 0, 0, 0, 0
+}
+
+// String lists the functions in c, for error messages.
+func (c PinCap) String() string {
+	names := [...]string{
+		"gpio", "pwm", "adc",
+		"i2c0_sda", "i2c0_scl", "i2c1_sda", "i2c1_scl",
+		"uart0_tx", "uart0_rx", "uart0_cts", "uart0_rts",
+		"uart1_tx", "uart1_rx", "uart1_cts", "uart1_rts",
+		"pio",
+	}
+	s := ""
+	for i, n := range names {
+		if c&(1<<i) == 0 {
+			continue
+		}
+		if s != "" {
+			s += ","
+		}
+		s += n
+	}
+	if s == "" {
+		return "none"
+	}
+	return s
+}
@@ -9,6 +9,15 @@ var SelectedBoard = Board{
 	I2C:     []string{"i2c0", "i2c1"},
 	SPI:     nil, // add when we expose SPI owners
 	UART:    []string{"uart0", "uart1"},
+	Pins:    picoPins(),
+	Aliases: map[string]int{
+		"SMPS_PS":    23,
+		"VBUS_SENSE": 24,
+		"LED":        25,
+		"ADC0":       26,
+		"ADC1":       27,
+		"ADC2":       28,
+	},
 	Defaults: struct {
 		I2C0_SDA, I2C0_SCL int
 		I2C1_SDA, I2C1_SCL int
@@ -22,3 +31,13 @@ var SelectedBoard = Board{
 		UART1_TX: 8, UART1_RX: 9,
 	},
 }
+
+// picoPins restricts the RP2040 table to what the Pico exposes: GPIO23..25
+// are wired on the PCB (SMPS power-save, VBUS sense, LED) and GPIO29 is VSYS/3.
+func picoPins() []PinCap {
+	p := rp2040Caps()[:29]
+	p[23] = CapGPIO
+	p[24] = CapGPIO
+	p[25] = CapGPIO | CapPWM
+	return p
+}
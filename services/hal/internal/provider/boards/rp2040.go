@@ -0,0 +1,29 @@
+package boards
+
+// rp2040Caps returns the RP2040 function-select table for GPIO 0..29
+// (datasheet §2.19.2). Every bank-0 pin has SIO, PWM, PIO and an I2C function;
+// UART functions follow a period-4 pattern and GPIO26..29 add the ADC.
+func rp2040Caps() []PinCap {
+	uart := [2][4]PinCap{
+		{CapUART0TX, CapUART0RX, CapUART0CTS, CapUART0RTS},
+		{CapUART1TX, CapUART1RX, CapUART1CTS, CapUART1RTS},
+	}
+	i2c := [2][2]PinCap{
+		{CapI2C0SDA, CapI2C0SCL},
+		{CapI2C1SDA, CapI2C1SCL},
+	}
+	pins := make([]PinCap, 30)
+	for n := range pins {
+		c := CapGPIO | CapPWM | CapPIO
+		// I2C: 0/1 → i2c0, 2/3 → i2c1, repeating every 4 pins.
+		c |= i2c[(n/2)&1][n&1]
+		// UART: 4-pin blocks map uart0, uart1, uart1, uart0, … (§1.4.3).
+		u := ((n/4 + 1) / 2) & 1
+		c |= uart[u][n&3]
+		if n >= 26 {
+			c |= CapADC
+		}
+		pins[n] = c
+	}
+	return pins
+}
@@ -0,0 +1,163 @@
+//go:build rp2040
+
+package provider
+
+import (
+	"hash/crc32"
+	"sync"
+
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+	"devicecode-go/x/nvstore"
+	"machine"
+)
+
+var (
+	_ core.NVStore     = (*rp2Registry)(nil)
+	_ core.ConfigStore = (*rp2Registry)(nil)
+	_ core.LogStore    = (*rp2Registry)(nil)
+)
+
+// -----------------------------------------------------------------------------
+// Non-volatile storage (reserved flash at the end of the data area)
+// -----------------------------------------------------------------------------
+//
+// machine.Flash addresses the flash after the program image. Its tail is
+// reserved, from the end:
+//
+//	nvRegion     application NV records, an nvstore.Records in two 4 KiB slots
+//	configRegion the imported config blob (CBOR), in two 8 KiB slots
+//	logRegion    the log checkpoint (types.LastLog as CBOR), in two 4 KiB slots
+//
+// Slots are written alternately, so a write cut short by power loss leaves
+// the previous copy. Nothing else in the firmware writes flash; the regions
+// only have to stay clear of a growing image, which the offset check in
+// openFlash catches.
+
+const (
+	nvRegion     = 8 << 10
+	configRegion = 16 << 10
+	logRegion    = 8 << 10
+)
+
+var flash struct {
+	once sync.Once
+	nv   *nvstore.Records
+	cfg  *nvstore.Slots
+	log  *nvstore.Slots
+	err  error
+}
+
+// openFlash maps the regions on first use. The log region sits below the
+// others, so adding it left their offsets unchanged.
+func openFlash() error {
+	flash.once.Do(func() {
+		dev := machine.Flash
+		end := dev.Size() - dev.Size()%dev.EraseBlockSize()
+		nvOff, cfgOff := end-nvRegion, end-nvRegion-configRegion
+		logOff := cfgOff - logRegion
+		if logOff < 0 {
+			flash.err = &errcode.E{C: errcode.Unavailable, Op: "flash", Msg: "no room for nv regions"}
+			return
+		}
+		s, err := nvstore.NewSlots(dev, nvOff, nvRegion)
+		if err != nil {
+			flash.err = &errcode.E{C: errcode.Unavailable, Op: "flash", Msg: "nv region", Err: err}
+			return
+		}
+		flash.nv = nvstore.NewRecords(s)
+		if flash.cfg, err = nvstore.NewSlots(dev, cfgOff, configRegion); err != nil {
+			flash.err = &errcode.E{C: errcode.Unavailable, Op: "flash", Msg: "config region", Err: err}
+			return
+		}
+		if flash.log, err = nvstore.NewSlots(dev, logOff, logRegion); err != nil {
+			flash.err = &errcode.E{C: errcode.Unavailable, Op: "flash", Msg: "log region", Err: err}
+		}
+	})
+	return flash.err
+}
+
+func openNV() (*nvstore.Records, error) {
+	if err := openFlash(); err != nil {
+		return nil, err
+	}
+	return flash.nv, nil
+}
+
+func (r *rp2Registry) LoadNV(key string) ([]byte, bool) {
+	recs, err := openNV()
+	if err != nil {
+		return nil, false
+	}
+	return recs.Get(key)
+}
+
+// SaveNV rewrites the region (one 4 KiB erase), so callers should save on
+// change rather than on a timer.
+func (r *rp2Registry) SaveNV(key string, b []byte) error {
+	recs, err := openNV()
+	if err != nil {
+		return err
+	}
+	switch err := recs.Put(key, b); err {
+	case nil:
+		return nil
+	case nvstore.ErrTooLarge:
+		return &errcode.E{C: errcode.InvalidPayload, Op: "nv_put", Msg: "nv region full", Err: err}
+	default:
+		return &errcode.E{C: errcode.Error, Op: "nv_put", Err: err}
+	}
+}
+
+// SaveConfig stores blob's data; the slot header carries length and CRC,
+// and LoadConfig rebuilds the blob around it.
+func (r *rp2Registry) SaveConfig(blob types.ConfigBlob) error {
+	if err := openFlash(); err != nil {
+		return err
+	}
+	if blob.Format != "cbor" {
+		return &errcode.E{C: errcode.Unsupported, Op: "config_store", Msg: "format " + blob.Format}
+	}
+	switch err := flash.cfg.Save(blob.Data); err {
+	case nil:
+		return nil
+	case nvstore.ErrTooLarge:
+		return &errcode.E{C: errcode.InvalidPayload, Op: "config_store", Msg: "config larger than its flash slot", Field: "blob.data"}
+	default:
+		return &errcode.E{C: errcode.Error, Op: "config_store", Err: err}
+	}
+}
+
+func (r *rp2Registry) LoadConfig() (types.ConfigBlob, bool) {
+	if openFlash() != nil {
+		return types.ConfigBlob{}, false
+	}
+	b, ok := flash.cfg.Load()
+	if !ok {
+		return types.ConfigBlob{}, false
+	}
+	return types.ConfigBlob{Format: "cbor", Size: uint32(len(b)), CRC32: crc32.ChecksumIEEE(b), Data: b}, true
+}
+
+func (r *rp2Registry) LoadLog() ([]byte, bool) {
+	if openFlash() != nil {
+		return nil, false
+	}
+	return flash.log.Load()
+}
+
+// SaveLog costs one 4 KiB erase, so checkpoints should be minutes apart.
+func (r *rp2Registry) SaveLog(b []byte) error {
+	if err := openFlash(); err != nil {
+		return err
+	}
+	switch err := flash.log.Save(b); err {
+	case nil:
+		return nil
+	case nvstore.ErrTooLarge:
+		return &errcode.E{C: errcode.InvalidPayload, Op: "log_put", Msg: "checkpoint larger than its flash slot", Field: "data"}
+	default:
+		return &errcode.E{C: errcode.Error, Op: "log_put", Err: err}
+	}
+}
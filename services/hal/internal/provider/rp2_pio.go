@@ -0,0 +1,394 @@
+//go:build rp2040
+
+package provider
+
+import (
+	"runtime/volatile"
+	"strconv"
+	"unsafe"
+
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/services/hal/internal/provider/boards"
+	"machine"
+)
+
+// -----------------------------------------------------------------------------
+// PIO (programmable I/O) blocks
+// -----------------------------------------------------------------------------
+//
+// The RP2040 has two PIO blocks, each with 32 words of instruction memory
+// shared by four state machines. Claims pick the first block with a free
+// machine and either the program already loaded (by name) or room for it.
+// Registers are driven directly (datasheet §3.7); shared registers are
+// written through the atomic set/clear aliases so state machines owned by
+// different devices never race on CTRL.
+
+const (
+	regPIO0       = 0x50200000
+	regPIO1       = 0x50300000
+	regResets     = 0x4000c000
+	aliasSet      = 0x2000
+	aliasClr      = 0x3000
+	resetPIO0Bit  = 10 // RESETS.RESET bit of PIO0; PIO1 is the next
+	pioInstrWords = 32
+	pioMachines   = 4
+)
+
+// pioHW overlays one PIO block's registers.
+type pioHW struct {
+	ctrl     volatile.Register32 // 0x000
+	fstat    volatile.Register32
+	fdebug   volatile.Register32
+	flevel   volatile.Register32
+	txf      [pioMachines]volatile.Register32   // 0x010
+	rxf      [pioMachines]volatile.Register32   // 0x020
+	_        [6]volatile.Register32             // IRQ .. DBG_CFGINFO
+	instrMem [pioInstrWords]volatile.Register32 // 0x048
+	sm       [pioMachines]pioSMHW               // 0x0c8
+}
+
+type pioSMHW struct {
+	clkdiv, execctrl, shiftctrl, addr, instr, pinctrl volatile.Register32
+}
+
+// Field positions (datasheet §3.7, SMx_* registers).
+const (
+	execSideEn     = 1 << 30
+	execJmpPin     = 24
+	execWrapTop    = 12
+	execWrapBottom = 7
+
+	shiftFJoinRX   = 1 << 31
+	shiftFJoinTX   = 1 << 30
+	shiftPullThr   = 25
+	shiftPushThr   = 20
+	shiftOutRight  = 1 << 19
+	shiftInRight   = 1 << 18
+	shiftAutoPull  = 1 << 17
+	shiftAutoPush  = 1 << 16
+	pinSideCount   = 29
+	pinSetCount    = 26
+	pinOutCount    = 20
+	pinInBase      = 15
+	pinSideBase    = 10
+	pinSetBase     = 5
+	pinOutBase     = 0
+	fstatTXFull    = 16
+	fstatRXEmpty   = 8
+	fstatTXEmpty   = 24
+	fdebugTXStall  = 24
+	ctrlRestart    = 4
+	ctrlClkRestart = 8
+
+	instrSetPins    = 0xe000 // set pins, <data>
+	instrSetPindirs = 0xe080 // set pindirs, <data>
+)
+
+// pioAlias returns the set or clear alias of a register in a block.
+func pioAlias(reg *volatile.Register32, alias uintptr) *volatile.Register32 {
+	return (*volatile.Register32)(unsafe.Pointer(uintptr(unsafe.Pointer(reg)) + alias))
+}
+
+type pioLoaded struct {
+	offset, n uint8
+	users     int
+}
+
+type pioBlock struct {
+	idx   int
+	hw    *pioHW
+	up    bool   // taken out of reset
+	used  uint32 // instruction memory words in use
+	progs map[string]*pioLoaded
+	sms   [pioMachines]*rp2PIOSM
+}
+
+func newPIOBlocks() [2]*pioBlock {
+	return [2]*pioBlock{
+		{idx: 0, hw: (*pioHW)(unsafe.Pointer(uintptr(regPIO0))), progs: map[string]*pioLoaded{}},
+		{idx: 1, hw: (*pioHW)(unsafe.Pointer(uintptr(regPIO1))), progs: map[string]*pioLoaded{}},
+	}
+}
+
+func (b *pioBlock) unreset() {
+	if b.up {
+		return
+	}
+	bit := uint32(1) << (resetPIO0Bit + b.idx)
+	reg32(regResets + aliasClr).Set(bit)
+	for reg32(regResets+0x8).Get()&bit == 0 {
+	}
+	b.up = true
+}
+
+// place returns where prog is or would be loaded, or false without room.
+func (b *pioBlock) place(prog core.PIOProgram) (uint8, bool) {
+	if l, ok := b.progs[prog.Name]; ok {
+		return l.offset, true
+	}
+	n := len(prog.Code)
+	mask := uint32(1)<<n - 1
+	for off := pioInstrWords - n; off >= 0; off-- {
+		if b.used&(mask<<off) == 0 {
+			return uint8(off), true
+		}
+	}
+	return 0, false
+}
+
+// load copies prog to offset, relocating jumps; a program loaded under the
+// same name gains a user instead.
+func (b *pioBlock) load(prog core.PIOProgram, off uint8) {
+	if l, ok := b.progs[prog.Name]; ok {
+		l.users++
+		return
+	}
+	for i, ins := range prog.Code {
+		if ins&0xe000 == 0 { // JMP: address in the low five bits
+			ins += uint16(off)
+		}
+		b.hw.instrMem[int(off)+i].Set(uint32(ins))
+	}
+	n := len(prog.Code)
+	b.used |= (uint32(1)<<n - 1) << off
+	b.progs[prog.Name] = &pioLoaded{offset: off, n: uint8(n), users: 1}
+}
+
+func (b *pioBlock) unload(name string) {
+	l := b.progs[name]
+	if l == nil {
+		return
+	}
+	if l.users--; l.users > 0 {
+		return
+	}
+	b.used &^= (uint32(1)<<l.n - 1) << l.offset
+	delete(b.progs, name)
+}
+
+// rp2PIOSM is a claimed state machine.
+type rp2PIOSM struct {
+	b     *pioBlock
+	idx   int
+	devID string
+	prog  core.PIOProgram
+	off   uint8
+	pins  []int
+}
+
+var _ core.PIOStateMachine = (*rp2PIOSM)(nil)
+
+func (s *rp2PIOSM) ID() core.ResourceID {
+	return core.ResourceID("pio" + strconv.Itoa(s.b.idx) + ".sm" + strconv.Itoa(s.idx))
+}
+
+func (s *rp2PIOSM) hw() *pioSMHW { return &s.b.hw.sm[s.idx] }
+
+func (s *rp2PIOSM) exec(ins uint16) { s.hw().instr.Set(uint32(ins)) }
+
+func (s *rp2PIOSM) claimed(n uint8) bool {
+	for _, p := range s.pins {
+		if p == int(n) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *rp2PIOSM) Configure(c core.PIOConfig) error {
+	for i := uint8(0); i < c.OutCount; i++ {
+		if !s.claimed(c.OutBase + i) {
+			return errcode.InvalidParams
+		}
+	}
+	for i := uint8(0); i < c.SetCount; i++ {
+		if !s.claimed(c.SetBase + i) {
+			return errcode.InvalidParams
+		}
+	}
+	if c.OutCount > 32 || c.SetCount > 5 || c.PullThreshold > 32 || c.PushThreshold > 32 {
+		return errcode.InvalidParams
+	}
+	for _, n := range c.Outputs {
+		if n < 0 || !s.claimed(uint8(n)) {
+			return errcode.InvalidParams
+		}
+	}
+
+	// Clock divider, 16.8 fixed point; an integer part of 0 means 65536.
+	div := uint32(1 << 8)
+	if sys := machine.CPUFrequency(); c.FreqHz != 0 && c.FreqHz < sys {
+		d := uint64(sys) * 256 / uint64(c.FreqHz)
+		if d>>8 > 0xffff {
+			return errcode.InvalidParams
+		}
+		div = uint32(d)
+	}
+
+	s.Enable(false)
+	h := s.hw()
+	h.clkdiv.Set(div << 8)
+
+	exec := uint32(s.off+s.prog.Wrap)<<execWrapTop | uint32(s.off+s.prog.WrapTarget)<<execWrapBottom |
+		uint32(c.JmpPin&31)<<execJmpPin
+	if s.prog.SideSetOpt {
+		exec |= execSideEn
+	}
+	h.execctrl.Set(exec)
+
+	shift := uint32(c.PullThreshold&31)<<shiftPullThr | uint32(c.PushThreshold&31)<<shiftPushThr
+	for _, f := range []struct {
+		on  bool
+		bit uint32
+	}{
+		{c.OutShiftRight, shiftOutRight}, {c.InShiftRight, shiftInRight},
+		{c.AutoPull, shiftAutoPull}, {c.AutoPush, shiftAutoPush},
+		{c.JoinTX, shiftFJoinTX}, {c.JoinRX, shiftFJoinRX},
+	} {
+		if f.on {
+			shift |= f.bit
+		}
+	}
+	h.shiftctrl.Set(shift)
+	// Toggling FJOIN_RX empties both FIFOs.
+	h.shiftctrl.Set(shift ^ shiftFJoinRX)
+	h.shiftctrl.Set(shift)
+
+	// Drive the outputs low and set their directions, one pin at a time
+	// through the SET mapping, before installing the real one.
+	for _, n := range c.Outputs {
+		h.pinctrl.Set(1<<pinSetCount | uint32(n)<<pinSetBase)
+		s.exec(instrSetPins)
+		s.exec(instrSetPindirs | 1)
+	}
+	h.pinctrl.Set(uint32(s.prog.SideSet)<<pinSideCount | uint32(c.SetCount)<<pinSetCount |
+		uint32(c.OutCount)<<pinOutCount | uint32(c.InBase&31)<<pinInBase |
+		uint32(c.SideSetBase&31)<<pinSideBase | uint32(c.SetBase&31)<<pinSetBase |
+		uint32(c.OutBase&31)<<pinOutBase)
+
+	pioAlias(&s.b.hw.ctrl, aliasSet).Set(1<<(ctrlRestart+s.idx) | 1<<(ctrlClkRestart+s.idx))
+	s.exec(uint16(s.off)) // jmp <start>
+	return nil
+}
+
+func (s *rp2PIOSM) Enable(on bool) {
+	alias := uintptr(aliasClr)
+	if on {
+		alias = aliasSet
+	}
+	pioAlias(&s.b.hw.ctrl, alias).Set(1 << s.idx)
+}
+
+func (s *rp2PIOSM) TryPut(w uint32) bool {
+	if s.b.hw.fstat.Get()&(1<<(fstatTXFull+s.idx)) != 0 {
+		return false
+	}
+	s.b.hw.fdebug.Set(1 << (fdebugTXStall + s.idx)) // write 1 to clear
+	s.b.hw.txf[s.idx].Set(w)
+	return true
+}
+
+func (s *rp2PIOSM) TryGet() (uint32, bool) {
+	if s.b.hw.fstat.Get()&(1<<(fstatRXEmpty+s.idx)) != 0 {
+		return 0, false
+	}
+	return s.b.hw.rxf[s.idx].Get(), true
+}
+
+func (s *rp2PIOSM) Drained() bool {
+	return s.b.hw.fstat.Get()&(1<<(fstatTXEmpty+s.idx)) != 0 &&
+		s.b.hw.fdebug.Get()&(1<<(fdebugTXStall+s.idx)) != 0
+}
+
+// ClaimPIO takes a state machine for prog and routes pins to its block.
+func (r *rp2Registry) ClaimPIO(devID string, prog core.PIOProgram, pins []int) (core.PIOStateMachine, error) {
+	if n := len(prog.Code); prog.Name == "" || n == 0 || n > pioInstrWords || int(prog.Wrap) >= n || prog.WrapTarget > prog.Wrap || prog.SideSet > 5 {
+		return nil, errcode.InvalidParams
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, n := range pins {
+		if err := checkPin(devID, "pin", n, boards.CapPIO); err != nil {
+			return nil, err
+		}
+		if owner, inUse := r.pinOwners[n]; inUse && owner.devID != "" {
+			return nil, errcode.PinInUse
+		}
+	}
+	for _, b := range r.pio {
+		idx := -1
+		for i, sm := range b.sms {
+			if sm == nil {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			continue
+		}
+		off, ok := b.place(prog)
+		if !ok {
+			continue
+		}
+		b.unreset()
+		b.load(prog, off)
+		sm := &rp2PIOSM{b: b, idx: idx, devID: devID, prog: prog, off: off, pins: append([]int(nil), pins...)}
+		b.sms[idx] = sm
+		mode := machine.PinPIO0
+		if b.idx == 1 {
+			mode = machine.PinPIO1
+		}
+		for _, n := range pins {
+			machine.Pin(n).Configure(machine.PinConfig{Mode: mode})
+			r.pinOwners[n] = pinOwner{devID: devID, fn: core.FuncPIO}
+		}
+		return sm, nil
+	}
+	return nil, &errcode.E{C: errcode.BusInUse, Op: devID, Field: "pio",
+		Msg: "no PIO block has a free state machine and room for " + prog.Name}
+}
+
+// ReleasePIO stops the machine, returns its pins to inputs and unloads the
+// program when it was the last user.
+func (r *rp2Registry) ReleasePIO(devID string, id core.ResourceID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, b := range r.pio {
+		for i, sm := range b.sms {
+			if sm == nil || sm.devID != devID || sm.ID() != id {
+				continue
+			}
+			r.releasePIO(sm)
+			b.sms[i] = nil
+			return
+		}
+	}
+}
+
+// releasePIO undoes a claim; r.mu must be held.
+func (r *rp2Registry) releasePIO(sm *rp2PIOSM) {
+	sm.Enable(false)
+	sm.b.unload(sm.prog.Name)
+	for _, n := range sm.pins {
+		if o, ok := r.pinOwners[n]; ok && o.devID == sm.devID && o.fn == core.FuncPIO {
+			machine.Pin(n).Configure(machine.PinConfig{Mode: machine.PinInput})
+			delete(r.pinOwners, n)
+		}
+	}
+}
+
+// pioActive reports whether any state machine is claimed.
+func (r *rp2Registry) pioActive() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, b := range r.pio {
+		for _, sm := range b.sms {
+			if sm != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
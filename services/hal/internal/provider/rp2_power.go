@@ -0,0 +1,66 @@
+//go:build rp2040
+
+package provider
+
+import (
+	"runtime/volatile"
+	"unsafe"
+
+	"devicecode-go/errcode"
+	"devicecode-go/services/hal/internal/core"
+	"devicecode-go/types"
+)
+
+var _ core.PowerManager = (*rp2Registry)(nil)
+
+// -----------------------------------------------------------------------------
+// Power modes (clock gating in deep sleep)
+// -----------------------------------------------------------------------------
+//
+// TinyGo's scheduler executes WFI whenever no goroutine is runnable. In idle
+// mode we set SCR.SLEEPDEEP so that WFI becomes deep sleep, and restrict
+// CLOCKS.SLEEP_EN* so peripherals we do not use stop their clocks while the
+// core sleeps. Clocks for wake sources (IO bank for SMBALERT#/buttons, UARTs,
+// timer, I2C, PWM, USB) stay on, as do the PIO blocks while a state machine
+// is claimed. Full run mode restores every sleep enable.
+//
+// Dormant (XOSC stopped) is not used: restoring the PLLs is not exposed by
+// the machine package, and a stopped UART clock would drop RX bytes.
+
+const (
+	regSCR       = 0xe000ed10 // Cortex-M0+ System Control Register
+	scrSleepDeep = 1 << 2
+
+	regClocksSleepEn0 = 0x40008000 + 0xa8
+	regClocksSleepEn1 = 0x40008000 + 0xac
+
+	// SLEEP_EN0 bits gated in idle: ADC (1,2), JTAG (9), PIO0/1 (12,13),
+	// SPI0/1 peri+sys (24..27).
+	idleGate0 = 1<<1 | 1<<2 | 1<<9 | 1<<12 | 1<<13 | 1<<24 | 1<<25 | 1<<26 | 1<<27
+)
+
+func reg32(addr uintptr) *volatile.Register32 {
+	return (*volatile.Register32)(unsafe.Pointer(addr))
+}
+
+func (r *rp2Registry) SetPowerMode(mode types.PowerMode) error {
+	scr := reg32(regSCR)
+	en0, en1 := reg32(regClocksSleepEn0), reg32(regClocksSleepEn1)
+	switch mode {
+	case types.PowerRun:
+		scr.ClearBits(scrSleepDeep)
+		en0.Set(0xffffffff)
+		en1.Set(0x7fff)
+	case types.PowerIdle:
+		gate := uint32(idleGate0)
+		if r.pioActive() {
+			gate &^= 1<<12 | 1<<13
+		}
+		en0.Set(0xffffffff &^ gate)
+		en1.Set(0x7fff)
+		scr.SetBits(scrSleepDeep)
+	default:
+		return errcode.InvalidPayload
+	}
+	return nil
+}
@@ -3,6 +3,10 @@
 package provider
 
 import (
+	"runtime/interrupt"
+	"runtime/volatile"
+	"sort"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -11,8 +15,12 @@ import (
 	"devicecode-go/services/hal/internal/core"
 	"devicecode-go/services/hal/internal/provider/boards"
 	"devicecode-go/services/hal/internal/provider/setups"
+	"devicecode-go/services/metrics"
+	"devicecode-go/types"
 	"devicecode-go/x/mathx"
+	"devicecode-go/x/onewire"
 	"devicecode-go/x/ramp"
+	"devicecode-go/x/smbus"
 	"machine"
 
 	uartx "github.com/jangala-dev/tinygo-uartx/uartx"
@@ -96,11 +104,6 @@ func pwmGroupBySlice(slice uint8) pwmCtrl {
 	}
 }
 
-type sliceCfg struct {
-	freqHz uint64
-	users  int
-}
-
 // rp2PWM is the provider's per-pin PWM handle (channel-level).
 type rp2PWM struct {
 	mu sync.Mutex
@@ -148,45 +151,41 @@ func (p *rp2PWM) Configure(freqHz uint64, top uint16) error {
 	top = mathx.Max(top, 1)
 	freqHz = mathx.Max(freqHz, 1)
 
-	globalPWM.mu.Lock()
-	defer globalPWM.mu.Unlock()
-
-	sc := globalPWM.slice[p.slice]
-	if sc == nil {
-		sc = &sliceCfg{}
-		globalPWM.slice[p.slice] = sc
+	// The slice period is shared by both channels (see core.PWMGroups).
+	err := pwmSlices.Join(p.slice, freqHz, p.registered, func() error {
+		return p.ctrl.Configure(machine.PWMConfig{Period: PeriodFromHz(freqHz)})
+	})
+	if err != nil {
+		return err
 	}
+	p.configured(freqHz, top)
+	return nil
+}
 
-	if sc.users == 0 {
-		// First writer configures the controller period for this slice.
-		period := PeriodFromHz(freqHz)
-		if err := p.ctrl.Configure(machine.PWMConfig{Period: period}); err != nil {
+// ConfigureNear settles on a slice frequency within tolPct of freqHz. When
+// the slice moves, the other channel's compare value is rescaled to its
+// new top so its duty is unchanged.
+func (p *rp2PWM) ConfigureNear(freqHz uint64, top uint16, tolPct uint8) (uint64, error) {
+	top = mathx.Max(top, 1)
+	freqHz = mathx.Max(freqHz, 1)
+
+	eff, err := pwmSlices.Negotiate(p.slice, freqHz, tolPct, p.registered, func(hz uint64) error {
+		if err := p.ctrl.Configure(machine.PWMConfig{Period: PeriodFromHz(hz)}); err != nil {
 			return err
 		}
-		sc.freqHz = freqHz
-		sc.users = 1
-		p.registered = true
-	} else {
-		// Already in use. If this handle has not yet been counted, enforce
-		// frequency compatibility and then count it. If it *has* been counted,
-		// allow reconfiguration only if this is the sole user.
-		if !p.registered {
-			if sc.freqHz != freqHz {
-				return errcode.Conflict
-			}
-			sc.users++
-			p.registered = true
-		} else if sc.users == 1 && sc.freqHz != freqHz {
-			// Sole user may reconfigure the slice frequency.
-			period := PeriodFromHz(freqHz)
-			if err := p.ctrl.Configure(machine.PWMConfig{Period: period}); err != nil {
-				return err
-			}
-			sc.freqHz = freqHz
-		} else if sc.freqHz != freqHz {
-			return errcode.Conflict
-		}
+		pwmPeers.rescale(p)
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
+	p.configured(eff, top)
+	return eff, nil
+}
+
+// configured records a successful slice join and switches the pin to PWM.
+func (p *rp2PWM) configured(freqHz uint64, top uint16) {
+	p.registered = true
 
 	// Switch pin to PWM function and cache tops.
 	machine.Pin(p.pin).Configure(machine.PinConfig{Mode: machine.PinPWM})
@@ -196,8 +195,6 @@ func (p *rp2PWM) Configure(freqHz uint64, top uint16) error {
 	p.reqTop = top
 	p.hwTop = p.ctrl.Top()
 	p.mu.Unlock()
-
-	return nil
 }
 
 func (p *rp2PWM) Set(level uint16) {
@@ -234,7 +231,7 @@ func (p *rp2PWM) StopRamp() {
 	p.mu.Unlock()
 }
 
-func (p *rp2PWM) Ramp(to uint16, durationMs uint32, steps uint16, _ core.PWMRampMode) bool {
+func (p *rp2PWM) Ramp(to uint16, durationMs uint32, steps uint16, mode core.PWMRampMode) bool {
 	// Immediate set when degenerate.
 	if steps == 0 || durationMs == 0 {
 		p.Set(to)
@@ -279,7 +276,7 @@ func (p *rp2PWM) Ramp(to uint16, durationMs uint32, steps uint16, _ core.PWMRamp
 				return true
 			}
 		}
-		ramp.StartLinear(start, tgt, top, durationMs, steps, tick, func(lvl uint16) {
+		ramp.Start(start, tgt, top, durationMs, steps, mode.Shape(), tick, func(lvl uint16) {
 			p.mu.Lock()
 			p.setHW(lvl)
 			p.mu.Unlock()
@@ -289,13 +286,52 @@ func (p *rp2PWM) Ramp(to uint16, durationMs uint32, steps uint16, _ core.PWMRamp
 }
 
 // Global PWM policy: per-slice frequency compatibility.
-var globalPWM struct {
-	mu    sync.Mutex
-	slice map[int]*sliceCfg // slice index -> cfg
+var pwmSlices core.PWMGroups
+
+// pwmPeers lists the claimed channels of each slice, so a negotiated
+// period change can rescale the channels already running. Lock order:
+// pwmSlices, then pwmPeers, then rp2PWM.mu.
+var pwmPeers pwmPeerTable
+
+type pwmPeerTable struct {
+	mu sync.Mutex
+	by [8][]*rp2PWM
 }
 
-func init() {
-	globalPWM.slice = make(map[int]*sliceCfg)
+func (t *pwmPeerTable) add(p *rp2PWM) {
+	t.mu.Lock()
+	t.by[p.slice] = append(t.by[p.slice], p)
+	t.mu.Unlock()
+}
+
+func (t *pwmPeerTable) remove(p *rp2PWM) {
+	t.mu.Lock()
+	l := t.by[p.slice]
+	for i, q := range l {
+		if q == p {
+			t.by[p.slice] = append(l[:i], l[i+1:]...)
+			break
+		}
+	}
+	t.mu.Unlock()
+}
+
+// rescale re-applies the level of every configured channel on p's slice
+// other than p against the controller's current top.
+func (t *pwmPeerTable) rescale(p *rp2PWM) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, q := range t.by[p.slice] {
+		if q == p {
+			continue
+		}
+		q.mu.Lock()
+		if q.hwTop != 0 {
+			q.hwTop = q.ctrl.Top()
+			q.setHW(q.level)
+		}
+		q.mu.Unlock()
+	}
 }
 
 // -----------------------------------------------------------------------------
@@ -342,6 +378,8 @@ type i2cOwner struct {
 	hw   *machine.I2C
 	reqs chan i2cReq
 	quit chan struct{}
+	errs *metrics.Counter // i2c.<id>.errors: bus errors, enqueue busy, timeouts
+	pec  *metrics.Counter // i2c.<id>.pec_errors: SMBus PEC mismatches
 }
 
 func newI2COwner(id core.ResourceID, hw *machine.I2C) *i2cOwner {
@@ -350,6 +388,8 @@ func newI2COwner(id core.ResourceID, hw *machine.I2C) *i2cOwner {
 		hw:   hw,
 		reqs: make(chan i2cReq, 16),
 		quit: make(chan struct{}),
+		errs: metrics.NewCounter("i2c." + string(id) + ".errors"),
+		pec:  metrics.NewCounter("i2c." + string(id) + ".pec_errors"),
 	}
 	go o.loop()
 	return o
@@ -360,6 +400,9 @@ func (o *i2cOwner) loop() {
 		select {
 		case req := <-o.reqs:
 			err := o.hw.Tx(req.addr, req.w, req.r)
+			if err != nil {
+				o.errs.Inc()
+			}
 			// best-effort reply; do not block the worker
 			select {
 			case req.done <- err:
@@ -380,8 +423,11 @@ type driversI2C struct {
 	timeout time.Duration // 0 => no deadline
 }
 
-// Ensure compile-time conformance with drivers.I2C
-var _ drivers.I2C = (*driversI2C)(nil)
+// Ensure compile-time conformance with drivers.I2C and core.SMBus
+var (
+	_ drivers.I2C = (*driversI2C)(nil)
+	_ core.SMBus  = (*driversI2C)(nil)
+)
 
 func (d *driversI2C) Tx(addr uint16, w, r []byte) error {
 	req := i2cReq{addr: addr, w: w, r: r, done: make(chan error, 1)}
@@ -408,6 +454,7 @@ func (d *driversI2C) Tx(addr uint16, w, r []byte) error {
 				}
 			}
 		case <-t.C:
+			d.o.errs.Inc()
 			return errcode.Busy
 		}
 		// Reuse 't' for completion wait below.
@@ -424,10 +471,177 @@ func (d *driversI2C) Tx(addr uint16, w, r []byte) error {
 	case err := <-req.done:
 		return err
 	case <-t.C:
+		d.o.errs.Inc()
 		return errcode.Timeout
 	}
 }
 
+// SMBus framing is layered on Tx; each call is still one bus transaction.
+
+func (d *driversI2C) TxPEC(addr uint16, w, r []byte) error {
+	if len(r) == 0 {
+		wp := append(append(make([]byte, 0, len(w)+1), w...), smbus.PEC(addr, w, nil))
+		return d.Tx(addr, wp, nil)
+	}
+	rp := make([]byte, len(r)+1)
+	if err := d.Tx(addr, w, rp); err != nil {
+		return err
+	}
+	if smbus.PEC(addr, w, rp[:len(r)]) != rp[len(r)] {
+		d.o.pec.Inc()
+		return errcode.CRCMismatch
+	}
+	copy(r, rp)
+	return nil
+}
+
+func (d *driversI2C) BlockRead(addr uint16, cmd byte, buf []byte, pec bool) (int, error) {
+	n := len(buf)
+	if n > smbus.MaxBlock {
+		n = smbus.MaxBlock
+	}
+	rp := make([]byte, smbus.BlockReadLen(n, pec))
+	if err := d.Tx(addr, []byte{cmd}, rp); err != nil {
+		return 0, err
+	}
+	data, err := smbus.ParseBlockRead(addr, cmd, rp, pec)
+	switch err {
+	case nil:
+	case smbus.ErrPEC:
+		d.o.pec.Inc()
+		return 0, errcode.CRCMismatch
+	default:
+		return 0, errcode.InvalidPayload
+	}
+	return copy(buf, data), nil
+}
+
+func (d *driversI2C) BlockWrite(addr uint16, cmd byte, data []byte, pec bool) error {
+	w, err := smbus.BlockWrite(addr, cmd, data, pec)
+	if err != nil {
+		return errcode.InvalidParams
+	}
+	return d.Tx(addr, w, nil)
+}
+
+func (d *driversI2C) PECErrors() uint32 { return d.o.pec.Value() }
+
+// -----------------------------------------------------------------------------
+// 1-Wire owner (bit-banged, one worker per bus)
+// -----------------------------------------------------------------------------
+
+// rp2OWLine drives the wire open-drain: low is output-low, high is input
+// (the external pull-up does the rest). Each slot runs with interrupts
+// masked so it cannot be stretched out of its window.
+type rp2OWLine struct {
+	p   machine.Pin
+	irq interrupt.State
+}
+
+func (l *rp2OWLine) Low() {
+	l.p.Low()
+	l.p.Configure(machine.PinConfig{Mode: machine.PinOutput})
+}
+func (l *rp2OWLine) Release()   { l.p.Configure(machine.PinConfig{Mode: machine.PinInput}) }
+func (l *rp2OWLine) High() bool { return l.p.Get() }
+func (l *rp2OWLine) Lock()      { l.irq = interrupt.Disable() }
+func (l *rp2OWLine) Unlock()    { interrupt.Restore(l.irq) }
+
+func (l *rp2OWLine) Wait(us uint32) {
+	end := time.Now().Add(time.Duration(us) * time.Microsecond)
+	for time.Now().Before(end) {
+	}
+}
+
+type owReq struct {
+	search bool
+	rom    onewire.ROM
+	w, r   []byte
+	done   chan owResult // buffered(1)
+}
+
+type owResult struct {
+	roms []onewire.ROM
+	err  error
+}
+
+type owOwner struct {
+	bus  *onewire.Bus
+	reqs chan owReq
+	quit chan struct{}
+	errs *metrics.Counter // ow.<id>.errors: no presence, CRC, search failures
+}
+
+func newOWOwner(id core.ResourceID, pin machine.Pin) *owOwner {
+	pin.Low()
+	pin.Configure(machine.PinConfig{Mode: machine.PinInput})
+	o := &owOwner{
+		bus:  onewire.NewBus(onewire.Bitbang{L: &rp2OWLine{p: pin}}),
+		reqs: make(chan owReq, 4),
+		quit: make(chan struct{}),
+		errs: metrics.NewCounter("ow." + string(id) + ".errors"),
+	}
+	go o.loop()
+	return o
+}
+
+func (o *owOwner) loop() {
+	for {
+		select {
+		case req := <-o.reqs:
+			var res owResult
+			if req.search {
+				res.roms, res.err = o.bus.Search(nil)
+			} else {
+				res.err = o.bus.Tx(req.rom, req.w, req.r)
+			}
+			if res.err != nil {
+				o.errs.Inc()
+			}
+			select {
+			case req.done <- res:
+			default:
+			}
+		case <-o.quit:
+			return
+		}
+	}
+}
+
+func (o *owOwner) stop() { close(o.quit) }
+
+// owBus adapts the owner to core.OneWire. A search of a full bus takes
+// tens of milliseconds, so the deadline is generous.
+type owBus struct{ o *owOwner }
+
+const owTimeout = time.Second
+
+func (b *owBus) do(req owReq) owResult {
+	req.done = make(chan owResult, 1)
+	t := time.NewTimer(owTimeout)
+	defer t.Stop()
+	select {
+	case b.o.reqs <- req:
+	case <-t.C:
+		return owResult{err: errcode.Busy}
+	}
+	select {
+	case res := <-req.done:
+		return res
+	case <-t.C:
+		return owResult{err: errcode.Timeout}
+	}
+}
+
+func (b *owBus) Search() ([]onewire.ROM, error) {
+	res := b.do(owReq{search: true})
+	return res.roms, res.err
+}
+
+func (b *owBus) Tx(rom onewire.ROM, w, r []byte) error {
+	return b.do(owReq{rom: rom, w: w, r: r}).err
+}
+
 // -----------------------------------------------------------------------------
 // Resource registry (GPIO + PWM + I2C)
 // -----------------------------------------------------------------------------
@@ -443,10 +657,25 @@ type rp2Registry struct {
 	// I2C
 	i2cOwners map[core.ResourceID]*i2cOwner
 
+	// 1-Wire
+	owOwners map[core.ResourceID]*owOwner
+
 	// UART
 	uartPorts  map[core.ResourceID]*rp2SerialPort
 	uartOwners map[core.ResourceID]string // <- NEW: bus id -> devID
 
+	// Plan entries rejected by pin-mux validation; claims report the cause.
+	planErrs map[core.ResourceID]error
+
+	// For hal/resources only: controller pins by bus, and the devices
+	// holding a shared (I2C, 1-Wire) bus.
+	busPins  map[core.ResourceID][]types.PinClaim
+	busUsers map[core.ResourceID][]string
+	aliases  map[string]int
+
+	// PIO blocks and their claimed state machines
+	pio [2]*pioBlock
+
 	// GPIO edge subscriptions
 	edge onceIRQ // worker + per-pin tables
 
@@ -463,56 +692,180 @@ func NewResourceRegistry(plan setups.ResourcePlan) *rp2Registry {
 		gpioMap:    make(map[int]*rp2GPIO),
 		pwmMap:     make(map[int]*rp2PWM),
 		i2cOwners:  make(map[core.ResourceID]*i2cOwner),
+		owOwners:   make(map[core.ResourceID]*owOwner),
 		uartPorts:  make(map[core.ResourceID]*rp2SerialPort),
 		uartOwners: make(map[core.ResourceID]string),
+		planErrs:   make(map[core.ResourceID]error),
+		busPins:    make(map[core.ResourceID][]types.PinClaim),
+		busUsers:   make(map[core.ResourceID][]string),
+		aliases:    make(map[string]int),
+		pio:        newPIOBlocks(),
 		edge:       newOnceIRQ(),
 	}
 
-	// Instantiate I2C owners from the provided plan (pins and frequency).
+	r.applyPlan(plan)
+	return r
+}
+
+// ApplyBuses instantiates controllers from a run-time plan (config/hal
+// "buses"). Controllers that are already running are left untouched.
+func (r *rp2Registry) ApplyBuses(plan types.BusPlan) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.applyPlan(plan)
+}
+
+func (r *rp2Registry) applyPlan(plan setups.ResourcePlan) {
+	for k, v := range plan.Aliases {
+		r.aliases[k] = v
+	}
 	for _, p := range plan.I2C {
-		var hw *machine.I2C
-		switch p.ID {
-		case "i2c0":
-			hw = machine.I2C0
-		case "i2c1":
-			hw = machine.I2C1
-		default:
+		if _, ok := r.i2cOwners[core.ResourceID(p.ID)]; ok {
 			continue
 		}
-		// Configure pins & bus frequency.
-		sda := machine.Pin(p.SDA)
-		scl := machine.Pin(p.SCL)
-		sda.Configure(machine.PinConfig{Mode: machine.PinI2C})
-		scl.Configure(machine.PinConfig{Mode: machine.PinI2C})
-		hw.Configure(machine.I2CConfig{
-			SCL:       scl,
-			SDA:       sda,
-			Frequency: p.Hz,
-		})
-		r.i2cOwners[core.ResourceID(p.ID)] = newI2COwner(core.ResourceID(p.ID), hw)
+		if err := r.addI2C(p); err != nil {
+			r.planErrs[core.ResourceID(p.ID)] = err
+		} else {
+			delete(r.planErrs, core.ResourceID(p.ID))
+		}
 	}
-
-	// UART setup
 	for _, u := range plan.UART {
-		var hw *uartx.UART
-		switch u.ID {
-		case "uart0":
-			hw = uartx.UART0
-		case "uart1":
-			hw = uartx.UART1
-		default:
+		if _, ok := r.uartPorts[core.ResourceID(u.ID)]; ok {
 			continue
 		}
-		// Configure pins and baud. Defaults inside uartx will apply if zero.
-		_ = hw.Configure(uartx.UARTConfig{
-			BaudRate: u.Baud,
-			TX:       machine.Pin(u.TX),
-			RX:       machine.Pin(u.RX),
-		})
-		r.uartPorts[core.ResourceID(u.ID)] = &rp2SerialPort{u: hw}
+		if err := r.addUART(u); err != nil {
+			r.planErrs[core.ResourceID(u.ID)] = err
+		} else {
+			delete(r.planErrs, core.ResourceID(u.ID))
+		}
 	}
+	for _, p := range plan.OneWire {
+		if _, ok := r.owOwners[core.ResourceID(p.ID)]; ok {
+			continue
+		}
+		if err := r.addOneWire(p); err != nil {
+			r.planErrs[core.ResourceID(p.ID)] = err
+		} else {
+			delete(r.planErrs, core.ResourceID(p.ID))
+		}
+	}
+}
 
-	return r
+// busPin validates a controller pin and that no device holds it as GPIO/PWM.
+func (r *rp2Registry) busPin(id, role string, n int, want boards.PinCap) error {
+	if err := checkPin(id, role, n, want); err != nil {
+		return err
+	}
+	if owner, inUse := r.pinOwners[n]; inUse && owner.devID != "" {
+		return &errcode.E{C: errcode.PinInUse, Op: id, Field: role,
+			Msg: "gpio " + strconv.Itoa(n) + " claimed by " + owner.devID}
+	}
+	return nil
+}
+
+// addI2C configures pins and frequency and starts the bus owner.
+func (r *rp2Registry) addI2C(p setups.I2CPlan) error {
+	var hw *machine.I2C
+	switch p.ID {
+	case "i2c0":
+		hw = machine.I2C0
+	case "i2c1":
+		hw = machine.I2C1
+	default:
+		return errcode.UnknownBus
+	}
+	sdaCap, sclCap := boards.I2CCaps(p.ID)
+	if err := firstErr(
+		r.busPin(p.ID, "SDA", p.SDA, sdaCap),
+		r.busPin(p.ID, "SCL", p.SCL, sclCap),
+	); err != nil {
+		return err
+	}
+	// Configure pins & bus frequency.
+	sda := machine.Pin(p.SDA)
+	scl := machine.Pin(p.SCL)
+	sda.Configure(machine.PinConfig{Mode: machine.PinI2C})
+	scl.Configure(machine.PinConfig{Mode: machine.PinI2C})
+	hw.Configure(machine.I2CConfig{
+		SCL:       scl,
+		SDA:       sda,
+		Frequency: p.Hz,
+	})
+	r.i2cOwners[core.ResourceID(p.ID)] = newI2COwner(core.ResourceID(p.ID), hw)
+	r.busPins[core.ResourceID(p.ID)] = []types.PinClaim{
+		{Pin: p.SDA, Func: "SDA", Owner: p.ID},
+		{Pin: p.SCL, Func: "SCL", Owner: p.ID},
+	}
+	return nil
+}
+
+// addUART configures pins, baud and optional flow control and wraps the port.
+func (r *rp2Registry) addUART(u setups.UARTPlan) error {
+	var hw *uartx.UART
+	var base uintptr
+	switch u.ID {
+	case "uart0":
+		hw, base = uartx.UART0, uart0Base
+	case "uart1":
+		hw, base = uartx.UART1, uart1Base
+	default:
+		return errcode.UnknownBus
+	}
+	txCap, rxCap, ctsCap, rtsCap := boards.UARTCaps(u.ID)
+	err := firstErr(
+		r.busPin(u.ID, "TX", u.TX, txCap),
+		r.busPin(u.ID, "RX", u.RX, rxCap),
+	)
+	if err == nil && u.CTS != nil && u.RTS != nil {
+		err = firstErr(
+			r.busPin(u.ID, "CTS", *u.CTS, ctsCap),
+			r.busPin(u.ID, "RTS", *u.RTS, rtsCap),
+		)
+	}
+	if err != nil {
+		return err
+	}
+	// Configure pins and baud. Defaults inside uartx will apply if zero.
+	_ = hw.Configure(uartx.UARTConfig{
+		BaudRate: u.Baud,
+		TX:       machine.Pin(u.TX),
+		RX:       machine.Pin(u.RX),
+	})
+	port := newRP2SerialPort(hw, base)
+	port.rxPin = machine.Pin(u.RX)
+	port.armRXStamp()
+	pins := []types.PinClaim{
+		{Pin: u.TX, Func: "TX", Owner: u.ID},
+		{Pin: u.RX, Func: "RX", Owner: u.ID},
+	}
+	if u.CTS != nil && u.RTS != nil {
+		machine.Pin(*u.CTS).Configure(machine.PinConfig{Mode: machine.PinUART})
+		machine.Pin(*u.RTS).Configure(machine.PinConfig{Mode: machine.PinUART})
+		port.hasFlow = true
+		_ = port.SetFlowControl(u.Flow)
+		pins = append(pins,
+			types.PinClaim{Pin: *u.CTS, Func: "CTS", Owner: u.ID},
+			types.PinClaim{Pin: *u.RTS, Func: "RTS", Owner: u.ID})
+	}
+	r.busPins[core.ResourceID(u.ID)] = pins
+	r.uartPorts[core.ResourceID(u.ID)] = port
+	metrics.Default.Func(u.ID+".rx_overruns", func() int64 { return int64(port.RXOverruns()) })
+	return nil
+}
+
+// addOneWire starts a bit-banged bus. Unlike controller pins, the GPIO is
+// also recorded as owned by the bus so that no device can claim it.
+func (r *rp2Registry) addOneWire(p setups.OneWirePlan) error {
+	if len(p.ID) < 3 || p.ID[:2] != "ow" {
+		return errcode.UnknownBus
+	}
+	if err := r.busPin(p.ID, "DQ", p.Pin, boards.CapGPIO); err != nil {
+		return err
+	}
+	r.pinOwners[p.Pin] = pinOwner{devID: p.ID, fn: core.FuncGPIOOut}
+	r.owOwners[core.ResourceID(p.ID)] = newOWOwner(core.ResourceID(p.ID), machine.Pin(p.Pin))
+	r.busPins[core.ResourceID(p.ID)] = []types.PinClaim{{Pin: p.Pin, Func: "DQ", Owner: p.ID}}
+	return nil
 }
 
 func (r *rp2Registry) ClassOf(id core.ResourceID) (core.BusClass, bool) {
@@ -522,6 +875,12 @@ func (r *rp2Registry) ClassOf(id core.ResourceID) (core.BusClass, bool) {
 	case "uart0", "uart1":
 		return core.BusStream, true
 	}
+	r.mu.Lock()
+	_, ok := r.owOwners[id]
+	r.mu.Unlock()
+	if ok {
+		return core.BusTransactional, true
+	}
 	return 0, false
 }
 
@@ -531,13 +890,61 @@ func (r *rp2Registry) ClaimI2C(devID string, id core.ResourceID) (drivers.I2C, e
 	defer r.mu.Unlock()
 	o := r.i2cOwners[id]
 	if o == nil {
+		if err := r.planErrs[id]; err != nil {
+			return nil, err
+		}
 		return nil, errcode.UnknownBus
 	}
+	r.addBusUser(id, devID)
 	return &driversI2C{o: o, timeout: 250 * time.Millisecond}, nil
 }
 
+// ReleaseI2C only forgets the user; owners are long-lived per bus.
 func (r *rp2Registry) ReleaseI2C(devID string, id core.ResourceID) {
-	// Owners are long-lived per bus; nothing to do here.
+	r.mu.Lock()
+	r.dropBusUser(id, devID)
+	r.mu.Unlock()
+}
+
+// 1-Wire (shared; the owner serialises transactions)
+func (r *rp2Registry) ClaimOneWire(devID string, id core.ResourceID) (core.OneWire, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o := r.owOwners[id]
+	if o == nil {
+		if err := r.planErrs[id]; err != nil {
+			return nil, err
+		}
+		return nil, errcode.UnknownBus
+	}
+	r.addBusUser(id, devID)
+	return &owBus{o: o}, nil
+}
+
+func (r *rp2Registry) ReleaseOneWire(devID string, id core.ResourceID) {
+	r.mu.Lock()
+	r.dropBusUser(id, devID)
+	r.mu.Unlock()
+}
+
+// addBusUser and dropBusUser keep busUsers; r.mu must be held.
+func (r *rp2Registry) addBusUser(id core.ResourceID, devID string) {
+	for _, u := range r.busUsers[id] {
+		if u == devID {
+			return
+		}
+	}
+	r.busUsers[id] = append(r.busUsers[id], devID)
+}
+
+func (r *rp2Registry) dropBusUser(id core.ResourceID, devID string) {
+	us := r.busUsers[id]
+	for i, u := range us {
+		if u == devID {
+			r.busUsers[id] = append(us[:i:i], us[i+1:]...)
+			return
+		}
+	}
 }
 
 // Serial
@@ -547,6 +954,9 @@ func (r *rp2Registry) ClaimSerial(devID string, id core.ResourceID) (core.Serial
 
 	p := r.uartPorts[id]
 	if p == nil {
+		if err := r.planErrs[id]; err != nil {
+			return nil, err
+		}
 		return nil, errcode.UnknownBus
 	}
 	if owner, taken := r.uartOwners[id]; taken && owner != "" && owner != devID {
@@ -566,9 +976,54 @@ func (r *rp2Registry) ReleaseSerial(devID string, id core.ResourceID) {
 }
 
 // Unified pin claims
-func (r *rp2Registry) inBoardRange(n int) bool {
-	min, max := boards.SelectedBoard.GPIOMin, boards.SelectedBoard.GPIOMax
-	return n >= min && n <= max
+// pinFuncCap maps a claimable function to the board capability it needs.
+func pinFuncCap(fn core.PinFunc) boards.PinCap {
+	switch fn {
+	case core.FuncGPIOIn, core.FuncGPIOOut:
+		return boards.CapGPIO
+	case core.FuncPWM:
+		return boards.CapPWM
+	case core.FuncPIO:
+		return boards.CapPIO
+	}
+	return 0
+}
+
+// checkPin validates that the board can route want to GPIO n.
+func checkPin(op, role string, n int, want boards.PinCap) error {
+	b := &boards.SelectedBoard
+	if n < b.GPIOMin || n > b.GPIOMax {
+		return &errcode.E{C: errcode.UnknownPin, Op: op, Field: role,
+			Msg: "gpio " + strconv.Itoa(n) + " not on board " + b.Name}
+	}
+	if !b.Supports(n, want) {
+		return &errcode.E{C: errcode.PinFunc, Op: op, Field: role,
+			Msg: "gpio " + strconv.Itoa(n) + " cannot be " + want.String() + " (has " + b.Caps(n).String() + ")"}
+	}
+	return nil
+}
+
+func firstErr(errs ...error) error {
+	for _, e := range errs {
+		if e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// PinByName resolves setup aliases first, then the board's own.
+func (r *rp2Registry) PinByName(name string) (int, error) {
+	r.mu.Lock()
+	n, ok := r.aliases[name]
+	r.mu.Unlock()
+	if ok {
+		return n, nil
+	}
+	if n, ok := boards.SelectedBoard.Alias(name); ok {
+		return n, nil
+	}
+	return -1, &errcode.E{C: errcode.UnknownPin, Op: "pin_alias", Msg: "no pin named " + name}
 }
 
 func (r *rp2Registry) lookupGPIO(n int) *rp2GPIO {
@@ -584,8 +1039,8 @@ func (r *rp2Registry) ClaimPin(devID string, n int, fn core.PinFunc) (core.PinHa
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if !r.inBoardRange(n) {
-		return nil, errcode.UnknownPin
+	if err := checkPin(devID, "pin", n, pinFuncCap(fn)); err != nil {
+		return nil, err
 	}
 	if owner, inUse := r.pinOwners[n]; inUse && owner.devID != "" {
 		return nil, errcode.PinInUse
@@ -619,6 +1074,7 @@ func (r *rp2Registry) ClaimPin(devID string, n int, fn core.PinFunc) (core.PinHa
 		}
 		// Cache for later cleanup on release.
 		r.pwmMap[n] = ph.pwm
+		pwmPeers.add(ph.pwm)
 
 	default:
 		return nil, errcode.Unsupported
@@ -650,17 +1106,11 @@ func (r *rp2Registry) ReleasePin(devID string, n int) {
 				p.mu.Unlock()
 
 				// Slice user accounting.
-				globalPWM.mu.Lock()
-				if sc := globalPWM.slice[p.slice]; sc != nil && p.registered {
-					if sc.users > 0 {
-						sc.users--
-					}
-					if sc.users == 0 {
-						sc.freqHz = 0
-					}
+				if p.registered {
+					pwmSlices.Leave(p.slice)
 					p.registered = false
 				}
-				globalPWM.mu.Unlock()
+				pwmPeers.remove(p)
 			}
 		}
 
@@ -684,6 +1134,93 @@ func PeriodFromHz(hz uint64) uint64 {
 	return uint64(time.Second) / hz
 }
 
+// ReleaseAll drops every pin, PIO, UART and shared-bus claim held by devID.
+func (r *rp2Registry) ReleaseAll(devID string) {
+	r.mu.Lock()
+	for _, b := range r.pio {
+		for i, sm := range b.sms {
+			if sm != nil && sm.devID == devID {
+				r.releasePIO(sm)
+				b.sms[i] = nil
+			}
+		}
+	}
+	var pins []int
+	for n, o := range r.pinOwners {
+		if o.devID == devID {
+			pins = append(pins, n)
+		}
+	}
+	for id, owner := range r.uartOwners {
+		if owner == devID {
+			delete(r.uartOwners, id)
+		}
+	}
+	for id := range r.busUsers {
+		r.dropBusUser(id, devID)
+	}
+	r.mu.Unlock()
+	for _, n := range pins {
+		r.ReleasePin(devID, n) // also closes the pin's edge streams
+	}
+}
+
+// Resources lists pin and bus ownership for hal/resources. 1-Wire pins
+// are recorded in pinOwners under the bus ID, so they are listed once,
+// with the other bus pins.
+func (r *rp2Registry) Resources() types.ResourceMap {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var m types.ResourceMap
+	for n, o := range r.pinOwners {
+		if _, bus := r.owOwners[core.ResourceID(o.devID)]; o.devID == "" || bus {
+			continue
+		}
+		m.Pins = append(m.Pins, types.PinClaim{Pin: n, Func: o.fn.String(), Owner: o.devID})
+	}
+	for _, pins := range r.busPins {
+		m.Pins = append(m.Pins, pins...)
+	}
+	sort.Slice(m.Pins, func(i, j int) bool { return m.Pins[i].Pin < m.Pins[j].Pin })
+
+	add := func(id core.ResourceID, class string) {
+		b := types.BusClaim{ID: string(id), Class: class}
+		if err := r.planErrs[id]; err != nil {
+			b.Error = err.Error()
+		}
+		if class == "uart" {
+			if owner := r.uartOwners[id]; owner != "" {
+				b.Users = []string{owner}
+			}
+		} else if us := r.busUsers[id]; len(us) > 0 {
+			b.Users = append([]string(nil), us...)
+			sort.Strings(b.Users)
+		}
+		m.Buses = append(m.Buses, b)
+	}
+	for id := range r.i2cOwners {
+		add(id, "i2c")
+	}
+	for id := range r.uartPorts {
+		add(id, "uart")
+	}
+	for id := range r.owOwners {
+		add(id, "onewire")
+	}
+	for id := range r.planErrs {
+		switch s := string(id); {
+		case len(s) > 3 && s[:3] == "i2c":
+			add(id, "i2c")
+		case len(s) > 4 && s[:4] == "uart":
+			add(id, "uart")
+		default:
+			add(id, "onewire")
+		}
+	}
+	sort.Slice(m.Buses, func(i, j int) bool { return m.Buses[i].ID < m.Buses[j].ID })
+	return m
+}
+
 // Close stops background workers (e.g. per-bus I2C goroutines).
 func (r *rp2Registry) Close() {
 	r.mu.Lock()
@@ -693,6 +1230,9 @@ func (r *rp2Registry) Close() {
 			o.stop()
 		}
 	}
+	for _, o := range r.owOwners {
+		o.stop()
+	}
 	r.edge.stop()
 }
 
@@ -703,14 +1243,127 @@ func (r *rp2Registry) ReadOnDieMilliC() int32 {
 }
 
 // rp2SerialPort adapts uartx.UART to serialPortX.
-type rp2SerialPort struct{ u *uartx.UART }
+type rp2SerialPort struct {
+	u *uartx.UART
+
+	// PL011 registers of this instance.
+	rsr *volatile.Register32 // UARTRSR; OE is sticky until written
+	cr  *volatile.Register32 // UARTCR
+	fr  *volatile.Register32 // UARTFR
+
+	overruns atomic.Uint32
+	hasFlow  bool // CTS/RTS pins are muxed to the UART
+
+	// RX start-of-burst timestamping: a falling-edge interrupt on the RX
+	// pin stamps the first start bit after the FIFO drains, then disarms
+	// itself so a burst costs one interrupt.
+	rxPin   machine.Pin
+	rxStamp atomic.Int64 // set by rxStartISR; 0 while armed
+	rxArmed atomic.Bool
+	lastTS  int64 // stamp for the last TryRead; reader goroutine only
+}
+
+// PL011 registers (RP2040 datasheet §4.2.8).
+const (
+	uart0Base = 0x40034000
+	uart1Base = 0x40038000
+
+	uartRSR = 0x004
+	uartFR  = 0x018
+	uartCR  = 0x030
+
+	rsrOE    = 1 << 3 // RX FIFO overrun
+	frBusy   = 1 << 3
+	crUARTEN = 1 << 0
+	crRTSEN  = 1 << 14
+	crCTSEN  = 1 << 15
+)
+
+func newRP2SerialPort(u *uartx.UART, base uintptr) *rp2SerialPort {
+	return &rp2SerialPort{
+		u:   u,
+		rsr: reg32(base + uartRSR),
+		cr:  reg32(base + uartCR),
+		fr:  reg32(base + uartFR),
+	}
+}
+
+// SetFlowControl enables or disables RTS/CTS. The PL011 must be disabled
+// while CR is reprogrammed, so pending TX is flushed first.
+func (p *rp2SerialPort) SetFlowControl(on bool) error {
+	if !p.hasFlow {
+		return errcode.Unsupported
+	}
+	_ = p.u.Flush()
+	for p.fr.HasBits(frBusy) {
+	}
+	cr := p.cr.Get()
+	p.cr.Set(cr &^ crUARTEN)
+	if on {
+		cr |= crRTSEN | crCTSEN
+	} else {
+		cr &^= crRTSEN | crCTSEN
+	}
+	p.cr.Set(cr)
+	return nil
+}
+
+// pollOverrun latches and clears the hardware overrun flag. The flag is
+// sticky, so polling on every read is enough to count each overrun episode.
+func (p *rp2SerialPort) pollOverrun() {
+	if p.rsr.HasBits(rsrOE) {
+		p.overruns.Add(1)
+		p.rsr.Set(0)
+	}
+}
+
+func (p *rp2SerialPort) RXOverruns() uint32 {
+	p.pollOverrun()
+	return p.overruns.Load()
+}
 
 func (p *rp2SerialPort) Readable() <-chan struct{} { return p.u.Readable() }
 func (p *rp2SerialPort) Writable() <-chan struct{} { return p.u.Writable() }
-func (p *rp2SerialPort) TryRead(b []byte) int      { return p.u.TryRead(b) }
 func (p *rp2SerialPort) TryWrite(b []byte) int     { return p.u.TryWrite(b) }
 func (p *rp2SerialPort) Flush() error              { return p.u.Flush() }
 
+// TryRead hands the pending start-of-burst stamp to the first read that
+// returns data, and re-arms the RX edge interrupt once a read finds the
+// FIFO drained.
+func (p *rp2SerialPort) TryRead(b []byte) int {
+	p.pollOverrun()
+	n := p.u.TryRead(b)
+	if n > 0 {
+		p.lastTS = p.rxStamp.Swap(0)
+	}
+	if n < len(b) && !p.rxArmed.Load() && p.rxStamp.Load() == 0 {
+		p.armRXStamp()
+	}
+	return n
+}
+
+func (p *rp2SerialPort) RXStamp() int64 { return p.lastTS }
+
+// rxStampPorts maps an RX pin to its port for rxStartISR (no closures).
+var rxStampPorts [32]*rp2SerialPort
+
+func (p *rp2SerialPort) armRXStamp() {
+	rxStampPorts[p.rxPin] = p
+	p.rxArmed.Store(true)
+	_ = p.rxPin.SetInterrupt(machine.PinFalling, rxStartISR)
+}
+
+// rxStartISR runs on the first RX start bit after arming.
+func rxStartISR(pin machine.Pin) {
+	p := rxStampPorts[pin]
+	if p == nil || !p.rxArmed.Load() {
+		return
+	}
+	p.rxStamp.Store(time.Now().UnixNano())
+	p.rxArmed.Store(false)
+	_ = pin.SetInterrupt(machine.PinFalling, nil)
+}
+
 func (p *rp2SerialPort) SetBaudRate(br uint32) error { p.u.SetBaudRate(br); return nil }
 
 // Parity strings: "none","even","odd"
@@ -731,11 +1384,15 @@ func (p *rp2SerialPort) SetFormat(databits, stopbits uint8, parity string) error
 // GPIO IRQ worker: best-effort edge delivery with debounce and selection
 // -----------------------------------------------------------------------------
 
-// onceIRQ encapsulates a single worker that services all pins.
+// onceIRQ encapsulates a single worker that services all pins. A pin may
+// carry several subscriptions (the claiming device plus observers), each
+// with its own edge selection, debounce and qualification state; the ISR is
+// armed for the union of their edges and the worker fans every level change
+// out to all of them.
 type onceIRQ struct {
 	mu      sync.Mutex
-	subs    map[int]*edgeSub // pin -> subscription
-	wake    chan struct{}    // size 1; ISRs perform non-blocking send
+	subs    map[int][]*edgeSub // pin -> subscriptions
+	wake    chan struct{}      // size 1; ISRs perform non-blocking send
 	running bool
 	quit    chan struct{}
 	done    chan struct{}
@@ -746,7 +1403,7 @@ type onceIRQ struct {
 
 func newOnceIRQ() onceIRQ {
 	return onceIRQ{
-		subs: make(map[int]*edgeSub),
+		subs: make(map[int][]*edgeSub),
 		wake: make(chan struct{}, 1),
 	}
 }
@@ -764,13 +1421,21 @@ type edgeSub struct {
 	lastLvl uint32 // 0/1
 }
 
+// closeLocked closes the event channel once. Caller holds w.mu.
+func (s *edgeSub) closeLocked() {
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
 type gpioEdgeStream struct {
 	s      *edgeSub
 	parent *onceIRQ
 }
 
 func (g *gpioEdgeStream) Events() <-chan core.GPIOEdgeEvent { return g.s.ch }
-func (g *gpioEdgeStream) Close()                            { g.parent.unsubscribe(g.s.pin) }
+func (g *gpioEdgeStream) Close()                            { g.parent.remove(g.s) }
 func (g *gpioEdgeStream) SetDebounce(d time.Duration) bool {
 	g.parent.mu.Lock()
 	g.s.debounce = d
@@ -778,17 +1443,22 @@ func (g *gpioEdgeStream) SetDebounce(d time.Duration) bool {
 	return true
 }
 func (g *gpioEdgeStream) SetEdges(sel core.GPIOEdge) bool {
-	flags := mapEdges(sel)
-	if !installISRForPin(g.parent, g.s.pin, flags) {
-		return false
-	}
 	g.parent.mu.Lock()
+	prev := g.s.edges
 	g.s.edges = sel
+	flags := g.parent.flagsLocked(g.s.pin)
 	g.parent.mu.Unlock()
+	if !installISRForPin(g.parent, g.s.pin, flags) {
+		g.parent.mu.Lock()
+		g.s.edges = prev
+		g.parent.mu.Unlock()
+		return false
+	}
 	return true
 }
 
-// Subscribe from registry.
+// Subscribe from registry. The pin must be claimed as a GPIO input; the
+// claiming device and any number of observers may subscribe to it.
 func (r *rp2Registry) SubscribeGPIOEdges(devID string, pin int, sel core.GPIOEdge, debounce time.Duration, buf int) (core.GPIOEdgeStream, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -797,7 +1467,7 @@ func (r *rp2Registry) SubscribeGPIOEdges(devID string, pin int, sel core.GPIOEdg
 	if !ok {
 		return nil, errcode.UnknownPin
 	}
-	if owner.devID != devID || owner.fn != core.FuncGPIOIn {
+	if owner.fn != core.FuncGPIOIn {
 		return nil, errcode.Conflict
 	}
 	if buf <= 0 {
@@ -815,54 +1485,98 @@ func (r *rp2Registry) SubscribeGPIOEdges(devID string, pin int, sel core.GPIOEdg
 
 	// Register subscription before enabling interrupts so an immediate IRQ
 	// has a destination with initial state already seeded.
-	r.edge.subscribe(s)
+	flags := r.edge.subscribe(s)
 
-	// Now attach ISR. If this fails, roll back the subscription.
-	flags := mapEdges(sel)
+	// Now (re)arm the ISR for the pin. If this fails, roll back.
 	if flags != 0 && !installISRForPin(&r.edge, pin, flags) {
-		r.edge.unsubscribe(pin)
+		r.edge.remove(s)
 		return nil, errcode.Unsupported
 	}
 	return &gpioEdgeStream{s: s, parent: &r.edge}, nil
 }
 
+// UnsubscribeGPIOEdges closes every subscription devID holds on pin; other
+// subscribers on the same pin are unaffected.
 func (r *rp2Registry) UnsubscribeGPIOEdges(devID string, pin int) {
 	r.mu.Lock()
-	if owner, ok := r.pinOwners[pin]; ok && owner.devID == devID {
-		r.edge.unsubscribe(pin)
-	}
+	r.edge.unsubscribeDev(pin, devID)
 	r.mu.Unlock()
 }
 
-// Internal: add/remove subscription and manage worker lifecycle.
-func (w *onceIRQ) subscribe(s *edgeSub) {
+// Internal: add/remove subscriptions and manage worker lifecycle.
+
+// subscribe adds s and returns the ISR flags now required for its pin.
+func (w *onceIRQ) subscribe(s *edgeSub) machine.PinChange {
 	w.mu.Lock()
-	w.subs[s.pin] = s
+	w.subs[s.pin] = append(w.subs[s.pin], s)
 	if !w.running {
 		w.quit = make(chan struct{})
-		w.done = make(chan struct{}) // NEW: fresh done chan per start
+		w.done = make(chan struct{}) // fresh done chan per start
 		go w.loop()
 		w.running = true
 	}
+	flags := w.flagsLocked(s.pin)
+	w.mu.Unlock()
+	return flags
+}
+
+// flagsLocked is the union of the edges wanted on pin. Caller holds w.mu.
+func (w *onceIRQ) flagsLocked(pin int) machine.PinChange {
+	var sel core.GPIOEdge
+	for _, s := range w.subs[pin] {
+		sel |= s.edges
+	}
+	return mapEdges(sel)
+}
+
+// remove closes one subscription.
+func (w *onceIRQ) remove(s *edgeSub) {
+	w.mu.Lock()
+	w.dropLocked(s.pin, func(t *edgeSub) bool { return t == s })
+	w.mu.Unlock()
+}
+
+// unsubscribeDev closes the subscriptions devID holds on pin.
+func (w *onceIRQ) unsubscribeDev(pin int, devID string) {
+	w.mu.Lock()
+	w.dropLocked(pin, func(t *edgeSub) bool { return t.devID == devID })
 	w.mu.Unlock()
 }
 
+// unsubscribe closes every subscription on pin (the pin is being released).
 func (w *onceIRQ) unsubscribe(pin int) {
 	w.mu.Lock()
-	if s := w.subs[pin]; s != nil {
-		s.closed = true
-		if s.ch != nil {
-			close(s.ch)
-			s.ch = nil
+	w.dropLocked(pin, func(*edgeSub) bool { return true })
+	w.mu.Unlock()
+}
+
+// dropLocked closes and removes the matching subscriptions on pin, re-arms
+// the ISR for those that remain and stops the worker when none are left.
+// Caller holds w.mu.
+func (w *onceIRQ) dropLocked(pin int, match func(*edgeSub) bool) {
+	list := w.subs[pin]
+	keep := list[:0]
+	for _, s := range list {
+		if match(s) {
+			s.closeLocked()
+			continue
 		}
+		keep = append(keep, s)
+	}
+	for i := len(keep); i < len(list); i++ {
+		list[i] = nil
+	}
+	if len(keep) == 0 {
 		delete(w.subs, pin)
+		disableISRForPin(pin)
+	} else {
+		w.subs[pin] = keep
+		installISRForPin(w, pin, w.flagsLocked(pin))
 	}
-	disableISRForPin(pin)
 	if w.running && len(w.subs) == 0 {
 		close(w.quit)
 		w.running = false
 	}
-	w.mu.Unlock()
 }
 
 func (w *onceIRQ) stop() {
@@ -875,28 +1589,23 @@ func (w *onceIRQ) stop() {
 	w.mu.Unlock()
 
 	if done != nil {
-		<-done // wait for worker exit (NEW)
+		<-done // wait for worker exit
 	}
 	// With worker stopped, it is now safe to tear down remaining state.
 	w.mu.Lock()
-	for pin := range w.subs {
+	for pin, list := range w.subs {
 		disableISRForPin(pin)
-	}
-	for _, s := range w.subs {
-		if !s.closed {
-			s.closed = true
-		}
-		if s.ch != nil {
-			close(s.ch)
-			s.ch = nil
+		for _, s := range list {
+			s.closeLocked()
 		}
 	}
-	w.subs = make(map[int]*edgeSub)
+	w.subs = make(map[int][]*edgeSub)
 	w.running = false
 	w.mu.Unlock()
 }
 
-// Worker: drain wake-ups, snapshot pending pins, qualify, deliver best-effort.
+// Worker: drain wake-ups, snapshot pending pins, read each level once and
+// fan it out to every subscription on the pin.
 func (w *onceIRQ) loop() {
 	defer func() {
 		// Signal that the worker has exited.
@@ -918,82 +1627,17 @@ func (w *onceIRQ) loop() {
 				if (bits>>uint(pin))&1 == 0 {
 					continue
 				}
-				// Snapshot subscription state under lock.
-				w.mu.Lock()
-				s := w.subs[pin]
-				if s == nil {
-					w.mu.Unlock()
-					continue
-				}
-				if s.closed {
-					// One-time channel close on observing closed (worker owns closing).
-					if s.ch != nil {
-						close(s.ch)
-						s.ch = nil
-					}
-					w.mu.Unlock()
-					continue
-				}
-				prev := s.lastLvl
-				lastTS := s.lastTS
-				debounce := s.debounce
-				edges := s.edges
-				ch := s.ch // send to this after unlock
-				w.mu.Unlock()
 				lvl := machine.Pin(pin).Get()
-
-				curr := b2u(lvl)
-
-				// Debounce
-				if debounce > 0 && (now-lastTS) < int64(debounce) {
-					w.mu.Lock()
-					if t := w.subs[pin]; t != nil {
-						t.lastLvl, t.lastTS = curr, now
-					}
-					w.mu.Unlock()
-					continue
-				}
-				// Edge qualification
-				rise := prev == 0 && curr == 1
-				fall := prev == 1 && curr == 0
-				if (rise && (edges&core.EdgeRising) == 0) || (fall && (edges&core.EdgeFalling) == 0) {
-					w.mu.Lock()
-					if t := w.subs[pin]; t != nil {
-						t.lastLvl, t.lastTS = curr, now
-					}
-					w.mu.Unlock()
-					continue
-				}
 				ev := core.GPIOEdgeEvent{Pin: pin, Level: lvl, TS: now}
-				// Commit new state and deliver best-effort.
+				// Sends are non-blocking, so delivery happens under the lock;
+				// that also keeps them ordered against close.
 				w.mu.Lock()
-				if t := w.subs[pin]; t != nil {
-					t.lastLvl, t.lastTS = curr, now
-				}
-				// re-check closed before sending
-				if t := w.subs[pin]; t != nil && !t.closed && ch != nil {
-					w.mu.Unlock()
-					select {
-					case ch <- ev:
-					default:
-						// drop oldest then retry
-						select {
-						case <-ch:
-						default:
-						}
-						select {
-						case ch <- ev:
-						default:
-						}
-					}
-				} else {
-					// closed or gone
-					if t != nil && t.closed && t.ch != nil {
-						close(t.ch)
-						t.ch = nil
+				for _, s := range w.subs[pin] {
+					if s.qualify(b2u(lvl), now) {
+						deliverEdge(s.ch, ev)
 					}
-					w.mu.Unlock()
 				}
+				w.mu.Unlock()
 			}
 		case <-w.quit:
 			return
@@ -1001,6 +1645,45 @@ func (w *onceIRQ) loop() {
 	}
 }
 
+// qualify applies s's debounce and edge filters to a new level and commits
+// it as the last seen state. Caller holds w.mu.
+func (s *edgeSub) qualify(curr uint32, now int64) bool {
+	prev, lastTS := s.lastLvl, s.lastTS
+	s.lastLvl, s.lastTS = curr, now
+	if s.closed {
+		return false
+	}
+	if s.debounce > 0 && (now-lastTS) < int64(s.debounce) {
+		return false
+	}
+	switch {
+	case curr == prev:
+		// Pulse shorter than worker latency: direction unknown, pass it on.
+		return true
+	case curr == 1:
+		return s.edges&core.EdgeRising != 0
+	default:
+		return s.edges&core.EdgeFalling != 0
+	}
+}
+
+// deliverEdge sends best-effort, dropping the oldest queued event when full.
+func deliverEdge(ch chan core.GPIOEdgeEvent, ev core.GPIOEdgeEvent) {
+	select {
+	case ch <- ev:
+		return
+	default:
+	}
+	select {
+	case <-ch:
+	default:
+	}
+	select {
+	case ch <- ev:
+	default:
+	}
+}
+
 // Helpers
 func b2u(b bool) uint32 {
 	if b {
@@ -110,4 +110,15 @@ var SelectedSetup = types.HALConfig{
 		{Domain: "power", Kind: "battery", Name: "internal", Verb: "read", IntervalMs: 1_000, JitterMs: 100},
 		{Domain: "env", Kind: "temperature", Name: "die", Verb: "read", IntervalMs: 1_000, JitterMs: 100},
 	},
+
+	// Roll-up of the reactor's inputs on group/power-telemetry/state; the
+	// reactor holds bring-up while it is degraded. The die sensor stands in
+	// for the board one, which the reactor can do without.
+	Groups: []types.GroupSpec{
+		{Name: "power-telemetry", StaleMs: 4_000, Members: []types.CapabilityAddress{
+			{Domain: "power", Kind: types.KindCharger, Name: "internal"},
+			{Domain: "power", Kind: types.KindBattery, Name: "internal"},
+			{Domain: "env", Kind: types.KindTemperature, Name: "die"},
+		}},
+	},
 }
@@ -3,6 +3,8 @@
 package setups
 
 import (
+	"devicecode-go/services/hal/devices/composite"
+	"devicecode-go/services/hal/devices/gpio_counter"
 	"devicecode-go/services/hal/devices/gpio_dout"
 	ltc4015dev "devicecode-go/services/hal/devices/ltc4015"
 	"devicecode-go/services/hal/devices/rp2_temp"
@@ -21,6 +23,10 @@ var SelectedPlan = ResourcePlan{
 		{ID: "uart0", TX: 0, RX: 1, Baud: 115_200},
 		{ID: "uart1", TX: 4, RX: 5, Baud: 115_200},
 	},
+	Aliases: map[string]int{
+		"SMBALERT": 20,
+		"FAN_EN":   10,
+	},
 }
 
 var SelectedSetup = types.HALConfig{
@@ -57,12 +63,13 @@ var SelectedSetup = types.HALConfig{
 		}},
 
 		{ID: "charger0", Type: "ltc4015", Params: ltc4015dev.Params{
-			Bus: "i2c1", Addr: 0x68, SMBAlertPin: 20,
+			Bus: "i2c1", Addr: 0x68, SMBAlertPinName: "SMBALERT",
 			RSNSB_uOhm: 3330, RSNSI_uOhm: 1670, Cells: 6,
 			Chem:       "leadacid",
 			NTCBiasOhm: 10000, R25Ohm: 10000, BetaK: 3435,
 			QCountPrescale: 0,
 			DomainBattery:  "power", DomainCharger: "power", Name: "internal",
+			VinCollapse_mVps: 2000, // pre-warn ahead of the 9 V VIN window
 
 			Boot: []types.BootAction{
 				// {Verb: "disable"},
@@ -76,6 +83,13 @@ var SelectedSetup = types.HALConfig{
 			},
 		}},
 
+		// Diagnostics: count SMBALERT# assertions alongside the charger's own
+		// subscription (hal/cap/power/counter/smbalert/value).
+		{ID: "smbalert_count", Type: "gpio_counter", Params: gpio_counter.Params{
+			PinName: "SMBALERT", Edges: "falling",
+			Domain: "power", Name: "smbalert",
+		}},
+
 		// Gates / enables -> switches (power domain)
 		{ID: "mpcie-usb", Type: "gpio_switch", Params: gpio_dout.Params{
 			Pin: 6, ActiveLow: false, Initial: false,
@@ -94,13 +108,24 @@ var SelectedSetup = types.HALConfig{
 			Domain: "power", Name: "cm5",
 		}},
 		{ID: "fan", Type: "gpio_switch", Params: gpio_dout.Params{
-			Pin: 10, ActiveLow: false, Initial: false,
+			PinName: "FAN_EN", ActiveLow: false, Initial: false,
 			Domain: "power", Name: "fan",
 		}},
 		{ID: "boost-load", Type: "gpio_switch", Params: gpio_dout.Params{
 			Pin: 14, ActiveLow: false, Initial: false,
 			Domain: "power", Name: "boost-load",
 		}},
+
+		// Fan on above 45.0 °C on the board sensor, off again at 42.0 °C
+		// (hal/cap/control/thermostat/fan/…); fails safe off after 10 s
+		// without a reading.
+		{ID: "fan_thermostat", Type: "composite", Params: composite.Params{
+			Policy: "thermostat", Name: "fan",
+			Source:   types.CapabilityAddress{Domain: "env", Kind: types.KindTemperature, Name: "core"},
+			Field:    "deci_c",
+			Output:   types.CapabilityAddress{Domain: "power", Kind: types.KindSwitch, Name: "fan"},
+			Setpoint: 450, Hysteresis: 30, Cool: true, StaleMs: 10_000,
+		}},
 	},
 
 	// Declarative polling schedules applied by HAL after devices are registered.
@@ -111,4 +136,22 @@ var SelectedSetup = types.HALConfig{
 		{Domain: "power", Kind: "battery", Name: "internal", Verb: "read", IntervalMs: 1_000, JitterMs: 100},
 		{Domain: "env", Kind: "temperature", Name: "die", Verb: "read", IntervalMs: 1_000, JitterMs: 100},
 	},
+
+	// Declarative alarms evaluated by HAL over retained values.
+	Alarms: []types.AlarmSpec{
+		// Board over-temperature: raise above 60.0 °C for 3 s, clear below 55.0 °C.
+		{Name: "core-overtemp", Source: types.CapabilityAddress{Domain: "env", Kind: "temperature", Name: "core"},
+			Field: "deci_c", Op: types.AlarmGT, Threshold: 600, Hysteresis: 50, DebounceMs: 3_000},
+	},
+
+	// Roll-up of the reactor's inputs on group/power-telemetry/state; the
+	// reactor holds bring-up while it is degraded. The die sensor stands in
+	// for the board one, which the reactor can do without.
+	Groups: []types.GroupSpec{
+		{Name: "power-telemetry", StaleMs: 4_000, Members: []types.CapabilityAddress{
+			{Domain: "power", Kind: types.KindCharger, Name: "internal"},
+			{Domain: "power", Kind: types.KindBattery, Name: "internal"},
+			{Domain: "env", Kind: types.KindTemperature, Name: "die"},
+		}},
+	},
 }
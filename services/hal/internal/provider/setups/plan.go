@@ -1,27 +1,20 @@
 package setups
 
-// ResourcePlan specifies wiring and operating parameters chosen by a setup.
-// Providers consume this plan to instantiate resource owners.
-type ResourcePlan struct {
-	I2C  []I2CPlan
-	UART []UARTPlan
-	// SPI, CAN, etc. can be added later in the same manner.
-}
+import "devicecode-go/types"
 
-type I2CPlan struct {
-	ID  string // e.g. "i2c0"
-	SDA int    // GPIO number
-	SCL int    // GPIO number
-	Hz  uint32 // bus frequency
-}
+// ResourcePlan specifies wiring and operating parameters chosen by a setup.
+// Providers consume this plan to instantiate resource owners. It shares its
+// schema with the Buses section of config/hal, so a setup-less image can be
+// given the same plan at run time.
+type ResourcePlan = types.BusPlan
 
-type UARTPlan struct {
-	ID   string // e.g. "uart0"
-	TX   int    // GPIO number
-	RX   int    // GPIO number
-	Baud uint32 // initial baud (format can be added later)
-}
+type (
+	I2CPlan     = types.I2CBus
+	UARTPlan    = types.UARTBus
+	OneWirePlan = types.OneWireBus
+)
 
+func PtrInt(v int) *int       { return &v }
 func PtrI32(v int32) *int32   { return &v }
 func PtrU32(v uint32) *uint32 { return &v }
 func PtrU16(v uint16) *uint16 { return &v }
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"devicecode-go/bus"
+)
+
+// TopicSnapshot is the retained topic carrying the latest snapshot.
+var TopicSnapshot = bus.T("sys", "metrics")
+
+// Run publishes a snapshot of Default on sys/metrics (retained) every
+// interval until ctx is cancelled. Consumers that forward telemetry (e.g. the
+// JSON UART) subscribe to TopicSnapshot.
+func Run(ctx context.Context, conn *bus.Connection, every time.Duration) {
+	if every <= 0 {
+		every = 10 * time.Second
+	}
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			conn.Publish(conn.NewMessage(TopicSnapshot, Default.Snapshot(), true))
+		}
+	}
+}
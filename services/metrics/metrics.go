@@ -0,0 +1,191 @@
+// Package metrics is a small on-device metrics registry.
+//
+// Metrics are registered once (at init or construction) and then updated
+// from any goroutine without allocation: counters and gauges are single
+// atomics, histograms are fixed bucket arrays. A periodic exporter (see Run)
+// publishes a compact snapshot on sys/metrics.
+package metrics
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"devicecode-go/types"
+)
+
+// ---- Instruments ----
+
+// Counter is a monotonically increasing count (wraps at 2^32).
+type Counter struct{ v atomic.Uint32 }
+
+func (c *Counter) Inc()          { c.v.Add(1) }
+func (c *Counter) Add(n uint32)  { c.v.Add(n) }
+func (c *Counter) Value() uint32 { return c.v.Load() }
+
+// Gauge holds the latest observed value.
+type Gauge struct{ v atomic.Int32 }
+
+func (g *Gauge) Set(v int32)  { g.v.Store(v) }
+func (g *Gauge) Add(d int32)  { g.v.Add(d) }
+func (g *Gauge) Value() int32 { return g.v.Load() }
+
+// Histogram counts observations into fixed buckets. Bucket i counts values
+// <= bounds[i]; the final bucket counts everything above the last bound.
+type Histogram struct {
+	bounds  []int64
+	buckets []atomic.Uint32
+}
+
+func (h *Histogram) Observe(v int64) {
+	i := 0
+	for i < len(h.bounds) && v > h.bounds[i] {
+		i++
+	}
+	h.buckets[i].Add(1)
+}
+
+// ---- Registry ----
+
+type kind uint8
+
+const (
+	kCounter kind = iota
+	kGauge
+	kHistogram
+	kFunc
+)
+
+type entry struct {
+	name string
+	kind kind
+	c    *Counter
+	g    *Gauge
+	h    *Histogram
+	fn   func() int64
+}
+
+// Registry owns a set of named instruments. Registration takes a lock and
+// may allocate; updates through the returned instruments do neither.
+type Registry struct {
+	mu      sync.Mutex
+	entries []entry
+}
+
+// Default is the process-wide registry used by subsystems.
+var Default = &Registry{}
+
+func (r *Registry) find(name string) *entry {
+	for i := range r.entries {
+		if r.entries[i].name == name {
+			return &r.entries[i]
+		}
+	}
+	return nil
+}
+
+// Counter returns the counter registered under name, creating it if needed.
+// It panics if name is already registered as a different kind.
+func (r *Registry) Counter(name string) *Counter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e := r.find(name); e != nil {
+		if e.kind != kCounter {
+			panic("metrics: " + name + " is not a counter")
+		}
+		return e.c
+	}
+	c := &Counter{}
+	r.entries = append(r.entries, entry{name: name, kind: kCounter, c: c})
+	return c
+}
+
+// Gauge returns the gauge registered under name, creating it if needed.
+func (r *Registry) Gauge(name string) *Gauge {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e := r.find(name); e != nil {
+		if e.kind != kGauge {
+			panic("metrics: " + name + " is not a gauge")
+		}
+		return e.g
+	}
+	g := &Gauge{}
+	r.entries = append(r.entries, entry{name: name, kind: kGauge, g: g})
+	return g
+}
+
+// Histogram returns the histogram registered under name, creating it with
+// the given ascending bounds if needed (bounds are ignored on re-lookup).
+func (r *Registry) Histogram(name string, bounds ...int64) *Histogram {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e := r.find(name); e != nil {
+		if e.kind != kHistogram {
+			panic("metrics: " + name + " is not a histogram")
+		}
+		return e.h
+	}
+	b := append([]int64(nil), bounds...)
+	h := &Histogram{bounds: b, buckets: make([]atomic.Uint32, len(b)+1)}
+	r.entries = append(r.entries, entry{name: name, kind: kHistogram, h: h})
+	return h
+}
+
+// Func registers a gauge sampled by calling fn at snapshot time. Use it for
+// state that already lives elsewhere (e.g. bus drop counts). Re-registering
+// a name replaces fn.
+func (r *Registry) Func(name string, fn func() int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e := r.find(name); e != nil {
+		if e.kind != kFunc {
+			panic("metrics: " + name + " is not a func gauge")
+		}
+		e.fn = fn
+		return
+	}
+	r.entries = append(r.entries, entry{name: name, kind: kFunc, fn: fn})
+}
+
+// Snapshot captures all instruments in registration order.
+func (r *Registry) Snapshot() types.MetricsSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := types.MetricsSnapshot{
+		TS:      time.Now().UnixNano(),
+		Metrics: make([]types.MetricSample, 0, len(r.entries)),
+	}
+	for i := range r.entries {
+		e := &r.entries[i]
+		s := types.MetricSample{Name: e.name}
+		switch e.kind {
+		case kCounter:
+			s.Value = int64(e.c.Value())
+		case kGauge:
+			s.Value = int64(e.g.Value())
+		case kFunc:
+			if e.fn != nil {
+				s.Value = e.fn()
+			}
+		case kHistogram:
+			s.Bounds = e.h.bounds
+			s.Buckets = make([]uint32, len(e.h.buckets))
+			for j := range e.h.buckets {
+				n := e.h.buckets[j].Load()
+				s.Buckets[j] = n
+				s.Value += int64(n)
+			}
+		}
+		out.Metrics = append(out.Metrics, s)
+	}
+	return out
+}
+
+// Convenience wrappers over Default.
+
+func NewCounter(name string) *Counter { return Default.Counter(name) }
+func NewGauge(name string) *Gauge     { return Default.Gauge(name) }
+func NewHistogram(name string, bounds ...int64) *Histogram {
+	return Default.Histogram(name, bounds...)
+}
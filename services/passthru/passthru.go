@@ -0,0 +1,352 @@
+// Package passthru joins two serial capabilities byte for byte, so an
+// engineer on one (e.g. the Pico's USB CDC port) can reach what is wired
+// to the other (e.g. the CM5 console UART) without extra cabling.
+//
+// passthru/control/start (types.PassthruStart) opens a session on each
+// capability and copies A's RX ring to B's TX ring and B's RX ring to A's
+// TX ring. The link ends on passthru/control/stop, on the escape sequence
+// typed on A, after IdleTimeoutMs without traffic, or when HAL closes or
+// expires either session; both sessions are then closed. passthru/state
+// (types.PassthruState, retained) carries the byte counts, refreshed every
+// second while bytes move.
+package passthru
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+	"devicecode-go/x/shmring"
+)
+
+var (
+	TopicStart = bus.T("passthru", "control", "start")
+	TopicStop  = bus.T("passthru", "control", "stop")
+	TopicState = bus.T("passthru", "state")
+)
+
+// DefaultEscape ends a link when typed on A at the start of a line, as in
+// ssh. Its bytes are held back while they match, so they never reach B.
+const DefaultEscape = "\r~."
+
+const (
+	openTimeout = time.Second // per session_open, reply and event
+	refresh     = time.Second
+	chunk       = 64
+)
+
+// Config selects the serial capabilities' domain. Empty fields take the
+// defaults noted.
+type Config struct {
+	Domain string      // default "io"
+	Clock  clock.Clock // nil: clock.Real
+}
+
+func (c *Config) defaults() {
+	if c.Domain == "" {
+		c.Domain = "io"
+	}
+	c.Clock = clock.Or(c.Clock)
+}
+
+// Run serves the passthrough controls until ctx is cancelled. One link
+// runs at a time; a second start is refused with errcode.Conflict.
+func Run(ctx context.Context, conn *bus.Connection, cfg Config) {
+	cfg.defaults()
+	s := &service{conn: conn, cfg: cfg}
+
+	start := bus.SubscribeT[types.PassthruStart](conn, TopicStart)
+	defer start.Unsubscribe()
+	stop := conn.Subscribe(TopicStop)
+	defer conn.Unsubscribe(stop)
+	events := conn.Subscribe(bus.T("hal", "cap", cfg.Domain, string(types.KindSerial), "+", "event", "+"))
+	defer conn.Unsubscribe(events)
+
+	s.publish()
+	t := cfg.Clock.NewTimer(refresh)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.end("stop")
+			return
+
+		case m := <-start.Channel():
+			p, ok := start.Value(m)
+			switch {
+			case !ok || p.A == "" || p.B == "" || p.A == p.B:
+				reply(conn, m, errcode.InvalidParams)
+			case s.l != nil:
+				reply(conn, m, errcode.Conflict)
+			default:
+				reply(conn, m, s.begin(ctx, p))
+			}
+
+		case m := <-stop.Channel():
+			s.end("stop")
+			reply(conn, m, nil)
+
+		case m := <-events.Channel():
+			// hal/cap/<domain>/serial/<name>/event/<tag>
+			name, _ := m.Topic.At(4).(string)
+			tag, _ := m.Topic.At(6).(string)
+			if s.l != nil && (name == s.l.a.name || name == s.l.b.name) &&
+				(tag == "session_closed" || tag == "session_expired") {
+				s.end(tag)
+			}
+
+		case why := <-s.done():
+			s.end(why)
+
+		case <-t.C():
+			s.tick()
+			t.Reset(refresh)
+		}
+	}
+}
+
+type service struct {
+	conn  *bus.Connection
+	cfg   Config
+	l     *link
+	ended string
+	last  types.PassthruState
+}
+
+type session struct {
+	name   string
+	rx, tx *shmring.Ring
+}
+
+type link struct {
+	a, b       session
+	idle       time.Duration
+	cancel     context.CancelFunc
+	why        chan string // from the pumps: "escape"
+	aToB, bToA atomic.Uint32
+	moved      uint32 // aToB+bToA at lastAt
+	lastAt     time.Time
+}
+
+func (s *service) done() <-chan string {
+	if s.l == nil {
+		return nil
+	}
+	return s.l.why
+}
+
+// begin opens both sessions and starts the pumps.
+func (s *service) begin(ctx context.Context, p types.PassthruStart) error {
+	a, err := s.open(ctx, p.A)
+	if err != nil {
+		return err
+	}
+	b, err := s.open(ctx, p.B)
+	if err != nil {
+		s.close(p.A)
+		return err
+	}
+	var esc []byte
+	switch p.Escape {
+	case "":
+		esc = []byte(DefaultEscape)
+	case "none":
+	default:
+		esc = []byte(p.Escape)
+	}
+	lctx, cancel := context.WithCancel(ctx)
+	l := &link{
+		a: a, b: b, cancel: cancel, why: make(chan string, 1),
+		idle:   time.Duration(p.IdleTimeoutMs) * time.Millisecond,
+		lastAt: s.cfg.Clock.Now(),
+	}
+	go pump(lctx, a.rx, b.tx, &l.aToB, newEscaper(esc), l.why)
+	go pump(lctx, b.rx, a.tx, &l.bToA, nil, l.why)
+	s.l, s.ended = l, ""
+	s.publish()
+	return nil
+}
+
+// end stops the link, if any, and closes its sessions.
+func (s *service) end(why string) {
+	l := s.l
+	if l == nil {
+		return
+	}
+	l.cancel()
+	s.close(l.a.name)
+	s.close(l.b.name)
+	s.ended = why
+	s.publish()
+	s.l = nil
+}
+
+func (s *service) tick() {
+	l := s.l
+	if l == nil {
+		return
+	}
+	now := s.cfg.Clock.Now()
+	if n := l.aToB.Load() + l.bToA.Load(); n != l.moved {
+		l.moved, l.lastAt = n, now
+		s.publish()
+		return
+	}
+	if l.idle > 0 && now.Sub(l.lastAt) >= l.idle {
+		s.end("idle")
+	}
+}
+
+func (s *service) publish() {
+	st := s.last // an ended link keeps its names and counts
+	st.Active, st.Ended = false, s.ended
+	if l := s.l; l != nil {
+		st.A, st.B = l.a.name, l.b.name
+		st.AToB, st.BToA = l.aToB.Load(), l.bToA.Load()
+		st.Active = s.ended == ""
+	}
+	st.TS = s.cfg.Clock.Now().UnixNano()
+	s.last = st
+	s.conn.Publish(s.conn.NewMessage(TopicState, st, true))
+}
+
+func (s *service) base(name string) bus.Topic {
+	return bus.T("hal", "cap", s.cfg.Domain, string(types.KindSerial), name)
+}
+
+// open asks HAL for a session on the named capability and waits for its
+// rings.
+func (s *service) open(ctx context.Context, name string) (session, error) {
+	base := s.base(name)
+	opened := bus.SubscribeT[types.SerialSessionOpened](s.conn, base.Append("event", "session_opened"))
+	defer opened.Unsubscribe()
+
+	ctx, cancel := context.WithTimeout(ctx, openTimeout)
+	defer cancel()
+	rep, err := s.conn.RequestWait(ctx, s.conn.NewMessage(base.Append("control", "session_open"), types.SerialSessionOpen{}, false))
+	if err != nil {
+		return session{}, errcode.Timeout
+	}
+	if e, ok := rep.Payload.(types.ErrorReply); ok {
+		return session{}, errcode.Code(e.Error)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			s.close(name)
+			return session{}, errcode.Timeout
+		case m := <-opened.Channel():
+			ev, ok := opened.Value(m)
+			if !ok {
+				continue
+			}
+			rx, tx := shmring.Get(shmring.Handle(ev.RXHandle)), shmring.Get(shmring.Handle(ev.TXHandle))
+			if rx == nil || tx == nil {
+				s.close(name)
+				return session{}, errcode.Unavailable
+			}
+			return session{name: name, rx: rx, tx: tx}, nil
+		}
+	}
+}
+
+func (s *service) close(name string) {
+	s.conn.Publish(s.conn.NewMessage(s.base(name).Append("control", "session_close"), nil, false))
+}
+
+func reply(conn *bus.Connection, m *bus.Message, err error) {
+	if !m.CanReply() {
+		return
+	}
+	if err == nil {
+		conn.Reply(m, types.OKReply{OK: true}, false)
+		return
+	}
+	verb, _ := m.Topic.At(m.Topic.Len() - 1).(string) // …/control/<verb>
+	conn.Reply(m, types.ErrorReplyOf(err, verb), false)
+}
+
+// ---- pumps ----
+
+// pump copies src to dst until ctx ends, counting the bytes delivered.
+// With esc it reports "escape" on why when the sequence completes.
+func pump(ctx context.Context, src, dst *shmring.Ring, n *atomic.Uint32, esc *escaper, why chan<- string) {
+	buf := make([]byte, chunk)
+	for {
+		k := src.TryReadInto(buf)
+		if k == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-src.Readable():
+			}
+			continue
+		}
+		out, hit := buf[:k], false
+		if esc != nil {
+			out, hit = esc.scan(out)
+		}
+		for len(out) > 0 {
+			w := dst.TryWriteFrom(out)
+			out = out[w:]
+			n.Add(uint32(w))
+			if len(out) == 0 {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-dst.Writable():
+			}
+		}
+		if hit {
+			select {
+			case why <- "escape":
+			default:
+			}
+			return
+		}
+	}
+}
+
+// escaper holds back bytes while they match seq; on a mismatch they are
+// released in order.
+type escaper struct {
+	seq []byte
+	i   int // bytes of seq matched
+	out []byte
+}
+
+func newEscaper(seq []byte) *escaper {
+	if len(seq) == 0 {
+		return nil
+	}
+	return &escaper{seq: seq}
+}
+
+// scan returns the bytes of in to forward and whether seq completed; bytes
+// after a completed seq are dropped.
+func (e *escaper) scan(in []byte) ([]byte, bool) {
+	e.out = e.out[:0]
+	for _, c := range in {
+		if c == e.seq[e.i] {
+			if e.i++; e.i == len(e.seq) {
+				e.i = 0
+				return e.out, true
+			}
+			continue
+		}
+		e.out = append(e.out, e.seq[:e.i]...)
+		e.i = 0
+		if c == e.seq[0] {
+			e.i = 1
+		} else {
+			e.out = append(e.out, c)
+		}
+	}
+	return e.out, false
+}
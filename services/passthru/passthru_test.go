@@ -0,0 +1,128 @@
+package passthru
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+	"devicecode-go/x/shmring"
+)
+
+// fakeSerial answers session_open on hal/cap/io/serial/<name> as serial_raw
+// does and hands the test the far side of each session's rings: what it
+// writes to rx arrives as if from the wire, and tx is what the link sent.
+func fakeSerial(t *testing.T, ctx context.Context, b *bus.Bus) <-chan session {
+	t.Helper()
+	conn := b.NewConnection("hal")
+	ctl := conn.Subscribe(bus.T("hal", "cap", "io", "serial", "+", "control", "+"))
+	out := make(chan session, 4)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case m := <-ctl.Channel():
+				name, _ := m.Topic.At(4).(string)
+				switch m.Topic.At(6) {
+				case "session_open":
+					rh, rx := shmring.NewRegistered(64)
+					th, tx := shmring.NewRegistered(64)
+					conn.Reply(m, types.OKReply{OK: true}, false)
+					conn.Publish(conn.NewMessage(bus.T("hal", "cap", "io", "serial", name, "event", "session_opened"),
+						types.SerialSessionOpened{RXHandle: uint32(rh), TXHandle: uint32(th)}, false))
+					out <- session{name: name, rx: rx, tx: tx}
+				case "session_close":
+					conn.Publish(conn.NewMessage(bus.T("hal", "cap", "io", "serial", name, "event", "session_closed"), nil, false))
+				}
+			}
+		}
+	}()
+	return out
+}
+
+func readN(t *testing.T, r *shmring.Ring, n int) string {
+	t.Helper()
+	var got []byte
+	deadline := time.After(2 * time.Second)
+	for len(got) < n {
+		buf := make([]byte, 64)
+		if k := r.TryReadInto(buf); k > 0 {
+			got = append(got, buf[:k]...)
+			continue
+		}
+		select {
+		case <-r.Readable():
+		case <-deadline:
+			t.Fatalf("read %q, want %d bytes", got, n)
+		}
+	}
+	return string(got)
+}
+
+func TestPassthru_CopiesBothWaysUntilEscape(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	b := bus.NewBus(8, "+", "#")
+	sessions := fakeSerial(t, ctx, b)
+	go Run(ctx, b.NewConnection("passthru"), Config{})
+
+	user := b.NewConnection("user")
+	state := bus.SubscribeT[types.PassthruState](user, TopicState)
+	<-state.Channel() // idle state: Run is listening
+	rep, err := user.RequestWait(ctx, user.NewMessage(TopicStart, types.PassthruStart{A: "usb0", B: "uart1"}, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, ok := rep.Payload.(types.OKReply); !ok || !r.OK {
+		t.Fatalf("start reply %#v", rep.Payload)
+	}
+	a, c := <-sessions, <-sessions
+
+	// A second start while linked is refused.
+	rep, _ = user.RequestWait(ctx, user.NewMessage(TopicStart, types.PassthruStart{A: "usb0", B: "uart0"}, false))
+	if e, ok := rep.Payload.(types.ErrorReply); !ok || errcode.Code(e.Error) != errcode.Conflict || e.Verb != "start" {
+		t.Fatalf("second start reply %#v", rep.Payload)
+	}
+
+	c.rx.TryWriteFrom([]byte("login: "))
+	if got := readN(t, a.tx, 7); got != "login: " {
+		t.Fatalf("b->a %q", got)
+	}
+	// A near miss of the escape is passed on whole; the escape itself is not.
+	a.rx.TryWriteFrom([]byte("root\r~x\r~."))
+	if got := readN(t, c.tx, 7); got != "root\r~x" {
+		t.Fatalf("a->b %q", got)
+	}
+
+	for {
+		select {
+		case m := <-state.Channel():
+			st, _ := state.Value(m)
+			if st.Active || st.Ended == "" {
+				continue
+			}
+			if st.Ended != "escape" || st.A != "usb0" || st.B != "uart1" || st.AToB != 7 || st.BToA != 7 {
+				t.Fatalf("state %+v", st)
+			}
+			return
+		case <-ctx.Done():
+			t.Fatal("link did not end")
+		}
+	}
+}
+
+func TestEscaper_HoldsBackAcrossChunks(t *testing.T) {
+	e := newEscaper([]byte(DefaultEscape))
+	if out, hit := e.scan([]byte("ls\r")); string(out) != "ls" || hit {
+		t.Fatalf("%q %v", out, hit)
+	}
+	if out, hit := e.scan([]byte("\r~")); string(out) != "\r" || hit {
+		t.Fatalf("%q %v", out, hit)
+	}
+	if out, hit := e.scan([]byte(".after")); len(out) != 0 || !hit {
+		t.Fatalf("%q %v", out, hit)
+	}
+}
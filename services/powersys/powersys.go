@@ -0,0 +1,124 @@
+// Package powersys derives system power figures from the charger and
+// battery capabilities and publishes them as a capability of their own,
+// hal/cap/<domain>/system/<name>/{info,status,value}, so consumers do not
+// each recompute ISYS and power from two topics.
+package powersys
+
+import (
+	"context"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/types"
+)
+
+// Config names the source capabilities and the derived one. Empty fields
+// take the defaults noted.
+type Config struct {
+	Domain  string        // default "power"
+	Charger string        // charger capability name; default "internal"
+	Battery string        // battery capability name; default "internal"
+	Name    string        // derived capability name; default Charger
+	MaxAge  time.Duration // pair only samples this close; default 4s
+}
+
+// Run publishes a derived value whenever either source updates and the
+// other is fresh, until ctx is cancelled. Status is up while both sources
+// are fresh and down otherwise.
+func Run(ctx context.Context, conn *bus.Connection, cfg Config) {
+	if cfg.Domain == "" {
+		cfg.Domain = "power"
+	}
+	if cfg.Charger == "" {
+		cfg.Charger = "internal"
+	}
+	if cfg.Battery == "" {
+		cfg.Battery = "internal"
+	}
+	if cfg.Name == "" {
+		cfg.Name = cfg.Charger
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = 4 * time.Second
+	}
+
+	base := bus.T("hal", "cap", cfg.Domain, string(types.KindSystem), cfg.Name)
+	conn.Publish(conn.NewMessage(base.Append("info"), types.Info{
+		SchemaVersion: 1, Driver: "powersys",
+		Detail: types.SystemPowerInfo{Charger: cfg.Charger, Battery: cfg.Battery},
+	}, true))
+	status := base.Append("status")
+	value := conn.NewValue(base.Append("value"), true)
+
+	chg := bus.SubscribeT[types.ChargerValue](conn,
+		bus.T("hal", "cap", cfg.Domain, string(types.KindCharger), cfg.Charger, "value"))
+	bat := bus.SubscribeT[types.BatteryValue](conn,
+		bus.T("hal", "cap", cfg.Domain, string(types.KindBattery), cfg.Battery, "value"))
+	defer chg.Unsubscribe()
+	defer bat.Unsubscribe()
+
+	var (
+		c        types.ChargerValue
+		b        types.BatteryValue
+		cAt, bAt time.Time
+		link     types.Link
+		seq      uint32
+	)
+	setLink := func(l types.Link) {
+		if l != link {
+			link = l
+			conn.Publish(conn.NewMessage(status,
+				types.CapabilityStatus{Link: l, TS: time.Now().UnixNano()}, true))
+		}
+	}
+	setLink(types.LinkDown)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m := <-chg.Channel():
+			v, ok := chg.Value(m)
+			if !ok {
+				continue
+			}
+			c, cAt = v, time.Now()
+		case m := <-bat.Channel():
+			v, ok := bat.Value(m)
+			if !ok {
+				continue
+			}
+			b, bAt = v, time.Now()
+		}
+		d := cAt.Sub(bAt)
+		if cAt.IsZero() || bAt.IsZero() || d > cfg.MaxAge || d < -cfg.MaxAge {
+			setLink(types.LinkDown)
+			continue
+		}
+		seq++
+		value.Publish(Compute(c, b), seq, 0)
+		setLink(types.LinkUp)
+	}
+}
+
+// Compute derives system power figures from one charger and one battery
+// sample. ISYS ≈ IIN − IBAT treats both currents as if at the same rail,
+// which is the approximation the firmware has always logged.
+func Compute(c types.ChargerValue, b types.BatteryValue) types.SystemPowerValue {
+	isys := c.IIn_mA - b.IBatMilliA
+	v := types.SystemPowerValue{
+		ISys_mA: isys,
+		PIn_mW:  mulMilli(c.VIN_mV, c.IIn_mA),
+		PBat_mW: mulMilli(b.PackMilliV, b.IBatMilliA),
+		PSys_mW: mulMilli(c.VSYS_mV, isys),
+	}
+	if v.PIn_mW > 0 {
+		if e := int64(v.PBat_mW+v.PSys_mW) * 100 / int64(v.PIn_mW); e > 0 && e <= 100 {
+			v.EffPct = uint8(e)
+		}
+	}
+	return v
+}
+
+// mulMilli returns mV·mA in mW.
+func mulMilli(mV, mA int32) int32 { return int32(int64(mV) * int64(mA) / 1000) }
@@ -0,0 +1,161 @@
+// Package recorder captures bus traffic to a stream of JSON lines and
+// replays it into a bus, so policy changes (reactor, thermal) can be
+// regression-tested against incidents captured in the field.
+//
+// One record per line:
+//
+//	{"t_ns":1200000,"topic":["hal","cap","env","temperature","core","value"],
+//	 "ret":true,"type":"TemperatureValue","p":{"deci_c":253}}
+//
+// t_ns is relative to the start of the recording. Payload types are named
+// and rebuilt through types.PayloadName / types.DecodePayload; unregistered
+// types replay as generic JSON values.
+package recorder
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/types"
+)
+
+// DefaultFilter is the traffic recorded when no filter is given.
+var DefaultFilter = bus.T("hal", "cap", "#")
+
+// Entry is one captured message.
+type Entry struct {
+	TS       int64           `json:"t_ns"`
+	Topic    []any           `json:"topic"`
+	Retained bool            `json:"ret,omitempty"`
+	Type     string          `json:"type,omitempty"`
+	Payload  json.RawMessage `json:"p,omitempty"`
+	Seq      uint32          `json:"seq,omitempty"`  // bus.Message.Seq
+	Mono     int64           `json:"mono,omitempty"` // bus.Message.Mono
+}
+
+// Record subscribes to filter (DefaultFilter if nil) and writes every message
+// to w until ctx is cancelled or a write fails. Retained state present at
+// start is captured first with t_ns=0.
+func Record(ctx context.Context, conn *bus.Connection, w io.Writer, filter bus.Topic) error {
+	if filter == nil {
+		filter = DefaultFilter
+	}
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+	enc := json.NewEncoder(bw)
+
+	t0 := time.Now()
+	for _, m := range conn.Retained(filter) {
+		if err := enc.Encode(toRecord(m, 0)); err != nil {
+			return err
+		}
+	}
+	sub := conn.Subscribe(filter)
+	defer conn.Unsubscribe(sub)
+
+	// Retained messages were written above; skip their re-delivery.
+	for drained := false; !drained; {
+		select {
+		case <-sub.Channel():
+		default:
+			drained = true
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case m := <-sub.Channel():
+			if m == nil {
+				return nil
+			}
+			if err := enc.Encode(toRecord(m, time.Since(t0).Nanoseconds())); err != nil {
+				return err
+			}
+			if len(sub.Channel()) == 0 {
+				_ = bw.Flush()
+			}
+		}
+	}
+}
+
+func toRecord(m *bus.Message, ts int64) Entry {
+	rec := Entry{TS: ts, Retained: m.Retained, Type: types.PayloadName(m.Payload), Seq: m.Seq, Mono: m.Mono}
+	for i := 0; i < m.Topic.Len(); i++ {
+		rec.Topic = append(rec.Topic, m.Topic.At(i))
+	}
+	if m.Payload != nil {
+		if b, err := json.Marshal(m.Payload); err == nil {
+			rec.Payload = b
+		}
+	}
+	return rec
+}
+
+// Replay reads records from r and publishes them on conn. speed scales the
+// recorded timing (1 = real time, 2 = twice as fast); speed <= 0 publishes
+// as fast as possible. It returns the number of records published.
+func Replay(ctx context.Context, conn *bus.Connection, r io.Reader, speed float64) (int, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	start := time.Now()
+	n := 0
+	for {
+		var rec Entry
+		if err := dec.Decode(&rec); err != nil {
+			if err == io.EOF {
+				return n, nil
+			}
+			return n, err
+		}
+		if speed > 0 {
+			due := start.Add(time.Duration(float64(rec.TS) / speed))
+			if d := time.Until(due); d > 0 {
+				select {
+				case <-time.After(d):
+				case <-ctx.Done():
+					return n, ctx.Err()
+				}
+			}
+		} else if ctx.Err() != nil {
+			return n, ctx.Err()
+		}
+		payload, err := decodePayload(rec)
+		if err != nil {
+			return n, err
+		}
+		m := conn.NewMessage(bus.T(topicTokens(rec.Topic)...), payload, rec.Retained)
+		m.Seq, m.Mono = rec.Seq, rec.Mono
+		conn.Publish(m)
+		n++
+	}
+}
+
+func decodePayload(rec Entry) (any, error) {
+	if len(rec.Payload) == 0 || string(rec.Payload) == "null" {
+		return nil, nil
+	}
+	if v, ok, err := types.DecodePayload(rec.Type, rec.Payload); ok {
+		return v, err
+	}
+	var v any
+	err := json.Unmarshal(rec.Payload, &v)
+	return v, err
+}
+
+// topicTokens restores token types after JSON: integral numbers become int.
+func topicTokens(raw []any) []bus.Token {
+	toks := make([]bus.Token, len(raw))
+	for i, t := range raw {
+		if f, ok := t.(float64); ok && f == float64(int(f)) {
+			toks[i] = int(f)
+			continue
+		}
+		toks[i] = t
+	}
+	return toks
+}
@@ -0,0 +1,341 @@
+// Package supervisor starts a firmware's services in dependency order,
+// waits for each to report ready, restarts those that exit, and stops
+// them in reverse order, so main does not grow a bespoke wait per service.
+//
+// A service runs after those named in its After list are ready. One with
+// a Ready topic is ready when that retained topic carries a payload
+// IsReady accepts; one without is ready as soon as it starts. A service
+// not ready within ReadyTimeout fails, and so do those that run after it
+// ("blocked"); the rest still start. A restarted service is awaited again,
+// ignoring the readiness its last run left retained; if it times out it is
+// marked failed but left running. Each service's state is retained on
+// sys/service/<name>/state (types.ServiceState).
+package supervisor
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+// TopicState returns sys/service/<name>/state.
+func TopicState(name string) bus.Topic { return bus.T("sys", "service", name, "state") }
+
+type Restart uint8
+
+const (
+	RestartNever  Restart = iota // an exit is final
+	RestartAlways                // restart after Backoff, doubling to maxBackoff; reset by a run longer than that
+)
+
+const (
+	defaultReadyTimeout = 5 * time.Second
+	defaultBackoff      = time.Second
+	maxBackoff          = 30 * time.Second
+)
+
+// Service is one long-running task. Run blocks until ctx is cancelled; it
+// gets a fresh connection named after the service on each start.
+type Service struct {
+	Name  string
+	Run   func(ctx context.Context, conn *bus.Connection)
+	After []string // services that must be ready before this one starts
+
+	Ready        bus.Topic              // retained readiness topic; nil: ready once started
+	IsReady      func(payload any) bool // nil: any payload
+	ReadyTimeout time.Duration          // 0: 5 s
+
+	Restart Restart
+	Backoff time.Duration // first restart delay; 0: 1 s
+}
+
+type entry struct {
+	svc    Service
+	cancel context.CancelFunc
+	done   chan struct{} // closed once the service has stopped for good
+	st     types.ServiceState
+	ready  bool // has been ready since Start
+}
+
+type Supervisor struct {
+	bus  *bus.Bus
+	conn *bus.Connection
+	clk  clock.Clock
+
+	mu      sync.Mutex
+	svcs    []*entry
+	byName  map[string]*entry
+	started []*entry
+}
+
+// New returns a supervisor for services on b. clk may be nil (clock.Real).
+func New(b *bus.Bus, clk clock.Clock) *Supervisor {
+	return &Supervisor{bus: b, conn: b.NewConnection("supervisor"), clk: clock.Or(clk), byName: map[string]*entry{}}
+}
+
+// Add registers svc. Names must be unique and non-empty.
+func (s *Supervisor) Add(svc Service) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if svc.Name == "" || svc.Run == nil {
+		return &errcode.E{C: errcode.InvalidParams, Op: "supervisor", Msg: "service needs a name and Run"}
+	}
+	if _, dup := s.byName[svc.Name]; dup {
+		return &errcode.E{C: errcode.Conflict, Op: "supervisor", Msg: "duplicate service " + svc.Name}
+	}
+	e := &entry{svc: svc, done: make(chan struct{}), st: types.ServiceState{Name: svc.Name}}
+	s.svcs = append(s.svcs, e)
+	s.byName[svc.Name] = e
+	return nil
+}
+
+// Start starts every service in dependency order, waiting for each to be
+// ready before starting those after it, and returns once all have been
+// tried. The error lists the services that failed or were blocked; the
+// others keep running. An unknown dependency or a cycle starts nothing.
+func (s *Supervisor) Start(ctx context.Context) error {
+	order, err := s.order()
+	if err != nil {
+		return err
+	}
+	for _, e := range order {
+		s.publish(e, types.ServiceWaiting, "")
+	}
+	var failed []string
+	for _, e := range order {
+		if blocked := s.blocked(e); blocked {
+			s.publish(e, types.ServiceFailed, "blocked")
+			failed = append(failed, e.svc.Name)
+			continue
+		}
+		if !s.launch(ctx, e) {
+			failed = append(failed, e.svc.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return &errcode.E{C: errcode.Unavailable, Op: "supervisor", Msg: "not ready: " + strings.Join(failed, ", ")}
+	}
+	return nil
+}
+
+// order sorts the services so each follows those it runs after, keeping
+// the order of Add among the rest.
+func (s *Supervisor) order() ([]*entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.svcs {
+		for _, dep := range e.svc.After {
+			if _, ok := s.byName[dep]; !ok {
+				return nil, &errcode.E{C: errcode.InvalidParams, Op: "supervisor", Msg: e.svc.Name + " runs after unknown " + dep}
+			}
+		}
+	}
+	placed := map[string]bool{}
+	var out []*entry
+	for len(out) < len(s.svcs) {
+		progress := false
+		for _, e := range s.svcs {
+			if placed[e.svc.Name] {
+				continue
+			}
+			ready := true
+			for _, dep := range e.svc.After {
+				ready = ready && placed[dep]
+			}
+			if ready {
+				placed[e.svc.Name], progress = true, true
+				out = append(out, e)
+			}
+		}
+		if !progress {
+			return nil, &errcode.E{C: errcode.InvalidParams, Op: "supervisor", Msg: "dependency cycle"}
+		}
+	}
+	return out, nil
+}
+
+// blocked reports whether a service e runs after never became ready.
+func (s *Supervisor) blocked(e *entry) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, dep := range e.svc.After {
+		if !s.byName[dep].ready {
+			return true
+		}
+	}
+	return false
+}
+
+// launch starts e and waits for it to be ready.
+func (s *Supervisor) launch(ctx context.Context, e *entry) bool {
+	var sub *bus.Subscription
+	if e.svc.Ready != nil {
+		// Subscribed first, so a readiness published at once is not missed.
+		sub = s.conn.Subscribe(e.svc.Ready)
+		defer s.conn.Unsubscribe(sub)
+	}
+	sctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	e.cancel = cancel
+	s.started = append(s.started, e)
+	s.mu.Unlock()
+	if sub == nil {
+		s.publish(e, types.ServiceReady, "")
+	} else {
+		s.publish(e, types.ServiceStarting, "")
+	}
+	go s.run(sctx, e)
+	if sub == nil {
+		return true
+	}
+	return s.awaitReady(ctx, e, sub)
+}
+
+// awaitReady waits for e's readiness on sub, moving e from starting to
+// ready or, after its ReadyTimeout, to failed.
+func (s *Supervisor) awaitReady(ctx context.Context, e *entry, sub *bus.Subscription) bool {
+	timeout := e.svc.ReadyTimeout
+	if timeout <= 0 {
+		timeout = defaultReadyTimeout
+	}
+	t := s.clk.NewTimer(timeout)
+	defer t.Stop()
+	for {
+		select {
+		case m := <-sub.Channel():
+			if e.svc.IsReady == nil || e.svc.IsReady(m.Payload) {
+				s.publishIf(e, types.ServiceStarting, types.ServiceReady, "")
+				return true
+			}
+		case <-t.C():
+			s.publishIf(e, types.ServiceStarting, types.ServiceFailed, "ready_timeout")
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// run runs e until ctx is cancelled, restarting it as its policy says.
+func (s *Supervisor) run(ctx context.Context, e *entry) {
+	defer close(e.done)
+	first := e.svc.Backoff
+	if first <= 0 {
+		first = defaultBackoff
+	}
+	backoff := first
+	var waitCancel context.CancelFunc // the restarted run's readiness wait
+	for {
+		began := s.clk.Now()
+		conn := s.bus.NewConnection(e.svc.Name)
+		e.svc.Run(ctx, conn)
+		conn.Disconnect()
+		if waitCancel != nil {
+			waitCancel()
+			waitCancel = nil
+		}
+		if s.clk.Now().Sub(began) > maxBackoff {
+			backoff = first // a crash long after the last one starts afresh
+		}
+		if ctx.Err() != nil {
+			s.publish(e, types.ServiceStopped, "")
+			return
+		}
+		if e.svc.Restart == RestartNever {
+			s.publish(e, types.ServiceExited, "")
+			return
+		}
+		s.mu.Lock()
+		e.st.Restarts++
+		s.mu.Unlock()
+		s.publish(e, types.ServiceRestarting, "")
+		t := s.clk.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			s.publish(e, types.ServiceStopped, "")
+			return
+		case <-t.C():
+		}
+		backoff = min(2*backoff, maxBackoff)
+		if e.svc.Ready == nil {
+			s.publish(e, types.ServiceReady, "")
+			continue
+		}
+		// The retained readiness, replayed as we subscribe, is the last
+		// run's: drop it and wait for this run's.
+		sub := s.conn.Subscribe(e.svc.Ready)
+		for len(sub.Channel()) > 0 {
+			<-sub.Channel()
+		}
+		s.publish(e, types.ServiceStarting, "")
+		var wctx context.Context
+		wctx, waitCancel = context.WithCancel(ctx)
+		go func() {
+			defer s.conn.Unsubscribe(sub)
+			s.awaitReady(wctx, e, sub)
+		}()
+	}
+}
+
+// Stop stops the started services in reverse start order, waiting up to
+// timeout for each. The error names those still running.
+func (s *Supervisor) Stop(timeout time.Duration) error {
+	s.mu.Lock()
+	started := append([]*entry(nil), s.started...)
+	s.mu.Unlock()
+	var stuck []string
+	for i := len(started) - 1; i >= 0; i-- {
+		e := started[i]
+		e.cancel()
+		t := s.clk.NewTimer(timeout)
+		select {
+		case <-e.done:
+		case <-t.C():
+			stuck = append(stuck, e.svc.Name)
+		}
+		t.Stop()
+	}
+	if len(stuck) > 0 {
+		return &errcode.E{C: errcode.Timeout, Op: "supervisor", Msg: "still running: " + strings.Join(stuck, ", ")}
+	}
+	return nil
+}
+
+// State returns the current state of the named service.
+func (s *Supervisor) State(name string) (types.ServiceState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.byName[name]
+	if !ok {
+		return types.ServiceState{}, false
+	}
+	return e.st, true
+}
+
+func (s *Supervisor) publish(e *entry, st types.ServiceStateName, why string) {
+	s.publishIf(e, "", st, why)
+}
+
+// publishIf moves e to state to if it is in state from ("": any), so a
+// late readiness does not overwrite an exit.
+func (s *Supervisor) publishIf(e *entry, from, to types.ServiceStateName, why string) {
+	s.mu.Lock()
+	if from != "" && e.st.State != from {
+		s.mu.Unlock()
+		return
+	}
+	e.st.State, e.st.Error, e.st.TS = to, why, s.clk.Now().UnixNano()
+	if to == types.ServiceReady {
+		e.ready = true
+	}
+	v := e.st
+	s.mu.Unlock()
+	s.conn.Publish(s.conn.NewMessage(TopicState(e.svc.Name), v, true))
+}
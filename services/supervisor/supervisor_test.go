@@ -0,0 +1,217 @@
+package supervisor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+var t0 = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// trace records what the services did, in order.
+type trace struct {
+	mu sync.Mutex
+	ev []string
+}
+
+func (l *trace) note(s string) {
+	l.mu.Lock()
+	l.ev = append(l.ev, s)
+	l.mu.Unlock()
+}
+
+func (l *trace) events() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.ev...)
+}
+
+// blocking notes its start and stop and runs until cancelled.
+func blocking(l *trace, name string) func(context.Context, *bus.Connection) {
+	return func(ctx context.Context, _ *bus.Connection) {
+		l.note("start " + name)
+		<-ctx.Done()
+		l.note("stop " + name)
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for " + what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSupervisor_OrderReadinessAndBlocked(t *testing.T) {
+	b := bus.NewBus(4, "+", "#")
+	clk := clock.NewFake(t0)
+	s := New(b, clk)
+	l := &trace{}
+	halState := bus.T("hal", "state")
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(s.Add(Service{Name: "energy", After: []string{"hal"}, Run: blocking(l, "energy")}))
+	must(s.Add(Service{Name: "hal", Ready: halState,
+		IsReady: func(p any) bool { return p.(types.HALState).Level == "ready" },
+		Run: func(ctx context.Context, c *bus.Connection) {
+			l.note("start hal")
+			c.Publish(c.NewMessage(halState, types.HALState{Level: "idle"}, true))
+			c.Publish(c.NewMessage(halState, types.HALState{Level: "ready"}, true))
+			<-ctx.Done()
+		}}))
+	must(s.Add(Service{Name: "late", Ready: bus.T("late", "state"), ReadyTimeout: time.Second, Run: blocking(l, "late")}))
+	must(s.Add(Service{Name: "dep", After: []string{"late"}, Run: blocking(l, "dep")}))
+	if err := s.Add(Service{Name: "hal", Run: blocking(l, "x")}); err == nil {
+		t.Fatal("duplicate accepted")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() { errc <- s.Start(ctx) }()
+	var err error
+	waitFor(t, "start", func() bool {
+		select {
+		case err = <-errc:
+			return true
+		default:
+			clk.Advance(100 * time.Millisecond)
+			return false
+		}
+	})
+	if err == nil || err.Error() != "unavailable: not ready: late, dep" {
+		t.Fatalf("Start = %v", err)
+	}
+	waitFor(t, "energy", func() bool { return len(l.events()) == 3 })
+	if got := l.events(); got[0] != "start hal" || got[1] != "start late" || got[2] != "start energy" {
+		t.Fatalf("order %q", got)
+	}
+	for name, want := range map[string]string{"hal": "ready", "energy": "ready", "late": "failed ready_timeout", "dep": "failed blocked"} {
+		st, _ := s.State(name)
+		got := string(st.State)
+		if st.Error != "" {
+			got += " " + st.Error
+		}
+		if got != want {
+			t.Errorf("%s: %q, want %q", name, got, want)
+		}
+	}
+	if ms := b.NewConnection("x").Retained(TopicState("dep")); len(ms) != 1 || ms[0].Payload.(types.ServiceState).Error != "blocked" {
+		t.Fatalf("retained state %v", ms)
+	}
+}
+
+func TestSupervisor_CycleStartsNothing(t *testing.T) {
+	s := New(bus.NewBus(4, "+", "#"), clock.NewFake(t0))
+	l := &trace{}
+	_ = s.Add(Service{Name: "a", After: []string{"b"}, Run: blocking(l, "a")})
+	_ = s.Add(Service{Name: "b", After: []string{"a"}, Run: blocking(l, "b")})
+	if err := s.Start(context.Background()); err == nil || len(l.events()) != 0 {
+		t.Fatalf("Start = %v, events %q", err, l.events())
+	}
+}
+
+func TestSupervisor_RestartAndStopOrder(t *testing.T) {
+	clk := clock.NewFake(t0)
+	s := New(bus.NewBus(4, "+", "#"), clk)
+	l := &trace{}
+	runs := 0
+	_ = s.Add(Service{Name: "a", Run: blocking(l, "a")})
+	_ = s.Add(Service{Name: "flaky", After: []string{"a"}, Restart: RestartAlways, Backoff: time.Second,
+		Run: func(ctx context.Context, c *bus.Connection) {
+			runs++
+			if runs == 1 {
+				l.note("exit flaky")
+				return
+			}
+			blocking(l, "flaky")(ctx, c)
+		}})
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "restarting", func() bool { st, _ := s.State("flaky"); return st.State == types.ServiceRestarting })
+	clk.Advance(999 * time.Millisecond)
+	if st, _ := s.State("flaky"); st.State != types.ServiceRestarting {
+		t.Fatalf("restarted early: %v", st.State)
+	}
+	clk.Advance(time.Millisecond)
+	waitFor(t, "restart", func() bool { st, _ := s.State("flaky"); return st.State == types.ServiceReady })
+	if st, _ := s.State("flaky"); st.Restarts != 1 {
+		t.Fatalf("restarts %d", st.Restarts)
+	}
+
+	if err := s.Stop(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	got := l.events()
+	if got[len(got)-2] != "stop flaky" || got[len(got)-1] != "stop a" {
+		t.Fatalf("stop order %q", got)
+	}
+	if st, _ := s.State("a"); st.State != types.ServiceStopped {
+		t.Fatalf("a: %v", st.State)
+	}
+}
+
+func TestSupervisor_RestartAwaitsReadinessAgain(t *testing.T) {
+	clk := clock.NewFake(t0)
+	s := New(bus.NewBus(4, "+", "#"), clk)
+	ready := bus.T("svc", "ready")
+	// Each run publishes its readiness when told to, then exits when told to.
+	publish, exit := make(chan bool), make(chan struct{})
+	_ = s.Add(Service{Name: "svc", Ready: ready, ReadyTimeout: 2 * time.Second, Restart: RestartAlways,
+		Run: func(ctx context.Context, c *bus.Connection) {
+			select {
+			case ok := <-publish:
+				if ok {
+					c.Publish(c.NewMessage(ready, true, true))
+				}
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case <-exit:
+			case <-ctx.Done():
+			}
+		}})
+	state := func() types.ServiceState { st, _ := s.State("svc"); return st }
+	go func() { publish <- true }()
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop(time.Second)
+
+	// Restarted after 1 s; the first run's retained readiness does not count.
+	exit <- struct{}{}
+	waitFor(t, "backoff", func() bool { return state().State == types.ServiceRestarting && clk.Pending() == 1 })
+	clk.Advance(time.Second)
+	waitFor(t, "restart", func() bool { return state().State == types.ServiceStarting && clk.Pending() == 1 })
+	publish <- true
+	waitFor(t, "ready again", func() bool { return state().State == types.ServiceReady })
+
+	// A run longer than maxBackoff resets the backoff to 1 s, and a run
+	// that never reports ready fails at its timeout.
+	clk.Advance(maxBackoff + time.Second)
+	exit <- struct{}{}
+	waitFor(t, "backoff", func() bool { return state().State == types.ServiceRestarting && clk.Pending() == 1 })
+	clk.Advance(time.Second)
+	waitFor(t, "restart", func() bool { return state().State == types.ServiceStarting && clk.Pending() == 1 })
+	publish <- false
+	clk.Advance(2 * time.Second)
+	waitFor(t, "timeout", func() bool { st := state(); return st.State == types.ServiceFailed && st.Error == "ready_timeout" })
+	if st := state(); st.Restarts != 2 {
+		t.Fatalf("restarts %d", st.Restarts)
+	}
+}
@@ -0,0 +1,177 @@
+// Package tempcomp compensates a lead-acid charge voltage for battery
+// temperature measured by an external probe (e.g. a DS18B20 on the pack)
+// rather than the charger's own NTC. It follows the probe's temperature
+// capability and sets the charger's per-cell VCHARGE through its
+// set_vcharge control.
+//
+// The charger's internal compensation should be off
+// (lead_acid_temp_comp=false): with it on the LTC4015 caps VCHARGE.
+package tempcomp
+
+import (
+	"context"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/services/metrics"
+	"devicecode-go/types"
+)
+
+// Config names the probe and charger and sets the compensation curve.
+// Empty fields take the defaults noted.
+type Config struct {
+	ProbeDomain string // default "env"
+	Probe       string // temperature capability name; required
+	Domain      string // charger domain; default "power"
+	Charger     string // charger capability name; default "internal"
+
+	Nominal_mVPerCell int32 // VCHARGE at RefDeciC; default 2400
+	RefDeciC          int16 // default 250 (25.0 °C)
+	// Slope in µV per °C per cell, so fractional mV/°C/cell coefficients
+	// fit; default -4000 (−4 mV/°C/cell). Usually negative.
+	Coeff_uVPerC  int32
+	Min_mVPerCell int32 // clamp; default Nominal-150
+	Max_mVPerCell int32 // clamp; default Nominal+150
+
+	Deadband_mV int32         // skip changes smaller than this; default 5
+	MinEvery    time.Duration // between writes; default 1 min
+	MaxAge      time.Duration // a probe older than this reverts to Nominal; default 5 min
+}
+
+func (c *Config) defaults() {
+	if c.ProbeDomain == "" {
+		c.ProbeDomain = "env"
+	}
+	if c.Domain == "" {
+		c.Domain = "power"
+	}
+	if c.Charger == "" {
+		c.Charger = "internal"
+	}
+	if c.Nominal_mVPerCell == 0 {
+		c.Nominal_mVPerCell = 2400
+	}
+	if c.RefDeciC == 0 {
+		c.RefDeciC = 250
+	}
+	if c.Coeff_uVPerC == 0 {
+		c.Coeff_uVPerC = -4000
+	}
+	if c.Min_mVPerCell == 0 {
+		c.Min_mVPerCell = c.Nominal_mVPerCell - 150
+	}
+	if c.Max_mVPerCell == 0 {
+		c.Max_mVPerCell = c.Nominal_mVPerCell + 150
+	}
+	if c.Deadband_mV <= 0 {
+		c.Deadband_mV = 5
+	}
+	if c.MinEvery <= 0 {
+		c.MinEvery = time.Minute
+	}
+	if c.MaxAge <= 0 {
+		c.MaxAge = 5 * time.Minute
+	}
+}
+
+// Target returns the compensated per-cell charge voltage at deciC.
+func Target(c Config, deciC int16) int32 {
+	c.defaults()
+	// µV/°C × deci°C / 10 / 1000 → mV, rounded to nearest.
+	num := int64(c.Coeff_uVPerC) * int64(deciC-c.RefDeciC)
+	adj := (num + sign(num)*5000) / 10000
+	v := c.Nominal_mVPerCell + int32(adj)
+	if v < c.Min_mVPerCell {
+		v = c.Min_mVPerCell
+	}
+	if v > c.Max_mVPerCell {
+		v = c.Max_mVPerCell
+	}
+	return v
+}
+
+func sign(v int64) int64 {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
+
+var (
+	mSetpoint = metrics.Default.Gauge("tempcomp.vcharge_mv_cell")
+	mWrites   = metrics.Default.Counter("tempcomp.writes")
+	mFailed   = metrics.Default.Counter("tempcomp.write_failed")
+)
+
+// Run compensates until ctx is cancelled. A write that is refused or
+// unanswered is retried on the next sample after MinEvery.
+func Run(ctx context.Context, conn *bus.Connection, cfg Config) {
+	cfg.defaults()
+	if cfg.Probe == "" {
+		return
+	}
+	probe := bus.SubscribeT[types.TemperatureValue](conn,
+		bus.T("hal", "cap", cfg.ProbeDomain, string(types.KindTemperature), cfg.Probe, "value"))
+	defer probe.Unsubscribe()
+	ctrl := bus.T("hal", "cap", cfg.Domain, string(types.KindCharger), cfg.Charger, "control", "set_vcharge")
+
+	stale := time.NewTicker(cfg.MaxAge / 2)
+	defer stale.Stop()
+
+	var (
+		applied int32 // last accepted setpoint; 0 until the first write
+		lastAt  time.Time
+		seenAt  time.Time
+	)
+	apply := func(want int32, force bool) {
+		d := want - applied
+		if d < 0 {
+			d = -d
+		}
+		if applied != 0 && d < cfg.Deadband_mV {
+			return
+		}
+		if !force && !lastAt.IsZero() && time.Since(lastAt) < cfg.MinEvery {
+			return
+		}
+		lastAt = time.Now()
+		if set(ctx, conn, ctrl, want) {
+			applied = want
+			mSetpoint.Set(want)
+			mWrites.Inc()
+		} else {
+			mFailed.Inc()
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m := <-probe.Channel():
+			v, ok := probe.Value(m)
+			if !ok {
+				continue
+			}
+			seenAt = time.Now()
+			apply(Target(cfg, v.DeciC), false)
+		case <-stale.C:
+			if !seenAt.IsZero() && time.Since(seenAt) > cfg.MaxAge {
+				// Lost the probe: fall back to the uncompensated voltage.
+				seenAt = time.Time{}
+				apply(cfg.Nominal_mVPerCell, true)
+			}
+		}
+	}
+}
+
+func set(ctx context.Context, conn *bus.Connection, ctrl bus.Topic, mV int32) bool {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	m, err := conn.RequestWait(ctx, conn.NewMessage(ctrl, types.VoltageMV{MilliV: mV}, false))
+	if err != nil {
+		return false
+	}
+	_, ok := m.Payload.(types.OKReply)
+	return ok
+}
@@ -0,0 +1,151 @@
+// Package timesync keeps wall-clock time for a board that has no RTC. It
+// takes the epoch from the host (sys/time/control/set, types.TimeSet,
+// usually sent over the bridge) or from any GNSS time capability
+// (hal/cap/+/time/+/value), disciplines an offset against the
+// local clock, and publishes it retained on sys/time (types.SysTime).
+//
+// The first sync, and any that finds the offset out by StepAt or more,
+// steps the offset; smaller errors are slewed out a quarter at a time, so
+// timestamps do not jump about with link latency. A GNSS fix outranks the
+// host: while one is recent, host settings are refused with
+// errcode.Conflict.
+//
+// NowEpochMs and EpochMs convert local time for other services and for
+// payload timestamps: energy files its totals under calendar days with
+// them, and the reactor stamps its log checkpoints, which are read after
+// the reboot that resets the local clock.
+package timesync
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+var (
+	TopicSet  = bus.T("sys", "time", "control", "set")
+	TopicTime = bus.T("sys", "time")
+)
+
+const slewGain = 4 // a slewed sync corrects 1/slewGain of the error
+
+// Config tunes the discipline. Empty fields take the defaults noted.
+type Config struct {
+	StepAt   time.Duration // errors this large are stepped; default 1 s
+	GNSSHold time.Duration // a GNSS fix outranks the host this long; default 10 min
+	Clock    clock.Clock   // nil: clock.Real
+}
+
+func (c *Config) defaults() {
+	if c.StepAt <= 0 {
+		c.StepAt = time.Second
+	}
+	if c.GNSSHold <= 0 {
+		c.GNSSHold = 10 * time.Minute
+	}
+	c.Clock = clock.Or(c.Clock)
+}
+
+// The offset in force, shared with NowEpochMs.
+var (
+	offsetNs atomic.Int64
+	synced   atomic.Bool
+)
+
+// EpochMs returns the wall-clock time, in Unix ms, of local clock reading
+// t, and whether time has been synced (if not, it is t unchanged).
+func EpochMs(t time.Time) (int64, bool) {
+	return (t.UnixNano() + offsetNs.Load()) / 1e6, synced.Load()
+}
+
+// NowEpochMs is EpochMs of the real clock now.
+func NowEpochMs() (int64, bool) { return EpochMs(time.Now()) }
+
+type service struct {
+	conn   *bus.Connection
+	cfg    Config
+	st     types.SysTime
+	offset int64     // ns
+	gnssAt time.Time // last GNSS sync (zero: none)
+}
+
+// Run serves time until ctx is cancelled.
+func Run(ctx context.Context, conn *bus.Connection, cfg Config) {
+	cfg.defaults()
+	// A restarted service carries on from the offset in force.
+	s := &service{conn: conn, cfg: cfg, offset: offsetNs.Load()}
+	s.st.Synced = synced.Load()
+
+	set := bus.SubscribeT[types.TimeSet](conn, TopicSet)
+	defer set.Unsubscribe()
+	gnss := bus.SubscribeT[types.TimeValue](conn, bus.T("hal", "cap", "+", string(types.KindTime), "+", "value"))
+	defer gnss.Unsubscribe()
+
+	s.publish()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m := <-set.Channel():
+			now := cfg.Clock.Now()
+			v, ok := set.Value(m)
+			switch {
+			case !ok || v.EpochMs <= 0:
+				reply(conn, m, errcode.InvalidPayload)
+			case !s.gnssAt.IsZero() && now.Sub(s.gnssAt) < cfg.GNSSHold:
+				reply(conn, m, &errcode.E{C: errcode.Conflict, Op: "set", Msg: "GNSS has time"})
+			default:
+				s.sync("host", v.EpochMs, now.UnixNano())
+				reply(conn, m, nil)
+			}
+		case m := <-gnss.Channel():
+			if v, ok := gnss.Value(m); ok && v.EpochMs > 0 && v.TS != 0 {
+				s.gnssAt = cfg.Clock.Now()
+				s.sync("gnss", v.EpochMs, v.TS)
+			}
+		}
+	}
+}
+
+// sync disciplines the offset with the epoch epochMs observed at local
+// time localNs.
+func (s *service) sync(source string, epochMs, localNs int64) {
+	measured := epochMs*1e6 - localNs
+	e := measured - s.offset
+	if !s.st.Synced || e >= int64(s.cfg.StepAt) || -e >= int64(s.cfg.StepAt) {
+		s.offset = measured
+	} else {
+		s.offset += e / slewGain
+	}
+	offsetNs.Store(s.offset)
+	synced.Store(true)
+	s.st.Synced, s.st.Source = true, source
+	s.st.StepMs = e / 1e6
+	s.st.Syncs++
+	s.publish()
+}
+
+func (s *service) publish() {
+	now := s.cfg.Clock.Now().UnixNano()
+	s.st.OffsetMs = s.offset / 1e6
+	s.st.EpochMs = (now + s.offset) / 1e6
+	s.st.TS = now
+	s.conn.Publish(s.conn.NewMessage(TopicTime, s.st, true))
+}
+
+func reply(conn *bus.Connection, m *bus.Message, err error) {
+	if !m.CanReply() {
+		return
+	}
+	if err == nil {
+		conn.Reply(m, types.OKReply{OK: true}, false)
+		return
+	}
+	verb, _ := m.Topic.At(m.Topic.Len() - 1).(string) // …/control/<verb>
+	conn.Reply(m, types.ErrorReplyOf(err, verb), false)
+}
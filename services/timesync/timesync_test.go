@@ -0,0 +1,89 @@
+package timesync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"devicecode-go/bus"
+	"devicecode-go/errcode"
+	"devicecode-go/types"
+	"devicecode-go/x/clock"
+)
+
+// The local clock starts at boot, as on the board.
+var boot = time.Unix(0, 0).Add(90 * time.Second)
+
+func call(t *testing.T, c *bus.Connection, epochMs int64) any {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	rep, err := c.RequestWait(ctx, c.NewMessage(TopicSet, types.TimeSet{EpochMs: epochMs}, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rep.Payload
+}
+
+func nextTime(t *testing.T, sub *bus.Subscription) types.SysTime {
+	t.Helper()
+	select {
+	case m := <-sub.Channel():
+		return m.Payload.(types.SysTime)
+	case <-time.After(time.Second):
+		t.Fatal("no sys/time")
+		return types.SysTime{}
+	}
+}
+
+func TestTimesync_StepsSlewsAndPrefersGNSS(t *testing.T) {
+	offsetNs.Store(0)
+	synced.Store(false)
+	b := bus.NewBus(8, "+", "#")
+	clk := clock.NewFake(boot)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := b.NewConnection("test")
+	st := c.Subscribe(TopicTime)
+	go Run(ctx, b.NewConnection("timesync"), Config{Clock: clk})
+	if v := nextTime(t, st); v.Synced {
+		t.Fatalf("synced at start: %+v", v)
+	}
+
+	// The first host time steps the offset.
+	const epoch = int64(1_760_000_000_000)
+	if rep := call(t, c, epoch); rep != (types.OKReply{OK: true}) {
+		t.Fatalf("set: %+v", rep)
+	}
+	v := nextTime(t, st)
+	if !v.Synced || v.Source != "host" || v.EpochMs != epoch || v.OffsetMs != epoch-boot.UnixMilli() {
+		t.Fatalf("after set: %+v", v)
+	}
+	if ms, ok := EpochMs(clk.Now().Add(1500 * time.Millisecond)); !ok || ms != epoch+1500 {
+		t.Fatalf("EpochMs = %d, %v", ms, ok)
+	}
+
+	// 200 ms of latency on the next one is slewed, a quarter at a time.
+	clk.Advance(time.Minute)
+	call(t, c, epoch+60_000-200)
+	if v = nextTime(t, st); v.StepMs != -200 || v.EpochMs != epoch+60_000-50 {
+		t.Fatalf("after slew: %+v", v)
+	}
+
+	// A GNSS fix steps it back and then holds off the host.
+	clk.Advance(time.Minute)
+	c.Publish(c.NewMessage(bus.T("hal", "cap", "env", string(types.KindTime), "gps", "value"),
+		types.TimeValue{EpochMs: epoch + 120_000 + 5000, TS: clk.Now().UnixNano()}, true))
+	if v = nextTime(t, st); v.Source != "gnss" || v.EpochMs != epoch+125_000 {
+		t.Fatalf("after gnss: %+v", v)
+	}
+	rep, ok := call(t, c, epoch).(types.ErrorReply)
+	if !ok || rep != (types.ErrorReply{Error: string(errcode.Conflict), Code: uint16(errcode.Conflict.Num()),
+		Verb: "set", Detail: "GNSS has time"}) {
+		t.Fatalf("host set under GNSS: %+v", rep)
+	}
+	clk.Advance(11 * time.Minute)
+	if rep := call(t, c, epoch+5_000_000); rep != (types.OKReply{OK: true}) {
+		t.Fatalf("host set after GNSS hold: %+v", rep)
+	}
+}
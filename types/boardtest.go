@@ -0,0 +1,63 @@
+package types
+
+// ------------------------
+// Board test plans (factory fixtures)
+// ------------------------
+
+type TestStepKind string
+
+const (
+	StepRail   TestStepKind = "rail"   // switch Rail On/off; pass on OK reply
+	StepExpect TestStepKind = "expect" // a message on Topic within WithinMs (and Field in [Min,Max] if set)
+	StepLED    TestStepKind = "led"    // set LED On/off; pass on OK reply
+	StepWait   TestStepKind = "wait"   // sleep WithinMs
+
+	// Switch Rail on and pass if the rise in Field on Topic (default: charger
+	// iin_mA) between a sample before and one taken SettleMs after lies in
+	// [Min,Max]. Below Min reports "open", above Max reports "short".
+	StepRailCurrent TestStepKind = "rail_current"
+)
+
+type TestStep struct {
+	Kind TestStepKind `json:"kind"`
+	Name string       `json:"name,omitempty"` // label in the report
+
+	Rail string `json:"rail,omitempty"` // rail/LED capability name
+	On   bool   `json:"on,omitempty"`
+
+	Topic    string `json:"topic,omitempty"` // slash-separated, wildcards allowed
+	Field    string `json:"field,omitempty"` // see Fielder; empty => any message passes
+	Min      int64  `json:"min,omitempty"`
+	Max      int64  `json:"max,omitempty"`
+	WithinMs uint32 `json:"within_ms,omitempty"`
+	SettleMs uint32 `json:"settle_ms,omitempty"` // rail_current: delay before the "after" sample
+
+	// Continue after a failure of this step (default: abort the plan).
+	ContinueOnFail bool `json:"continue_on_fail,omitempty"`
+}
+
+// Retained: config/boardtest
+type TestPlan struct {
+	Name  string     `json:"name"`
+	Steps []TestStep `json:"steps"`
+}
+
+type TestStepResult struct {
+	Index     int          `json:"i"`
+	Kind      TestStepKind `json:"kind"`
+	Name      string       `json:"name,omitempty"`
+	Pass      bool         `json:"pass"`
+	Value     int64        `json:"value,omitempty"`  // observed value (rail_current: delta)
+	Before    int64        `json:"before,omitempty"` // rail_current only
+	After     int64        `json:"after,omitempty"`  // rail_current only
+	ElapsedMs uint32       `json:"elapsed_ms"`
+	Detail    string       `json:"detail,omitempty"`
+}
+
+// Published: boardtest/report; also written as one JSON line on the UART.
+type TestReport struct {
+	Plan    string           `json:"plan"`
+	Pass    bool             `json:"pass"`
+	Steps   []TestStepResult `json:"steps"`
+	Skipped int              `json:"skipped,omitempty"` // steps not run after an abort
+}
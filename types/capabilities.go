@@ -12,16 +12,30 @@ const (
 	KindPWM         Kind = "pwm"
 	KindTemperature Kind = "temperature"
 	KindHumidity    Kind = "humidity"
+	KindPressure    Kind = "pressure" // barometric, Pa
 	KindSerial      Kind = "serial"
 	KindButton      Kind = "button"
 	KindBattery     Kind = "battery"
 	KindCharger     Kind = "charger"
+	KindCounter     Kind = "counter"
+	KindSystem      Kind = "system" // derived power figures
+	KindEnergy      Kind = "energy" // integrated system power
+	KindPosition    Kind = "position"
+	KindTime        Kind = "time"
+	KindModem       Kind = "modem"
+	KindSensor      Kind = "sensor" // per-rail power monitor
+	KindGPIO        Kind = "gpio"   // expander pin
+	KindBuzzer      Kind = "buzzer"
+	KindLEDStrip    Kind = "ledstrip"   // addressable pixels
+	KindThermostat  Kind = "thermostat" // composite: source value driving a switch
 )
 
 func (k Kind) Valid() bool {
 	switch k {
 	case KindLED, KindSwitch, KindPWM, KindTemperature, KindHumidity,
-		KindSerial, KindButton, KindBattery, KindCharger:
+		KindSerial, KindButton, KindBattery, KindCharger, KindCounter, KindSystem,
+		KindPosition, KindTime, KindModem, KindSensor, KindGPIO, KindBuzzer,
+		KindThermostat, KindEnergy, KindLEDStrip, KindPressure:
 		return true
 	}
 	return false
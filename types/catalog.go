@@ -0,0 +1,125 @@
+package types
+
+// ------------------------
+// Capability catalogue (retained: hal/catalog)
+// ------------------------
+
+// FieldMeta describes one numeric field of a value payload for display:
+// the displayed quantity is raw × 10^Exp in Unit. Min/Max bound the raw
+// value when HasRange is set. Booleans have Unit "bool".
+type FieldMeta struct {
+	Name     string `json:"name"` // JSON tag, as read by Fielder
+	Unit     string `json:"unit,omitempty"`
+	Exp      int8   `json:"exp,omitempty"`
+	HasRange bool   `json:"has_range,omitempty"`
+	Min      int64  `json:"min,omitempty"`
+	Max      int64  `json:"max,omitempty"`
+}
+
+// CatalogEntry compiles one capability's info, value fields and verbs.
+type CatalogEntry struct {
+	Domain string      `json:"domain"`
+	Kind   Kind        `json:"kind"`
+	Name   string      `json:"name"`
+	Driver string      `json:"driver"`
+	Detail any         `json:"detail,omitempty"`
+	Fields []FieldMeta `json:"fields,omitempty"`
+	Verbs  []VerbInfo  `json:"verbs,omitempty"`
+	// Aliases are the old addresses this capability is also reachable at
+	// (see CapAlias).
+	Aliases []CapabilityAddress `json:"aliases,omitempty"`
+}
+
+// Retained: hal/catalog. Rev increases each time the HAL regenerates it
+// (start-up and every applied configuration); entries are sorted by
+// domain, kind, name.
+type Catalog struct {
+	Rev  uint32         `json:"rev"`
+	TS   int64          `json:"ts_ns"`
+	Caps []CatalogEntry `json:"caps"`
+}
+
+func rng(name, unit string, exp int8, min, max int64) FieldMeta {
+	return FieldMeta{Name: name, Unit: unit, Exp: exp, HasRange: true, Min: min, Max: max}
+}
+
+var kindFields = map[Kind][]FieldMeta{
+	KindTemperature: {rng("deci_c", "°C", -1, -400, 1250)},
+	KindHumidity:    {rng("rh_x100", "%RH", -2, 0, 10000)},
+	KindPressure:    {rng("pa", "Pa", 0, 30000, 110000), {Name: "sea_level_pa", Unit: "Pa"}},
+	KindPosition: {
+		{Name: "fix", Unit: "bool"},
+		{Name: "quality"},
+		{Name: "sats"},
+		{Name: "hdop_x100", Exp: -2},
+		rng("lat_e7", "°", -7, -900000000, 900000000),
+		rng("lon_e7", "°", -7, -1800000000, 1800000000),
+		{Name: "alt_cm", Unit: "m", Exp: -2},
+		{Name: "speed_cmps", Unit: "m/s", Exp: -2},
+		{Name: "course_cdeg", Unit: "°", Exp: -2},
+	},
+	KindTime: {{Name: "epoch_ms", Unit: "s", Exp: -3}, {Name: "ts_ns", Unit: "ns"}},
+	KindModem: {
+		{Name: "ready", Unit: "bool"},
+		{Name: "registered", Unit: "bool"},
+		{Name: "roaming", Unit: "bool"},
+		rng("csq", "", 0, 0, 99),
+		{Name: "rssi_dbm", Unit: "dBm"},
+		{Name: "data", Unit: "bool"},
+	},
+	KindButton: {{Name: "pressed", Unit: "bool"}},
+	KindLED:    {{Name: "on", Unit: "bool"}},
+	KindSwitch: {{Name: "on", Unit: "bool"}, {Name: "ts_ns", Unit: "ns"}},
+	KindPWM:    {{Name: "level"}}, // 0..Top, see PWMInfo
+	KindGPIO:   {{Name: "level", Unit: "bool"}, {Name: "ts_ns", Unit: "ns"}},
+	KindBuzzer: {{Name: "playing", Unit: "bool"}, {Name: "priority"}},
+	KindThermostat: {
+		{Name: "enabled", Unit: "bool"}, {Name: "input"}, {Name: "setpoint"},
+		{Name: "hysteresis"}, {Name: "demand", Unit: "bool"}, {Name: "stale", Unit: "bool"},
+		{Name: "ts_ns", Unit: "ns"},
+	},
+	KindLEDStrip: {{Name: "lit"}, rng("brightness", "", 0, 0, 255)},
+	KindCounter: {
+		{Name: "rising"}, {Name: "falling"}, {Name: "ts_ns", Unit: "ns"},
+	},
+	KindBattery: {
+		{Name: "pack_mV", Unit: "V", Exp: -3},
+		{Name: "per_cell_mV", Unit: "V", Exp: -3},
+		{Name: "ibat_mA", Unit: "A", Exp: -3},
+		{Name: "temp_mC", Unit: "°C", Exp: -3},
+		{Name: "bsr_uohm_per_cell", Unit: "Ω", Exp: -6},
+	},
+	KindCharger: {
+		{Name: "vin_mV", Unit: "V", Exp: -3},
+		{Name: "vsys_mV", Unit: "V", Exp: -3},
+		{Name: "iin_mA", Unit: "A", Exp: -3},
+		{Name: "state", Unit: "bits"},
+		{Name: "status", Unit: "bits"},
+		{Name: "sys", Unit: "bits"},
+	},
+	KindSystem: {
+		{Name: "isys_mA", Unit: "A", Exp: -3},
+		{Name: "pin_mW", Unit: "W", Exp: -3},
+		{Name: "pbat_mW", Unit: "W", Exp: -3},
+		{Name: "psys_mW", Unit: "W", Exp: -3},
+		rng("eff_pct", "%", 0, 0, 100),
+	},
+	KindEnergy: {
+		{Name: "day"},
+		{Name: "in_mWh", Unit: "Wh", Exp: -3},
+		{Name: "to_batt_mWh", Unit: "Wh", Exp: -3},
+		{Name: "from_batt_mWh", Unit: "Wh", Exp: -3},
+		{Name: "to_load_mWh", Unit: "Wh", Exp: -3},
+	},
+	KindSensor: {
+		{Name: "bus_mV", Unit: "V", Exp: -3},
+		{Name: "shunt_uV", Unit: "V", Exp: -6},
+		{Name: "i_mA", Unit: "A", Exp: -3},
+		{Name: "p_mW", Unit: "W", Exp: -3},
+		rng("alert", "", 0, 0, 2),
+	},
+}
+
+// ValueFields returns display metadata for the value payload of kind k,
+// or nil if the kind has no numeric value (e.g. serial).
+func ValueFields(k Kind) []FieldMeta { return kindFields[k] }
@@ -0,0 +1,515 @@
+package types
+
+import (
+	"errors"
+
+	"devicecode-go/x/cbor"
+)
+
+// ------------------------
+// CBOR mappers
+// ------------------------
+
+// Every payload struct writes and reads itself as a CBOR map keyed by its
+// JSON field names (x/cbor: zero values left out, keys in canonical
+// order). The ones here are written by hand; cborgen writes the rest into
+// cbor_gen.go.
+
+//go:generate go run ./internal/cborgen
+
+func (a CapabilityAddress) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("domain", a.Domain)
+		m.Text("kind", string(a.Kind))
+		m.Text("name", a.Name)
+	})
+}
+
+func (a *CapabilityAddress) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "domain":
+			return cbor.ReadText(d, &a.Domain)
+		case "kind":
+			return cbor.ReadText(d, &a.Kind)
+		case "name":
+			return cbor.ReadText(d, &a.Name)
+		}
+		return d.Skip()
+	})
+}
+
+// ---- bus plan ----
+
+func (p BusPlan) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Array("i2c", len(p.I2C), func(e *cbor.Encoder, i int) { p.I2C[i].MarshalCBOR(e) })
+		m.Array("uart", len(p.UART), func(e *cbor.Encoder, i int) { p.UART[i].MarshalCBOR(e) })
+		m.Array("onewire", len(p.OneWire), func(e *cbor.Encoder, i int) { p.OneWire[i].MarshalCBOR(e) })
+		if len(p.Aliases) != 0 {
+			m.Put("aliases", func(e *cbor.Encoder) {
+				e.Map(func(am *cbor.Map) {
+					for name, pin := range p.Aliases {
+						// Written even when 0: the alias itself is the data.
+						v := int64(pin)
+						am.Put(name, func(e *cbor.Encoder) { e.Int(v) })
+					}
+				})
+			})
+		}
+	})
+}
+
+func (p *BusPlan) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "i2c":
+			return d.Array(func(d *cbor.Decoder) error {
+				var b I2CBus
+				err := b.UnmarshalCBOR(d)
+				p.I2C = append(p.I2C, b)
+				return err
+			})
+		case "uart":
+			return d.Array(func(d *cbor.Decoder) error {
+				var b UARTBus
+				err := b.UnmarshalCBOR(d)
+				p.UART = append(p.UART, b)
+				return err
+			})
+		case "onewire":
+			return d.Array(func(d *cbor.Decoder) error {
+				var b OneWireBus
+				err := b.UnmarshalCBOR(d)
+				p.OneWire = append(p.OneWire, b)
+				return err
+			})
+		case "aliases":
+			p.Aliases = map[string]int{}
+			return d.Map(func(name string, d *cbor.Decoder) error {
+				var pin int
+				err := cbor.ReadInt(d, &pin)
+				p.Aliases[name] = pin
+				return err
+			})
+		}
+		return d.Skip()
+	})
+}
+
+func (b I2CBus) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("id", b.ID)
+		m.Int("sda", int64(b.SDA))
+		m.Int("scl", int64(b.SCL))
+		m.Uint("hz", uint64(b.Hz))
+	})
+}
+
+func (b *I2CBus) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "id":
+			return cbor.ReadText(d, &b.ID)
+		case "sda":
+			return cbor.ReadInt(d, &b.SDA)
+		case "scl":
+			return cbor.ReadInt(d, &b.SCL)
+		case "hz":
+			return cbor.ReadUint(d, &b.Hz)
+		}
+		return d.Skip()
+	})
+}
+
+func (b UARTBus) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("id", b.ID)
+		m.Int("tx", int64(b.TX))
+		m.Int("rx", int64(b.RX))
+		m.Uint("baud", uint64(b.Baud))
+		cbor.OptInt(m, "cts", b.CTS)
+		cbor.OptInt(m, "rts", b.RTS)
+		m.Bool("flow", b.Flow)
+	})
+}
+
+func (b *UARTBus) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "id":
+			return cbor.ReadText(d, &b.ID)
+		case "tx":
+			return cbor.ReadInt(d, &b.TX)
+		case "rx":
+			return cbor.ReadInt(d, &b.RX)
+		case "baud":
+			return cbor.ReadUint(d, &b.Baud)
+		case "cts":
+			return cbor.ReadOptInt(d, &b.CTS)
+		case "rts":
+			return cbor.ReadOptInt(d, &b.RTS)
+		case "flow":
+			return cbor.ReadBool(d, &b.Flow)
+		}
+		return d.Skip()
+	})
+}
+
+func (b OneWireBus) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("id", b.ID)
+		m.Int("pin", int64(b.Pin))
+	})
+}
+
+func (b *OneWireBus) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "id":
+			return cbor.ReadText(d, &b.ID)
+		case "pin":
+			return cbor.ReadInt(d, &b.Pin)
+		}
+		return d.Skip()
+	})
+}
+
+// ---- pollers and alarms ----
+
+func (p PollSpec) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("domain", p.Domain)
+		m.Text("kind", string(p.Kind))
+		m.Text("name", p.Name)
+		m.Text("verb", p.Verb)
+		m.Uint("interval_ms", uint64(p.IntervalMs))
+		m.Uint("jitter_ms", uint64(p.JitterMs))
+		cbor.OptUint(m, "phase_ms", p.PhaseMs)
+	})
+}
+
+func (p *PollSpec) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "domain":
+			return cbor.ReadText(d, &p.Domain)
+		case "kind":
+			return cbor.ReadText(d, &p.Kind)
+		case "name":
+			return cbor.ReadText(d, &p.Name)
+		case "verb":
+			return cbor.ReadText(d, &p.Verb)
+		case "interval_ms":
+			return cbor.ReadUint(d, &p.IntervalMs)
+		case "jitter_ms":
+			return cbor.ReadUint(d, &p.JitterMs)
+		case "phase_ms":
+			return cbor.ReadOptUint(d, &p.PhaseMs)
+		}
+		return d.Skip()
+	})
+}
+
+func (a AlarmSpec) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("name", a.Name)
+		m.Value("source", a.Source)
+		m.Text("field", a.Field)
+		m.Text("op", string(a.Op))
+		m.Int("threshold", a.Threshold)
+		m.Int("hysteresis", a.Hysteresis)
+		m.Uint("debounce_ms", uint64(a.DebounceMs))
+	})
+}
+
+func (a *AlarmSpec) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "name":
+			return cbor.ReadText(d, &a.Name)
+		case "source":
+			return a.Source.UnmarshalCBOR(d)
+		case "field":
+			return cbor.ReadText(d, &a.Field)
+		case "op":
+			return cbor.ReadText(d, &a.Op)
+		case "threshold":
+			return cbor.ReadInt(d, &a.Threshold)
+		case "hysteresis":
+			return cbor.ReadInt(d, &a.Hysteresis)
+		case "debounce_ms":
+			return cbor.ReadUint(d, &a.DebounceMs)
+		}
+		return d.Skip()
+	})
+}
+
+// ---- payloads held as any ----
+
+// A payload held in an any field (BootAction.Payload, Info.Detail) is
+// written as {"type": name, "value": payload} so it can be rebuilt by
+// name. A type without a CBOR encoding is written with an empty type,
+// which fails to read back with ErrNoCBOR (config export checks that its
+// blob reads back).
+
+var ErrNoCBOR = errors.New("payload type has no CBOR encoding")
+
+func decodeAs[T any, P interface {
+	*T
+	cbor.Unmarshaler
+}](d *cbor.Decoder) (any, error) {
+	var v T
+	err := P(&v).UnmarshalCBOR(d)
+	return v, err
+}
+
+func putPayload(e *cbor.Encoder, v any) {
+	name, p, ok := cborMarshaler(v)
+	e.Map(func(m *cbor.Map) {
+		m.Put("type", func(e *cbor.Encoder) { e.Text(name) })
+		if ok {
+			m.Value("value", p)
+		}
+	})
+}
+
+func readPayload(d *cbor.Decoder, p *any) error {
+	// "type" sorts before "value" in canonical order.
+	var name string
+	err := d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "type":
+			return cbor.ReadText(d, &name)
+		case "value":
+			dec, ok := cborPayloads[name]
+			if !ok {
+				return ErrNoCBOR
+			}
+			v, err := dec(d)
+			*p = v
+			return err
+		}
+		return d.Skip()
+	})
+	if err == nil && *p == nil {
+		err = ErrNoCBOR
+	}
+	return err
+}
+
+func (c ChargerConfigure) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		cbor.OptBool(m, "enable", c.Enable)
+		cbor.OptBool(m, "lead_acid_temp_comp", c.LeadAcidTempComp)
+		cbor.OptUint(m, "cfg_set", c.CfgSet)
+		cbor.OptUint(m, "cfg_clear", c.CfgClear)
+		cbor.OptInt(m, "iin_limit_mA", c.IinLimit_mA)
+		cbor.OptInt(m, "icharge_target_mA", c.IChargeTarget_mA)
+		cbor.OptInt(m, "iin_high_mA", c.IinHigh_mA)
+		cbor.OptInt(m, "ibat_low_mA", c.IbatLow_mA)
+		cbor.OptInt(m, "die_temp_high_mC", c.DieTempHigh_mC)
+		cbor.OptUint(m, "bsr_high_uohm_per_cell", c.BSRHigh_uOhmPerCell)
+		cbor.OptInt(m, "vcharge_mV_per_cell", c.VCharge_mVPerCell)
+		cbor.OptUint(m, "max_charge_time_s", c.MaxChargeTime_s)
+		cbor.OptUint(m, "max_cv_time_s", c.MaxCVTime_s)
+		cbor.OptUint(m, "max_absorb_time_s", c.MaxAbsorbTime_s)
+		cbor.OptInt(m, "c_over_x_mA", c.COverX_mA)
+		cbor.OptBool(m, "c_over_x_term", c.COverXTerm)
+		cbor.OptInt(m, "vin_lo_mV", c.VinLo_mV)
+		cbor.OptInt(m, "vin_hi_mV", c.VinHi_mV)
+		cbor.OptInt(m, "vsys_lo_mV", c.VsysLo_mV)
+		cbor.OptInt(m, "vsys_hi_mV", c.VsysHi_mV)
+		cbor.OptInt(m, "vbat_lo_mV_per_cell", c.VbatLo_mVPerCell)
+		cbor.OptInt(m, "vbat_hi_mV_per_cell", c.VbatHi_mVPerCell)
+		cbor.OptUint(m, "ntc_ratio_hi", c.NTCRatioHi)
+		cbor.OptUint(m, "ntc_ratio_lo", c.NTCRatioLo)
+		cbor.OptInt(m, "vin_uvcl_mV", c.VinUVCL_mV)
+		if c.AlertMask != nil {
+			m.Value("alert_mask", *c.AlertMask)
+		}
+	})
+}
+
+func (c *ChargerConfigure) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "enable":
+			return cbor.ReadOptBool(d, &c.Enable)
+		case "lead_acid_temp_comp":
+			return cbor.ReadOptBool(d, &c.LeadAcidTempComp)
+		case "cfg_set":
+			return cbor.ReadOptUint(d, &c.CfgSet)
+		case "cfg_clear":
+			return cbor.ReadOptUint(d, &c.CfgClear)
+		case "iin_limit_mA":
+			return cbor.ReadOptInt(d, &c.IinLimit_mA)
+		case "icharge_target_mA":
+			return cbor.ReadOptInt(d, &c.IChargeTarget_mA)
+		case "iin_high_mA":
+			return cbor.ReadOptInt(d, &c.IinHigh_mA)
+		case "ibat_low_mA":
+			return cbor.ReadOptInt(d, &c.IbatLow_mA)
+		case "die_temp_high_mC":
+			return cbor.ReadOptInt(d, &c.DieTempHigh_mC)
+		case "bsr_high_uohm_per_cell":
+			return cbor.ReadOptUint(d, &c.BSRHigh_uOhmPerCell)
+		case "vcharge_mV_per_cell":
+			return cbor.ReadOptInt(d, &c.VCharge_mVPerCell)
+		case "max_charge_time_s":
+			return cbor.ReadOptUint(d, &c.MaxChargeTime_s)
+		case "max_cv_time_s":
+			return cbor.ReadOptUint(d, &c.MaxCVTime_s)
+		case "max_absorb_time_s":
+			return cbor.ReadOptUint(d, &c.MaxAbsorbTime_s)
+		case "c_over_x_mA":
+			return cbor.ReadOptInt(d, &c.COverX_mA)
+		case "c_over_x_term":
+			return cbor.ReadOptBool(d, &c.COverXTerm)
+		case "vin_lo_mV":
+			return cbor.ReadOptInt(d, &c.VinLo_mV)
+		case "vin_hi_mV":
+			return cbor.ReadOptInt(d, &c.VinHi_mV)
+		case "vsys_lo_mV":
+			return cbor.ReadOptInt(d, &c.VsysLo_mV)
+		case "vsys_hi_mV":
+			return cbor.ReadOptInt(d, &c.VsysHi_mV)
+		case "vbat_lo_mV_per_cell":
+			return cbor.ReadOptInt(d, &c.VbatLo_mVPerCell)
+		case "vbat_hi_mV_per_cell":
+			return cbor.ReadOptInt(d, &c.VbatHi_mVPerCell)
+		case "ntc_ratio_hi":
+			return cbor.ReadOptUint(d, &c.NTCRatioHi)
+		case "ntc_ratio_lo":
+			return cbor.ReadOptUint(d, &c.NTCRatioLo)
+		case "vin_uvcl_mV":
+			return cbor.ReadOptInt(d, &c.VinUVCL_mV)
+		case "alert_mask":
+			c.AlertMask = new(ChargerAlertMask)
+			return c.AlertMask.UnmarshalCBOR(d)
+		}
+		return d.Skip()
+	})
+}
+
+func (a ChargerAlertMask) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		cbor.OptUint(m, "limit", a.Limit)
+		cbor.OptUint(m, "chg_state", a.ChgState)
+		cbor.OptUint(m, "chg_status", a.ChgStatus)
+	})
+}
+
+func (a *ChargerAlertMask) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "limit":
+			return cbor.ReadOptUint(d, &a.Limit)
+		case "chg_state":
+			return cbor.ReadOptUint(d, &a.ChgState)
+		case "chg_status":
+			return cbor.ReadOptUint(d, &a.ChgStatus)
+		}
+		return d.Skip()
+	})
+}
+
+func (v CurrentMA) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) { m.Int("MilliA", int64(v.MilliA)) })
+}
+
+func (v *CurrentMA) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		if k == "MilliA" {
+			return cbor.ReadInt(d, &v.MilliA)
+		}
+		return d.Skip()
+	})
+}
+
+func (v VoltageMV) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) { m.Int("MilliV", int64(v.MilliV)) })
+}
+
+func (v *VoltageMV) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		if k == "MilliV" {
+			return cbor.ReadInt(d, &v.MilliV)
+		}
+		return d.Skip()
+	})
+}
+
+func (v ResistanceMicroOhmPerCell) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) { m.Uint("MicroOhmPerCell", uint64(v.MicroOhmPerCell)) })
+}
+
+func (v *ResistanceMicroOhmPerCell) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		if k == "MicroOhmPerCell" {
+			return cbor.ReadUint(d, &v.MicroOhmPerCell)
+		}
+		return d.Skip()
+	})
+}
+
+// ---- reactor ----
+
+func (c ReactorConfig) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("temp_limit_deci_c", int64(c.TempLimit_deciC))
+		m.Int("temp_hyst_deci_c", int64(c.TempHyst_deciC))
+		if c.ChgTempLimit_deciC != 0 {
+			m.Int("chg_temp_limit_deci_c", int64(c.ChgTempLimit_deciC))
+			m.Int("chg_temp_hyst_deci_c", int64(c.ChgTempHyst_deciC))
+		}
+		m.Int("pg_on_vin_mV", int64(c.PGOnVIN_mV))
+		m.Int("sag_vin_mV", int64(c.SagVIN_mV))
+		m.Int("pg_on_vbat_mV", int64(c.PGOnVBAT_mV))
+		m.Int("pg_off_hyst_mV", int64(c.PGOffHyst_mV))
+		m.Int("sag_vbat_mV", int64(c.SagVBAT_mV))
+		m.Uint("debounce_ok_ms", uint64(c.DebounceOK_ms))
+		m.Uint("stale_max_ms", uint64(c.StaleMax_ms))
+		m.Array("led", len(c.LED), func(e *cbor.Encoder, i int) { c.LED[i].MarshalCBOR(e) })
+		m.Value("self_check", c.SelfCheck)
+	})
+}
+
+func (c *ReactorConfig) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "temp_limit_deci_c":
+			return cbor.ReadInt(d, &c.TempLimit_deciC)
+		case "temp_hyst_deci_c":
+			return cbor.ReadInt(d, &c.TempHyst_deciC)
+		case "chg_temp_limit_deci_c":
+			return cbor.ReadInt(d, &c.ChgTempLimit_deciC)
+		case "chg_temp_hyst_deci_c":
+			return cbor.ReadInt(d, &c.ChgTempHyst_deciC)
+		case "pg_on_vin_mV":
+			return cbor.ReadInt(d, &c.PGOnVIN_mV)
+		case "sag_vin_mV":
+			return cbor.ReadInt(d, &c.SagVIN_mV)
+		case "pg_on_vbat_mV":
+			return cbor.ReadInt(d, &c.PGOnVBAT_mV)
+		case "pg_off_hyst_mV":
+			return cbor.ReadInt(d, &c.PGOffHyst_mV)
+		case "sag_vbat_mV":
+			return cbor.ReadInt(d, &c.SagVBAT_mV)
+		case "debounce_ok_ms":
+			return cbor.ReadUint(d, &c.DebounceOK_ms)
+		case "stale_max_ms":
+			return cbor.ReadUint(d, &c.StaleMax_ms)
+		case "led":
+			return d.Array(func(d *cbor.Decoder) error {
+				var p LEDPattern
+				err := p.UnmarshalCBOR(d)
+				c.LED = append(c.LED, p)
+				return err
+			})
+		case "self_check":
+			return c.SelfCheck.UnmarshalCBOR(d)
+		}
+		return d.Skip()
+	})
+}
@@ -0,0 +1,4636 @@
+// Code generated by cborgen; DO NOT EDIT.
+
+package types
+
+import "devicecode-go/x/cbor"
+
+func (x TestStep) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("kind", string(x.Kind))
+		m.Text("name", x.Name)
+		m.Text("rail", x.Rail)
+		m.Bool("on", x.On)
+		m.Text("topic", x.Topic)
+		m.Text("field", x.Field)
+		m.Int("min", x.Min)
+		m.Int("max", x.Max)
+		m.Uint("within_ms", uint64(x.WithinMs))
+		m.Uint("settle_ms", uint64(x.SettleMs))
+		m.Bool("continue_on_fail", x.ContinueOnFail)
+	})
+}
+
+func (x *TestStep) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "kind":
+			return cbor.ReadText(d, &x.Kind)
+		case "name":
+			return cbor.ReadText(d, &x.Name)
+		case "rail":
+			return cbor.ReadText(d, &x.Rail)
+		case "on":
+			return cbor.ReadBool(d, &x.On)
+		case "topic":
+			return cbor.ReadText(d, &x.Topic)
+		case "field":
+			return cbor.ReadText(d, &x.Field)
+		case "min":
+			return cbor.ReadInt(d, &x.Min)
+		case "max":
+			return cbor.ReadInt(d, &x.Max)
+		case "within_ms":
+			return cbor.ReadUint(d, &x.WithinMs)
+		case "settle_ms":
+			return cbor.ReadUint(d, &x.SettleMs)
+		case "continue_on_fail":
+			return cbor.ReadBool(d, &x.ContinueOnFail)
+		}
+		return d.Skip()
+	})
+}
+
+func (x TestPlan) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("name", x.Name)
+		m.Array("steps", len(x.Steps), func(e *cbor.Encoder, i int) { x.Steps[i].MarshalCBOR(e) })
+	})
+}
+
+func (x *TestPlan) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "name":
+			return cbor.ReadText(d, &x.Name)
+		case "steps":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v TestStep
+				err := v.UnmarshalCBOR(d)
+				x.Steps = append(x.Steps, v)
+				return err
+			})
+		}
+		return d.Skip()
+	})
+}
+
+func (x TestStepResult) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("i", int64(x.Index))
+		m.Text("kind", string(x.Kind))
+		m.Text("name", x.Name)
+		m.Bool("pass", x.Pass)
+		m.Int("value", x.Value)
+		m.Int("before", x.Before)
+		m.Int("after", x.After)
+		m.Uint("elapsed_ms", uint64(x.ElapsedMs))
+		m.Text("detail", x.Detail)
+	})
+}
+
+func (x *TestStepResult) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "i":
+			return cbor.ReadInt(d, &x.Index)
+		case "kind":
+			return cbor.ReadText(d, &x.Kind)
+		case "name":
+			return cbor.ReadText(d, &x.Name)
+		case "pass":
+			return cbor.ReadBool(d, &x.Pass)
+		case "value":
+			return cbor.ReadInt(d, &x.Value)
+		case "before":
+			return cbor.ReadInt(d, &x.Before)
+		case "after":
+			return cbor.ReadInt(d, &x.After)
+		case "elapsed_ms":
+			return cbor.ReadUint(d, &x.ElapsedMs)
+		case "detail":
+			return cbor.ReadText(d, &x.Detail)
+		}
+		return d.Skip()
+	})
+}
+
+func (x TestReport) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("plan", x.Plan)
+		m.Bool("pass", x.Pass)
+		m.Array("steps", len(x.Steps), func(e *cbor.Encoder, i int) { x.Steps[i].MarshalCBOR(e) })
+		m.Int("skipped", int64(x.Skipped))
+	})
+}
+
+func (x *TestReport) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "plan":
+			return cbor.ReadText(d, &x.Plan)
+		case "pass":
+			return cbor.ReadBool(d, &x.Pass)
+		case "steps":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v TestStepResult
+				err := v.UnmarshalCBOR(d)
+				x.Steps = append(x.Steps, v)
+				return err
+			})
+		case "skipped":
+			return cbor.ReadInt(d, &x.Skipped)
+		}
+		return d.Skip()
+	})
+}
+
+func (x FieldMeta) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("name", x.Name)
+		m.Text("unit", x.Unit)
+		m.Int("exp", int64(x.Exp))
+		m.Bool("has_range", x.HasRange)
+		m.Int("min", x.Min)
+		m.Int("max", x.Max)
+	})
+}
+
+func (x *FieldMeta) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "name":
+			return cbor.ReadText(d, &x.Name)
+		case "unit":
+			return cbor.ReadText(d, &x.Unit)
+		case "exp":
+			return cbor.ReadInt(d, &x.Exp)
+		case "has_range":
+			return cbor.ReadBool(d, &x.HasRange)
+		case "min":
+			return cbor.ReadInt(d, &x.Min)
+		case "max":
+			return cbor.ReadInt(d, &x.Max)
+		}
+		return d.Skip()
+	})
+}
+
+func (x CatalogEntry) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("domain", x.Domain)
+		m.Text("kind", string(x.Kind))
+		m.Text("name", x.Name)
+		m.Text("driver", x.Driver)
+		if x.Detail != nil {
+			m.Put("detail", func(e *cbor.Encoder) { putPayload(e, x.Detail) })
+		}
+		m.Array("fields", len(x.Fields), func(e *cbor.Encoder, i int) { x.Fields[i].MarshalCBOR(e) })
+		m.Array("verbs", len(x.Verbs), func(e *cbor.Encoder, i int) { x.Verbs[i].MarshalCBOR(e) })
+		m.Array("aliases", len(x.Aliases), func(e *cbor.Encoder, i int) { x.Aliases[i].MarshalCBOR(e) })
+	})
+}
+
+func (x *CatalogEntry) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "domain":
+			return cbor.ReadText(d, &x.Domain)
+		case "kind":
+			return cbor.ReadText(d, &x.Kind)
+		case "name":
+			return cbor.ReadText(d, &x.Name)
+		case "driver":
+			return cbor.ReadText(d, &x.Driver)
+		case "detail":
+			return readPayload(d, &x.Detail)
+		case "fields":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v FieldMeta
+				err := v.UnmarshalCBOR(d)
+				x.Fields = append(x.Fields, v)
+				return err
+			})
+		case "verbs":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v VerbInfo
+				err := v.UnmarshalCBOR(d)
+				x.Verbs = append(x.Verbs, v)
+				return err
+			})
+		case "aliases":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v CapabilityAddress
+				err := v.UnmarshalCBOR(d)
+				x.Aliases = append(x.Aliases, v)
+				return err
+			})
+		}
+		return d.Skip()
+	})
+}
+
+func (x Catalog) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("rev", uint64(x.Rev))
+		m.Int("ts_ns", x.TS)
+		m.Array("caps", len(x.Caps), func(e *cbor.Encoder, i int) { x.Caps[i].MarshalCBOR(e) })
+	})
+}
+
+func (x *Catalog) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "rev":
+			return cbor.ReadUint(d, &x.Rev)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		case "caps":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v CatalogEntry
+				err := v.UnmarshalCBOR(d)
+				x.Caps = append(x.Caps, v)
+				return err
+			})
+		}
+		return d.Skip()
+	})
+}
+
+func (x ThermostatInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Value("source", x.Source)
+		m.Text("field", x.Field)
+		m.Value("output", x.Output)
+		m.Bool("cool", x.Cool)
+	})
+}
+
+func (x *ThermostatInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "source":
+			return x.Source.UnmarshalCBOR(d)
+		case "field":
+			return cbor.ReadText(d, &x.Field)
+		case "output":
+			return x.Output.UnmarshalCBOR(d)
+		case "cool":
+			return cbor.ReadBool(d, &x.Cool)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ThermostatValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("enabled", x.Enabled)
+		m.Int("input", x.Input)
+		m.Int("setpoint", x.Setpoint)
+		m.Int("hysteresis", x.Hysteresis)
+		m.Bool("demand", x.Demand)
+		m.Bool("stale", x.Stale)
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *ThermostatValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "enabled":
+			return cbor.ReadBool(d, &x.Enabled)
+		case "input":
+			return cbor.ReadInt(d, &x.Input)
+		case "setpoint":
+			return cbor.ReadInt(d, &x.Setpoint)
+		case "hysteresis":
+			return cbor.ReadInt(d, &x.Hysteresis)
+		case "demand":
+			return cbor.ReadBool(d, &x.Demand)
+		case "stale":
+			return cbor.ReadBool(d, &x.Stale)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ThermostatSet) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("setpoint", x.Setpoint)
+		m.Int("hysteresis", x.Hysteresis)
+	})
+}
+
+func (x *ThermostatSet) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "setpoint":
+			return cbor.ReadInt(d, &x.Setpoint)
+		case "hysteresis":
+			return cbor.ReadInt(d, &x.Hysteresis)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ThermostatEnable) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("on", x.On)
+	})
+}
+
+func (x *ThermostatEnable) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "on":
+			return cbor.ReadBool(d, &x.On)
+		}
+		return d.Skip()
+	})
+}
+
+func (x TemperatureInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("sensor", x.Sensor)
+		m.Uint("addr", uint64(x.Addr))
+		m.Text("bus", x.Bus)
+		m.Text("rom", x.ROM)
+	})
+}
+
+func (x *TemperatureInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "sensor":
+			return cbor.ReadText(d, &x.Sensor)
+		case "addr":
+			return cbor.ReadUint(d, &x.Addr)
+		case "bus":
+			return cbor.ReadText(d, &x.Bus)
+		case "rom":
+			return cbor.ReadText(d, &x.ROM)
+		}
+		return d.Skip()
+	})
+}
+
+func (x HumidityInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("sensor", x.Sensor)
+		m.Uint("addr", uint64(x.Addr))
+		m.Text("bus", x.Bus)
+	})
+}
+
+func (x *HumidityInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "sensor":
+			return cbor.ReadText(d, &x.Sensor)
+		case "addr":
+			return cbor.ReadUint(d, &x.Addr)
+		case "bus":
+			return cbor.ReadText(d, &x.Bus)
+		}
+		return d.Skip()
+	})
+}
+
+func (x PressureInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("sensor", x.Sensor)
+		m.Uint("addr", uint64(x.Addr))
+		m.Text("bus", x.Bus)
+		m.Int("altitude_m", int64(x.AltitudeM))
+	})
+}
+
+func (x *PressureInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "sensor":
+			return cbor.ReadText(d, &x.Sensor)
+		case "addr":
+			return cbor.ReadUint(d, &x.Addr)
+		case "bus":
+			return cbor.ReadText(d, &x.Bus)
+		case "altitude_m":
+			return cbor.ReadInt(d, &x.AltitudeM)
+		}
+		return d.Skip()
+	})
+}
+
+func (x TemperatureValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("deci_c", int64(x.DeciC))
+	})
+}
+
+func (x *TemperatureValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "deci_c":
+			return cbor.ReadInt(d, &x.DeciC)
+		}
+		return d.Skip()
+	})
+}
+
+func (x HumidityValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("rh_x100", uint64(x.RHx100))
+	})
+}
+
+func (x *HumidityValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "rh_x100":
+			return cbor.ReadUint(d, &x.RHx100)
+		}
+		return d.Skip()
+	})
+}
+
+func (x PressureValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("pa", uint64(x.Pa))
+		m.Uint("sea_level_pa", uint64(x.SeaLevelPa))
+	})
+}
+
+func (x *PressureValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "pa":
+			return cbor.ReadUint(d, &x.Pa)
+		case "sea_level_pa":
+			return cbor.ReadUint(d, &x.SeaLevelPa)
+		}
+		return d.Skip()
+	})
+}
+
+func (x OneWireDiscovery) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Texts("roms", x.ROMs)
+		m.Texts("unnamed", x.Unnamed)
+		m.Texts("missing", x.Missing)
+	})
+}
+
+func (x *OneWireDiscovery) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "roms":
+			return cbor.ReadTexts(d, &x.ROMs)
+		case "unnamed":
+			return cbor.ReadTexts(d, &x.Unnamed)
+		case "missing":
+			return cbor.ReadTexts(d, &x.Missing)
+		}
+		return d.Skip()
+	})
+}
+
+func (x PositionInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("receiver", x.Receiver)
+		m.Text("bus", x.Bus)
+		m.Uint("baud", uint64(x.Baud))
+	})
+}
+
+func (x *PositionInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "receiver":
+			return cbor.ReadText(d, &x.Receiver)
+		case "bus":
+			return cbor.ReadText(d, &x.Bus)
+		case "baud":
+			return cbor.ReadUint(d, &x.Baud)
+		}
+		return d.Skip()
+	})
+}
+
+func (x PositionValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("fix", x.Fix)
+		m.Uint("quality", uint64(x.Quality))
+		m.Uint("sats", uint64(x.Sats))
+		m.Uint("hdop_x100", uint64(x.HDOPx100))
+		m.Int("lat_e7", int64(x.LatE7))
+		m.Int("lon_e7", int64(x.LonE7))
+		m.Int("alt_cm", int64(x.AltCm))
+		m.Int("speed_cmps", int64(x.SpeedCmps))
+		m.Int("course_cdeg", int64(x.CourseCdeg))
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *PositionValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "fix":
+			return cbor.ReadBool(d, &x.Fix)
+		case "quality":
+			return cbor.ReadUint(d, &x.Quality)
+		case "sats":
+			return cbor.ReadUint(d, &x.Sats)
+		case "hdop_x100":
+			return cbor.ReadUint(d, &x.HDOPx100)
+		case "lat_e7":
+			return cbor.ReadInt(d, &x.LatE7)
+		case "lon_e7":
+			return cbor.ReadInt(d, &x.LonE7)
+		case "alt_cm":
+			return cbor.ReadInt(d, &x.AltCm)
+		case "speed_cmps":
+			return cbor.ReadInt(d, &x.SpeedCmps)
+		case "course_cdeg":
+			return cbor.ReadInt(d, &x.CourseCdeg)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x TimeInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("source", x.Source)
+	})
+}
+
+func (x *TimeInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "source":
+			return cbor.ReadText(d, &x.Source)
+		}
+		return d.Skip()
+	})
+}
+
+func (x TimeValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("epoch_ms", x.EpochMs)
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *TimeValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "epoch_ms":
+			return cbor.ReadInt(d, &x.EpochMs)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ButtonInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("pin", int64(x.Pin))
+	})
+}
+
+func (x *ButtonInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "pin":
+			return cbor.ReadInt(d, &x.Pin)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ButtonValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("pressed", x.Pressed)
+	})
+}
+
+func (x *ButtonValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "pressed":
+			return cbor.ReadBool(d, &x.Pressed)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ButtonGesture) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("held_ms", uint64(x.HeldMs))
+	})
+}
+
+func (x *ButtonGesture) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "held_ms":
+			return cbor.ReadUint(d, &x.HeldMs)
+		}
+		return d.Skip()
+	})
+}
+
+func (x CounterInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("pin", int64(x.Pin))
+		m.Text("edges", x.Edges)
+	})
+}
+
+func (x *CounterInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "pin":
+			return cbor.ReadInt(d, &x.Pin)
+		case "edges":
+			return cbor.ReadText(d, &x.Edges)
+		}
+		return d.Skip()
+	})
+}
+
+func (x CounterValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("rising", uint64(x.Rising))
+		m.Uint("falling", uint64(x.Falling))
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *CounterValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "rising":
+			return cbor.ReadUint(d, &x.Rising)
+		case "falling":
+			return cbor.ReadUint(d, &x.Falling)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x LEDInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("pin", int64(x.Pin))
+	})
+}
+
+func (x *LEDInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "pin":
+			return cbor.ReadInt(d, &x.Pin)
+		}
+		return d.Skip()
+	})
+}
+
+func (x LEDValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("on", x.On)
+	})
+}
+
+func (x *LEDValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "on":
+			return cbor.ReadBool(d, &x.On)
+		}
+		return d.Skip()
+	})
+}
+
+func (x LEDSet) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("on", x.On)
+	})
+}
+
+func (x *LEDSet) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "on":
+			return cbor.ReadBool(d, &x.On)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SwitchInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("pin", int64(x.Pin))
+		m.Text("interlock", x.Interlock)
+	})
+}
+
+func (x *SwitchInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "pin":
+			return cbor.ReadInt(d, &x.Pin)
+		case "interlock":
+			return cbor.ReadText(d, &x.Interlock)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SwitchValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("on", x.On)
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *SwitchValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "on":
+			return cbor.ReadBool(d, &x.On)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SwitchSet) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("on", x.On)
+	})
+}
+
+func (x *SwitchSet) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "on":
+			return cbor.ReadBool(d, &x.On)
+		}
+		return d.Skip()
+	})
+}
+
+func (x GPIOInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("chip", x.Chip)
+		m.Text("bus", x.Bus)
+		m.Uint("addr", uint64(x.Addr))
+		m.Int("pin", int64(x.Pin))
+		m.Bool("output", x.Output)
+		m.Bool("pull", x.Pull)
+		m.Bool("active_low", x.ActiveLow)
+		m.Bool("irq", x.IRQ)
+	})
+}
+
+func (x *GPIOInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "chip":
+			return cbor.ReadText(d, &x.Chip)
+		case "bus":
+			return cbor.ReadText(d, &x.Bus)
+		case "addr":
+			return cbor.ReadUint(d, &x.Addr)
+		case "pin":
+			return cbor.ReadInt(d, &x.Pin)
+		case "output":
+			return cbor.ReadBool(d, &x.Output)
+		case "pull":
+			return cbor.ReadBool(d, &x.Pull)
+		case "active_low":
+			return cbor.ReadBool(d, &x.ActiveLow)
+		case "irq":
+			return cbor.ReadBool(d, &x.IRQ)
+		}
+		return d.Skip()
+	})
+}
+
+func (x GPIOValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("level", x.Level)
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *GPIOValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "level":
+			return cbor.ReadBool(d, &x.Level)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x GPIOSet) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("level", x.Level)
+	})
+}
+
+func (x *GPIOSet) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "level":
+			return cbor.ReadBool(d, &x.Level)
+		}
+		return d.Skip()
+	})
+}
+
+func (x PWMInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("pin", int64(x.Pin))
+		m.Text("chip", x.Chip)
+		m.Text("bus", x.Bus)
+		m.Uint("addr", uint64(x.Addr))
+		m.Int("slice", int64(x.Slice))
+		m.Text("channel", x.Channel)
+		m.Uint("freq_hz", x.FreqHz)
+		m.Uint("top", uint64(x.Top))
+		m.Bool("active_low", x.ActiveLow)
+		m.Uint("initial", uint64(x.Initial))
+	})
+}
+
+func (x *PWMInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "pin":
+			return cbor.ReadInt(d, &x.Pin)
+		case "chip":
+			return cbor.ReadText(d, &x.Chip)
+		case "bus":
+			return cbor.ReadText(d, &x.Bus)
+		case "addr":
+			return cbor.ReadUint(d, &x.Addr)
+		case "slice":
+			return cbor.ReadInt(d, &x.Slice)
+		case "channel":
+			return cbor.ReadText(d, &x.Channel)
+		case "freq_hz":
+			return cbor.ReadUint(d, &x.FreqHz)
+		case "top":
+			return cbor.ReadUint(d, &x.Top)
+		case "active_low":
+			return cbor.ReadBool(d, &x.ActiveLow)
+		case "initial":
+			return cbor.ReadUint(d, &x.Initial)
+		}
+		return d.Skip()
+	})
+}
+
+func (x PWMValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("level", uint64(x.Level))
+	})
+}
+
+func (x *PWMValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "level":
+			return cbor.ReadUint(d, &x.Level)
+		}
+		return d.Skip()
+	})
+}
+
+func (x PWMSet) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("level", uint64(x.Level))
+	})
+}
+
+func (x *PWMSet) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "level":
+			return cbor.ReadUint(d, &x.Level)
+		}
+		return d.Skip()
+	})
+}
+
+func (x PWMRamp) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("to", uint64(x.To))
+		m.Uint("duration_ms", uint64(x.DurationMs))
+		m.Uint("steps", uint64(x.Steps))
+		m.Uint("mode", uint64(x.Mode))
+	})
+}
+
+func (x *PWMRamp) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "to":
+			return cbor.ReadUint(d, &x.To)
+		case "duration_ms":
+			return cbor.ReadUint(d, &x.DurationMs)
+		case "steps":
+			return cbor.ReadUint(d, &x.Steps)
+		case "mode":
+			return cbor.ReadUint(d, &x.Mode)
+		}
+		return d.Skip()
+	})
+}
+
+func (x PWMFreq) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("requested_hz", x.RequestedHz)
+		m.Uint("effective_hz", x.EffectiveHz)
+	})
+}
+
+func (x *PWMFreq) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "requested_hz":
+			return cbor.ReadUint(d, &x.RequestedHz)
+		case "effective_hz":
+			return cbor.ReadUint(d, &x.EffectiveHz)
+		}
+		return d.Skip()
+	})
+}
+
+func (x BuzzerInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("pin", int64(x.Pin))
+		m.Texts("patterns", x.Patterns)
+	})
+}
+
+func (x *BuzzerInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "pin":
+			return cbor.ReadInt(d, &x.Pin)
+		case "patterns":
+			return cbor.ReadTexts(d, &x.Patterns)
+		}
+		return d.Skip()
+	})
+}
+
+func (x BuzzerValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("playing", x.Playing)
+		m.Text("pattern", x.Pattern)
+		m.Uint("priority", uint64(x.Priority))
+	})
+}
+
+func (x *BuzzerValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "playing":
+			return cbor.ReadBool(d, &x.Playing)
+		case "pattern":
+			return cbor.ReadText(d, &x.Pattern)
+		case "priority":
+			return cbor.ReadUint(d, &x.Priority)
+		}
+		return d.Skip()
+	})
+}
+
+func (x BuzzerTone) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("freq_hz", uint64(x.FreqHz))
+		m.Uint("duration_ms", uint64(x.DurationMs))
+	})
+}
+
+func (x *BuzzerTone) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "freq_hz":
+			return cbor.ReadUint(d, &x.FreqHz)
+		case "duration_ms":
+			return cbor.ReadUint(d, &x.DurationMs)
+		}
+		return d.Skip()
+	})
+}
+
+func (x BuzzerBeep) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("freq_hz", uint64(x.FreqHz))
+		m.Uint("duration_ms", uint64(x.DurationMs))
+		m.Uint("priority", uint64(x.Priority))
+	})
+}
+
+func (x *BuzzerBeep) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "freq_hz":
+			return cbor.ReadUint(d, &x.FreqHz)
+		case "duration_ms":
+			return cbor.ReadUint(d, &x.DurationMs)
+		case "priority":
+			return cbor.ReadUint(d, &x.Priority)
+		}
+		return d.Skip()
+	})
+}
+
+func (x BuzzerPlay) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("pattern", x.Pattern)
+		m.Array("tones", len(x.Tones), func(e *cbor.Encoder, i int) { x.Tones[i].MarshalCBOR(e) })
+		m.Uint("repeat", uint64(x.Repeat))
+		m.Uint("priority", uint64(x.Priority))
+	})
+}
+
+func (x *BuzzerPlay) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "pattern":
+			return cbor.ReadText(d, &x.Pattern)
+		case "tones":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v BuzzerTone
+				err := v.UnmarshalCBOR(d)
+				x.Tones = append(x.Tones, v)
+				return err
+			})
+		case "repeat":
+			return cbor.ReadUint(d, &x.Repeat)
+		case "priority":
+			return cbor.ReadUint(d, &x.Priority)
+		}
+		return d.Skip()
+	})
+}
+
+func (x BuzzerStop) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("priority", uint64(x.Priority))
+	})
+}
+
+func (x *BuzzerStop) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "priority":
+			return cbor.ReadUint(d, &x.Priority)
+		}
+		return d.Skip()
+	})
+}
+
+func (x LEDStripInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("pin", int64(x.Pin))
+		m.Uint("count", uint64(x.Count))
+		m.Text("order", x.Order)
+		m.Text("sm", x.SM)
+	})
+}
+
+func (x *LEDStripInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "pin":
+			return cbor.ReadInt(d, &x.Pin)
+		case "count":
+			return cbor.ReadUint(d, &x.Count)
+		case "order":
+			return cbor.ReadText(d, &x.Order)
+		case "sm":
+			return cbor.ReadText(d, &x.SM)
+		}
+		return d.Skip()
+	})
+}
+
+func (x LEDStripValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("lit", uint64(x.Lit))
+		m.Uint("brightness", uint64(x.Brightness))
+	})
+}
+
+func (x *LEDStripValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "lit":
+			return cbor.ReadUint(d, &x.Lit)
+		case "brightness":
+			return cbor.ReadUint(d, &x.Brightness)
+		}
+		return d.Skip()
+	})
+}
+
+func (x LEDStripSet) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("start", uint64(x.Start))
+		m.Array("colors", len(x.Colors), func(e *cbor.Encoder, i int) { e.Uint(uint64(x.Colors[i])) })
+	})
+}
+
+func (x *LEDStripSet) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "start":
+			return cbor.ReadUint(d, &x.Start)
+		case "colors":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v uint32
+				err := cbor.ReadUint(d, &v)
+				x.Colors = append(x.Colors, v)
+				return err
+			})
+		}
+		return d.Skip()
+	})
+}
+
+func (x LEDStripFill) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("color", uint64(x.Color))
+		m.Uint("start", uint64(x.Start))
+		m.Uint("count", uint64(x.Count))
+	})
+}
+
+func (x *LEDStripFill) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "color":
+			return cbor.ReadUint(d, &x.Color)
+		case "start":
+			return cbor.ReadUint(d, &x.Start)
+		case "count":
+			return cbor.ReadUint(d, &x.Count)
+		}
+		return d.Skip()
+	})
+}
+
+func (x LEDStripBrightness) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("level", uint64(x.Level))
+	})
+}
+
+func (x *LEDStripBrightness) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "level":
+			return cbor.ReadUint(d, &x.Level)
+		}
+		return d.Skip()
+	})
+}
+
+func (x HALState) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("level", x.Level)
+		m.Text("status", x.Status)
+		m.Int("ts_ns", x.TS)
+		m.Array("errors", len(x.Errors), func(e *cbor.Encoder, i int) { x.Errors[i].MarshalCBOR(e) })
+	})
+}
+
+func (x *HALState) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "level":
+			return cbor.ReadText(d, &x.Level)
+		case "status":
+			return cbor.ReadText(d, &x.Status)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		case "errors":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v ConfigError
+				err := v.UnmarshalCBOR(d)
+				x.Errors = append(x.Errors, v)
+				return err
+			})
+		}
+		return d.Skip()
+	})
+}
+
+func (x ConfigError) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("id", x.ID)
+		m.Text("type", x.Type)
+		m.Text("error", x.Error)
+		m.Text("detail", x.Detail)
+	})
+}
+
+func (x *ConfigError) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "id":
+			return cbor.ReadText(d, &x.ID)
+		case "type":
+			return cbor.ReadText(d, &x.Type)
+		case "error":
+			return cbor.ReadText(d, &x.Error)
+		case "detail":
+			return cbor.ReadText(d, &x.Detail)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ResourceMap) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("ts_ns", x.TS)
+		m.Array("pins", len(x.Pins), func(e *cbor.Encoder, i int) { x.Pins[i].MarshalCBOR(e) })
+		m.Array("buses", len(x.Buses), func(e *cbor.Encoder, i int) { x.Buses[i].MarshalCBOR(e) })
+	})
+}
+
+func (x *ResourceMap) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		case "pins":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v PinClaim
+				err := v.UnmarshalCBOR(d)
+				x.Pins = append(x.Pins, v)
+				return err
+			})
+		case "buses":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v BusClaim
+				err := v.UnmarshalCBOR(d)
+				x.Buses = append(x.Buses, v)
+				return err
+			})
+		}
+		return d.Skip()
+	})
+}
+
+func (x PinClaim) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("pin", int64(x.Pin))
+		m.Text("func", x.Func)
+		m.Text("owner", x.Owner)
+	})
+}
+
+func (x *PinClaim) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "pin":
+			return cbor.ReadInt(d, &x.Pin)
+		case "func":
+			return cbor.ReadText(d, &x.Func)
+		case "owner":
+			return cbor.ReadText(d, &x.Owner)
+		}
+		return d.Skip()
+	})
+}
+
+func (x BusClaim) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("id", x.ID)
+		m.Text("class", x.Class)
+		m.Texts("users", x.Users)
+		m.Text("error", x.Error)
+	})
+}
+
+func (x *BusClaim) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "id":
+			return cbor.ReadText(d, &x.ID)
+		case "class":
+			return cbor.ReadText(d, &x.Class)
+		case "users":
+			return cbor.ReadTexts(d, &x.Users)
+		case "error":
+			return cbor.ReadText(d, &x.Error)
+		}
+		return d.Skip()
+	})
+}
+
+func (x CapabilityStatus) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("link", string(x.Link))
+		m.Int("ts_ns", x.TS)
+		m.Text("error", x.Error)
+		m.Uint("backoff", uint64(x.Backoff))
+	})
+}
+
+func (x *CapabilityStatus) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "link":
+			return cbor.ReadText(d, &x.Link)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		case "error":
+			return cbor.ReadText(d, &x.Error)
+		case "backoff":
+			return cbor.ReadUint(d, &x.Backoff)
+		}
+		return d.Skip()
+	})
+}
+
+func (x PollStart) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("verb", x.Verb)
+		m.Uint("interval_ms", uint64(x.IntervalMs))
+		m.Uint("jitter_ms", uint64(x.JitterMs))
+		cbor.OptUint(m, "phase_ms", x.PhaseMs)
+	})
+}
+
+func (x *PollStart) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "verb":
+			return cbor.ReadText(d, &x.Verb)
+		case "interval_ms":
+			return cbor.ReadUint(d, &x.IntervalMs)
+		case "jitter_ms":
+			return cbor.ReadUint(d, &x.JitterMs)
+		case "phase_ms":
+			return cbor.ReadOptUint(d, &x.PhaseMs)
+		}
+		return d.Skip()
+	})
+}
+
+func (x PollStop) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("verb", x.Verb)
+	})
+}
+
+func (x *PollStop) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "verb":
+			return cbor.ReadText(d, &x.Verb)
+		}
+		return d.Skip()
+	})
+}
+
+func (x PollBurst) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("verb", x.Verb)
+		m.Uint("rate_ms", uint64(x.RateMs))
+		m.Uint("duration_ms", uint64(x.DurationMs))
+	})
+}
+
+func (x *PollBurst) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "verb":
+			return cbor.ReadText(d, &x.Verb)
+		case "rate_ms":
+			return cbor.ReadUint(d, &x.RateMs)
+		case "duration_ms":
+			return cbor.ReadUint(d, &x.DurationMs)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ReadSync) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("verb", x.Verb)
+		m.Uint("timeout_ms", uint64(x.TimeoutMs))
+	})
+}
+
+func (x *ReadSync) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "verb":
+			return cbor.ReadText(d, &x.Verb)
+		case "timeout_ms":
+			return cbor.ReadUint(d, &x.TimeoutMs)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ControlDone) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("verb", x.Verb)
+		m.Bool("ok", x.OK)
+		m.Text("error", x.Error)
+		m.Uint("code", uint64(x.Code))
+		m.Text("detail", x.Detail)
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *ControlDone) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "verb":
+			return cbor.ReadText(d, &x.Verb)
+		case "ok":
+			return cbor.ReadBool(d, &x.OK)
+		case "error":
+			return cbor.ReadText(d, &x.Error)
+		case "code":
+			return cbor.ReadUint(d, &x.Code)
+		case "detail":
+			return cbor.ReadText(d, &x.Detail)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x Quarantine) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("verb", x.Verb)
+		m.Uint("strikes", uint64(x.Strikes))
+		m.Uint("for_ms", uint64(x.ForMs))
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *Quarantine) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "verb":
+			return cbor.ReadText(d, &x.Verb)
+		case "strikes":
+			return cbor.ReadUint(d, &x.Strikes)
+		case "for_ms":
+			return cbor.ReadUint(d, &x.ForMs)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x EventStorm) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("active", x.Active)
+		m.Uint("rate", uint64(x.Rate))
+		m.Uint("limit", uint64(x.Limit))
+		m.Uint("dropped", uint64(x.Dropped))
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *EventStorm) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "active":
+			return cbor.ReadBool(d, &x.Active)
+		case "rate":
+			return cbor.ReadUint(d, &x.Rate)
+		case "limit":
+			return cbor.ReadUint(d, &x.Limit)
+		case "dropped":
+			return cbor.ReadUint(d, &x.Dropped)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ConfigBlob) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("format", x.Format)
+		m.Uint("size", uint64(x.Size))
+		m.Uint("crc32", uint64(x.CRC32))
+		m.Blob("data", x.Data)
+	})
+}
+
+func (x *ConfigBlob) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "format":
+			return cbor.ReadText(d, &x.Format)
+		case "size":
+			return cbor.ReadUint(d, &x.Size)
+		case "crc32":
+			return cbor.ReadUint(d, &x.CRC32)
+		case "data":
+			b, err := d.Blob()
+			x.Data = b
+			return err
+		}
+		return d.Skip()
+	})
+}
+
+func (x ConfigImport) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Value("blob", x.Blob)
+		m.Bool("apply", x.Apply)
+	})
+}
+
+func (x *ConfigImport) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "blob":
+			return x.Blob.UnmarshalCBOR(d)
+		case "apply":
+			return cbor.ReadBool(d, &x.Apply)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ConfigStaged) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("crc32", uint64(x.CRC32))
+		m.Uint("size", uint64(x.Size))
+		m.Int("devices", int64(x.Devices))
+		m.Bool("reactor", x.Reactor)
+		m.Bool("stored", x.Stored)
+		m.Bool("applied", x.Applied)
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *ConfigStaged) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "crc32":
+			return cbor.ReadUint(d, &x.CRC32)
+		case "size":
+			return cbor.ReadUint(d, &x.Size)
+		case "devices":
+			return cbor.ReadInt(d, &x.Devices)
+		case "reactor":
+			return cbor.ReadBool(d, &x.Reactor)
+		case "stored":
+			return cbor.ReadBool(d, &x.Stored)
+		case "applied":
+			return cbor.ReadBool(d, &x.Applied)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x NVGet) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("key", x.Key)
+	})
+}
+
+func (x *NVGet) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "key":
+			return cbor.ReadText(d, &x.Key)
+		}
+		return d.Skip()
+	})
+}
+
+func (x NVPut) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("key", x.Key)
+		m.Blob("data", x.Data)
+	})
+}
+
+func (x *NVPut) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "key":
+			return cbor.ReadText(d, &x.Key)
+		case "data":
+			b, err := d.Blob()
+			x.Data = b
+			return err
+		}
+		return d.Skip()
+	})
+}
+
+func (x NVRecord) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("key", x.Key)
+		m.Bool("found", x.Found)
+		m.Blob("data", x.Data)
+	})
+}
+
+func (x *NVRecord) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "key":
+			return cbor.ReadText(d, &x.Key)
+		case "found":
+			return cbor.ReadBool(d, &x.Found)
+		case "data":
+			b, err := d.Blob()
+			x.Data = b
+			return err
+		}
+		return d.Skip()
+	})
+}
+
+func (x LastLog) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("found", x.Found)
+		m.Int("ts_ns", x.TS)
+		m.Blob("data", x.Data)
+	})
+}
+
+func (x *LastLog) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "found":
+			return cbor.ReadBool(d, &x.Found)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		case "data":
+			b, err := d.Blob()
+			x.Data = b
+			return err
+		}
+		return d.Skip()
+	})
+}
+
+func (x Transform) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("kind", string(x.Kind))
+		m.Text("field", x.Field)
+		m.Text("op", string(x.Op))
+		m.Int("num", x.Num)
+		m.Int("den", x.Den)
+		m.Int("add", x.Add)
+		m.Int("min", x.Min)
+		m.Int("max", x.Max)
+		m.Text("unit", x.Unit)
+	})
+}
+
+func (x *Transform) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "kind":
+			return cbor.ReadText(d, &x.Kind)
+		case "field":
+			return cbor.ReadText(d, &x.Field)
+		case "op":
+			return cbor.ReadText(d, &x.Op)
+		case "num":
+			return cbor.ReadInt(d, &x.Num)
+		case "den":
+			return cbor.ReadInt(d, &x.Den)
+		case "add":
+			return cbor.ReadInt(d, &x.Add)
+		case "min":
+			return cbor.ReadInt(d, &x.Min)
+		case "max":
+			return cbor.ReadInt(d, &x.Max)
+		case "unit":
+			return cbor.ReadText(d, &x.Unit)
+		}
+		return d.Skip()
+	})
+}
+
+func (x AlarmState) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("name", x.Name)
+		m.Bool("active", x.Active)
+		m.Int("value", x.Value)
+		m.Value("source", x.Source)
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *AlarmState) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "name":
+			return cbor.ReadText(d, &x.Name)
+		case "active":
+			return cbor.ReadBool(d, &x.Active)
+		case "value":
+			return cbor.ReadInt(d, &x.Value)
+		case "source":
+			return x.Source.UnmarshalCBOR(d)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x GroupSpec) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("name", x.Name)
+		m.Array("members", len(x.Members), func(e *cbor.Encoder, i int) { x.Members[i].MarshalCBOR(e) })
+		m.Uint("stale_ms", uint64(x.StaleMs))
+	})
+}
+
+func (x *GroupSpec) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "name":
+			return cbor.ReadText(d, &x.Name)
+		case "members":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v CapabilityAddress
+				err := v.UnmarshalCBOR(d)
+				x.Members = append(x.Members, v)
+				return err
+			})
+		case "stale_ms":
+			return cbor.ReadUint(d, &x.StaleMs)
+		}
+		return d.Skip()
+	})
+}
+
+func (x CapAlias) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Value("alias", x.Alias)
+		m.Value("target", x.Target)
+	})
+}
+
+func (x *CapAlias) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "alias":
+			return x.Alias.UnmarshalCBOR(d)
+		case "target":
+			return x.Target.UnmarshalCBOR(d)
+		}
+		return d.Skip()
+	})
+}
+
+func (x GroupState) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("name", x.Name)
+		m.Text("link", string(x.Link))
+		m.Array("offenders", len(x.Offenders), func(e *cbor.Encoder, i int) { x.Offenders[i].MarshalCBOR(e) })
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *GroupState) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "name":
+			return cbor.ReadText(d, &x.Name)
+		case "link":
+			return cbor.ReadText(d, &x.Link)
+		case "offenders":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v GroupOffender
+				err := v.UnmarshalCBOR(d)
+				x.Offenders = append(x.Offenders, v)
+				return err
+			})
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x GroupOffender) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Value("cap", x.Cap)
+		m.Text("error", x.Error)
+	})
+}
+
+func (x *GroupOffender) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "cap":
+			return x.Cap.UnmarshalCBOR(d)
+		case "error":
+			return cbor.ReadText(d, &x.Error)
+		}
+		return d.Skip()
+	})
+}
+
+func (x PowerSet) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("mode", string(x.Mode))
+	})
+}
+
+func (x *PowerSet) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "mode":
+			return cbor.ReadText(d, &x.Mode)
+		}
+		return d.Skip()
+	})
+}
+
+func (x PowerState) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("mode", string(x.Mode))
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *PowerState) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "mode":
+			return cbor.ReadText(d, &x.Mode)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x OKReply) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("ok", x.OK)
+	})
+}
+
+func (x *OKReply) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "ok":
+			return cbor.ReadBool(d, &x.OK)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ErrorReply) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("ok", x.OK)
+		m.Text("error", x.Error)
+		m.Uint("code", uint64(x.Code))
+		m.Text("verb", x.Verb)
+		m.Text("detail", x.Detail)
+		m.Text("field", x.Field)
+		m.Bool("retryable", x.Retryable)
+	})
+}
+
+func (x *ErrorReply) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "ok":
+			return cbor.ReadBool(d, &x.OK)
+		case "error":
+			return cbor.ReadText(d, &x.Error)
+		case "code":
+			return cbor.ReadUint(d, &x.Code)
+		case "verb":
+			return cbor.ReadText(d, &x.Verb)
+		case "detail":
+			return cbor.ReadText(d, &x.Detail)
+		case "field":
+			return cbor.ReadText(d, &x.Field)
+		case "retryable":
+			return cbor.ReadBool(d, &x.Retryable)
+		}
+		return d.Skip()
+	})
+}
+
+func (x BroadcastReply) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("ok", x.OK)
+		m.Array("results", len(x.Results), func(e *cbor.Encoder, i int) { x.Results[i].MarshalCBOR(e) })
+	})
+}
+
+func (x *BroadcastReply) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "ok":
+			return cbor.ReadBool(d, &x.OK)
+		case "results":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v BroadcastResult
+				err := v.UnmarshalCBOR(d)
+				x.Results = append(x.Results, v)
+				return err
+			})
+		}
+		return d.Skip()
+	})
+}
+
+func (x BroadcastResult) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Value("address", x.Address)
+		m.Bool("ok", x.OK)
+		m.Text("error", x.Error)
+	})
+}
+
+func (x *BroadcastResult) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "address":
+			return x.Address.UnmarshalCBOR(d)
+		case "ok":
+			return cbor.ReadBool(d, &x.OK)
+		case "error":
+			return cbor.ReadText(d, &x.Error)
+		}
+		return d.Skip()
+	})
+}
+
+func (x Info) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("schema_version", int64(x.SchemaVersion))
+		m.Text("driver", x.Driver)
+		if x.Detail != nil {
+			m.Put("detail", func(e *cbor.Encoder) { putPayload(e, x.Detail) })
+		}
+	})
+}
+
+func (x *Info) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "schema_version":
+			return cbor.ReadInt(d, &x.SchemaVersion)
+		case "driver":
+			return cbor.ReadText(d, &x.Driver)
+		case "detail":
+			return readPayload(d, &x.Detail)
+		}
+		return d.Skip()
+	})
+}
+
+func (x VerbInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("verb", x.Verb)
+		m.Text("payload", x.Payload)
+	})
+}
+
+func (x *VerbInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "verb":
+			return cbor.ReadText(d, &x.Verb)
+		case "payload":
+			return cbor.ReadText(d, &x.Payload)
+		}
+		return d.Skip()
+	})
+}
+
+func (x CapabilityVerbs) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Array("verbs", len(x.Verbs), func(e *cbor.Encoder, i int) { x.Verbs[i].MarshalCBOR(e) })
+	})
+}
+
+func (x *CapabilityVerbs) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "verbs":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v VerbInfo
+				err := v.UnmarshalCBOR(d)
+				x.Verbs = append(x.Verbs, v)
+				return err
+			})
+		}
+		return d.Skip()
+	})
+}
+
+func (x BootAction) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("verb", x.Verb)
+		if x.Payload != nil {
+			m.Put("payload", func(e *cbor.Encoder) { putPayload(e, x.Payload) })
+		}
+	})
+}
+
+func (x *BootAction) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "verb":
+			return cbor.ReadText(d, &x.Verb)
+		case "payload":
+			return readPayload(d, &x.Payload)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ModemInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("bus", x.Bus)
+		m.Uint("baud", uint64(x.Baud))
+	})
+}
+
+func (x *ModemInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "bus":
+			return cbor.ReadText(d, &x.Bus)
+		case "baud":
+			return cbor.ReadUint(d, &x.Baud)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ModemValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("ready", x.Ready)
+		m.Text("sim", x.SIM)
+		m.Bool("registered", x.Registered)
+		m.Bool("roaming", x.Roaming)
+		m.Uint("csq", uint64(x.CSQ))
+		m.Int("rssi_dbm", int64(x.RSSIdBm))
+		m.Bool("data", x.Data)
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *ModemValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "ready":
+			return cbor.ReadBool(d, &x.Ready)
+		case "sim":
+			return cbor.ReadText(d, &x.SIM)
+		case "registered":
+			return cbor.ReadBool(d, &x.Registered)
+		case "roaming":
+			return cbor.ReadBool(d, &x.Roaming)
+		case "csq":
+			return cbor.ReadUint(d, &x.CSQ)
+		case "rssi_dbm":
+			return cbor.ReadInt(d, &x.RSSIdBm)
+		case "data":
+			return cbor.ReadBool(d, &x.Data)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ModemIdentity) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("model", x.Model)
+		m.Text("revision", x.Revision)
+		m.Text("imei", x.IMEI)
+	})
+}
+
+func (x *ModemIdentity) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "model":
+			return cbor.ReadText(d, &x.Model)
+		case "revision":
+			return cbor.ReadText(d, &x.Revision)
+		case "imei":
+			return cbor.ReadText(d, &x.IMEI)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ModemCommand) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("cmd", x.Cmd)
+		m.Uint("timeout_ms", uint64(x.TimeoutMs))
+	})
+}
+
+func (x *ModemCommand) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "cmd":
+			return cbor.ReadText(d, &x.Cmd)
+		case "timeout_ms":
+			return cbor.ReadUint(d, &x.TimeoutMs)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ModemResponse) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("cmd", x.Cmd)
+		m.Bool("ok", x.OK)
+		m.Texts("lines", x.Lines)
+	})
+}
+
+func (x *ModemResponse) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "cmd":
+			return cbor.ReadText(d, &x.Cmd)
+		case "ok":
+			return cbor.ReadBool(d, &x.OK)
+		case "lines":
+			return cbor.ReadTexts(d, &x.Lines)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ModemSessionOpen) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("dial", x.Dial)
+		m.Int("rx_size", int64(x.RXSize))
+		m.Int("tx_size", int64(x.TXSize))
+	})
+}
+
+func (x *ModemSessionOpen) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "dial":
+			return cbor.ReadBool(d, &x.Dial)
+		case "rx_size":
+			return cbor.ReadInt(d, &x.RXSize)
+		case "tx_size":
+			return cbor.ReadInt(d, &x.TXSize)
+		}
+		return d.Skip()
+	})
+}
+
+func (x BatteryInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("cells", uint64(x.Cells))
+		m.Text("chem", x.Chem)
+		m.Uint("rsnsb_uohm", uint64(x.RSNSB_uOhm))
+		m.Text("bus", x.Bus)
+		m.Uint("addr", uint64(x.Addr))
+	})
+}
+
+func (x *BatteryInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "cells":
+			return cbor.ReadUint(d, &x.Cells)
+		case "chem":
+			return cbor.ReadText(d, &x.Chem)
+		case "rsnsb_uohm":
+			return cbor.ReadUint(d, &x.RSNSB_uOhm)
+		case "bus":
+			return cbor.ReadText(d, &x.Bus)
+		case "addr":
+			return cbor.ReadUint(d, &x.Addr)
+		}
+		return d.Skip()
+	})
+}
+
+func (x BatteryValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("pack_mV", int64(x.PackMilliV))
+		m.Int("per_cell_mV", int64(x.PerCellMilliV))
+		m.Int("ibat_mA", int64(x.IBatMilliA))
+		m.Int("temp_mC", int64(x.TempMilliC))
+		m.Uint("bsr_uohm_per_cell", uint64(x.BSR_uOhmPerCell))
+	})
+}
+
+func (x *BatteryValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "pack_mV":
+			return cbor.ReadInt(d, &x.PackMilliV)
+		case "per_cell_mV":
+			return cbor.ReadInt(d, &x.PerCellMilliV)
+		case "ibat_mA":
+			return cbor.ReadInt(d, &x.IBatMilliA)
+		case "temp_mC":
+			return cbor.ReadInt(d, &x.TempMilliC)
+		case "bsr_uohm_per_cell":
+			return cbor.ReadUint(d, &x.BSR_uOhmPerCell)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ChargerInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("rsnsi_uohm", uint64(x.RSNSI_uOhm))
+		m.Text("bus", x.Bus)
+		m.Uint("addr", uint64(x.Addr))
+	})
+}
+
+func (x *ChargerInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "rsnsi_uohm":
+			return cbor.ReadUint(d, &x.RSNSI_uOhm)
+		case "bus":
+			return cbor.ReadText(d, &x.Bus)
+		case "addr":
+			return cbor.ReadUint(d, &x.Addr)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ChargerValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("vin_mV", int64(x.VIN_mV))
+		m.Int("vsys_mV", int64(x.VSYS_mV))
+		m.Int("iin_mA", int64(x.IIn_mA))
+		m.Uint("state", uint64(x.State))
+		m.Uint("status", uint64(x.Status))
+		m.Uint("sys", uint64(x.Sys))
+	})
+}
+
+func (x *ChargerValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "vin_mV":
+			return cbor.ReadInt(d, &x.VIN_mV)
+		case "vsys_mV":
+			return cbor.ReadInt(d, &x.VSYS_mV)
+		case "iin_mA":
+			return cbor.ReadInt(d, &x.IIn_mA)
+		case "state":
+			return cbor.ReadUint(d, &x.State)
+		case "status":
+			return cbor.ReadUint(d, &x.Status)
+		case "sys":
+			return cbor.ReadUint(d, &x.Sys)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SystemPowerInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("charger", x.Charger)
+		m.Text("battery", x.Battery)
+	})
+}
+
+func (x *SystemPowerInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "charger":
+			return cbor.ReadText(d, &x.Charger)
+		case "battery":
+			return cbor.ReadText(d, &x.Battery)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SystemPowerValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("isys_mA", int64(x.ISys_mA))
+		m.Int("pin_mW", int64(x.PIn_mW))
+		m.Int("pbat_mW", int64(x.PBat_mW))
+		m.Int("psys_mW", int64(x.PSys_mW))
+		m.Uint("eff_pct", uint64(x.EffPct))
+	})
+}
+
+func (x *SystemPowerValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "isys_mA":
+			return cbor.ReadInt(d, &x.ISys_mA)
+		case "pin_mW":
+			return cbor.ReadInt(d, &x.PIn_mW)
+		case "pbat_mW":
+			return cbor.ReadInt(d, &x.PBat_mW)
+		case "psys_mW":
+			return cbor.ReadInt(d, &x.PSys_mW)
+		case "eff_pct":
+			return cbor.ReadUint(d, &x.EffPct)
+		}
+		return d.Skip()
+	})
+}
+
+func (x EnergyInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("source", x.Source)
+		m.Int("days", int64(x.Days))
+		m.Bool("persisted", x.Persisted)
+	})
+}
+
+func (x *EnergyInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "source":
+			return cbor.ReadText(d, &x.Source)
+		case "days":
+			return cbor.ReadInt(d, &x.Days)
+		case "persisted":
+			return cbor.ReadBool(d, &x.Persisted)
+		}
+		return d.Skip()
+	})
+}
+
+func (x EnergyTotals) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("in_mWh", uint64(x.In_mWh))
+		m.Uint("to_batt_mWh", uint64(x.ToBatt_mWh))
+		m.Uint("from_batt_mWh", uint64(x.FromBatt_mWh))
+		m.Uint("to_load_mWh", uint64(x.ToLoad_mWh))
+	})
+}
+
+func (x *EnergyTotals) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "in_mWh":
+			return cbor.ReadUint(d, &x.In_mWh)
+		case "to_batt_mWh":
+			return cbor.ReadUint(d, &x.ToBatt_mWh)
+		case "from_batt_mWh":
+			return cbor.ReadUint(d, &x.FromBatt_mWh)
+		case "to_load_mWh":
+			return cbor.ReadUint(d, &x.ToLoad_mWh)
+		}
+		return d.Skip()
+	})
+}
+
+func (x EnergyValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("day", int64(x.Day))
+		m.Uint("in_mWh", uint64(x.In_mWh))
+		m.Uint("to_batt_mWh", uint64(x.ToBatt_mWh))
+		m.Uint("from_batt_mWh", uint64(x.FromBatt_mWh))
+		m.Uint("to_load_mWh", uint64(x.ToLoad_mWh))
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *EnergyValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "day":
+			return cbor.ReadInt(d, &x.Day)
+		case "in_mWh":
+			return cbor.ReadUint(d, &x.In_mWh)
+		case "to_batt_mWh":
+			return cbor.ReadUint(d, &x.ToBatt_mWh)
+		case "from_batt_mWh":
+			return cbor.ReadUint(d, &x.FromBatt_mWh)
+		case "to_load_mWh":
+			return cbor.ReadUint(d, &x.ToLoad_mWh)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x EnergyHistory) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("day", int64(x.Day))
+		m.Array("days", len(x.Days), func(e *cbor.Encoder, i int) { x.Days[i].MarshalCBOR(e) })
+		m.Value("week", x.Week)
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *EnergyHistory) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "day":
+			return cbor.ReadInt(d, &x.Day)
+		case "days":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v EnergyTotals
+				err := v.UnmarshalCBOR(d)
+				x.Days = append(x.Days, v)
+				return err
+			})
+		case "week":
+			return x.Week.UnmarshalCBOR(d)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x RailInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("sensor", x.Sensor)
+		m.Text("bus", x.Bus)
+		m.Uint("addr", uint64(x.Addr))
+		m.Uint("channel", uint64(x.Channel))
+		m.Uint("shunt_uohm", uint64(x.Shunt_uOhm))
+	})
+}
+
+func (x *RailInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "sensor":
+			return cbor.ReadText(d, &x.Sensor)
+		case "bus":
+			return cbor.ReadText(d, &x.Bus)
+		case "addr":
+			return cbor.ReadUint(d, &x.Addr)
+		case "channel":
+			return cbor.ReadUint(d, &x.Channel)
+		case "shunt_uohm":
+			return cbor.ReadUint(d, &x.Shunt_uOhm)
+		}
+		return d.Skip()
+	})
+}
+
+func (x RailValue) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("bus_mV", int64(x.Bus_mV))
+		m.Int("shunt_uV", int64(x.Shunt_uV))
+		m.Int("i_mA", int64(x.I_mA))
+		m.Int("p_mW", int64(x.P_mW))
+		m.Uint("alert", uint64(x.Alert))
+	})
+}
+
+func (x *RailValue) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "bus_mV":
+			return cbor.ReadInt(d, &x.Bus_mV)
+		case "shunt_uV":
+			return cbor.ReadInt(d, &x.Shunt_uV)
+		case "i_mA":
+			return cbor.ReadInt(d, &x.I_mA)
+		case "p_mW":
+			return cbor.ReadInt(d, &x.P_mW)
+		case "alert":
+			return cbor.ReadUint(d, &x.Alert)
+		}
+		return d.Skip()
+	})
+}
+
+func (x RailLimits) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("warn_mA", int64(x.Warn_mA))
+		m.Int("crit_mA", int64(x.Crit_mA))
+	})
+}
+
+func (x *RailLimits) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "warn_mA":
+			return cbor.ReadInt(d, &x.Warn_mA)
+		case "crit_mA":
+			return cbor.ReadInt(d, &x.Crit_mA)
+		}
+		return d.Skip()
+	})
+}
+
+func (x RailAlert) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("level", x.Level)
+		m.Int("i_mA", int64(x.I_mA))
+	})
+}
+
+func (x *RailAlert) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "level":
+			return cbor.ReadText(d, &x.Level)
+		case "i_mA":
+			return cbor.ReadInt(d, &x.I_mA)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ChargerEnable) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("On", x.On)
+	})
+}
+
+func (x *ChargerEnable) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "On":
+			return cbor.ReadBool(d, &x.On)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SetInputLimit) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("MilliA", int64(x.MilliA))
+	})
+}
+
+func (x *SetInputLimit) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "MilliA":
+			return cbor.ReadInt(d, &x.MilliA)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SetChargeTarget) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("MilliA", int64(x.MilliA))
+	})
+}
+
+func (x *SetChargeTarget) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "MilliA":
+			return cbor.ReadInt(d, &x.MilliA)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SetVinWindow) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("Lo_mV", int64(x.Lo_mV))
+		m.Int("Hi_mV", int64(x.Hi_mV))
+	})
+}
+
+func (x *SetVinWindow) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Lo_mV":
+			return cbor.ReadInt(d, &x.Lo_mV)
+		case "Hi_mV":
+			return cbor.ReadInt(d, &x.Hi_mV)
+		}
+		return d.Skip()
+	})
+}
+
+func (x BSRResult) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("bsr_uohm_per_cell", uint64(x.BSR_uOhmPerCell))
+		m.Int("icharge_mA", int64(x.ICharge_mA))
+		m.Array("trend", len(x.Trend), func(e *cbor.Encoder, i int) { e.Uint(uint64(x.Trend[i])) })
+	})
+}
+
+func (x *BSRResult) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "bsr_uohm_per_cell":
+			return cbor.ReadUint(d, &x.BSR_uOhmPerCell)
+		case "icharge_mA":
+			return cbor.ReadInt(d, &x.ICharge_mA)
+		case "trend":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v uint32
+				err := cbor.ReadUint(d, &v)
+				x.Trend = append(x.Trend, v)
+				return err
+			})
+		}
+		return d.Skip()
+	})
+}
+
+func (x SolarSweep) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("from_mV", int64(x.From_mV))
+		m.Int("to_mV", int64(x.To_mV))
+		m.Int("step_mV", int64(x.Step_mV))
+		m.Uint("settle_ms", uint64(x.Settle_ms))
+		m.Int("iin_limit_mA", int64(x.IinLimit_mA))
+	})
+}
+
+func (x *SolarSweep) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "from_mV":
+			return cbor.ReadInt(d, &x.From_mV)
+		case "to_mV":
+			return cbor.ReadInt(d, &x.To_mV)
+		case "step_mV":
+			return cbor.ReadInt(d, &x.Step_mV)
+		case "settle_ms":
+			return cbor.ReadUint(d, &x.Settle_ms)
+		case "iin_limit_mA":
+			return cbor.ReadInt(d, &x.IinLimit_mA)
+		}
+		return d.Skip()
+	})
+}
+
+func (x IVPoint) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("uvcl_mV", int64(x.UVCL_mV))
+		m.Int("vin_mV", int64(x.VIN_mV))
+		m.Int("iin_mA", int64(x.IIN_mA))
+	})
+}
+
+func (x *IVPoint) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "uvcl_mV":
+			return cbor.ReadInt(d, &x.UVCL_mV)
+		case "vin_mV":
+			return cbor.ReadInt(d, &x.VIN_mV)
+		case "iin_mA":
+			return cbor.ReadInt(d, &x.IIN_mA)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SolarSweepResult) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Array("points", len(x.Points), func(e *cbor.Encoder, i int) { x.Points[i].MarshalCBOR(e) })
+		m.Int("vmp_mV", int64(x.Vmp_mV))
+		m.Int("imp_mA", int64(x.Imp_mA))
+		m.Int("pmax_mW", int64(x.Pmax_mW))
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *SolarSweepResult) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "points":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v IVPoint
+				err := v.UnmarshalCBOR(d)
+				x.Points = append(x.Points, v)
+				return err
+			})
+		case "vmp_mV":
+			return cbor.ReadInt(d, &x.Vmp_mV)
+		case "imp_mA":
+			return cbor.ReadInt(d, &x.Imp_mA)
+		case "pmax_mW":
+			return cbor.ReadInt(d, &x.Pmax_mW)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x VinCollapseWarning) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("vin_mV", int64(x.VIN_mV))
+		m.Int("slope_mVps", int64(x.Slope_mVps))
+	})
+}
+
+func (x *VinCollapseWarning) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "vin_mV":
+			return cbor.ReadInt(d, &x.VIN_mV)
+		case "slope_mVps":
+			return cbor.ReadInt(d, &x.Slope_mVps)
+		}
+		return d.Skip()
+	})
+}
+
+func (x VinWindowSet) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("Lo_mV", int64(x.Lo_mV))
+		m.Int("Hi_mV", int64(x.Hi_mV))
+	})
+}
+
+func (x *VinWindowSet) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Lo_mV":
+			return cbor.ReadInt(d, &x.Lo_mV)
+		case "Hi_mV":
+			return cbor.ReadInt(d, &x.Hi_mV)
+		}
+		return d.Skip()
+	})
+}
+
+func (x VbatWindowSet) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("Lo_mVPerCell", int64(x.Lo_mVPerCell))
+		m.Int("Hi_mVPerCell", int64(x.Hi_mVPerCell))
+	})
+}
+
+func (x *VbatWindowSet) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Lo_mVPerCell":
+			return cbor.ReadInt(d, &x.Lo_mVPerCell)
+		case "Hi_mVPerCell":
+			return cbor.ReadInt(d, &x.Hi_mVPerCell)
+		}
+		return d.Skip()
+	})
+}
+
+func (x VsysWindowSet) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("Lo_mV", int64(x.Lo_mV))
+		m.Int("Hi_mV", int64(x.Hi_mV))
+	})
+}
+
+func (x *VsysWindowSet) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Lo_mV":
+			return cbor.ReadInt(d, &x.Lo_mV)
+		case "Hi_mV":
+			return cbor.ReadInt(d, &x.Hi_mV)
+		}
+		return d.Skip()
+	})
+}
+
+func (x TempMilliC) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("MilliC", int64(x.MilliC))
+	})
+}
+
+func (x *TempMilliC) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "MilliC":
+			return cbor.ReadInt(d, &x.MilliC)
+		}
+		return d.Skip()
+	})
+}
+
+func (x NTCRatioWindowRaw) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("Hi", uint64(x.Hi))
+		m.Uint("Lo", uint64(x.Lo))
+	})
+}
+
+func (x *NTCRatioWindowRaw) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Hi":
+			return cbor.ReadUint(d, &x.Hi)
+		case "Lo":
+			return cbor.ReadUint(d, &x.Lo)
+		}
+		return d.Skip()
+	})
+}
+
+func (x DurationS) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("Seconds", uint64(x.Seconds))
+	})
+}
+
+func (x *DurationS) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Seconds":
+			return cbor.ReadUint(d, &x.Seconds)
+		}
+		return d.Skip()
+	})
+}
+
+func (x COverXSet) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("threshold_mA", int64(x.Threshold_mA))
+		m.Bool("term", x.Term)
+	})
+}
+
+func (x *COverXSet) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "threshold_mA":
+			return cbor.ReadInt(d, &x.Threshold_mA)
+		case "term":
+			return cbor.ReadBool(d, &x.Term)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ChargerConfigBitsUpdate) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("Set", uint64(x.Set))
+		m.Uint("Clear", uint64(x.Clear))
+	})
+}
+
+func (x *ChargerConfigBitsUpdate) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "Set":
+			return cbor.ReadUint(d, &x.Set)
+		case "Clear":
+			return cbor.ReadUint(d, &x.Clear)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SerialSessionOpen) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("rx_size", int64(x.RXSize))
+		m.Int("tx_size", int64(x.TXSize))
+		m.Uint("idle_timeout_ms", uint64(x.IdleTimeoutMs))
+	})
+}
+
+func (x *SerialSessionOpen) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "rx_size":
+			return cbor.ReadInt(d, &x.RXSize)
+		case "tx_size":
+			return cbor.ReadInt(d, &x.TXSize)
+		case "idle_timeout_ms":
+			return cbor.ReadUint(d, &x.IdleTimeoutMs)
+		}
+		return d.Skip()
+	})
+}
+
+func (_ SerialSessionClose) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+	})
+}
+
+func (_ *SerialSessionClose) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(_ string, d *cbor.Decoder) error { return d.Skip() })
+}
+
+func (x SerialSetBaud) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("baud", uint64(x.Baud))
+	})
+}
+
+func (x *SerialSetBaud) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "baud":
+			return cbor.ReadUint(d, &x.Baud)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SerialSetFormat) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("data_bits", uint64(x.DataBits))
+		m.Uint("stop_bits", uint64(x.StopBits))
+		m.Uint("parity", uint64(x.Parity))
+	})
+}
+
+func (x *SerialSetFormat) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "data_bits":
+			return cbor.ReadUint(d, &x.DataBits)
+		case "stop_bits":
+			return cbor.ReadUint(d, &x.StopBits)
+		case "parity":
+			return cbor.ReadUint(d, &x.Parity)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SerialSetFlowControl) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("enabled", x.Enabled)
+	})
+}
+
+func (x *SerialSetFlowControl) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "enabled":
+			return cbor.ReadBool(d, &x.Enabled)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SerialSessionOpened) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("session_id", uint64(x.SessionID))
+		m.Uint("rx_handle", uint64(x.RXHandle))
+		m.Uint("tx_handle", uint64(x.TXHandle))
+	})
+}
+
+func (x *SerialSessionOpened) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "session_id":
+			return cbor.ReadUint(d, &x.SessionID)
+		case "rx_handle":
+			return cbor.ReadUint(d, &x.RXHandle)
+		case "tx_handle":
+			return cbor.ReadUint(d, &x.TXHandle)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SerialSessionExpired) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("session_id", uint64(x.SessionID))
+		m.Uint("idle_ms", uint64(x.IdleMs))
+	})
+}
+
+func (x *SerialSessionExpired) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "session_id":
+			return cbor.ReadUint(d, &x.SessionID)
+		case "idle_ms":
+			return cbor.ReadUint(d, &x.IdleMs)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SerialStats) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("rx_overruns", uint64(x.RXOverruns))
+	})
+}
+
+func (x *SerialStats) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "rx_overruns":
+			return cbor.ReadUint(d, &x.RXOverruns)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SerialLoopback) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("duration_ms", uint64(x.DurationMs))
+		m.Text("pattern", x.Pattern)
+	})
+}
+
+func (x *SerialLoopback) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "duration_ms":
+			return cbor.ReadUint(d, &x.DurationMs)
+		case "pattern":
+			return cbor.ReadText(d, &x.Pattern)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SerialLoopbackResult) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("pattern", x.Pattern)
+		m.Uint("duration_ms", uint64(x.DurationMs))
+		m.Uint("tx_bytes", uint64(x.TXBytes))
+		m.Uint("rx_bytes", uint64(x.RXBytes))
+		m.Uint("byte_errors", uint64(x.ByteErrors))
+		m.Uint("bit_errors", uint64(x.BitErrors))
+		m.Uint("bits_checked", uint64(x.BitsChecked))
+		m.Uint("rx_bytes_per_s", uint64(x.RXBytesPerS))
+	})
+}
+
+func (x *SerialLoopbackResult) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "pattern":
+			return cbor.ReadText(d, &x.Pattern)
+		case "duration_ms":
+			return cbor.ReadUint(d, &x.DurationMs)
+		case "tx_bytes":
+			return cbor.ReadUint(d, &x.TXBytes)
+		case "rx_bytes":
+			return cbor.ReadUint(d, &x.RXBytes)
+		case "byte_errors":
+			return cbor.ReadUint(d, &x.ByteErrors)
+		case "bit_errors":
+			return cbor.ReadUint(d, &x.BitErrors)
+		case "bits_checked":
+			return cbor.ReadUint(d, &x.BitsChecked)
+		case "rx_bytes_per_s":
+			return cbor.ReadUint(d, &x.RXBytesPerS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SerialInfo) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("bus", x.Bus)
+		m.Uint("baud", uint64(x.Baud))
+	})
+}
+
+func (x *SerialInfo) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "bus":
+			return cbor.ReadText(d, &x.Bus)
+		case "baud":
+			return cbor.ReadUint(d, &x.Baud)
+		}
+		return d.Skip()
+	})
+}
+
+func (x PassthruStart) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("a", x.A)
+		m.Text("b", x.B)
+		m.Text("escape", x.Escape)
+		m.Uint("idle_timeout_ms", uint64(x.IdleTimeoutMs))
+	})
+}
+
+func (x *PassthruStart) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "a":
+			return cbor.ReadText(d, &x.A)
+		case "b":
+			return cbor.ReadText(d, &x.B)
+		case "escape":
+			return cbor.ReadText(d, &x.Escape)
+		case "idle_timeout_ms":
+			return cbor.ReadUint(d, &x.IdleTimeoutMs)
+		}
+		return d.Skip()
+	})
+}
+
+func (x PassthruState) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("active", x.Active)
+		m.Text("a", x.A)
+		m.Text("b", x.B)
+		m.Uint("a_to_b", uint64(x.AToB))
+		m.Uint("b_to_a", uint64(x.BToA))
+		m.Text("ended", x.Ended)
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *PassthruState) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "active":
+			return cbor.ReadBool(d, &x.Active)
+		case "a":
+			return cbor.ReadText(d, &x.A)
+		case "b":
+			return cbor.ReadText(d, &x.B)
+		case "a_to_b":
+			return cbor.ReadUint(d, &x.AToB)
+		case "b_to_a":
+			return cbor.ReadUint(d, &x.BToA)
+		case "ended":
+			return cbor.ReadText(d, &x.Ended)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x MetricsSnapshot) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("ts_ns", x.TS)
+		m.Array("metrics", len(x.Metrics), func(e *cbor.Encoder, i int) { x.Metrics[i].MarshalCBOR(e) })
+	})
+}
+
+func (x *MetricsSnapshot) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		case "metrics":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v MetricSample
+				err := v.UnmarshalCBOR(d)
+				x.Metrics = append(x.Metrics, v)
+				return err
+			})
+		}
+		return d.Skip()
+	})
+}
+
+func (x MetricSample) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("name", x.Name)
+		m.Int("v", x.Value)
+		m.Array("bounds", len(x.Bounds), func(e *cbor.Encoder, i int) { e.Int(int64(x.Bounds[i])) })
+		m.Array("buckets", len(x.Buckets), func(e *cbor.Encoder, i int) { e.Uint(uint64(x.Buckets[i])) })
+	})
+}
+
+func (x *MetricSample) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "name":
+			return cbor.ReadText(d, &x.Name)
+		case "v":
+			return cbor.ReadInt(d, &x.Value)
+		case "bounds":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v int64
+				err := cbor.ReadInt(d, &v)
+				x.Bounds = append(x.Bounds, v)
+				return err
+			})
+		case "buckets":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v uint32
+				err := cbor.ReadUint(d, &v)
+				x.Buckets = append(x.Buckets, v)
+				return err
+			})
+		}
+		return d.Skip()
+	})
+}
+
+func (x ServiceState) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("name", x.Name)
+		m.Text("state", string(x.State))
+		m.Uint("restarts", uint64(x.Restarts))
+		m.Text("error", x.Error)
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *ServiceState) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "name":
+			return cbor.ReadText(d, &x.Name)
+		case "state":
+			return cbor.ReadText(d, &x.State)
+		case "restarts":
+			return cbor.ReadUint(d, &x.Restarts)
+		case "error":
+			return cbor.ReadText(d, &x.Error)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x TimeSet) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Int("epoch_ms", x.EpochMs)
+	})
+}
+
+func (x *TimeSet) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "epoch_ms":
+			return cbor.ReadInt(d, &x.EpochMs)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SysTime) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Bool("synced", x.Synced)
+		m.Text("source", x.Source)
+		m.Int("epoch_ms", x.EpochMs)
+		m.Int("offset_ms", x.OffsetMs)
+		m.Int("step_ms", x.StepMs)
+		m.Uint("syncs", uint64(x.Syncs))
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *SysTime) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "synced":
+			return cbor.ReadBool(d, &x.Synced)
+		case "source":
+			return cbor.ReadText(d, &x.Source)
+		case "epoch_ms":
+			return cbor.ReadInt(d, &x.EpochMs)
+		case "offset_ms":
+			return cbor.ReadInt(d, &x.OffsetMs)
+		case "step_ms":
+			return cbor.ReadInt(d, &x.StepMs)
+		case "syncs":
+			return cbor.ReadUint(d, &x.Syncs)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x ReactorIncidents) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Uint("over_temp", uint64(x.OverTemp))
+		m.Uint("emergency_down", uint64(x.EmergencyDown))
+		m.Bool("persisted", x.Persisted)
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *ReactorIncidents) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "over_temp":
+			return cbor.ReadUint(d, &x.OverTemp)
+		case "emergency_down":
+			return cbor.ReadUint(d, &x.EmergencyDown)
+		case "persisted":
+			return cbor.ReadBool(d, &x.Persisted)
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SelfCheckConfig) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Array("required", len(x.Required), func(e *cbor.Encoder, i int) { x.Required[i].MarshalCBOR(e) })
+		m.Int("min_vbat_mV", int64(x.MinVBAT_mV))
+		m.Array("temps", len(x.Temps), func(e *cbor.Encoder, i int) { x.Temps[i].MarshalCBOR(e) })
+		m.Int("temp_tol_deci_c", int64(x.TempTol_deciC))
+		m.Uint("timeout_ms", uint64(x.Timeout_ms))
+	})
+}
+
+func (x *SelfCheckConfig) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "required":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v CapabilityAddress
+				err := v.UnmarshalCBOR(d)
+				x.Required = append(x.Required, v)
+				return err
+			})
+		case "min_vbat_mV":
+			return cbor.ReadInt(d, &x.MinVBAT_mV)
+		case "temps":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v CapabilityAddress
+				err := v.UnmarshalCBOR(d)
+				x.Temps = append(x.Temps, v)
+				return err
+			})
+		case "temp_tol_deci_c":
+			return cbor.ReadInt(d, &x.TempTol_deciC)
+		case "timeout_ms":
+			return cbor.ReadUint(d, &x.Timeout_ms)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SelfCheckResult) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("state", x.State)
+		m.Array("checks", len(x.Checks), func(e *cbor.Encoder, i int) { x.Checks[i].MarshalCBOR(e) })
+		m.Int("ts_ns", x.TS)
+	})
+}
+
+func (x *SelfCheckResult) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "state":
+			return cbor.ReadText(d, &x.State)
+		case "checks":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v SelfCheckItem
+				err := v.UnmarshalCBOR(d)
+				x.Checks = append(x.Checks, v)
+				return err
+			})
+		case "ts_ns":
+			return cbor.ReadInt(d, &x.TS)
+		}
+		return d.Skip()
+	})
+}
+
+func (x SelfCheckItem) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("check", x.Check)
+		m.Bool("ok", x.OK)
+		m.Int("value", int64(x.Value))
+		m.Text("detail", x.Detail)
+	})
+}
+
+func (x *SelfCheckItem) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "check":
+			return cbor.ReadText(d, &x.Check)
+		case "ok":
+			return cbor.ReadBool(d, &x.OK)
+		case "value":
+			return cbor.ReadInt(d, &x.Value)
+		case "detail":
+			return cbor.ReadText(d, &x.Detail)
+		}
+		return d.Skip()
+	})
+}
+
+func (x LEDPattern) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("state", x.State)
+		m.Array("steps_ms", len(x.Steps_ms), func(e *cbor.Encoder, i int) { e.Uint(uint64(x.Steps_ms[i])) })
+	})
+}
+
+func (x *LEDPattern) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "state":
+			return cbor.ReadText(d, &x.State)
+		case "steps_ms":
+			return d.Array(func(d *cbor.Decoder) error {
+				var v uint16
+				err := cbor.ReadUint(d, &v)
+				x.Steps_ms = append(x.Steps_ms, v)
+				return err
+			})
+		}
+		return d.Skip()
+	})
+}
+
+func (x LogConfig) MarshalCBOR(e *cbor.Encoder) {
+	e.Map(func(m *cbor.Map) {
+		m.Text("level", string(x.Level))
+	})
+}
+
+func (x *LogConfig) UnmarshalCBOR(d *cbor.Decoder) error {
+	return d.Map(func(k string, d *cbor.Decoder) error {
+		switch k {
+		case "level":
+			return cbor.ReadText(d, &x.Level)
+		}
+		return d.Skip()
+	})
+}
+
+// cborPayloads rebuilds a payload from CBOR by type name.
+var cborPayloads = map[string]func(d *cbor.Decoder) (any, error){
+	"TestStep":                  decodeAs[TestStep],
+	"TestPlan":                  decodeAs[TestPlan],
+	"TestStepResult":            decodeAs[TestStepResult],
+	"TestReport":                decodeAs[TestReport],
+	"CapabilityAddress":         decodeAs[CapabilityAddress],
+	"FieldMeta":                 decodeAs[FieldMeta],
+	"CatalogEntry":              decodeAs[CatalogEntry],
+	"Catalog":                   decodeAs[Catalog],
+	"ThermostatInfo":            decodeAs[ThermostatInfo],
+	"ThermostatValue":           decodeAs[ThermostatValue],
+	"ThermostatSet":             decodeAs[ThermostatSet],
+	"ThermostatEnable":          decodeAs[ThermostatEnable],
+	"TemperatureInfo":           decodeAs[TemperatureInfo],
+	"HumidityInfo":              decodeAs[HumidityInfo],
+	"PressureInfo":              decodeAs[PressureInfo],
+	"TemperatureValue":          decodeAs[TemperatureValue],
+	"HumidityValue":             decodeAs[HumidityValue],
+	"PressureValue":             decodeAs[PressureValue],
+	"OneWireDiscovery":          decodeAs[OneWireDiscovery],
+	"PositionInfo":              decodeAs[PositionInfo],
+	"PositionValue":             decodeAs[PositionValue],
+	"TimeInfo":                  decodeAs[TimeInfo],
+	"TimeValue":                 decodeAs[TimeValue],
+	"ButtonInfo":                decodeAs[ButtonInfo],
+	"ButtonValue":               decodeAs[ButtonValue],
+	"ButtonGesture":             decodeAs[ButtonGesture],
+	"CounterInfo":               decodeAs[CounterInfo],
+	"CounterValue":              decodeAs[CounterValue],
+	"LEDInfo":                   decodeAs[LEDInfo],
+	"LEDValue":                  decodeAs[LEDValue],
+	"LEDSet":                    decodeAs[LEDSet],
+	"SwitchInfo":                decodeAs[SwitchInfo],
+	"SwitchValue":               decodeAs[SwitchValue],
+	"SwitchSet":                 decodeAs[SwitchSet],
+	"GPIOInfo":                  decodeAs[GPIOInfo],
+	"GPIOValue":                 decodeAs[GPIOValue],
+	"GPIOSet":                   decodeAs[GPIOSet],
+	"PWMInfo":                   decodeAs[PWMInfo],
+	"PWMValue":                  decodeAs[PWMValue],
+	"PWMSet":                    decodeAs[PWMSet],
+	"PWMRamp":                   decodeAs[PWMRamp],
+	"PWMFreq":                   decodeAs[PWMFreq],
+	"BuzzerInfo":                decodeAs[BuzzerInfo],
+	"BuzzerValue":               decodeAs[BuzzerValue],
+	"BuzzerTone":                decodeAs[BuzzerTone],
+	"BuzzerBeep":                decodeAs[BuzzerBeep],
+	"BuzzerPlay":                decodeAs[BuzzerPlay],
+	"BuzzerStop":                decodeAs[BuzzerStop],
+	"LEDStripInfo":              decodeAs[LEDStripInfo],
+	"LEDStripValue":             decodeAs[LEDStripValue],
+	"LEDStripSet":               decodeAs[LEDStripSet],
+	"LEDStripFill":              decodeAs[LEDStripFill],
+	"LEDStripBrightness":        decodeAs[LEDStripBrightness],
+	"HALState":                  decodeAs[HALState],
+	"ConfigError":               decodeAs[ConfigError],
+	"ResourceMap":               decodeAs[ResourceMap],
+	"PinClaim":                  decodeAs[PinClaim],
+	"BusClaim":                  decodeAs[BusClaim],
+	"CapabilityStatus":          decodeAs[CapabilityStatus],
+	"PollStart":                 decodeAs[PollStart],
+	"PollStop":                  decodeAs[PollStop],
+	"PollBurst":                 decodeAs[PollBurst],
+	"ReadSync":                  decodeAs[ReadSync],
+	"ControlDone":               decodeAs[ControlDone],
+	"Quarantine":                decodeAs[Quarantine],
+	"EventStorm":                decodeAs[EventStorm],
+	"PollSpec":                  decodeAs[PollSpec],
+	"ConfigBlob":                decodeAs[ConfigBlob],
+	"ConfigImport":              decodeAs[ConfigImport],
+	"ConfigStaged":              decodeAs[ConfigStaged],
+	"NVGet":                     decodeAs[NVGet],
+	"NVPut":                     decodeAs[NVPut],
+	"NVRecord":                  decodeAs[NVRecord],
+	"LastLog":                   decodeAs[LastLog],
+	"BusPlan":                   decodeAs[BusPlan],
+	"I2CBus":                    decodeAs[I2CBus],
+	"UARTBus":                   decodeAs[UARTBus],
+	"OneWireBus":                decodeAs[OneWireBus],
+	"Transform":                 decodeAs[Transform],
+	"AlarmSpec":                 decodeAs[AlarmSpec],
+	"AlarmState":                decodeAs[AlarmState],
+	"GroupSpec":                 decodeAs[GroupSpec],
+	"CapAlias":                  decodeAs[CapAlias],
+	"GroupState":                decodeAs[GroupState],
+	"GroupOffender":             decodeAs[GroupOffender],
+	"PowerSet":                  decodeAs[PowerSet],
+	"PowerState":                decodeAs[PowerState],
+	"OKReply":                   decodeAs[OKReply],
+	"ErrorReply":                decodeAs[ErrorReply],
+	"BroadcastReply":            decodeAs[BroadcastReply],
+	"BroadcastResult":           decodeAs[BroadcastResult],
+	"Info":                      decodeAs[Info],
+	"VerbInfo":                  decodeAs[VerbInfo],
+	"CapabilityVerbs":           decodeAs[CapabilityVerbs],
+	"BootAction":                decodeAs[BootAction],
+	"ModemInfo":                 decodeAs[ModemInfo],
+	"ModemValue":                decodeAs[ModemValue],
+	"ModemIdentity":             decodeAs[ModemIdentity],
+	"ModemCommand":              decodeAs[ModemCommand],
+	"ModemResponse":             decodeAs[ModemResponse],
+	"ModemSessionOpen":          decodeAs[ModemSessionOpen],
+	"BatteryInfo":               decodeAs[BatteryInfo],
+	"BatteryValue":              decodeAs[BatteryValue],
+	"ChargerInfo":               decodeAs[ChargerInfo],
+	"ChargerValue":              decodeAs[ChargerValue],
+	"SystemPowerInfo":           decodeAs[SystemPowerInfo],
+	"SystemPowerValue":          decodeAs[SystemPowerValue],
+	"EnergyInfo":                decodeAs[EnergyInfo],
+	"EnergyTotals":              decodeAs[EnergyTotals],
+	"EnergyValue":               decodeAs[EnergyValue],
+	"EnergyHistory":             decodeAs[EnergyHistory],
+	"RailInfo":                  decodeAs[RailInfo],
+	"RailValue":                 decodeAs[RailValue],
+	"RailLimits":                decodeAs[RailLimits],
+	"RailAlert":                 decodeAs[RailAlert],
+	"ChargerEnable":             decodeAs[ChargerEnable],
+	"SetInputLimit":             decodeAs[SetInputLimit],
+	"SetChargeTarget":           decodeAs[SetChargeTarget],
+	"SetVinWindow":              decodeAs[SetVinWindow],
+	"ChargerConfigure":          decodeAs[ChargerConfigure],
+	"ChargerAlertMask":          decodeAs[ChargerAlertMask],
+	"BSRResult":                 decodeAs[BSRResult],
+	"SolarSweep":                decodeAs[SolarSweep],
+	"IVPoint":                   decodeAs[IVPoint],
+	"SolarSweepResult":          decodeAs[SolarSweepResult],
+	"VinCollapseWarning":        decodeAs[VinCollapseWarning],
+	"VinWindowSet":              decodeAs[VinWindowSet],
+	"VbatWindowSet":             decodeAs[VbatWindowSet],
+	"VsysWindowSet":             decodeAs[VsysWindowSet],
+	"CurrentMA":                 decodeAs[CurrentMA],
+	"VoltageMV":                 decodeAs[VoltageMV],
+	"TempMilliC":                decodeAs[TempMilliC],
+	"ResistanceMicroOhmPerCell": decodeAs[ResistanceMicroOhmPerCell],
+	"NTCRatioWindowRaw":         decodeAs[NTCRatioWindowRaw],
+	"DurationS":                 decodeAs[DurationS],
+	"COverXSet":                 decodeAs[COverXSet],
+	"ChargerConfigBitsUpdate":   decodeAs[ChargerConfigBitsUpdate],
+	"SerialSessionOpen":         decodeAs[SerialSessionOpen],
+	"SerialSessionClose":        decodeAs[SerialSessionClose],
+	"SerialSetBaud":             decodeAs[SerialSetBaud],
+	"SerialSetFormat":           decodeAs[SerialSetFormat],
+	"SerialSetFlowControl":      decodeAs[SerialSetFlowControl],
+	"SerialSessionOpened":       decodeAs[SerialSessionOpened],
+	"SerialSessionExpired":      decodeAs[SerialSessionExpired],
+	"SerialStats":               decodeAs[SerialStats],
+	"SerialLoopback":            decodeAs[SerialLoopback],
+	"SerialLoopbackResult":      decodeAs[SerialLoopbackResult],
+	"SerialInfo":                decodeAs[SerialInfo],
+	"PassthruStart":             decodeAs[PassthruStart],
+	"PassthruState":             decodeAs[PassthruState],
+	"MetricsSnapshot":           decodeAs[MetricsSnapshot],
+	"MetricSample":              decodeAs[MetricSample],
+	"ServiceState":              decodeAs[ServiceState],
+	"TimeSet":                   decodeAs[TimeSet],
+	"SysTime":                   decodeAs[SysTime],
+	"ReactorIncidents":          decodeAs[ReactorIncidents],
+	"ReactorConfig":             decodeAs[ReactorConfig],
+	"SelfCheckConfig":           decodeAs[SelfCheckConfig],
+	"SelfCheckResult":           decodeAs[SelfCheckResult],
+	"SelfCheckItem":             decodeAs[SelfCheckItem],
+	"LEDPattern":                decodeAs[LEDPattern],
+	"LogConfig":                 decodeAs[LogConfig],
+}
+
+// cborMarshaler returns the type name and encoder of a payload held
+// as a value or a non-nil pointer.
+func cborMarshaler(v any) (string, cbor.Marshaler, bool) {
+	switch p := v.(type) {
+	case TestStep:
+		return "TestStep", p, true
+	case *TestStep:
+		if p != nil {
+			return "TestStep", *p, true
+		}
+	case TestPlan:
+		return "TestPlan", p, true
+	case *TestPlan:
+		if p != nil {
+			return "TestPlan", *p, true
+		}
+	case TestStepResult:
+		return "TestStepResult", p, true
+	case *TestStepResult:
+		if p != nil {
+			return "TestStepResult", *p, true
+		}
+	case TestReport:
+		return "TestReport", p, true
+	case *TestReport:
+		if p != nil {
+			return "TestReport", *p, true
+		}
+	case CapabilityAddress:
+		return "CapabilityAddress", p, true
+	case *CapabilityAddress:
+		if p != nil {
+			return "CapabilityAddress", *p, true
+		}
+	case FieldMeta:
+		return "FieldMeta", p, true
+	case *FieldMeta:
+		if p != nil {
+			return "FieldMeta", *p, true
+		}
+	case CatalogEntry:
+		return "CatalogEntry", p, true
+	case *CatalogEntry:
+		if p != nil {
+			return "CatalogEntry", *p, true
+		}
+	case Catalog:
+		return "Catalog", p, true
+	case *Catalog:
+		if p != nil {
+			return "Catalog", *p, true
+		}
+	case ThermostatInfo:
+		return "ThermostatInfo", p, true
+	case *ThermostatInfo:
+		if p != nil {
+			return "ThermostatInfo", *p, true
+		}
+	case ThermostatValue:
+		return "ThermostatValue", p, true
+	case *ThermostatValue:
+		if p != nil {
+			return "ThermostatValue", *p, true
+		}
+	case ThermostatSet:
+		return "ThermostatSet", p, true
+	case *ThermostatSet:
+		if p != nil {
+			return "ThermostatSet", *p, true
+		}
+	case ThermostatEnable:
+		return "ThermostatEnable", p, true
+	case *ThermostatEnable:
+		if p != nil {
+			return "ThermostatEnable", *p, true
+		}
+	case TemperatureInfo:
+		return "TemperatureInfo", p, true
+	case *TemperatureInfo:
+		if p != nil {
+			return "TemperatureInfo", *p, true
+		}
+	case HumidityInfo:
+		return "HumidityInfo", p, true
+	case *HumidityInfo:
+		if p != nil {
+			return "HumidityInfo", *p, true
+		}
+	case PressureInfo:
+		return "PressureInfo", p, true
+	case *PressureInfo:
+		if p != nil {
+			return "PressureInfo", *p, true
+		}
+	case TemperatureValue:
+		return "TemperatureValue", p, true
+	case *TemperatureValue:
+		if p != nil {
+			return "TemperatureValue", *p, true
+		}
+	case HumidityValue:
+		return "HumidityValue", p, true
+	case *HumidityValue:
+		if p != nil {
+			return "HumidityValue", *p, true
+		}
+	case PressureValue:
+		return "PressureValue", p, true
+	case *PressureValue:
+		if p != nil {
+			return "PressureValue", *p, true
+		}
+	case OneWireDiscovery:
+		return "OneWireDiscovery", p, true
+	case *OneWireDiscovery:
+		if p != nil {
+			return "OneWireDiscovery", *p, true
+		}
+	case PositionInfo:
+		return "PositionInfo", p, true
+	case *PositionInfo:
+		if p != nil {
+			return "PositionInfo", *p, true
+		}
+	case PositionValue:
+		return "PositionValue", p, true
+	case *PositionValue:
+		if p != nil {
+			return "PositionValue", *p, true
+		}
+	case TimeInfo:
+		return "TimeInfo", p, true
+	case *TimeInfo:
+		if p != nil {
+			return "TimeInfo", *p, true
+		}
+	case TimeValue:
+		return "TimeValue", p, true
+	case *TimeValue:
+		if p != nil {
+			return "TimeValue", *p, true
+		}
+	case ButtonInfo:
+		return "ButtonInfo", p, true
+	case *ButtonInfo:
+		if p != nil {
+			return "ButtonInfo", *p, true
+		}
+	case ButtonValue:
+		return "ButtonValue", p, true
+	case *ButtonValue:
+		if p != nil {
+			return "ButtonValue", *p, true
+		}
+	case ButtonGesture:
+		return "ButtonGesture", p, true
+	case *ButtonGesture:
+		if p != nil {
+			return "ButtonGesture", *p, true
+		}
+	case CounterInfo:
+		return "CounterInfo", p, true
+	case *CounterInfo:
+		if p != nil {
+			return "CounterInfo", *p, true
+		}
+	case CounterValue:
+		return "CounterValue", p, true
+	case *CounterValue:
+		if p != nil {
+			return "CounterValue", *p, true
+		}
+	case LEDInfo:
+		return "LEDInfo", p, true
+	case *LEDInfo:
+		if p != nil {
+			return "LEDInfo", *p, true
+		}
+	case LEDValue:
+		return "LEDValue", p, true
+	case *LEDValue:
+		if p != nil {
+			return "LEDValue", *p, true
+		}
+	case LEDSet:
+		return "LEDSet", p, true
+	case *LEDSet:
+		if p != nil {
+			return "LEDSet", *p, true
+		}
+	case SwitchInfo:
+		return "SwitchInfo", p, true
+	case *SwitchInfo:
+		if p != nil {
+			return "SwitchInfo", *p, true
+		}
+	case SwitchValue:
+		return "SwitchValue", p, true
+	case *SwitchValue:
+		if p != nil {
+			return "SwitchValue", *p, true
+		}
+	case SwitchSet:
+		return "SwitchSet", p, true
+	case *SwitchSet:
+		if p != nil {
+			return "SwitchSet", *p, true
+		}
+	case GPIOInfo:
+		return "GPIOInfo", p, true
+	case *GPIOInfo:
+		if p != nil {
+			return "GPIOInfo", *p, true
+		}
+	case GPIOValue:
+		return "GPIOValue", p, true
+	case *GPIOValue:
+		if p != nil {
+			return "GPIOValue", *p, true
+		}
+	case GPIOSet:
+		return "GPIOSet", p, true
+	case *GPIOSet:
+		if p != nil {
+			return "GPIOSet", *p, true
+		}
+	case PWMInfo:
+		return "PWMInfo", p, true
+	case *PWMInfo:
+		if p != nil {
+			return "PWMInfo", *p, true
+		}
+	case PWMValue:
+		return "PWMValue", p, true
+	case *PWMValue:
+		if p != nil {
+			return "PWMValue", *p, true
+		}
+	case PWMSet:
+		return "PWMSet", p, true
+	case *PWMSet:
+		if p != nil {
+			return "PWMSet", *p, true
+		}
+	case PWMRamp:
+		return "PWMRamp", p, true
+	case *PWMRamp:
+		if p != nil {
+			return "PWMRamp", *p, true
+		}
+	case PWMFreq:
+		return "PWMFreq", p, true
+	case *PWMFreq:
+		if p != nil {
+			return "PWMFreq", *p, true
+		}
+	case BuzzerInfo:
+		return "BuzzerInfo", p, true
+	case *BuzzerInfo:
+		if p != nil {
+			return "BuzzerInfo", *p, true
+		}
+	case BuzzerValue:
+		return "BuzzerValue", p, true
+	case *BuzzerValue:
+		if p != nil {
+			return "BuzzerValue", *p, true
+		}
+	case BuzzerTone:
+		return "BuzzerTone", p, true
+	case *BuzzerTone:
+		if p != nil {
+			return "BuzzerTone", *p, true
+		}
+	case BuzzerBeep:
+		return "BuzzerBeep", p, true
+	case *BuzzerBeep:
+		if p != nil {
+			return "BuzzerBeep", *p, true
+		}
+	case BuzzerPlay:
+		return "BuzzerPlay", p, true
+	case *BuzzerPlay:
+		if p != nil {
+			return "BuzzerPlay", *p, true
+		}
+	case BuzzerStop:
+		return "BuzzerStop", p, true
+	case *BuzzerStop:
+		if p != nil {
+			return "BuzzerStop", *p, true
+		}
+	case LEDStripInfo:
+		return "LEDStripInfo", p, true
+	case *LEDStripInfo:
+		if p != nil {
+			return "LEDStripInfo", *p, true
+		}
+	case LEDStripValue:
+		return "LEDStripValue", p, true
+	case *LEDStripValue:
+		if p != nil {
+			return "LEDStripValue", *p, true
+		}
+	case LEDStripSet:
+		return "LEDStripSet", p, true
+	case *LEDStripSet:
+		if p != nil {
+			return "LEDStripSet", *p, true
+		}
+	case LEDStripFill:
+		return "LEDStripFill", p, true
+	case *LEDStripFill:
+		if p != nil {
+			return "LEDStripFill", *p, true
+		}
+	case LEDStripBrightness:
+		return "LEDStripBrightness", p, true
+	case *LEDStripBrightness:
+		if p != nil {
+			return "LEDStripBrightness", *p, true
+		}
+	case HALState:
+		return "HALState", p, true
+	case *HALState:
+		if p != nil {
+			return "HALState", *p, true
+		}
+	case ConfigError:
+		return "ConfigError", p, true
+	case *ConfigError:
+		if p != nil {
+			return "ConfigError", *p, true
+		}
+	case ResourceMap:
+		return "ResourceMap", p, true
+	case *ResourceMap:
+		if p != nil {
+			return "ResourceMap", *p, true
+		}
+	case PinClaim:
+		return "PinClaim", p, true
+	case *PinClaim:
+		if p != nil {
+			return "PinClaim", *p, true
+		}
+	case BusClaim:
+		return "BusClaim", p, true
+	case *BusClaim:
+		if p != nil {
+			return "BusClaim", *p, true
+		}
+	case CapabilityStatus:
+		return "CapabilityStatus", p, true
+	case *CapabilityStatus:
+		if p != nil {
+			return "CapabilityStatus", *p, true
+		}
+	case PollStart:
+		return "PollStart", p, true
+	case *PollStart:
+		if p != nil {
+			return "PollStart", *p, true
+		}
+	case PollStop:
+		return "PollStop", p, true
+	case *PollStop:
+		if p != nil {
+			return "PollStop", *p, true
+		}
+	case PollBurst:
+		return "PollBurst", p, true
+	case *PollBurst:
+		if p != nil {
+			return "PollBurst", *p, true
+		}
+	case ReadSync:
+		return "ReadSync", p, true
+	case *ReadSync:
+		if p != nil {
+			return "ReadSync", *p, true
+		}
+	case ControlDone:
+		return "ControlDone", p, true
+	case *ControlDone:
+		if p != nil {
+			return "ControlDone", *p, true
+		}
+	case Quarantine:
+		return "Quarantine", p, true
+	case *Quarantine:
+		if p != nil {
+			return "Quarantine", *p, true
+		}
+	case EventStorm:
+		return "EventStorm", p, true
+	case *EventStorm:
+		if p != nil {
+			return "EventStorm", *p, true
+		}
+	case PollSpec:
+		return "PollSpec", p, true
+	case *PollSpec:
+		if p != nil {
+			return "PollSpec", *p, true
+		}
+	case ConfigBlob:
+		return "ConfigBlob", p, true
+	case *ConfigBlob:
+		if p != nil {
+			return "ConfigBlob", *p, true
+		}
+	case ConfigImport:
+		return "ConfigImport", p, true
+	case *ConfigImport:
+		if p != nil {
+			return "ConfigImport", *p, true
+		}
+	case ConfigStaged:
+		return "ConfigStaged", p, true
+	case *ConfigStaged:
+		if p != nil {
+			return "ConfigStaged", *p, true
+		}
+	case NVGet:
+		return "NVGet", p, true
+	case *NVGet:
+		if p != nil {
+			return "NVGet", *p, true
+		}
+	case NVPut:
+		return "NVPut", p, true
+	case *NVPut:
+		if p != nil {
+			return "NVPut", *p, true
+		}
+	case NVRecord:
+		return "NVRecord", p, true
+	case *NVRecord:
+		if p != nil {
+			return "NVRecord", *p, true
+		}
+	case LastLog:
+		return "LastLog", p, true
+	case *LastLog:
+		if p != nil {
+			return "LastLog", *p, true
+		}
+	case BusPlan:
+		return "BusPlan", p, true
+	case *BusPlan:
+		if p != nil {
+			return "BusPlan", *p, true
+		}
+	case I2CBus:
+		return "I2CBus", p, true
+	case *I2CBus:
+		if p != nil {
+			return "I2CBus", *p, true
+		}
+	case UARTBus:
+		return "UARTBus", p, true
+	case *UARTBus:
+		if p != nil {
+			return "UARTBus", *p, true
+		}
+	case OneWireBus:
+		return "OneWireBus", p, true
+	case *OneWireBus:
+		if p != nil {
+			return "OneWireBus", *p, true
+		}
+	case Transform:
+		return "Transform", p, true
+	case *Transform:
+		if p != nil {
+			return "Transform", *p, true
+		}
+	case AlarmSpec:
+		return "AlarmSpec", p, true
+	case *AlarmSpec:
+		if p != nil {
+			return "AlarmSpec", *p, true
+		}
+	case AlarmState:
+		return "AlarmState", p, true
+	case *AlarmState:
+		if p != nil {
+			return "AlarmState", *p, true
+		}
+	case GroupSpec:
+		return "GroupSpec", p, true
+	case *GroupSpec:
+		if p != nil {
+			return "GroupSpec", *p, true
+		}
+	case CapAlias:
+		return "CapAlias", p, true
+	case *CapAlias:
+		if p != nil {
+			return "CapAlias", *p, true
+		}
+	case GroupState:
+		return "GroupState", p, true
+	case *GroupState:
+		if p != nil {
+			return "GroupState", *p, true
+		}
+	case GroupOffender:
+		return "GroupOffender", p, true
+	case *GroupOffender:
+		if p != nil {
+			return "GroupOffender", *p, true
+		}
+	case PowerSet:
+		return "PowerSet", p, true
+	case *PowerSet:
+		if p != nil {
+			return "PowerSet", *p, true
+		}
+	case PowerState:
+		return "PowerState", p, true
+	case *PowerState:
+		if p != nil {
+			return "PowerState", *p, true
+		}
+	case OKReply:
+		return "OKReply", p, true
+	case *OKReply:
+		if p != nil {
+			return "OKReply", *p, true
+		}
+	case ErrorReply:
+		return "ErrorReply", p, true
+	case *ErrorReply:
+		if p != nil {
+			return "ErrorReply", *p, true
+		}
+	case BroadcastReply:
+		return "BroadcastReply", p, true
+	case *BroadcastReply:
+		if p != nil {
+			return "BroadcastReply", *p, true
+		}
+	case BroadcastResult:
+		return "BroadcastResult", p, true
+	case *BroadcastResult:
+		if p != nil {
+			return "BroadcastResult", *p, true
+		}
+	case Info:
+		return "Info", p, true
+	case *Info:
+		if p != nil {
+			return "Info", *p, true
+		}
+	case VerbInfo:
+		return "VerbInfo", p, true
+	case *VerbInfo:
+		if p != nil {
+			return "VerbInfo", *p, true
+		}
+	case CapabilityVerbs:
+		return "CapabilityVerbs", p, true
+	case *CapabilityVerbs:
+		if p != nil {
+			return "CapabilityVerbs", *p, true
+		}
+	case BootAction:
+		return "BootAction", p, true
+	case *BootAction:
+		if p != nil {
+			return "BootAction", *p, true
+		}
+	case ModemInfo:
+		return "ModemInfo", p, true
+	case *ModemInfo:
+		if p != nil {
+			return "ModemInfo", *p, true
+		}
+	case ModemValue:
+		return "ModemValue", p, true
+	case *ModemValue:
+		if p != nil {
+			return "ModemValue", *p, true
+		}
+	case ModemIdentity:
+		return "ModemIdentity", p, true
+	case *ModemIdentity:
+		if p != nil {
+			return "ModemIdentity", *p, true
+		}
+	case ModemCommand:
+		return "ModemCommand", p, true
+	case *ModemCommand:
+		if p != nil {
+			return "ModemCommand", *p, true
+		}
+	case ModemResponse:
+		return "ModemResponse", p, true
+	case *ModemResponse:
+		if p != nil {
+			return "ModemResponse", *p, true
+		}
+	case ModemSessionOpen:
+		return "ModemSessionOpen", p, true
+	case *ModemSessionOpen:
+		if p != nil {
+			return "ModemSessionOpen", *p, true
+		}
+	case BatteryInfo:
+		return "BatteryInfo", p, true
+	case *BatteryInfo:
+		if p != nil {
+			return "BatteryInfo", *p, true
+		}
+	case BatteryValue:
+		return "BatteryValue", p, true
+	case *BatteryValue:
+		if p != nil {
+			return "BatteryValue", *p, true
+		}
+	case ChargerInfo:
+		return "ChargerInfo", p, true
+	case *ChargerInfo:
+		if p != nil {
+			return "ChargerInfo", *p, true
+		}
+	case ChargerValue:
+		return "ChargerValue", p, true
+	case *ChargerValue:
+		if p != nil {
+			return "ChargerValue", *p, true
+		}
+	case SystemPowerInfo:
+		return "SystemPowerInfo", p, true
+	case *SystemPowerInfo:
+		if p != nil {
+			return "SystemPowerInfo", *p, true
+		}
+	case SystemPowerValue:
+		return "SystemPowerValue", p, true
+	case *SystemPowerValue:
+		if p != nil {
+			return "SystemPowerValue", *p, true
+		}
+	case EnergyInfo:
+		return "EnergyInfo", p, true
+	case *EnergyInfo:
+		if p != nil {
+			return "EnergyInfo", *p, true
+		}
+	case EnergyTotals:
+		return "EnergyTotals", p, true
+	case *EnergyTotals:
+		if p != nil {
+			return "EnergyTotals", *p, true
+		}
+	case EnergyValue:
+		return "EnergyValue", p, true
+	case *EnergyValue:
+		if p != nil {
+			return "EnergyValue", *p, true
+		}
+	case EnergyHistory:
+		return "EnergyHistory", p, true
+	case *EnergyHistory:
+		if p != nil {
+			return "EnergyHistory", *p, true
+		}
+	case RailInfo:
+		return "RailInfo", p, true
+	case *RailInfo:
+		if p != nil {
+			return "RailInfo", *p, true
+		}
+	case RailValue:
+		return "RailValue", p, true
+	case *RailValue:
+		if p != nil {
+			return "RailValue", *p, true
+		}
+	case RailLimits:
+		return "RailLimits", p, true
+	case *RailLimits:
+		if p != nil {
+			return "RailLimits", *p, true
+		}
+	case RailAlert:
+		return "RailAlert", p, true
+	case *RailAlert:
+		if p != nil {
+			return "RailAlert", *p, true
+		}
+	case ChargerEnable:
+		return "ChargerEnable", p, true
+	case *ChargerEnable:
+		if p != nil {
+			return "ChargerEnable", *p, true
+		}
+	case SetInputLimit:
+		return "SetInputLimit", p, true
+	case *SetInputLimit:
+		if p != nil {
+			return "SetInputLimit", *p, true
+		}
+	case SetChargeTarget:
+		return "SetChargeTarget", p, true
+	case *SetChargeTarget:
+		if p != nil {
+			return "SetChargeTarget", *p, true
+		}
+	case SetVinWindow:
+		return "SetVinWindow", p, true
+	case *SetVinWindow:
+		if p != nil {
+			return "SetVinWindow", *p, true
+		}
+	case ChargerConfigure:
+		return "ChargerConfigure", p, true
+	case *ChargerConfigure:
+		if p != nil {
+			return "ChargerConfigure", *p, true
+		}
+	case ChargerAlertMask:
+		return "ChargerAlertMask", p, true
+	case *ChargerAlertMask:
+		if p != nil {
+			return "ChargerAlertMask", *p, true
+		}
+	case BSRResult:
+		return "BSRResult", p, true
+	case *BSRResult:
+		if p != nil {
+			return "BSRResult", *p, true
+		}
+	case SolarSweep:
+		return "SolarSweep", p, true
+	case *SolarSweep:
+		if p != nil {
+			return "SolarSweep", *p, true
+		}
+	case IVPoint:
+		return "IVPoint", p, true
+	case *IVPoint:
+		if p != nil {
+			return "IVPoint", *p, true
+		}
+	case SolarSweepResult:
+		return "SolarSweepResult", p, true
+	case *SolarSweepResult:
+		if p != nil {
+			return "SolarSweepResult", *p, true
+		}
+	case VinCollapseWarning:
+		return "VinCollapseWarning", p, true
+	case *VinCollapseWarning:
+		if p != nil {
+			return "VinCollapseWarning", *p, true
+		}
+	case VinWindowSet:
+		return "VinWindowSet", p, true
+	case *VinWindowSet:
+		if p != nil {
+			return "VinWindowSet", *p, true
+		}
+	case VbatWindowSet:
+		return "VbatWindowSet", p, true
+	case *VbatWindowSet:
+		if p != nil {
+			return "VbatWindowSet", *p, true
+		}
+	case VsysWindowSet:
+		return "VsysWindowSet", p, true
+	case *VsysWindowSet:
+		if p != nil {
+			return "VsysWindowSet", *p, true
+		}
+	case CurrentMA:
+		return "CurrentMA", p, true
+	case *CurrentMA:
+		if p != nil {
+			return "CurrentMA", *p, true
+		}
+	case VoltageMV:
+		return "VoltageMV", p, true
+	case *VoltageMV:
+		if p != nil {
+			return "VoltageMV", *p, true
+		}
+	case TempMilliC:
+		return "TempMilliC", p, true
+	case *TempMilliC:
+		if p != nil {
+			return "TempMilliC", *p, true
+		}
+	case ResistanceMicroOhmPerCell:
+		return "ResistanceMicroOhmPerCell", p, true
+	case *ResistanceMicroOhmPerCell:
+		if p != nil {
+			return "ResistanceMicroOhmPerCell", *p, true
+		}
+	case NTCRatioWindowRaw:
+		return "NTCRatioWindowRaw", p, true
+	case *NTCRatioWindowRaw:
+		if p != nil {
+			return "NTCRatioWindowRaw", *p, true
+		}
+	case DurationS:
+		return "DurationS", p, true
+	case *DurationS:
+		if p != nil {
+			return "DurationS", *p, true
+		}
+	case COverXSet:
+		return "COverXSet", p, true
+	case *COverXSet:
+		if p != nil {
+			return "COverXSet", *p, true
+		}
+	case ChargerConfigBitsUpdate:
+		return "ChargerConfigBitsUpdate", p, true
+	case *ChargerConfigBitsUpdate:
+		if p != nil {
+			return "ChargerConfigBitsUpdate", *p, true
+		}
+	case SerialSessionOpen:
+		return "SerialSessionOpen", p, true
+	case *SerialSessionOpen:
+		if p != nil {
+			return "SerialSessionOpen", *p, true
+		}
+	case SerialSessionClose:
+		return "SerialSessionClose", p, true
+	case *SerialSessionClose:
+		if p != nil {
+			return "SerialSessionClose", *p, true
+		}
+	case SerialSetBaud:
+		return "SerialSetBaud", p, true
+	case *SerialSetBaud:
+		if p != nil {
+			return "SerialSetBaud", *p, true
+		}
+	case SerialSetFormat:
+		return "SerialSetFormat", p, true
+	case *SerialSetFormat:
+		if p != nil {
+			return "SerialSetFormat", *p, true
+		}
+	case SerialSetFlowControl:
+		return "SerialSetFlowControl", p, true
+	case *SerialSetFlowControl:
+		if p != nil {
+			return "SerialSetFlowControl", *p, true
+		}
+	case SerialSessionOpened:
+		return "SerialSessionOpened", p, true
+	case *SerialSessionOpened:
+		if p != nil {
+			return "SerialSessionOpened", *p, true
+		}
+	case SerialSessionExpired:
+		return "SerialSessionExpired", p, true
+	case *SerialSessionExpired:
+		if p != nil {
+			return "SerialSessionExpired", *p, true
+		}
+	case SerialStats:
+		return "SerialStats", p, true
+	case *SerialStats:
+		if p != nil {
+			return "SerialStats", *p, true
+		}
+	case SerialLoopback:
+		return "SerialLoopback", p, true
+	case *SerialLoopback:
+		if p != nil {
+			return "SerialLoopback", *p, true
+		}
+	case SerialLoopbackResult:
+		return "SerialLoopbackResult", p, true
+	case *SerialLoopbackResult:
+		if p != nil {
+			return "SerialLoopbackResult", *p, true
+		}
+	case SerialInfo:
+		return "SerialInfo", p, true
+	case *SerialInfo:
+		if p != nil {
+			return "SerialInfo", *p, true
+		}
+	case PassthruStart:
+		return "PassthruStart", p, true
+	case *PassthruStart:
+		if p != nil {
+			return "PassthruStart", *p, true
+		}
+	case PassthruState:
+		return "PassthruState", p, true
+	case *PassthruState:
+		if p != nil {
+			return "PassthruState", *p, true
+		}
+	case MetricsSnapshot:
+		return "MetricsSnapshot", p, true
+	case *MetricsSnapshot:
+		if p != nil {
+			return "MetricsSnapshot", *p, true
+		}
+	case MetricSample:
+		return "MetricSample", p, true
+	case *MetricSample:
+		if p != nil {
+			return "MetricSample", *p, true
+		}
+	case ServiceState:
+		return "ServiceState", p, true
+	case *ServiceState:
+		if p != nil {
+			return "ServiceState", *p, true
+		}
+	case TimeSet:
+		return "TimeSet", p, true
+	case *TimeSet:
+		if p != nil {
+			return "TimeSet", *p, true
+		}
+	case SysTime:
+		return "SysTime", p, true
+	case *SysTime:
+		if p != nil {
+			return "SysTime", *p, true
+		}
+	case ReactorIncidents:
+		return "ReactorIncidents", p, true
+	case *ReactorIncidents:
+		if p != nil {
+			return "ReactorIncidents", *p, true
+		}
+	case ReactorConfig:
+		return "ReactorConfig", p, true
+	case *ReactorConfig:
+		if p != nil {
+			return "ReactorConfig", *p, true
+		}
+	case SelfCheckConfig:
+		return "SelfCheckConfig", p, true
+	case *SelfCheckConfig:
+		if p != nil {
+			return "SelfCheckConfig", *p, true
+		}
+	case SelfCheckResult:
+		return "SelfCheckResult", p, true
+	case *SelfCheckResult:
+		if p != nil {
+			return "SelfCheckResult", *p, true
+		}
+	case SelfCheckItem:
+		return "SelfCheckItem", p, true
+	case *SelfCheckItem:
+		if p != nil {
+			return "SelfCheckItem", *p, true
+		}
+	case LEDPattern:
+		return "LEDPattern", p, true
+	case *LEDPattern:
+		if p != nil {
+			return "LEDPattern", *p, true
+		}
+	case LogConfig:
+		return "LogConfig", p, true
+	case *LogConfig:
+		if p != nil {
+			return "LogConfig", *p, true
+		}
+	}
+	return "", nil, false
+}
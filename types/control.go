@@ -0,0 +1,38 @@
+package types
+
+// ------------------------
+// Thermostat (composite: a source value driving a switch)
+// ------------------------
+
+type ThermostatInfo struct {
+	Source CapabilityAddress `json:"source"`
+	Field  string            `json:"field"` // Fielder name read from Source's value
+	Output CapabilityAddress `json:"output"`
+	Cool   bool              `json:"cool,omitempty"` // demand above the setpoint
+}
+
+// Retained: hal/cap/<domain>/thermostat/<name>/value. Input and Setpoint
+// are in the units of the source field (e.g. deci_c). Stale is set while
+// the source has been silent for longer than the configured limit, which
+// also drops demand.
+type ThermostatValue struct {
+	Enabled    bool  `json:"enabled"`
+	Input      int64 `json:"input"`
+	Setpoint   int64 `json:"setpoint"`
+	Hysteresis int64 `json:"hysteresis"`
+	Demand     bool  `json:"demand"`
+	Stale      bool  `json:"stale,omitempty"`
+	TS         int64 `json:"ts_ns"`
+}
+
+// Set the setpoint and hysteresis (source units; hysteresis >= 0).
+type ThermostatSet struct {
+	Setpoint   int64 `json:"setpoint"`
+	Hysteresis int64 `json:"hysteresis"`
+}
+
+// Enable or disable regulation. Disabling switches the output off once
+// and then leaves it to other callers.
+type ThermostatEnable struct {
+	On bool `json:"on"`
+}
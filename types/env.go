@@ -5,9 +5,10 @@ package types
 // ------------------------
 
 type TemperatureInfo struct {
-	Sensor string `json:"sensor"` // "aht20", "shtc3", ...
-	Addr   uint16 `json:"addr"`   // I2C address
-	Bus    string `json:"bus"`    // "i2c0", ...
+	Sensor string `json:"sensor"`        // "aht20", "shtc3", ...
+	Addr   uint16 `json:"addr"`          // I2C address
+	Bus    string `json:"bus"`           // "i2c0", "ow0", ...
+	ROM    string `json:"rom,omitempty"` // 1-Wire ROM ID
 }
 
 type HumidityInfo struct {
@@ -16,6 +17,13 @@ type HumidityInfo struct {
 	Bus    string `json:"bus"`
 }
 
+type PressureInfo struct {
+	Sensor    string `json:"sensor"`
+	Addr      uint16 `json:"addr"`
+	Bus       string `json:"bus"`
+	AltitudeM int32  `json:"altitude_m"` // station altitude used for SeaLevelPa
+}
+
 type TemperatureValue struct {
 	// Tenths of °C (e.g. 231 => 23.1°C).
 	DeciC int16 `json:"deci_c"`
@@ -25,3 +33,18 @@ type HumidityValue struct {
 	// Hundredths of %RH (0..10000 for 0..100.00%).
 	RHx100 uint16 `json:"rh_x100"`
 }
+
+type PressureValue struct {
+	Pa uint32 `json:"pa"` // at the sensor
+	// Reduced to sea level from PressureInfo.AltitudeM (equal to Pa at 0 m),
+	// for comparison with weather reports.
+	SeaLevelPa uint32 `json:"sea_level_pa"`
+}
+
+// Event: …/event/discovered, published by 1-Wire probe devices after each
+// bus search. ROM IDs use the onewire.ROM string form.
+type OneWireDiscovery struct {
+	ROMs    []string `json:"roms"`              // every device found
+	Unnamed []string `json:"unnamed,omitempty"` // found, but not configured
+	Missing []string `json:"missing,omitempty"` // configured, but not found
+}
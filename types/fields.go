@@ -0,0 +1,407 @@
+package types
+
+import (
+	"unsafe"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Fielder exposes named integer fields of a value payload without reflection.
+// Names match the JSON tags; booleans read as 0/1.
+type Fielder interface {
+	Field(name string) (int64, bool)
+}
+
+// FieldSetter returns a copy of a value payload with one named integer
+// field replaced, for HAL's value transforms. Names match Field's; ok is
+// false (and v unchanged) for a field it does not have.
+type FieldSetter interface {
+	WithField(name string, x int64) (v any, ok bool)
+}
+
+// put stores x in *p, saturating at the limits of T.
+func put[T constraints.Integer](p *T, x int64) {
+	t := T(x)
+	if int64(t) == x && (t < 0) == (x < 0) {
+		*p = t
+		return
+	}
+	top := T(1) << (8*unsafe.Sizeof(t) - 1)
+	unsigned := top > 0
+	switch {
+	case x < 0 && unsigned:
+		*p = 0
+	case x < 0:
+		*p = top // the most negative T
+	case unsigned:
+		*p = ^T(0)
+	default:
+		*p = ^top
+	}
+}
+
+func b2i(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (v TemperatureValue) Field(name string) (int64, bool) {
+	if name == "deci_c" {
+		return int64(v.DeciC), true
+	}
+	return 0, false
+}
+
+func (v HumidityValue) Field(name string) (int64, bool) {
+	if name == "rh_x100" {
+		return int64(v.RHx100), true
+	}
+	return 0, false
+}
+
+func (v PressureValue) Field(name string) (int64, bool) {
+	switch name {
+	case "pa":
+		return int64(v.Pa), true
+	case "sea_level_pa":
+		return int64(v.SeaLevelPa), true
+	}
+	return 0, false
+}
+
+func (v PositionValue) Field(name string) (int64, bool) {
+	switch name {
+	case "fix":
+		return b2i(v.Fix), true
+	case "quality":
+		return int64(v.Quality), true
+	case "sats":
+		return int64(v.Sats), true
+	case "hdop_x100":
+		return int64(v.HDOPx100), true
+	case "lat_e7":
+		return int64(v.LatE7), true
+	case "lon_e7":
+		return int64(v.LonE7), true
+	case "alt_cm":
+		return int64(v.AltCm), true
+	case "speed_cmps":
+		return int64(v.SpeedCmps), true
+	case "course_cdeg":
+		return int64(v.CourseCdeg), true
+	}
+	return 0, false
+}
+
+func (v TimeValue) Field(name string) (int64, bool) {
+	switch name {
+	case "epoch_ms":
+		return v.EpochMs, true
+	case "ts_ns":
+		return v.TS, true
+	}
+	return 0, false
+}
+
+func (v ModemValue) Field(name string) (int64, bool) {
+	switch name {
+	case "ready":
+		return b2i(v.Ready), true
+	case "registered":
+		return b2i(v.Registered), true
+	case "roaming":
+		return b2i(v.Roaming), true
+	case "csq":
+		return int64(v.CSQ), true
+	case "rssi_dbm":
+		return int64(v.RSSIdBm), true
+	case "data":
+		return b2i(v.Data), true
+	}
+	return 0, false
+}
+
+func (v ButtonValue) Field(name string) (int64, bool) {
+	if name == "pressed" {
+		return b2i(v.Pressed), true
+	}
+	return 0, false
+}
+
+func (v CounterValue) Field(name string) (int64, bool) {
+	switch name {
+	case "rising":
+		return int64(v.Rising), true
+	case "falling":
+		return int64(v.Falling), true
+	}
+	return 0, false
+}
+
+func (v LEDValue) Field(name string) (int64, bool) {
+	if name == "on" {
+		return b2i(v.On), true
+	}
+	return 0, false
+}
+
+func (v SwitchValue) Field(name string) (int64, bool) {
+	if name == "on" {
+		return b2i(v.On), true
+	}
+	return 0, false
+}
+
+func (v GPIOValue) Field(name string) (int64, bool) {
+	if name == "level" {
+		return b2i(v.Level), true
+	}
+	return 0, false
+}
+
+func (v BuzzerValue) Field(name string) (int64, bool) {
+	switch name {
+	case "playing":
+		return b2i(v.Playing), true
+	case "priority":
+		return int64(v.Priority), true
+	}
+	return 0, false
+}
+
+func (v LEDStripValue) Field(name string) (int64, bool) {
+	switch name {
+	case "lit":
+		return int64(v.Lit), true
+	case "brightness":
+		return int64(v.Brightness), true
+	}
+	return 0, false
+}
+
+func (v PWMValue) Field(name string) (int64, bool) {
+	if name == "level" {
+		return int64(v.Level), true
+	}
+	return 0, false
+}
+
+func (v SerialStats) Field(name string) (int64, bool) {
+	if name == "rx_overruns" {
+		return int64(v.RXOverruns), true
+	}
+	return 0, false
+}
+
+func (v BatteryValue) Field(name string) (int64, bool) {
+	switch name {
+	case "pack_mV":
+		return int64(v.PackMilliV), true
+	case "per_cell_mV":
+		return int64(v.PerCellMilliV), true
+	case "ibat_mA":
+		return int64(v.IBatMilliA), true
+	case "temp_mC":
+		return int64(v.TempMilliC), true
+	case "bsr_uohm_per_cell":
+		return int64(v.BSR_uOhmPerCell), true
+	}
+	return 0, false
+}
+
+func (v SystemPowerValue) Field(name string) (int64, bool) {
+	switch name {
+	case "isys_mA":
+		return int64(v.ISys_mA), true
+	case "pin_mW":
+		return int64(v.PIn_mW), true
+	case "pbat_mW":
+		return int64(v.PBat_mW), true
+	case "psys_mW":
+		return int64(v.PSys_mW), true
+	case "eff_pct":
+		return int64(v.EffPct), true
+	}
+	return 0, false
+}
+
+func (v EnergyValue) Field(name string) (int64, bool) {
+	switch name {
+	case "day":
+		return int64(v.Day), true
+	case "in_mWh":
+		return int64(v.In_mWh), true
+	case "to_batt_mWh":
+		return int64(v.ToBatt_mWh), true
+	case "from_batt_mWh":
+		return int64(v.FromBatt_mWh), true
+	case "to_load_mWh":
+		return int64(v.ToLoad_mWh), true
+	}
+	return 0, false
+}
+
+func (v RailValue) Field(name string) (int64, bool) {
+	switch name {
+	case "bus_mV":
+		return int64(v.Bus_mV), true
+	case "shunt_uV":
+		return int64(v.Shunt_uV), true
+	case "i_mA":
+		return int64(v.I_mA), true
+	case "p_mW":
+		return int64(v.P_mW), true
+	case "alert":
+		return int64(v.Alert), true
+	}
+	return 0, false
+}
+
+func (v ChargerValue) Field(name string) (int64, bool) {
+	switch name {
+	case "vin_mV":
+		return int64(v.VIN_mV), true
+	case "vsys_mV":
+		return int64(v.VSYS_mV), true
+	case "iin_mA":
+		return int64(v.IIn_mA), true
+	case "state":
+		return int64(v.State), true
+	case "status":
+		return int64(v.Status), true
+	case "sys":
+		return int64(v.Sys), true
+	}
+	return 0, false
+}
+
+func (v ThermostatValue) Field(name string) (int64, bool) {
+	switch name {
+	case "enabled":
+		return b2i(v.Enabled), true
+	case "input":
+		return v.Input, true
+	case "setpoint":
+		return v.Setpoint, true
+	case "hysteresis":
+		return v.Hysteresis, true
+	case "demand":
+		return b2i(v.Demand), true
+	case "stale":
+		return b2i(v.Stale), true
+	case "ts_ns":
+		return v.TS, true
+	}
+	return 0, false
+}
+
+// ---- FieldSetter for the measured values ----
+
+func (v TemperatureValue) WithField(name string, x int64) (any, bool) {
+	if name == "deci_c" {
+		put(&v.DeciC, x)
+		return v, true
+	}
+	return v, false
+}
+
+func (v HumidityValue) WithField(name string, x int64) (any, bool) {
+	if name == "rh_x100" {
+		put(&v.RHx100, x)
+		return v, true
+	}
+	return v, false
+}
+
+func (v PressureValue) WithField(name string, x int64) (any, bool) {
+	switch name {
+	case "pa":
+		put(&v.Pa, x)
+	case "sea_level_pa":
+		put(&v.SeaLevelPa, x)
+	default:
+		return v, false
+	}
+	return v, true
+}
+
+func (v CounterValue) WithField(name string, x int64) (any, bool) {
+	switch name {
+	case "rising":
+		put(&v.Rising, x)
+	case "falling":
+		put(&v.Falling, x)
+	default:
+		return v, false
+	}
+	return v, true
+}
+
+func (v BatteryValue) WithField(name string, x int64) (any, bool) {
+	switch name {
+	case "pack_mV":
+		put(&v.PackMilliV, x)
+	case "per_cell_mV":
+		put(&v.PerCellMilliV, x)
+	case "ibat_mA":
+		put(&v.IBatMilliA, x)
+	case "temp_mC":
+		put(&v.TempMilliC, x)
+	case "bsr_uohm_per_cell":
+		put(&v.BSR_uOhmPerCell, x)
+	default:
+		return v, false
+	}
+	return v, true
+}
+
+func (v SystemPowerValue) WithField(name string, x int64) (any, bool) {
+	switch name {
+	case "isys_mA":
+		put(&v.ISys_mA, x)
+	case "pin_mW":
+		put(&v.PIn_mW, x)
+	case "pbat_mW":
+		put(&v.PBat_mW, x)
+	case "psys_mW":
+		put(&v.PSys_mW, x)
+	case "eff_pct":
+		put(&v.EffPct, x)
+	default:
+		return v, false
+	}
+	return v, true
+}
+
+func (v RailValue) WithField(name string, x int64) (any, bool) {
+	switch name {
+	case "bus_mV":
+		put(&v.Bus_mV, x)
+	case "shunt_uV":
+		put(&v.Shunt_uV, x)
+	case "i_mA":
+		put(&v.I_mA, x)
+	case "p_mW":
+		put(&v.P_mW, x)
+	default:
+		return v, false
+	}
+	return v, true
+}
+
+// Only the measurements; the status bitfields are not transformable.
+func (v ChargerValue) WithField(name string, x int64) (any, bool) {
+	switch name {
+	case "vin_mV":
+		put(&v.VIN_mV, x)
+	case "vsys_mV":
+		put(&v.VSYS_mV, x)
+	case "iin_mA":
+		put(&v.IIn_mA, x)
+	default:
+		return v, false
+	}
+	return v, true
+}
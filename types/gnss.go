@@ -0,0 +1,39 @@
+package types
+
+// ------------------------
+// GNSS position & time
+// ------------------------
+
+type PositionInfo struct {
+	Receiver string `json:"receiver"` // "nmea"
+	Bus      string `json:"bus"`      // "uart1", ...
+	Baud     uint32 `json:"baud"`
+}
+
+// Retained: hal/cap/<domain>/position/<name>/value. Published on each RMC
+// sentence; GGA fields are the most recent seen. Fix is false (and the
+// coordinates stale or zero) while the receiver reports no fix.
+type PositionValue struct {
+	Fix        bool   `json:"fix"`
+	Quality    uint8  `json:"quality"` // GGA fix quality: 0 none, 1 GPS, 2 DGPS, …
+	Sats       uint8  `json:"sats"`
+	HDOPx100   uint16 `json:"hdop_x100"`
+	LatE7      int32  `json:"lat_e7"` // degrees × 1e7, north positive
+	LonE7      int32  `json:"lon_e7"` // degrees × 1e7, east positive
+	AltCm      int32  `json:"alt_cm"` // above mean sea level
+	SpeedCmps  int32  `json:"speed_cmps"`
+	CourseCdeg int32  `json:"course_cdeg"` // -1 when unknown
+	TS         int64  `json:"ts_ns"`
+}
+
+type TimeInfo struct {
+	Source string `json:"source"` // "gnss"
+}
+
+// Retained: hal/cap/<domain>/time/<name>/value. EpochMs is UTC from the
+// receiver; TS is the local clock when the sentence's first byte arrived,
+// so EpochMs×1e6 − TS is the local clock's offset.
+type TimeValue struct {
+	EpochMs int64 `json:"epoch_ms"`
+	TS      int64 `json:"ts_ns"`
+}
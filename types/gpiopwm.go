@@ -12,6 +12,29 @@ type ButtonValue struct {
 	Pressed bool `json:"pressed"`
 }
 
+// Event: …/event/short, …/event/long, …/event/double. HeldMs is how long
+// the (last) press lasted; for long it is the threshold reached.
+type ButtonGesture struct {
+	HeldMs uint32 `json:"held_ms"`
+}
+
+// ------------------------
+// Edge counter (observes a pin claimed by another device)
+// ------------------------
+
+type CounterInfo struct {
+	Pin   int    `json:"pin"`
+	Edges string `json:"edges"` // "rising","falling","both"
+}
+
+// Retained: hal/cap/<domain>/counter/<name>/value. Counts are since boot or
+// the last reset; TS is the time of the most recent counted edge.
+type CounterValue struct {
+	Rising  uint32 `json:"rising"`
+	Falling uint32 `json:"falling"`
+	TS      int64  `json:"ts_ns"`
+}
+
 // ------------------------
 // LED (boolean LED; use PWM for brightness)
 // ------------------------
@@ -33,23 +56,58 @@ type LEDSet struct {
 // ------------------------
 
 type SwitchInfo struct {
-	Pin int `json:"pin"`
+	Pin       int    `json:"pin"`
+	Interlock string `json:"interlock,omitempty"` // interlock group, if any
 }
 
+// Retained: hal/cap/<domain>/switch/<name>/value. On is read back from the
+// pin after every change, so it reflects the actual output.
 type SwitchValue struct {
-	On bool `json:"on"`
+	On bool  `json:"on"`
+	TS int64 `json:"ts_ns"`
 }
 
 type SwitchSet struct {
 	On bool `json:"on"`
 }
 
+// ------------------------
+// GPIO (expander pins)
+// ------------------------
+
+type GPIOInfo struct {
+	Chip      string `json:"chip"` // "mcp23017", "pcf8574"
+	Bus       string `json:"bus"`
+	Addr      uint16 `json:"addr"`
+	Pin       int    `json:"pin"` // on the chip; MCP23017 GPB0 is 8
+	Output    bool   `json:"output"`
+	Pull      bool   `json:"pull,omitempty"`
+	ActiveLow bool   `json:"active_low"`
+	IRQ       bool   `json:"irq,omitempty"` // input changes are reported via the INT line
+}
+
+// Retained: hal/cap/<domain>/gpio/<name>/value. Level is logical (after
+// ActiveLow); TS is when it was read.
+type GPIOValue struct {
+	Level bool  `json:"level"`
+	TS    int64 `json:"ts_ns"`
+}
+
+type GPIOSet struct {
+	Level bool `json:"level"`
+}
+
 // ------------------------
 // PWM
 // ------------------------
 
+// For outputs on an expander chip, Chip/Bus/Addr identify the chip and Pin
+// is the output number on it.
 type PWMInfo struct {
 	Pin       int    `json:"pin"`
+	Chip      string `json:"chip,omitempty"` // e.g. "pca9685"; empty for MCU pins
+	Bus       string `json:"bus,omitempty"`
+	Addr      uint16 `json:"addr,omitempty"`
 	Slice     int    `json:"slice,omitempty"`   // provider may fill
 	Channel   string `json:"channel,omitempty"` // "A" or "B"
 	FreqHz    uint64 `json:"freq_hz,omitempty"`
@@ -71,12 +129,105 @@ type PWMRampMode uint8
 
 const (
 	PWMRampLinear PWMRampMode = iota // evenly spaced absolute steps
-	// future: gamma/exponential/trapezoid...
+	PWMRampEase                      // smoothstep
+	PWMRampSine                      // raised cosine
 )
 
 type PWMRamp struct {
 	To         uint16      `json:"to"`          // 0..Top (logical)
 	DurationMs uint32      `json:"duration_ms"` // total duration
 	Steps      uint16      `json:"steps"`       // >0
-	Mode       PWMRampMode `json:"mode"`        // 0=linear
+	Mode       PWMRampMode `json:"mode"`        // 0=linear, 1=ease, 2=sine
+}
+
+// PWMFreq is the payload of a pwm output's freq_negotiated event: the
+// frequency its shared period generator settled on.
+type PWMFreq struct {
+	RequestedHz uint64 `json:"requested_hz"`
+	EffectiveHz uint64 `json:"effective_hz"`
+}
+
+// ------------------------
+// Buzzer (PWM-driven piezo/magnetic sounder)
+// ------------------------
+
+type BuzzerInfo struct {
+	Pin      int      `json:"pin"`
+	Patterns []string `json:"patterns"` // built-in names accepted by play
+}
+
+// Retained: hal/cap/<domain>/buzzer/<name>/value. Pattern is the name
+// being played ("beep" for a single tone, "custom" for supplied tones).
+type BuzzerValue struct {
+	Playing  bool   `json:"playing"`
+	Pattern  string `json:"pattern,omitempty"`
+	Priority uint8  `json:"priority,omitempty"`
+}
+
+// BuzzerTone is one step of a pattern; FreqHz 0 is a rest.
+type BuzzerTone struct {
+	FreqHz     uint16 `json:"freq_hz"`
+	DurationMs uint16 `json:"duration_ms"`
+}
+
+// A request replaces what is playing if its Priority is at least as high;
+// otherwise it is refused with errcode.Busy.
+type BuzzerBeep struct {
+	FreqHz     uint16 `json:"freq_hz"`     // 0 uses the device default
+	DurationMs uint16 `json:"duration_ms"` // 0 uses 100 ms
+	Priority   uint8  `json:"priority"`
+}
+
+// Play a built-in Pattern, or Tones if Pattern is empty. Repeat counts
+// extra passes; RepeatForever plays until stopped or pre-empted.
+type BuzzerPlay struct {
+	Pattern  string       `json:"pattern,omitempty"`
+	Tones    []BuzzerTone `json:"tones,omitempty"`
+	Repeat   uint8        `json:"repeat,omitempty"`
+	Priority uint8        `json:"priority"`
+}
+
+const BuzzerRepeatForever = 0xFF
+
+// Stop silences the buzzer if what is playing has Priority at most this.
+type BuzzerStop struct {
+	Priority uint8 `json:"priority"`
+}
+
+// ------------------------
+// LED strip (addressable WS2812-style pixels)
+// ------------------------
+
+type LEDStripInfo struct {
+	Pin   int    `json:"pin"`
+	Count uint16 `json:"count"` // pixels
+	Order string `json:"order"` // wire byte order: "grb", "rgb" or "grbw"
+	SM    string `json:"sm"`    // PIO state machine, e.g. "pio0.sm0"
+}
+
+// Retained: hal/cap/<domain>/ledstrip/<name>/value. Lit counts pixels
+// that are not black.
+type LEDStripValue struct {
+	Lit        uint16 `json:"lit"`
+	Brightness uint8  `json:"brightness"`
+}
+
+// Colours are 0xRRGGBB, or 0xWWRRGGBB on "grbw" strips, scaled by the
+// strip's brightness when shown.
+
+// LEDStripSet writes Colors to consecutive pixels from Start.
+type LEDStripSet struct {
+	Start  uint16   `json:"start"`
+	Colors []uint32 `json:"colors"`
+}
+
+// LEDStripFill sets Count pixels from Start (0: to the end) to Color.
+type LEDStripFill struct {
+	Color uint32 `json:"color"`
+	Start uint16 `json:"start,omitempty"`
+	Count uint16 `json:"count,omitempty"`
+}
+
+type LEDStripBrightness struct {
+	Level uint8 `json:"level"` // 0..255
 }
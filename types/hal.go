@@ -1,5 +1,7 @@
 package types
 
+import "devicecode-go/errcode"
+
 // ------------------------
 // Common HAL state (retained)
 // ------------------------
@@ -8,6 +10,44 @@ type HALState struct {
 	Level  string `json:"level"`  // "idle", "ready", "stopped"
 	Status string `json:"status"` // freeform short code
 	TS     int64  `json:"ts_ns"`  // publish Unix ns (matches HAL)
+
+	// Set with Status "config_rejected": why the last config/hal was not
+	// applied. The previous configuration keeps running.
+	Errors []ConfigError `json:"errors,omitempty"`
+}
+
+// ConfigError is one problem found while validating a config/hal.
+type ConfigError struct {
+	ID     string `json:"id"`    // device ID
+	Type   string `json:"type"`  // device type
+	Error  string `json:"error"` // errcode, e.g. "pin_in_use"
+	Detail string `json:"detail,omitempty"`
+}
+
+// ResourceMap is the retained hal/resources: every claimed pin and bus and
+// who holds it, republished after each config/hal so a pin_in_use or
+// conflict can be traced from the host.
+type ResourceMap struct {
+	TS    int64      `json:"ts_ns"`
+	Pins  []PinClaim `json:"pins"`  // sorted by pin
+	Buses []BusClaim `json:"buses"` // sorted by ID
+}
+
+// PinClaim is a GPIO held by a device, or by a bus for its own wiring.
+type PinClaim struct {
+	Pin   int    `json:"pin"`
+	Func  string `json:"func"`  // "gpio_in", "gpio_out", "pwm", "pio", or a bus role ("SDA", "TX", "DQ", ...)
+	Owner string `json:"owner"` // device ID, or bus ID for bus pins
+}
+
+// BusClaim is a configured (or rejected) bus controller and its users.
+// I2C and 1-Wire are shared, so Users may list several devices; a UART
+// has at most one.
+type BusClaim struct {
+	ID    string   `json:"id"`    // e.g. "i2c0"
+	Class string   `json:"class"` // "i2c", "uart", "onewire"
+	Users []string `json:"users,omitempty"`
+	Error string   `json:"error,omitempty"` // why the plan entry was rejected
 }
 
 // Link is the link/state reported for a capability.
@@ -23,6 +63,9 @@ type CapabilityStatus struct {
 	Link  Link   `json:"link"`
 	TS    int64  `json:"ts_ns"`           // Unix ns (matches HAL)
 	Error string `json:"error,omitempty"` // machine-readable short code
+	// Poll back-off level of the owning device after repeated errors:
+	// polls are held off for about 2^(level-1) s (capped). 0 = none.
+	Backoff uint8 `json:"backoff,omitempty"`
 }
 
 // ------------------------
@@ -30,15 +73,69 @@ type CapabilityStatus struct {
 // ------------------------
 
 type PollStart struct {
-	Verb       string `json:"verb"`        // e.g. "read"
-	IntervalMs uint32 `json:"interval_ms"` // >0
-	JitterMs   uint16 `json:"jitter_ms"`   // uniform [0..JitterMs]
+	Verb       string  `json:"verb"`               // e.g. "read"
+	IntervalMs uint32  `json:"interval_ms"`        // >0
+	JitterMs   uint16  `json:"jitter_ms"`          // uniform [0..JitterMs]
+	PhaseMs    *uint32 `json:"phase_ms,omitempty"` // nil => staggered automatically
 }
 
 type PollStop struct {
 	Verb string `json:"verb,omitempty"` // empty => "read"
 }
 
+// Control: …/control/burst. HAL triggers Verb every RateMs (no faster
+// than its floor) for DurationMs, then reverts to the capability's poll
+// schedule, or stops if it had none.
+type PollBurst struct {
+	Verb       string `json:"verb,omitempty"` // empty => "read"
+	RateMs     uint32 `json:"rate_ms"`        // >0; raised to the floor (50)
+	DurationMs uint32 `json:"duration_ms"`    // >0; capped at 60000
+}
+
+// Control: …/control/read_sync. HAL triggers Verb and replies with the
+// next value the capability emits (or its error), instead of a bare OK.
+type ReadSync struct {
+	Verb      string `json:"verb,omitempty"`       // empty => "read"
+	TimeoutMs uint32 `json:"timeout_ms,omitempty"` // empty => 1000; capped at 10000
+}
+
+// Event: …/event/control_done/<id>, for a control sent as
+// …/control/<verb>/<id>. Where the reply only says the work was queued,
+// this says it was done: by the device, or failed, or not reported by the
+// device's control deadline (Error "timeout"). Error, Code and Detail are
+// as in ErrorReply.
+type ControlDone struct {
+	Verb   string `json:"verb"`
+	OK     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+	Code   uint16 `json:"code,omitempty"`
+	Detail string `json:"detail,omitempty"`
+	TS     int64  `json:"ts_ns"`
+}
+
+// Event: …/event/quarantined. The capability had Strikes controls refused
+// as invalid_payload in quick succession, and HAL now refuses its controls
+// with "quarantined" for ForMs. Verb is that of the last malformed control.
+type Quarantine struct {
+	Verb    string `json:"verb"`
+	Strikes uint8  `json:"strikes"`
+	ForMs   uint32 `json:"for_ms"`
+	TS      int64  `json:"ts_ns"`
+}
+
+// Retained: …/storm. The capability's events exceeded Limit in a second,
+// so HAL drops them while Active. It is republished each second of the
+// storm and once more, inactive, when a second has at most Limit/2. Rate
+// is the events in the last second counted; Dropped those dropped so far
+// in this storm.
+type EventStorm struct {
+	Active  bool   `json:"active"`
+	Rate    uint16 `json:"rate"`
+	Limit   uint16 `json:"limit"`
+	Dropped uint32 `json:"dropped"`
+	TS      int64  `json:"ts_ns"`
+}
+
 type PollSpec struct {
 	Domain     string `json:"domain"`      // e.g. "env"
 	Kind       Kind   `json:"kind"`        // e.g. "temperature"
@@ -46,6 +143,9 @@ type PollSpec struct {
 	Verb       string `json:"verb"`        // typically "read"
 	IntervalMs uint32 `json:"interval_ms"` // >0
 	JitterMs   uint16 `json:"jitter_ms"`   // optional
+	// Offset within the interval, from HAL start. Nil lets HAL place the
+	// poller in the widest gap among pollers sharing its interval.
+	PhaseMs *uint32 `json:"phase_ms,omitempty"`
 }
 
 // ------------------------
@@ -53,14 +153,244 @@ type PollSpec struct {
 // ------------------------
 
 type HALConfig struct {
+	Buses   *BusPlan    `json:"buses,omitempty"` // applied before devices are built
 	Devices []HALDevice `json:"devices"`
 	Pollers []PollSpec  `json:"pollers,omitempty"`
+	Alarms  []AlarmSpec `json:"alarms,omitempty"`
+	Groups  []GroupSpec `json:"groups,omitempty"`
+	Aliases []CapAlias  `json:"aliases,omitempty"`
+}
+
+// ConfigBlob is a serialised config document, as exported on
+// hal/config/control/export and accepted by hal/config/control/import.
+// Data is canonical CBOR (RFC 8949 §4.2.1) of a map with "hal" (the
+// HALConfig) and, when the reactor has published one, "reactor" (its
+// ReactorConfig). Keys within are the JSON field names.
+type ConfigBlob struct {
+	Format string `json:"format"` // "cbor"
+	Size   uint32 `json:"size"`
+	CRC32  uint32 `json:"crc32"` // IEEE, over Data
+	Data   []byte `json:"data"`  // base64 in JSON
+}
+
+// ConfigImport stages Blob as the config for the next boot; Apply also
+// publishes it on config/hal straight away.
+type ConfigImport struct {
+	Blob  ConfigBlob `json:"blob"`
+	Apply bool       `json:"apply,omitempty"`
+}
+
+// ConfigStaged is retained on hal/config/staged after a successful import.
+type ConfigStaged struct {
+	CRC32   uint32 `json:"crc32"`
+	Size    uint32 `json:"size"`
+	Devices int    `json:"devices"`
+	Reactor bool   `json:"reactor"` // carries reactor thresholds
+	Stored  bool   `json:"stored"`  // written to non-volatile storage
+	Applied bool   `json:"applied"`
+	TS      int64  `json:"ts_ns"`
+}
+
+// Small application records kept in non-volatile storage by HAL
+// (hal/nv/control/get and put). Keys are short names chosen by the
+// application, e.g. "reactor/incidents"; Data is opaque to HAL.
+type NVGet struct {
+	Key string `json:"key"`
+}
+
+type NVPut struct {
+	Key  string `json:"key"`
+	Data []byte `json:"data"`
+}
+
+// NVRecord replies to get; Found is false for a key never written.
+type NVRecord struct {
+	Key   string `json:"key"`
+	Found bool   `json:"found"`
+	Data  []byte `json:"data,omitempty"`
+}
+
+// LastLog is a checkpoint of the newest log output, kept by HAL in its own
+// reserved flash region so it outlives a reset or power loss. Each
+// hal/nv/control/log_put replaces it; hal/nv/control/log_get replies with
+// the stored one (Found false if there is none). The reactor reads the
+// previous boot's at start-up and serves it on sys/lastlog/control/get.
+type LastLog struct {
+	Found bool   `json:"found"`
+	TS    int64  `json:"ts_ns"` // wall-clock Unix ns it was taken at; 0: time not synced
+	Data  []byte `json:"data,omitempty"`
+}
+
+// BusPlan is the controller wiring (pins, clock rates) for a board spin.
+// Compile-time setups provide one; config/hal may supply it at run time.
+// Controllers are instantiated once: entries for an already configured
+// controller are ignored.
+type BusPlan struct {
+	I2C     []I2CBus       `json:"i2c,omitempty"`
+	UART    []UARTBus      `json:"uart,omitempty"`
+	OneWire []OneWireBus   `json:"onewire,omitempty"`
+	Aliases map[string]int `json:"aliases,omitempty"` // pin name -> GPIO
+}
+
+type I2CBus struct {
+	ID  string `json:"id"`  // e.g. "i2c0"
+	SDA int    `json:"sda"` // GPIO number
+	SCL int    `json:"scl"` // GPIO number
+	Hz  uint32 `json:"hz"`  // bus frequency
+}
+
+type UARTBus struct {
+	ID   string `json:"id"`   // e.g. "uart0"
+	TX   int    `json:"tx"`   // GPIO number
+	RX   int    `json:"rx"`   // GPIO number
+	Baud uint32 `json:"baud"` // initial baud
+
+	// Optional hardware flow control pins (GPIO numbers). Both must be set
+	// for RTS/CTS to be available; Flow selects the initial state.
+	CTS  *int `json:"cts,omitempty"`
+	RTS  *int `json:"rts,omitempty"`
+	Flow bool `json:"flow,omitempty"`
+}
+
+// OneWireBus is a bit-banged 1-Wire bus on one GPIO. The wire needs an
+// external pull-up (4.7 kΩ to 3V3).
+type OneWireBus struct {
+	ID  string `json:"id"`  // e.g. "ow0"
+	Pin int    `json:"pin"` // GPIO number
 }
 
 type HALDevice struct {
 	ID     string      `json:"id"`     // logical device id
 	Type   string      `json:"type"`   // e.g. "gpio_led"
 	Params interface{} `json:"params"` // device-specific params (JSON-like)
+	// How long HAL waits for the device's Init before reporting its
+	// capabilities as init_timeout and moving on. 0 uses 2 s.
+	InitTimeoutMs uint32 `json:"init_timeout_ms,omitempty"`
+	// How long HAL waits for a control sent with a request ID to finish
+	// before reporting it timed out (see ControlDone). 0 uses 5 s.
+	ControlTimeoutMs uint32 `json:"control_timeout_ms,omitempty"`
+	// Applied in order to the device's values before HAL publishes them.
+	Transforms []Transform `json:"transforms,omitempty"`
+}
+
+type TransformOp string
+
+const (
+	TransformScale   TransformOp = "scale"   // v*Num/Den, rounded
+	TransformOffset  TransformOp = "offset"  // v+Add
+	TransformClamp   TransformOp = "clamp"   // into [Min, Max]
+	TransformConvert TransformOp = "convert" // Unit "<from>:<to>", e.g. "deci_c:milli_c"
+)
+
+// Transform is one step of a device's value pipeline. It rewrites one
+// integer field (see FieldSetter), saturating at the field's range; values
+// without the field pass through.
+type Transform struct {
+	Kind  Kind        `json:"kind,omitempty"` // one capability kind of the device; empty => all
+	Field string      `json:"field"`          // JSON name
+	Op    TransformOp `json:"op"`
+	Num   int64       `json:"num,omitempty"`
+	Den   int64       `json:"den,omitempty"` // 0 => 1
+	Add   int64       `json:"add,omitempty"`
+	Min   int64       `json:"min,omitempty"`
+	Max   int64       `json:"max,omitempty"`
+	Unit  string      `json:"unit,omitempty"`
+}
+
+// ------------------------
+// Alarms (declarative thresholds over capability values)
+// ------------------------
+
+type AlarmOp string
+
+const (
+	AlarmGT AlarmOp = "gt" // raise when value >  threshold
+	AlarmGE AlarmOp = "ge" // raise when value >= threshold
+	AlarmLT AlarmOp = "lt" // raise when value <  threshold
+	AlarmLE AlarmOp = "le" // raise when value <= threshold
+)
+
+type AlarmSpec struct {
+	Name       string            `json:"name"`        // alarm/<name>/...
+	Source     CapabilityAddress `json:"source"`      // "+" matches any domain/kind/name
+	Field      string            `json:"field"`       // value field (JSON name), see Fielder
+	Op         AlarmOp           `json:"op"`          // gt|ge|lt|le
+	Threshold  int64             `json:"threshold"`   // in the field's units
+	Hysteresis int64             `json:"hysteresis"`  // clear only once back past threshold∓hysteresis
+	DebounceMs uint32            `json:"debounce_ms"` // condition must persist across samples this long
+}
+
+// Retained: alarm/<name>/state
+type AlarmState struct {
+	Name   string            `json:"name"`
+	Active bool              `json:"active"`
+	Value  int64             `json:"value"`  // sample that caused the last transition
+	Source CapabilityAddress `json:"source"` // capability that caused the last transition
+	TS     int64             `json:"ts_ns"`
+}
+
+// ------------------------
+// Capability groups
+// ------------------------
+
+// GroupSpec names a set of capabilities whose health HAL rolls up on
+// group/<name>/state.
+type GroupSpec struct {
+	Name    string              `json:"name"`               // group/<name>/state
+	Members []CapabilityAddress `json:"members"`            // exact addresses
+	StaleMs uint32              `json:"stale_ms,omitempty"` // a member without a value this long is an offender; 0 = never
+}
+
+// ------------------------
+// Capability aliases
+// ------------------------
+
+// CapAlias keeps a renamed capability reachable at its old address while
+// host software catches up with a board revision: HAL republishes
+// everything Target publishes under Alias as well, and routes controls
+// sent to Alias to Target. An address a device registers itself is never
+// an alias.
+type CapAlias struct {
+	Alias  CapabilityAddress `json:"alias"`  // the old address, e.g. …/button-led
+	Target CapabilityAddress `json:"target"` // the capability behind it, e.g. …/status-led
+}
+
+// Retained: group/<name>/state. Link is up while every member's last
+// status is up and its value fresh, degraded otherwise.
+type GroupState struct {
+	Name      string          `json:"name"`
+	Link      Link            `json:"link"`
+	Offenders []GroupOffender `json:"offenders,omitempty"`
+	TS        int64           `json:"ts_ns"`
+}
+
+// GroupOffender is a member holding its group degraded. Error is the
+// member's status error, "stale", or "no_value" before its first value.
+type GroupOffender struct {
+	Cap   CapabilityAddress `json:"cap"`
+	Error string            `json:"error"`
+}
+
+// ------------------------
+// Power management
+// ------------------------
+
+type PowerMode string
+
+const (
+	PowerRun  PowerMode = "run"  // full clocks
+	PowerIdle PowerMode = "idle" // unused peripheral clocks gated in sleep; pollers stretched
+)
+
+// Control: hal/power/control/set
+type PowerSet struct {
+	Mode PowerMode `json:"mode"`
+}
+
+// Retained: hal/power/state
+type PowerState struct {
+	Mode PowerMode `json:"mode"`
+	TS   int64     `json:"ts_ns"`
 }
 
 // ------------------------
@@ -72,8 +402,47 @@ type OKReply struct {
 }
 
 type ErrorReply struct {
-	OK    bool   `json:"ok"`
-	Error string `json:"error"`
+	OK        bool   `json:"ok"`
+	Error     string `json:"error"`            // errcode.Code
+	Code      uint16 `json:"code"`             // errcode.Num of Error
+	Verb      string `json:"verb,omitempty"`   // control verb, when known
+	Detail    string `json:"detail,omitempty"` // human-readable context
+	Field     string `json:"field,omitempty"`  // offending payload field
+	Retryable bool   `json:"retryable"`        // same request may succeed later
+}
+
+// ErrorReplyOf is the structured reply to a request that failed with err,
+// for the control verb (may be ""). err may be a bare errcode.Code or an
+// *errcode.E carrying detail and the offending field; a nil or uncoded
+// err is reported as errcode.Error.
+func ErrorReplyOf(err error, verb string) ErrorReply {
+	code := errcode.Of(err)
+	if code == "" || code == errcode.OK {
+		code = errcode.Error
+	}
+	detail, field := errcode.DetailOf(err)
+	return ErrorReply{
+		Error:     string(code),
+		Code:      uint16(code.Num()),
+		Verb:      verb,
+		Detail:    detail,
+		Field:     field,
+		Retryable: errcode.Retryable(code),
+	}
+}
+
+// BroadcastReply answers a control addressed with "+" for the domain or
+// name (e.g. hal/cap/power/switch/+/control/set): one result per matching
+// capability, in address order. OK is true only if every one succeeded.
+type BroadcastReply struct {
+	OK      bool              `json:"ok"`
+	Results []BroadcastResult `json:"results"`
+}
+
+type BroadcastResult struct {
+	Address CapabilityAddress `json:"address"`
+	OK      bool              `json:"ok"`
+	Error   string            `json:"error,omitempty"` // errcode.Code
 }
 
 // ------------------------
@@ -86,6 +455,20 @@ type Info struct {
 	Detail        interface{} `json:"detail,omitempty"` // one of *Info types below
 }
 
+// ------------------------
+// Control verb introspection (retained)
+// ------------------------
+
+type VerbInfo struct {
+	Verb    string `json:"verb"`
+	Payload string `json:"payload,omitempty"` // payload type name; empty => none
+}
+
+// Retained: hal/cap/<domain>/<kind>/<name>/verbs
+type CapabilityVerbs struct {
+	Verbs []VerbInfo `json:"verbs"`
+}
+
 type BootAction struct {
 	Verb    string `json:"verb"`
 	Payload any    `json:"payload,omitempty"`
@@ -0,0 +1,35 @@
+package types
+
+import "reflect"
+
+// Retained payloads are shared: the bus hands one value to every
+// subscriber, late ones included, and keeps it as the retained copy. So a
+// payload must share no memory its publisher goes on to change. Plain
+// values (numbers, strings, and arrays and structs of them) are copied
+// when boxed and are safe as they are; one holding a slice, map or
+// pointer implements Cloner.
+
+// Cloner returns a copy of a payload that shares no memory with it, so the
+// publisher may reuse its buffers once the copy is published.
+type Cloner interface {
+	Clone() any
+}
+
+// SelfContained reports whether a copy of a t shares no memory with the
+// original.
+func SelfContained(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface,
+		reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return false
+	case reflect.Array:
+		return SelfContained(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !SelfContained(t.Field(i).Type) {
+				return false
+			}
+		}
+	}
+	return true
+}
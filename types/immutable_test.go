@@ -0,0 +1,50 @@
+package types
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Every registered value payload can be published retained as it is, or
+// clones itself.
+func TestValuePayloadsSafeToRetain(t *testing.T) {
+	for name, dec := range payloadDecoders {
+		if !strings.HasSuffix(name, "Value") {
+			continue
+		}
+		v, err := dec([]byte("{}"))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, ok := v.(Cloner); !ok && !SelfContained(reflect.TypeOf(v)) {
+			t.Errorf("%s holds references but is not a Cloner", name)
+		}
+	}
+}
+
+func TestSelfContained(t *testing.T) {
+	type flat struct {
+		A int32
+		S string
+		X [2]uint16
+	}
+	type deep struct {
+		A int32
+		P []IVPoint
+	}
+	for _, c := range []struct {
+		v    any
+		want bool
+	}{
+		{flat{}, true},
+		{deep{}, false},
+		{&flat{}, false},
+		{[1]deep{}, false},
+		{int64(0), true},
+	} {
+		if got := SelfContained(reflect.TypeOf(c.v)); got != c.want {
+			t.Errorf("%T: %v, want %v", c.v, got, c.want)
+		}
+	}
+}
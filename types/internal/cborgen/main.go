@@ -0,0 +1,439 @@
+// Command cborgen writes types/cbor_gen.go: MarshalCBOR and UnmarshalCBOR
+// for every struct in package types that does not have hand-written ones
+// (types/cbor.go), and the by-name table MarshalPayload and
+// UnmarshalPayload use. Run it through go generate in types/.
+//
+// Fields are keyed by their JSON names, so a payload reads the same in
+// either encoding. The field kinds covered are the ones the package uses:
+// integers, bools, strings and named types over them, pointers to those
+// (optional fields), structs and pointers to them, slices of all of these,
+// []byte, and any (a registered payload, written with its type name). A
+// struct with anything else must be written by hand or listed in skip.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const out = "cbor_gen.go"
+
+// skip lists structs encoded elsewhere.
+var skip = map[string]string{
+	"HALConfig": "services/hal/internal/core/configxfer.go (device params need the HAL's registry)",
+	"HALDevice": "services/hal/internal/core/configxfer.go",
+}
+
+type gen struct {
+	decls   map[string]ast.Expr // every non-generic type declaration
+	structs []string            // in file and source order
+	manual  map[string]bool     // hand-written MarshalCBOR
+	b       bytes.Buffer
+}
+
+func main() {
+	g := &gen{decls: map[string]ast.Expr{}, manual: map[string]bool{}}
+	if err := g.load("."); err != nil {
+		fail(err)
+	}
+	if err := g.emit(); err != nil {
+		fail(err)
+	}
+	src, err := format.Source(g.b.Bytes())
+	if err != nil {
+		fail(fmt.Errorf("format: %v", err))
+	}
+	if err := os.WriteFile(out, src, 0o644); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "cborgen:", err)
+	os.Exit(1)
+}
+
+func (g *gen) load(dir string) error {
+	fset := token.NewFileSet()
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, e := range ents {
+		n := e.Name()
+		if strings.HasSuffix(n, ".go") && !strings.HasSuffix(n, "_test.go") && n != out {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		f, err := parser.ParseFile(fset, n, nil, 0)
+		if err != nil {
+			return err
+		}
+		for _, d := range f.Decls {
+			switch d := d.(type) {
+			case *ast.GenDecl:
+				for _, s := range d.Specs {
+					ts, ok := s.(*ast.TypeSpec)
+					if !ok || ts.TypeParams != nil {
+						continue
+					}
+					g.decls[ts.Name.Name] = ts.Type
+					if _, ok := ts.Type.(*ast.StructType); ok && ts.Name.IsExported() {
+						g.structs = append(g.structs, ts.Name.Name)
+					}
+				}
+			case *ast.FuncDecl:
+				if d.Recv != nil && d.Name.Name == "MarshalCBOR" {
+					g.manual[recvName(d.Recv.List[0].Type)] = true
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func recvName(e ast.Expr) string {
+	if s, ok := e.(*ast.StarExpr); ok {
+		e = s.X
+	}
+	if id, ok := e.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+func (g *gen) p(format string, args ...any) { fmt.Fprintf(&g.b, format+"\n", args...) }
+
+func (g *gen) emit() error {
+	g.p("// Code generated by cborgen; DO NOT EDIT.")
+	g.p("")
+	g.p("package types")
+	g.p("")
+	g.p(`import "devicecode-go/x/cbor"`)
+
+	var named []string // every struct with CBOR methods
+	for _, name := range g.structs {
+		if _, ok := skip[name]; ok {
+			continue
+		}
+		named = append(named, name)
+		if g.manual[name] {
+			continue
+		}
+		if err := g.emitStruct(name, g.decls[name].(*ast.StructType)); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+
+	g.p("")
+	g.p("// cborPayloads rebuilds a payload from CBOR by type name.")
+	g.p("var cborPayloads = map[string]func(d *cbor.Decoder) (any, error){")
+	for _, n := range named {
+		g.p("%q: decodeAs[%s],", n, n)
+	}
+	g.p("}")
+	g.p("")
+	g.p("// cborMarshaler returns the type name and encoder of a payload held")
+	g.p("// as a value or a non-nil pointer.")
+	g.p("func cborMarshaler(v any) (string, cbor.Marshaler, bool) {")
+	g.p("switch p := v.(type) {")
+	for _, n := range named {
+		g.p("case %s:", n)
+		g.p("return %q, p, true", n)
+		g.p("case *%s:", n)
+		g.p("if p != nil {")
+		g.p("return %q, *p, true", n)
+		g.p("}")
+	}
+	g.p("}")
+	g.p(`return "", nil, false`)
+	g.p("}")
+	return nil
+}
+
+type field struct {
+	name, key string
+	typ       ast.Expr
+}
+
+func (g *gen) emitStruct(name string, st *ast.StructType) error {
+	var fs []field
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			return fmt.Errorf("embedded field %s not supported", exprString(f.Type))
+		}
+		tag := ""
+		if f.Tag != nil {
+			s, _ := strconv.Unquote(f.Tag.Value)
+			tag = reflect.StructTag(s).Get("json")
+		}
+		key, _, _ := strings.Cut(tag, ",")
+		if key == "-" {
+			continue
+		}
+		for _, id := range f.Names {
+			if !id.IsExported() {
+				continue
+			}
+			k := key
+			if k == "" {
+				k = id.Name
+			}
+			fs = append(fs, field{id.Name, k, f.Type})
+		}
+	}
+
+	recv := "x"
+	if len(fs) == 0 {
+		recv = "_"
+	}
+	g.p("")
+	g.p("func (%s %s) MarshalCBOR(e *cbor.Encoder) {", recv, name)
+	g.p("e.Map(func(m *cbor.Map) {")
+	for _, f := range fs {
+		if err := g.encField(f); err != nil {
+			return err
+		}
+	}
+	g.p("})")
+	g.p("}")
+	g.p("")
+	if len(fs) == 0 {
+		g.p("func (%s *%s) UnmarshalCBOR(d *cbor.Decoder) error {", recv, name)
+		g.p("return d.Map(func(_ string, d *cbor.Decoder) error { return d.Skip() })")
+		g.p("}")
+		return nil
+	}
+	g.p("func (x *%s) UnmarshalCBOR(d *cbor.Decoder) error {", name)
+	g.p("return d.Map(func(k string, d *cbor.Decoder) error {")
+	g.p("switch k {")
+	for _, f := range fs {
+		g.p("case %q:", f.key)
+		if err := g.decField(f); err != nil {
+			return err
+		}
+	}
+	g.p("}")
+	g.p("return d.Skip()")
+	g.p("})")
+	g.p("}")
+	return nil
+}
+
+// Kinds a field type resolves to.
+const (
+	kInt = iota + 1
+	kUint
+	kBool
+	kText
+	kStruct
+	kAny
+	kOther
+)
+
+// kind resolves e through the package's type declarations.
+func (g *gen) kind(e ast.Expr) int {
+	for {
+		switch t := e.(type) {
+		case *ast.Ident:
+			switch t.Name {
+			case "int", "int8", "int16", "int32", "int64":
+				return kInt
+			case "uint", "uint8", "uint16", "uint32", "uint64", "byte":
+				return kUint
+			case "bool":
+				return kBool
+			case "string":
+				return kText
+			case "any":
+				return kAny
+			}
+			d, ok := g.decls[t.Name]
+			if !ok {
+				return kOther
+			}
+			if _, ok := d.(*ast.StructType); ok {
+				if _, skipped := skip[t.Name]; skipped {
+					return kOther
+				}
+				return kStruct
+			}
+			e = d
+		case *ast.InterfaceType:
+			if t.Methods == nil || len(t.Methods.List) == 0 {
+				return kAny
+			}
+			return kOther
+		default:
+			return kOther
+		}
+	}
+}
+
+func (g *gen) encField(f field) error {
+	v := "x." + f.name
+	switch t := f.typ.(type) {
+	case *ast.StarExpr:
+		switch g.kind(t.X) {
+		case kInt:
+			g.p("cbor.OptInt(m, %q, %s)", f.key, v)
+		case kUint:
+			g.p("cbor.OptUint(m, %q, %s)", f.key, v)
+		case kBool:
+			if !isIdent(t.X, "bool") {
+				return fmt.Errorf("field %s: named bool", f.name)
+			}
+			g.p("cbor.OptBool(m, %q, %s)", f.key, v)
+		case kStruct:
+			g.p("if %s != nil {", v)
+			g.p("m.Value(%q, *%s)", f.key, v)
+			g.p("}")
+		default:
+			return fmt.Errorf("field %s: unsupported type %s", f.name, exprString(f.typ))
+		}
+		return nil
+	case *ast.ArrayType:
+		if t.Len != nil {
+			return fmt.Errorf("field %s: arrays not supported", f.name)
+		}
+		if isIdent(t.Elt, "byte") || isIdent(t.Elt, "uint8") {
+			g.p("m.Blob(%q, %s)", f.key, v)
+			return nil
+		}
+		if isIdent(t.Elt, "string") {
+			g.p("m.Texts(%q, %s)", f.key, v)
+			return nil
+		}
+		var item string
+		switch g.kind(t.Elt) {
+		case kInt:
+			item = fmt.Sprintf("e.Int(int64(%s[i]))", v)
+		case kUint:
+			item = fmt.Sprintf("e.Uint(uint64(%s[i]))", v)
+		case kBool:
+			item = fmt.Sprintf("e.Bool(bool(%s[i]))", v)
+		case kText:
+			item = fmt.Sprintf("e.Text(string(%s[i]))", v)
+		case kStruct:
+			item = fmt.Sprintf("%s[i].MarshalCBOR(e)", v)
+		default:
+			return fmt.Errorf("field %s: unsupported type %s", f.name, exprString(f.typ))
+		}
+		g.p("m.Array(%q, len(%s), func(e *cbor.Encoder, i int) { %s })", f.key, v, item)
+		return nil
+	}
+	switch g.kind(f.typ) {
+	case kInt:
+		g.p("m.Int(%q, %s)", f.key, conv(f.typ, "int64", v))
+	case kUint:
+		g.p("m.Uint(%q, %s)", f.key, conv(f.typ, "uint64", v))
+	case kBool:
+		g.p("m.Bool(%q, %s)", f.key, conv(f.typ, "bool", v))
+	case kText:
+		g.p("m.Text(%q, %s)", f.key, conv(f.typ, "string", v))
+	case kStruct:
+		g.p("m.Value(%q, %s)", f.key, v)
+	case kAny:
+		g.p("if %s != nil {", v)
+		g.p("m.Put(%q, func(e *cbor.Encoder) { putPayload(e, %s) })", f.key, v)
+		g.p("}")
+	default:
+		return fmt.Errorf("field %s: unsupported type %s", f.name, exprString(f.typ))
+	}
+	return nil
+}
+
+func (g *gen) decField(f field) error {
+	v := "x." + f.name
+	switch t := f.typ.(type) {
+	case *ast.StarExpr:
+		switch g.kind(t.X) {
+		case kInt:
+			g.p("return cbor.ReadOptInt(d, &%s)", v)
+		case kUint:
+			g.p("return cbor.ReadOptUint(d, &%s)", v)
+		case kBool:
+			g.p("return cbor.ReadOptBool(d, &%s)", v)
+		case kStruct:
+			g.p("%s = new(%s)", v, exprString(t.X))
+			g.p("return %s.UnmarshalCBOR(d)", v)
+		}
+		return nil
+	case *ast.ArrayType:
+		if isIdent(t.Elt, "byte") || isIdent(t.Elt, "uint8") {
+			g.p("b, err := d.Blob()")
+			g.p("%s = b", v)
+			g.p("return err")
+			return nil
+		}
+		if isIdent(t.Elt, "string") {
+			g.p("return cbor.ReadTexts(d, &%s)", v)
+			return nil
+		}
+		elt := exprString(t.Elt)
+		g.p("return d.Array(func(d *cbor.Decoder) error {")
+		g.p("var v %s", elt)
+		switch g.kind(t.Elt) {
+		case kInt:
+			g.p("err := cbor.ReadInt(d, &v)")
+		case kUint:
+			g.p("err := cbor.ReadUint(d, &v)")
+		case kBool:
+			g.p("err := cbor.ReadBool(d, &v)")
+		case kText:
+			g.p("err := cbor.ReadText(d, &v)")
+		case kStruct:
+			g.p("err := v.UnmarshalCBOR(d)")
+		}
+		g.p("%s = append(%s, v)", v, v)
+		g.p("return err")
+		g.p("})")
+		return nil
+	}
+	switch g.kind(f.typ) {
+	case kInt:
+		g.p("return cbor.ReadInt(d, &%s)", v)
+	case kUint:
+		g.p("return cbor.ReadUint(d, &%s)", v)
+	case kBool:
+		g.p("return cbor.ReadBool(d, &%s)", v)
+	case kText:
+		g.p("return cbor.ReadText(d, &%s)", v)
+	case kStruct:
+		g.p("return %s.UnmarshalCBOR(d)", v)
+	case kAny:
+		g.p("return readPayload(d, &%s)", v)
+	}
+	return nil
+}
+
+// conv converts v of type t to base unless it already is one.
+func conv(t ast.Expr, base, v string) string {
+	if isIdent(t, base) {
+		return v
+	}
+	return base + "(" + v + ")"
+}
+
+func isIdent(e ast.Expr, name string) bool {
+	id, ok := e.(*ast.Ident)
+	return ok && id.Name == name
+}
+
+func exprString(e ast.Expr) string {
+	var b bytes.Buffer
+	_ = format.Node(&b, token.NewFileSet(), e)
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package types
+
+// ------------------------
+// Cellular modem
+// ------------------------
+
+type ModemInfo struct {
+	Bus  string `json:"bus"`
+	Baud uint32 `json:"baud"`
+}
+
+// Retained: hal/cap/<domain>/modem/<name>/value. Published after each
+// poll (and on entering/leaving a data session). While Data is set the
+// AT channel belongs to the session and the radio fields are stale.
+type ModemValue struct {
+	Ready      bool   `json:"ready"` // answered AT and finished init
+	SIM        string `json:"sim"`   // "ready","absent","pin","puk","unknown"
+	Registered bool   `json:"registered"`
+	Roaming    bool   `json:"roaming"`
+	CSQ        uint8  `json:"csq"`      // +CSQ rssi 0..31, 99 unknown
+	RSSIdBm    int16  `json:"rssi_dbm"` // from CSQ; 0 when unknown
+	Data       bool   `json:"data"`     // a data session owns the port
+	TS         int64  `json:"ts_ns"`
+}
+
+// Event: …/event/identity, once after init.
+type ModemIdentity struct {
+	Model    string `json:"model"`
+	Revision string `json:"revision"`
+	IMEI     string `json:"imei"`
+}
+
+// Control: …/control/command. Runs one AT command (e.g. "AT+COPS?") and
+// emits …/event/response. Refused while a data session is open.
+type ModemCommand struct {
+	Cmd       string `json:"cmd"`
+	TimeoutMs uint32 `json:"timeout_ms,omitempty"` // default 2000
+}
+
+// Event: …/event/response.
+type ModemResponse struct {
+	Cmd   string   `json:"cmd"`
+	OK    bool     `json:"ok"`
+	Lines []string `json:"lines,omitempty"` // intermediate lines, final result last
+}
+
+// Control: …/control/session_open. Hands the UART to a data session over
+// shmring rings, as serial_raw does; the reply event is
+// …/event/session_opened (SerialSessionOpened). With Dial the modem first
+// dials the configured data number and waits for CONNECT (PPP); without,
+// the session is raw passthrough of the AT channel.
+type ModemSessionOpen struct {
+	Dial   bool `json:"dial"`
+	RXSize int  `json:"rx_size,omitempty"`
+	TXSize int  `json:"tx_size,omitempty"`
+}
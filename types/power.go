@@ -1,5 +1,10 @@
 package types
 
+// Telemetry values in this file are flat value types: no pointer or
+// optional fields, so a sample costs no heap beyond boxing into the event.
+// Pointer-optional fields are kept to control payloads (ChargerConfigure),
+// which are rare.
+
 // ------------------------
 // Battery / Charger (ltc4015)
 // ------------------------
@@ -37,6 +42,106 @@ type ChargerValue struct {
 	Sys     uint16 `json:"sys"`    // raw SYSTEM_STATUS bits
 }
 
+// ------------------------
+// System power (derived from charger + battery values)
+// ------------------------
+
+type SystemPowerInfo struct {
+	Charger string `json:"charger"` // source capability names
+	Battery string `json:"battery"`
+}
+
+// Retained value: hal/cap/power/system/<name>/value.
+// Signs follow IBAT: battery power > 0 while charging.
+type SystemPowerValue struct {
+	ISys_mA int32 `json:"isys_mA"` // ≈ IIN − IBAT
+	PIn_mW  int32 `json:"pin_mW"`  // VIN·IIN
+	PBat_mW int32 `json:"pbat_mW"` // VBAT·IBAT
+	PSys_mW int32 `json:"psys_mW"` // VSYS·ISYS
+	EffPct  uint8 `json:"eff_pct"` // (PBAT+PSYS)/PIN while on input; 0 = unknown
+}
+
+// ------------------------
+// Energy accounting (integrated from system power)
+// ------------------------
+
+type EnergyInfo struct {
+	Source    string `json:"source"`    // system power capability name
+	Days      int    `json:"days"`      // days of history kept
+	Persisted bool   `json:"persisted"` // totals survive a reboot
+}
+
+// EnergyTotals are the energies of one day, in mWh. Battery energy is
+// split by direction so charge and discharge do not cancel.
+type EnergyTotals struct {
+	In_mWh       uint32 `json:"in_mWh"`        // from the input (panel, DC)
+	ToBatt_mWh   uint32 `json:"to_batt_mWh"`   // into the battery
+	FromBatt_mWh uint32 `json:"from_batt_mWh"` // out of the battery
+	ToLoad_mWh   uint32 `json:"to_load_mWh"`   // to the system rail
+}
+
+// Retained value: hal/cap/power/energy/<name>/value, today so far. Day
+// counts UTC days since the Unix epoch; nothing is counted until the time
+// is synced (sys/time).
+type EnergyValue struct {
+	Day          int32  `json:"day"`
+	In_mWh       uint32 `json:"in_mWh"`
+	ToBatt_mWh   uint32 `json:"to_batt_mWh"`
+	FromBatt_mWh uint32 `json:"from_batt_mWh"`
+	ToLoad_mWh   uint32 `json:"to_load_mWh"`
+	TS           int64  `json:"ts_ns"`
+}
+
+// Retained: hal/cap/power/energy/<name>/history, republished at each day
+// rollover and save. Days[0] is today; Week sums all of Days.
+type EnergyHistory struct {
+	Day  int32          `json:"day"`
+	Days []EnergyTotals `json:"days"`
+	Week EnergyTotals   `json:"week"`
+	TS   int64          `json:"ts_ns"`
+}
+
+func (h EnergyHistory) Clone() any {
+	h.Days = append([]EnergyTotals(nil), h.Days...)
+	return h
+}
+
+// ------------------------
+// Rail power sensors (INA219/INA3221)
+// ------------------------
+
+type RailInfo struct {
+	Sensor     string `json:"sensor"` // "ina219","ina3221"
+	Bus        string `json:"bus"`
+	Addr       uint16 `json:"addr"`
+	Channel    uint8  `json:"channel,omitempty"` // ina3221: 1..3
+	Shunt_uOhm uint32 `json:"shunt_uohm"`
+}
+
+// Retained value: hal/cap/power/sensor/<rail>/value.
+// Current is positive flowing IN+ → IN−; P = bus × I.
+type RailValue struct {
+	Bus_mV   int32 `json:"bus_mV"`
+	Shunt_uV int32 `json:"shunt_uV"`
+	I_mA     int32 `json:"i_mA"`
+	P_mW     int32 `json:"p_mW"`
+	Alert    uint8 `json:"alert"` // 0 ok, 1 warning, 2 critical
+}
+
+// Control: …/control/set_limits. Current thresholds for the rail; 0
+// disables one. On the INA3221 they are programmed into the chip so the
+// ALERT pin fires between polls.
+type RailLimits struct {
+	Warn_mA int32 `json:"warn_mA"`
+	Crit_mA int32 `json:"crit_mA"`
+}
+
+// Event: …/event/alert, when a rail's alert level changes.
+type RailAlert struct {
+	Level string `json:"level"` // "ok","warning","critical"
+	I_mA  int32  `json:"i_mA"`
+}
+
 // Controls
 type ChargerEnable struct{ On bool }           // verb: "enable"
 type SetInputLimit struct{ MilliA int32 }      // verb: "set_input_limit"
@@ -59,6 +164,16 @@ type ChargerConfigure struct {
 	IbatLow_mA          *int32  `json:"ibat_low_mA,omitempty"`
 	DieTempHigh_mC      *int32  `json:"die_temp_high_mC,omitempty"`
 	BSRHigh_uOhmPerCell *uint32 `json:"bsr_high_uohm_per_cell,omitempty"`
+	// Lead-acid only: VCHARGE_SETTING per cell (e.g. from external temp comp).
+	VCharge_mVPerCell *int32 `json:"vcharge_mV_per_cell,omitempty"`
+
+	// Charge termination. Timers are in s; a phase timer always ends its
+	// phase, and C/x termination (when on) can end it sooner.
+	MaxChargeTime_s *uint16 `json:"max_charge_time_s,omitempty"`
+	MaxCVTime_s     *uint16 `json:"max_cv_time_s,omitempty"`     // lithium only
+	MaxAbsorbTime_s *uint16 `json:"max_absorb_time_s,omitempty"` // lead-acid and LiFePO4 only
+	COverX_mA       *int32  `json:"c_over_x_mA,omitempty"`       // C/x comparator threshold
+	COverXTerm      *bool   `json:"c_over_x_term,omitempty"`
 
 	// Windows (0/0 is permitted; driver writes codes as given)
 	VinLo_mV         *int32  `json:"vin_lo_mV,omitempty"`
@@ -81,6 +196,53 @@ type ChargerAlertMask struct {
 	ChgStatus *uint16 `json:"chg_status,omitempty"` // ltc4015.ChargeStatusEnable
 }
 
+// Event payload: hal/cap/power/battery/<name>/event/bsr_complete.
+// A scheduled (or run_bsr) battery series resistance measurement finished.
+// Trend holds recent results, oldest first, including this one.
+type BSRResult struct {
+	BSR_uOhmPerCell uint32   `json:"bsr_uohm_per_cell"`
+	ICharge_mA      int32    `json:"icharge_mA"` // charge current the measurement used
+	Trend           []uint32 `json:"trend"`
+}
+
+// Verb payload: solar_sweep. VIN_UVCL is stepped from From_mV to To_mV
+// (either direction) in Step_mV steps, holding each for Settle_ms before
+// VIN and IIN are read. IinLimit_mA, if set, replaces the input current
+// limit for the sweep. Both settings are restored afterwards.
+type SolarSweep struct {
+	From_mV     int32  `json:"from_mV"`
+	To_mV       int32  `json:"to_mV"`
+	Step_mV     int32  `json:"step_mV"`
+	Settle_ms   uint32 `json:"settle_ms,omitempty"`
+	IinLimit_mA int32  `json:"iin_limit_mA,omitempty"`
+}
+
+// IVPoint is one operating point recorded by a solar sweep.
+type IVPoint struct {
+	UVCL_mV int32 `json:"uvcl_mV"` // requested VIN_UVCL
+	VIN_mV  int32 `json:"vin_mV"`
+	IIN_mA  int32 `json:"iin_mA"`
+}
+
+// Event payload: hal/cap/power/charger/<name>/event/solar_sweep.
+// Vmp/Imp are the recorded point of highest power; Pmax_mW refines it
+// with a parabola through that point and its neighbours, so it may lie
+// a little above every recorded point.
+type SolarSweepResult struct {
+	Points  []IVPoint `json:"points"`
+	Vmp_mV  int32     `json:"vmp_mV"`
+	Imp_mA  int32     `json:"imp_mA"`
+	Pmax_mW int32     `json:"pmax_mW"`
+	TS      int64     `json:"ts_ns"`
+}
+
+// Event payload: hal/cap/power/charger/<name>/event/vin_collapse_warning.
+// Slope is negative (VIN falling).
+type VinCollapseWarning struct {
+	VIN_mV     int32 `json:"vin_mV"`
+	Slope_mVps int32 `json:"slope_mVps"`
+}
+
 // ------------ Small payloads for verbs ------------
 
 type VinWindowSet struct{ Lo_mV, Hi_mV int32 }
@@ -91,6 +253,14 @@ type VoltageMV struct{ MilliV int32 }
 type TempMilliC struct{ MilliC int32 }
 type ResistanceMicroOhmPerCell struct{ MicroOhmPerCell uint32 }
 type NTCRatioWindowRaw struct{ Hi, Lo uint16 }
+type DurationS struct{ Seconds uint16 }
+
+// Verb payload: set_c_over_x. Sets the C/x threshold and whether reaching
+// it ends the charge phase.
+type COverXSet struct {
+	Threshold_mA int32 `json:"threshold_mA"`
+	Term         bool  `json:"term"`
+}
 
 type ChargerConfigBitsUpdate struct {
 	Set, Clear uint16
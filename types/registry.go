@@ -0,0 +1,212 @@
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"devicecode-go/x/cbor"
+)
+
+// ------------------------
+// Payload registry (JSON tooling: CLI, recorder; CBOR: bridge, NV)
+// ------------------------
+
+// Bus payloads are concrete Go values. Tools that move them through JSON
+// name the type with PayloadName and rebuild it with DecodePayload; those
+// that move them as CBOR use MarshalPayload and UnmarshalPayload.
+
+func dec[T any](b []byte) (any, error) {
+	var v T
+	err := json.Unmarshal(b, &v)
+	return v, err
+}
+
+var payloadDecoders = map[string]func([]byte) (any, error){
+	// env
+	"TemperatureInfo":  dec[TemperatureInfo],
+	"TemperatureValue": dec[TemperatureValue],
+	"OneWireDiscovery": dec[OneWireDiscovery],
+	"HumidityInfo":     dec[HumidityInfo],
+	"HumidityValue":    dec[HumidityValue],
+	"PressureInfo":     dec[PressureInfo],
+	"PressureValue":    dec[PressureValue],
+	"PositionInfo":     dec[PositionInfo],
+	"PositionValue":    dec[PositionValue],
+	"TimeInfo":         dec[TimeInfo],
+	"TimeValue":        dec[TimeValue],
+	// gpio / pwm
+	"ButtonInfo":    dec[ButtonInfo],
+	"ButtonValue":   dec[ButtonValue],
+	"ButtonGesture": dec[ButtonGesture],
+	"CounterInfo":   dec[CounterInfo],
+	"CounterValue":  dec[CounterValue],
+	"LEDInfo":       dec[LEDInfo],
+	"LEDValue":      dec[LEDValue],
+	"LEDSet":        dec[LEDSet],
+	"SwitchInfo":    dec[SwitchInfo],
+	"SwitchValue":   dec[SwitchValue],
+	"SwitchSet":     dec[SwitchSet],
+	"GPIOInfo":      dec[GPIOInfo],
+	"GPIOValue":     dec[GPIOValue],
+	"GPIOSet":       dec[GPIOSet],
+	"BuzzerInfo":    dec[BuzzerInfo],
+	"BuzzerValue":   dec[BuzzerValue],
+	"BuzzerBeep":    dec[BuzzerBeep],
+	"BuzzerPlay":    dec[BuzzerPlay],
+	"BuzzerStop":    dec[BuzzerStop],
+	// led strip
+	"LEDStripInfo":       dec[LEDStripInfo],
+	"LEDStripValue":      dec[LEDStripValue],
+	"LEDStripSet":        dec[LEDStripSet],
+	"LEDStripFill":       dec[LEDStripFill],
+	"LEDStripBrightness": dec[LEDStripBrightness],
+	// composite
+	"ThermostatInfo":   dec[ThermostatInfo],
+	"ThermostatValue":  dec[ThermostatValue],
+	"ThermostatSet":    dec[ThermostatSet],
+	"ThermostatEnable": dec[ThermostatEnable],
+	"PWMInfo":          dec[PWMInfo],
+	"PWMValue":         dec[PWMValue],
+	"PWMSet":           dec[PWMSet],
+	"PWMRamp":          dec[PWMRamp],
+	"PWMFreq":          dec[PWMFreq],
+	// power
+	"BatteryInfo":               dec[BatteryInfo],
+	"BatteryValue":              dec[BatteryValue],
+	"ChargerInfo":               dec[ChargerInfo],
+	"ChargerValue":              dec[ChargerValue],
+	"RailInfo":                  dec[RailInfo],
+	"RailValue":                 dec[RailValue],
+	"RailLimits":                dec[RailLimits],
+	"RailAlert":                 dec[RailAlert],
+	"SystemPowerInfo":           dec[SystemPowerInfo],
+	"SystemPowerValue":          dec[SystemPowerValue],
+	"EnergyInfo":                dec[EnergyInfo],
+	"EnergyValue":               dec[EnergyValue],
+	"EnergyHistory":             dec[EnergyHistory],
+	"ChargerConfigure":          dec[ChargerConfigure],
+	"ChargerAlertMask":          dec[ChargerAlertMask],
+	"ChargerConfigBitsUpdate":   dec[ChargerConfigBitsUpdate],
+	"VinWindowSet":              dec[VinWindowSet],
+	"VinCollapseWarning":        dec[VinCollapseWarning],
+	"BSRResult":                 dec[BSRResult],
+	"SolarSweep":                dec[SolarSweep],
+	"SolarSweepResult":          dec[SolarSweepResult],
+	"VbatWindowSet":             dec[VbatWindowSet],
+	"VsysWindowSet":             dec[VsysWindowSet],
+	"CurrentMA":                 dec[CurrentMA],
+	"VoltageMV":                 dec[VoltageMV],
+	"TempMilliC":                dec[TempMilliC],
+	"ResistanceMicroOhmPerCell": dec[ResistanceMicroOhmPerCell],
+	"NTCRatioWindowRaw":         dec[NTCRatioWindowRaw],
+	"DurationS":                 dec[DurationS],
+	"COverXSet":                 dec[COverXSet],
+	// serial
+	"SerialInfo":           dec[SerialInfo],
+	"SerialSessionOpen":    dec[SerialSessionOpen],
+	"SerialSessionOpened":  dec[SerialSessionOpened],
+	"SerialSessionExpired": dec[SerialSessionExpired],
+	"SerialSetBaud":        dec[SerialSetBaud],
+	"SerialSetFormat":      dec[SerialSetFormat],
+	"SerialStats":          dec[SerialStats],
+	"SerialSetFlowControl": dec[SerialSetFlowControl],
+	"SerialLoopback":       dec[SerialLoopback],
+	"SerialLoopbackResult": dec[SerialLoopbackResult],
+	"PassthruStart":        dec[PassthruStart],
+	"PassthruState":        dec[PassthruState],
+	// modem
+	"ModemInfo":        dec[ModemInfo],
+	"ModemValue":       dec[ModemValue],
+	"ModemIdentity":    dec[ModemIdentity],
+	"ModemCommand":     dec[ModemCommand],
+	"ModemResponse":    dec[ModemResponse],
+	"ModemSessionOpen": dec[ModemSessionOpen],
+	// hal
+	"HALState":         dec[HALState],
+	"CapabilityStatus": dec[CapabilityStatus],
+	"CapabilityVerbs":  dec[CapabilityVerbs],
+	"Catalog":          dec[Catalog],
+	"ResourceMap":      dec[ResourceMap],
+	"ConfigBlob":       dec[ConfigBlob],
+	"ConfigImport":     dec[ConfigImport],
+	"ConfigStaged":     dec[ConfigStaged],
+	"NVGet":            dec[NVGet],
+	"NVPut":            dec[NVPut],
+	"NVRecord":         dec[NVRecord],
+	"LastLog":          dec[LastLog],
+	"PollStart":        dec[PollStart],
+	"PollStop":         dec[PollStop],
+	"PollBurst":        dec[PollBurst],
+	"ReadSync":         dec[ReadSync],
+	"ControlDone":      dec[ControlDone],
+	"Quarantine":       dec[Quarantine],
+	"EventStorm":       dec[EventStorm],
+	"AlarmState":       dec[AlarmState],
+	"GroupState":       dec[GroupState],
+	"PowerSet":         dec[PowerSet],
+	"PowerState":       dec[PowerState],
+	"OKReply":          dec[OKReply],
+	"ErrorReply":       dec[ErrorReply],
+	"BroadcastReply":   dec[BroadcastReply],
+	// sys
+	"MetricsSnapshot":  dec[MetricsSnapshot],
+	"LogConfig":        dec[LogConfig],
+	"ServiceState":     dec[ServiceState],
+	"TimeSet":          dec[TimeSet],
+	"SysTime":          dec[SysTime],
+	"ReactorIncidents": dec[ReactorIncidents],
+	"ReactorConfig":    dec[ReactorConfig],
+	"SelfCheckResult":  dec[SelfCheckResult],
+	"LEDPattern":       dec[LEDPattern],
+	"TestPlan":         dec[TestPlan],
+	"TestReport":       dec[TestReport],
+}
+
+// PayloadName returns the bare Go type name of v ("" for nil or unnamed).
+// Pointers are named after their element type.
+func PayloadName(v any) string {
+	if v == nil {
+		return ""
+	}
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// DecodePayload rebuilds a payload of the named type from JSON. ok is false
+// if the name is not registered.
+func DecodePayload(name string, b []byte) (v any, ok bool, err error) {
+	d, ok := payloadDecoders[name]
+	if !ok {
+		return nil, false, nil
+	}
+	v, err = d(b)
+	return v, true, err
+}
+
+// MarshalPayload returns v's type name and its canonical CBOR encoding,
+// the one wire format the bridge, NV records and config export share. ok
+// is false for types without one (see cborgen).
+func MarshalPayload(v any) (name string, b []byte, ok bool) {
+	name, m, ok := cborMarshaler(v)
+	if !ok {
+		return "", nil, false
+	}
+	return name, cbor.Marshal(m), true
+}
+
+// UnmarshalPayload rebuilds a payload of the named type from CBOR. ok is
+// false if the type has no CBOR encoding.
+func UnmarshalPayload(name string, b []byte) (v any, ok bool, err error) {
+	d, ok := cborPayloads[name]
+	if !ok {
+		return nil, false, nil
+	}
+	dec := cbor.NewDecoder(b)
+	if v, err = d(dec); err == nil {
+		err = dec.Done()
+	}
+	return v, true, err
+}
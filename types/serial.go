@@ -29,6 +29,9 @@ type SerialSessionOpen struct {
 	// Power-of-two sizes (bytes). Device will default if zero.
 	RXSize int `json:"rx_size,omitempty"`
 	TXSize int `json:"tx_size,omitempty"`
+	// Close the session after this long without client activity (reading
+	// RX, writing TX or session_keepalive). 0 uses the device default.
+	IdleTimeoutMs uint32 `json:"idle_timeout_ms,omitempty"`
 }
 
 type SerialSessionClose struct{}
@@ -43,13 +46,79 @@ type SerialSetFormat struct {
 	Parity   Parity `json:"parity"`
 }
 
+type SerialSetFlowControl struct {
+	Enabled bool `json:"enabled"` // RTS/CTS
+}
+
 type SerialSessionOpened struct {
 	SessionID uint32 `json:"session_id"`
 	RXHandle  uint32 `json:"rx_handle"`
 	TXHandle  uint32 `json:"tx_handle"`
 }
 
+// Event: …/event/session_expired. The device closed an idle session; its
+// ring handles are no longer valid.
+type SerialSessionExpired struct {
+	SessionID uint32 `json:"session_id"`
+	IdleMs    uint32 `json:"idle_ms"`
+}
+
+// Retained value of a serial capability while a session is open.
+type SerialStats struct {
+	RXOverruns uint32 `json:"rx_overruns"` // cumulative hardware RX FIFO overruns
+}
+
+// Control: …/control/run_loopback. Drives TX with Pattern for DurationMs
+// and checks what comes back on RX; needs an external TX→RX jumper and no
+// open session. Pattern is "counter" (default) or "prbs7".
+type SerialLoopback struct {
+	DurationMs uint32 `json:"duration_ms"`
+	Pattern    string `json:"pattern,omitempty"`
+}
+
+// Event: …/event/loopback_result. Comparison is positional, so a dropped
+// byte shows as TXBytes > RXBytes and errors for the rest of the run.
+type SerialLoopbackResult struct {
+	Pattern     string `json:"pattern"`
+	DurationMs  uint32 `json:"duration_ms"`
+	TXBytes     uint32 `json:"tx_bytes"`
+	RXBytes     uint32 `json:"rx_bytes"`
+	ByteErrors  uint32 `json:"byte_errors"`
+	BitErrors   uint32 `json:"bit_errors"`
+	BitsChecked uint32 `json:"bits_checked"`
+	RXBytesPerS uint32 `json:"rx_bytes_per_s"`
+}
+
 type SerialInfo struct {
 	Bus  string `json:"bus"`
 	Baud uint32 `json:"baud"` // 0 if unspecified
 }
+
+// ------------------------
+// Passthrough (services/passthru)
+// ------------------------
+
+// Control: passthru/control/start. Joins serial capabilities A and B (in
+// the io domain) byte for byte, e.g. a USB CDC port to the CM5 console
+// UART. Escape, typed on A, ends the link; "" uses "\r~." and "none"
+// disables it. IdleTimeoutMs, if set, ends the link after that long with
+// no bytes either way. Replies OKReply once both sessions are open.
+type PassthruStart struct {
+	A             string `json:"a"`
+	B             string `json:"b"`
+	Escape        string `json:"escape,omitempty"`
+	IdleTimeoutMs uint32 `json:"idle_timeout_ms,omitempty"`
+}
+
+// Retained: passthru/state. Counts are for the current (or last) link.
+// Ended says why the last link ended: "stop", "escape", "idle",
+// "session_closed" or "session_expired".
+type PassthruState struct {
+	Active bool   `json:"active"`
+	A      string `json:"a,omitempty"`
+	B      string `json:"b,omitempty"`
+	AToB   uint32 `json:"a_to_b"`
+	BToA   uint32 `json:"b_to_a"`
+	Ended  string `json:"ended,omitempty"`
+	TS     int64  `json:"ts_ns"`
+}
@@ -0,0 +1,182 @@
+package types
+
+// ------------------------
+// System metrics
+// ------------------------
+
+// Retained: sys/metrics
+type MetricsSnapshot struct {
+	TS      int64          `json:"ts_ns"`
+	Metrics []MetricSample `json:"metrics"`
+}
+
+// Value is the count (counter), level (gauge) or total observations
+// (histogram). Histograms also carry their bounds and per-bucket counts;
+// the last bucket counts values above the final bound.
+type MetricSample struct {
+	Name    string   `json:"name"`
+	Value   int64    `json:"v"`
+	Bounds  []int64  `json:"bounds,omitempty"`
+	Buckets []uint32 `json:"buckets,omitempty"`
+}
+
+// ------------------------
+// Services
+// ------------------------
+
+type ServiceStateName string
+
+const (
+	ServiceWaiting    ServiceStateName = "waiting"    // for the services it runs after
+	ServiceStarting   ServiceStateName = "starting"   // running, not yet ready
+	ServiceReady      ServiceStateName = "ready"      // running and ready
+	ServiceRestarting ServiceStateName = "restarting" // exited; restarts after a back-off
+	ServiceExited     ServiceStateName = "exited"     // exited and not restarted
+	ServiceFailed     ServiceStateName = "failed"     // not ready in time, or never started
+	ServiceStopped    ServiceStateName = "stopped"    // stopped by the supervisor
+)
+
+// Retained: sys/service/<name>/state, published by the supervisor on
+// each change. Error says why a service failed ("ready_timeout", or
+// "blocked" when one it runs after failed).
+type ServiceState struct {
+	Name     string           `json:"name"`
+	State    ServiceStateName `json:"state"`
+	Restarts uint16           `json:"restarts"`
+	Error    string           `json:"error,omitempty"`
+	TS       int64            `json:"ts_ns"`
+}
+
+// ------------------------
+// Wall-clock time
+// ------------------------
+
+// TimeSet is the host's wall-clock time, sent on sys/time/control/set
+// (over the bridge) as close to the moment it names as the link allows.
+type TimeSet struct {
+	EpochMs int64 `json:"epoch_ms"`
+}
+
+// Retained: sys/time, published by services/timesync on each sync.
+// OffsetMs is the wall clock minus the local clock, as disciplined; the
+// epoch at local time t (Unix ns) is t/1e6 + OffsetMs. Source is "host" or
+// "gnss"; StepMs is the error the last sync found before correcting it.
+type SysTime struct {
+	Synced   bool   `json:"synced"`
+	Source   string `json:"source,omitempty"`
+	EpochMs  int64  `json:"epoch_ms"`
+	OffsetMs int64  `json:"offset_ms"`
+	StepMs   int64  `json:"step_ms"`
+	Syncs    uint32 `json:"syncs"`
+	TS       int64  `json:"ts_ns"`
+}
+
+// ------------------------
+// Reactor incidents
+// ------------------------
+
+// Retained: reactor/incidents. Lifetime counts of protective actions, for
+// prioritising field visits. OverTemp counts over-temp latch activations;
+// EmergencyDown counts emergency down-sequences (the rails cut while up or
+// coming up, for any reason but a user request). Persisted is false when
+// the board has no NV storage, in which case counts start at each boot.
+type ReactorIncidents struct {
+	OverTemp      uint32 `json:"over_temp"`
+	EmergencyDown uint32 `json:"emergency_down"`
+	Persisted     bool   `json:"persisted"`
+	TS            int64  `json:"ts_ns"`
+}
+
+// ------------------------
+// Reactor thresholds
+// ------------------------
+
+// ReactorConfig is the reactor's rail-control thresholds. Retained:
+// reactor/config carries the values in force; publishing one on
+// config/reactor (retained) replaces them, and is checked first (a limit
+// must sit above its sag/hysteresis point). The HAL config export and
+// import carry it alongside the HAL config.
+//
+// The over-temp latch votes two sensors, each against its own limit: the
+// board (core) sensor and the charger's NTC. Either at or over its limit
+// sets the latch; it clears only when both are fresh and at or below
+// limit-hyst. A zero ChgTempLimit leaves the charger out of the vote.
+type ReactorConfig struct {
+	TempLimit_deciC    int32  `json:"temp_limit_deci_c"`               // over-temp latch sets at or above
+	TempHyst_deciC     int32  `json:"temp_hyst_deci_c"`                // and clears at limit-hyst
+	ChgTempLimit_deciC int32  `json:"chg_temp_limit_deci_c,omitempty"` // the same for the charger NTC
+	ChgTempHyst_deciC  int32  `json:"chg_temp_hyst_deci_c,omitempty"`
+	PGOnVIN_mV         int32  `json:"pg_on_vin_mV"`   // VIN good for bring-up
+	SagVIN_mV          int32  `json:"sag_vin_mV"`     // VIN below this cannot hold the rails
+	PGOnVBAT_mV        int32  `json:"pg_on_vbat_mV"`  // VBAT good for bring-up
+	PGOffHyst_mV       int32  `json:"pg_off_hyst_mV"` // VBAT good clears at on-hyst
+	SagVBAT_mV         int32  `json:"sag_vbat_mV"`
+	DebounceOK_ms      uint32 `json:"debounce_ok_ms"` // supply good this long before bring-up
+	StaleMax_ms        uint32 `json:"stale_max_ms"`   // a reading older than this is ignored
+
+	// LED replaces the button LED pattern of the states it names; the
+	// others keep their defaults.
+	LED []LEDPattern `json:"led,omitempty"`
+
+	// SelfCheck gates the first bring-up after boot.
+	SelfCheck SelfCheckConfig `json:"self_check"`
+}
+
+// SelfCheckConfig is the startup self-check: bring-up waits until every
+// configured check passes, and is refused if they have not all passed
+// within Timeout_ms. A zero config checks nothing and passes at once.
+type SelfCheckConfig struct {
+	Required      []CapabilityAddress `json:"required,omitempty"` // present with link up
+	MinVBAT_mV    int32               `json:"min_vbat_mV"`        // battery reading at least this; 0: unchecked
+	Temps         []CapabilityAddress `json:"temps,omitempty"`    // temperature sensors that must agree
+	TempTol_deciC int32               `json:"temp_tol_deci_c"`    // widest spread among Temps
+	Timeout_ms    uint32              `json:"timeout_ms"`         // from boot; required with any check
+}
+
+// Retained: reactor/selfcheck. State is "running" until every check has
+// passed ("passed") or the time allowed runs out ("failed"); a failed or
+// running check is released by reactor/selfcheck/control/override
+// ("overridden"). Checks hold the latest verdict of each check.
+type SelfCheckResult struct {
+	State  string          `json:"state"`
+	Checks []SelfCheckItem `json:"checks,omitempty"`
+	TS     int64           `json:"ts_ns"`
+}
+
+// SelfCheckItem is one check: "cap/<domain>/<kind>/<name>", "vbat" (Value
+// in mV) or "temp_spread" (Value in deci-°C). Detail says why it has not
+// passed: "absent" or the link state for a capability, "no_reading",
+// "below_floor" or "too_wide".
+type SelfCheckItem struct {
+	Check  string `json:"check"`
+	OK     bool   `json:"ok"`
+	Value  int32  `json:"value,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// LEDPattern is how the button LED shows one reactor state: "fault",
+// "overtemp", "maintenance", "battery", "charging", "on" or "off".
+// Steps_ms alternate on and off, starting on, and repeat; a single step
+// holds the LED on, or off if it is 0.
+type LEDPattern struct {
+	State    string   `json:"state"`
+	Steps_ms []uint16 `json:"steps_ms"`
+}
+
+// ------------------------
+// Logging
+// ------------------------
+
+type LogLevel string
+
+const (
+	LogError LogLevel = "error"
+	LogWarn  LogLevel = "warn"
+	LogInfo  LogLevel = "info"
+	LogDebug LogLevel = "debug"
+)
+
+// Retained: config/log/<service>
+type LogConfig struct {
+	Level LogLevel `json:"level"`
+}
@@ -0,0 +1,65 @@
+// Package cbor encodes and decodes the subset of CBOR (RFC 8949) the
+// firmware needs, without reflection: unsigned and negative integers, byte
+// and text strings, arrays, maps with text keys, booleans and null.
+//
+// Encoding is canonical ("core deterministic", RFC 8949 §4.2.1): integers
+// and lengths use their shortest form, lengths are definite, and map keys
+// are sorted by their encoded bytes. Types write themselves with a
+// Marshaler and read themselves with an Unmarshaler; Map leaves out zero
+// values, so the same value always encodes to the same bytes and a decoder
+// that finds a key absent leaves the field zero.
+package cbor
+
+import "errors"
+
+// Major types.
+const (
+	majUint  = 0
+	majNint  = 1
+	majBytes = 2
+	majText  = 3
+	majArray = 4
+	majMap   = 5
+	majOther = 7
+)
+
+const (
+	valFalse = 0xf4
+	valTrue  = 0xf5
+	valNull  = 0xf6
+)
+
+var (
+	ErrTruncated = errors.New("cbor: truncated")
+	ErrType      = errors.New("cbor: unexpected type")
+	ErrRange     = errors.New("cbor: value out of range")
+	ErrSyntax    = errors.New("cbor: unsupported or malformed item")
+	ErrDupKey    = errors.New("cbor: duplicate map key")
+	ErrTrailing  = errors.New("cbor: trailing bytes")
+)
+
+// Marshaler is implemented by types that encode themselves.
+type Marshaler interface {
+	MarshalCBOR(e *Encoder)
+}
+
+// Unmarshaler is implemented by types that decode themselves.
+type Unmarshaler interface {
+	UnmarshalCBOR(d *Decoder) error
+}
+
+// Marshal encodes v.
+func Marshal(v Marshaler) []byte {
+	var e Encoder
+	v.MarshalCBOR(&e)
+	return e.b
+}
+
+// Unmarshal decodes b, which must hold exactly one item, into v.
+func Unmarshal(b []byte, v Unmarshaler) error {
+	d := NewDecoder(b)
+	if err := v.UnmarshalCBOR(d); err != nil {
+		return err
+	}
+	return d.Done()
+}
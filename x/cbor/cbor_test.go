@@ -0,0 +1,158 @@
+package cbor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func enc(f func(e *Encoder)) string {
+	var e Encoder
+	f(&e)
+	return hex.EncodeToString(e.Bytes())
+}
+
+// Vectors from RFC 8949 Appendix A.
+func TestEncode_Vectors(t *testing.T) {
+	cases := []struct {
+		f    func(e *Encoder)
+		want string
+	}{
+		{func(e *Encoder) { e.Uint(0) }, "00"},
+		{func(e *Encoder) { e.Uint(23) }, "17"},
+		{func(e *Encoder) { e.Uint(24) }, "1818"},
+		{func(e *Encoder) { e.Uint(1000) }, "1903e8"},
+		{func(e *Encoder) { e.Uint(1000000) }, "1a000f4240"},
+		{func(e *Encoder) { e.Uint(1000000000000) }, "1b000000e8d4a51000"},
+		{func(e *Encoder) { e.Int(-1) }, "20"},
+		{func(e *Encoder) { e.Int(-1000) }, "3903e7"},
+		{func(e *Encoder) { e.Bool(false) }, "f4"},
+		{func(e *Encoder) { e.Null() }, "f6"},
+		{func(e *Encoder) { e.Text("IETF") }, "6449455446"},
+		{func(e *Encoder) { e.Blob([]byte{1, 2, 3, 4}) }, "4401020304"},
+		{func(e *Encoder) { e.Array(3, func(e *Encoder, i int) { e.Uint(uint64(i + 1)) }) }, "83010203"},
+	}
+	for i, c := range cases {
+		if got := enc(c.f); got != c.want {
+			t.Errorf("case %d: got %s want %s", i, got, c.want)
+		}
+	}
+}
+
+func TestMap_CanonicalOrderAndZeroes(t *testing.T) {
+	got := enc(func(e *Encoder) {
+		e.Map(func(m *Map) {
+			m.Text("bb", "x")
+			m.Uint("a", 1)
+			m.Uint("zero", 0)   // left out
+			m.Text("empty", "") // left out
+			m.Bool("c", true)
+			var z int32
+			OptInt(m, "opt", &z) // set: written even though zero
+		})
+	})
+	// {"a":1,"c":true,"bb":"x","opt":0}
+	want := "a4" + "6161" + "01" + "6163" + "f5" + "626262" + "6178" + "636f7074" + "00"
+	if got != want {
+		t.Fatalf("got %s\nwant %s", got, want)
+	}
+}
+
+type sample struct {
+	Name  string
+	N     int32
+	U     uint16
+	On    bool
+	Tags  []string
+	Opt   *uint32
+	Blob  []byte
+	Inner *sample
+}
+
+func (s sample) MarshalCBOR(e *Encoder) {
+	e.Map(func(m *Map) {
+		m.Text("name", s.Name)
+		m.Int("n", int64(s.N))
+		m.Uint("u", uint64(s.U))
+		m.Bool("on", s.On)
+		m.Texts("tags", s.Tags)
+		OptUint(m, "opt", s.Opt)
+		m.Blob("blob", s.Blob)
+		if s.Inner != nil {
+			m.Value("inner", *s.Inner)
+		}
+	})
+}
+
+func (s *sample) UnmarshalCBOR(d *Decoder) error {
+	return d.Map(func(k string, d *Decoder) error {
+		switch k {
+		case "name":
+			return ReadText(d, &s.Name)
+		case "n":
+			return ReadInt(d, &s.N)
+		case "u":
+			return ReadUint(d, &s.U)
+		case "on":
+			return ReadBool(d, &s.On)
+		case "tags":
+			return ReadTexts(d, &s.Tags)
+		case "opt":
+			return ReadOptUint(d, &s.Opt)
+		case "blob":
+			b, err := d.Blob()
+			s.Blob = b
+			return err
+		case "inner":
+			s.Inner = new(sample)
+			return s.Inner.UnmarshalCBOR(d)
+		}
+		return d.Skip()
+	})
+}
+
+func TestRoundTrip(t *testing.T) {
+	opt := uint32(0)
+	in := sample{Name: "x", N: -70000, U: 65535, On: true, Tags: []string{"a", "b"}, Opt: &opt,
+		Blob: []byte{0, 1}, Inner: &sample{Name: "in"}}
+	b := Marshal(in)
+	var out sample
+	if err := Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "x" || out.N != -70000 || out.U != 65535 || !out.On || len(out.Tags) != 2 ||
+		out.Opt == nil || *out.Opt != 0 || !bytes.Equal(out.Blob, in.Blob) || out.Inner == nil || out.Inner.Name != "in" {
+		t.Fatalf("round trip = %+v", out)
+	}
+	if !bytes.Equal(Marshal(out), b) {
+		t.Fatal("re-encoding differs")
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	h := func(s string) []byte { b, _ := hex.DecodeString(s); return b }
+	var s sample
+	cases := []struct {
+		in   string
+		want error
+	}{
+		{"a1616e", ErrTruncated},       // value missing
+		{"a1616e1a0001", ErrTruncated}, // short uint32
+		{"a1617519ffff" + "00", ErrTrailing},
+		{"a161751a00010000", ErrRange}, // 65536 into uint16
+		{"a1616e6178", ErrType},        // text into int32
+		{"a2616e01616e02", ErrDupKey},
+		{"bf", ErrSyntax},                   // indefinite map
+		{"a1617a" + "81" + "9f", ErrSyntax}, // unknown key, indefinite array inside
+	}
+	for _, c := range cases {
+		s = sample{}
+		if err := Unmarshal(h(c.in), &s); err != c.want {
+			t.Errorf("%s: err = %v, want %v", c.in, err, c.want)
+		}
+	}
+	// Unknown keys are skipped, nested included.
+	if err := Unmarshal(h("a2617aa161788201f6616e05"), &s); err != nil || s.N != 5 {
+		t.Fatalf("skip: err %v n %d", err, s.N)
+	}
+}
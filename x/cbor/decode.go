@@ -0,0 +1,302 @@
+package cbor
+
+// maxDepth bounds nesting when skipping unknown values.
+const maxDepth = 16
+
+// Decoder reads CBOR items from a buffer.
+type Decoder struct {
+	b   []byte
+	off int
+}
+
+func NewDecoder(b []byte) *Decoder { return &Decoder{b: b} }
+
+// Done reports ErrTrailing if any bytes are left.
+func (d *Decoder) Done() error {
+	if d.off != len(d.b) {
+		return ErrTrailing
+	}
+	return nil
+}
+
+// head reads an item header. Indefinite lengths and reserved
+// additional-information values are rejected.
+func (d *Decoder) head() (maj byte, v uint64, err error) {
+	if d.off >= len(d.b) {
+		return 0, 0, ErrTruncated
+	}
+	ib := d.b[d.off]
+	maj, ai := ib>>5, ib&0x1f
+	d.off++
+	var n int
+	switch {
+	case ai < 24:
+		return maj, uint64(ai), nil
+	case ai == 24:
+		n = 1
+	case ai == 25:
+		n = 2
+	case ai == 26:
+		n = 4
+	case ai == 27:
+		n = 8
+	default:
+		return 0, 0, ErrSyntax
+	}
+	if len(d.b)-d.off < n {
+		return 0, 0, ErrTruncated
+	}
+	for i := 0; i < n; i++ {
+		v = v<<8 | uint64(d.b[d.off+i])
+	}
+	d.off += n
+	return maj, v, nil
+}
+
+// peek returns the next initial byte without consuming it.
+func (d *Decoder) peek() (byte, error) {
+	if d.off >= len(d.b) {
+		return 0, ErrTruncated
+	}
+	return d.b[d.off], nil
+}
+
+// Null consumes a null if one is next.
+func (d *Decoder) Null() bool {
+	if b, err := d.peek(); err == nil && b == valNull {
+		d.off++
+		return true
+	}
+	return false
+}
+
+func (d *Decoder) Uint() (uint64, error) {
+	maj, v, err := d.head()
+	if err != nil {
+		return 0, err
+	}
+	if maj != majUint {
+		return 0, ErrType
+	}
+	return v, nil
+}
+
+func (d *Decoder) Int() (int64, error) {
+	maj, v, err := d.head()
+	if err != nil {
+		return 0, err
+	}
+	if maj != majUint && maj != majNint {
+		return 0, ErrType
+	}
+	if v > 1<<63-1 {
+		return 0, ErrRange
+	}
+	if maj == majNint {
+		return -1 - int64(v), nil
+	}
+	return int64(v), nil
+}
+
+func (d *Decoder) Bool() (bool, error) {
+	b, err := d.peek()
+	if err != nil {
+		return false, err
+	}
+	if b != valTrue && b != valFalse {
+		return false, ErrType
+	}
+	d.off++
+	return b == valTrue, nil
+}
+
+func (d *Decoder) str(want byte) ([]byte, error) {
+	maj, n, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	if maj != want {
+		return nil, ErrType
+	}
+	if uint64(len(d.b)-d.off) < n {
+		return nil, ErrTruncated
+	}
+	s := d.b[d.off : d.off+int(n)]
+	d.off += int(n)
+	return s, nil
+}
+
+func (d *Decoder) Text() (string, error) {
+	s, err := d.str(majText)
+	return string(s), err
+}
+
+// Blob returns a copy of a byte string.
+func (d *Decoder) Blob() ([]byte, error) {
+	s, err := d.str(majBytes)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), s...), nil
+}
+
+// Array calls item once per element; item must consume exactly one item.
+func (d *Decoder) Array(item func(d *Decoder) error) error {
+	maj, n, err := d.head()
+	if err != nil {
+		return err
+	}
+	if maj != majArray {
+		return ErrType
+	}
+	if n > uint64(len(d.b)-d.off) { // each element takes at least a byte
+		return ErrTruncated
+	}
+	for i := uint64(0); i < n; i++ {
+		if err := item(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Map calls field once per entry with its text key; field must consume
+// the value, calling Skip for keys it does not know.
+func (d *Decoder) Map(field func(key string, d *Decoder) error) error {
+	maj, n, err := d.head()
+	if err != nil {
+		return err
+	}
+	if maj != majMap {
+		return ErrType
+	}
+	if n > uint64(len(d.b)-d.off)/2 {
+		return ErrTruncated
+	}
+	var seen []string
+	for i := uint64(0); i < n; i++ {
+		k, err := d.Text()
+		if err != nil {
+			return err
+		}
+		for _, s := range seen {
+			if s == k {
+				return ErrDupKey
+			}
+		}
+		seen = append(seen, k)
+		if err := field(k, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Skip consumes one item of any supported type.
+func (d *Decoder) Skip() error { return d.skip(0) }
+
+func (d *Decoder) skip(depth int) error {
+	if depth > maxDepth {
+		return ErrSyntax
+	}
+	b, err := d.peek()
+	if err != nil {
+		return err
+	}
+	if b == valFalse || b == valTrue || b == valNull {
+		d.off++
+		return nil
+	}
+	maj, n, err := d.head()
+	if err != nil {
+		return err
+	}
+	switch maj {
+	case majUint, majNint:
+		return nil
+	case majBytes, majText:
+		if uint64(len(d.b)-d.off) < n {
+			return ErrTruncated
+		}
+		d.off += int(n)
+		return nil
+	case majArray, majMap:
+		if maj == majMap {
+			n *= 2
+		}
+		if n > uint64(len(d.b)-d.off) {
+			return ErrTruncated
+		}
+		for i := uint64(0); i < n; i++ {
+			if err := d.skip(depth + 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return ErrSyntax
+}
+
+// Typed reads into fields, range-checked against the field's type.
+
+func ReadInt[T ~int | ~int8 | ~int16 | ~int32 | ~int64](d *Decoder, p *T) error {
+	v, err := d.Int()
+	if err != nil {
+		return err
+	}
+	if int64(T(v)) != v {
+		return ErrRange
+	}
+	*p = T(v)
+	return nil
+}
+
+func ReadUint[T ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64](d *Decoder, p *T) error {
+	v, err := d.Uint()
+	if err != nil {
+		return err
+	}
+	if uint64(T(v)) != v {
+		return ErrRange
+	}
+	*p = T(v)
+	return nil
+}
+
+func ReadText[T ~string](d *Decoder, p *T) error {
+	s, err := d.Text()
+	*p = T(s)
+	return err
+}
+
+func ReadBool(d *Decoder, p *bool) error {
+	v, err := d.Bool()
+	*p = v
+	return err
+}
+
+func ReadTexts(d *Decoder, p *[]string) error {
+	*p = nil
+	return d.Array(func(d *Decoder) error {
+		s, err := d.Text()
+		*p = append(*p, s)
+		return err
+	})
+}
+
+// Optional fields: allocate and read.
+
+func ReadOptInt[T ~int | ~int8 | ~int16 | ~int32 | ~int64](d *Decoder, p **T) error {
+	*p = new(T)
+	return ReadInt(d, *p)
+}
+
+func ReadOptUint[T ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64](d *Decoder, p **T) error {
+	*p = new(T)
+	return ReadUint(d, *p)
+}
+
+func ReadOptBool(d *Decoder, p **bool) error {
+	*p = new(bool)
+	return ReadBool(d, *p)
+}
@@ -0,0 +1,171 @@
+package cbor
+
+import "sort"
+
+// Encoder appends CBOR items to a buffer.
+type Encoder struct {
+	b []byte
+}
+
+// Bytes returns the encoded items.
+func (e *Encoder) Bytes() []byte { return e.b }
+
+func (e *Encoder) head(maj byte, v uint64) {
+	m := maj << 5
+	switch {
+	case v < 24:
+		e.b = append(e.b, m|byte(v))
+	case v <= 0xff:
+		e.b = append(e.b, m|24, byte(v))
+	case v <= 0xffff:
+		e.b = append(e.b, m|25, byte(v>>8), byte(v))
+	case v <= 0xffffffff:
+		e.b = append(e.b, m|26, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	default:
+		e.b = append(e.b, m|27, byte(v>>56), byte(v>>48), byte(v>>40), byte(v>>32),
+			byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	}
+}
+
+func (e *Encoder) Uint(v uint64) { e.head(majUint, v) }
+
+func (e *Encoder) Int(v int64) {
+	if v < 0 {
+		e.head(majNint, uint64(-1-v))
+		return
+	}
+	e.head(majUint, uint64(v))
+}
+
+func (e *Encoder) Bool(v bool) {
+	if v {
+		e.b = append(e.b, valTrue)
+	} else {
+		e.b = append(e.b, valFalse)
+	}
+}
+
+func (e *Encoder) Null() { e.b = append(e.b, valNull) }
+
+func (e *Encoder) Text(s string) {
+	e.head(majText, uint64(len(s)))
+	e.b = append(e.b, s...)
+}
+
+func (e *Encoder) Blob(b []byte) {
+	e.head(majBytes, uint64(len(b)))
+	e.b = append(e.b, b...)
+}
+
+// Array writes n items produced by item(e, i).
+func (e *Encoder) Array(n int, item func(e *Encoder, i int)) {
+	e.head(majArray, uint64(n))
+	for i := 0; i < n; i++ {
+		item(e, i)
+	}
+}
+
+// Map writes the map fill builds, with keys in canonical order.
+func (e *Encoder) Map(fill func(m *Map)) {
+	var m Map
+	fill(&m)
+	// Text keys: shorter encodes first, then bytewise.
+	sort.Slice(m.ents, func(i, j int) bool {
+		a, b := m.ents[i].key, m.ents[j].key
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+	e.head(majMap, uint64(len(m.ents)))
+	for _, en := range m.ents {
+		e.Text(en.key)
+		e.b = append(e.b, en.val...)
+	}
+}
+
+// Map collects the entries of one map. The typed setters leave out zero
+// values; Value, Array and Texts write whenever called (Array and Texts
+// skip empty slices).
+type Map struct {
+	ents []mapEnt
+}
+
+type mapEnt struct {
+	key string
+	val []byte
+}
+
+// Put writes key with the item val produces, whatever its value.
+func (m *Map) Put(key string, val func(e *Encoder)) {
+	var e Encoder
+	val(&e)
+	m.ents = append(m.ents, mapEnt{key, e.b})
+}
+
+func (m *Map) Uint(key string, v uint64) {
+	if v != 0 {
+		m.Put(key, func(e *Encoder) { e.Uint(v) })
+	}
+}
+
+func (m *Map) Int(key string, v int64) {
+	if v != 0 {
+		m.Put(key, func(e *Encoder) { e.Int(v) })
+	}
+}
+
+func (m *Map) Bool(key string, v bool) {
+	if v {
+		m.Put(key, func(e *Encoder) { e.Bool(true) })
+	}
+}
+
+func (m *Map) Text(key, s string) {
+	if s != "" {
+		m.Put(key, func(e *Encoder) { e.Text(s) })
+	}
+}
+
+func (m *Map) Blob(key string, b []byte) {
+	if len(b) != 0 {
+		m.Put(key, func(e *Encoder) { e.Blob(b) })
+	}
+}
+
+func (m *Map) Value(key string, v Marshaler) {
+	m.Put(key, v.MarshalCBOR)
+}
+
+func (m *Map) Array(key string, n int, item func(e *Encoder, i int)) {
+	if n != 0 {
+		m.Put(key, func(e *Encoder) { e.Array(n, item) })
+	}
+}
+
+func (m *Map) Texts(key string, ss []string) {
+	m.Array(key, len(ss), func(e *Encoder, i int) { e.Text(ss[i]) })
+}
+
+// Optional fields: written when p is set, even to zero.
+
+func OptInt[T ~int | ~int8 | ~int16 | ~int32 | ~int64](m *Map, key string, p *T) {
+	if p != nil {
+		v := int64(*p)
+		m.Put(key, func(e *Encoder) { e.Int(v) })
+	}
+}
+
+func OptUint[T ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64](m *Map, key string, p *T) {
+	if p != nil {
+		v := uint64(*p)
+		m.Put(key, func(e *Encoder) { e.Uint(v) })
+	}
+}
+
+func OptBool(m *Map, key string, p *bool) {
+	if p != nil {
+		v := *p
+		m.Put(key, func(e *Encoder) { e.Bool(v) })
+	}
+}
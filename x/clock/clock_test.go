@@ -0,0 +1,90 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func fired(tm Timer) (time.Time, bool) {
+	select {
+	case at := <-tm.C():
+		return at, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFakeAdvanceFiresInOrder(t *testing.T) {
+	f := NewFake(t0)
+	a := f.NewTimer(30 * time.Millisecond)
+	b := f.NewTimer(10 * time.Millisecond)
+
+	f.Advance(5 * time.Millisecond)
+	if _, ok := fired(a); ok {
+		t.Fatal("a fired early")
+	}
+	if _, ok := fired(b); ok {
+		t.Fatal("b fired early")
+	}
+
+	f.Advance(25 * time.Millisecond)
+	if at, ok := fired(b); !ok || !at.Equal(t0.Add(10*time.Millisecond)) {
+		t.Fatalf("b = %v %v", at, ok)
+	}
+	if at, ok := fired(a); !ok || !at.Equal(t0.Add(30*time.Millisecond)) {
+		t.Fatalf("a = %v %v", at, ok)
+	}
+	if got := f.Now(); !got.Equal(t0.Add(30 * time.Millisecond)) {
+		t.Fatalf("now = %v", got)
+	}
+	if f.Pending() != 0 {
+		t.Fatalf("pending = %d", f.Pending())
+	}
+}
+
+func TestFakeStopAndReset(t *testing.T) {
+	f := NewFake(t0)
+	tm := f.NewTimer(time.Second)
+	if !tm.Stop() {
+		t.Fatal("Stop on armed timer = false")
+	}
+	if tm.Stop() {
+		t.Fatal("second Stop = true")
+	}
+	f.Advance(2 * time.Second)
+	if _, ok := fired(tm); ok {
+		t.Fatal("stopped timer fired")
+	}
+
+	if tm.Reset(time.Second) {
+		t.Fatal("Reset of stopped timer = true")
+	}
+	if !tm.Reset(3 * time.Second) {
+		t.Fatal("Reset of armed timer = false")
+	}
+	f.Advance(2 * time.Second)
+	if _, ok := fired(tm); ok {
+		t.Fatal("fired at the replaced deadline")
+	}
+	f.Advance(time.Second)
+	if _, ok := fired(tm); !ok {
+		t.Fatal("not fired at the new deadline")
+	}
+
+	tm.Reset(0)
+	if _, ok := fired(tm); !ok {
+		t.Fatal("zero Reset did not fire immediately")
+	}
+}
+
+func TestOr(t *testing.T) {
+	if Or(nil) != Real {
+		t.Fatal("Or(nil) is not Real")
+	}
+	f := NewFake(t0)
+	if Or(f) != Clock(f) {
+		t.Fatal("Or(f) is not f")
+	}
+}
@@ -5,12 +5,22 @@ package fmtx
 import (
 	"fmt"
 	"io"
+	"os"
 )
 
+// DefaultOutput is used by Print/Printf, as on MCU builds.
+var DefaultOutput io.Writer = os.Stdout
+
 func Sprintf(format string, a ...any) string                    { return fmt.Sprintf(format, a...) }
-func Printf(format string, a ...any) (int, error)               { return fmt.Printf(format, a...) }
+func Printf(format string, a ...any) (int, error)               { return fmt.Fprintf(DefaultOutput, format, a...) }
 func Fprintf(w io.Writer, format string, a ...any) (int, error) { return fmt.Fprintf(w, format, a...) }
 func Errorf(format string, a ...any) error                      { return fmt.Errorf(format, a...) }
-func Sprint(a ...any) string                                    { return fmt.Sprint(a...) }
-func Fprint(w io.Writer, a ...any) (int, error)                 { return fmt.Fprint(w, a...) }
-func Print(a ...any) (int, error)                               { return fmt.Print(a...) }
+
+// Sprint always separates operands with a space, matching the MCU build
+// (fmt.Sprint omits it between strings).
+func Sprint(a ...any) string {
+	s := fmt.Sprintln(a...)
+	return s[:len(s)-1]
+}
+func Fprint(w io.Writer, a ...any) (int, error) { return io.WriteString(w, Sprint(a...)) }
+func Print(a ...any) (int, error)               { return Fprint(DefaultOutput, a...) }
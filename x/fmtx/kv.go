@@ -0,0 +1,89 @@
+package fmtx
+
+import (
+	"devicecode-go/types"
+	"devicecode-go/x/strconvx"
+)
+
+// AppendKV appends a compact, space-separated key=value rendering of v to
+// dst, for the payload types logged most often. It does not allocate when
+// dst has room and never touches fmt or reflection, so it is cheap to call
+// from logging paths on the MCU. ok is false (dst unchanged) for other types.
+func AppendKV(dst []byte, v any) (out []byte, ok bool) {
+	switch x := v.(type) {
+	case types.BatteryValue:
+		dst = kvMilli(dst, "pack", int64(x.PackMilliV), "V")
+		dst = kvMilli(dst, "cell", int64(x.PerCellMilliV), "V")
+		dst = kvInt(dst, "ibat", int64(x.IBatMilliA), "mA")
+		dst = kvMilli(dst, "temp", int64(x.TempMilliC), "C")
+		dst = kvInt(dst, "bsr", int64(x.BSR_uOhmPerCell), "uR")
+	case types.ChargerValue:
+		dst = kvMilli(dst, "vin", int64(x.VIN_mV), "V")
+		dst = kvMilli(dst, "vsys", int64(x.VSYS_mV), "V")
+		dst = kvInt(dst, "iin", int64(x.IIn_mA), "mA")
+		dst = kvHex(dst, "state", x.State)
+		dst = kvHex(dst, "status", x.Status)
+		dst = kvHex(dst, "sys", x.Sys)
+	case types.SystemPowerValue:
+		dst = kvInt(dst, "isys", int64(x.ISys_mA), "mA")
+		dst = kvMilli(dst, "pin", int64(x.PIn_mW), "W")
+		dst = kvMilli(dst, "pbat", int64(x.PBat_mW), "W")
+		dst = kvMilli(dst, "psys", int64(x.PSys_mW), "W")
+		if x.EffPct != 0 {
+			dst = kvInt(dst, "eff", int64(x.EffPct), "%")
+		}
+	case types.CapabilityStatus:
+		dst = kvStr(dst, "link", string(x.Link))
+		if x.Error != "" {
+			dst = kvStr(dst, "err", x.Error)
+		}
+		dst = kvInt(dst, "ts", x.TS, "")
+	case types.TemperatureValue:
+		dst = key(dst, "t")
+		dst = append(strconvx.AppendDeci(dst, int64(x.DeciC)), 'C')
+	case types.HumidityValue:
+		dst = key(dst, "rh")
+		dst = append(strconvx.AppendHundredths(dst, int64(x.RHx100)), '%')
+	case types.SwitchValue:
+		dst = kvBool(dst, "on", x.On)
+	case types.LEDValue:
+		dst = kvBool(dst, "on", x.On)
+	default:
+		return dst, false
+	}
+	return dst, true
+}
+
+// key appends "k=", preceded by a space unless dst is empty or already
+// ends in one.
+func key(dst []byte, k string) []byte {
+	if n := len(dst); n > 0 && dst[n-1] != ' ' {
+		dst = append(dst, ' ')
+	}
+	dst = append(dst, k...)
+	return append(dst, '=')
+}
+
+func kvInt(dst []byte, k string, v int64, unit string) []byte {
+	dst = strconvx.AppendInt64(key(dst, k), v)
+	return append(dst, unit...)
+}
+
+func kvMilli(dst []byte, k string, v int64, unit string) []byte {
+	dst = strconvx.AppendMilli(key(dst, k), v)
+	return append(dst, unit...)
+}
+
+func kvHex(dst []byte, k string, v uint16) []byte {
+	dst = append(key(dst, k), '0', 'x')
+	return strconvx.AppendHex16(dst, v)
+}
+
+func kvStr(dst []byte, k, s string) []byte { return append(key(dst, k), s...) }
+
+func kvBool(dst []byte, k string, b bool) []byte {
+	if b {
+		return append(key(dst, k), "true"...)
+	}
+	return append(key(dst, k), "false"...)
+}
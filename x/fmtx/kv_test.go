@@ -0,0 +1,41 @@
+package fmtx
+
+import (
+	"testing"
+
+	"devicecode-go/types"
+)
+
+func TestAppendKV(t *testing.T) {
+	for _, c := range []struct {
+		v    any
+		want string
+	}{
+		{types.BatteryValue{PackMilliV: 12345, PerCellMilliV: 2057, IBatMilliA: -120, TempMilliC: 25300, BSR_uOhmPerCell: 12000},
+			"pack=12.345V cell=2.057V ibat=-120mA temp=25.300C bsr=12000uR"},
+		{types.ChargerValue{VIN_mV: 12000, VSYS_mV: 11900, IIn_mA: 500, State: 1, Status: 2, Sys: 0x10},
+			"vin=12.000V vsys=11.900V iin=500mA state=0x0001 status=0x0002 sys=0x0010"},
+		{types.SystemPowerValue{ISys_mA: 300, PIn_mW: 6000, PBat_mW: -1250, PSys_mW: 3570, EffPct: 90},
+			"isys=300mA pin=6.000W pbat=-1.250W psys=3.570W eff=90%"},
+		{types.CapabilityStatus{Link: types.LinkDegraded, Error: "timeout", TS: 42}, "link=degraded err=timeout ts=42"},
+		{types.TemperatureValue{DeciC: -15}, "t=-1.5C"},
+		{types.HumidityValue{RHx100: 4507}, "rh=45.07%"},
+		{types.SwitchValue{On: true}, "on=true"},
+	} {
+		got, ok := AppendKV(nil, c.v)
+		if !ok || string(got) != c.want {
+			t.Fatalf("AppendKV(%T) = %q, %v; want %q", c.v, got, ok, c.want)
+		}
+	}
+	if got, ok := AppendKV([]byte("x"), 1); ok || string(got) != "x" {
+		t.Fatalf("AppendKV(int) = %q, %v; want unchanged, false", got, ok)
+	}
+}
+
+func TestAppendKV_NoAlloc(t *testing.T) {
+	buf := make([]byte, 0, 128)
+	v := types.ChargerValue{VIN_mV: 12000, IIn_mA: 500}
+	if n := testing.AllocsPerRun(100, func() { _, _ = AppendKV(buf[:0], v) }); n != 0 {
+		t.Fatalf("allocs = %v, want 0", n)
+	}
+}
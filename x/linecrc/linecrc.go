@@ -0,0 +1,150 @@
+// Package linecrc frames text lines with a sequence number and a CRC16 so
+// the reader of a lossy link, such as a UART fed from a ring that can
+// overflow, can tell whole lines from truncated or corrupted ones and
+// count those lost.
+//
+// A framed line is the text, " #", the sequence number in decimal, '*',
+// and the CRC-16/CCITT-FALSE of everything before the '*' as four
+// lower-case hex digits:
+//
+//	{"power/battery/internal/vbat":12650} #1207*3fa2
+package linecrc
+
+import "devicecode-go/x/strconvx"
+
+// Update returns crc advanced over p (CRC-16/CCITT-FALSE: polynomial
+// 0x1021, initial value 0xFFFF, no reflection).
+func Update(crc uint16, p []byte) uint16 {
+	for _, b := range p {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// Writer numbers and checksums lines as they are written. The zero value
+// starts at sequence 0.
+type Writer struct {
+	seq  uint32
+	crc  uint16
+	open bool // crc holds part of a line
+}
+
+// Add feeds bytes of the current line, whether or not the link accepted
+// them: the footer must describe the line that was meant.
+func (w *Writer) Add(p []byte) {
+	if !w.open {
+		w.crc, w.open = 0xFFFF, true
+	}
+	w.crc = Update(w.crc, p)
+}
+
+// Footer appends the current line's footer to dst (without a newline)
+// and starts the next line.
+func (w *Writer) Footer(dst []byte) []byte {
+	start := len(dst)
+	dst = append(dst, " #"...)
+	dst = strconvx.AppendUint64(dst, uint64(w.seq))
+	w.Add(dst[start:])
+	dst = append(dst, '*')
+	dst = strconvx.AppendHex16(dst, w.crc)
+	w.seq++
+	w.open = false
+	return dst
+}
+
+// Check validates the footer of line (trailing CR/LF allowed) and returns
+// the text before it and its sequence number.
+func Check(line []byte) (text []byte, seq uint32, ok bool) {
+	for len(line) > 0 && (line[len(line)-1] == '\n' || line[len(line)-1] == '\r') {
+		line = line[:len(line)-1]
+	}
+	if len(line) < 8 || line[len(line)-5] != '*' {
+		return nil, 0, false
+	}
+	var want uint16
+	for _, c := range line[len(line)-4:] {
+		v, ok := hexVal(c)
+		if !ok {
+			return nil, 0, false
+		}
+		want = want<<4 | uint16(v)
+	}
+	framed := line[:len(line)-5]
+	if Update(0xFFFF, framed) != want {
+		return nil, 0, false
+	}
+	i := len(framed)
+	for i > 0 && framed[i-1] >= '0' && framed[i-1] <= '9' {
+		i--
+	}
+	if i == len(framed) || i < 2 || framed[i-1] != '#' || framed[i-2] != ' ' || len(framed)-i > 10 {
+		return nil, 0, false
+	}
+	var n uint64
+	for _, c := range framed[i:] {
+		n = n*10 + uint64(c-'0')
+	}
+	if n > 1<<32-1 {
+		return nil, 0, false
+	}
+	return framed[:i-2], uint32(n), true
+}
+
+func hexVal(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// Stats tallies a stream of framed lines for a collector.
+type Stats struct {
+	Good    uint64 // lines received intact
+	Corrupt uint64 // lines received whose footer did not check
+	Lost    uint64 // lines never received intact, from sequence gaps
+	Resets  uint64 // times the sequence restarted (sender rebooted)
+
+	next    uint32
+	started bool
+}
+
+// Line checks one received line, counts it, and returns its text if it
+// was intact. A sequence number behind the expected one is taken as a
+// sender restart and counts nothing lost.
+func (s *Stats) Line(line []byte) (text []byte, ok bool) {
+	text, seq, ok := Check(line)
+	if !ok {
+		s.Corrupt++
+		return nil, false
+	}
+	if s.started {
+		if gap := seq - s.next; gap < 1<<31 {
+			s.Lost += uint64(gap)
+		} else {
+			s.Resets++
+		}
+	}
+	s.next, s.started = seq+1, true
+	s.Good++
+	return text, true
+}
+
+// LossPct is the percentage of lines lost, 0 before any gap.
+func (s *Stats) LossPct() float64 {
+	if s.Good+s.Lost == 0 {
+		return 0
+	}
+	return 100 * float64(s.Lost) / float64(s.Good+s.Lost)
+}
@@ -0,0 +1,73 @@
+package linecrc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUpdate_CheckValue(t *testing.T) {
+	if got := Update(0xFFFF, []byte("123456789")); got != 0x29B1 {
+		t.Fatalf("crc = %#04x, want 0x29b1", got)
+	}
+}
+
+func frame(w *Writer, text string) string {
+	w.Add([]byte(text))
+	return string(w.Footer([]byte(text))) + "\n"
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	var w Writer
+	for i, text := range []string{`{"a":1}`, ``, `{"b":"x #3*0000"}`} {
+		line := frame(&w, text)
+		got, seq, ok := Check([]byte(line))
+		if !ok || string(got) != text || seq != uint32(i) {
+			t.Fatalf("Check(%q) = %q, %d, %v", line, got, seq, ok)
+		}
+	}
+}
+
+func TestCheck_RejectsDamage(t *testing.T) {
+	var w Writer
+	line := frame(&w, `{"power/charger/internal/vin":12000}`)
+	for _, bad := range []string{
+		line[:10] + line[20:], // bytes dropped mid-line
+		strings.Replace(line, "12000", "12001", 1),
+		strings.Replace(line, " #0*", " #1*", 1),
+		line[:len(line)-3],
+		`{"a":1}`,
+	} {
+		if _, _, ok := Check([]byte(bad)); ok {
+			t.Fatalf("Check(%q) ok", bad)
+		}
+	}
+}
+
+func TestStats_CountsLossAndRestart(t *testing.T) {
+	var w Writer
+	var s Stats
+	for i := 0; i < 10; i++ {
+		line := frame(&w, `{"n":1}`)
+		switch i {
+		case 3, 4: // never arrived
+		case 6: // truncated
+			s.Line([]byte(line[:5]))
+		default:
+			if _, ok := s.Line([]byte(line)); !ok {
+				t.Fatalf("line %d rejected", i)
+			}
+		}
+	}
+	if s.Good != 7 || s.Lost != 3 || s.Corrupt != 1 {
+		t.Fatalf("stats = %+v", s)
+	}
+	if got := s.LossPct(); got != 30 {
+		t.Fatalf("loss = %v%%, want 30", got)
+	}
+
+	var w2 Writer // sender rebooted
+	s.Line([]byte(frame(&w2, `{}`)))
+	if s.Resets != 1 || s.Lost != 3 {
+		t.Fatalf("after restart: %+v", s)
+	}
+}
@@ -0,0 +1,136 @@
+// Package lz4 compresses small buffers in the LZ4 block format (no frame
+// header or checksum). The compressor is a greedy single-pass matcher
+// with a small hash table on the stack, so it suits a microcontroller
+// compressing a few kilobytes at a time; any LZ4 block decoder reads its
+// output.
+package lz4
+
+import "errors"
+
+// ErrCorrupt reports a block that does not decode, or decodes past the
+// caller's limit.
+var ErrCorrupt = errors.New("lz4: corrupt block")
+
+const (
+	minMatch     = 4
+	lastLiterals = 5  // the block ends with at least this many literals
+	mfLimit      = 12 // no match starts within this many bytes of the end
+	maxOffset    = 65535
+	hashLog      = 10
+)
+
+// Compress appends the LZ4 block for src to dst. Input shorter than
+// mfLimit+1 bytes comes out as one literal run.
+func Compress(dst, src []byte) []byte {
+	var table [1 << hashLog]int32 // position+1 of the last 4-byte sequence per hash (0: none)
+	anchor, i := 0, 0
+	for i+mfLimit < len(src) {
+		seq := load32(src, i)
+		h := hash(seq)
+		ref := int(table[h]) - 1
+		table[h] = int32(i + 1)
+		if ref < 0 || i-ref > maxOffset || load32(src, ref) != seq {
+			i++
+			continue
+		}
+		end := i + minMatch
+		for r := ref + minMatch; end < len(src)-lastLiterals && src[end] == src[r]; end, r = end+1, r+1 {
+		}
+		dst = appendSeq(dst, src[anchor:i], i-ref, end-i)
+		i, anchor = end, end
+	}
+	lit := src[anchor:]
+	dst = append(dst, byte(min(len(lit), 15))<<4)
+	if len(lit) >= 15 {
+		dst = appendLen(dst, len(lit)-15)
+	}
+	return append(dst, lit...)
+}
+
+// Decompress appends the data in block src to dst. It fails with
+// ErrCorrupt if src is malformed or would append more than limit bytes.
+func Decompress(dst, src []byte, limit int) ([]byte, error) {
+	base := len(dst)
+	i := 0
+	for i < len(src) {
+		tok := src[i]
+		i++
+		ll := int(tok >> 4)
+		if ll == 15 {
+			n, j, ok := readLen(src, i)
+			if !ok {
+				return dst, ErrCorrupt
+			}
+			ll, i = ll+n, j
+		}
+		if ll > len(src)-i || len(dst)-base+ll > limit {
+			return dst, ErrCorrupt
+		}
+		dst = append(dst, src[i:i+ll]...)
+		i += ll
+		if i == len(src) {
+			return dst, nil // the last sequence has no match
+		}
+		if i+2 > len(src) {
+			return dst, ErrCorrupt
+		}
+		off := int(src[i]) | int(src[i+1])<<8
+		i += 2
+		ml := int(tok&15) + minMatch
+		if tok&15 == 15 {
+			n, j, ok := readLen(src, i)
+			if !ok {
+				return dst, ErrCorrupt
+			}
+			ml, i = ml+n, j
+		}
+		if off == 0 || off > len(dst)-base || len(dst)-base+ml > limit {
+			return dst, ErrCorrupt
+		}
+		// Byte by byte: the match may overlap what it is copying.
+		for p := len(dst) - off; ml > 0; p, ml = p+1, ml-1 {
+			dst = append(dst, dst[p])
+		}
+	}
+	return dst, ErrCorrupt // empty, or ended on a match
+}
+
+func appendSeq(dst, lit []byte, off, ml int) []byte {
+	ml -= minMatch
+	dst = append(dst, byte(min(len(lit), 15))<<4|byte(min(ml, 15)))
+	if len(lit) >= 15 {
+		dst = appendLen(dst, len(lit)-15)
+	}
+	dst = append(dst, lit...)
+	dst = append(dst, byte(off), byte(off>>8))
+	if ml >= 15 {
+		dst = appendLen(dst, ml-15)
+	}
+	return dst
+}
+
+// appendLen writes a length extension: 255s, then the remainder.
+func appendLen(dst []byte, n int) []byte {
+	for ; n >= 255; n -= 255 {
+		dst = append(dst, 255)
+	}
+	return append(dst, byte(n))
+}
+
+func readLen(src []byte, i int) (n, next int, ok bool) {
+	for i < len(src) {
+		b := src[i]
+		i++
+		n += int(b)
+		if b != 255 {
+			return n, i, true
+		}
+	}
+	return 0, i, false
+}
+
+func load32(b []byte, i int) uint32 {
+	return uint32(b[i]) | uint32(b[i+1])<<8 | uint32(b[i+2])<<16 | uint32(b[i+3])<<24
+}
+
+func hash(v uint32) uint32 { return v * 2654435761 >> (32 - hashLog) }
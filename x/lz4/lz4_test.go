@@ -0,0 +1,64 @@
+package lz4
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestDecompress_HandBuiltBlock(t *testing.T) {
+	// "a", then a 29-byte match at offset 1 (4+15+10), then 5 literals.
+	block := []byte{0x1F, 'a', 0x01, 0x00, 10, 0x50, 'a', 'a', 'a', 'a', 'a'}
+	got, err := Decompress(nil, block, 100)
+	if err != nil || string(got) != strings.Repeat("a", 35) {
+		t.Fatalf("got %q, %v", got, err)
+	}
+}
+
+func TestCompress_RoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	noise := make([]byte, 3000)
+	rnd.Read(noise)
+	var frames strings.Builder
+	for i := 0; i < 30; i++ {
+		frames.WriteString(`{"op":"msg","id":3,"topic":["hal","cap","env","temperature","t`)
+		frames.WriteByte(byte('0' + i%10))
+		frames.WriteString(`","value"],"ret":true,"type":"TemperatureValue","p":{"deci_c":25` + "}}\n")
+	}
+	for _, src := range [][]byte{
+		nil,
+		[]byte("short"),
+		[]byte(strings.Repeat("x", 1000)),
+		[]byte(frames.String()),
+		noise,
+	} {
+		block := Compress(nil, src)
+		got, err := Decompress([]byte("keep"), block, len(src))
+		if err != nil || string(got) != "keep"+string(src) {
+			t.Fatalf("round trip of %d bytes: %v", len(src), err)
+		}
+	}
+	if n, z := frames.Len(), len(Compress(nil, []byte(frames.String()))); z*4 > n {
+		t.Fatalf("frames %d -> %d bytes", n, z)
+	}
+}
+
+func TestDecompress_RejectsCorruptAndOversize(t *testing.T) {
+	src := bytes.Repeat([]byte("abcdefgh"), 50)
+	block := Compress(nil, src)
+	if _, err := Decompress(nil, block, len(src)-1); err != ErrCorrupt {
+		t.Fatalf("over limit: %v", err)
+	}
+	for _, bad := range [][]byte{
+		{},
+		block[:len(block)-1],
+		{0x10, 'a', 0x02, 0x00, 0x00}, // offset before the start
+		{0x10, 'a', 0x00, 0x00, 0x00}, // offset 0
+		{0xF0, 255, 255},              // length runs off the end
+	} {
+		if _, err := Decompress(nil, bad, 1000); err != ErrCorrupt {
+			t.Fatalf("Decompress(% x) = %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package mathx
+
+// Integer filters for telemetry. Each is a small value type with no
+// allocation; make each with its New, as the zero values do not filter.
+
+// EMA is an exponential moving average, y += α(x−y), with α in Q16
+// (65536 = 1). The average is kept in Q16 so slow filters still follow
+// small steps. The first sample after New or Reset is taken as the
+// average.
+type EMA struct {
+	alpha  int64 // Q16
+	acc    int64 // Q16
+	primed bool
+}
+
+// NewEMA returns an EMA with smoothing alphaQ16/65536; 0 is taken as 1
+// (no smoothing).
+func NewEMA(alphaQ16 uint16) EMA {
+	a := int64(alphaQ16)
+	if a == 0 {
+		a = 1 << 16
+	}
+	return EMA{alpha: a}
+}
+
+// Add feeds x and returns the new average.
+func (f *EMA) Add(x int32) int32 {
+	xq := int64(x) << 16
+	if !f.primed {
+		f.acc, f.primed = xq, true
+	} else {
+		f.acc += (f.alpha*(xq-f.acc) + 1<<15) >> 16
+	}
+	return f.Value()
+}
+
+// Value is the average rounded to the nearest integer (0 before any sample).
+func (f *EMA) Value() int32 { return int32((f.acc + 1<<15) >> 16) }
+
+// Reset forgets the history; the next sample primes the average.
+func (f *EMA) Reset() { f.acc, f.primed = 0, false }
+
+// MedianMax bounds a Median's window.
+const MedianMax = 15
+
+// Median is the median of the last n samples, which rejects isolated
+// spikes without the lag of an average. Until n have arrived it is the
+// median of those seen. An even count gives the mean of the middle two.
+type Median struct {
+	buf  [MedianMax]int32
+	n    uint8 // window
+	len  uint8 // samples held
+	next uint8 // slot for the next sample
+}
+
+// NewMedian returns a median over n samples, clamped to [1, MedianMax].
+func NewMedian(n int) Median {
+	return Median{n: uint8(Clamp(n, 1, MedianMax))}
+}
+
+// Add feeds x and returns the median of the window.
+func (f *Median) Add(x int32) int32 {
+	f.buf[f.next] = x
+	if f.next++; f.next == f.n {
+		f.next = 0
+	}
+	if f.len < f.n {
+		f.len++
+	}
+	return f.Value()
+}
+
+// Value is the median of the samples held (0 before any).
+func (f *Median) Value() int32 {
+	if f.len == 0 {
+		return 0
+	}
+	var s [MedianMax]int32
+	k := int(f.len)
+	copy(s[:], f.buf[:k])
+	for i := 1; i < k; i++ { // insertion sort: k is small
+		v, j := s[i], i
+		for ; j > 0 && s[j-1] > v; j-- {
+			s[j] = s[j-1]
+		}
+		s[j] = v
+	}
+	if k%2 == 1 {
+		return s[k/2]
+	}
+	sum := int64(s[k/2-1]) + int64(s[k/2])
+	if sum < 0 {
+		return int32((sum - 1) / 2)
+	}
+	return int32((sum + 1) / 2)
+}
+
+// Reset empties the window.
+func (f *Median) Reset() { f.len, f.next = 0, 0 }
+
+// RateLimit follows its input but moves at most Up per sample when rising
+// and Down per sample when falling, so one bad reading cannot swing a
+// controlled output. The first sample after New or Reset is taken as is.
+type RateLimit struct {
+	up, down int64
+	y        int32
+	primed   bool
+}
+
+// NewRateLimit returns a limiter with the given steps per sample; a
+// negative step is taken as its magnitude.
+func NewRateLimit(up, down int32) RateLimit {
+	abs := func(v int32) int64 {
+		if v < 0 {
+			return -int64(v)
+		}
+		return int64(v)
+	}
+	return RateLimit{up: abs(up), down: abs(down)}
+}
+
+// Add feeds x and returns the limited output.
+func (f *RateLimit) Add(x int32) int32 {
+	if !f.primed {
+		f.y, f.primed = x, true
+		return x
+	}
+	d := Clamp(int64(x)-int64(f.y), -f.down, f.up)
+	f.y = int32(int64(f.y) + d)
+	return f.y
+}
+
+// Value is the last output (0 before any sample).
+func (f *RateLimit) Value() int32 { return f.y }
+
+// Reset forgets the output; the next sample is taken as is.
+func (f *RateLimit) Reset() { f.y, f.primed = 0, false }
@@ -0,0 +1,102 @@
+package mathx
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// noisy returns a slow sine with noise and the odd spike, in deci-units.
+func noisy(n int) []int32 {
+	r := rand.New(rand.NewSource(1))
+	out := make([]int32, n)
+	for i := range out {
+		v := 250 + 120*math.Sin(float64(i)/40) + r.NormFloat64()*6
+		if r.Intn(25) == 0 {
+			v += 400
+		}
+		out[i] = int32(math.Round(v))
+	}
+	return out
+}
+
+func TestEMA_TracksFloatReference(t *testing.T) {
+	for _, a := range []uint16{65535, 16384, 4096, 655} {
+		f := NewEMA(a)
+		alpha := float64(a) / 65536
+		var ref float64
+		for i, x := range noisy(2000) {
+			if i == 0 {
+				ref = float64(x)
+			} else {
+				ref += alpha * (float64(x) - ref)
+			}
+			if got := f.Add(x); math.Abs(float64(got)-ref) > 1 {
+				t.Fatalf("α=%d sample %d: %d, reference %.2f", a, i, got, ref)
+			}
+		}
+	}
+	// A slow filter still reaches a one-unit step.
+	f := NewEMA(64)
+	f.Add(0)
+	for i := 0; i < 20000; i++ {
+		f.Add(1)
+	}
+	if f.Value() != 1 {
+		t.Fatalf("stuck at %d", f.Value())
+	}
+	f.Reset()
+	if f.Add(-40) != -40 {
+		t.Fatal("reset did not re-prime")
+	}
+}
+
+func TestMedian_MatchesSortedWindow(t *testing.T) {
+	for _, n := range []int{1, 3, 4, 5, 9, MedianMax} {
+		f := NewMedian(n)
+		xs := noisy(500)
+		for i, x := range xs {
+			w := append([]int32(nil), xs[max(0, i+1-n):i+1]...)
+			sort.Slice(w, func(a, b int) bool { return w[a] < w[b] })
+			var ref float64
+			if k := len(w); k%2 == 1 {
+				ref = float64(w[k/2])
+			} else {
+				ref = (float64(w[k/2-1]) + float64(w[k/2])) / 2
+			}
+			ref = math.Copysign(math.Floor(math.Abs(ref)+0.5), ref) // half away from zero
+			if got := f.Add(x); float64(got) != ref {
+				t.Fatalf("n=%d sample %d: %d, reference %v", n, i, got, ref)
+			}
+		}
+	}
+	f := NewMedian(3)
+	for _, x := range []int32{-3, -4} {
+		f.Add(x)
+	}
+	if f.Value() != -4 {
+		t.Fatalf("even negative mean %d", f.Value())
+	}
+}
+
+func TestRateLimit_MatchesFloatReference(t *testing.T) {
+	f := NewRateLimit(5, -20)
+	var ref float64
+	for i, x := range noisy(1000) {
+		if i == 0 {
+			ref = float64(x)
+		} else {
+			ref += math.Max(-20, math.Min(5, float64(x)-ref))
+		}
+		if got := f.Add(x); float64(got) != ref {
+			t.Fatalf("sample %d: %d, reference %v", i, got, ref)
+		}
+	}
+	// A full-range swing moves one step without overflowing.
+	g := NewRateLimit(math.MaxInt32, math.MaxInt32)
+	g.Add(math.MinInt32)
+	if got := g.Add(math.MaxInt32); got != -1 {
+		t.Fatalf("swing to %d", got)
+	}
+}
@@ -0,0 +1,296 @@
+// Package nmea parses the NMEA 0183 sentences a GNSS receiver emits for
+// position and time (RMC, GGA). Parsing works in place on the received
+// line and never allocates; all quantities are fixed-point integers.
+package nmea
+
+// Kind is the sentence type, independent of talker (GP, GN, GL, …).
+type Kind uint8
+
+const (
+	Unknown Kind = iota
+	RMC
+	GGA
+)
+
+// RMCData holds an RMC (recommended minimum) sentence.
+type RMCData struct {
+	Valid      bool  // status A
+	UnixMs     int64 // UTC, 0 if date or time is empty
+	LatE7      int32 // degrees × 1e7, north positive
+	LonE7      int32 // degrees × 1e7, east positive
+	SpeedCmps  int32 // over ground, cm/s
+	CourseCdeg int32 // true course, 0.01°; -1 if empty
+}
+
+// GGAData holds a GGA (fix data) sentence.
+type GGAData struct {
+	Quality  uint8 // 0 no fix, 1 GPS, 2 DGPS, …
+	Sats     uint8
+	HDOPx100 uint16
+	AltCm    int32 // above mean sea level
+	LatE7    int32
+	LonE7    int32
+}
+
+// Check validates the "$…*hh" framing and checksum of line (trailing CR/LF
+// allowed) and returns the sentence body between '$' and '*'.
+func Check(line []byte) (body []byte, ok bool) {
+	for len(line) > 0 && (line[len(line)-1] == '\n' || line[len(line)-1] == '\r') {
+		line = line[:len(line)-1]
+	}
+	if len(line) < 7 || line[0] != '$' || line[len(line)-3] != '*' {
+		return nil, false
+	}
+	hi, ok1 := hexVal(line[len(line)-2])
+	lo, ok2 := hexVal(line[len(line)-1])
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+	body = line[1 : len(line)-3]
+	var sum byte
+	for _, c := range body {
+		sum ^= c
+	}
+	return body, sum == hi<<4|lo
+}
+
+// KindOf classifies a checked sentence body.
+func KindOf(body []byte) Kind {
+	if len(body) < 6 || body[5] != ',' {
+		return Unknown
+	}
+	switch string(body[2:5]) { // no allocation: compared in place
+	case "RMC":
+		return RMC
+	case "GGA":
+		return GGA
+	}
+	return Unknown
+}
+
+// ParseRMC decodes a checked RMC body.
+//
+//	xxRMC,hhmmss.ss,A,llll.ll,a,yyyyy.yy,a,x.x,x.x,ddmmyy,x.x,a
+func ParseRMC(body []byte, out *RMCData) bool {
+	var f fields
+	f.init(body)
+	f.next() // talker+type
+	tm := f.next()
+	status := f.next()
+	lat, ns := f.next(), f.next()
+	lon, ew := f.next(), f.next()
+	spd := f.next()
+	crs := f.next()
+	date := f.next()
+	if !f.ok {
+		return false
+	}
+	*out = RMCData{Valid: len(status) == 1 && status[0] == 'A', CourseCdeg: -1}
+	if ms, ok := parseTime(tm); ok {
+		if days, ok := parseDate(date); ok {
+			out.UnixMs = days*86400000 + ms
+		}
+	}
+	out.LatE7, _ = parseCoord(lat, ns, 2)
+	out.LonE7, _ = parseCoord(lon, ew, 3)
+	if kn, ok := parseFixed(spd, 3); ok {
+		// 1 kn = 51.4444 cm/s; kn is in thousandths.
+		out.SpeedCmps = int32(kn * 514444 / 10000000)
+	}
+	if c, ok := parseFixed(crs, 2); ok {
+		out.CourseCdeg = int32(c)
+	}
+	return true
+}
+
+// ParseGGA decodes a checked GGA body.
+//
+//	xxGGA,hhmmss.ss,llll.ll,a,yyyyy.yy,a,q,nn,h.h,alt,M,geo,M,age,ref
+func ParseGGA(body []byte, out *GGAData) bool {
+	var f fields
+	f.init(body)
+	f.next()
+	f.next() // time (RMC carries the date as well)
+	lat, ns := f.next(), f.next()
+	lon, ew := f.next(), f.next()
+	q := f.next()
+	sats := f.next()
+	hdop := f.next()
+	alt := f.next()
+	if !f.ok {
+		return false
+	}
+	*out = GGAData{}
+	if v, ok := parseFixed(q, 0); ok {
+		out.Quality = uint8(v)
+	}
+	if v, ok := parseFixed(sats, 0); ok {
+		out.Sats = uint8(v)
+	}
+	if v, ok := parseFixed(hdop, 2); ok {
+		out.HDOPx100 = uint16(v)
+	}
+	if v, ok := parseFixed(alt, 2); ok {
+		out.AltCm = int32(v)
+	}
+	out.LatE7, _ = parseCoord(lat, ns, 2)
+	out.LonE7, _ = parseCoord(lon, ew, 3)
+	return true
+}
+
+// ---- field scanning ----
+
+type fields struct {
+	rest []byte
+	ok   bool // false once a required field was missing
+	done bool
+}
+
+func (f *fields) init(b []byte) { f.rest, f.ok = b, true }
+
+// next returns the next comma-separated field; running out marks f not ok.
+func (f *fields) next() []byte {
+	if f.done {
+		f.ok = false
+		return nil
+	}
+	for i, c := range f.rest {
+		if c == ',' {
+			v := f.rest[:i]
+			f.rest = f.rest[i+1:]
+			return v
+		}
+	}
+	f.done = true
+	return f.rest
+}
+
+func hexVal(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	}
+	return 0, false
+}
+
+// parseFixed reads an unsigned or negative decimal, scaled by 10^dec
+// (extra fraction digits are truncated).
+func parseFixed(b []byte, dec int) (int64, bool) {
+	if len(b) == 0 {
+		return 0, false
+	}
+	neg := b[0] == '-'
+	if neg {
+		b = b[1:]
+	}
+	var v int64
+	frac := -1
+	digits := 0
+	for _, c := range b {
+		switch {
+		case c == '.' && frac < 0:
+			frac = 0
+		case c >= '0' && c <= '9':
+			if frac >= 0 {
+				if frac == dec {
+					continue
+				}
+				frac++
+			}
+			v = v*10 + int64(c-'0')
+			digits++
+		default:
+			return 0, false
+		}
+	}
+	if digits == 0 {
+		return 0, false
+	}
+	if frac < 0 {
+		frac = 0
+	}
+	for ; frac < dec; frac++ {
+		v *= 10
+	}
+	if neg {
+		v = -v
+	}
+	return v, true
+}
+
+// parseCoord reads (d)ddmm.mmmm plus hemisphere into degrees × 1e7.
+func parseCoord(b, hemi []byte, degDigits int) (int32, bool) {
+	if len(b) < degDigits+2 || len(hemi) != 1 {
+		return 0, false
+	}
+	deg, ok := parseFixed(b[:degDigits], 0)
+	if !ok {
+		return 0, false
+	}
+	min, ok := parseFixed(b[degDigits:], 5) // minutes × 1e5
+	if !ok {
+		return 0, false
+	}
+	// minutes × 1e5 → degrees × 1e7: × 100 / 60.
+	v := deg*10000000 + min*100/60
+	switch hemi[0] {
+	case 'S', 'W':
+		v = -v
+	case 'N', 'E':
+	default:
+		return 0, false
+	}
+	return int32(v), true
+}
+
+// parseTime reads hhmmss(.sss) into milliseconds since midnight.
+func parseTime(b []byte) (int64, bool) {
+	if len(b) < 6 {
+		return 0, false
+	}
+	h, ok1 := parseFixed(b[0:2], 0)
+	m, ok2 := parseFixed(b[2:4], 0)
+	s, ok3 := parseFixed(b[4:], 3)
+	if !ok1 || !ok2 || !ok3 || h > 23 || m > 59 || s >= 61000 {
+		return 0, false
+	}
+	return (h*3600+m*60)*1000 + s, true
+}
+
+// parseDate reads ddmmyy into days since 1970-01-01 (yy 80–99 → 19yy,
+// otherwise 20yy).
+func parseDate(b []byte) (int64, bool) {
+	if len(b) != 6 {
+		return 0, false
+	}
+	d, ok1 := parseFixed(b[0:2], 0)
+	m, ok2 := parseFixed(b[2:4], 0)
+	y, ok3 := parseFixed(b[4:6], 0)
+	if !ok1 || !ok2 || !ok3 || d < 1 || d > 31 || m < 1 || m > 12 {
+		return 0, false
+	}
+	if y >= 80 {
+		y += 1900
+	} else {
+		y += 2000
+	}
+	return daysFromCivil(y, m, d), true
+}
+
+// daysFromCivil is Howard Hinnant's algorithm for the proleptic Gregorian
+// calendar.
+func daysFromCivil(y, m, d int64) int64 {
+	if m <= 2 {
+		y--
+	}
+	era := y / 400
+	yoe := y - era*400
+	mp := (m + 9) % 12
+	doy := (153*mp+2)/5 + d - 1
+	doe := yoe*365 + yoe/4 - yoe/100 + doy
+	return era*146097 + doe - 719468
+}
@@ -0,0 +1,102 @@
+package nmea
+
+import "testing"
+
+const (
+	rmcLine = "$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A\r\n"
+	ggaLine = "$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47\r\n"
+)
+
+func TestCheck(t *testing.T) {
+	if _, ok := Check([]byte(rmcLine)); !ok {
+		t.Fatal("valid RMC rejected")
+	}
+	bad := []byte(rmcLine)
+	bad[10] = '9'
+	if _, ok := Check(bad); ok {
+		t.Fatal("corrupt RMC accepted")
+	}
+	for _, s := range []string{"", "$*00", "GPRMC,1*00", "$GPRMC,1*ZZ"} {
+		if _, ok := Check([]byte(s)); ok {
+			t.Fatalf("%q accepted", s)
+		}
+	}
+}
+
+func TestRMC(t *testing.T) {
+	body, _ := Check([]byte(rmcLine))
+	if KindOf(body) != RMC {
+		t.Fatal("kind")
+	}
+	var r RMCData
+	if !ParseRMC(body, &r) {
+		t.Fatal("parse")
+	}
+	// 1994-03-23T12:35:19Z
+	if !r.Valid || r.UnixMs != 764426119000 {
+		t.Fatalf("time: %+v", r)
+	}
+	// 48°07.038' = 48.1173°, 11°31.000' = 11.516666…°
+	if r.LatE7 != 481173000 || r.LonE7 != 115166666 {
+		t.Fatalf("position: %+v", r)
+	}
+	// 22.4 kn ≈ 1152 cm/s; 84.4°
+	if r.SpeedCmps != 1152 || r.CourseCdeg != 8440 {
+		t.Fatalf("motion: %+v", r)
+	}
+}
+
+func TestRMCNoFix(t *testing.T) {
+	body, ok := Check([]byte("$GNRMC,,V,,,,,,,,,,N*4D"))
+	if !ok {
+		t.Fatal("check")
+	}
+	var r RMCData
+	if !ParseRMC(body, &r) || r.Valid || r.UnixMs != 0 || r.CourseCdeg != -1 {
+		t.Fatalf("%+v", r)
+	}
+}
+
+func TestGGA(t *testing.T) {
+	body, _ := Check([]byte(ggaLine))
+	if KindOf(body) != GGA {
+		t.Fatal("kind")
+	}
+	var g GGAData
+	if !ParseGGA(body, &g) {
+		t.Fatal("parse")
+	}
+	if g.Quality != 1 || g.Sats != 8 || g.HDOPx100 != 90 || g.AltCm != 54540 {
+		t.Fatalf("%+v", g)
+	}
+	if g.LatE7 != 481173000 || g.LonE7 != 115166666 {
+		t.Fatalf("position: %+v", g)
+	}
+}
+
+func TestSouthWest(t *testing.T) {
+	lat, _ := parseCoord([]byte("3351.5000"), []byte("S"), 2)
+	lon, _ := parseCoord([]byte("15112.3000"), []byte("W"), 3)
+	if lat != -338583333 || lon != -1512050000 {
+		t.Fatalf("lat %d lon %d", lat, lon)
+	}
+}
+
+func TestNoAllocs(t *testing.T) {
+	rmc, gga := []byte(rmcLine), []byte(ggaLine)
+	var r RMCData
+	var g GGAData
+	n := testing.AllocsPerRun(100, func() {
+		b, _ := Check(rmc)
+		if KindOf(b) == RMC {
+			ParseRMC(b, &r)
+		}
+		b, _ = Check(gga)
+		if KindOf(b) == GGA {
+			ParseGGA(b, &g)
+		}
+	})
+	if n != 0 {
+		t.Fatalf("allocs per parse: %v", n)
+	}
+}
@@ -0,0 +1,157 @@
+// Package nvstore keeps small blobs in a region of erasable flash so they
+// survive reboots and power loss.
+//
+// A Slots region is two equal slots written alternately. Each write goes
+// to the slot not holding the current copy, behind a header carrying a
+// generation number and a CRC-32 over header and data; Load returns the
+// valid copy with the highest generation. A write cut short by power loss
+// leaves the previous copy in place.
+//
+// Records layers a small key/value map on a Slots region: the whole map is
+// rewritten on every Put, so it suits counters and settings that change
+// rarely (each Put costs one slot erase).
+package nvstore
+
+import (
+	"errors"
+	"hash/crc32"
+)
+
+// Flash is the subset of TinyGo's machine.BlockDevice the store uses.
+// Offsets are bytes from the start of the device.
+type Flash interface {
+	ReadAt(p []byte, off int64) (int, error)
+	WriteAt(p []byte, off int64) (int, error)
+	Size() int64
+	WriteBlockSize() int64
+	EraseBlockSize() int64
+	// EraseBlocks erases n erase blocks starting at erase block start.
+	EraseBlocks(start, n int64) error
+}
+
+var (
+	ErrTooLarge = errors.New("nvstore: blob larger than slot")
+	ErrRegion   = errors.New("nvstore: region not erase-aligned or outside flash")
+)
+
+const (
+	magic   = 0x4e565331 // "NVS1"
+	hdrSize = 16         // magic, gen, len, crc (little-endian u32 each)
+)
+
+// Slots is a two-slot region of a Flash device.
+type Slots struct {
+	dev  Flash
+	off  int64 // region start (bytes)
+	slot int64 // bytes per slot, a multiple of the erase block size
+	gen  uint32
+	cur  int // slot holding the current copy; -1 none
+}
+
+// NewSlots uses size bytes of dev from off as two slots. Both must be
+// multiples of twice the erase block size.
+func NewSlots(dev Flash, off, size int64) (*Slots, error) {
+	eb := dev.EraseBlockSize()
+	if eb <= 0 || off < 0 || off%eb != 0 || size <= 0 || size%(2*eb) != 0 || off+size > dev.Size() {
+		return nil, ErrRegion
+	}
+	s := &Slots{dev: dev, off: off, slot: size / 2, cur: -1}
+	for i := 0; i < 2; i++ {
+		if g, _, ok := s.header(i); ok && (s.cur < 0 || int32(g-s.gen) > 0) {
+			s.gen, s.cur = g, i
+		}
+	}
+	return s, nil
+}
+
+// Cap is the largest blob Save accepts.
+func (s *Slots) Cap() int { return int(s.slot) - hdrSize }
+
+// header reads and checks slot i, returning its generation and length.
+func (s *Slots) header(i int) (gen uint32, n int, ok bool) {
+	var h [hdrSize]byte
+	if _, err := s.dev.ReadAt(h[:], s.off+int64(i)*s.slot); err != nil {
+		return 0, 0, false
+	}
+	if le32(h[0:]) != magic {
+		return 0, 0, false
+	}
+	gen, n = le32(h[4:]), int(le32(h[8:]))
+	if n > s.Cap() {
+		return 0, 0, false
+	}
+	data := make([]byte, n)
+	if _, err := s.dev.ReadAt(data, s.off+int64(i)*s.slot+hdrSize); err != nil {
+		return 0, 0, false
+	}
+	if crc(h[:12], data) != le32(h[12:]) {
+		return 0, 0, false
+	}
+	return gen, n, true
+}
+
+// Load returns the current blob, or false if neither slot holds one.
+func (s *Slots) Load() ([]byte, bool) {
+	if s.cur < 0 {
+		return nil, false
+	}
+	_, n, ok := s.header(s.cur)
+	if !ok {
+		return nil, false
+	}
+	b := make([]byte, n)
+	if _, err := s.dev.ReadAt(b, s.off+int64(s.cur)*s.slot+hdrSize); err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+// Save writes b as the new current blob.
+func (s *Slots) Save(b []byte) error {
+	if len(b) > s.Cap() {
+		return ErrTooLarge
+	}
+	next := 0
+	if s.cur == 0 {
+		next = 1
+	}
+	base := s.off + int64(next)*s.slot
+	eb := s.dev.EraseBlockSize()
+	if err := s.dev.EraseBlocks(base/eb, s.slot/eb); err != nil {
+		return err
+	}
+	gen := s.gen + 1
+	// Header and data go in one padded write; the CRC makes a torn write
+	// invalid.
+	wb := s.dev.WriteBlockSize()
+	n := int64(hdrSize + len(b))
+	if wb > 1 && n%wb != 0 {
+		n += wb - n%wb
+	}
+	buf := make([]byte, n)
+	for i := hdrSize + len(b); i < len(buf); i++ {
+		buf[i] = 0xff
+	}
+	put32(buf[0:], magic)
+	put32(buf[4:], gen)
+	put32(buf[8:], uint32(len(b)))
+	copy(buf[hdrSize:], b)
+	put32(buf[12:], crc(buf[:12], b))
+	if _, err := s.dev.WriteAt(buf, base); err != nil {
+		return err
+	}
+	s.gen, s.cur = gen, next
+	return nil
+}
+
+func crc(h, data []byte) uint32 {
+	return crc32.Update(crc32.ChecksumIEEE(h), crc32.IEEETable, data)
+}
+
+func le32(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+func put32(b []byte, v uint32) {
+	b[0], b[1], b[2], b[3] = byte(v), byte(v>>8), byte(v>>16), byte(v>>24)
+}
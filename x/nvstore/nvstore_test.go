@@ -0,0 +1,173 @@
+package nvstore
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// memFlash is an in-memory Flash. Erased bytes read 0xff; writes may only
+// clear bits, as on NOR flash. failAt, when > 0, truncates the write that
+// would cross that byte offset to simulate power loss.
+type memFlash struct {
+	b      []byte
+	eb, wb int64
+	failAt int64
+}
+
+func newMem(size, eb, wb int64) *memFlash {
+	m := &memFlash{b: make([]byte, size), eb: eb, wb: wb}
+	for i := range m.b {
+		m.b[i] = 0xff
+	}
+	return m
+}
+
+var errTorn = errors.New("torn write")
+
+func (m *memFlash) ReadAt(p []byte, off int64) (int, error) { return copy(p, m.b[off:]), nil }
+func (m *memFlash) WriteAt(p []byte, off int64) (int, error) {
+	if len(p)%int(m.wb) != 0 || off%m.wb != 0 {
+		return 0, errors.New("unaligned write")
+	}
+	n := len(p)
+	if m.failAt > 0 && off < m.failAt && off+int64(n) > m.failAt {
+		n = int(m.failAt - off)
+	}
+	for i := 0; i < n; i++ {
+		m.b[off+int64(i)] &= p[i]
+	}
+	if n < len(p) {
+		return n, errTorn
+	}
+	return n, nil
+}
+func (m *memFlash) Size() int64           { return int64(len(m.b)) }
+func (m *memFlash) WriteBlockSize() int64 { return m.wb }
+func (m *memFlash) EraseBlockSize() int64 { return m.eb }
+func (m *memFlash) EraseBlocks(start, n int64) error {
+	for i := start * m.eb; i < (start+n)*m.eb; i++ {
+		m.b[i] = 0xff
+	}
+	return nil
+}
+
+func TestSlots_SaveLoadAlternates(t *testing.T) {
+	dev := newMem(16<<10, 4096, 256)
+	s, err := NewSlots(dev, 8192, 8192)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Load(); ok {
+		t.Fatal("blank region loaded a blob")
+	}
+	for i, want := range []string{"one", "two", "three"} {
+		if err := s.Save([]byte(want)); err != nil {
+			t.Fatalf("save %d: %v", i, err)
+		}
+		// A fresh view of the same flash picks the newest copy.
+		r, err := NewSlots(dev, 8192, 8192)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := r.Load()
+		if !ok || string(got) != want {
+			t.Fatalf("after save %d: got %q,%v want %q", i, got, ok, want)
+		}
+		if r.cur != i%2 {
+			t.Fatalf("save %d landed in slot %d", i, r.cur)
+		}
+	}
+	if !bytes.Equal(dev.b[:8192], bytes.Repeat([]byte{0xff}, 8192)) {
+		t.Fatal("write outside the region")
+	}
+}
+
+func TestSlots_TornWriteKeepsPrevious(t *testing.T) {
+	dev := newMem(8192, 4096, 256)
+	s, _ := NewSlots(dev, 0, 8192)
+	if err := s.Save(bytes.Repeat([]byte{'a'}, 600)); err != nil {
+		t.Fatal(err)
+	}
+	// The next save goes to slot 1 (offset 4096); cut it after one page.
+	dev.failAt = 4096 + 256
+	if err := s.Save(bytes.Repeat([]byte{'b'}, 600)); err == nil {
+		t.Fatal("torn save reported success")
+	}
+	r, _ := NewSlots(dev, 0, 8192)
+	got, ok := r.Load()
+	if !ok || got[0] != 'a' || len(got) != 600 {
+		t.Fatalf("after torn write: got %d bytes %q.., ok=%v; want the previous blob", len(got), got[:1], ok)
+	}
+}
+
+func TestSlots_Limits(t *testing.T) {
+	dev := newMem(8192, 4096, 256)
+	if _, err := NewSlots(dev, 100, 8192); err != ErrRegion {
+		t.Fatalf("unaligned: err = %v", err)
+	}
+	if _, err := NewSlots(dev, 0, 16384); err != ErrRegion {
+		t.Fatalf("oversize: err = %v", err)
+	}
+	s, _ := NewSlots(dev, 0, 8192)
+	if err := s.Save(make([]byte, s.Cap()+1)); err != ErrTooLarge {
+		t.Fatalf("too large: err = %v", err)
+	}
+}
+
+func TestRecords_EncodeDecode(t *testing.T) {
+	m := map[string][]byte{"b": {1, 2, 3}, "a": nil, "long-key": bytes.Repeat([]byte{7}, 300)}
+	b := EncodeRecords(m)
+	if b[0] != 1 || b[1] != 'a' {
+		t.Fatalf("keys not sorted: % x", b[:4])
+	}
+	got, err := DecodeRecords(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || !bytes.Equal(got["b"], m["b"]) || len(got["a"]) != 0 || !bytes.Equal(got["long-key"], m["long-key"]) {
+		t.Fatalf("round trip = %v", got)
+	}
+	if !bytes.Equal(EncodeRecords(got), b) {
+		t.Fatal("re-encoding differs")
+	}
+	for _, bad := range [][]byte{{0}, {3, 'a'}, {1, 'a', 5, 0, 1}} {
+		if _, err := DecodeRecords(bad); err != ErrCorrupt {
+			t.Fatalf("% x: err = %v, want ErrCorrupt", bad, err)
+		}
+	}
+}
+
+func TestRecords_PutPersists(t *testing.T) {
+	dev := newMem(8192, 4096, 256)
+	s, _ := NewSlots(dev, 0, 8192)
+	r := NewRecords(s)
+	if err := r.Put("x", []byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Put("y", []byte{2}); err != nil {
+		t.Fatal(err)
+	}
+	gen := s.gen
+	if err := r.Put("y", []byte{2}); err != nil || s.gen != gen {
+		t.Fatalf("unchanged put rewrote flash (err %v)", err)
+	}
+	if err := r.Put("", nil); err != ErrKey {
+		t.Fatalf("empty key: err = %v", err)
+	}
+
+	s2, _ := NewSlots(dev, 0, 8192)
+	r2 := NewRecords(s2)
+	if v, ok := r2.Get("x"); !ok || !bytes.Equal(v, []byte{1}) {
+		t.Fatalf("x = %v,%v", v, ok)
+	}
+	if v, ok := r2.Get("y"); !ok || !bytes.Equal(v, []byte{2}) {
+		t.Fatalf("y = %v,%v", v, ok)
+	}
+	if err := r2.Put("big", make([]byte, s2.Cap())); err != ErrTooLarge {
+		t.Fatalf("over capacity: err = %v", err)
+	}
+	if _, ok := r2.Get("big"); ok {
+		t.Fatal("rejected record kept in memory")
+	}
+}
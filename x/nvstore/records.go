@@ -0,0 +1,114 @@
+package nvstore
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// MaxKey bounds a record key.
+const MaxKey = 255
+
+var (
+	ErrKey     = errors.New("nvstore: empty or over-long key")
+	ErrCorrupt = errors.New("nvstore: malformed record set")
+)
+
+// Records is a key/value map kept in a Slots region. Keys are kept sorted
+// in the encoding, so the same map always encodes to the same bytes.
+type Records struct {
+	mu   sync.Mutex
+	s    *Slots
+	recs map[string][]byte
+}
+
+// NewRecords loads the map held in s. A slot that does not decode counts
+// as empty.
+func NewRecords(s *Slots) *Records {
+	r := &Records{s: s, recs: map[string][]byte{}}
+	if b, ok := s.Load(); ok {
+		if m, err := DecodeRecords(b); err == nil {
+			r.recs = m
+		}
+	}
+	return r
+}
+
+// Get returns a copy of key's data.
+func (r *Records) Get(key string) ([]byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.recs[key]
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), b...), true
+}
+
+// Put stores data under key and rewrites the region. Storing the bytes
+// already held is a no-op, so callers need not track what they wrote.
+func (r *Records) Put(key string, data []byte) error {
+	if key == "" || len(key) > MaxKey {
+		return ErrKey
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if old, ok := r.recs[key]; ok && string(old) == string(data) {
+		return nil
+	}
+	next := make(map[string][]byte, len(r.recs)+1)
+	for k, v := range r.recs {
+		next[k] = v
+	}
+	next[key] = append([]byte(nil), data...)
+	b := EncodeRecords(next)
+	if len(b) > r.s.Cap() {
+		return ErrTooLarge
+	}
+	if err := r.s.Save(b); err != nil {
+		return err
+	}
+	r.recs = next
+	return nil
+}
+
+// EncodeRecords lays m out as, per key in ascending order:
+// key length (1 byte), key, data length (2 bytes, little-endian), data.
+func EncodeRecords(m map[string][]byte) []byte {
+	keys := make([]string, 0, len(m))
+	n := 0
+	for k, v := range m {
+		keys = append(keys, k)
+		n += 3 + len(k) + len(v)
+	}
+	sort.Strings(keys)
+	b := make([]byte, 0, n)
+	for _, k := range keys {
+		v := m[k]
+		b = append(b, byte(len(k)))
+		b = append(b, k...)
+		b = append(b, byte(len(v)), byte(len(v)>>8))
+		b = append(b, v...)
+	}
+	return b
+}
+
+// DecodeRecords reverses EncodeRecords.
+func DecodeRecords(b []byte) (map[string][]byte, error) {
+	m := map[string][]byte{}
+	for len(b) > 0 {
+		kl := int(b[0])
+		if kl == 0 || len(b) < 1+kl+2 {
+			return nil, ErrCorrupt
+		}
+		k := string(b[1 : 1+kl])
+		b = b[1+kl:]
+		dl := int(b[0]) | int(b[1])<<8
+		if len(b) < 2+dl {
+			return nil, ErrCorrupt
+		}
+		m[k] = append([]byte(nil), b[2:2+dl]...)
+		b = b[2+dl:]
+	}
+	return m, nil
+}
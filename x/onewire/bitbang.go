@@ -0,0 +1,74 @@
+package onewire
+
+// Line is an open-drain wire with an external pull-up (4.7 kΩ typical).
+type Line interface {
+	Low()       // drive the wire low
+	Release()   // stop driving; the pull-up takes the wire high
+	High() bool // sample the wire
+	Wait(us uint32)
+
+	// Lock and Unlock bracket each time slot. A GPIO line masks
+	// interrupts so a slot is not stretched past its window.
+	Lock()
+	Unlock()
+}
+
+// Bitbang generates standard-speed slots on a Line.
+type Bitbang struct{ L Line }
+
+// Standard-speed timings (µs), per Maxim AN126.
+const (
+	tResetLow   = 480
+	tPresence   = 70
+	tResetTail  = 410
+	tWrite1Low  = 6
+	tWrite1Tail = 64
+	tWrite0Low  = 60
+	tWrite0Tail = 10
+	tReadLow    = 6
+	tReadSample = 9
+	tReadTail   = 55
+)
+
+func (b Bitbang) Reset() bool {
+	l := b.L
+	l.Lock()
+	l.Low()
+	l.Wait(tResetLow)
+	l.Release()
+	l.Wait(tPresence)
+	present := !l.High()
+	l.Unlock()
+	l.Wait(tResetTail)
+	return present
+}
+
+func (b Bitbang) WriteBit(bit bool) {
+	l := b.L
+	l.Lock()
+	l.Low()
+	if bit {
+		l.Wait(tWrite1Low)
+		l.Release()
+		l.Unlock()
+		l.Wait(tWrite1Tail)
+		return
+	}
+	l.Wait(tWrite0Low)
+	l.Release()
+	l.Unlock()
+	l.Wait(tWrite0Tail)
+}
+
+func (b Bitbang) ReadBit() bool {
+	l := b.L
+	l.Lock()
+	l.Low()
+	l.Wait(tReadLow)
+	l.Release()
+	l.Wait(tReadSample)
+	v := l.High()
+	l.Unlock()
+	l.Wait(tReadTail)
+	return v
+}
@@ -0,0 +1,181 @@
+// Package onewire implements the Maxim 1-Wire protocol above the bit slot:
+// ROM search, addressed transactions and the Dallas CRC-8.
+//
+// Timing lives below the Slots interface. Bitbang provides it for an
+// open-drain GPIO; tests substitute simulated devices.
+package onewire
+
+import (
+	"errors"
+	"strconv"
+)
+
+// ROM commands.
+const (
+	cmdSearch = 0xF0
+	cmdMatch  = 0x55
+	cmdSkip   = 0xCC
+)
+
+var (
+	ErrNoPresence = errors.New("onewire: no device present")
+	ErrCRC        = errors.New("onewire: crc mismatch")
+	ErrSearch     = errors.New("onewire: search failed")
+)
+
+// ROM is a 64-bit device ID as read from the wire: family code in the low
+// byte, CRC in the high byte.
+type ROM uint64
+
+// Family returns the family code (0x28 for DS18B20).
+func (r ROM) Family() uint8 { return uint8(r) }
+
+// String renders the ROM most-significant byte first, as printed on
+// probe labels and by most tools (e.g. "3c0000123456ab28").
+func (r ROM) String() string {
+	s := strconv.FormatUint(uint64(r), 16)
+	for len(s) < 16 {
+		s = "0" + s
+	}
+	return s
+}
+
+// ParseROM parses the String form.
+func ParseROM(s string) (ROM, error) {
+	if len(s) != 16 {
+		return 0, strconv.ErrSyntax
+	}
+	v, err := strconv.ParseUint(s, 16, 64)
+	return ROM(v), err
+}
+
+// Valid reports whether the ROM's CRC byte matches its first seven bytes.
+func (r ROM) Valid() bool {
+	var b [8]byte
+	for i := range b {
+		b[i] = byte(r >> (8 * i))
+	}
+	return r != 0 && CRC8(b[:7]) == b[7]
+}
+
+// Slots are the three bus primitives. Reset reports whether any device
+// answered with a presence pulse.
+type Slots interface {
+	Reset() bool
+	WriteBit(bit bool)
+	ReadBit() bool
+}
+
+// Bus runs transactions over Slots. It is not safe for concurrent use;
+// a provider serialises callers.
+type Bus struct{ s Slots }
+
+func NewBus(s Slots) *Bus { return &Bus{s: s} }
+
+func (b *Bus) writeByte(v byte) {
+	for i := 0; i < 8; i++ {
+		b.s.WriteBit(v&(1<<i) != 0)
+	}
+}
+
+func (b *Bus) readByte() byte {
+	var v byte
+	for i := 0; i < 8; i++ {
+		if b.s.ReadBit() {
+			v |= 1 << i
+		}
+	}
+	return v
+}
+
+// Tx resets the bus, addresses rom (0 skips ROM matching and addresses
+// every device), writes w and then reads len(r) bytes.
+func (b *Bus) Tx(rom ROM, w, r []byte) error {
+	if !b.s.Reset() {
+		return ErrNoPresence
+	}
+	if rom == 0 {
+		b.writeByte(cmdSkip)
+	} else {
+		b.writeByte(cmdMatch)
+		for i := 0; i < 8; i++ {
+			b.writeByte(byte(rom >> (8 * i)))
+		}
+	}
+	for _, c := range w {
+		b.writeByte(c)
+	}
+	for i := range r {
+		r[i] = b.readByte()
+	}
+	return nil
+}
+
+// Search enumerates every device on the bus, appending to out. IDs with
+// a bad CRC fail the search (usually noise or a marginal pull-up).
+func (b *Bus) Search(out []ROM) ([]ROM, error) {
+	var (
+		last     ROM
+		lastDisc = -1 // bit index of the last discrepancy taken as 0
+		n0       = len(out)
+	)
+	for {
+		if !b.s.Reset() {
+			if len(out) == n0 {
+				return out, nil // empty bus
+			}
+			return out, ErrNoPresence
+		}
+		b.writeByte(cmdSearch)
+		var rom ROM
+		disc := -1
+		for i := 0; i < 64; i++ {
+			id, cmp := b.s.ReadBit(), b.s.ReadBit()
+			var dir bool
+			switch {
+			case id && cmp:
+				return out, ErrSearch // no device answered
+			case id != cmp:
+				dir = id // all remaining devices agree
+			default: // discrepancy
+				switch {
+				case i < lastDisc:
+					dir = last&(1<<i) != 0
+				case i == lastDisc:
+					dir = true
+				}
+				if !dir {
+					disc = i
+				}
+			}
+			if dir {
+				rom |= 1 << i
+			}
+			b.s.WriteBit(dir)
+		}
+		if !rom.Valid() {
+			return out, ErrCRC
+		}
+		out = append(out, rom)
+		if disc < 0 {
+			return out, nil
+		}
+		last, lastDisc = rom, disc
+	}
+}
+
+// CRC8 is the Dallas/Maxim CRC (x^8 + x^5 + x^4 + 1, reflected).
+func CRC8(p []byte) byte {
+	var crc byte
+	for _, c := range p {
+		for i := 0; i < 8; i++ {
+			mix := (crc ^ c) & 1
+			crc >>= 1
+			if mix != 0 {
+				crc ^= 0x8C
+			}
+			c >>= 1
+		}
+	}
+	return crc
+}
@@ -0,0 +1,195 @@
+package onewire
+
+import (
+	"sort"
+	"testing"
+)
+
+// sim models devices on the wire at slot level: ROM commands, search
+// triplets and a read-scratchpad (0xBE) function.
+type sim struct {
+	devs []*simDev
+
+	phase   int // 0 ROM command, 1 search, 2 match, 3 function
+	acc     byte
+	nacc    int
+	bit     int // ROM bit index (search, match)
+	step    int // search: 0 id, 1 complement, 2 direction
+	readBit int // function phase: output bit index
+}
+
+type simDev struct {
+	rom     ROM
+	scratch []byte
+
+	active, selected bool
+	out              []byte
+}
+
+func (s *sim) Reset() bool {
+	s.phase, s.acc, s.nacc, s.bit, s.step, s.readBit = 0, 0, 0, 0, 0, 0
+	for _, d := range s.devs {
+		d.active, d.selected, d.out = true, false, nil
+	}
+	return len(s.devs) > 0
+}
+
+func (s *sim) WriteBit(b bool) {
+	switch s.phase {
+	case 0, 3:
+		if b {
+			s.acc |= 1 << s.nacc
+		}
+		if s.nacc++; s.nacc < 8 {
+			return
+		}
+		c := s.acc
+		s.acc, s.nacc = 0, 0
+		if s.phase == 3 {
+			for _, d := range s.devs {
+				if d.selected && c == 0xBE {
+					d.out = d.scratch
+				}
+			}
+			return
+		}
+		switch c {
+		case cmdSearch:
+			s.phase = 1
+		case cmdMatch:
+			s.phase = 2
+		case cmdSkip:
+			for _, d := range s.devs {
+				d.selected = true
+			}
+			s.phase = 3
+		}
+	case 1, 2:
+		for _, d := range s.devs {
+			if d.active && (d.rom&(1<<s.bit) != 0) != b {
+				d.active = false
+			}
+		}
+		s.step = 0
+		if s.bit++; s.bit == 64 {
+			for _, d := range s.devs {
+				d.selected = d.active
+			}
+			s.phase = 3
+		}
+	}
+}
+
+func (s *sim) ReadBit() bool {
+	v := true // wired-AND with the pull-up
+	switch s.phase {
+	case 1:
+		for _, d := range s.devs {
+			if d.active {
+				b := d.rom&(1<<s.bit) != 0
+				if s.step == 1 {
+					b = !b
+				}
+				v = v && b
+			}
+		}
+		s.step++
+	case 3:
+		for _, d := range s.devs {
+			if d.selected && s.readBit/8 < len(d.out) {
+				v = v && d.out[s.readBit/8]&(1<<(s.readBit%8)) != 0
+			}
+		}
+		s.readBit++
+	}
+	return v
+}
+
+func makeROM(family byte, serial uint64) ROM {
+	var b [8]byte
+	b[0] = family
+	for i := 1; i < 7; i++ {
+		b[i] = byte(serial >> (8 * (i - 1)))
+	}
+	b[7] = CRC8(b[:7])
+	var r ROM
+	for i := range b {
+		r |= ROM(b[i]) << (8 * i)
+	}
+	return r
+}
+
+func TestCRC8(t *testing.T) {
+	// Maxim AN27 worked example.
+	if got := CRC8([]byte{0x02, 0x1C, 0xB8, 0x01, 0x00, 0x00, 0x00}); got != 0xA2 {
+		t.Fatalf("CRC8 = %#x, want 0xa2", got)
+	}
+	if !ROM(0xA2000000_01B81C02).Valid() {
+		t.Fatal("AN27 ROM reported invalid")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	want := []ROM{
+		makeROM(0x28, 0x000012345678),
+		makeROM(0x28, 0x000012345679),
+		makeROM(0x28, 0x0000ABCDEF01),
+		makeROM(0x10, 0x000000000001),
+	}
+	s := &sim{}
+	for _, r := range want {
+		s.devs = append(s.devs, &simDev{rom: r})
+	}
+	got, err := NewBus(s).Search(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	sort.Slice(want, func(i, j int) bool { return want[i] < want[j] })
+	if len(got) != len(want) {
+		t.Fatalf("found %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("found %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSearchEmpty(t *testing.T) {
+	got, err := NewBus(&sim{}).Search(nil)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("Search = %v, %v; want empty, nil", got, err)
+	}
+}
+
+func TestTxMatch(t *testing.T) {
+	a := &simDev{rom: makeROM(0x28, 1), scratch: []byte{0x91, 0x01, 0, 0, 0x7F, 0xFF, 0x0F, 0x10}}
+	b := &simDev{rom: makeROM(0x28, 2), scratch: []byte{0x50, 0x05, 0, 0, 0x7F, 0xFF, 0x0C, 0x10}}
+	bus := NewBus(&sim{devs: []*simDev{a, b}})
+	var r [8]byte
+	if err := bus.Tx(b.rom, []byte{0xBE}, r[:]); err != nil {
+		t.Fatal(err)
+	}
+	if string(r[:]) != string(b.scratch) {
+		t.Fatalf("read % x, want % x", r, b.scratch)
+	}
+	if err := NewBus(&sim{}).Tx(0, []byte{0x44}, nil); err != ErrNoPresence {
+		t.Fatalf("empty bus err = %v, want ErrNoPresence", err)
+	}
+}
+
+func TestROMString(t *testing.T) {
+	r := makeROM(0x28, 0x0000ABCDEF01)
+	s := r.String()
+	if len(s) != 16 || s[14:] != "28" {
+		t.Fatalf("String = %q", s)
+	}
+	back, err := ParseROM(s)
+	if err != nil || back != r {
+		t.Fatalf("ParseROM(%q) = %v, %v", s, back, err)
+	}
+	if _, err := ParseROM("28"); err == nil {
+		t.Fatal("short ROM accepted")
+	}
+}
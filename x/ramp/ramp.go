@@ -0,0 +1,79 @@
+package ramp
+
+import "time"
+
+// Ramp is a tick-driven scalar trajectory from one value to another over a
+// fixed number of evenly spaced steps. The caller owns timing: wait
+// Interval, take Next, repeat until Next reports no more. The zero Ramp is
+// finished.
+type Ramp struct {
+	from, to int32
+	shape    Shape
+	steps    uint16
+	i        uint16
+	every    time.Duration
+}
+
+// New returns a ramp from 'from' to 'to'. steps==0 or durationMs==0 gives
+// a single immediate step to 'to'. A nil shape is Linear.
+func New(from, to int32, durationMs uint32, steps uint16, shape Shape) Ramp {
+	if shape == nil {
+		shape = Linear
+	}
+	r := Ramp{from: from, to: to, shape: shape, steps: steps}
+	if steps == 0 || durationMs == 0 {
+		r.steps = 1
+		return r
+	}
+	stepMs := durationMs / uint32(steps)
+	if stepMs == 0 {
+		stepMs = 1
+	}
+	r.every = time.Duration(stepMs) * time.Millisecond
+	return r
+}
+
+// Interval is the wait before each step; 0 for an immediate ramp.
+func (r *Ramp) Interval() time.Duration { return r.every }
+
+// Done reports whether the last step has been taken.
+func (r *Ramp) Done() bool { return r.i >= r.steps }
+
+// Next advances one step and returns its value and whether more follow.
+// The last step returns exactly 'to'; a finished ramp keeps returning it.
+func (r *Ramp) Next() (int32, bool) {
+	if r.i < r.steps {
+		r.i++
+	}
+	return r.At(r.i), r.i < r.steps
+}
+
+// At returns the value at step i without advancing.
+func (r *Ramp) At(i uint16) int32 {
+	if i == 0 {
+		return r.from
+	}
+	if i >= r.steps {
+		return r.to
+	}
+	p := uint16(roundDiv(int64(i)*Q, int64(r.steps)))
+	d := int64(r.to) - int64(r.from)
+	return r.from + int32(roundDiv(d*int64(r.shape(p)), Q))
+}
+
+// Run drives r to completion synchronously: tick before each step, set on
+// each change of value and always on the last step. It returns early if
+// tick reports cancellation.
+func Run(r *Ramp, tick Tick, set func(v int32)) {
+	last := r.At(r.i)
+	for !r.Done() {
+		if r.every > 0 && !tick(r.every) {
+			return
+		}
+		v, more := r.Next()
+		if v != last || !more {
+			set(v)
+			last = v
+		}
+	}
+}
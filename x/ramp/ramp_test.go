@@ -0,0 +1,185 @@
+package ramp
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func collect(r Ramp) []int32 {
+	var out []int32
+	Run(&r, func(time.Duration) bool { return true }, func(v int32) { out = append(out, v) })
+	return out
+}
+
+func TestShapesHitEndpoints(t *testing.T) {
+	shapes := map[string]Shape{
+		"linear": Linear, "ease": Ease, "sine": Sine,
+		"piecewise": Piecewise(Point{0, 0}, Point{Q / 4, Q / 2}, Point{Q, Q}),
+	}
+	for name, s := range shapes {
+		if got := s(0); got != 0 {
+			t.Errorf("%s(0) = %d, want 0", name, got)
+		}
+		if got := s(Q); got != Q {
+			t.Errorf("%s(Q) = %d, want %d", name, got, Q)
+		}
+		prev := uint16(0)
+		for p := 0; p <= Q; p += 97 {
+			v := s(uint16(p))
+			if v < prev {
+				t.Fatalf("%s not monotonic at %d: %d < %d", name, p, v, prev)
+			}
+			prev = v
+		}
+	}
+}
+
+func TestSineMatchesFloat(t *testing.T) {
+	for p := 0; p <= Q; p += 257 {
+		want := (1 - math.Cos(math.Pi*float64(p)/Q)) / 2 * Q
+		if d := math.Abs(float64(Sine(uint16(p))) - want); d > 16 {
+			t.Fatalf("Sine(%d) = %d, want ~%.1f", p, Sine(uint16(p)), want)
+		}
+	}
+}
+
+func TestEaseSymmetric(t *testing.T) {
+	for p := 0; p <= Q; p += 331 {
+		a, b := int(Ease(uint16(p))), int(Ease(uint16(Q-p)))
+		if d := a + b - Q; d < -1 || d > 1 {
+			t.Fatalf("Ease(%d)+Ease(%d) = %d, want %d±1", p, Q-p, a+b, Q)
+		}
+	}
+}
+
+func TestRampExtremes(t *testing.T) {
+	cases := []struct {
+		from, to int32
+		steps    uint16
+	}{
+		{0, 65535, 10},
+		{65535, 0, 10},
+		{0, 1, 100},
+		{1, 0, 100},
+		{math.MinInt32, math.MaxInt32, 7},
+		{math.MaxInt32, math.MinInt32, 7},
+		{-5000, 3000, 1000},
+	}
+	for _, c := range cases {
+		for _, s := range []Shape{Linear, Ease, Sine} {
+			out := collect(New(c.from, c.to, 1000, c.steps, s))
+			if len(out) == 0 || out[len(out)-1] != c.to {
+				t.Fatalf("%d→%d: last = %v, want %d", c.from, c.to, out, c.to)
+			}
+			lo, hi := c.from, c.to
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			prev := c.from
+			for _, v := range out {
+				if v < lo || v > hi {
+					t.Fatalf("%d→%d: %d overshoots", c.from, c.to, v)
+				}
+				if (c.to > c.from && v < prev) || (c.to < c.from && v > prev) {
+					t.Fatalf("%d→%d: %d reverses after %d", c.from, c.to, v, prev)
+				}
+				prev = v
+			}
+		}
+	}
+}
+
+func TestRampLinearRounding(t *testing.T) {
+	// 0→1 over 4 steps: 0.25, 0.5, 0.75, 1 round to 0, 1, 1, 1, so the
+	// change lands at the half-way step; the last step is always set.
+	r := New(0, 1, 400, 4, Linear)
+	var got []int32
+	for !r.Done() {
+		v, _ := r.Next()
+		got = append(got, v)
+	}
+	want := []int32{0, 1, 1, 1}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("steps = %v, want %v", got, want)
+		}
+	}
+	if out := collect(New(0, 1, 400, 4, Linear)); len(out) != 2 || out[0] != 1 || out[1] != 1 {
+		t.Fatalf("Run set %v, want [1 1]", out)
+	}
+	// Negative moves round half away from zero as well.
+	r = New(0, -1, 400, 4, Linear)
+	r.Next()
+	if v, _ := r.Next(); v != -1 {
+		t.Fatalf("half-way of 0→-1 = %d, want -1", v)
+	}
+}
+
+func TestRampDegenerate(t *testing.T) {
+	for _, r := range []Ramp{New(3, 9, 0, 10, nil), New(3, 9, 100, 0, nil)} {
+		if r.Interval() != 0 {
+			t.Fatalf("interval = %v, want 0", r.Interval())
+		}
+		if out := collect(r); len(out) != 1 || out[0] != 9 {
+			t.Fatalf("got %v, want [9]", out)
+		}
+	}
+	var z Ramp
+	if !z.Done() {
+		t.Fatal("zero Ramp not done")
+	}
+	if out := collect(New(5, 5, 100, 10, Linear)); len(out) != 1 || out[0] != 5 {
+		t.Fatalf("flat ramp set %v, want [5]", out)
+	}
+}
+
+func TestRunTiming(t *testing.T) {
+	var ticks int
+	var total time.Duration
+	r := New(0, 100, 1000, 10, Linear)
+	Run(&r, func(d time.Duration) bool { ticks++; total += d; return true }, func(int32) {})
+	if ticks != 10 || total != time.Second {
+		t.Fatalf("ticks=%d total=%v, want 10 and 1s", ticks, total)
+	}
+	// Sub-millisecond steps are stretched to 1 ms.
+	if r := New(0, 100, 5, 10, Linear); r.Interval() != time.Millisecond {
+		t.Fatalf("interval = %v, want 1ms", r.Interval())
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	n := 0
+	r := New(0, 100, 1000, 10, Linear)
+	Run(&r, func(time.Duration) bool { n++; return n <= 3 }, func(int32) {})
+	if r.Done() {
+		t.Fatal("cancelled ramp reports done")
+	}
+	if v := r.At(3); v != 30 {
+		t.Fatalf("At(3) = %d, want 30", v)
+	}
+}
+
+func TestStartClampsToTop(t *testing.T) {
+	var last uint16
+	var max uint16
+	Start(0, 5000, 1000, 100, 10, Ease, func(time.Duration) bool { return true }, func(l uint16) {
+		last = l
+		if l > max {
+			max = l
+		}
+	})
+	if last != 1000 || max != 1000 {
+		t.Fatalf("last=%d max=%d, want 1000", last, max)
+	}
+}
+
+func TestPiecewiseHolds(t *testing.T) {
+	s := Piecewise(Point{Q / 2, 100}, Point{Q / 2, 200}, Point{Q - 1, 300})
+	if s(0) != 100 || s(Q/2) != 100 || s(Q) != 300 {
+		t.Fatalf("got %d %d %d", s(0), s(Q/2), s(Q))
+	}
+	if v := s(Q/2 + 1); v < 200 || v > 201 {
+		t.Fatalf("after jump = %d, want ~200", v)
+	}
+}
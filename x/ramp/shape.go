@@ -0,0 +1,88 @@
+package ramp
+
+// Q is full scale for progress and shape output (Q16).
+const Q = 65535
+
+// Shape maps progress p in [0..Q] to a fraction of the move in [0..Q].
+// Shapes should return 0 at p=0 and Q at p=Q; the final step of a ramp
+// always lands on the target regardless.
+type Shape func(p uint16) uint16
+
+// Linear moves at constant rate.
+func Linear(p uint16) uint16 { return p }
+
+// Ease is smoothstep (3p²-2p³): zero slope at both ends.
+func Ease(p uint16) uint16 {
+	x := uint64(p)
+	return uint16(x * x * (3*Q - 2*x) / (Q * Q))
+}
+
+// Sine is a raised cosine, (1-cos πp)/2: gentler at the ends than Ease.
+func Sine(p uint16) uint16 {
+	s := uint64(quarterSin(p))
+	return uint16((s*s + Q/2) / Q)
+}
+
+// sinQ holds sin(iπ/128) in Q16 for i in [0..64], a quarter wave.
+var sinQ = [65]uint16{
+	0, 1608, 3216, 4821, 6424, 8022, 9616, 11204, 12785, 14359, 15924, 17479,
+	19024, 20557, 22078, 23586, 25079, 26557, 28020, 29465, 30893, 32302,
+	33692, 35061, 36409, 37736, 39039, 40319, 41575, 42806, 44011, 45189,
+	46340, 47464, 48558, 49624, 50659, 51664, 52638, 53580, 54490, 55367,
+	56211, 57021, 57797, 58537, 59243, 59913, 60546, 61144, 61704, 62227,
+	62713, 63161, 63571, 63943, 64276, 64570, 64826, 65042, 65219, 65357,
+	65456, 65515, 65535,
+}
+
+// quarterSin returns sin(πp/2) for p in [0..Q], interpolating sinQ.
+func quarterSin(p uint16) uint16 {
+	pos := uint32(p) * 64 // table index in Q16
+	i := pos / Q
+	if i >= 64 {
+		return sinQ[64]
+	}
+	f := pos - i*Q
+	a, b := uint32(sinQ[i]), uint32(sinQ[i+1])
+	return uint16(a + ((b-a)*f+Q/2)/Q)
+}
+
+// Point is a Piecewise breakpoint: at progress At the shape is Level.
+type Point struct {
+	At    uint16
+	Level uint16
+}
+
+// Piecewise interpolates linearly between points, which must be sorted by
+// At. It holds the first point's level before it and the last one's after.
+// With no points it is Linear.
+func Piecewise(pts ...Point) Shape {
+	if len(pts) == 0 {
+		return Linear
+	}
+	return func(p uint16) uint16 {
+		if p <= pts[0].At {
+			return pts[0].Level
+		}
+		for i := 1; i < len(pts); i++ {
+			a, b := pts[i-1], pts[i]
+			if p > b.At {
+				continue
+			}
+			span := int64(b.At) - int64(a.At)
+			if span == 0 {
+				return b.Level
+			}
+			d := int64(b.Level) - int64(a.Level)
+			return uint16(int64(a.Level) + roundDiv(d*(int64(p)-int64(a.At)), span))
+		}
+		return pts[len(pts)-1].Level
+	}
+}
+
+// roundDiv divides rounding half away from zero; b > 0.
+func roundDiv(a, b int64) int64 {
+	if a < 0 {
+		return -((-a + b/2) / b)
+	}
+	return (a + b/2) / b
+}
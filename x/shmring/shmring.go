@@ -13,7 +13,9 @@
 // APIs
 //   - Spans:    WriteAcquire/WriteCommit, ReadAcquire/ReadRelease
 //   - Helpers:  TryWriteFrom, TryReadInto (copy-based)
-//   - Introspection: Available(), Space(), Cap(), Readable(), Writable()
+//   - Introspection: Available(), Space(), Cap(), Readable(), Writable(),
+//     Produced(), Consumed()
+//   - Snapshot: Tail (producer side)
 package shmring
 
 import (
@@ -26,6 +28,8 @@ type Ring struct {
 	rd   atomic.Uint32 // consumer index (monotonic modulo size)
 	wr   atomic.Uint32 // producer index (monotonic modulo size)
 
+	filled bool // producer-owned: size bytes have been committed since New
+
 	readable chan struct{} // empty -> non-empty edge
 	writable chan struct{} // full  -> non-full  edge
 }
@@ -55,6 +59,14 @@ func (r *Ring) Available() int {
 	return int(wr - rd)
 }
 
+// Produced returns the producer's running byte count (wraps at 2^32).
+// It changes only when the producer commits, so it doubles as an activity
+// marker for the producing side.
+func (r *Ring) Produced() uint32 { return r.wr.Load() }
+
+// Consumed returns the consumer's running byte count (wraps at 2^32).
+func (r *Ring) Consumed() uint32 { return r.rd.Load() }
+
 // Space returns bytes free for the producer.
 func (r *Ring) Space() int {
 	rd := r.rd.Load()
@@ -107,6 +119,9 @@ func (r *Ring) WriteCommit(n int) {
 	beforeAvail := wr - rd
 
 	r.wr.Store(wr + uint32(n)) // release to consumer
+	if !r.filled && wr+uint32(n) >= r.size() {
+		r.filled = true
+	}
 
 	// Notify consumer on empty->non-empty transition.
 	if beforeAvail == 0 {
@@ -200,3 +215,31 @@ func (r *Ring) TryReadInto(dst []byte) int {
 	r.ReadRelease(n)
 	return n
 }
+
+// ---- Snapshot ----
+
+// Tail appends to dst the newest n bytes committed to the ring (at most
+// Cap, and fewer if fewer have been written), oldest first, whether or not
+// the consumer has read them. Bytes the consumer has released stay in the
+// buffer until the producer overwrites them, so a ring nobody reads still
+// holds its recent history. Call it from the producer's goroutine: a
+// concurrent write would overwrite the oldest of the bytes being copied.
+func (r *Ring) Tail(dst []byte, n int) []byte {
+	wr := r.wr.Load()
+	have := r.size()
+	if !r.filled {
+		have = wr
+	}
+	if n < 0 {
+		n = 0
+	}
+	if uint32(n) > have {
+		n = int(have)
+	}
+	start := (wr - uint32(n)) & r.mask
+	if end := int(start) + n; end <= len(r.buf) {
+		return append(dst, r.buf[start:end]...)
+	}
+	dst = append(dst, r.buf[start:]...)
+	return append(dst, r.buf[:n-(len(r.buf)-int(start))]...)
+}
@@ -106,3 +106,27 @@ func TestReadableWritableEdges(t *testing.T) {
 		// Not necessarily full before, so Writable may not fire; expand if needed to force full.
 	}
 }
+
+func TestTailKeepsReleasedHistory(t *testing.T) {
+	r := New(8)
+	if got := r.Tail(nil, 4); len(got) != 0 {
+		t.Fatalf("empty ring: %q", got)
+	}
+	r.TryWriteFrom([]byte("abc"))
+	if got := string(r.Tail([]byte(">"), 8)); got != ">abc" {
+		t.Fatalf("short history: %q", got)
+	}
+	// Nobody reads: release everything after each write, as a history
+	// ring does, and the newest bytes survive across the wrap.
+	for _, s := range []string{"defgh", "ijklm"} {
+		r.ReadRelease(r.Available())
+		r.TryWriteFrom([]byte(s))
+	}
+	r.ReadRelease(r.Available())
+	if got := string(r.Tail(nil, 100)); got != "fghijklm" {
+		t.Fatalf("full history: %q", got)
+	}
+	if got := string(r.Tail(nil, 3)); got != "klm" {
+		t.Fatalf("newest 3: %q", got)
+	}
+}
@@ -0,0 +1,95 @@
+// Package smbus holds the SMBus framing that sits on top of plain I²C
+// transfers: packet error checking (PEC) and block transfers. It has no
+// bus access of its own; callers build and check byte slices around a
+// drivers.I2C-style Tx(addr, w, r).
+package smbus
+
+import "errors"
+
+// MaxBlock is the largest SMBus 2.0 block (3.0 allows 255).
+const MaxBlock = 32
+
+var (
+	ErrPEC   = errors.New("smbus: pec mismatch")
+	ErrBlock = errors.New("smbus: block count out of range")
+)
+
+// CRC8 continues crc over p with the SMBus PEC polynomial (x^8 + x^2 + x +
+// 1, not reflected). Start a transaction with 0.
+func CRC8(crc byte, p ...byte) byte {
+	for _, b := range p {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// PEC returns the packet error code for a Tx(addr, w, r) transaction:
+// every byte on the wire, address bytes included, excluding the PEC
+// itself. With both w and r set it covers the repeated start.
+func PEC(addr uint16, w, r []byte) byte {
+	a := byte(addr << 1)
+	var crc byte
+	if len(w) > 0 {
+		crc = CRC8(crc, a)
+		crc = CRC8(crc, w...)
+	}
+	if len(r) > 0 {
+		crc = CRC8(crc, a|1)
+		crc = CRC8(crc, r...)
+	}
+	return crc
+}
+
+// BlockWrite returns the write buffer for a block write of data to cmd:
+// cmd, count, data and, with pec, the PEC byte.
+func BlockWrite(addr uint16, cmd byte, data []byte, pec bool) ([]byte, error) {
+	if len(data) == 0 || len(data) > MaxBlock {
+		return nil, ErrBlock
+	}
+	w := make([]byte, 0, 3+len(data))
+	w = append(w, cmd, byte(len(data)))
+	w = append(w, data...)
+	if pec {
+		w = append(w, PEC(addr, w, nil))
+	}
+	return w, nil
+}
+
+// BlockReadLen is the read length for a block read of up to max bytes:
+// the count byte, max data bytes and, with pec, the PEC byte. A plain Tx
+// cannot stop on the count, so a shorter block is over-read; devices
+// return padding, which ParseBlockRead ignores.
+func BlockReadLen(max int, pec bool) int {
+	n := 1 + max
+	if pec {
+		n++
+	}
+	return n
+}
+
+// ParseBlockRead checks r, read after writing cmd, and returns the block.
+// The PEC, when asked for, follows the counted bytes.
+func ParseBlockRead(addr uint16, cmd byte, r []byte, pec bool) ([]byte, error) {
+	if len(r) == 0 {
+		return nil, ErrBlock
+	}
+	n := int(r[0])
+	end := 1 + n
+	if pec {
+		end++
+	}
+	if n == 0 || end > len(r) {
+		return nil, ErrBlock
+	}
+	if pec && PEC(addr, []byte{cmd}, r[:1+n]) != r[1+n] {
+		return nil, ErrPEC
+	}
+	return r[1 : 1+n], nil
+}
@@ -0,0 +1,75 @@
+package smbus
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCRC8Check(t *testing.T) {
+	// CRC-8/SMBUS check value.
+	if got := CRC8(0, []byte("123456789")...); got != 0xF4 {
+		t.Fatalf("CRC8 = %#x, want 0xf4", got)
+	}
+	if CRC8(CRC8(0, 1, 2), 3) != CRC8(0, 1, 2, 3) {
+		t.Fatal("CRC8 does not chain")
+	}
+}
+
+func TestPECReadWord(t *testing.T) {
+	// Read word from 0x68, command 0x3A: the PEC covers 0xD0 0x3A 0xD1 lo hi.
+	want := CRC8(0, 0xD0, 0x3A, 0xD1, 0x34, 0x12)
+	if got := PEC(0x68, []byte{0x3A}, []byte{0x34, 0x12}); got != want {
+		t.Fatalf("PEC = %#x, want %#x", got, want)
+	}
+	// A write covers only the write address.
+	if got := PEC(0x68, []byte{0x3A, 1}, nil); got != CRC8(0, 0xD0, 0x3A, 1) {
+		t.Fatalf("write PEC = %#x", got)
+	}
+}
+
+func TestBlockRoundTrip(t *testing.T) {
+	data := []byte{1, 2, 3}
+	w, err := BlockWrite(0x50, 0x10, data, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w) != 6 || w[1] != 3 || w[5] != PEC(0x50, w[:5], nil) {
+		t.Fatalf("block write = % x", w)
+	}
+
+	// Device answers count, data, PEC, then padding up to the read length.
+	r := make([]byte, BlockReadLen(8, true))
+	r[0] = 3
+	copy(r[1:], data)
+	r[4] = PEC(0x50, []byte{0x10}, r[:4])
+	for i := 5; i < len(r); i++ {
+		r[i] = 0xFF
+	}
+	got, err := ParseBlockRead(0x50, 0x10, r, true)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("block read = % x, %v", got, err)
+	}
+
+	r[2] ^= 1
+	if _, err := ParseBlockRead(0x50, 0x10, r, true); err != ErrPEC {
+		t.Fatalf("corrupt block: err = %v, want ErrPEC", err)
+	}
+}
+
+func TestBlockBounds(t *testing.T) {
+	if _, err := BlockWrite(0x50, 0, nil, false); err != ErrBlock {
+		t.Fatalf("empty write: %v", err)
+	}
+	if _, err := BlockWrite(0x50, 0, make([]byte, MaxBlock+1), false); err != ErrBlock {
+		t.Fatalf("oversize write: %v", err)
+	}
+	r := make([]byte, BlockReadLen(4, false))
+	r[0] = 5
+	if _, err := ParseBlockRead(0x50, 0, r, false); err != ErrBlock {
+		t.Fatalf("count past buffer: %v", err)
+	}
+	r[0] = 0
+	if _, err := ParseBlockRead(0x50, 0, r, false); err != ErrBlock {
+		t.Fatalf("zero count: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package strconvx
+
+// Allocation-free appenders working on caller-provided buffers. These are
+// shared by host and MCU builds: they never go through strconv, so output
+// is identical on both.
+
+const hexDigits = "0123456789abcdef"
+
+// AppendUint64 appends the decimal form of u.
+func AppendUint64(dst []byte, u uint64) []byte {
+	var tmp [20]byte
+	i := len(tmp)
+	for {
+		i--
+		tmp[i] = byte('0' + u%10)
+		u /= 10
+		if u == 0 {
+			break
+		}
+	}
+	return append(dst, tmp[i:]...)
+}
+
+// AppendInt64 appends the decimal form of i.
+func AppendInt64(dst []byte, i int64) []byte {
+	if i < 0 {
+		dst = append(dst, '-')
+		return AppendUint64(dst, uint64(-i)) // -MinInt64 wraps to its magnitude
+	}
+	return AppendUint64(dst, uint64(i))
+}
+
+// AppendFixed appends v scaled down by 10^decimals with exactly that many
+// fraction digits, e.g. AppendFixed(dst, -1234, 2) → "-12.34".
+func AppendFixed(dst []byte, v int64, decimals int) []byte {
+	if decimals <= 0 {
+		return AppendInt64(dst, v)
+	}
+	u := uint64(v)
+	if v < 0 {
+		dst = append(dst, '-')
+		u = uint64(-v)
+	}
+	p := uint64(1)
+	for i := 0; i < decimals; i++ {
+		p *= 10
+	}
+	dst = AppendUint64(dst, u/p)
+	dst = append(dst, '.')
+	frac := u % p
+	for p /= 10; p > 0; p /= 10 {
+		dst = append(dst, byte('0'+frac/p%10))
+	}
+	return dst
+}
+
+// AppendDeci appends tenths as a decimal, e.g. 235 → "23.5".
+func AppendDeci(dst []byte, deci int64) []byte { return AppendFixed(dst, deci, 1) }
+
+// AppendHundredths appends hundredths as a decimal, e.g. 4507 → "45.07".
+func AppendHundredths(dst []byte, hx100 int64) []byte { return AppendFixed(dst, hx100, 2) }
+
+// AppendMilli appends thousandths as a decimal, e.g. 12034 (mV) → "12.034".
+func AppendMilli(dst []byte, milli int64) []byte { return AppendFixed(dst, milli, 3) }
+
+// AppendHex16 appends v as four lower-case hex digits (no prefix).
+func AppendHex16(dst []byte, v uint16) []byte {
+	return append(dst, hexDigits[v>>12&0xF], hexDigits[v>>8&0xF], hexDigits[v>>4&0xF], hexDigits[v&0xF])
+}
+
+// AppendHex32 appends v as eight lower-case hex digits (no prefix).
+func AppendHex32(dst []byte, v uint32) []byte {
+	dst = AppendHex16(dst, uint16(v>>16))
+	return AppendHex16(dst, uint16(v))
+}
@@ -0,0 +1,64 @@
+package strconvx
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAppendIntUint(t *testing.T) {
+	cases := []struct {
+		got, want string
+	}{
+		{string(AppendUint64(nil, 0)), "0"},
+		{string(AppendUint64(nil, math.MaxUint64)), "18446744073709551615"},
+		{string(AppendInt64(nil, -42)), "-42"},
+		{string(AppendInt64(nil, math.MinInt64)), "-9223372036854775808"},
+		{string(AppendInt64([]byte("n="), 7)), "n=7"},
+	}
+	for _, c := range cases {
+		if c.got != c.want {
+			t.Fatalf("got %q, want %q", c.got, c.want)
+		}
+	}
+}
+
+func TestAppendFixed(t *testing.T) {
+	cases := []struct {
+		got, want string
+	}{
+		{string(AppendDeci(nil, 235)), "23.5"},
+		{string(AppendDeci(nil, -5)), "-0.5"},
+		{string(AppendDeci(nil, 0)), "0.0"},
+		{string(AppendHundredths(nil, 4507)), "45.07"},
+		{string(AppendHundredths(nil, -1)), "-0.01"},
+		{string(AppendMilli(nil, 12034)), "12.034"},
+		{string(AppendMilli(nil, 7)), "0.007"},
+		{string(AppendFixed(nil, 12, 0)), "12"},
+	}
+	for _, c := range cases {
+		if c.got != c.want {
+			t.Fatalf("got %q, want %q", c.got, c.want)
+		}
+	}
+}
+
+func TestAppendHex(t *testing.T) {
+	if got := string(AppendHex16(nil, 0x0a1f)); got != "0a1f" {
+		t.Fatalf("AppendHex16 = %q", got)
+	}
+	if got := string(AppendHex32([]byte("0x"), 0xdeadbeef)); got != "0xdeadbeef" {
+		t.Fatalf("AppendHex32 = %q", got)
+	}
+}
+
+func TestAppendNoAlloc(t *testing.T) {
+	buf := make([]byte, 0, 64)
+	n := testing.AllocsPerRun(100, func() {
+		b := AppendDeci(buf[:0], -1234)
+		b = AppendHex32(b, 0x1234)
+		_ = AppendUint64(b, math.MaxUint64)
+	})
+	if n != 0 {
+		t.Fatalf("allocs = %v, want 0", n)
+	}
+}
@@ -8,6 +8,8 @@ import "strconv"
 // Delegate straight through.
 
 func Itoa(i int) string                                   { return strconv.Itoa(i) }
+func Itoa64(i int64) string                               { return strconv.FormatInt(i, 10) }
+func Utoa64(u uint64) string                              { return strconv.FormatUint(u, 10) }
 func Atoi(s string) (int, error)                          { return strconv.Atoi(s) }
 func FormatInt(i int64, base int) string                  { return strconv.FormatInt(i, base) }
 func FormatUint(u uint64, base int) string                { return strconv.FormatUint(u, base) }